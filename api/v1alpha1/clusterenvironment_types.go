@@ -17,7 +17,13 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // ClusterEnvironmentSpec defines the desired state of ClusterEnvironment
@@ -31,7 +37,9 @@ type ClusterEnvironmentSpec struct {
 	// Description of the ClusterEnvironment
 	Description string `json:"description,omitempty"`
 
-	// Labels
+	// Labels tag the ClusterEnvironment, either as `key` or `key=value`
+	// (e.g. `gpu`, `tier=gold`).  RegisteredServices can select the
+	// environments they may be used in by these labels and the Topology ones.
 	Labels []string `json:"labels,omitempty"`
 
 	// Namespaces in target cluster where applications are deployed
@@ -40,10 +48,105 @@ type ClusterEnvironmentSpec struct {
 	// Namespaces in target cluster where services are discovered
 	ServiceNamespaces []string `json:"serviceNamespaces,omitempty"`
 
+	// FailoverServers are the URLs of other API servers of the cluster, e.g.
+	// behind different load balancers.  They are tried in order when the
+	// server of the kubeconfig can not be reached, with the same
+	// credentials.  They can also be listed in the `failover-servers` key of
+	// the ClusterContextSecret.
+	// +optional
+	// +kubebuilder:validation:items:Pattern=`^https?://`
+	FailoverServers []string `json:"failoverServers,omitempty"`
+
 	// Cluster Admin's contact information
 	ContactInfo string `json:"contactInfo,omitempty"`
+
+	// HealthCheckPolicy defines how RegisteredServices that lack a health
+	// check and that can be used in the environment are handled
+	// +optional
+	HealthCheckPolicy HealthCheckPolicy `json:"healthCheckPolicy,omitempty"`
+
+	// Topology labels describe where the cluster runs (e.g.
+	// `topology.kubernetes.io/region: eu-west-1`).  ServiceClaims can prefer
+	// the RegisteredServices discovered in clusters with a given topology.
+	// +optional
+	Topology map[string]string `json:"topology,omitempty"`
+
+	// TLS defines how the certificate of the cluster's API server is
+	// verified
+	// +optional
+	TLS *ClusterEnvironmentTLS `json:"tls,omitempty"`
+
+	// AgentAuthentication defines how the agents deployed in the cluster
+	// authenticate to Primaza.  With `Kubeconfig`, the default, they use the
+	// kubeconfig provided in their namespace.  With `TokenRequest`, Primaza
+	// issues them short-lived ServiceAccount tokens and rotates them.
+	// +optional
+	AgentAuthentication AgentAuthentication `json:"agentAuthentication,omitempty"`
+
+	// SynchronizeNamespaces makes Primaza create the application and
+	// service namespaces in the cluster, label them, and install the
+	// ServiceAccount and RBAC resources their agent requires.  The
+	// kubeconfig's user needs to be granted the permission to do so.
+	// +optional
+	SynchronizeNamespaces bool `json:"synchronizeNamespaces,omitempty"`
+
+	// AgentVersion pins the version of the agents deployed in the cluster,
+	// i.e. the tag of their images.  It defaults to the version of the
+	// control plane.  Changing it upgrades, or downgrades, the agents.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[\w][\w.-]{0,127}$`
+	AgentVersion string `json:"agentVersion,omitempty"`
+
+	// ServiceCatalogFilters restricts the services of the catalog pushed to
+	// the application namespaces to the ones matching at least one of the
+	// filters.  The whole catalog of the environment is pushed if there is
+	// none.
+	// +optional
+	ServiceCatalogFilters []ServiceCatalogFilter `json:"serviceCatalogFilters,omitempty"`
 }
 
+// AgentAuthentication defines how agents authenticate to Primaza
+// +kubebuilder:validation:Enum=Kubeconfig;TokenRequest
+type AgentAuthentication string
+
+const (
+	// AgentAuthenticationKubeconfig makes agents use the kubeconfig provided
+	// in their namespace
+	AgentAuthenticationKubeconfig AgentAuthentication = "Kubeconfig"
+	// AgentAuthenticationTokenRequest makes agents use short-lived tokens
+	// issued by Primaza through the TokenRequest API
+	AgentAuthenticationTokenRequest AgentAuthentication = "TokenRequest"
+)
+
+// ClusterEnvironmentTLS defines how the certificate of a cluster's API
+// server is verified
+type ClusterEnvironmentTLS struct {
+	// RequireVerification rejects kubeconfigs that disable the verification
+	// of the API server's certificate (`insecure-skip-tls-verify`)
+	// +optional
+	RequireVerification bool `json:"requireVerification,omitempty"`
+
+	// PinnedPublicKeys are the base64 encoded SHA-256 digests of the Subject
+	// Public Key Info of trusted certificates.  When set, Primaza only
+	// connects to the cluster if a certificate presented by the API server
+	// matches one of them.
+	// +optional
+	PinnedPublicKeys []string `json:"pinnedPublicKeys,omitempty"`
+}
+
+// HealthCheckPolicy defines how RegisteredServices lacking a health check
+// are handled
+// +kubebuilder:validation:Enum=Require;Warn
+type HealthCheckPolicy string
+
+const (
+	// HealthCheckPolicyRequire rejects RegisteredServices lacking a health check
+	HealthCheckPolicyRequire HealthCheckPolicy = "Require"
+	// HealthCheckPolicyWarn admits RegisteredServices lacking a health
+	// check with a warning
+	HealthCheckPolicyWarn HealthCheckPolicy = "Warn"
+)
+
 // ClusterEnvironmentStatus defines the observed state of ClusterEnvironment
 type ClusterEnvironmentStatus struct {
 	// The State of the cluster environment
@@ -51,8 +154,286 @@ type ClusterEnvironmentStatus struct {
 	//+kubebuilder:default:=Offline
 	State ClusterEnvironmentState `json:"state"`
 
-	// Status Conditions
+	// Status Conditions, one per type listed in
+	// ClusterEnvironmentConditionTypes
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=8
 	Conditions []metav1.Condition `json:"conditions"`
+
+	// ObservedGeneration is the generation of the ClusterEnvironment the status
+	// was last computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Summary describes the status at a glance.
+	// +optional
+	Summary string `json:"summary,omitempty"`
+
+	// LastHeartbeatTime is the last time Primaza checked the connection to
+	// the cluster
+	// +optional
+	LastHeartbeatTime *metav1.Time `json:"lastHeartbeatTime,omitempty"`
+
+	// LastContactTime is the last time Primaza successfully connected to the
+	// cluster.  It is not set if the cluster was never contacted.
+	// +optional
+	LastContactTime *metav1.Time `json:"lastContactTime,omitempty"`
+
+	// Namespaces reports, for each application and service namespace,
+	// whether it was synchronized and prepared, and whether its agent is
+	// active
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +listMapKey=type
+	Namespaces []ClusterEnvironmentNamespaceStatus `json:"namespaces,omitempty"`
+}
+
+// ClusterEnvironmentNamespaceType is the type of a namespace of a cluster
+// +kubebuilder:validation:Enum=Application;Service
+type ClusterEnvironmentNamespaceType string
+
+const (
+	// ClusterEnvironmentApplicationNamespace is the type of the namespaces
+	// listed in ApplicationNamespaces
+	ClusterEnvironmentApplicationNamespace ClusterEnvironmentNamespaceType = "Application"
+	// ClusterEnvironmentServiceNamespace is the type of the namespaces listed
+	// in ServiceNamespaces
+	ClusterEnvironmentServiceNamespace ClusterEnvironmentNamespaceType = "Service"
+)
+
+// ClusterEnvironmentNamespaceStatus reports the status of a namespace in
+// the cluster
+type ClusterEnvironmentNamespaceStatus struct {
+	// Name of the namespace
+	Name string `json:"name"`
+
+	// Type of the namespace
+	Type ClusterEnvironmentNamespaceType `json:"type"`
+
+	// Conditions of the namespace, i.e. Synchronized, Prepared and
+	// AgentActive
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=8
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// ClusterEnvironmentNamespaceConditionSynchronized reports whether the
+	// namespace exists, is labeled, and holds the RBAC resources required by
+	// its agent.  It is only set when SynchronizeNamespaces is enabled.
+	ClusterEnvironmentNamespaceConditionSynchronized = "Synchronized"
+	// ClusterEnvironmentNamespaceConditionPrepared reports whether the
+	// permissions required by the agent are granted in the namespace, and
+	// whether the agent and its resources were pushed to it
+	ClusterEnvironmentNamespaceConditionPrepared = "Prepared"
+	// ClusterEnvironmentNamespaceConditionAgentActive reports whether the
+	// agent of the namespace is rolled out
+	ClusterEnvironmentNamespaceConditionAgentActive = "AgentActive"
+)
+
+// HeartbeatResolution is the minimum time between two recorded heartbeats
+// with the same outcome, so that periodic checks do not update the status
+// on each reconciliation
+const HeartbeatResolution = 30 * time.Second
+
+const (
+	// ClusterEnvironmentConditionOnline reports whether Primaza can connect
+	// to the cluster
+	ClusterEnvironmentConditionOnline = "Online"
+	// ClusterEnvironmentConditionApplicationNamespacePermissionsRequired
+	// reports whether some application namespaces lack the permissions
+	// required by the application agent
+	ClusterEnvironmentConditionApplicationNamespacePermissionsRequired = "ApplicationNamespacePermissionsRequired"
+	// ClusterEnvironmentConditionServiceNamespacePermissionsRequired reports
+	// whether some service namespaces lack the permissions required by the
+	// service agent
+	ClusterEnvironmentConditionServiceNamespacePermissionsRequired = "ServiceNamespacePermissionsRequired"
+	// ClusterEnvironmentConditionContacted reports whether the last heartbeat
+	// reached the cluster, and otherwise whether it was ever contacted
+	ClusterEnvironmentConditionContacted = "Contacted"
+	// ClusterEnvironmentConditionCredentialsExpiring reports whether the
+	// credentials of the cluster's kubeconfig are expired or expire soon
+	ClusterEnvironmentConditionCredentialsExpiring = "CredentialsExpiring"
+	// ClusterEnvironmentConditionAgentsRolledOut reports whether the agents
+	// deployed in the cluster's namespaces are rolled out
+	ClusterEnvironmentConditionAgentsRolledOut = "AgentsRolledOut"
+	// ClusterEnvironmentConditionAgentVersionSkew reports whether the
+	// version of some agents is outside of the skew the control plane
+	// supports
+	ClusterEnvironmentConditionAgentVersionSkew = "AgentVersionSkew"
+)
+
+const (
+	// ClusterEnvironmentContactedReason is the reason of the Contacted
+	// condition when the last heartbeat reached the cluster
+	ClusterEnvironmentContactedReason = "Contacted"
+	// ClusterEnvironmentNeverContactedReason is the reason of the Contacted
+	// condition when the cluster was never reached
+	ClusterEnvironmentNeverContactedReason = "NeverContacted"
+	// ClusterEnvironmentContactLostReason is the reason of the Contacted
+	// condition when the cluster was reached before, but not by the last
+	// heartbeat
+	ClusterEnvironmentContactLostReason = "ContactLost"
+)
+
+// ClusterEnvironmentConditionTypes lists the types of the conditions of a
+// ClusterEnvironment's status
+var ClusterEnvironmentConditionTypes = []string{
+	ClusterEnvironmentConditionOnline,
+	ClusterEnvironmentConditionApplicationNamespacePermissionsRequired,
+	ClusterEnvironmentConditionServiceNamespacePermissionsRequired,
+	ClusterEnvironmentConditionContacted,
+	ClusterEnvironmentConditionCredentialsExpiring,
+	ClusterEnvironmentConditionAgentsRolledOut,
+	ClusterEnvironmentConditionAgentVersionSkew,
+	ConditionReady,
+}
+
+// SetCondition sets the given condition, observed for the given generation
+// of the ClusterEnvironment.  Its LastTransitionTime is only updated when its
+// status changes.
+func (s *ClusterEnvironmentStatus) SetCondition(c metav1.Condition, generation int64) {
+	c.ObservedGeneration = generation
+	meta.SetStatusCondition(&s.Conditions, c)
+}
+
+// RecordHeartbeat records the outcome of a connection check made at the
+// given time, and updates the Contacted condition accordingly.  Heartbeats
+// with the same outcome as the previous one are only recorded once every
+// HeartbeatResolution.
+func (s *ClusterEnvironmentStatus) RecordHeartbeat(now metav1.Time, contacted bool, generation int64) {
+	if c := meta.FindStatusCondition(s.Conditions, ClusterEnvironmentConditionContacted); c != nil &&
+		s.LastHeartbeatTime != nil &&
+		(c.Status == metav1.ConditionTrue) == contacted &&
+		now.Sub(s.LastHeartbeatTime.Time) < HeartbeatResolution {
+		return
+	}
+
+	s.LastHeartbeatTime = &now
+	c := metav1.Condition{Type: ClusterEnvironmentConditionContacted}
+	switch {
+	case contacted:
+		s.LastContactTime = &now
+		c.Status = metav1.ConditionTrue
+		c.Reason = ClusterEnvironmentContactedReason
+	case s.LastContactTime == nil:
+		c.Status = metav1.ConditionFalse
+		c.Reason = ClusterEnvironmentNeverContactedReason
+		c.Message = "the cluster has never been contacted"
+	default:
+		c.Status = metav1.ConditionFalse
+		c.Reason = ClusterEnvironmentContactLostReason
+		c.Message = fmt.Sprintf("no contact since %s", s.LastContactTime.UTC().Format(time.RFC3339))
+	}
+	s.SetCondition(c, generation)
+}
+
+// SetNamespaceCondition sets the given condition of a namespace, observed
+// for the given generation of the ClusterEnvironment
+func (s *ClusterEnvironmentStatus) SetNamespaceCondition(name string, t ClusterEnvironmentNamespaceType, c metav1.Condition, generation int64) {
+	c.ObservedGeneration = generation
+	for i := range s.Namespaces {
+		if s.Namespaces[i].Name == name && s.Namespaces[i].Type == t {
+			meta.SetStatusCondition(&s.Namespaces[i].Conditions, c)
+			return
+		}
+	}
+
+	ns := ClusterEnvironmentNamespaceStatus{Name: name, Type: t}
+	meta.SetStatusCondition(&ns.Conditions, c)
+	s.Namespaces = append(s.Namespaces, ns)
+}
+
+// RemoveNamespaceCondition removes the conditions of the given type from all
+// the namespaces
+func (s *ClusterEnvironmentStatus) RemoveNamespaceCondition(conditionType string) {
+	for i := range s.Namespaces {
+		meta.RemoveStatusCondition(&s.Namespaces[i].Conditions, conditionType)
+	}
+	s.pruneEmptyNamespaces()
+}
+
+// PruneNamespaces removes the status of the namespaces that are no more
+// listed in the specification of the ClusterEnvironment, and returns whether
+// some were removed
+func (ce *ClusterEnvironment) PruneNamespaces() bool {
+	listed := func(ns ClusterEnvironmentNamespaceStatus) bool {
+		nn := ce.Spec.ApplicationNamespaces
+		if ns.Type == ClusterEnvironmentServiceNamespace {
+			nn = ce.Spec.ServiceNamespaces
+		}
+		for _, n := range nn {
+			if n == ns.Name {
+				return true
+			}
+		}
+		return false
+	}
+
+	l := len(ce.Status.Namespaces)
+	nn := ce.Status.Namespaces[:0]
+	for _, ns := range ce.Status.Namespaces {
+		if listed(ns) {
+			nn = append(nn, ns)
+		}
+	}
+	ce.Status.Namespaces = nn
+	ce.Status.pruneEmptyNamespaces()
+	return len(ce.Status.Namespaces) != l
+}
+
+func (s *ClusterEnvironmentStatus) pruneEmptyNamespaces() {
+	nn := s.Namespaces[:0]
+	for _, ns := range s.Namespaces {
+		if len(ns.Conditions) > 0 {
+			nn = append(nn, ns)
+		}
+	}
+	if len(nn) == 0 {
+		nn = nil
+	}
+	s.Namespaces = nn
+}
+
+// PruneConditions removes the conditions whose type is not one of
+// ClusterEnvironmentConditionTypes, and the duplicates of each type but the
+// latest, e.g. accumulated by former versions of Primaza.  It returns whether
+// conditions were removed.
+func (s *ClusterEnvironmentStatus) PruneConditions() bool {
+	latest := map[string]int{}
+	for i, c := range s.Conditions {
+		if !isClusterEnvironmentConditionType(c.Type) {
+			continue
+		}
+		if j, ok := latest[c.Type]; !ok || !c.LastTransitionTime.Before(&s.Conditions[j].LastTransitionTime) {
+			latest[c.Type] = i
+		}
+	}
+	if len(latest) == len(s.Conditions) {
+		return false
+	}
+
+	conditions := make([]metav1.Condition, 0, len(latest))
+	for i, c := range s.Conditions {
+		if j, ok := latest[c.Type]; ok && i == j {
+			conditions = append(conditions, c)
+		}
+	}
+	s.Conditions = conditions
+	return true
+}
+
+func isClusterEnvironmentConditionType(t string) bool {
+	for _, ct := range ClusterEnvironmentConditionTypes {
+		if ct == t {
+			return true
+		}
+	}
+	return false
 }
 
 type ClusterEnvironmentState string
@@ -67,6 +448,7 @@ const (
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:name="Environment",type="string",JSONPath=".spec.environmentName",description="the environment associated to the ClusterEnvironment instance"
 //+kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state",description="the state of the ClusterEnvironment"
+//+kubebuilder:printcolumn:name="Summary",type="string",JSONPath=".status.summary",description="the status of the ClusterEnvironment at a glance"
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // ClusterEnvironment is the Schema for the clusterenvironments API
@@ -94,3 +476,17 @@ func init() {
 func (ce *ClusterEnvironment) HasDeletionTimestamp() bool {
 	return !ce.DeletionTimestamp.IsZero()
 }
+
+// SelectorLabels returns the labels environment selectors are evaluated
+// against: the Topology labels and the Labels of the ClusterEnvironment
+func (ce *ClusterEnvironment) SelectorLabels() labels.Set {
+	ls := labels.Set{}
+	for k, v := range ce.Spec.Topology {
+		ls[k] = v
+	}
+	for _, l := range ce.Spec.Labels {
+		k, v, _ := strings.Cut(l, "=")
+		ls[k] = v
+	}
+	return ls
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("ClusterEnvironment conditions", func() {
+	It("records the observed generation", func() {
+		status := ClusterEnvironmentStatus{}
+		status.SetCondition(metav1.Condition{Type: ClusterEnvironmentConditionOnline, Status: metav1.ConditionTrue, Reason: "ConnectionSuccessful"}, 2)
+		status.SetCondition(metav1.Condition{Type: ClusterEnvironmentConditionOnline, Status: metav1.ConditionTrue, Reason: "ConnectionSuccessful"}, 3)
+
+		Expect(status.Conditions).To(HaveLen(1))
+		Expect(status.Conditions[0].ObservedGeneration).To(Equal(int64(3)))
+	})
+
+	It("prunes stale and duplicate conditions", func() {
+		start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		status := ClusterEnvironmentStatus{}
+		for i := 0; i < 5; i++ {
+			status.Conditions = append(status.Conditions,
+				metav1.Condition{Type: "Connection", LastTransitionTime: metav1.NewTime(start.Add(time.Duration(i) * time.Minute))},
+				metav1.Condition{Type: ClusterEnvironmentConditionOnline, Reason: "Attempt", LastTransitionTime: metav1.NewTime(start.Add(time.Duration(i) * time.Minute))})
+		}
+		status.Conditions = append(status.Conditions,
+			metav1.Condition{Type: ClusterEnvironmentConditionServiceNamespacePermissionsRequired, LastTransitionTime: metav1.NewTime(start)})
+
+		Expect(status.PruneConditions()).To(BeTrue())
+		Expect(status.Conditions).To(HaveLen(2))
+		Expect(status.Conditions[0].Type).To(Equal(ClusterEnvironmentConditionOnline))
+		Expect(status.Conditions[0].LastTransitionTime.Time).To(Equal(start.Add(4 * time.Minute)))
+		Expect(status.Conditions[1].Type).To(Equal(ClusterEnvironmentConditionServiceNamespacePermissionsRequired))
+
+		Expect(status.PruneConditions()).To(BeFalse())
+	})
+
+	Describe("heartbeats", func() {
+		start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		at := func(d time.Duration) metav1.Time { return metav1.NewTime(start.Add(d)) }
+		contacted := func(s ClusterEnvironmentStatus) *metav1.Condition {
+			for i := range s.Conditions {
+				if s.Conditions[i].Type == ClusterEnvironmentConditionContacted {
+					return &s.Conditions[i]
+				}
+			}
+			return nil
+		}
+
+		It("reports environments never contacted", func() {
+			status := ClusterEnvironmentStatus{}
+			status.RecordHeartbeat(at(0), false, 1)
+
+			Expect(status.LastHeartbeatTime.Time).To(Equal(start))
+			Expect(status.LastContactTime).To(BeNil())
+			Expect(contacted(status).Status).To(Equal(metav1.ConditionFalse))
+			Expect(contacted(status).Reason).To(Equal(ClusterEnvironmentNeverContactedReason))
+		})
+
+		It("reports environments whose contact was lost", func() {
+			status := ClusterEnvironmentStatus{}
+			status.RecordHeartbeat(at(0), true, 1)
+			Expect(status.LastContactTime.Time).To(Equal(start))
+			Expect(contacted(status).Status).To(Equal(metav1.ConditionTrue))
+
+			status.RecordHeartbeat(at(time.Second), false, 1)
+			Expect(status.LastHeartbeatTime.Time).To(Equal(start.Add(time.Second)))
+			Expect(status.LastContactTime.Time).To(Equal(start))
+			Expect(contacted(status).Reason).To(Equal(ClusterEnvironmentContactLostReason))
+			Expect(contacted(status).Message).To(Equal("no contact since 2023-01-01T00:00:00Z"))
+		})
+
+		It("throttles heartbeats with the same outcome", func() {
+			status := ClusterEnvironmentStatus{}
+			status.RecordHeartbeat(at(0), true, 1)
+			status.RecordHeartbeat(at(HeartbeatResolution/2), true, 1)
+			Expect(status.LastHeartbeatTime.Time).To(Equal(start))
+
+			status.RecordHeartbeat(at(HeartbeatResolution), true, 1)
+			Expect(status.LastHeartbeatTime.Time).To(Equal(start.Add(HeartbeatResolution)))
+			Expect(status.LastContactTime.Time).To(Equal(start.Add(HeartbeatResolution)))
+		})
+	})
+
+	Describe("namespaces", func() {
+		synchronized := metav1.Condition{
+			Type:   ClusterEnvironmentNamespaceConditionSynchronized,
+			Status: metav1.ConditionTrue,
+			Reason: "NamespaceSynchronized",
+		}
+		prepared := metav1.Condition{
+			Type:   ClusterEnvironmentNamespaceConditionPrepared,
+			Status: metav1.ConditionFalse,
+			Reason: "PermissionsNotGranted",
+		}
+
+		It("sets the conditions of each namespace", func() {
+			status := ClusterEnvironmentStatus{}
+			status.SetNamespaceCondition("apps", ClusterEnvironmentApplicationNamespace, synchronized, 1)
+			status.SetNamespaceCondition("apps", ClusterEnvironmentApplicationNamespace, prepared, 2)
+			status.SetNamespaceCondition("apps", ClusterEnvironmentServiceNamespace, prepared, 2)
+
+			Expect(status.Namespaces).To(HaveLen(2))
+			Expect(status.Namespaces[0].Name).To(Equal("apps"))
+			Expect(status.Namespaces[0].Type).To(Equal(ClusterEnvironmentApplicationNamespace))
+			Expect(status.Namespaces[0].Conditions).To(HaveLen(2))
+			Expect(status.Namespaces[0].Conditions[1].ObservedGeneration).To(BeEquivalentTo(2))
+			Expect(status.Namespaces[1].Type).To(Equal(ClusterEnvironmentServiceNamespace))
+			Expect(status.Namespaces[1].Conditions).To(HaveLen(1))
+		})
+
+		It("removes the namespaces left without conditions", func() {
+			status := ClusterEnvironmentStatus{}
+			status.SetNamespaceCondition("apps", ClusterEnvironmentApplicationNamespace, synchronized, 1)
+			status.SetNamespaceCondition("svcs", ClusterEnvironmentServiceNamespace, synchronized, 1)
+			status.SetNamespaceCondition("svcs", ClusterEnvironmentServiceNamespace, prepared, 1)
+
+			status.RemoveNamespaceCondition(ClusterEnvironmentNamespaceConditionSynchronized)
+			Expect(status.Namespaces).To(HaveLen(1))
+			Expect(status.Namespaces[0].Name).To(Equal("svcs"))
+			Expect(status.Namespaces[0].Conditions).To(HaveLen(1))
+
+			status.RemoveNamespaceCondition(ClusterEnvironmentNamespaceConditionPrepared)
+			Expect(status.Namespaces).To(BeNil())
+		})
+
+		It("prunes the namespaces that are no more listed", func() {
+			ce := ClusterEnvironment{
+				Spec: ClusterEnvironmentSpec{
+					ApplicationNamespaces: []string{"apps"},
+					ServiceNamespaces:     []string{"svcs"},
+				},
+			}
+			ce.Status.SetNamespaceCondition("apps", ClusterEnvironmentApplicationNamespace, prepared, 1)
+			ce.Status.SetNamespaceCondition("svcs", ClusterEnvironmentServiceNamespace, prepared, 1)
+			Expect(ce.PruneNamespaces()).To(BeFalse())
+			Expect(ce.Status.Namespaces).To(HaveLen(2))
+
+			ce.Status.SetNamespaceCondition("svcs", ClusterEnvironmentApplicationNamespace, prepared, 1)
+			ce.Spec.ApplicationNamespaces = nil
+			Expect(ce.PruneNamespaces()).To(BeTrue())
+			Expect(ce.Status.Namespaces).To(HaveLen(1))
+			Expect(ce.Status.Namespaces[0].Name).To(Equal("svcs"))
+			Expect(ce.Status.Namespaces[0].Type).To(Equal(ClusterEnvironmentServiceNamespace))
+		})
+	})
+})
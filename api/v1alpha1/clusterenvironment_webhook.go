@@ -0,0 +1,221 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/primaza/primaza/pkg/authn"
+)
+
+// log is for logging in this package.
+var clusterenvironmentlog = logf.Log.WithName("clusterenvironment-resource")
+
+type clusterEnvironmentValidator struct {
+	client client.Client
+}
+
+var _ admission.CustomValidator = &clusterEnvironmentValidator{}
+
+func (r *ClusterEnvironment) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithValidator(&clusterEnvironmentValidator{
+			client: mgr.GetClient(),
+		}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-primaza-io-v1alpha1-clusterenvironment,mutating=false,failurePolicy=fail,sideEffects=None,groups=primaza.io,resources=clusterenvironments,verbs=create;update,versions=v1alpha1,name=vclusterenvironment.kb.io,admissionReviewVersions=v1
+
+// ValidateCreate implements admission.CustomValidator
+func (v *clusterEnvironmentValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	r, ok := obj.(*ClusterEnvironment)
+	if !ok {
+		err := fmt.Errorf("Object is not a Cluster Environment")
+		clusterenvironmentlog.Error(err, "Attempted to validate non-ClusterEnvironment resource", "gvk", obj.GetObjectKind().GroupVersionKind())
+		return err
+	}
+
+	clusterenvironmentlog.Info("validate create", "name", r.Name)
+	return v.validate(ctx, r, true)
+}
+
+// ValidateUpdate implements admission.CustomValidator
+func (v *clusterEnvironmentValidator) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) error {
+	r, ok := newObj.(*ClusterEnvironment)
+	if !ok {
+		err := fmt.Errorf("Object is not a Cluster Environment")
+		clusterenvironmentlog.Error(err, "Attempted to validate non-ClusterEnvironment resource", "gvk", newObj.GetObjectKind().GroupVersionKind())
+		return err
+	}
+
+	old, ok := oldObj.(*ClusterEnvironment)
+	if !ok {
+		err := fmt.Errorf("Old Object is not a Cluster Environment")
+		clusterenvironmentlog.Error(err, "Attempted to validate non-ClusterEnvironment resource", "gvk", oldObj.GetObjectKind().GroupVersionKind())
+		return err
+	}
+
+	clusterenvironmentlog.Info("validate update", "name", r.Name)
+	// the finalizer of a cluster environment being deleted must be removable
+	// even if its secret has been deleted first
+	if r.DeletionTimestamp != nil {
+		return nil
+	}
+	// the secret is only checked when the cluster environment refers to
+	// another one, or requires another TLS verification of it, so that
+	// the cluster environment stays updatable when the secret is missing
+	checkSecret := old.Spec.ClusterContextSecret != r.Spec.ClusterContextSecret ||
+		!reflect.DeepEqual(old.Spec.TLS, r.Spec.TLS)
+	return v.validate(ctx, r, checkSecret)
+}
+
+// ValidateDelete implements admission.CustomValidator
+func (v *clusterEnvironmentValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	r, ok := obj.(*ClusterEnvironment)
+	if !ok {
+		err := fmt.Errorf("Object is not a Cluster Environment")
+		clusterenvironmentlog.Error(err, "Attempted to validate non-ClusterEnvironment resource", "gvk", obj.GetObjectKind().GroupVersionKind())
+		return err
+	}
+
+	clusterenvironmentlog.Info("validate delete", "name", r.Name)
+	return nil // no validation
+}
+
+func (v *clusterEnvironmentValidator) validate(ctx context.Context, r *ClusterEnvironment, checkSecret bool) error {
+	errs := field.ErrorList{}
+	if checkSecret {
+		var err error
+		if errs, err = v.ValidateClusterContextSecret(ctx, *r); err != nil {
+			return err
+		}
+	}
+	errs = append(errs, r.Spec.ValidateNamespaces()...)
+	errs = append(errs, r.Spec.ValidateTLS()...)
+	return errs.ToAggregate()
+}
+
+// ValidateClusterContextSecret checks that the secret referred by the
+// ClusterEnvironment exists and contains a usable kubeconfig
+func (v *clusterEnvironmentValidator) ValidateClusterContextSecret(ctx context.Context, ce ClusterEnvironment) (field.ErrorList, error) {
+	path := field.NewPath("spec", "clusterContextSecret")
+	if ce.Spec.ClusterContextSecret == "" {
+		return field.ErrorList{field.Required(path, "ClusterContextSecret can not be empty")}, nil
+	}
+
+	s := corev1.Secret{}
+	k := types.NamespacedName{Namespace: ce.Namespace, Name: ce.Spec.ClusterContextSecret}
+	if err := v.client.Get(ctx, k, &s); err != nil {
+		if apierrors.IsNotFound(err) {
+			return field.ErrorList{field.NotFound(path, ce.Spec.ClusterContextSecret)}, nil
+		}
+		return nil, err
+	}
+
+	kc, ok := s.Data["kubeconfig"]
+	if _, isToken := authn.TokenConfig(s.Data); !ok && isToken {
+		return nil, nil
+	}
+	if !ok {
+		return field.ErrorList{
+			field.Invalid(path, ce.Spec.ClusterContextSecret, "Secret does not contain the key 'kubeconfig'"),
+		}, nil
+	}
+	cfg, err := clientcmd.Load(kc)
+	if err != nil {
+		return field.ErrorList{
+			field.Invalid(path, ce.Spec.ClusterContextSecret, fmt.Sprintf("Secret does not contain a valid kubeconfig: %v", err)),
+		}, nil
+	}
+	// an empty context name selects the kubeconfig's current context
+	contextName := string(s.Data["context"])
+	cc := clientcmd.NewNonInteractiveClientConfig(*cfg, contextName, &clientcmd.ConfigOverrides{}, nil)
+	rc, err := cc.ClientConfig()
+	if err != nil {
+		return field.ErrorList{
+			field.Invalid(path, ce.Spec.ClusterContextSecret, fmt.Sprintf("Secret does not contain a usable kubeconfig: %v", err)),
+		}, nil
+	}
+	if err := authn.Validate(rc); err != nil {
+		return field.ErrorList{
+			field.Invalid(path, ce.Spec.ClusterContextSecret, err.Error()),
+		}, nil
+	}
+	if rc.Insecure && ce.Spec.TLS != nil && ce.Spec.TLS.RequireVerification {
+		return field.ErrorList{
+			field.Invalid(path, ce.Spec.ClusterContextSecret, "kubeconfig skips TLS verification, which spec.tls.requireVerification forbids"),
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// ValidateTLS checks that the pinned public keys are base64 encoded SHA-256
+// digests
+func (s *ClusterEnvironmentSpec) ValidateTLS() field.ErrorList {
+	errs := field.ErrorList{}
+	if s.TLS == nil {
+		return errs
+	}
+	path := field.NewPath("spec", "tls", "pinnedPublicKeys")
+	for i, pin := range s.TLS.PinnedPublicKeys {
+		if d, err := base64.StdEncoding.DecodeString(pin); err != nil || len(d) != sha256.Size {
+			errs = append(errs, field.Invalid(path.Index(i), pin, "must be a base64 encoded SHA-256 digest"))
+		}
+	}
+	return errs
+}
+
+// ValidateNamespaces checks that no namespace is listed more than once in
+// application or service namespaces.  A namespace can be both an application
+// and a service namespace.
+func (s *ClusterEnvironmentSpec) ValidateNamespaces() field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateUniqueNamespaces(field.NewPath("spec", "applicationNamespaces"), s.ApplicationNamespaces)...)
+	errs = append(errs, validateUniqueNamespaces(field.NewPath("spec", "serviceNamespaces"), s.ServiceNamespaces)...)
+	return errs
+}
+
+func validateUniqueNamespaces(path *field.Path, namespaces []string) field.ErrorList {
+	errs := field.ErrorList{}
+	found := map[string]struct{}{}
+	for i, ns := range namespaces {
+		if _, ok := found[ns]; ok {
+			errs = append(errs, field.Duplicate(path.Index(i), ns))
+			continue
+		}
+		found[ns] = struct{}{}
+	}
+	return errs
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testKubeconfig = `
+apiVersion: v1
+kind: Config
+clusters:
+- name: worker
+  cluster:
+    server: https://worker:6443
+contexts:
+- name: worker
+  context:
+    cluster: worker
+    user: worker
+current-context: worker
+users:
+- name: worker
+  user:
+    token: token
+`
+
+const testInsecureKubeconfig = `
+apiVersion: v1
+kind: Config
+clusters:
+- name: worker
+  cluster:
+    server: https://worker:6443
+    insecure-skip-tls-verify: true
+contexts:
+- name: worker
+  context:
+    cluster: worker
+    user: worker
+current-context: worker
+users:
+- name: worker
+  user:
+    token: token
+`
+
+const testAuthProviderKubeconfig = `
+apiVersion: v1
+kind: Config
+clusters:
+- name: worker
+  cluster:
+    server: https://worker:6443
+contexts:
+- name: worker
+  context:
+    cluster: worker
+    user: worker
+current-context: worker
+users:
+- name: worker
+  user:
+    auth-provider:
+      name: gcp
+`
+
+// testPin is the base64 encoded SHA-256 digest of an empty string
+const testPin = "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+
+func newClusterEnvironment(name, namespace string, spec ClusterEnvironmentSpec) ClusterEnvironment {
+	return ClusterEnvironment{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: spec,
+	}
+}
+
+func newKubeconfigSecret(name, namespace string, data map[string]string) *corev1.Secret {
+	s := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{},
+	}
+	for k, v := range data {
+		s.Data[k] = []byte(v)
+	}
+	return s
+}
+
+var _ = Describe("ClusterEnvironment Webhook tests", func() {
+	var validator clusterEnvironmentValidator
+	BeforeEach(func() {
+		schemeBuilder, err := SchemeBuilder.Build()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(corev1.AddToScheme(schemeBuilder)).To(Succeed())
+
+		validator = clusterEnvironmentValidator{
+			client: fake.NewClientBuilder().
+				WithScheme(schemeBuilder).
+				WithObjects(
+					newKubeconfigSecret("valid", "primaza", map[string]string{"kubeconfig": testKubeconfig}),
+					newKubeconfigSecret("valid-context", "primaza", map[string]string{"kubeconfig": testKubeconfig, "context": "worker"}),
+					newKubeconfigSecret("missing-context", "primaza", map[string]string{"kubeconfig": testKubeconfig, "context": "spam"}),
+					newKubeconfigSecret("no-kubeconfig", "primaza", map[string]string{"config": testKubeconfig}),
+					newKubeconfigSecret("invalid-kubeconfig", "primaza", map[string]string{"kubeconfig": "{"}),
+					newKubeconfigSecret("insecure", "primaza", map[string]string{"kubeconfig": testInsecureKubeconfig}),
+					newKubeconfigSecret("auth-provider", "primaza", map[string]string{"kubeconfig": testAuthProviderKubeconfig}),
+				).
+				Build(),
+		}
+	})
+
+	secretPath := field.NewPath("spec", "clusterContextSecret")
+
+	DescribeTable("Creation validation",
+		func(ce ClusterEnvironment, expected field.ErrorList) {
+			err := validator.ValidateCreate(context.Background(), &ce)
+			if expected == nil {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expected.ToAggregate().Error()))
+			}
+		},
+		Entry("Valid cluster environment",
+			newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+				EnvironmentName:       "dev",
+				ClusterContextSecret:  "valid",
+				ApplicationNamespaces: []string{"applications"},
+				ServiceNamespaces:     []string{"services"},
+			}),
+			nil),
+		Entry("Valid context",
+			newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+				EnvironmentName:      "dev",
+				ClusterContextSecret: "valid-context",
+			}),
+			nil),
+		Entry("Single namespace",
+			newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+				EnvironmentName:       "dev",
+				ClusterContextSecret:  "valid",
+				ApplicationNamespaces: []string{"myapp"},
+				ServiceNamespaces:     []string{"myapp"},
+			}),
+			nil),
+		Entry("Missing secret",
+			newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+				EnvironmentName:      "dev",
+				ClusterContextSecret: "spam",
+			}),
+			field.ErrorList{field.NotFound(secretPath, "spam")}),
+		Entry("Secret in another namespace",
+			newClusterEnvironment("worker", "eggs", ClusterEnvironmentSpec{
+				EnvironmentName:      "dev",
+				ClusterContextSecret: "valid",
+			}),
+			field.ErrorList{field.NotFound(secretPath, "valid")}),
+		Entry("Secret without kubeconfig",
+			newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+				EnvironmentName:      "dev",
+				ClusterContextSecret: "no-kubeconfig",
+			}),
+			field.ErrorList{field.Invalid(secretPath, "no-kubeconfig", "Secret does not contain the key 'kubeconfig'")}),
+		Entry("Duplicate namespaces",
+			newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+				EnvironmentName:       "dev",
+				ClusterContextSecret:  "valid",
+				ApplicationNamespaces: []string{"applications", "applications"},
+				ServiceNamespaces:     []string{"services", "other", "services"},
+			}),
+			field.ErrorList{
+				field.Duplicate(field.NewPath("spec", "applicationNamespaces").Index(1), "applications"),
+				field.Duplicate(field.NewPath("spec", "serviceNamespaces").Index(2), "services"),
+			}),
+		Entry("Insecure kubeconfig",
+			newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+				EnvironmentName:      "dev",
+				ClusterContextSecret: "insecure",
+			}),
+			nil),
+		Entry("Insecure kubeconfig requiring verification",
+			newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+				EnvironmentName:      "dev",
+				ClusterContextSecret: "insecure",
+				TLS:                  &ClusterEnvironmentTLS{RequireVerification: true},
+			}),
+			field.ErrorList{field.Invalid(secretPath, "insecure", "kubeconfig skips TLS verification, which spec.tls.requireVerification forbids")}),
+		Entry("Unsupported auth provider",
+			newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+				EnvironmentName:      "dev",
+				ClusterContextSecret: "auth-provider",
+			}),
+			field.ErrorList{field.Invalid(secretPath, "auth-provider",
+				`unsupported authentication method: auth provider "gcp" is not supported, use an exec credential plugin instead`)}),
+		Entry("Pinned public keys",
+			newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+				EnvironmentName:      "dev",
+				ClusterContextSecret: "valid",
+				TLS: &ClusterEnvironmentTLS{
+					RequireVerification: true,
+					PinnedPublicKeys:    []string{testPin},
+				},
+			}),
+			nil),
+		Entry("Invalid pinned public keys",
+			newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+				EnvironmentName:      "dev",
+				ClusterContextSecret: "valid",
+				TLS: &ClusterEnvironmentTLS{
+					PinnedPublicKeys: []string{testPin, "spam", "c3BhbQ=="},
+				},
+			}),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "tls", "pinnedPublicKeys").Index(1), "spam", "must be a base64 encoded SHA-256 digest"),
+				field.Invalid(field.NewPath("spec", "tls", "pinnedPublicKeys").Index(2), "c3BhbQ==", "must be a base64 encoded SHA-256 digest"),
+			}),
+	)
+
+	DescribeTable("Kubeconfig validation",
+		func(secret string) {
+			ce := newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+				EnvironmentName:      "dev",
+				ClusterContextSecret: secret,
+			})
+			errs, err := validator.ValidateClusterContextSecret(context.Background(), ce)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Type).To(Equal(field.ErrorTypeInvalid))
+			Expect(errs[0].Field).To(Equal(secretPath.String()))
+		},
+		Entry("Unparseable kubeconfig", "invalid-kubeconfig"),
+		Entry("Missing context", "missing-context"),
+	)
+
+	It("Validates updates", func() {
+		old := newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+			EnvironmentName:      "dev",
+			ClusterContextSecret: "valid",
+		})
+		ce := old
+		ce.Spec.ClusterContextSecret = "spam"
+		err := validator.ValidateUpdate(context.Background(), &old, &ce)
+		Expect(err).To(MatchError(field.ErrorList{field.NotFound(secretPath, "spam")}.ToAggregate().Error()))
+	})
+
+	It("Does not check an unchanged missing secret on update", func() {
+		old := newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+			EnvironmentName:      "dev",
+			ClusterContextSecret: "spam",
+		})
+		ce := old
+		ce.Finalizers = []string{"clusterenvironment.primaza.io/finalizer"}
+		Expect(validator.ValidateUpdate(context.Background(), &old, &ce)).To(Succeed())
+
+		ce.Spec.TLS = &ClusterEnvironmentTLS{RequireVerification: true}
+		err := validator.ValidateUpdate(context.Background(), &old, &ce)
+		Expect(err).To(MatchError(field.ErrorList{field.NotFound(secretPath, "spam")}.ToAggregate().Error()))
+	})
+
+	It("Lets the finalizer of a deleted cluster environment be removed", func() {
+		now := v1.Now()
+		old := newClusterEnvironment("worker", "primaza", ClusterEnvironmentSpec{
+			EnvironmentName:      "dev",
+			ClusterContextSecret: "spam",
+		})
+		old.DeletionTimestamp = &now
+		old.Finalizers = []string{"clusterenvironment.primaza.io/finalizer"}
+		ce := old
+		ce.Finalizers = nil
+		Expect(validator.ValidateUpdate(context.Background(), &old, &ce)).To(Succeed())
+	})
+})
@@ -0,0 +1,392 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/primaza/primaza/pkg/envtag"
+)
+
+const (
+	// DefaultHealthCheckInterval is the interval between two runs of a
+	// health check that does not define one
+	DefaultHealthCheckInterval = 5 * time.Minute
+	// MinHealthCheckInterval is the shortest allowed interval between two
+	// runs of a health check
+	MinHealthCheckInterval = 10 * time.Second
+	// DefaultHealthCheckFailureThreshold is the number of consecutive failed
+	// runs after which a service is considered unreachable
+	DefaultHealthCheckFailureThreshold = 3
+	// DefaultHealthCheckSuccessThreshold is the number of consecutive passed
+	// runs after which an unreachable service is considered reachable again
+	DefaultHealthCheckSuccessThreshold = 1
+	// DefaultHealthProbeTimeout is the time a health probe that does not
+	// define a deadline is allowed to take
+	DefaultHealthProbeTimeout = 10 * time.Second
+	// MaxHealthCheckResults is the number of health check executions kept
+	// in the status of RegisteredServices
+	MaxHealthCheckResults = 10
+	// RegisteredServiceConditionHealthy reports the result of the latest
+	// health check of a RegisteredService
+	RegisteredServiceConditionHealthy = "Healthy"
+)
+
+var (
+	imagePathComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	imageDomainRegexp        = regexp.MustCompile(`^(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*(?::[0-9]+)?$`)
+	imageTagRegexp           = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	imageDigestRegexp        = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+)
+
+// ParseCommand splits a health check command into its arguments.  Arguments
+// are separated by whitespaces, unless they are quoted with single or double
+// quotes.  Backslashes escape the following character, except within single
+// quotes.
+func ParseCommand(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg, escaped := false, false
+	var quote rune
+	for _, c := range command {
+		switch {
+		case escaped:
+			current.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(c)
+		case c == '\'' || c == '"':
+			quote, inArg = c, true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+
+	switch {
+	case escaped:
+		return nil, fmt.Errorf("command ends with an escape character")
+	case quote != 0:
+		return nil, fmt.Errorf("command has an unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("command is empty")
+	}
+	return args, nil
+}
+
+// validateImage checks that image is a valid container image reference,
+// e.g. `quay.io/primaza/pg-check:v1` or `postgres@sha256:...`
+func validateImage(image string) error {
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		if !imageDigestRegexp.MatchString(name[i+1:]) {
+			return fmt.Errorf("invalid digest %q", name[i+1:])
+		}
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		if !imageTagRegexp.MatchString(name[i+1:]) {
+			return fmt.Errorf("invalid tag %q", name[i+1:])
+		}
+		name = name[:i]
+	}
+
+	components := strings.Split(name, "/")
+	// the first component is a registry if it looks like a host name
+	if len(components) > 1 && (strings.ContainsAny(components[0], ".:") || components[0] == "localhost") {
+		if !imageDomainRegexp.MatchString(components[0]) {
+			return fmt.Errorf("invalid registry %q", components[0])
+		}
+		components = components[1:]
+	}
+	for _, c := range components {
+		if !imagePathComponentRegexp.MatchString(c) {
+			return fmt.Errorf("invalid repository %q, only lowercase letters, digits and separators are allowed", name)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the health check defines exactly one check, and that
+// it can be run
+func (h *HealthCheck) Validate(path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if h == nil {
+		return errs
+	}
+
+	checks := 0
+	if h.Container != nil {
+		checks++
+		errs = append(errs, h.Container.validate(path.Child("container"))...)
+	}
+	if h.HTTPGet != nil {
+		checks++
+		errs = append(errs, h.HTTPGet.validate(path.Child("httpGet"))...)
+	}
+	if h.TCPSocket != nil {
+		checks++
+	}
+	if h.GRPC != nil {
+		checks++
+	}
+	switch {
+	case checks == 0:
+		errs = append(errs, field.Required(path, "one of container, httpGet, tcpSocket and grpc must be set"))
+	case checks > 1:
+		errs = append(errs, field.Forbidden(path, "only one of container, httpGet, tcpSocket and grpc can be set"))
+	}
+
+	if h.Interval != nil && h.Interval.Duration < MinHealthCheckInterval {
+		errs = append(errs, field.Invalid(path.Child("interval"), h.Interval.Duration.String(),
+			fmt.Sprintf("must be at least %s", MinHealthCheckInterval)))
+	}
+	if h.ActiveDeadlineSeconds != nil && *h.ActiveDeadlineSeconds <= 0 {
+		errs = append(errs, field.Invalid(path.Child("activeDeadlineSeconds"), *h.ActiveDeadlineSeconds, "must be greater than 0"))
+	}
+	if h.TTLSecondsAfterFinished != nil && *h.TTLSecondsAfterFinished < 0 {
+		errs = append(errs, field.Invalid(path.Child("ttlSecondsAfterFinished"), *h.TTLSecondsAfterFinished, "must be greater than or equal to 0"))
+	}
+	errs = append(errs, validateThreshold(path.Child("failureThreshold"), h.FailureThreshold)...)
+	errs = append(errs, validateThreshold(path.Child("successThreshold"), h.SuccessThreshold)...)
+	switch h.ConcurrencyPolicy {
+	case "", HealthCheckConcurrencyPolicyForbid, HealthCheckConcurrencyPolicyReplace:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("concurrencyPolicy"), h.ConcurrencyPolicy,
+			[]string{string(HealthCheckConcurrencyPolicyForbid), string(HealthCheckConcurrencyPolicyReplace)}))
+	}
+	return errs
+}
+
+func validateThreshold(path *field.Path, threshold *int32) field.ErrorList {
+	if threshold == nil || (*threshold >= 1 && *threshold <= MaxHealthCheckResults) {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(path, *threshold, fmt.Sprintf("must be between 1 and %d", MaxHealthCheckResults))}
+}
+
+func (c *HealthCheckContainer) validate(path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if c.Image == "" {
+		errs = append(errs, field.Required(path.Child("image"), "Image cannot be empty"))
+	} else if err := validateImage(c.Image); err != nil {
+		errs = append(errs, field.Invalid(path.Child("image"), c.Image, err.Error()))
+	}
+
+	if _, err := ParseCommand(c.Command); err != nil {
+		errs = append(errs, field.Invalid(path.Child("command"), c.Command, err.Error()))
+	}
+
+	if sa := c.ServiceAccountName; sa != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(sa) {
+			errs = append(errs, field.Invalid(path.Child("serviceAccountName"), sa, msg))
+		}
+	}
+
+	if p := c.ServiceEndpointDefinitionMountPath; p != "" && !strings.HasPrefix(p, "/") {
+		errs = append(errs, field.Invalid(path.Child("serviceEndpointDefinitionMountPath"), p, "must be an absolute path"))
+	}
+	switch p := c.BindingSecretMountPath; {
+	case p == "":
+	case !strings.HasPrefix(p, "/"):
+		errs = append(errs, field.Invalid(path.Child("bindingSecretMountPath"), p, "must be an absolute path"))
+	case p == c.ServiceEndpointDefinitionMountPath:
+		errs = append(errs, field.Invalid(path.Child("bindingSecretMountPath"), p, "must differ from serviceEndpointDefinitionMountPath"))
+	}
+
+	switch c.ImagePullPolicy {
+	case "", corev1.PullAlways, corev1.PullIfNotPresent, corev1.PullNever:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("imagePullPolicy"), c.ImagePullPolicy,
+			[]string{string(corev1.PullAlways), string(corev1.PullIfNotPresent), string(corev1.PullNever)}))
+	}
+	return errs
+}
+
+func (a *HealthCheckHTTPGetAction) validate(path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if a.Path != "" && !strings.HasPrefix(a.Path, "/") {
+		errs = append(errs, field.Invalid(path.Child("path"), a.Path, "path must start with /"))
+	}
+
+	switch a.Scheme {
+	case "", corev1.URISchemeHTTP, corev1.URISchemeHTTPS:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("scheme"), a.Scheme,
+			[]string{string(corev1.URISchemeHTTP), string(corev1.URISchemeHTTPS)}))
+	}
+	return errs
+}
+
+// Probe returns the endpoint of the health check's probe, or nil if the
+// health check runs a container
+func (h *HealthCheck) Probe() *HealthCheckEndpoint {
+	switch {
+	case h.HTTPGet != nil:
+		return &h.HTTPGet.HealthCheckEndpoint
+	case h.TCPSocket != nil:
+		return &h.TCPSocket.HealthCheckEndpoint
+	case h.GRPC != nil:
+		return &h.GRPC.HealthCheckEndpoint
+	}
+	return nil
+}
+
+// Keys returns the names of the ServiceEndpointDefinition items holding the
+// host and port of the service
+func (e HealthCheckEndpoint) Keys() (string, string) {
+	host, port := e.HostKey, e.PortKey
+	if host == "" {
+		host = "host"
+	}
+	if port == "" {
+		port = "port"
+	}
+	return host, port
+}
+
+// RunInterval returns the interval between two runs of the health check
+func (h *HealthCheck) RunInterval() time.Duration {
+	if h.Interval == nil {
+		return DefaultHealthCheckInterval
+	}
+	return h.Interval.Duration
+}
+
+// RunDeadline returns the time a run of the health check is allowed to take.
+// Unless set, health check containers can run until the next run is due.
+func (h *HealthCheck) RunDeadline() time.Duration {
+	switch {
+	case h.ActiveDeadlineSeconds != nil:
+		return time.Duration(*h.ActiveDeadlineSeconds) * time.Second
+	case h.Container != nil:
+		return h.RunInterval()
+	}
+	return DefaultHealthProbeTimeout
+}
+
+// HealthCheckFor returns the health check of the registered services of the
+// given environment: the one of the first override matching the environment,
+// or the default one
+func (s ServiceClassSpec) HealthCheckFor(environment string) *HealthCheck {
+	for _, o := range s.HealthCheckOverrides {
+		if envtag.Match(environment, o.Environments) {
+			hc := o.HealthCheck
+			return &hc
+		}
+	}
+	return s.HealthCheck
+}
+
+// validateHealthCheckOverrides validates the environment constraints and the
+// health check of each override
+func validateHealthCheckOverrides(path *field.Path, overrides []HealthCheckOverride) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, o := range overrides {
+		p := path.Index(i)
+		if len(o.Environments) == 0 {
+			errs = append(errs, field.Required(p.Child("environments"), "at least one environment constraint must be set"))
+		}
+		errs = append(errs, ValidateEnvironmentConstraints(p.Child("environments"), o.Environments)...)
+		errs = append(errs, o.HealthCheck.Validate(p.Child("healthCheck"))...)
+	}
+	return errs
+}
+
+// ThresholdReached returns true if the latest results of the given history
+// that passed, or failed, like the latest one are enough to consider the
+// service reachable, or unreachable
+func (h *HealthCheck) ThresholdReached(history []HealthCheckResult) bool {
+	if len(history) == 0 {
+		return false
+	}
+
+	passed := history[len(history)-1].Passed
+	threshold := int32(DefaultHealthCheckFailureThreshold)
+	switch {
+	case passed && h.SuccessThreshold != nil:
+		threshold = *h.SuccessThreshold
+	case passed:
+		threshold = DefaultHealthCheckSuccessThreshold
+	case h.FailureThreshold != nil:
+		threshold = *h.FailureThreshold
+	}
+
+	var n int32
+	for i := len(history) - 1; i >= 0 && history[i].Passed == passed; i-- {
+		n++
+	}
+	return n >= threshold
+}
+
+// ReplacesRunningChecks returns true if a running health check is stopped
+// when the next run is due
+func (h *HealthCheck) ReplacesRunningChecks() bool {
+	return h.ConcurrencyPolicy == HealthCheckConcurrencyPolicyReplace
+}
+
+// RecordHealthCheck appends the result to the health check history, keeping
+// only the latest MaxHealthCheckResults executions, and updates the Healthy
+// condition with the given reason.  It returns false if a result with the
+// same start time is already recorded.
+func (s *RegisteredServiceStatus) RecordHealthCheck(result HealthCheckResult, reason string) bool {
+	for _, r := range s.HealthChecks {
+		if r.Time.Equal(&result.Time) {
+			return false
+		}
+	}
+
+	s.HealthChecks = append(s.HealthChecks, result)
+	if l := len(s.HealthChecks); l > MaxHealthCheckResults {
+		s.HealthChecks = s.HealthChecks[l-MaxHealthCheckResults:]
+	}
+
+	status := metav1.ConditionFalse
+	if result.Passed {
+		status = metav1.ConditionTrue
+	}
+	meta.SetStatusCondition(&s.Conditions, metav1.Condition{
+		Type:    RegisteredServiceConditionHealthy,
+		Status:  status,
+		Reason:  reason,
+		Message: result.Message,
+	})
+	return true
+}
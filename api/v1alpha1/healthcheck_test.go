@@ -0,0 +1,238 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("HealthCheck", func() {
+	DescribeTable("ParseCommand",
+		func(command string, expected []string) {
+			Expect(ParseCommand(command)).To(Equal(expected))
+		},
+		Entry("single argument", "pg_isready", []string{"pg_isready"}),
+		Entry("whitespaces", "  pg_isready\t-h  db ", []string{"pg_isready", "-h", "db"}),
+		Entry("quotes", `sh -c 'echo "$HOST"' "a b"`, []string{"sh", "-c", `echo "$HOST"`, "a b"}),
+		Entry("escapes", `echo a\ b "c\"d"`, []string{"echo", "a b", `c"d`}),
+		Entry("empty quoted argument", `echo ""`, []string{"echo", ""}),
+	)
+
+	DescribeTable("ParseCommand failures",
+		func(command string) {
+			_, err := ParseCommand(command)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("empty", "  "),
+		Entry("unterminated quote", `sh -c 'echo`),
+		Entry("trailing escape", `echo \`),
+	)
+
+	DescribeTable("Validate",
+		func(hc *HealthCheck, expected field.ErrorList) {
+			Expect(hc.Validate(field.NewPath("spec", "healthCheck"))).To(Equal(expected))
+		},
+		Entry("nil health check", nil, field.ErrorList{}),
+		Entry("valid health check",
+			&HealthCheck{Container: &HealthCheckContainer{
+				Image:           "quay.io/primaza/pg-check:v1.0",
+				Command:         "pg_isready",
+				ImagePullPolicy: corev1.PullAlways,
+			}},
+			field.ErrorList{}),
+		Entry("image with registry port and digest",
+			&HealthCheck{Container: &HealthCheckContainer{
+				Image:   "localhost:5000/pg-check@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+				Command: "pg_isready",
+			}},
+			field.ErrorList{}),
+		Entry("invalid health check",
+			&HealthCheck{Container: &HealthCheckContainer{
+				Image:                              "Quay.io/Primaza/PG check",
+				Command:                            "sh -c 'pg_isready",
+				ImagePullPolicy:                    "Sometimes",
+				ServiceEndpointDefinitionMountPath: "sed",
+				ServiceAccountName:                 "Health_Check",
+			}},
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "healthCheck", "container", "image"), "Quay.io/Primaza/PG check",
+					`invalid repository "Quay.io/Primaza/PG check", only lowercase letters, digits and separators are allowed`),
+				field.Invalid(field.NewPath("spec", "healthCheck", "container", "command"), "sh -c 'pg_isready", "command has an unterminated ' quote"),
+				field.Invalid(field.NewPath("spec", "healthCheck", "container", "serviceAccountName"), "Health_Check",
+					"a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')"),
+				field.Invalid(field.NewPath("spec", "healthCheck", "container", "serviceEndpointDefinitionMountPath"), "sed", "must be an absolute path"),
+				field.NotSupported(field.NewPath("spec", "healthCheck", "container", "imagePullPolicy"), corev1.PullPolicy("Sometimes"),
+					[]string{"Always", "IfNotPresent", "Never"}),
+			}),
+		Entry("invalid binding secret mount path",
+			&HealthCheck{Container: &HealthCheckContainer{Image: "postgres", Command: "pg_isready", BindingSecretMountPath: "bindings"}},
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "healthCheck", "container", "bindingSecretMountPath"), "bindings", "must be an absolute path"),
+			}),
+		Entry("conflicting mount paths",
+			&HealthCheck{Container: &HealthCheckContainer{
+				Image:                              "postgres",
+				Command:                            "pg_isready",
+				ServiceEndpointDefinitionMountPath: "/bindings",
+				BindingSecretMountPath:             "/bindings",
+			}},
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "healthCheck", "container", "bindingSecretMountPath"), "/bindings",
+					"must differ from serviceEndpointDefinitionMountPath"),
+			}),
+		Entry("missing image",
+			&HealthCheck{Container: &HealthCheckContainer{Command: "pg_isready"}},
+			field.ErrorList{
+				field.Required(field.NewPath("spec", "healthCheck", "container", "image"), "Image cannot be empty"),
+			}),
+		Entry("invalid tag",
+			&HealthCheck{Container: &HealthCheckContainer{Image: "postgres:-latest", Command: "pg_isready"}},
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "healthCheck", "container", "image"), "postgres:-latest", `invalid tag "-latest"`),
+			}),
+		Entry("too short interval",
+			&HealthCheck{
+				Container: &HealthCheckContainer{Image: "postgres", Command: "pg_isready"},
+				Interval:  &metav1.Duration{Duration: time.Second},
+			},
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "healthCheck", "interval"), "1s", "must be at least 10s"),
+			}),
+		Entry("invalid job settings",
+			&HealthCheck{
+				Container:               &HealthCheckContainer{Image: "postgres", Command: "pg_isready"},
+				ActiveDeadlineSeconds:   pointer.Int64(0),
+				TTLSecondsAfterFinished: pointer.Int32(-1),
+				ConcurrencyPolicy:       "Allow",
+			},
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "healthCheck", "activeDeadlineSeconds"), int64(0), "must be greater than 0"),
+				field.Invalid(field.NewPath("spec", "healthCheck", "ttlSecondsAfterFinished"), int32(-1), "must be greater than or equal to 0"),
+				field.NotSupported(field.NewPath("spec", "healthCheck", "concurrencyPolicy"), HealthCheckConcurrencyPolicy("Allow"),
+					[]string{"Forbid", "Replace"}),
+			}),
+		Entry("invalid thresholds",
+			&HealthCheck{
+				HTTPGet:          &HealthCheckHTTPGetAction{Path: "/healthz"},
+				FailureThreshold: pointer.Int32(0),
+				SuccessThreshold: pointer.Int32(11),
+			},
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "healthCheck", "failureThreshold"), int32(0), "must be between 1 and 10"),
+				field.Invalid(field.NewPath("spec", "healthCheck", "successThreshold"), int32(11), "must be between 1 and 10"),
+			}),
+		Entry("valid probe",
+			&HealthCheck{HTTPGet: &HealthCheckHTTPGetAction{Path: "/healthz", Scheme: corev1.URISchemeHTTPS}},
+			field.ErrorList{}),
+		Entry("invalid probe",
+			&HealthCheck{HTTPGet: &HealthCheckHTTPGetAction{Path: "healthz", Scheme: "FTP"}},
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "healthCheck", "httpGet", "path"), "healthz", "path must start with /"),
+				field.NotSupported(field.NewPath("spec", "healthCheck", "httpGet", "scheme"), corev1.URIScheme("FTP"),
+					[]string{"HTTP", "HTTPS"}),
+			}),
+		Entry("no check",
+			&HealthCheck{},
+			field.ErrorList{
+				field.Required(field.NewPath("spec", "healthCheck"), "one of container, httpGet, tcpSocket and grpc must be set"),
+			}),
+		Entry("several checks",
+			&HealthCheck{
+				Container: &HealthCheckContainer{Image: "postgres", Command: "pg_isready"},
+				TCPSocket: &HealthCheckTCPSocketAction{},
+			},
+			field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "healthCheck"), "only one of container, httpGet, tcpSocket and grpc can be set"),
+			}),
+	)
+
+	DescribeTable("ThresholdReached",
+		func(hc HealthCheck, passed []bool, expected bool) {
+			history := make([]HealthCheckResult, 0, len(passed))
+			for _, p := range passed {
+				history = append(history, HealthCheckResult{Passed: p})
+			}
+			Expect(hc.ThresholdReached(history)).To(Equal(expected))
+		},
+		Entry("no history", HealthCheck{}, nil, false),
+		Entry("single failure", HealthCheck{}, []bool{true, false}, false),
+		Entry("default failure threshold", HealthCheck{}, []bool{true, false, false, false}, true),
+		Entry("interrupted failures", HealthCheck{}, []bool{false, false, true, false}, false),
+		Entry("custom failure threshold", HealthCheck{FailureThreshold: pointer.Int32(1)}, []bool{true, false}, true),
+		Entry("default success threshold", HealthCheck{}, []bool{false, true}, true),
+		Entry("custom success threshold", HealthCheck{SuccessThreshold: pointer.Int32(2)}, []bool{false, true}, false),
+		Entry("custom success threshold reached", HealthCheck{SuccessThreshold: pointer.Int32(2)}, []bool{false, true, true}, true),
+	)
+
+	DescribeTable("HealthCheckFor",
+		func(environment string, expected string) {
+			spec := ServiceClassSpec{
+				HealthCheck: &HealthCheck{Container: &HealthCheckContainer{Image: "postgres", Command: "pg_isready"}},
+				HealthCheckOverrides: []HealthCheckOverride{
+					{Environments: []string{"prod"}, HealthCheck: HealthCheck{Container: &HealthCheckContainer{Image: "postgres", Command: "psql -c 'select 1'"}}},
+					{Environments: []string{"!dev", "!staging"}, HealthCheck: HealthCheck{TCPSocket: &HealthCheckTCPSocketAction{}}},
+				},
+			}
+			hc := spec.HealthCheckFor(environment)
+			switch {
+			case hc.Container != nil:
+				Expect(hc.Container.Command).To(Equal(expected))
+			default:
+				Expect(hc.TCPSocket).NotTo(BeNil())
+				Expect(expected).To(Equal("tcp"))
+			}
+		},
+		Entry("default", "dev", "pg_isready"),
+		Entry("first matching override", "prod", "psql -c 'select 1'"),
+		Entry("negated override", "prod-us", "tcp"),
+		Entry("excluded environment", "staging", "pg_isready"),
+	)
+
+	It("records the latest health check results", func() {
+		status := RegisteredServiceStatus{}
+		start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < MaxHealthCheckResults+2; i++ {
+			result := HealthCheckResult{Time: metav1.NewTime(start.Add(time.Duration(i) * time.Minute)), Passed: true}
+			Expect(status.RecordHealthCheck(result, "HealthCheckPassed")).To(BeTrue())
+		}
+		Expect(status.HealthChecks).To(HaveLen(MaxHealthCheckResults))
+		Expect(status.HealthChecks[0].Time.Time).To(Equal(start.Add(2 * time.Minute)))
+
+		last := status.HealthChecks[MaxHealthCheckResults-1]
+		Expect(status.RecordHealthCheck(last, "HealthCheckPassed")).To(BeFalse())
+
+		healthy := meta.FindStatusCondition(status.Conditions, RegisteredServiceConditionHealthy)
+		Expect(healthy.Status).To(Equal(metav1.ConditionTrue))
+		since := healthy.LastTransitionTime
+
+		failure := HealthCheckResult{Time: metav1.NewTime(start.Add(time.Hour)), Message: "connection refused"}
+		Expect(status.RecordHealthCheck(failure, "HealthCheckFailed")).To(BeTrue())
+		healthy = meta.FindStatusCondition(status.Conditions, RegisteredServiceConditionHealthy)
+		Expect(healthy.Status).To(Equal(metav1.ConditionFalse))
+		Expect(healthy.Reason).To(Equal("HealthCheckFailed"))
+		Expect(healthy.Message).To(Equal("connection refused"))
+		Expect(healthy.LastTransitionTime.Before(&since)).To(BeFalse())
+	})
+})
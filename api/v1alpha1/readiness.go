@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionReady is the type of the condition every Primaza resource reports
+// its readiness with.  Along with the status' observedGeneration, it follows
+// the conventions GitOps tools like Argo CD and Flux compute the health of
+// resources from.
+const ConditionReady = "Ready"
+
+const (
+	// ReadyReasonPending is the reason of the Ready condition of the
+	// resources that have not been processed yet
+	ReadyReasonPending = "Pending"
+	// ReadyReasonPaused is the reason of the Ready condition of the
+	// ServiceClasses whose registration is paused
+	ReadyReasonPaused = "Paused"
+	// ReadyReasonRegistering is the reason of the Ready condition of the
+	// ServiceClasses whose services are being registered
+	ReadyReasonRegistering = "Registering"
+	// ReadyReasonUnhealthy is the reason of the Ready condition of the
+	// RegisteredServices whose latest health check failed
+	ReadyReasonUnhealthy = "Unhealthy"
+	// ReadyReasonMaintenance is the reason of the Ready condition of the
+	// RegisteredServices in maintenance
+	ReadyReasonMaintenance = "Maintenance"
+)
+
+// setReady sets the Ready condition, observed for the given generation.
+// Its LastTransitionTime is only updated when its status changes.
+func setReady(conditions *[]metav1.Condition, generation int64, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               ConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// UpdateReadiness sets the observed generation of the ServiceClass and its
+// Ready condition: a ServiceClass is ready when its services are registered,
+// i.e. it is neither paused, nor disconnected from the control plane, nor
+// kept from synchronizing by manual edits.
+func (sc *ServiceClass) UpdateReadiness() {
+	sc.Status.ObservedGeneration = sc.Generation
+	connection := meta.FindStatusCondition(sc.Status.Conditions, ServiceClassConditionConnection)
+	override := meta.FindStatusCondition(sc.Status.Conditions, ServiceClassConditionManualOverride)
+	switch {
+	case sc.Spec.Paused:
+		setReady(&sc.Status.Conditions, sc.Generation, metav1.ConditionFalse, ReadyReasonPaused, "registration is paused")
+	case connection != nil && connection.Status == metav1.ConditionFalse:
+		setReady(&sc.Status.Conditions, sc.Generation, metav1.ConditionFalse, connection.Reason, connection.Message)
+	case sc.Spec.ManualEditPolicy == ManualEditPolicyPause && override != nil && override.Status == metav1.ConditionTrue:
+		setReady(&sc.Status.Conditions, sc.Generation, metav1.ConditionFalse, override.Reason, override.Message)
+	default:
+		setReady(&sc.Status.Conditions, sc.Generation, metav1.ConditionTrue, ReadyReasonRegistering, "")
+	}
+}
+
+// UpdateReadiness sets the observed generation of the RegisteredService and
+// its Ready condition: a RegisteredService is ready when it is available or
+// claimed, healthy, and not in maintenance.
+func (rs *RegisteredService) UpdateReadiness() {
+	rs.Status.ObservedGeneration = rs.Generation
+	healthy := meta.FindStatusCondition(rs.Status.Conditions, RegisteredServiceConditionHealthy)
+	switch {
+	case rs.Spec.Maintenance != nil:
+		setReady(&rs.Status.Conditions, rs.Generation, metav1.ConditionFalse, ReadyReasonMaintenance, rs.Spec.Maintenance.Message)
+	case rs.Status.State == "":
+		setReady(&rs.Status.Conditions, rs.Generation, metav1.ConditionUnknown, ReadyReasonPending, "")
+	case rs.Status.State == RegisteredServiceStateUnreachable:
+		setReady(&rs.Status.Conditions, rs.Generation, metav1.ConditionFalse, rs.Status.State, "")
+	case healthy != nil && healthy.Status == metav1.ConditionFalse:
+		setReady(&rs.Status.Conditions, rs.Generation, metav1.ConditionFalse, ReadyReasonUnhealthy, healthy.Message)
+	default:
+		setReady(&rs.Status.Conditions, rs.Generation, metav1.ConditionTrue, rs.Status.State, "")
+	}
+}
+
+// UpdateReadiness sets the observed generation of the ClusterEnvironment and
+// its Ready condition: a ClusterEnvironment is ready when it is online.
+func (ce *ClusterEnvironment) UpdateReadiness() {
+	ce.Status.ObservedGeneration = ce.Generation
+	switch ce.Status.State {
+	case ClusterEnvironmentStateOnline:
+		ce.Status.SetCondition(metav1.Condition{Type: ConditionReady, Status: metav1.ConditionTrue, Reason: string(ce.Status.State)}, ce.Generation)
+	case "":
+		ce.Status.SetCondition(metav1.Condition{Type: ConditionReady, Status: metav1.ConditionUnknown, Reason: ReadyReasonPending}, ce.Generation)
+	default:
+		ce.Status.SetCondition(metav1.Condition{Type: ConditionReady, Status: metav1.ConditionFalse, Reason: string(ce.Status.State), Message: ce.Status.failureMessage()}, ce.Generation)
+	}
+}
+
+// UpdateReadiness sets the observed generation of the ServiceClaim and its
+// Ready condition: a ServiceClaim is ready when it is resolved, and the
+// claimed RegisteredService is available and its application exists.  The
+// Ready condition of claims that are not resolved keeps the reason they are
+// not, if any.
+func (sc *ServiceClaim) UpdateReadiness() {
+	sc.Status.ObservedGeneration = sc.Generation
+	ready := meta.FindStatusCondition(sc.Status.Conditions, ConditionReady)
+	degraded := meta.FindStatusCondition(sc.Status.Conditions, ServiceClaimConditionDegraded)
+	stale := meta.FindStatusCondition(sc.Status.Conditions, ServiceClaimConditionStale)
+	switch {
+	case sc.Status.State == ServiceClaimStateResolved && degraded != nil && degraded.Status == metav1.ConditionTrue:
+		setReady(&sc.Status.Conditions, sc.Generation, metav1.ConditionFalse, ServiceClaimConditionDegraded, degraded.Message)
+	case sc.Status.State == ServiceClaimStateResolved && stale != nil && stale.Status == metav1.ConditionTrue:
+		setReady(&sc.Status.Conditions, sc.Generation, metav1.ConditionFalse, ServiceClaimConditionStale, stale.Message)
+	case sc.Status.State == ServiceClaimStateResolved:
+		setReady(&sc.Status.Conditions, sc.Generation, metav1.ConditionTrue, string(sc.Status.State), "")
+	case ready != nil && ready.Status == metav1.ConditionFalse &&
+		ready.Reason != ServiceClaimConditionDegraded && ready.Reason != ServiceClaimConditionStale:
+		// keep the reason the claim is not resolved, e.g. a validation error
+		ready.ObservedGeneration = sc.Generation
+	default:
+		reason := string(sc.Status.State)
+		if reason == "" {
+			reason = ReadyReasonPending
+		}
+		setReady(&sc.Status.Conditions, sc.Generation, metav1.ConditionFalse, reason, "")
+	}
+}
+
+// UpdateReadiness sets the observed generation of the ServiceBinding and its
+// Ready condition: a ServiceBinding is ready when it is bound to its
+// applications.
+func (sb *ServiceBinding) UpdateReadiness() {
+	sb.Status.ObservedGeneration = sb.Generation
+	switch bound := meta.FindStatusCondition(sb.Status.Conditions, ServiceBindingBoundCondition); {
+	case bound == nil:
+		setReady(&sb.Status.Conditions, sb.Generation, metav1.ConditionUnknown, ReadyReasonPending, "")
+	default:
+		setReady(&sb.Status.Conditions, sb.Generation, bound.Status, bound.Reason, bound.Message)
+	}
+}
+
+// UpdateReadiness sets the observed generation of the ServiceCatalog and its
+// Ready condition: a ServiceCatalog is ready when it is pushed to the
+// application namespaces.
+func (sc *ServiceCatalog) UpdateReadiness() {
+	sc.Status.ObservedGeneration = sc.Generation
+	switch pushed := meta.FindStatusCondition(sc.Status.Conditions, ServiceCatalogConditionPushed); {
+	case pushed == nil:
+		setReady(&sc.Status.Conditions, sc.Generation, metav1.ConditionUnknown, ReadyReasonPending, "")
+	default:
+		setReady(&sc.Status.Conditions, sc.Generation, pushed.Status, pushed.Reason, pushed.Message)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// expectReady checks the Ready condition among the given conditions
+func expectReady(conditions []metav1.Condition, status metav1.ConditionStatus, reason string) {
+	ready := meta.FindStatusCondition(conditions, ConditionReady)
+	Expect(ready).NotTo(BeNil())
+	Expect(ready.Status).To(Equal(status))
+	Expect(ready.Reason).To(Equal(reason))
+	Expect(ready.ObservedGeneration).To(BeEquivalentTo(3))
+}
+
+var _ = Describe("Readiness", func() {
+	DescribeTable("ServiceClass",
+		func(spec ServiceClassSpec, conditions []metav1.Condition, status metav1.ConditionStatus, reason string) {
+			sc := ServiceClass{ObjectMeta: metav1.ObjectMeta{Generation: 3}, Spec: spec, Status: ServiceClassStatus{Conditions: conditions}}
+			sc.UpdateReadiness()
+			Expect(sc.Status.ObservedGeneration).To(BeEquivalentTo(3))
+			expectReady(sc.Status.Conditions, status, reason)
+		},
+		Entry("registering", ServiceClassSpec{}, nil, metav1.ConditionTrue, ReadyReasonRegistering),
+		Entry("paused", ServiceClassSpec{Paused: true}, nil, metav1.ConditionFalse, ReadyReasonPaused),
+		Entry("disconnected", ServiceClassSpec{}, []metav1.Condition{
+			{Type: ServiceClassConditionConnection, Status: metav1.ConditionFalse, Reason: "ClientCreationError"},
+		}, metav1.ConditionFalse, "ClientCreationError"),
+		Entry("manual edits kept", ServiceClassSpec{ManualEditPolicy: ManualEditPolicyWarn}, []metav1.Condition{
+			{Type: ServiceClassConditionManualOverride, Status: metav1.ConditionTrue, Reason: "ManualEditsKept"},
+		}, metav1.ConditionTrue, ReadyReasonRegistering),
+		Entry("synchronization paused", ServiceClassSpec{ManualEditPolicy: ManualEditPolicyPause}, []metav1.Condition{
+			{Type: ServiceClassConditionManualOverride, Status: metav1.ConditionTrue, Reason: "SyncPaused"},
+		}, metav1.ConditionFalse, "SyncPaused"),
+	)
+
+	DescribeTable("RegisteredService",
+		func(rs RegisteredService, status metav1.ConditionStatus, reason string) {
+			rs.Generation = 3
+			rs.UpdateReadiness()
+			Expect(rs.Status.ObservedGeneration).To(BeEquivalentTo(3))
+			expectReady(rs.Status.Conditions, status, reason)
+		},
+		Entry("pending", RegisteredService{}, metav1.ConditionUnknown, ReadyReasonPending),
+		Entry("available", RegisteredService{Status: RegisteredServiceStatus{State: RegisteredServiceStateAvailable}},
+			metav1.ConditionTrue, RegisteredServiceStateAvailable),
+		Entry("unreachable", RegisteredService{Status: RegisteredServiceStatus{State: RegisteredServiceStateUnreachable}},
+			metav1.ConditionFalse, RegisteredServiceStateUnreachable),
+		Entry("unhealthy", RegisteredService{Status: RegisteredServiceStatus{
+			State: RegisteredServiceStateClaimed,
+			Conditions: []metav1.Condition{
+				{Type: RegisteredServiceConditionHealthy, Status: metav1.ConditionFalse, Reason: "HealthCheckFailed"},
+			},
+		}}, metav1.ConditionFalse, ReadyReasonUnhealthy),
+		Entry("in maintenance", RegisteredService{
+			Spec:   RegisteredServiceSpec{Maintenance: &Maintenance{}},
+			Status: RegisteredServiceStatus{State: RegisteredServiceStateAvailable},
+		}, metav1.ConditionFalse, ReadyReasonMaintenance),
+	)
+
+	DescribeTable("ClusterEnvironment",
+		func(status ClusterEnvironmentStatus, ready metav1.ConditionStatus, reason string) {
+			ce := ClusterEnvironment{ObjectMeta: metav1.ObjectMeta{Generation: 3}, Status: status}
+			ce.UpdateReadiness()
+			Expect(ce.Status.ObservedGeneration).To(BeEquivalentTo(3))
+			expectReady(ce.Status.Conditions, ready, reason)
+			Expect(ce.Status.PruneConditions()).To(BeFalse())
+		},
+		Entry("online", ClusterEnvironmentStatus{State: ClusterEnvironmentStateOnline}, metav1.ConditionTrue, "Online"),
+		Entry("partial", ClusterEnvironmentStatus{State: ClusterEnvironmentStatePartial}, metav1.ConditionFalse, "Partial"),
+		Entry("offline", ClusterEnvironmentStatus{State: ClusterEnvironmentStateOffline}, metav1.ConditionFalse, "Offline"),
+	)
+
+	DescribeTable("ServiceClaim",
+		func(status ServiceClaimStatus, ready metav1.ConditionStatus, reason string) {
+			sc := ServiceClaim{ObjectMeta: metav1.ObjectMeta{Generation: 3}, Status: status}
+			sc.UpdateReadiness()
+			Expect(sc.Status.ObservedGeneration).To(BeEquivalentTo(3))
+			expectReady(sc.Status.Conditions, ready, reason)
+		},
+		Entry("new", ServiceClaimStatus{}, metav1.ConditionFalse, ReadyReasonPending),
+		Entry("pending", ServiceClaimStatus{
+			State: ServiceClaimStatePending,
+			Conditions: []metav1.Condition{
+				{Type: ServiceClaimConditionReady, Status: metav1.ConditionFalse, Reason: "NoMatchingServiceFound"},
+			},
+		}, metav1.ConditionFalse, "NoMatchingServiceFound"),
+		Entry("resolved", ServiceClaimStatus{State: ServiceClaimStateResolved}, metav1.ConditionTrue, string(ServiceClaimStateResolved)),
+		Entry("degraded", ServiceClaimStatus{
+			State: ServiceClaimStateResolved,
+			Conditions: []metav1.Condition{
+				{Type: ServiceClaimConditionDegraded, Status: metav1.ConditionTrue},
+			},
+		}, metav1.ConditionFalse, ServiceClaimConditionDegraded),
+		Entry("pending after being degraded", ServiceClaimStatus{
+			State: ServiceClaimStatePending,
+			Conditions: []metav1.Condition{
+				{Type: ServiceClaimConditionReady, Status: metav1.ConditionFalse, Reason: ServiceClaimConditionDegraded},
+			},
+		}, metav1.ConditionFalse, string(ServiceClaimStatePending)),
+	)
+
+	It("reports whether ServiceBindings are bound", func() {
+		sb := ServiceBinding{ObjectMeta: metav1.ObjectMeta{Generation: 3}}
+		sb.UpdateReadiness()
+		expectReady(sb.Status.Conditions, metav1.ConditionUnknown, ReadyReasonPending)
+
+		meta.SetStatusCondition(&sb.Status.Conditions, metav1.Condition{Type: ServiceBindingBoundCondition, Status: metav1.ConditionFalse, Reason: "NoApplicationFound"})
+		sb.UpdateReadiness()
+		expectReady(sb.Status.Conditions, metav1.ConditionFalse, "NoApplicationFound")
+	})
+
+	It("reports whether ServiceCatalogs are pushed", func() {
+		sc := ServiceCatalog{ObjectMeta: metav1.ObjectMeta{Generation: 3}}
+		meta.SetStatusCondition(&sc.Status.Conditions, metav1.Condition{Type: ServiceCatalogConditionPushed, Status: metav1.ConditionTrue, Reason: "Pushed"})
+		sc.UpdateReadiness()
+		Expect(sc.Status.ObservedGeneration).To(BeEquivalentTo(3))
+		expectReady(sc.Status.Conditions, metav1.ConditionTrue, "Pushed")
+	})
+})
@@ -18,6 +18,10 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/primaza/primaza/pkg/envtag"
+	"github.com/primaza/primaza/pkg/slices"
 )
 
 // RegisteredServiceConstraints defines constrains to be honored when determining
@@ -25,6 +29,41 @@ import (
 type RegisteredServiceConstraints struct {
 	// Environments defines in which environments the RegisteredService may be used.
 	Environments []string `json:"environments,omitempty"`
+
+	// EnvironmentSelector is a label selector expression restricting the
+	// environments the RegisteredService may be used in to the ones with a
+	// ClusterEnvironment whose Topology labels and Labels match it (e.g.
+	// `topology.kubernetes.io/region in (eu-west-1,eu-central-1),!deprecated`).
+	// +optional
+	EnvironmentSelector string `json:"environmentSelector,omitempty"`
+}
+
+// Allows reports whether the constraints allow the RegisteredService to be
+// used in the given environment, whose ClusterEnvironments are looked up in
+// the given ones.  Nil constraints allow every environment.
+func (c *RegisteredServiceConstraints) Allows(environment string, clusterEnvironments []ClusterEnvironment) bool {
+	if c == nil {
+		return true
+	}
+	if !envtag.Match(environment, c.Environments) {
+		return false
+	}
+	if c.EnvironmentSelector == "" {
+		return true
+	}
+
+	sel, err := labels.Parse(c.EnvironmentSelector)
+	if err != nil {
+		// invalid selectors are rejected by the webhook
+		return false
+	}
+	for i := range clusterEnvironments {
+		ce := &clusterEnvironments[i]
+		if ce.Spec.EnvironmentName == environment && sel.Matches(ce.SelectorLabels()) {
+			return true
+		}
+	}
+	return false
 }
 
 // ServiceEndpointDefinitionSecretRef defines a reference to
@@ -57,6 +96,9 @@ type ServiceEndpointDefinitionItem struct {
 
 // RegisteredServiceSpec defines the desired state of RegisteredService
 type RegisteredServiceSpec struct {
+	// ServiceMetadata describes the service to developers
+	ServiceMetadata `json:",inline"`
+
 	// Constraints defines under which circumstances the RegisteredService may
 	// be used.
 	// +optional
@@ -70,6 +112,30 @@ type RegisteredServiceSpec struct {
 	// +optional
 	SLA string `json:"sla,omitempty"`
 
+	// Priority of the service over the other services matching the same
+	// claims, the higher the preferred.  Used by the claims ranking matching
+	// services by `Priority`.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// SharingPolicy defines whether the service can be claimed by a single
+	// ServiceClaim at a time, `Exclusive`, e.g. a dedicated database, or by
+	// several ones, `Shared`, e.g. a cache.  Defaults to `Exclusive`.
+	// +optional
+	SharingPolicy RegisteredServiceSharingPolicy `json:"sharingPolicy,omitempty"`
+
+	// MaxClaims limits the number of ServiceClaims a `Shared` service can be
+	// claimed by at once.  Unlimited if not set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxClaims int32 `json:"maxClaims,omitempty"`
+
+	// Maintenance, when set, puts the service in maintenance: it is removed
+	// from the ServiceCatalogs and can not be claimed any more, while the
+	// ServiceClaims that already claim it keep their bindings.
+	// +optional
+	Maintenance *Maintenance `json:"maintenance,omitempty"`
+
 	// ServiceClassIdentity defines a set of attributes that are sufficient to
 	// identify a service class.  A ServiceClaim whose ServiceClassIdentity
 	// field is a subset of a RegisteredService's keys can claim that service.
@@ -80,16 +146,110 @@ type RegisteredServiceSpec struct {
 	ServiceEndpointDefinition []ServiceEndpointDefinitionItem `json:"serviceEndpointDefinition"`
 }
 
+// RegisteredServiceSharingPolicy defines how many ServiceClaims can claim a
+// RegisteredService at once
+// +kubebuilder:validation:Enum=Exclusive;Shared
+type RegisteredServiceSharingPolicy string
+
+const (
+	RegisteredServiceSharingPolicyExclusive RegisteredServiceSharingPolicy = "Exclusive"
+	RegisteredServiceSharingPolicyShared    RegisteredServiceSharingPolicy = "Shared"
+)
+
+// Capacity returns the number of ServiceClaims the service can be claimed by
+// at once, or 0 if it is unlimited
+func (s *RegisteredServiceSpec) Capacity() int32 {
+	if s.SharingPolicy != RegisteredServiceSharingPolicyShared {
+		return 1
+	}
+	return s.MaxClaims
+}
+
+// Maintenance describes the maintenance of a RegisteredService
+type Maintenance struct {
+	// Message explains the maintenance to the users of the service, e.g.
+	// `migrating to PostgreSQL 15`.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Drain signals the applications bound to the service that they should
+	// stop using it: their ServiceClaims get the Draining condition and a
+	// warning event is recorded on their ServiceBindings.
+	// +optional
+	Drain bool `json:"drain,omitempty"`
+}
+
+// HealthCheckResult records an execution of a health check
+type HealthCheckResult struct {
+	// Time the health check started at
+	Time metav1.Time `json:"time"`
+	// Passed reports whether the health check passed
+	Passed bool `json:"passed"`
+	// Duration of the health check
+	// +optional
+	Duration metav1.Duration `json:"duration,omitempty"`
+	// Message explaining the result, e.g. why the health check failed
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // RegisteredServiceStatus defines the observed state of RegisteredService.
 type RegisteredServiceStatus struct {
 	// State describes the current state of the service.
 	// +optional
 	State string `json:"state,omitempty"`
+
+	// LastClaimedTime is the last time the service has been claimed or released.
+	// +optional
+	LastClaimedTime *metav1.Time `json:"lastClaimedTime,omitempty"`
+
+	// ClaimedBy is the latest ServiceClaim the service is claimed by, e.g.
+	// `ServiceClaim/mydb`.
+	// +optional
+	ClaimedBy string `json:"claimedBy,omitempty"`
+
+	// Claims lists the names of the ServiceClaims the service is claimed by.
+	// +optional
+	Claims []string `json:"claims,omitempty"`
+
+	// IdleSince is set when the service has been available without being claimed
+	// for longer than the configured idle period, and it reports since when the
+	// service is not claimed.
+	// +optional
+	IdleSince *metav1.Time `json:"idleSince,omitempty"`
+
+	// Transitions records the latest changes of state of the service.
+	// +optional
+	Transitions []StateTransition `json:"transitions,omitempty"`
+
+	// HealthChecks records the latest executions of the health check.
+	// +optional
+	HealthChecks []HealthCheckResult `json:"healthChecks,omitempty"`
+
+	// Conditions of the service.  The Healthy condition reports the result
+	// of the latest health check.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of the RegisteredService the status
+	// was last computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Summary describes the status at a glance.
+	// +optional
+	Summary string `json:"summary,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:storageversion
 //+kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state",description="the state of the RegisteredService"
+//+kubebuilder:printcolumn:name="Environments",type="string",JSONPath=".spec.constraints.environments",description="the environments the RegisteredService may be used in"
+//+kubebuilder:printcolumn:name="Claimed By",type="string",JSONPath=".status.claimedBy",description="the ServiceClaim the RegisteredService is claimed by"
+//+kubebuilder:printcolumn:name="Summary",type="string",JSONPath=".status.summary",description="the status of the RegisteredService at a glance"
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // RegisteredService is the Schema for the registeredservices API.
@@ -101,6 +261,54 @@ type RegisteredService struct {
 	Status RegisteredServiceStatus `json:"status,omitempty"`
 }
 
+// AcceptsClaim reports whether the named ServiceClaim can claim the service:
+// either it already does, or the service is reachable, not in maintenance
+// and it is claimed by fewer ServiceClaims than its capacity
+func (rs *RegisteredService) AcceptsClaim(claim string) bool {
+	if slices.ItemContains(rs.Status.Claims, claim) {
+		return true
+	}
+	if rs.Status.State == RegisteredServiceStateUnreachable || rs.Spec.Maintenance != nil {
+		return false
+	}
+	capacity := rs.Spec.Capacity()
+	return capacity == 0 || int32(len(rs.Status.Claims)) < capacity
+}
+
+// Listed reports whether the service is listed in the ServiceCatalogs, i.e.
+// whether it has been registered and accepts new claims
+func (rs *RegisteredService) Listed() bool {
+	switch rs.Status.State {
+	case RegisteredServiceStateAvailable, RegisteredServiceStateClaimed:
+		return rs.AcceptsClaim("")
+	}
+	return false
+}
+
+// AddClaim records that the service is claimed by the named ServiceClaim
+func (s *RegisteredServiceStatus) AddClaim(claim string) {
+	if !slices.ItemContains(s.Claims, claim) {
+		s.Claims = append(s.Claims, claim)
+	}
+	s.ClaimedBy = "ServiceClaim/" + claim
+}
+
+// RemoveClaim records that the service is not claimed by the named
+// ServiceClaim any more
+func (s *RegisteredServiceStatus) RemoveClaim(claim string) {
+	claims := s.Claims[:0]
+	for _, c := range s.Claims {
+		if c != claim {
+			claims = append(claims, c)
+		}
+	}
+	s.Claims = claims
+	s.ClaimedBy = ""
+	if l := len(s.Claims); l > 0 {
+		s.ClaimedBy = "ServiceClaim/" + s.Claims[l-1]
+	}
+}
+
 const (
 	RegisteredServiceStateAvailable   string = "Available"
 	RegisteredServiceStateUnreachable string = "Unreachable"
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RegisteredService claims", func() {
+	claimed := func(spec RegisteredServiceSpec, claims ...string) *RegisteredService {
+		rs := &RegisteredService{Spec: spec, Status: RegisteredServiceStatus{State: RegisteredServiceStateAvailable}}
+		for _, c := range claims {
+			rs.Status.AddClaim(c)
+			rs.Status.State = RegisteredServiceStateClaimed
+		}
+		return rs
+	}
+
+	It("accepts a single claim by default", func() {
+		rs := claimed(RegisteredServiceSpec{}, "spam")
+		Expect(rs.AcceptsClaim("spam")).To(BeTrue())
+		Expect(rs.AcceptsClaim("eggs")).To(BeFalse())
+	})
+
+	It("accepts claims up to the capacity of shared services", func() {
+		spec := RegisteredServiceSpec{SharingPolicy: RegisteredServiceSharingPolicyShared, MaxClaims: 2}
+		Expect(claimed(spec, "spam").AcceptsClaim("eggs")).To(BeTrue())
+		Expect(claimed(spec, "spam", "eggs").AcceptsClaim("ham")).To(BeFalse())
+
+		spec.MaxClaims = 0
+		Expect(claimed(spec, "spam", "eggs").AcceptsClaim("ham")).To(BeTrue())
+	})
+
+	It("does not accept new claims while unreachable", func() {
+		rs := claimed(RegisteredServiceSpec{SharingPolicy: RegisteredServiceSharingPolicyShared}, "spam")
+		rs.Status.State = RegisteredServiceStateUnreachable
+		Expect(rs.AcceptsClaim("spam")).To(BeTrue())
+		Expect(rs.AcceptsClaim("eggs")).To(BeFalse())
+	})
+
+	It("does not accept nor list new claims in maintenance", func() {
+		rs := claimed(RegisteredServiceSpec{SharingPolicy: RegisteredServiceSharingPolicyShared}, "spam")
+		Expect(rs.Listed()).To(BeTrue())
+
+		rs.Spec.Maintenance = &Maintenance{Drain: true}
+		Expect(rs.AcceptsClaim("spam")).To(BeTrue())
+		Expect(rs.AcceptsClaim("eggs")).To(BeFalse())
+		Expect(rs.Listed()).To(BeFalse())
+	})
+
+	It("tracks the claims the service is claimed by", func() {
+		rs := claimed(RegisteredServiceSpec{SharingPolicy: RegisteredServiceSharingPolicyShared}, "spam", "eggs", "spam")
+		Expect(rs.Status.Claims).To(Equal([]string{"spam", "eggs"}))
+		Expect(rs.Status.ClaimedBy).To(Equal("ServiceClaim/spam"))
+
+		rs.Status.RemoveClaim("spam")
+		Expect(rs.Status.Claims).To(Equal([]string{"eggs"}))
+		Expect(rs.Status.ClaimedBy).To(Equal("ServiceClaim/eggs"))
+
+		rs.Status.RemoveClaim("eggs")
+		Expect(rs.Status.Claims).To(BeEmpty())
+		Expect(rs.Status.ClaimedBy).To(BeEmpty())
+	})
+})
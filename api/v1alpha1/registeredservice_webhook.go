@@ -0,0 +1,210 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/primaza/primaza/pkg/envtag"
+)
+
+// log is for logging in this package.
+var registeredservicelog = logf.Log.WithName("registeredservice-resource")
+
+type registeredServiceValidator struct {
+	client client.Client
+}
+
+var _ admission.CustomValidator = &registeredServiceValidator{}
+var _ admissionWarner = &registeredServiceValidator{}
+
+func (r *RegisteredService) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	v := &registeredServiceValidator{
+		client: mgr.GetClient(),
+	}
+	mgr.GetWebhookServer().Register("/validate-primaza-io-v1alpha1-registeredservice", withWarnings(r, v, v))
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-primaza-io-v1alpha1-registeredservice,mutating=false,failurePolicy=fail,sideEffects=None,groups=primaza.io,resources=registeredservices,verbs=create;update,versions=v1alpha1,name=vregisteredservice.kb.io,admissionReviewVersions=v1
+
+// ValidateEnvironmentConstraints checks that every environment constraint is
+// either an environment name pattern or a negated one (e.g. `!prod-*`)
+func ValidateEnvironmentConstraints(path *field.Path, environments []string) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, e := range environments {
+		env := strings.TrimPrefix(e, envtag.NegativeConstraintSymbol)
+		switch {
+		case env == "":
+			errs = append(errs, field.Invalid(path.Index(i), e, "Environment can not be empty"))
+		case strings.HasPrefix(env, envtag.NegativeConstraintSymbol):
+			errs = append(errs, field.Invalid(path.Index(i), e, "Environment can be negated only once"))
+		case strings.ContainsAny(env, " \t\n"):
+			errs = append(errs, field.Invalid(path.Index(i), e, "Environment can not contain whitespaces"))
+		case e == envtag.NegativeConstraintSymbol+envtag.WildcardSymbol:
+			errs = append(errs, field.Invalid(path.Index(i), e, "Environment pattern can not exclude every environment"))
+		default:
+			if err := envtag.ValidatePattern(env); err != nil {
+				errs = append(errs, field.Invalid(path.Index(i), e, fmt.Sprintf("Invalid environment pattern: %s", err)))
+			}
+		}
+	}
+	return errs
+}
+
+func (r *RegisteredService) validate() field.ErrorList {
+	errs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if len(r.Spec.ServiceClassIdentity) == 0 {
+		errs = append(errs, field.Required(specPath.Child("serviceClassIdentity"), "ServiceClassIdentity can not be empty"))
+	}
+
+	names := map[string]struct{}{}
+	for i, sed := range r.Spec.ServiceEndpointDefinition {
+		if _, found := names[sed.Name]; found {
+			errs = append(errs, field.Duplicate(specPath.Child("serviceEndpointDefinition").Index(i).Child("name"), sed.Name))
+		} else {
+			names[sed.Name] = struct{}{}
+		}
+	}
+
+	if c := r.Spec.Constraints; c != nil {
+		errs = append(errs, ValidateEnvironmentConstraints(specPath.Child("constraints", "environments"), c.Environments)...)
+		if _, err := labels.Parse(c.EnvironmentSelector); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("constraints", "environmentSelector"), c.EnvironmentSelector, fmt.Sprintf("Invalid environment selector: %s", err)))
+		}
+	}
+	errs = append(errs, r.Spec.HealthCheck.Validate(specPath.Child("healthcheck"))...)
+
+	if r.Spec.MaxClaims != 0 && r.Spec.SharingPolicy != RegisteredServiceSharingPolicyShared {
+		errs = append(errs, field.Invalid(specPath.Child("maxClaims"), r.Spec.MaxClaims, "MaxClaims can only be set on Shared services"))
+	}
+
+	return errs
+}
+
+func (v *registeredServiceValidator) validate(ctx context.Context, r *RegisteredService) error {
+	errs := r.validate()
+	required, _, err := v.healthCheckPolicies(ctx, r)
+	if err != nil {
+		return err
+	}
+	for _, ce := range required {
+		errs = append(errs, field.Required(field.NewPath("spec", "healthcheck"),
+			fmt.Sprintf("ClusterEnvironment %s requires a health check for the services usable in environment %s", ce.Name, ce.Spec.EnvironmentName)))
+	}
+	return errs.ToAggregate()
+}
+
+// healthCheckPolicies returns the ClusterEnvironments whose HealthCheckPolicy
+// respectively requires or recommends a health check for the given
+// RegisteredService.  Only the ClusterEnvironments whose environment
+// satisfies the RegisteredService's constraints are returned.
+func (v *registeredServiceValidator) healthCheckPolicies(ctx context.Context, r *RegisteredService) ([]ClusterEnvironment, []ClusterEnvironment, error) {
+	if r.Spec.HealthCheck != nil {
+		return nil, nil, nil
+	}
+
+	cel := ClusterEnvironmentList{}
+	if err := v.client.List(ctx, &cel, client.InNamespace(r.Namespace)); err != nil {
+		return nil, nil, err
+	}
+
+	var required, recommended []ClusterEnvironment
+	for _, ce := range cel.Items {
+		if !r.Spec.Constraints.Allows(ce.Spec.EnvironmentName, cel.Items) {
+			continue
+		}
+		switch ce.Spec.HealthCheckPolicy {
+		case HealthCheckPolicyRequire:
+			required = append(required, ce)
+		case HealthCheckPolicyWarn:
+			recommended = append(recommended, ce)
+		}
+	}
+	return required, recommended, nil
+}
+
+// Warnings implements admissionWarner
+func (v *registeredServiceValidator) Warnings(ctx context.Context, obj runtime.Object) []string {
+	r, ok := obj.(*RegisteredService)
+	if !ok {
+		return nil
+	}
+
+	_, recommended, err := v.healthCheckPolicies(ctx, r)
+	if err != nil {
+		registeredservicelog.Error(err, "Failed to check health check policies", "name", r.Name)
+		return nil
+	}
+	var warnings []string
+	for _, ce := range recommended {
+		warnings = append(warnings, fmt.Sprintf("spec.healthcheck: ClusterEnvironment %s recommends a health check for the services usable in environment %s",
+			ce.Name, ce.Spec.EnvironmentName))
+	}
+	return warnings
+}
+
+// ValidateCreate implements admission.CustomValidator
+func (v *registeredServiceValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	r, ok := obj.(*RegisteredService)
+	if !ok {
+		err := fmt.Errorf("Object is not a Registered Service")
+		registeredservicelog.Error(err, "Attempted to validate non-RegisteredService resource", "gvk", obj.GetObjectKind().GroupVersionKind())
+		return err
+	}
+
+	registeredservicelog.Info("validate create", "name", r.Name)
+	return v.validate(ctx, r)
+}
+
+// ValidateUpdate implements admission.CustomValidator
+func (v *registeredServiceValidator) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) error {
+	r, ok := newObj.(*RegisteredService)
+	if !ok {
+		err := fmt.Errorf("Object is not a Registered Service")
+		registeredservicelog.Error(err, "Attempted to validate non-RegisteredService resource", "gvk", newObj.GetObjectKind().GroupVersionKind())
+		return err
+	}
+
+	registeredservicelog.Info("validate update", "name", r.Name)
+	return v.validate(ctx, r)
+}
+
+// ValidateDelete implements admission.CustomValidator
+func (v *registeredServiceValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	r, ok := obj.(*RegisteredService)
+	if !ok {
+		err := fmt.Errorf("Object is not a Registered Service")
+		registeredservicelog.Error(err, "Attempted to validate non-RegisteredService resource", "gvk", obj.GetObjectKind().GroupVersionKind())
+		return err
+	}
+
+	registeredservicelog.Info("validate delete", "name", r.Name)
+	return nil // no validation
+}
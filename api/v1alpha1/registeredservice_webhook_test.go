@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newRegisteredService(name, namespace string, spec RegisteredServiceSpec) RegisteredService {
+	return RegisteredService{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: spec,
+	}
+}
+
+var _ = Describe("RegisteredService Webhook tests", func() {
+	var validator registeredServiceValidator
+	BeforeEach(func() {
+		schemeBuilder, err := SchemeBuilder.Build()
+		Expect(err).NotTo(HaveOccurred())
+
+		validator = registeredServiceValidator{
+			client: fake.NewClientBuilder().
+				WithScheme(schemeBuilder).
+				Build(),
+		}
+	})
+
+	sci := []ServiceClassIdentityItem{{Name: "type", Value: "psql"}}
+
+	DescribeTable("Creation validation",
+		func(rs RegisteredService, expected error) {
+			err := validator.ValidateCreate(context.Background(), &rs)
+			if expected == nil {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(Equal(expected))
+			}
+		},
+		Entry("Valid service",
+			newRegisteredService("spam", "eggs",
+				RegisteredServiceSpec{
+					ServiceClassIdentity: sci,
+					ServiceEndpointDefinition: []ServiceEndpointDefinitionItem{
+						{Name: "host", Value: "localhost"},
+						{Name: "port", Value: "5432"},
+					},
+					Constraints: &RegisteredServiceConstraints{
+						Environments: []string{"dev", "!prod"},
+					},
+				},
+			),
+			nil),
+		Entry("Empty ServiceClassIdentity",
+			newRegisteredService("spam", "eggs", RegisteredServiceSpec{}),
+			field.ErrorList{
+				field.Required(field.NewPath("spec", "serviceClassIdentity"), "ServiceClassIdentity can not be empty"),
+			}.ToAggregate()),
+		Entry("Duplicate ServiceEndpointDefinition names",
+			newRegisteredService("spam", "eggs",
+				RegisteredServiceSpec{
+					ServiceClassIdentity: sci,
+					ServiceEndpointDefinition: []ServiceEndpointDefinitionItem{
+						{Name: "host", Value: "localhost"},
+						{Name: "host", Value: "127.0.0.1"},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Duplicate(field.NewPath("spec", "serviceEndpointDefinition").Index(1).Child("name"), "host"),
+			}.ToAggregate()),
+		Entry("Malformed environment constraints",
+			newRegisteredService("spam", "eggs",
+				RegisteredServiceSpec{
+					ServiceClassIdentity: sci,
+					Constraints: &RegisteredServiceConstraints{
+						Environments: []string{"!", "!!prod", "my env"},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "constraints", "environments").Index(0), "!", "Environment can not be empty"),
+				field.Invalid(field.NewPath("spec", "constraints", "environments").Index(1), "!!prod", "Environment can be negated only once"),
+				field.Invalid(field.NewPath("spec", "constraints", "environments").Index(2), "my env", "Environment can not contain whitespaces"),
+			}.ToAggregate()),
+		Entry("Environment constraint patterns",
+			newRegisteredService("spam", "eggs",
+				RegisteredServiceSpec{
+					ServiceClassIdentity: sci,
+					Constraints: &RegisteredServiceConstraints{
+						Environments: []string{"dev-*", "!*-restricted", "!*", "dev-?"},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "constraints", "environments").Index(2), "!*", "Environment pattern can not exclude every environment"),
+				field.Invalid(field.NewPath("spec", "constraints", "environments").Index(3), "dev-?", "Invalid environment pattern: only the * wildcard is supported"),
+			}.ToAggregate()),
+		Entry("Valid environment selector",
+			newRegisteredService("spam", "eggs",
+				RegisteredServiceSpec{
+					ServiceClassIdentity: sci,
+					Constraints: &RegisteredServiceConstraints{
+						EnvironmentSelector: "topology.kubernetes.io/region in (eu-west-1,eu-central-1),!deprecated",
+					},
+				},
+			),
+			nil),
+		Entry("Malformed environment selector",
+			newRegisteredService("spam", "eggs",
+				RegisteredServiceSpec{
+					ServiceClassIdentity: sci,
+					Constraints: &RegisteredServiceConstraints{
+						EnvironmentSelector: "region in eu-west-1",
+					},
+				},
+			),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "constraints", "environmentSelector"), "region in eu-west-1",
+					"Invalid environment selector: unable to parse requirement: found 'eu-west-1' expected: '('"),
+			}.ToAggregate()),
+		Entry("MaxClaims on an exclusive service",
+			newRegisteredService("spam", "eggs",
+				RegisteredServiceSpec{
+					ServiceClassIdentity: sci,
+					MaxClaims:            2,
+				},
+			),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "maxClaims"), int32(2), "MaxClaims can only be set on Shared services"),
+			}.ToAggregate()),
+	)
+
+	It("should enforce the health check policies of the environments the service can be used in", func() {
+		schemeBuilder, err := SchemeBuilder.Build()
+		Expect(err).NotTo(HaveOccurred())
+
+		newClusterEnvironment := func(name, environment string, policy HealthCheckPolicy) *ClusterEnvironment {
+			return &ClusterEnvironment{
+				ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "eggs"},
+				Spec: ClusterEnvironmentSpec{
+					EnvironmentName:   environment,
+					HealthCheckPolicy: policy,
+				},
+			}
+		}
+		validator = registeredServiceValidator{
+			client: fake.NewClientBuilder().
+				WithScheme(schemeBuilder).
+				WithObjects(
+					newClusterEnvironment("prod-cluster", "prod", HealthCheckPolicyRequire),
+					newClusterEnvironment("stage-cluster", "stage", HealthCheckPolicyWarn),
+					newClusterEnvironment("dev-cluster", "dev", ""),
+				).
+				Build(),
+		}
+
+		rs := newRegisteredService("spam", "eggs", RegisteredServiceSpec{ServiceClassIdentity: sci})
+		Expect(validator.ValidateCreate(context.Background(), &rs)).To(Equal(field.ErrorList{
+			field.Required(field.NewPath("spec", "healthcheck"),
+				"ClusterEnvironment prod-cluster requires a health check for the services usable in environment prod"),
+		}.ToAggregate()))
+
+		rs.Spec.Constraints = &RegisteredServiceConstraints{Environments: []string{"!prod"}}
+		Expect(validator.ValidateCreate(context.Background(), &rs)).To(Succeed())
+		Expect(validator.Warnings(context.Background(), &rs)).To(Equal([]string{
+			"spec.healthcheck: ClusterEnvironment stage-cluster recommends a health check for the services usable in environment stage",
+		}))
+
+		rs.Spec.Constraints = nil
+		rs.Spec.HealthCheck = &HealthCheck{Container: &HealthCheckContainer{Image: "postgres", Command: "pg_isready"}}
+		Expect(validator.ValidateCreate(context.Background(), &rs)).To(Succeed())
+		Expect(validator.Warnings(context.Background(), &rs)).To(BeEmpty())
+	})
+})
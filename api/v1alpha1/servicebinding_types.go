@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -40,6 +42,59 @@ type ServiceBindingSpec struct {
 
 	// Env creates environment variables based on the Secret values
 	Env []Environment `json:"env,omitempty"`
+
+	// ProjectedKeys restricts the keys of the Secret that are projected as
+	// files into the application.  All the keys are projected when empty.
+	// +optional
+	ProjectedKeys []string `json:"projectedKeys,omitempty"`
+
+	// ReachabilityCheck, when set, makes the application agent verify that
+	// the service endpoint can be reached from the application namespace
+	// before marking the ServiceBinding Ready
+	// +optional
+	ReachabilityCheck *ReachabilityCheck `json:"reachabilityCheck,omitempty"`
+
+	// Vault, when set, makes the application agent annotate the application
+	// so that the Vault agent injector projects the given keys of a Vault
+	// secret next to the ones of the Secret
+	// +optional
+	Vault *ServiceBindingVault `json:"vault,omitempty"`
+}
+
+// ServiceBindingVault defines the Vault secret the Vault agent injector
+// projects into the application
+type ServiceBindingVault struct {
+	// Role of the Kubernetes auth method the Vault agent authenticates with
+	Role string `json:"role"`
+
+	// SecretPath is the path the Vault agent reads the secret from (e.g.
+	// `secret/data/primaza/apps/db`)
+	SecretPath string `json:"secretPath"`
+
+	// Keys of the secret to project as files into the application
+	Keys []string `json:"keys"`
+}
+
+// ReachabilityCheck defines which Secret keys hold the address of the
+// service, and how long to wait for a connection to it
+type ReachabilityCheck struct {
+	HealthCheckEndpoint `json:",inline"`
+
+	// Timeout of the connection attempt.  Defaults to 5 seconds.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// DefaultReachabilityCheckTimeout is the time the application agent waits
+// for a connection to the service endpoint
+const DefaultReachabilityCheckTimeout = 5 * time.Second
+
+// RunTimeout returns the timeout of the connection attempt
+func (c *ReachabilityCheck) RunTimeout() time.Duration {
+	if c.Timeout == nil {
+		return DefaultReachabilityCheckTimeout
+	}
+	return c.Timeout.Duration
 }
 
 // Environment represents a key to Secret data keys and name of the environment variable
@@ -60,6 +115,9 @@ const (
 	// projected the secret into the Workload.
 	// As an example, this will occur when the secret to be bound is not found
 	ServiceBindingNotBoundCondition = "NotBound"
+	// ServiceBindingReachableCondition reports whether the service endpoint
+	// can be reached from the application namespace
+	ServiceBindingReachableCondition = "Reachable"
 )
 
 // ServiceBindingStatus defines the observed state of ServiceBinding.
@@ -67,8 +125,15 @@ const (
 type ServiceBindingStatus struct {
 	// The status of the service binding along with reason and type
 	// +optional
+	// +listType=map
+	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
+	// ObservedGeneration is the generation of the ServiceBinding the status
+	// was last computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// +kubebuilder:validation:Enum=Ready;Malformed
 	// The state of the service binding observed
 	// +kubebuilder:default:=Malformed
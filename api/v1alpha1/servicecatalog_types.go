@@ -24,6 +24,9 @@ type ServiceCatalogService struct {
 	// Name defines the name of the known service
 	Name string `json:"name"`
 
+	// ServiceMetadata describes the service to developers
+	ServiceMetadata `json:",inline"`
+
 	// ServiceClassIdentity defines a set of attributes that are sufficient to
 	// identify a service class.  A ServiceClaim whose ServiceClassIdentity
 	// field is a subset of a RegisteredService's keys can claim that service.
@@ -34,21 +37,57 @@ type ServiceCatalogService struct {
 	ServiceEndpointDefinitionKeys []string `json:"serviceEndpointDefinitionKeys"`
 }
 
+// ServiceCatalogFilter selects the services of a catalog by their
+// ServiceClassIdentity
+type ServiceCatalogFilter struct {
+	// ServiceClassIdentity lists the attributes a service's
+	// ServiceClassIdentity must all contain to match the filter, e.g. only
+	// `type=postgresql`
+	// +kubebuilder:validation:MinItems=1
+	ServiceClassIdentity []ServiceClassIdentityItem `json:"serviceClassIdentity"`
+}
+
 // ServiceCatalogSpec defines the desired state of ServiceCatalog
 type ServiceCatalogSpec struct {
 	// Services contains a list of services that are known to Primaza.
 	Services []ServiceCatalogService `json:"services,omitempty"`
 }
 
+// ServiceCatalogStatus defines the observed state of ServiceCatalog
+type ServiceCatalogStatus struct {
+	// Conditions of the catalog.  The Pushed condition reports whether the
+	// catalog has been pushed to the application namespaces of the cluster
+	// environments of its environment.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of the ServiceCatalog the status
+	// was last computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+const (
+	// ServiceCatalogConditionPushed reports whether the catalog has been
+	// pushed to the application namespaces
+	ServiceCatalogConditionPushed = "Pushed"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Environment",type="string",JSONPath=".metadata.name",description="the environment of the ServiceCatalog"
+//+kubebuilder:printcolumn:name="Pushed",type="string",JSONPath=".status.conditions[?(@.type==\"Pushed\")].status",description="whether the ServiceCatalog has been pushed to the application namespaces"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // ServiceCatalog is the Schema for the servicecatalogs API
 type ServiceCatalog struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec ServiceCatalogSpec `json:"spec,omitempty"`
+	Spec   ServiceCatalogSpec   `json:"spec,omitempty"`
+	Status ServiceCatalogStatus `json:"status,omitempty"`
 }
 
 //+kubebuilder:object:root=true
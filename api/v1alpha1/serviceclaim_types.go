@@ -17,12 +17,31 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type ServiceClaimApplicationClusterContext struct {
 	ClusterEnvironmentName string `json:"clusterEnvironmentName"`
 	Namespace              string `json:"namespace"`
+	// AdditionalNamespaces lists the other application namespaces of the
+	// cluster environment the application spans, where the binding secret is
+	// replicated too.
+	// +optional
+	AdditionalNamespaces []string `json:"additionalNamespaces,omitempty"`
+}
+
+// Namespaces returns the application namespaces the binding secret is pushed to
+func (c *ServiceClaimApplicationClusterContext) Namespaces() []string {
+	ns := []string{c.Namespace}
+	for _, n := range c.AdditionalNamespaces {
+		if n != c.Namespace {
+			ns = append(ns, n)
+		}
+	}
+	return ns
 }
 
 // ServiceClaimSpec defines the desired state of ServiceClaim
@@ -30,7 +49,16 @@ type ServiceClaimSpec struct {
 	// ServiceClassIdentity defines a set of attributes that are sufficient to
 	// identify a service class.  A ServiceClaim whose ServiceClassIdentity
 	// field is a subset of a RegisteredService's keys can claim that service.
-	ServiceClassIdentity []ServiceClassIdentityItem `json:"serviceClassIdentity"`
+	// Required unless ServiceSelector is set.
+	// +optional
+	ServiceClassIdentity []ServiceClassIdentityItem `json:"serviceClassIdentity,omitempty"`
+
+	// ServiceSelector is a query over the labels of the RegisteredServices
+	// the claim can claim (e.g. `team: payments`).  When both
+	// ServiceSelector and ServiceClassIdentity are set, a RegisteredService
+	// has to satisfy both.
+	// +optional
+	ServiceSelector *metav1.LabelSelector `json:"serviceSelector,omitempty"`
 
 	// ServiceEndpointDefinition defines a set of attributes sufficient for a
 	// client to establish a connection to the service.
@@ -43,20 +71,384 @@ type ServiceClaimSpec struct {
 	EnvironmentTag string `json:"environmentTag,omitempty"`
 	// +optional
 	ApplicationClusterContext *ServiceClaimApplicationClusterContext `json:"applicationClusterContext,omitempty"`
+
+	// SecretType overrides the type of the generated binding Secret.
+	// If not set, the type is derived from the ServiceClassIdentity item
+	// named `type` as `servicebinding.io/<type>`.  Set it to `Opaque` to
+	// disable the derivation.
+	// +optional
+	SecretType string `json:"secretType,omitempty"`
+
+	// Encoders render the Service Endpoint Definition into additional
+	// keys of the binding Secret, for frameworks that read their
+	// configuration from a single file.
+	// +optional
+	Encoders []BindingSecretEncoder `json:"encoders,omitempty"`
+
+	// Env maps keys of the binding Secret to environment variables of the
+	// application's containers (e.g. key `host` to `DATABASE_HOST`)
+	// +optional
+	Env []Environment `json:"env,omitempty"`
+
+	// ProjectedKeys restricts the keys of the binding Secret that are
+	// projected as files into the application.  All the keys are projected
+	// when empty.
+	// +optional
+	ProjectedKeys []string `json:"projectedKeys,omitempty"`
+
+	// StalePolicy defines what the application agent does when the
+	// application selected by the claim does not exist any more: `Ignore`
+	// it, `Flag` the claim with the Stale condition, or `Release` the claim
+	// by deleting it.  Defaults to `Ignore`.
+	// +optional
+	StalePolicy ServiceClaimStalePolicy `json:"stalePolicy,omitempty"`
+
+	// StaleGracePeriod is how long the application has to be missing before
+	// the claim is considered stale.  Defaults to 5 minutes.
+	// +optional
+	StaleGracePeriod *metav1.Duration `json:"staleGracePeriod,omitempty"`
+
+	// TTL, when set, makes the claim expire once this duration has elapsed
+	// since its creation or its last renewal: its bindings are revoked and
+	// the claimed RegisteredService is released.  Useful for ephemeral
+	// environments, e.g. preview deployments.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// RenewTime renews the claim's lease: the claim expires once TTL has
+	// elapsed since the latest of its creation and RenewTime.  Setting it
+	// on an expired claim resolves the claim again.
+	// +optional
+	RenewTime *metav1.Time `json:"renewTime,omitempty"`
+
+	// MatchingPreferences are soft preferences on the ClusterEnvironment
+	// the claimed RegisteredService is discovered in.  Among the matching
+	// RegisteredServices, the one with the highest sum of the weights of the
+	// preferences it satisfies is claimed.
+	// +optional
+	MatchingPreferences []MatchingPreference `json:"matchingPreferences,omitempty"`
+
+	// RankingPolicy defines which RegisteredService is claimed when several
+	// of them match the claim equally well with respect to the matching
+	// preferences: the one with the highest `Priority`, the `MostSpecific`
+	// one, whose ServiceClassIdentity has the fewest attributes not requested
+	// by the claim, or the `Newest` one.  Remaining ties are broken by the
+	// name of the RegisteredServices.  Defaults to `Priority`.
+	// +optional
+	RankingPolicy ServiceClaimRankingPolicy `json:"rankingPolicy,omitempty"`
+
+	// RebindPolicy defines when the claim is moved back to Pending to be
+	// resolved by another matching RegisteredService after losing the
+	// claimed one: `Never`, `OnDeregistration`, when the claimed
+	// RegisteredService is deleted, or `OnUnreachable`, when it is deleted
+	// or becomes Unreachable.  Defaults to `OnDeregistration` if Primaza is
+	// started with `--failover-claims`, and to `Never` otherwise.
+	// +optional
+	RebindPolicy ServiceClaimRebindPolicy `json:"rebindPolicy,omitempty"`
+
+	// ReachabilityCheck, when set, makes the application agents verify that
+	// the service endpoint can be reached from the application namespaces
+	// before marking the ServiceBindings Ready
+	// +optional
+	ReachabilityCheck *ReachabilityCheck `json:"reachabilityCheck,omitempty"`
+
+	// Vault, when set, makes Primaza store the secret-backed values of the
+	// Service Endpoint Definition in HashiCorp Vault instead of the binding
+	// Secret.  The applications read them through the Vault agent injector.
+	// Requires Primaza to be started with `--vault-address`.
+	// +optional
+	Vault *ServiceClaimVault `json:"vault,omitempty"`
+}
+
+// ServiceClaimVault defines where the secret-backed values of the Service
+// Endpoint Definition are stored in Vault, and the role the applications
+// read them with
+type ServiceClaimVault struct {
+	// Role of the Kubernetes auth method the Vault agent of the
+	// applications authenticates with
+	Role string `json:"role"`
+
+	// Mount of the KV version 2 secrets engine.  Defaults to `secret`.
+	// +optional
+	Mount string `json:"mount,omitempty"`
+
+	// Path of the secret in the secrets engine.  Defaults to
+	// `primaza/<namespace>/<claim name>`.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// DefaultServiceClaimVaultMount is the KV version 2 secrets engine the
+// secret-backed values are stored in
+const DefaultServiceClaimVaultMount = "secret"
+
+// VaultMount returns the secrets engine the claim's secret-backed values
+// are stored in
+func (sc *ServiceClaim) VaultMount() string {
+	if sc.Spec.Vault == nil || sc.Spec.Vault.Mount == "" {
+		return DefaultServiceClaimVaultMount
+	}
+	return sc.Spec.Vault.Mount
+}
+
+// VaultPath returns the path of the secret the claim's secret-backed values
+// are stored in
+func (sc *ServiceClaim) VaultPath() string {
+	if sc.Spec.Vault == nil || sc.Spec.Vault.Path == "" {
+		return fmt.Sprintf("primaza/%s/%s", sc.Namespace, sc.Name)
+	}
+	return sc.Spec.Vault.Path
+}
+
+// MatchingPreference is a soft preference on the ClusterEnvironment a
+// RegisteredService is discovered in.  It is satisfied when the
+// ClusterEnvironment has all the Topology labels and its environment is not
+// AvoidEnvironment.
+type MatchingPreference struct {
+	// Weight added to the score of the RegisteredServices satisfying the
+	// preference
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+
+	// Topology labels the ClusterEnvironment has to declare (e.g.
+	// `topology.kubernetes.io/region: eu-west-1`)
+	// +optional
+	Topology map[string]string `json:"topology,omitempty"`
+
+	// AvoidEnvironment is an environment the ClusterEnvironment should
+	// preferably not belong to
+	// +optional
+	AvoidEnvironment string `json:"avoidEnvironment,omitempty"`
+}
+
+// ServiceClaimRankingPolicy defines how the RegisteredServices matching a
+// claim are ranked
+// +kubebuilder:validation:Enum=Priority;MostSpecific;Newest
+type ServiceClaimRankingPolicy string
+
+const (
+	ServiceClaimRankingPolicyPriority     ServiceClaimRankingPolicy = "Priority"
+	ServiceClaimRankingPolicyMostSpecific ServiceClaimRankingPolicy = "MostSpecific"
+	ServiceClaimRankingPolicyNewest       ServiceClaimRankingPolicy = "Newest"
+)
+
+// Ranking returns the policy the RegisteredServices matching the claim are
+// ranked with
+func (s *ServiceClaimSpec) Ranking() ServiceClaimRankingPolicy {
+	if s.RankingPolicy == "" {
+		return ServiceClaimRankingPolicyPriority
+	}
+	return s.RankingPolicy
+}
+
+// ServiceClaimRebindPolicy defines when a claim that lost its
+// RegisteredService is resolved again
+// +kubebuilder:validation:Enum=Never;OnDeregistration;OnUnreachable
+type ServiceClaimRebindPolicy string
+
+const (
+	ServiceClaimRebindPolicyNever            ServiceClaimRebindPolicy = "Never"
+	ServiceClaimRebindPolicyOnDeregistration ServiceClaimRebindPolicy = "OnDeregistration"
+	ServiceClaimRebindPolicyOnUnreachable    ServiceClaimRebindPolicy = "OnUnreachable"
+)
+
+// Rebinds reports whether the claim is to be resolved again when its
+// RegisteredService is deregistered or, if unreachable is true, when it
+// becomes Unreachable.  failover is the policy's default, see RebindPolicy.
+func (s *ServiceClaimSpec) Rebinds(unreachable, failover bool) bool {
+	policy := s.RebindPolicy
+	if policy == "" {
+		policy = ServiceClaimRebindPolicyNever
+		if failover {
+			policy = ServiceClaimRebindPolicyOnDeregistration
+		}
+	}
+
+	switch policy {
+	case ServiceClaimRebindPolicyOnUnreachable:
+		return true
+	case ServiceClaimRebindPolicyOnDeregistration:
+		return !unreachable
+	default:
+		return false
+	}
+}
+
+// ExpirationTime returns when the claim expires, or nil if it has no TTL
+func (sc *ServiceClaim) ExpirationTime() *metav1.Time {
+	if sc.Spec.TTL == nil {
+		return nil
+	}
+	start := sc.CreationTimestamp
+	if rt := sc.Spec.RenewTime; rt != nil && start.Before(rt) {
+		start = *rt
+	}
+	// status times are serialized with a precision of one second
+	expiration := metav1.NewTime(start.Add(sc.Spec.TTL.Duration)).Rfc3339Copy()
+	return &expiration
+}
+
+// ServiceClaimStalePolicy defines how a claim whose application does not
+// exist any more is handled
+// +kubebuilder:validation:Enum=Ignore;Flag;Release
+type ServiceClaimStalePolicy string
+
+const (
+	ServiceClaimStalePolicyIgnore  ServiceClaimStalePolicy = "Ignore"
+	ServiceClaimStalePolicyFlag    ServiceClaimStalePolicy = "Flag"
+	ServiceClaimStalePolicyRelease ServiceClaimStalePolicy = "Release"
+
+	// DefaultServiceClaimStaleGracePeriod is the time the application of a
+	// claim has to be missing before the claim is considered stale
+	DefaultServiceClaimStaleGracePeriod = 5 * time.Minute
+)
+
+// StaleAfter returns how long the application of the claim has to be
+// missing before the claim is considered stale
+func (s *ServiceClaimSpec) StaleAfter() time.Duration {
+	if s.StaleGracePeriod == nil {
+		return DefaultServiceClaimStaleGracePeriod
+	}
+	return s.StaleGracePeriod.Duration
+}
+
+// BindingSecretFormat defines the format a BindingSecretEncoder renders the
+// Service Endpoint Definition into
+// +kubebuilder:validation:Enum=json;properties;dotenv
+type BindingSecretFormat string
+
+const (
+	BindingSecretFormatJSON       BindingSecretFormat = "json"
+	BindingSecretFormatProperties BindingSecretFormat = "properties"
+	BindingSecretFormatDotenv     BindingSecretFormat = "dotenv"
+)
+
+// BindingSecretEncoder adds to the binding Secret a key containing the whole
+// Service Endpoint Definition rendered in the given format
+type BindingSecretEncoder struct {
+	// Format of the rendered Service Endpoint Definition
+	Format BindingSecretFormat `json:"format"`
+
+	// Key of the binding Secret the rendered Service Endpoint Definition is
+	// stored into (e.g. `application.properties`)
+	Key string `json:"key"`
 }
 
 const (
 	ServiceClaimConditionReady = "Ready"
+	// ServiceClaimConditionDegraded is set when the claimed RegisteredService
+	// is not available any more
+	ServiceClaimConditionDegraded = "Degraded"
+	// ServiceClaimConditionStale is set when the application selected by
+	// the claim does not exist any more
+	ServiceClaimConditionStale = "Stale"
+	// ServiceClaimConditionRebound is set when the claim has been resolved
+	// by another RegisteredService after losing the claimed one
+	ServiceClaimConditionRebound = "Rebound"
+	// ServiceClaimConditionDraining is set when the claimed RegisteredService
+	// is in maintenance and its applications should stop using it
+	ServiceClaimConditionDraining = "Draining"
 )
 
+type ServiceClaimBindingState string
+
+const (
+	ServiceClaimBindingStatePushed ServiceClaimBindingState = "Pushed"
+	ServiceClaimBindingStateFailed ServiceClaimBindingState = "Failed"
+)
+
+// ServiceClaimBinding reports the state of the copy of the binding secret
+// and service binding in an application namespace
+type ServiceClaimBinding struct {
+	// ClusterEnvironment the application namespace belongs to
+	ClusterEnvironment string `json:"clusterEnvironment"`
+	// Namespace the binding secret is pushed to
+	Namespace string `json:"namespace"`
+	// State of the copy
+	// +kubebuilder:validation:Enum=Pushed;Failed
+	State ServiceClaimBindingState `json:"state"`
+	// Message explaining why the copy could not be pushed
+	// +optional
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the last time the state of the copy changed
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+}
+
+// MaxServiceClaimSelectionCandidates is the maximum number of candidates
+// recorded in the selection of a claim
+const MaxServiceClaimSelectionCandidates = 5
+
+// ServiceClaimSelection reports how the claimed RegisteredService has been
+// chosen among the matching ones
+type ServiceClaimSelection struct {
+	// RankingPolicy the matching RegisteredServices have been ranked with
+	RankingPolicy ServiceClaimRankingPolicy `json:"rankingPolicy"`
+	// Matches is the number of RegisteredServices matching the claim
+	Matches int32 `json:"matches"`
+	// Candidates lists the best ranked matching RegisteredServices, the
+	// claimed one first
+	// +optional
+	Candidates []string `json:"candidates,omitempty"`
+}
+
 // ServiceClaimStatus defines the observed state of ServiceClaim
 type ServiceClaimStatus struct {
-	//+kubebuilder:validation:Enum=Pending;Resolved;Invalid
+	//+kubebuilder:validation:Enum=Pending;Resolved;Invalid;Expired
 	//+kubebuilder:default:=Pending
-	State             ServiceClaimState  `json:"state"`
-	ClaimID           string             `json:"claimID,omitempty"`
-	RegisteredService string             `json:"registeredService"`
-	Conditions        []metav1.Condition `json:"conditions,omitempty"`
+	State             ServiceClaimState `json:"state"`
+	ClaimID           string            `json:"claimID,omitempty"`
+	RegisteredService string            `json:"registeredService"`
+	// Conditions of the claim, at most one per type.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of the ServiceClaim the status
+	// was last computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Transitions records the latest changes of state of the claim.
+	// +optional
+	Transitions []StateTransition `json:"transitions,omitempty"`
+	// Bindings reports the state of each copy of the binding secret.
+	// +optional
+	Bindings []ServiceClaimBinding `json:"bindings,omitempty"`
+	// Summary describes the status at a glance.
+	// +optional
+	Summary string `json:"summary,omitempty"`
+	// Selection reports how the claimed RegisteredService has been chosen.
+	// +optional
+	Selection *ServiceClaimSelection `json:"selection,omitempty"`
+	// ExpirationTime is when the claim expires, if it has a TTL.
+	// +optional
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+	// PreviousRegisteredService is the RegisteredService the claim was
+	// resolved with, while the claim is Pending to be rebound.
+	// +optional
+	PreviousRegisteredService string `json:"previousRegisteredService,omitempty"`
+	// VaultKeys are the keys of the Service Endpoint Definition stored in
+	// Vault instead of the binding Secret.
+	// +optional
+	VaultKeys []string `json:"vaultKeys,omitempty"`
+}
+
+// SetBinding adds or updates the state of the copy of the binding secret in
+// the binding's cluster environment and namespace.  Its LastTransitionTime is
+// only updated when its state changes.
+func (s *ServiceClaimStatus) SetBinding(binding ServiceClaimBinding) {
+	for i, b := range s.Bindings {
+		if b.ClusterEnvironment != binding.ClusterEnvironment || b.Namespace != binding.Namespace {
+			continue
+		}
+		if b.State == binding.State {
+			binding.LastTransitionTime = b.LastTransitionTime
+		}
+		s.Bindings[i] = binding
+		return
+	}
+	s.Bindings = append(s.Bindings, binding)
 }
 
 type ServiceClaimState string
@@ -65,11 +457,15 @@ const (
 	ServiceClaimStatePending  ServiceClaimState = "Pending"
 	ServiceClaimStateResolved ServiceClaimState = "Resolved"
 	ServiceClaimStateInvalid  ServiceClaimState = "Invalid"
+	ServiceClaimStateExpired  ServiceClaimState = "Expired"
 )
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state",description="the state of the ServiceClaim"
+//+kubebuilder:printcolumn:name="Service",type="string",JSONPath=".status.registeredService",description="the RegisteredService bound to the ServiceClaim"
+//+kubebuilder:printcolumn:name="Environment",type="string",JSONPath=".spec.environmentTag",description="the environment the ServiceClaim is bound in"
+//+kubebuilder:printcolumn:name="Summary",type="string",JSONPath=".status.summary",description="the status of the ServiceClaim at a glance"
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // ServiceClaim is the Schema for the serviceclaims API
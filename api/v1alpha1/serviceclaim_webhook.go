@@ -21,13 +21,20 @@ import (
 	"fmt"
 	"reflect"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+// bindingSecretTypeKey is the key of the binding Secret holding the type of
+// the claimed service
+const bindingSecretTypeKey = "type"
+
 // log is for logging in this package.
 var serviceclaimlog = logf.Log.WithName("serviceclaim-resource")
 
@@ -59,28 +66,167 @@ func (v *serviceClaimValidator) ValidateCreate(ctx context.Context, obj runtime.
 	}
 
 	serviceclaimlog.Info("validate create", "name", r.Name)
-	return v.validate(r)
+	return v.validate(r).ToAggregate()
 }
 
-func (v *serviceClaimValidator) validate(r *ServiceClaim) error {
+func (v *serviceClaimValidator) validate(r *ServiceClaim) field.ErrorList {
+	errs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if len(r.Spec.ServiceClassIdentity) == 0 && r.Spec.ServiceSelector == nil {
+		errs = append(errs, field.Required(specPath.Child("serviceClassIdentity"), "ServiceClassIdentity cannot be empty"))
+	}
+	if sel := r.Spec.ServiceSelector; sel != nil {
+		if _, err := metav1.LabelSelectorAsSelector(sel); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("serviceSelector"), sel, err.Error()))
+		}
+	}
+	names := map[string]struct{}{}
+	for i, sci := range r.Spec.ServiceClassIdentity {
+		path := specPath.Child("serviceClassIdentity").Index(i).Child("name")
+		if sci.Name == "" {
+			errs = append(errs, field.Required(path, "ServiceClassIdentity key cannot be empty"))
+		} else if _, found := names[sci.Name]; found {
+			errs = append(errs, field.Duplicate(path, sci.Name))
+		} else {
+			names[sci.Name] = struct{}{}
+		}
+	}
+
+	if len(r.Spec.ServiceEndpointDefinitionKeys) == 0 {
+		errs = append(errs, field.Required(specPath.Child("serviceEndpointDefinitionKeys"), "ServiceEndpointDefinitionKeys cannot be empty"))
+	}
+
 	if r.Spec.ApplicationClusterContext != nil && r.Spec.EnvironmentTag != "" {
-		return fmt.Errorf("Both ApplicationClusterContext and EnvironmentTag cannot be used together")
+		errs = append(errs, field.Forbidden(specPath.Child("environmentTag"), "Both ApplicationClusterContext and EnvironmentTag cannot be used together"))
 	}
 	if r.Spec.ApplicationClusterContext == nil && r.Spec.EnvironmentTag == "" {
-		return fmt.Errorf("Both ApplicationClusterContext and EnvironmentTag cannot be empty")
+		errs = append(errs, field.Required(specPath.Child("environmentTag"), "Both ApplicationClusterContext and EnvironmentTag cannot be empty"))
+	}
+	if acc := r.Spec.ApplicationClusterContext; acc != nil {
+		errs = append(errs, acc.validate(specPath.Child("applicationClusterContext"))...)
 	}
 	if r.Spec.Application.Name != "" && r.Spec.Application.Selector != nil {
-		return fmt.Errorf("Both Application name and Application selector cannot be used together")
+		errs = append(errs, field.Forbidden(specPath.Child("application", "selector"), "Both Application name and Application selector cannot be used together"))
 	}
-	return nil
+
+	keys := map[string]struct{}{}
+	for _, k := range r.Spec.ServiceEndpointDefinitionKeys {
+		keys[k] = struct{}{}
+	}
+	for n := range names {
+		keys[n] = struct{}{}
+	}
+	errs = append(errs, validateEncoders(specPath.Child("encoders"), r.Spec.Encoders, keys)...)
+
+	// the binding Secret can also get its `type` from the claimed service
+	keys[bindingSecretTypeKey] = struct{}{}
+	errs = append(errs, validateEnv(specPath.Child("env"), r.Spec.Env, keys)...)
+	for i, k := range r.Spec.ProjectedKeys {
+		if _, found := keys[k]; !found {
+			errs = append(errs, field.NotFound(specPath.Child("projectedKeys").Index(i), k))
+		}
+	}
+
+	errs = append(errs, r.Spec.validatePreferences(specPath)...)
+	return errs
 }
 
-func (v *serviceClaimValidator) validateUpdate(old *ServiceClaim, new *ServiceClaim) error {
+// validatePreferences checks the optional fields tuning how the claim is
+// matched, bound and released
+func (s *ServiceClaimSpec) validatePreferences(specPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, p := range s.MatchingPreferences {
+		if len(p.Topology) == 0 && p.AvoidEnvironment == "" {
+			errs = append(errs, field.Required(specPath.Child("matchingPreferences").Index(i), "MatchingPreference must define either Topology or AvoidEnvironment"))
+		}
+	}
+	if rc := s.ReachabilityCheck; rc != nil && rc.Timeout != nil && rc.Timeout.Duration <= 0 {
+		errs = append(errs, field.Invalid(specPath.Child("reachabilityCheck", "timeout"), rc.Timeout.Duration.String(), "Timeout must be positive"))
+	}
+	if gp := s.StaleGracePeriod; gp != nil && gp.Duration <= 0 {
+		errs = append(errs, field.Invalid(specPath.Child("staleGracePeriod"), gp.Duration.String(), "StaleGracePeriod must be positive"))
+	}
+	if ttl := s.TTL; ttl != nil && ttl.Duration <= 0 {
+		errs = append(errs, field.Invalid(specPath.Child("ttl"), ttl.Duration.String(), "TTL must be positive"))
+	}
+	if v := s.Vault; v != nil && v.Role == "" {
+		errs = append(errs, field.Required(specPath.Child("vault", "role"), "Vault role cannot be empty"))
+	}
+	return errs
+}
 
-	if !reflect.DeepEqual(old.Spec, new.Spec) {
-		return fmt.Errorf("Service Claim's Service Class Identity or Service Endpoint Definition Keys are not meant to be updated, Please delete the existing service claim")
+// validateEncoders checks that encoders write into valid keys that do not
+// clash with the ones already in the binding Secret
+func validateEncoders(path *field.Path, encoders []BindingSecretEncoder, keys map[string]struct{}) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, e := range encoders {
+		path := path.Index(i).Child("key")
+		if e.Key == "" {
+			errs = append(errs, field.Required(path, "Encoder key cannot be empty"))
+			continue
+		}
+		for _, msg := range validation.IsConfigMapKey(e.Key) {
+			errs = append(errs, field.Invalid(path, e.Key, msg))
+		}
+		if _, found := keys[e.Key]; found {
+			errs = append(errs, field.Duplicate(path, e.Key))
+		} else {
+			keys[e.Key] = struct{}{}
+		}
 	}
-	return nil
+	return errs
+}
+
+// validateEnv checks that environment variables have valid and unique names
+// and are taken from keys of the binding Secret
+func validateEnv(path *field.Path, env []Environment, keys map[string]struct{}) field.ErrorList {
+	errs := field.ErrorList{}
+	names := map[string]struct{}{}
+	for i, e := range env {
+		path := path.Index(i)
+		for _, msg := range validation.IsEnvVarName(e.Name) {
+			errs = append(errs, field.Invalid(path.Child("name"), e.Name, msg))
+		}
+		if _, found := names[e.Name]; found {
+			errs = append(errs, field.Duplicate(path.Child("name"), e.Name))
+		}
+		names[e.Name] = struct{}{}
+		if _, found := keys[e.Key]; !found {
+			errs = append(errs, field.NotFound(path.Child("key"), e.Key))
+		}
+	}
+	return errs
+}
+
+func (v *serviceClaimValidator) validateUpdate(old *ServiceClaim, new *ServiceClaim) field.ErrorList {
+	// claims stored before the current validation rules must stay
+	// updatable, e.g. to remove their finalizer, as long as their spec
+	// is not changed
+	if new.DeletionTimestamp != nil || reflect.DeepEqual(old.Spec, new.Spec) {
+		return nil
+	}
+
+	errs := v.validate(new)
+	specPath := field.NewPath("spec")
+
+	if old.Spec.EnvironmentTag != new.Spec.EnvironmentTag {
+		errs = append(errs, field.Invalid(specPath.Child("environmentTag"), new.Spec.EnvironmentTag, "EnvironmentTag is immutable"))
+	}
+	if !reflect.DeepEqual(old.Spec.ApplicationClusterContext, new.Spec.ApplicationClusterContext) {
+		errs = append(errs, field.Invalid(specPath.Child("applicationClusterContext"), new.Spec.ApplicationClusterContext, "ApplicationClusterContext is immutable"))
+	}
+	// the target environment has been checked already, and the lease can be
+	// renewed or extended at any time
+	oldSpec, newSpec := old.Spec, new.Spec
+	oldSpec.EnvironmentTag, newSpec.EnvironmentTag = "", ""
+	oldSpec.ApplicationClusterContext, newSpec.ApplicationClusterContext = nil, nil
+	oldSpec.TTL, newSpec.TTL = nil, nil
+	oldSpec.RenewTime, newSpec.RenewTime = nil, nil
+	if !reflect.DeepEqual(oldSpec, newSpec) {
+		errs = append(errs, field.Forbidden(specPath, "Service Claim's Service Class Identity or Service Endpoint Definition Keys are not meant to be updated, Please delete the existing service claim"))
+	}
+	return errs
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -100,7 +246,7 @@ func (v *serviceClaimValidator) ValidateUpdate(ctx context.Context, oldObj runti
 	}
 
 	serviceclaimlog.Info("validate update", "name", newServiceClaim.Name)
-	return v.validateUpdate(oldServiceClaim, newServiceClaim)
+	return v.validateUpdate(oldServiceClaim, newServiceClaim).ToAggregate()
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -117,3 +263,19 @@ func (v *serviceClaimValidator) ValidateDelete(ctx context.Context, obj runtime.
 	// TODO(user): fill in your validation logic upon object deletion.
 	return nil
 }
+
+func (acc *ServiceClaimApplicationClusterContext) validate(path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	namespaces := map[string]struct{}{acc.Namespace: {}}
+	for i, ns := range acc.AdditionalNamespaces {
+		nsPath := path.Child("additionalNamespaces").Index(i)
+		for _, msg := range validation.IsDNS1123Label(ns) {
+			errs = append(errs, field.Invalid(nsPath, ns, msg))
+		}
+		if _, found := namespaces[ns]; found {
+			errs = append(errs, field.Duplicate(nsPath, ns))
+		}
+		namespaces[ns] = struct{}{}
+	}
+	return errs
+}
@@ -18,12 +18,14 @@ package v1alpha1
 
 import (
 	"context"
-	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -37,82 +39,337 @@ func newServiceClaim(name, namespace string, spec ServiceClaimSpec) ServiceClaim
 	}
 }
 
-var _ = Describe("Webhook tests", func() {
-	Context("When creating ServiceClaim with ApplicationClusterContext and EnvironmentTag", func() {
-		It("should an error saying the resource cannot be created", func() {
-			var validator serviceClaimValidator
-			schemeBuilder, err := SchemeBuilder.Build()
-			Expect(err).NotTo(HaveOccurred())
+var _ = Describe("ServiceClaim Webhook tests", func() {
+	var validator serviceClaimValidator
+	BeforeEach(func() {
+		schemeBuilder, err := SchemeBuilder.Build()
+		Expect(err).NotTo(HaveOccurred())
 
-			validator = serviceClaimValidator{
-				client: fake.NewClientBuilder().
-					WithScheme(schemeBuilder).
-					WithLists(&ServiceClaimList{}).
-					Build(),
-			}
-			scacc := ServiceClaimApplicationClusterContext{}
-			serviceClaim := newServiceClaim("spam", "eggs",
-				ServiceClaimSpec{
-					EnvironmentTag:            "prod",
-					ApplicationClusterContext: &scacc,
-				},
-			)
-
-			expected := fmt.Errorf("Both ApplicationClusterContext and EnvironmentTag cannot be used together")
-			Expect(validator.ValidateCreate(context.Background(), &serviceClaim)).To(Equal(expected))
-		})
+		validator = serviceClaimValidator{
+			client: fake.NewClientBuilder().
+				WithScheme(schemeBuilder).
+				WithLists(&ServiceClaimList{}).
+				Build(),
+		}
 	})
 
-	Context("When creating ServiceClaim with empty ApplicationClusterContext and EnvironmentTag", func() {
-		It("should an error saying the resource cannot be created", func() {
-			var validator serviceClaimValidator
-			schemeBuilder, err := SchemeBuilder.Build()
-			Expect(err).NotTo(HaveOccurred())
+	sci := []ServiceClassIdentityItem{{Name: "type", Value: "psql"}}
+	sedKeys := []string{"host"}
 
-			validator = serviceClaimValidator{
-				client: fake.NewClientBuilder().
-					WithScheme(schemeBuilder).
-					WithLists(&ServiceClaimList{}).
-					Build(),
-			}
-			serviceClaim := newServiceClaim("spam", "eggs",
-				ServiceClaimSpec{
-					EnvironmentTag:            "",
-					ApplicationClusterContext: nil,
-				},
-			)
-
-			expected := fmt.Errorf("Both ApplicationClusterContext and EnvironmentTag cannot be empty")
+	DescribeTable("Creation validation failures",
+		func(serviceClaim ServiceClaim, expected error) {
 			Expect(validator.ValidateCreate(context.Background(), &serviceClaim)).To(Equal(expected))
-		})
-	})
-
-	Context("When creating ServiceClaim with Application name and Application selector", func() {
-		It("should an error saying the resource cannot be created", func() {
-			var validator serviceClaimValidator
-			schemeBuilder, err := SchemeBuilder.Build()
-			Expect(err).NotTo(HaveOccurred())
-
-			validator = serviceClaimValidator{
-				client: fake.NewClientBuilder().
-					WithScheme(schemeBuilder).
-					WithLists(&ServiceClaimList{}).
-					Build(),
-			}
-			as := ApplicationSelector{
-				Name:     "some-name",
-				Selector: &metav1.LabelSelector{},
-			}
-			serviceClaim := newServiceClaim("spam", "eggs",
-				ServiceClaimSpec{
-					Application:    as,
+		},
+		Entry("ApplicationClusterContext and EnvironmentTag",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+					ApplicationClusterContext:     &ServiceClaimApplicationClusterContext{},
+				},
+			),
+			field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "environmentTag"), "Both ApplicationClusterContext and EnvironmentTag cannot be used together"),
+			}.ToAggregate()),
+		Entry("Empty ApplicationClusterContext and EnvironmentTag",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+				},
+			),
+			field.ErrorList{
+				field.Required(field.NewPath("spec", "environmentTag"), "Both ApplicationClusterContext and EnvironmentTag cannot be empty"),
+			}.ToAggregate()),
+		Entry("Invalid additional namespaces",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					ApplicationClusterContext: &ServiceClaimApplicationClusterContext{
+						ClusterEnvironmentName: "worker",
+						Namespace:              "frontend",
+						AdditionalNamespaces:   []string{"backend", "frontend", "Backend"},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Duplicate(field.NewPath("spec", "applicationClusterContext", "additionalNamespaces").Index(1), "frontend"),
+				field.Invalid(field.NewPath("spec", "applicationClusterContext", "additionalNamespaces").Index(2), "Backend",
+					"a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')"),
+			}.ToAggregate()),
+		Entry("Application name and Application selector",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+					Application: ApplicationSelector{
+						Name:     "some-name",
+						Selector: &metav1.LabelSelector{},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "application", "selector"), "Both Application name and Application selector cannot be used together"),
+			}.ToAggregate()),
+		Entry("Empty ServiceClassIdentity and ServiceEndpointDefinitionKeys",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
 					EnvironmentTag: "prod",
 				},
-			)
+			),
+			field.ErrorList{
+				field.Required(field.NewPath("spec", "serviceClassIdentity"), "ServiceClassIdentity cannot be empty"),
+				field.Required(field.NewPath("spec", "serviceEndpointDefinitionKeys"), "ServiceEndpointDefinitionKeys cannot be empty"),
+			}.ToAggregate()),
+		Entry("Invalid ServiceClassIdentity keys",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity: []ServiceClassIdentityItem{
+						{Name: "type", Value: "psql"},
+						{Name: "", Value: "aws"},
+						{Name: "type", Value: "mysql"},
+					},
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+				},
+			),
+			field.ErrorList{
+				field.Required(field.NewPath("spec", "serviceClassIdentity").Index(1).Child("name"), "ServiceClassIdentity key cannot be empty"),
+				field.Duplicate(field.NewPath("spec", "serviceClassIdentity").Index(2).Child("name"), "type"),
+			}.ToAggregate()),
+		Entry("Invalid ServiceSelector",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "team", Operator: "Is", Values: []string{"payments"}},
+						},
+					},
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+				},
+			),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "serviceSelector"), &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "team", Operator: "Is", Values: []string{"payments"}},
+					},
+				}, "\"Is\" is not a valid label selector operator"),
+			}.ToAggregate()),
+		Entry("Invalid encoder keys",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+					Encoders: []BindingSecretEncoder{
+						{Format: BindingSecretFormatJSON, Key: "binding.json"},
+						{Format: BindingSecretFormatDotenv, Key: ""},
+						{Format: BindingSecretFormatProperties, Key: "host"},
+						{Format: BindingSecretFormatDotenv, Key: "binding.json"},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Required(field.NewPath("spec", "encoders").Index(1).Child("key"), "Encoder key cannot be empty"),
+				field.Duplicate(field.NewPath("spec", "encoders").Index(2).Child("key"), "host"),
+				field.Duplicate(field.NewPath("spec", "encoders").Index(3).Child("key"), "binding.json"),
+			}.ToAggregate()),
+		Entry("Invalid env",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+					Env: []Environment{
+						{Name: "DATABASE_HOST", Key: "host"},
+						{Name: "DATABASE_TYPE", Key: "type"},
+						{Name: "1HOST", Key: "host"},
+						{Name: "DATABASE_HOST", Key: "port"},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "env").Index(2).Child("name"), "1HOST", validation.IsEnvVarName("1HOST")[0]),
+				field.Duplicate(field.NewPath("spec", "env").Index(3).Child("name"), "DATABASE_HOST"),
+				field.NotFound(field.NewPath("spec", "env").Index(3).Child("key"), "port"),
+			}.ToAggregate()),
+		Entry("Unknown projected keys",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+					ProjectedKeys:                 []string{"host", "type", "password"},
+				},
+			),
+			field.ErrorList{
+				field.NotFound(field.NewPath("spec", "projectedKeys").Index(2), "password"),
+			}.ToAggregate()),
+		Entry("Empty matching preference",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+					MatchingPreferences: []MatchingPreference{
+						{Weight: 10, AvoidEnvironment: "prod"},
+						{Weight: 10},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Required(field.NewPath("spec", "matchingPreferences").Index(1), "MatchingPreference must define either Topology or AvoidEnvironment"),
+			}.ToAggregate()),
+		Entry("Negative reachability check timeout",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+					ReachabilityCheck:             &ReachabilityCheck{Timeout: &metav1.Duration{}},
+				},
+			),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "reachabilityCheck", "timeout"), "0s", "Timeout must be positive"),
+			}.ToAggregate()),
+		Entry("Negative stale grace period",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+					StalePolicy:                   ServiceClaimStalePolicyRelease,
+					StaleGracePeriod:              &metav1.Duration{Duration: -time.Minute},
+				},
+			),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "staleGracePeriod"), "-1m0s", "StaleGracePeriod must be positive"),
+			}.ToAggregate()),
+		Entry("Zero TTL",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+					TTL:                           &metav1.Duration{},
+				},
+			),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "ttl"), "0s", "TTL must be positive"),
+			}.ToAggregate()),
+		Entry("Vault without role",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+					Vault:                         &ServiceClaimVault{Mount: "kv"},
+				},
+			),
+			field.ErrorList{
+				field.Required(field.NewPath("spec", "vault", "role"), "Vault role cannot be empty"),
+			}.ToAggregate()),
+	)
 
-			expected := fmt.Errorf("Both Application name and Application selector cannot be used together")
-			Expect(validator.ValidateCreate(context.Background(), &serviceClaim)).To(Equal(expected))
-		})
-	})
+	DescribeTable("Update validation",
+		func(oldClaim, newClaim ServiceClaim) {
+			Expect(validator.ValidateUpdate(context.Background(), &oldClaim, &newClaim)).To(Succeed())
+		},
+		Entry("Removing the finalizer of a claim stored before the validation rules",
+			ServiceClaim{
+				ObjectMeta: v1.ObjectMeta{Name: "spam", Namespace: "eggs", Finalizers: []string{"serviceclaims.primaza.io/finalizer"}},
+				Spec:       ServiceClaimSpec{ServiceClassIdentity: sci, EnvironmentTag: "prod"},
+			},
+			newServiceClaim("spam", "eggs", ServiceClaimSpec{ServiceClassIdentity: sci, EnvironmentTag: "prod"})),
+		Entry("Updating a deleted claim",
+			ServiceClaim{
+				ObjectMeta: v1.ObjectMeta{Name: "spam", Namespace: "eggs", DeletionTimestamp: &v1.Time{Time: time.Now()}},
+				Spec:       ServiceClaimSpec{ServiceClassIdentity: sci, EnvironmentTag: "prod"},
+			},
+			ServiceClaim{
+				ObjectMeta: v1.ObjectMeta{Name: "spam", Namespace: "eggs", DeletionTimestamp: &v1.Time{Time: time.Now()}},
+				Spec:       ServiceClaimSpec{ServiceClassIdentity: sci, EnvironmentTag: "prod", Env: []Environment{{Name: "HOST", Key: "host"}}},
+			}),
+		Entry("Renewing the lease",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+					TTL:                           &metav1.Duration{Duration: time.Hour},
+				},
+			),
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+					TTL:                           &metav1.Duration{Duration: time.Hour},
+					RenewTime:                     &metav1.Time{Time: time.Now()},
+				},
+			)),
+		Entry("Extending the lease",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+					TTL:                           &metav1.Duration{Duration: time.Hour},
+				},
+			),
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+					TTL:                           &metav1.Duration{Duration: 72 * time.Hour},
+				},
+			)),
+	)
 
+	DescribeTable("Update validation failures",
+		func(oldClaim, newClaim ServiceClaim, expected error) {
+			Expect(validator.ValidateUpdate(context.Background(), &oldClaim, &newClaim)).To(Equal(expected))
+		},
+		Entry("EnvironmentTag is immutable",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+				},
+			),
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "dev",
+				},
+			),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "environmentTag"), "dev", "EnvironmentTag is immutable"),
+			}.ToAggregate()),
+		Entry("ServiceEndpointDefinitionKeys are immutable",
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: sedKeys,
+					EnvironmentTag:                "prod",
+				},
+			),
+			newServiceClaim("spam", "eggs",
+				ServiceClaimSpec{
+					ServiceClassIdentity:          sci,
+					ServiceEndpointDefinitionKeys: []string{"host", "port"},
+					EnvironmentTag:                "prod",
+				},
+			),
+			field.ErrorList{
+				field.Forbidden(field.NewPath("spec"), "Service Claim's Service Class Identity or Service Endpoint Definition Keys are not meant to be updated, Please delete the existing service claim"),
+			}.ToAggregate()),
+	)
 })
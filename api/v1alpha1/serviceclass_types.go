@@ -17,14 +17,41 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"time"
+
+	"github.com/primaza/primaza/pkg/primaza/constants"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// DefaultSyncInterval is the interval between two full synchronizations
+	// of the resources of a ServiceClass that does not define one
+	DefaultSyncInterval = 5 * time.Minute
+	// MinSyncInterval is the shortest allowed interval between two full
+	// synchronizations of the resources of a ServiceClass
+	MinSyncInterval = 30 * time.Second
+	// MaxSyncInterval is the longest allowed interval between two full
+	// synchronizations of the resources of a ServiceClass
+	MaxSyncInterval = 24 * time.Hour
+)
+
 type ServiceEndpointDefinitionMappings struct {
 	ResourceFields  []ServiceClassResourceFieldMapping  `json:"resourceFields,omitempty"`
 	SecretRefFields []ServiceClassSecretRefFieldMapping `json:"secretRefFields,omitempty"`
+	HandlerFields   []ServiceClassHandlerFieldMapping   `json:"handlerFields,omitempty"`
 }
 
+// ValueTransformation defines a transformation applied to a mapping's extracted value
+// +kubebuilder:validation:Enum=base64decode;trimSpace;toLower;urlEncode
+type ValueTransformation string
+
+const (
+	ValueTransformationBase64Decode ValueTransformation = "base64decode"
+	ValueTransformationTrimSpace    ValueTransformation = "trimSpace"
+	ValueTransformationToLower      ValueTransformation = "toLower"
+	ValueTransformationURLEncode    ValueTransformation = "urlEncode"
+)
+
 type ServiceClassResourceFieldMapping struct {
 	// Name of the data referred to
 	Name string `json:"name"`
@@ -37,6 +64,21 @@ type ServiceClassResourceFieldMapping struct {
 	// +optional
 	// +kubebuilder:default=true
 	Secret bool `json:"secret"`
+
+	// Optional indicates whether the mapping can be skipped when JsonPath
+	// does not resolve to any value in the service resource.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+
+	// Default defines the value to use when JsonPath does not resolve
+	// to any value in the service resource.
+	// +optional
+	Default *string `json:"default,omitempty"`
+
+	// Transformations defines the chain of transformations applied, in order,
+	// to the extracted value.
+	// +optional
+	Transformations []ValueTransformation `json:"transformations,omitempty"`
 }
 
 type ServiceClassSecretRefFieldMapping struct {
@@ -50,8 +92,142 @@ type ServiceClassSecretRefFieldMapping struct {
 	// SecretKey defines a JsonPath used to extract from resource's specification
 	// the Key to be copied from the linked secret
 	SecretKey string `json:"secretKey"`
+
+	// Binary indicates whether the data referred to is binary (e.g. a
+	// keystore).  Binary data is carried base64 encoded and is restored
+	// as is in generated secrets, hence it can not be transformed.
+	// +optional
+	Binary bool `json:"binary,omitempty"`
+
+	// Transformations defines the chain of transformations applied, in order,
+	// to the extracted value.
+	// +optional
+	Transformations []ValueTransformation `json:"transformations,omitempty"`
+}
+
+// ServiceClassHandlerFieldMapping defines a mapping whose data is produced by
+// a discovery handler registered in the service agent
+type ServiceClassHandlerFieldMapping struct {
+	// Name of the data referred to
+	Name string `json:"name"`
+
+	// Handler is the name of the discovery handler producing the data (e.g.
+	// `ack-rds`)
+	Handler string `json:"handler"`
+
+	// Key of the data among the ones produced by the handler.  Defaults to
+	// Name.
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// Secret indicates whether or not the mapping data needs to be stored in a secret.
+	// +optional
+	// +kubebuilder:default=true
+	Secret bool `json:"secret"`
+
+	// Optional indicates whether the mapping can be skipped when the handler
+	// does not produce any value for Key.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+
+	// Transformations defines the chain of transformations applied, in order,
+	// to the extracted value.
+	// +optional
+	Transformations []ValueTransformation `json:"transformations,omitempty"`
 }
 
+// HandlerKey returns the key of the data among the ones produced by the
+// handler
+func (m ServiceClassHandlerFieldMapping) HandlerKey() string {
+	if m.Key != "" {
+		return m.Key
+	}
+	return m.Name
+}
+
+// ServiceClassResourceOwner identifies the owners of service resources
+type ServiceClassResourceOwner struct {
+	// APIVersion of the owner
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the owner
+	Kind string `json:"kind"`
+
+	// Name is a shell pattern the name of the owner must match
+	// (e.g. `prod-*`).  Any owner's name matches if it is empty.
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// ServiceClassJoinType defines how a secondary resource is related to the
+// service resource
+// +kubebuilder:validation:Enum=Owner;Label;JSONPath
+type ServiceClassJoinType string
+
+const (
+	// ServiceClassJoinByOwner relates the secondary resources owned by
+	// the service resource
+	ServiceClassJoinByOwner ServiceClassJoinType = "Owner"
+	// ServiceClassJoinByLabel relates the secondary resources with a label
+	// whose value is the name of the service resource
+	ServiceClassJoinByLabel ServiceClassJoinType = "Label"
+	// ServiceClassJoinByJSONPath relates the secondary resource whose name
+	// is read from the service resource
+	ServiceClassJoinByJSONPath ServiceClassJoinType = "JSONPath"
+)
+
+// ServiceClassJoin defines how a secondary resource is related to the
+// service resource
+type ServiceClassJoin struct {
+	// By defines whether the secondary resource is related to the service
+	// resource by owner reference, by label, or by a reference read from the
+	// service resource
+	By ServiceClassJoinType `json:"by"`
+
+	// Label is the key of the label whose value is the name of the service
+	// resource.  It is required when joining by label.
+	// +optional
+	Label string `json:"label,omitempty"`
+
+	// JsonPath is the path of the field of the service resource holding
+	// the name of the secondary resource (e.g.
+	// `.spec.writeConnectionSecretToRef.name`).  It is required when
+	// joining by JSONPath.
+	// +optional
+	JsonPath string `json:"jsonPath,omitempty"`
+}
+
+// ServiceClassSecondaryResource defines a resource related to the service
+// resource, from which part of the service endpoint definition is read
+type ServiceClassSecondaryResource struct {
+	// APIVersion of the secondary resource
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the secondary resource
+	Kind string `json:"kind"`
+
+	// Join defines how the secondary resource is related to the service resource
+	Join ServiceClassJoin `json:"join"`
+
+	// ResourceFields defines the mappings whose data is read from the
+	// secondary resource
+	ResourceFields []ServiceClassResourceFieldMapping `json:"resourceFields"`
+}
+
+// ServiceClassResourceScope defines whether service resources are
+// namespaced or cluster-scoped
+// +kubebuilder:validation:Enum=Namespaced;Cluster
+type ServiceClassResourceScope string
+
+const (
+	// ServiceClassResourceScopeNamespaced looks service resources up in
+	// the ServiceClass's namespace
+	ServiceClassResourceScopeNamespaced ServiceClassResourceScope = "Namespaced"
+	// ServiceClassResourceScopeCluster looks cluster-scoped service
+	// resources up
+	ServiceClassResourceScopeCluster ServiceClassResourceScope = "Cluster"
+)
+
 // ServiceClassResource defines
 type ServiceClassResource struct {
 	// APIVersion of the underlying service resource
@@ -60,13 +236,72 @@ type ServiceClassResource struct {
 	// Kind of the underlying service resource
 	Kind string `json:"kind"`
 
+	// Scope of the underlying service resource, `Namespaced` or `Cluster`.
+	// Defaults to `Namespaced`.
+	// +optional
+	Scope ServiceClassResourceScope `json:"scope,omitempty"`
+
+	// Selector restricts the service resources managed by the ServiceClass
+	// to the ones whose labels match it.  Many ServiceClasses can manage the
+	// same kind of resources if their selectors are disjoint.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// OwnedBy restricts the service resources managed by the ServiceClass to
+	// the ones owned by a given kind of resources, e.g. the secrets generated
+	// by an operator for its instances.
+	// +optional
+	OwnedBy *ServiceClassResourceOwner `json:"ownedBy,omitempty"`
+
+	// Secondary defines another resource, related to the service resource,
+	// from which part of the service endpoint definition is read (e.g. the
+	// Service generated for a database instance).
+	// +optional
+	Secondary *ServiceClassSecondaryResource `json:"secondary,omitempty"`
+
+	// Related defines further resources, related to the service resource,
+	// from which part of the service endpoint definition is read, so that a
+	// registered service can be composed of many resources (e.g. a custom
+	// resource, the Service generated for it and its connection Secret).
+	// +optional
+	Related []ServiceClassSecondaryResource `json:"related,omitempty"`
+
+	// Helm, when set, makes the ServiceClass discover the releases of a Helm
+	// chart: the resources are Helm's release Secrets (`v1` `Secret`), and
+	// the mappings read the chart, the values and the rendered resources of
+	// the deployed revision of each release.
+	// +optional
+	Helm *ServiceClassHelm `json:"helm,omitempty"`
+
 	// ServiceEndpointDefinitionMappings defines how a key-value mapping projected
 	// into services may be constructed.
 	ServiceEndpointDefinitionMappings ServiceEndpointDefinitionMappings `json:"serviceEndpointDefinitionMappings"`
 }
 
+// ServiceClassHelm selects the Helm releases discovered by a ServiceClass
+type ServiceClassHelm struct {
+	// Chart is the name of the chart whose releases are discovered (e.g.
+	// `postgresql`)
+	Chart string `json:"chart"`
+}
+
+// HealthCheckOverride replaces the health check of a ServiceClass in some
+// environments
+type HealthCheckOverride struct {
+	// Environments the override applies to, as environment constraints
+	// (e.g. `prod` or `!dev-*`)
+	// +kubebuilder:validation:MinItems=1
+	Environments []string `json:"environments"`
+
+	// HealthCheck for the registered services of these environments
+	HealthCheck HealthCheck `json:"healthCheck"`
+}
+
 // ServiceClassSpec defines the desired state of ServiceClass
 type ServiceClassSpec struct {
+	// ServiceMetadata describes the service to developers
+	ServiceMetadata `json:",inline"`
+
 	// Constraints defines under which circumstances the ServiceClass may
 	// be used.
 	// +optional
@@ -76,6 +311,13 @@ type ServiceClassSpec struct {
 	// +optional
 	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
 
+	// HealthCheckOverrides replace the default health check in some
+	// environments, e.g. to run stricter checks in production.  The first
+	// override whose environments match the environment of the registered
+	// services applies.
+	// +optional
+	HealthCheckOverrides []HealthCheckOverride `json:"healthCheckOverrides,omitempty"`
+
 	// Resource defines the resource type to be used to convert into Registered
 	// Services
 	Resource ServiceClassResource `json:"resource"`
@@ -84,6 +326,132 @@ type ServiceClassSpec struct {
 	// identify a service class.  A ServiceClaim whose ServiceClassIdentity
 	// field is a subset of a RegisteredService's keys can claim that service.
 	ServiceClassIdentity []ServiceClassIdentityItem `json:"serviceClassIdentity"`
+
+	// ManualEditPolicy defines how the service agent reacts when the
+	// RegisteredServices and Secrets it manages are edited by someone else.
+	// +optional
+	// +kubebuilder:default=Revert
+	ManualEditPolicy ManualEditPolicy `json:"manualEditPolicy,omitempty"`
+
+	// Paused stops the registration of the resources matched by the
+	// ServiceClass.  The service agent reports them in the status' preview
+	// instead, so that the mappings can be validated before going live.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// SyncInterval is the interval between two full synchronizations of the
+	// resources matched by the ServiceClass, i.e. their re-discovery and the
+	// rewriting of their RegisteredServices.  Defaults to 5m, must be between
+	// 30s and 24h.
+	// +optional
+	SyncInterval *metav1.Duration `json:"syncInterval,omitempty"`
+
+	// AnnotateProvenance makes the service agent annotate the
+	// RegisteredServices with the field each service endpoint definition
+	// item is read from, to support data-governance reviews.
+	// +optional
+	AnnotateProvenance bool `json:"annotateProvenance,omitempty"`
+
+	// ExternalSecrets makes the secret-backed service endpoint definition
+	// values flow through External Secrets Operator stores, instead of
+	// copying them into Secrets in the control plane.
+	// +optional
+	ExternalSecrets *ServiceClassExternalSecrets `json:"externalSecrets,omitempty"`
+
+	// Export makes the service agent render the RegisteredServices into a
+	// bundle in the ServiceClass's namespace, instead of writing them to
+	// the control plane, so that the control plane's state can be managed
+	// declaratively, e.g. with Argo CD or Flux.
+	// +optional
+	Export *ServiceClassExport `json:"export,omitempty"`
+}
+
+// ServiceClassExport defines the bundle the RegisteredServices are rendered
+// into
+type ServiceClassExport struct {
+	// ConfigMap is the name of the ConfigMap holding the manifests of the
+	// RegisteredServices, one key per RegisteredService.  The manifests of
+	// their Secrets are held by the Secret with the same name.
+	ConfigMap string `json:"configMap"`
+}
+
+// ExternalSecretStoreRef refers to an External Secrets Operator store
+type ExternalSecretStoreRef struct {
+	// Name of the store
+	Name string `json:"name"`
+
+	// Kind of the store, `SecretStore` or `ClusterSecretStore`.  Defaults to
+	// `SecretStore`.
+	// +optional
+	// +kubebuilder:validation:Enum=SecretStore;ClusterSecretStore
+	// +kubebuilder:default=SecretStore
+	Kind string `json:"kind,omitempty"`
+}
+
+// ServiceClassExternalSecrets defines the External Secrets Operator stores
+// the secret-backed values of the registered services flow through
+type ServiceClassExternalSecrets struct {
+	// PushStoreRef is the store, in the ServiceClass's cluster and
+	// namespace, the values are pushed to by a PushSecret
+	PushStoreRef ExternalSecretStoreRef `json:"pushStoreRef"`
+
+	// PullStoreRef is the store, in the control plane, the registered
+	// services' secrets are read from by an ExternalSecret
+	PullStoreRef ExternalSecretStoreRef `json:"pullStoreRef"`
+
+	// RemoteKeyPrefix prefixes the keys the values are stored under in the
+	// store.  Defaults to `primaza`.
+	// +optional
+	RemoteKeyPrefix string `json:"remoteKeyPrefix,omitempty"`
+
+	// RefreshInterval of the PushSecrets and ExternalSecrets.  Defaults to
+	// 1 hour.
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// ManualEditPolicy defines how the service agent reacts to manual edits of
+// the objects it manages
+// +kubebuilder:validation:Enum=Revert;Warn;Pause
+type ManualEditPolicy string
+
+const (
+	// ManualEditPolicyRevert overwrites manual edits
+	ManualEditPolicyRevert ManualEditPolicy = "Revert"
+	// ManualEditPolicyWarn keeps manually edited objects as they are, and
+	// keeps on synchronizing the others
+	ManualEditPolicyWarn ManualEditPolicy = "Warn"
+	// ManualEditPolicyPause stops synchronizing all the ServiceClass's
+	// objects while any of them is manually edited
+	ManualEditPolicyPause ManualEditPolicy = "Pause"
+)
+
+const (
+	// ServiceClassConditionManualOverride reports whether the objects
+	// managed for the ServiceClass have been manually edited
+	ServiceClassConditionManualOverride = "ManualOverride"
+	// ServiceClassConditionCredentialsExpiring reports whether the
+	// credentials the service agent uses to connect to the control plane
+	// are expired or expire soon
+	ServiceClassConditionCredentialsExpiring = "CredentialsExpiring"
+	// ServiceClassConditionConnection reports whether the service agent can
+	// connect to the control plane with the permissions it requires
+	ServiceClassConditionConnection = "Connection"
+)
+
+// ClusterScoped tells whether the service resources are cluster-scoped
+func (r ServiceClassResource) ClusterScoped() bool {
+	return r.Scope == ServiceClassResourceScopeCluster
+}
+
+// SecondaryResources returns the secondary resource, if any, followed by the
+// related ones
+func (r ServiceClassResource) SecondaryResources() []ServiceClassSecondaryResource {
+	secondaries := make([]ServiceClassSecondaryResource, 0, len(r.Related)+1)
+	if r.Secondary != nil {
+		secondaries = append(secondaries, *r.Secondary)
+	}
+	return append(secondaries, r.Related...)
 }
 
 func (s ServiceClassSpec) GetEnvironmentConstraints() []string {
@@ -94,13 +462,85 @@ func (s ServiceClassSpec) GetEnvironmentConstraints() []string {
 	return nil
 }
 
+// ServiceClassResourcePreview reports whether a resource matched by a paused
+// ServiceClass would be registered
+type ServiceClassResourcePreview struct {
+	// Name of the resource
+	Name string `json:"name"`
+
+	// Namespace of the resource
+	Namespace string `json:"namespace"`
+
+	// Mappings reports the extraction of each ServiceEndpointDefinition mapping
+	// +optional
+	Mappings []ServiceClassMappingPreview `json:"mappings,omitempty"`
+
+	// Error reports why the mappings can not be evaluated on the resource
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// ServiceClassMappingPreview reports the extraction of a
+// ServiceEndpointDefinition mapping from a resource
+type ServiceClassMappingPreview struct {
+	// Name of the mapping
+	Name string `json:"name"`
+
+	// Extracted is true when a value has been extracted.  Optional mappings
+	// that do not resolve to any value are not extracted, but do not fail.
+	Extracted bool `json:"extracted"`
+
+	// Error reports why the extraction failed
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
 // ServiceClassStatus defines the observed state of ServiceClass
 type ServiceClassStatus struct {
+	// Conditions of the service class, at most one per type.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of the ServiceClass the status
+	// was last computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Preview lists the resources currently matched by the ServiceClass
+	// while it is paused
+	// +optional
+	Preview []ServiceClassResourcePreview `json:"preview,omitempty"`
+
+	// Registration reports the progress of the latest registration of the
+	// resources matched by the ServiceClass
+	// +optional
+	Registration *ServiceClassRegistration `json:"registration,omitempty"`
+
+	// Summary describes the status at a glance.
+	// +optional
+	Summary string `json:"summary,omitempty"`
+}
+
+// ServiceClassRegistration reports the progress of the registration of the
+// resources matched by a ServiceClass
+type ServiceClassRegistration struct {
+	// Registered is the number of matched resources whose RegisteredService
+	// has been written
+	Registered int `json:"registered"`
+
+	// Total is the number of resources matched by the ServiceClass
+	Total int `json:"total"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Paused",type="boolean",JSONPath=".spec.paused",description="whether the ServiceClass is paused"
+//+kubebuilder:printcolumn:name="Registered",type="integer",JSONPath=".status.registration.registered",description="the number of registered resources"
+//+kubebuilder:printcolumn:name="Total",type="integer",JSONPath=".status.registration.total",description="the number of matched resources"
+//+kubebuilder:printcolumn:name="Summary",type="string",JSONPath=".status.summary",description="the status of the ServiceClass at a glance"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // ServiceClass is the Schema for the serviceclasses API
 type ServiceClass struct {
@@ -120,6 +560,25 @@ type ServiceClassList struct {
 	Items           []ServiceClass `json:"items"`
 }
 
+// SyncPeriod returns the interval between two full synchronizations of the
+// resources matched by the ServiceClass
+func (r *ServiceClassSpec) SyncPeriod() time.Duration {
+	if r.SyncInterval == nil {
+		return DefaultSyncInterval
+	}
+	return r.SyncInterval.Duration
+}
+
+// Tenant returns the tenant the service class belongs to, i.e. the Primaza
+// namespace it is defined in.  Copies pushed to worker clusters record it
+// with the primaza.io/tenant label.
+func (r *ServiceClass) Tenant() string {
+	if t, ok := r.Labels[constants.PrimazaTenantLabel]; ok {
+		return t
+	}
+	return r.Namespace
+}
+
 func init() {
 	SchemeBuilder.Register(&ServiceClass{}, &ServiceClassList{})
 }
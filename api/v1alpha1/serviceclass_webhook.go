@@ -19,9 +19,15 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"path"
 	"reflect"
+	"strings"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/util/jsonpath"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -38,37 +44,211 @@ type serviceClassValidator struct {
 }
 
 var _ admission.CustomValidator = &serviceClassValidator{}
+var _ admissionWarner = &serviceClassValidator{}
 
 func (r *ServiceClass) SetupWebhookWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewWebhookManagedBy(mgr).
-		For(r).
-		WithValidator(&serviceClassValidator{
-			client: mgr.GetClient(),
-		}).
-		Complete()
+	v := &serviceClassValidator{
+		client: mgr.GetClient(),
+	}
+	mgr.GetWebhookServer().Register("/validate-primaza-io-v1alpha1-serviceclass", withWarnings(r, v, v))
+	return nil
 }
 
 // TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
 //+kubebuilder:webhook:path=/validate-primaza-io-v1alpha1-serviceclass,mutating=false,failurePolicy=fail,sideEffects=None,groups=primaza.io,resources=serviceclasses,verbs=create;update,versions=v1alpha1,name=vserviceclass.kb.io,admissionReviewVersions=v1
 
 func (r *ServiceClassResource) ValidateMapping() field.ErrorList {
-	errs := field.ErrorList{}
 	names := map[string]struct{}{}
 	childPath := field.NewPath("spec", "resource")
-	for i, mapping := range r.ServiceEndpointDefinitionMappings.ResourceFields {
-		path := childPath.Child("serviceEndpointDefinitionMapping").Index(i)
-		j := jsonpath.New("")
-		formatted := fmt.Sprintf("{%v}", mapping.JsonPath)
-		if err := j.Parse(formatted); err != nil {
+	errs := validateResourceFields(r.ServiceEndpointDefinitionMappings.ResourceFields, childPath.Child("serviceEndpointDefinitionMapping"), names)
+	errs = append(errs, validateSecretRefFields(r.ServiceEndpointDefinitionMappings.SecretRefFields,
+		childPath.Child("serviceEndpointDefinitionMappings", "secretRefFields"), names)...)
+	errs = append(errs, validateHandlerFields(r.ServiceEndpointDefinitionMappings.HandlerFields,
+		childPath.Child("serviceEndpointDefinitionMappings", "handlerFields"), names)...)
+	if r.Secondary != nil {
+		errs = append(errs, validateResourceFields(r.Secondary.ResourceFields, childPath.Child("secondary", "resourceFields"), names)...)
+	}
+	for i, related := range r.Related {
+		errs = append(errs, validateResourceFields(related.ResourceFields, childPath.Child("related").Index(i).Child("resourceFields"), names)...)
+	}
+
+	return errs
+}
+
+// validateResourceFields validates the given mappings, whose names must not
+// be in names.  It adds the mappings' names to names.
+func validateResourceFields(mappings []ServiceClassResourceFieldMapping, fieldPath *field.Path, names map[string]struct{}) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, mapping := range mappings {
+		path := fieldPath.Index(i)
+		if !isValidJSONPath(mapping.JsonPath) {
 			errs = append(errs, field.Invalid(path.Child("jsonPath"), mapping.JsonPath, "Invalid JSONPath"))
 		}
-		if _, found := names[mapping.Name]; found {
-			errs = append(errs, field.Duplicate(path.Child("name"), mapping.Name))
-		} else {
-			names[mapping.Name] = struct{}{}
+		errs = append(errs, validateMappingName(mapping.Name, path.Child("name"), names)...)
+	}
+	return errs
+}
+
+// validateSecretRefFields validates the given mappings, whose names must not
+// be in names.  It adds the mappings' names to names.
+func validateSecretRefFields(mappings []ServiceClassSecretRefFieldMapping, fieldPath *field.Path, names map[string]struct{}) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, mapping := range mappings {
+		path := fieldPath.Index(i)
+		if !isValidJSONPath(mapping.SecretName) {
+			errs = append(errs, field.Invalid(path.Child("secretName"), mapping.SecretName, "Invalid JSONPath"))
+		}
+		if !isValidJSONPath(mapping.SecretKey) {
+			errs = append(errs, field.Invalid(path.Child("secretKey"), mapping.SecretKey, "Invalid JSONPath"))
+		}
+		if mapping.Binary && len(mapping.Transformations) > 0 {
+			errs = append(errs, field.Forbidden(path.Child("transformations"), "Binary data can not be transformed"))
 		}
+		errs = append(errs, validateMappingName(mapping.Name, path.Child("name"), names)...)
 	}
+	return errs
+}
 
+// validateHandlerFields validates the given mappings, whose names must not be
+// in names.  It adds the mappings' names to names.
+func validateHandlerFields(mappings []ServiceClassHandlerFieldMapping, fieldPath *field.Path, names map[string]struct{}) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, mapping := range mappings {
+		path := fieldPath.Index(i)
+		if mapping.Handler == "" {
+			errs = append(errs, field.Required(path.Child("handler"), "Handler cannot be empty"))
+		}
+		errs = append(errs, validateMappingName(mapping.Name, path.Child("name"), names)...)
+	}
+	return errs
+}
+
+// validateMappingName checks that name is not in names, and adds it
+func validateMappingName(name string, path *field.Path, names map[string]struct{}) field.ErrorList {
+	if _, found := names[name]; found {
+		return field.ErrorList{field.Duplicate(path, name)}
+	}
+	names[name] = struct{}{}
+	return nil
+}
+
+// isValidJSONPath tells whether the given JSONPath can be parsed
+func isValidJSONPath(path string) bool {
+	return jsonpath.New("").Parse(fmt.Sprintf("{%v}", path)) == nil
+}
+
+func (r *ServiceClassResource) ValidateSecondary() field.ErrorList {
+	errs := field.ErrorList{}
+	p := field.NewPath("spec", "resource")
+	if r.Secondary != nil {
+		errs = append(errs, validateSecondaryResource(*r.Secondary, p.Child("secondary"))...)
+	}
+	for i, related := range r.Related {
+		errs = append(errs, validateSecondaryResource(related, p.Child("related").Index(i))...)
+	}
+	return errs
+}
+
+// validateSecondaryResource validates the type and the join of a secondary
+// resource
+func validateSecondaryResource(secondary ServiceClassSecondaryResource, p *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if secondary.APIVersion == "" {
+		errs = append(errs, field.Required(p.Child("apiVersion"), "Secondary resource's APIVersion cannot be empty"))
+	}
+	if secondary.Kind == "" {
+		errs = append(errs, field.Required(p.Child("kind"), "Secondary resource's Kind cannot be empty"))
+	}
+	switch secondary.Join.By {
+	case ServiceClassJoinByLabel:
+		if secondary.Join.Label == "" {
+			errs = append(errs, field.Required(p.Child("join", "label"), "Label cannot be empty when joining by label"))
+		}
+	case ServiceClassJoinByJSONPath:
+		if secondary.Join.JsonPath == "" {
+			errs = append(errs, field.Required(p.Child("join", "jsonPath"), "JSONPath cannot be empty when joining by JSONPath"))
+		} else if !isValidJSONPath(secondary.Join.JsonPath) {
+			errs = append(errs, field.Invalid(p.Child("join", "jsonPath"), secondary.Join.JsonPath, "Invalid JSONPath"))
+		}
+	}
+	return errs
+}
+
+func (r *ServiceClassResource) ValidateOwnedBy() field.ErrorList {
+	errs := field.ErrorList{}
+	if r.OwnedBy == nil {
+		return errs
+	}
+
+	p := field.NewPath("spec", "resource", "ownedBy")
+	if r.OwnedBy.APIVersion == "" {
+		errs = append(errs, field.Required(p.Child("apiVersion"), "Owner's APIVersion cannot be empty"))
+	}
+	if r.OwnedBy.Kind == "" {
+		errs = append(errs, field.Required(p.Child("kind"), "Owner's Kind cannot be empty"))
+	}
+	if _, err := path.Match(r.OwnedBy.Name, ""); err != nil {
+		errs = append(errs, field.Invalid(p.Child("name"), r.OwnedBy.Name, "Invalid name pattern"))
+	}
+	return errs
+}
+
+// ValidateHelm checks that the ServiceClasses discovering Helm releases
+// watch the release Secrets
+func (r *ServiceClassResource) ValidateHelm() field.ErrorList {
+	errs := field.ErrorList{}
+	if r.Helm == nil {
+		return errs
+	}
+
+	p := field.NewPath("spec", "resource")
+	if r.Helm.Chart == "" {
+		errs = append(errs, field.Required(p.Child("helm", "chart"), "Chart cannot be empty"))
+	}
+	if r.APIVersion != "v1" || r.Kind != "Secret" {
+		errs = append(errs, field.Invalid(p, fmt.Sprintf("%s.%s", r.Kind, r.APIVersion), "Helm releases are discovered through their release Secrets, resource must be Secret.v1"))
+	}
+	if r.ClusterScoped() {
+		errs = append(errs, field.Invalid(p.Child("scope"), r.Scope, "Helm release Secrets are namespaced"))
+	}
+	return errs
+}
+
+// ValidateExport checks that the RegisteredServices are exported to a valid
+// ConfigMap.  The Secrets of exported RegisteredServices are rendered along
+// with them, so they can not flow through External Secrets Operator stores.
+func (r *ServiceClassSpec) ValidateExport() field.ErrorList {
+	errs := field.ErrorList{}
+	if r.Export == nil {
+		return errs
+	}
+
+	p := field.NewPath("spec", "export")
+	if r.Export.ConfigMap == "" {
+		errs = append(errs, field.Required(p.Child("configMap"), "ConfigMap cannot be empty"))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(r.Export.ConfigMap) {
+			errs = append(errs, field.Invalid(p.Child("configMap"), r.Export.ConfigMap, msg))
+		}
+	}
+	if r.ExternalSecrets != nil {
+		errs = append(errs, field.Forbidden(p, "Exported registered services cannot use external secrets"))
+	}
+	return errs
+}
+
+// ValidateSyncInterval checks that the resources are synchronized neither
+// too often for the API servers, nor too rarely to repair drifts in time
+func (r *ServiceClassSpec) ValidateSyncInterval() field.ErrorList {
+	errs := field.ErrorList{}
+	if r.SyncInterval == nil {
+		return errs
+	}
+
+	if d := r.SyncInterval.Duration; d < MinSyncInterval || d > MaxSyncInterval {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "syncInterval"), d.String(),
+			fmt.Sprintf("must be between %s and %s", MinSyncInterval, MaxSyncInterval)))
+	}
 	return errs
 }
 
@@ -87,6 +267,14 @@ func (v *serviceClassValidator) ValidateCreate(ctx context.Context, obj runtime.
 		return err
 	}
 	errs = append(errs, r.Spec.Resource.ValidateMapping()...)
+	errs = append(errs, r.Spec.Resource.ValidateOwnedBy()...)
+	errs = append(errs, r.Spec.Resource.ValidateHelm()...)
+	errs = append(errs, r.Spec.Resource.ValidateSecondary()...)
+	errs = append(errs, r.Spec.ValidateExport()...)
+	errs = append(errs, r.Spec.ValidateSyncInterval()...)
+	errs = append(errs, r.Spec.HealthCheck.Validate(field.NewPath("spec", "healthCheck"))...)
+	errs = append(errs, validateHealthCheckOverrides(field.NewPath("spec", "healthCheckOverrides"), r.Spec.HealthCheckOverrides)...)
+	errs = append(errs, ValidateEnvironmentConstraints(field.NewPath("spec", "constraints", "environments"), r.Spec.GetEnvironmentConstraints())...)
 	return errs.ToAggregate()
 }
 
@@ -127,14 +315,17 @@ func (v *serviceClassValidator) ValidateUpdate(ctx context.Context, oldObj runti
 	if oldServiceClass.Spec.Resource.Kind != newClass.Spec.Resource.Kind {
 		errs = append(errs, field.Invalid(childPath.Child("kind"), newClass.Spec.Resource.Kind, "Kind is immutable"))
 	}
-	// a cheap way of doing data sets; struct{} is zero-sized, so we don't needlessly make allocations
-	oldMappings := map[ServiceClassResourceFieldMapping]struct{}{}
-	newMappings := map[ServiceClassResourceFieldMapping]struct{}{}
+	if oldServiceClass.Spec.Resource.ClusterScoped() != newClass.Spec.Resource.ClusterScoped() {
+		errs = append(errs, field.Invalid(childPath.Child("scope"), newClass.Spec.Resource.Scope, "Scope is immutable"))
+	}
+	// index mappings by name, so that their order does not matter
+	oldMappings := map[string]ServiceClassResourceFieldMapping{}
+	newMappings := map[string]ServiceClassResourceFieldMapping{}
 	for _, item := range oldServiceClass.Spec.Resource.ServiceEndpointDefinitionMappings.ResourceFields {
-		oldMappings[item] = struct{}{}
+		oldMappings[item.Name] = item
 	}
 	for _, item := range newClass.Spec.Resource.ServiceEndpointDefinitionMappings.ResourceFields {
-		newMappings[item] = struct{}{}
+		newMappings[item.Name] = item
 	}
 	if !reflect.DeepEqual(oldMappings, newMappings) {
 		errs = append(errs,
@@ -143,6 +334,14 @@ func (v *serviceClassValidator) ValidateUpdate(ctx context.Context, oldObj runti
 				"ServiceEndpointDefinitionMapping is immutable"))
 	}
 	errs = append(errs, newClass.Spec.Resource.ValidateMapping()...)
+	errs = append(errs, newClass.Spec.Resource.ValidateOwnedBy()...)
+	errs = append(errs, newClass.Spec.Resource.ValidateHelm()...)
+	errs = append(errs, newClass.Spec.Resource.ValidateSecondary()...)
+	errs = append(errs, newClass.Spec.ValidateExport()...)
+	errs = append(errs, newClass.Spec.ValidateSyncInterval()...)
+	errs = append(errs, newClass.Spec.HealthCheck.Validate(field.NewPath("spec", "healthCheck"))...)
+	errs = append(errs, validateHealthCheckOverrides(field.NewPath("spec", "healthCheckOverrides"), newClass.Spec.HealthCheckOverrides)...)
+	errs = append(errs, ValidateEnvironmentConstraints(field.NewPath("spec", "constraints", "environments"), newClass.Spec.GetEnvironmentConstraints())...)
 	list, err := v.IsDuplicateClass(ctx, *newClass)
 	if err != nil {
 		return err
@@ -152,9 +351,42 @@ func (v *serviceClassValidator) ValidateUpdate(ctx context.Context, oldObj runti
 	return errs.ToAggregate()
 }
 
+// credentialLikeKeys are substrings of the names of mappings whose values are
+// likely to be credentials
+var credentialLikeKeys = []string{"password", "secret", "token", "credential", "key", "cert"}
+
+// Warnings implements admissionWarner
+func (v *serviceClassValidator) Warnings(ctx context.Context, obj runtime.Object) []string {
+	r, ok := obj.(*ServiceClass)
+	if !ok {
+		return nil
+	}
+
+	var warnings []string
+	for i, mapping := range r.Spec.Resource.ServiceEndpointDefinitionMappings.ResourceFields {
+		path := field.NewPath("spec", "resource", "serviceEndpointDefinitionMappings", "resourceFields").Index(i)
+		if jp := strings.Trim(strings.TrimSpace(mapping.JsonPath), "{}"); jp == ".metadata" || jp == "metadata" {
+			warnings = append(warnings, fmt.Sprintf("%s: jsonPath %s maps the whole metadata of the service resource, consider mapping the fields you need only",
+				path.Child("jsonPath"), mapping.JsonPath))
+		}
+
+		name := strings.ToLower(mapping.Name)
+		for _, k := range credentialLikeKeys {
+			if strings.Contains(name, k) {
+				warnings = append(warnings, fmt.Sprintf("%s: %s looks like a credential, consider reading it from a secret with a secretRefFields mapping",
+					path.Child("name"), mapping.Name))
+				break
+			}
+		}
+	}
+	return warnings
+}
+
+// IsDuplicateClass reports the service classes of the same tenant that
+// manage the same resources as serviceClass in its namespace
 func (validator *serviceClassValidator) IsDuplicateClass(ctx context.Context, serviceClass ServiceClass) (field.ErrorList, error) {
 	classList := ServiceClassList{}
-	err := validator.client.List(ctx, &classList)
+	err := validator.client.List(ctx, &classList, client.InNamespace(serviceClass.Namespace))
 	if err != nil {
 		// The list call failed; report as an error.
 		return nil, err
@@ -163,8 +395,19 @@ func (validator *serviceClassValidator) IsDuplicateClass(ctx context.Context, se
 	serviceclasslog.Info("checking items", "items", classList)
 	for _, item := range classList.Items {
 		if serviceClass.Name != item.Name &&
+			serviceClass.Namespace == item.Namespace &&
+			serviceClass.Tenant() == item.Tenant() &&
 			serviceClass.Spec.Resource.Kind == item.Spec.Resource.Kind &&
 			serviceClass.Spec.Resource.APIVersion == item.Spec.Resource.APIVersion {
+			disjoint, err := disjointSelectors(serviceClass.Spec.Resource.Selector, item.Spec.Resource.Selector)
+			if err != nil {
+				return field.ErrorList{
+					field.Invalid(field.NewPath("spec", "resource", "selector"), serviceClass.Spec.Resource.Selector, err.Error())}, nil
+			}
+			if disjoint || differentCharts(serviceClass.Spec.Resource.Helm, item.Spec.Resource.Helm) {
+				continue
+			}
+
 			// We found another ServiceClass that manages the same kind/apiVersion in this namespace, so report it as a match.
 			return field.ErrorList{
 				field.Forbidden(field.NewPath("spec", "resource"),
@@ -179,3 +422,112 @@ func (validator *serviceClassValidator) IsDuplicateClass(ctx context.Context, se
 	return nil, nil
 
 }
+
+// differentCharts tells whether two ServiceClasses discover the releases of
+// different Helm charts
+func differentCharts(a, b *ServiceClassHelm) bool {
+	return a != nil && b != nil && a.Chart != b.Chart
+}
+
+// keyRequirements sums up the requirements of a label selector on a label key
+type keyRequirements struct {
+	// in is the set of allowed values, nil if any value is allowed
+	in sets.String
+	// notIn is the set of forbidden values
+	notIn sets.String
+	// exists tells whether the label must exist
+	exists bool
+	// notExists tells whether the label must not exist
+	notExists bool
+}
+
+// disjointSelectors tells whether no set of labels can match both selectors.
+// It returns false when it can not prove it, e.g. when a selector is nil
+// and so it matches everything.
+func disjointSelectors(a, b *metav1.LabelSelector) (bool, error) {
+	ra, err := selectorRequirements(a)
+	if err != nil {
+		return false, err
+	}
+	rb, err := selectorRequirements(b)
+	if err != nil {
+		return false, err
+	}
+
+	for k, r := range ra {
+		if o, ok := rb[k]; ok {
+			r = r.merge(o)
+		}
+		if !r.satisfiable() {
+			return true, nil
+		}
+	}
+	for k, r := range rb {
+		if _, ok := ra[k]; !ok && !r.satisfiable() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// selectorRequirements returns the requirements of the selector by label key
+func selectorRequirements(s *metav1.LabelSelector) (map[string]keyRequirements, error) {
+	rs := map[string]keyRequirements{}
+	if s == nil {
+		return rs, nil
+	}
+
+	sel, err := metav1.LabelSelectorAsSelector(s)
+	if err != nil {
+		return nil, err
+	}
+	reqs, _ := sel.Requirements()
+	for _, req := range reqs {
+		r := keyRequirements{notIn: sets.NewString()}
+		switch req.Operator() {
+		case selection.In, selection.Equals, selection.DoubleEquals:
+			r.in = sets.NewString(req.Values().List()...)
+			r.exists = true
+		case selection.NotIn, selection.NotEquals:
+			r.notIn.Insert(req.Values().List()...)
+		case selection.Exists:
+			r.exists = true
+		case selection.DoesNotExist:
+			r.notExists = true
+		default:
+			// other operators are not allowed in label selectors, and
+			// can not restrict the matched labels
+			continue
+		}
+		if o, ok := rs[req.Key()]; ok {
+			r = r.merge(o)
+		}
+		rs[req.Key()] = r
+	}
+	return rs, nil
+}
+
+// merge returns the requirements satisfied by the values satisfying both r and o
+func (r keyRequirements) merge(o keyRequirements) keyRequirements {
+	m := keyRequirements{
+		in:        r.in,
+		notIn:     r.notIn.Union(o.notIn),
+		exists:    r.exists || o.exists,
+		notExists: r.notExists || o.notExists,
+	}
+	switch {
+	case m.in == nil:
+		m.in = o.in
+	case o.in != nil:
+		m.in = m.in.Intersection(o.in)
+	}
+	return m
+}
+
+// satisfiable tells whether a label value can satisfy the requirements
+func (r keyRequirements) satisfiable() bool {
+	if r.notExists {
+		return !r.exists
+	}
+	return r.in == nil || r.in.Difference(r.notIn).Len() > 0
+}
@@ -18,13 +18,17 @@ package v1alpha1
 
 import (
 	"context"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/primaza/primaza/pkg/primaza/constants"
 )
 
 func newServiceClass(name, namespace string, spec ServiceClassSpec) ServiceClass {
@@ -98,6 +102,203 @@ var _ = Describe("Webhook tests", func() {
 			field.ErrorList{
 				field.Duplicate(field.NewPath("spec", "resource", "serviceEndpointDefinitionMapping").Index(1).Child("name"), "x"),
 			}.ToAggregate()),
+		Entry("Invalid secretRefFields",
+			newServiceClass("spam", "eggs",
+				ServiceClassSpec{
+					Resource: ServiceClassResource{
+						APIVersion: "foo.bar/v1",
+						Kind:       "baz",
+						ServiceEndpointDefinitionMappings: ServiceEndpointDefinitionMappings{
+							ResourceFields: []ServiceClassResourceFieldMapping{
+								{Name: "password", JsonPath: ".spec.password"},
+							},
+							SecretRefFields: []ServiceClassSecretRefFieldMapping{
+								{Name: "password", SecretName: ".spec.secret", SecretKey: ".spec.key"},
+								{Name: "user", SecretName: ".spec.secret[", SecretKey: ".spec.user[0"},
+								{Name: "user", SecretName: ".spec.secret", SecretKey: ".spec.user"},
+							},
+						},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Duplicate(field.NewPath("spec", "resource", "serviceEndpointDefinitionMappings", "secretRefFields").Index(0).Child("name"), "password"),
+				field.Invalid(field.NewPath("spec", "resource", "serviceEndpointDefinitionMappings", "secretRefFields").Index(1).Child("secretName"), ".spec.secret[", "Invalid JSONPath"),
+				field.Invalid(field.NewPath("spec", "resource", "serviceEndpointDefinitionMappings", "secretRefFields").Index(1).Child("secretKey"), ".spec.user[0", "Invalid JSONPath"),
+				field.Duplicate(field.NewPath("spec", "resource", "serviceEndpointDefinitionMappings", "secretRefFields").Index(2).Child("name"), "user"),
+			}.ToAggregate()),
+		Entry("Transformed binary secretRefFields",
+			newServiceClass("spam", "eggs",
+				ServiceClassSpec{
+					Resource: ServiceClassResource{
+						APIVersion: "foo.bar/v1",
+						Kind:       "baz",
+						ServiceEndpointDefinitionMappings: ServiceEndpointDefinitionMappings{
+							SecretRefFields: []ServiceClassSecretRefFieldMapping{
+								{Name: "password", SecretName: ".spec.secret", SecretKey: ".spec.key",
+									Transformations: []ValueTransformation{ValueTransformationTrimSpace}},
+								{Name: "keystore", SecretName: ".spec.secret", SecretKey: ".spec.keystore", Binary: true,
+									Transformations: []ValueTransformation{ValueTransformationBase64Decode}},
+							},
+						},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "resource", "serviceEndpointDefinitionMappings", "secretRefFields").Index(1).Child("transformations"), "Binary data can not be transformed"),
+			}.ToAggregate()),
+		Entry("Invalid handlerFields",
+			newServiceClass("spam", "eggs",
+				ServiceClassSpec{
+					Resource: ServiceClassResource{
+						APIVersion: "foo.bar/v1",
+						Kind:       "baz",
+						ServiceEndpointDefinitionMappings: ServiceEndpointDefinitionMappings{
+							ResourceFields: []ServiceClassResourceFieldMapping{
+								{Name: "host", JsonPath: ".spec.host"},
+							},
+							HandlerFields: []ServiceClassHandlerFieldMapping{
+								{Name: "host", Handler: "ack-rds"},
+								{Name: "port"},
+							},
+						},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Duplicate(field.NewPath("spec", "resource", "serviceEndpointDefinitionMappings", "handlerFields").Index(0).Child("name"), "host"),
+				field.Required(field.NewPath("spec", "resource", "serviceEndpointDefinitionMappings", "handlerFields").Index(1).Child("handler"), "Handler cannot be empty"),
+			}.ToAggregate()),
+		Entry("Invalid owner",
+			newServiceClass("spam", "eggs",
+				ServiceClassSpec{
+					Resource: ServiceClassResource{
+						APIVersion: "v1",
+						Kind:       "Secret",
+						OwnedBy: &ServiceClassResourceOwner{
+							Kind: "PostgresCluster",
+							Name: "prod-[",
+						},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Required(field.NewPath("spec", "resource", "ownedBy", "apiVersion"), "Owner's APIVersion cannot be empty"),
+				field.Invalid(field.NewPath("spec", "resource", "ownedBy", "name"), "prod-[", "Invalid name pattern"),
+			}.ToAggregate()),
+		Entry("Invalid helm release discovery",
+			newServiceClass("spam", "eggs",
+				ServiceClassSpec{
+					Resource: ServiceClassResource{
+						APIVersion: "foo.bar/v1",
+						Kind:       "baz",
+						Helm:       &ServiceClassHelm{},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Required(field.NewPath("spec", "resource", "helm", "chart"), "Chart cannot be empty"),
+				field.Invalid(field.NewPath("spec", "resource"), "baz.foo.bar/v1",
+					"Helm releases are discovered through their release Secrets, resource must be Secret.v1"),
+			}.ToAggregate()),
+		Entry("Invalid export",
+			newServiceClass("spam", "eggs",
+				ServiceClassSpec{
+					Resource: ServiceClassResource{
+						APIVersion: "foo.bar/v1",
+						Kind:       "baz",
+					},
+					ExternalSecrets: &ServiceClassExternalSecrets{},
+					Export:          &ServiceClassExport{ConfigMap: "Registered_Services"},
+				},
+			),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "export", "configMap"), "Registered_Services",
+					validation.IsDNS1123Subdomain("Registered_Services")[0]),
+				field.Forbidden(field.NewPath("spec", "export"), "Exported registered services cannot use external secrets"),
+			}.ToAggregate()),
+		Entry("Invalid sync interval",
+			newServiceClass("spam", "eggs",
+				ServiceClassSpec{
+					Resource: ServiceClassResource{
+						APIVersion: "foo.bar/v1",
+						Kind:       "baz",
+					},
+					SyncInterval: &v1.Duration{Duration: time.Second},
+				},
+			),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "syncInterval"), "1s", "must be between 30s and 24h0m0s"),
+			}.ToAggregate()),
+		Entry("Invalid secondary resource",
+			newServiceClass("spam", "eggs",
+				ServiceClassSpec{
+					Resource: ServiceClassResource{
+						APIVersion: "foo.bar/v1",
+						Kind:       "baz",
+						ServiceEndpointDefinitionMappings: ServiceEndpointDefinitionMappings{
+							ResourceFields: []ServiceClassResourceFieldMapping{
+								{Name: "host", JsonPath: ".spec.host"},
+							},
+						},
+						Secondary: &ServiceClassSecondaryResource{
+							APIVersion: "v1",
+							Kind:       "Service",
+							Join:       ServiceClassJoin{By: ServiceClassJoinByLabel},
+							ResourceFields: []ServiceClassResourceFieldMapping{
+								{Name: "host", JsonPath: ".spec.clusterIP"},
+								{Name: "port", JsonPath: ".spec.ports[0].port"},
+							},
+						},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Duplicate(field.NewPath("spec", "resource", "secondary", "resourceFields").Index(0).Child("name"), "host"),
+				field.Required(field.NewPath("spec", "resource", "secondary", "join", "label"), "Label cannot be empty when joining by label"),
+			}.ToAggregate()),
+		Entry("Invalid related resources",
+			newServiceClass("spam", "eggs",
+				ServiceClassSpec{
+					Resource: ServiceClassResource{
+						APIVersion: "foo.bar/v1",
+						Kind:       "baz",
+						ServiceEndpointDefinitionMappings: ServiceEndpointDefinitionMappings{
+							ResourceFields: []ServiceClassResourceFieldMapping{
+								{Name: "host", JsonPath: ".spec.host"},
+							},
+						},
+						Related: []ServiceClassSecondaryResource{
+							{
+								APIVersion: "v1",
+								Kind:       "Service",
+								Join:       ServiceClassJoin{By: ServiceClassJoinByOwner},
+								ResourceFields: []ServiceClassResourceFieldMapping{
+									{Name: "port", JsonPath: ".spec.ports[0].port"},
+								},
+							},
+							{
+								APIVersion: "v1",
+								Kind:       "ConfigMap",
+								Join:       ServiceClassJoin{By: ServiceClassJoinByJSONPath},
+								ResourceFields: []ServiceClassResourceFieldMapping{
+									{Name: "port", JsonPath: ".data.port"},
+								},
+							},
+							{
+								APIVersion: "v1",
+								Kind:       "ConfigMap",
+								Join:       ServiceClassJoin{By: ServiceClassJoinByJSONPath, JsonPath: ".spec.configRef["},
+							},
+						},
+					},
+				},
+			),
+			field.ErrorList{
+				field.Duplicate(field.NewPath("spec", "resource", "related").Index(1).Child("resourceFields").Index(0).Child("name"), "port"),
+				field.Required(field.NewPath("spec", "resource", "related").Index(1).Child("join", "jsonPath"), "JSONPath cannot be empty when joining by JSONPath"),
+				field.Invalid(field.NewPath("spec", "resource", "related").Index(2).Child("join", "jsonPath"), ".spec.configRef[", "Invalid JSONPath"),
+			}.ToAggregate()),
 	)
 
 	DescribeTable("Update validation failures",
@@ -138,6 +339,25 @@ var _ = Describe("Webhook tests", func() {
 			field.ErrorList{
 				field.Invalid(field.NewPath("spec", "resource", "kind"), "bam", "Kind is immutable"),
 			}.ToAggregate()),
+		Entry("Resource Scope is immutable",
+			newServiceClass("spam", "eggs",
+				ServiceClassSpec{
+					Resource: ServiceClassResource{
+						APIVersion: "foo.bar/v1",
+						Kind:       "baz",
+					},
+				}),
+			newServiceClass("spam", "eggs",
+				ServiceClassSpec{
+					Resource: ServiceClassResource{
+						APIVersion: "foo.bar/v1",
+						Kind:       "baz",
+						Scope:      ServiceClassResourceScopeCluster,
+					},
+				}),
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "resource", "scope"), ServiceClassResourceScopeCluster, "Scope is immutable"),
+			}.ToAggregate()),
 		Entry("Resource APIVersion is immutable",
 			newServiceClass("spam", "eggs",
 				ServiceClassSpec{
@@ -298,4 +518,103 @@ var _ = Describe("Webhook tests", func() {
 			}.ToAggregate(),
 		))
 	})
+
+	It("should allow service classes with the same resource type in different namespaces or tenants, or with disjoint selectors", func() {
+		schemeBuilder, err := SchemeBuilder.Build()
+		Expect(err).NotTo(HaveOccurred())
+
+		class := newServiceClass("spam", "eggs", ServiceClassSpec{
+			Resource: ServiceClassResource{
+				APIVersion: "foo.bar/v1",
+				Kind:       "baz",
+				Selector: &v1.LabelSelector{
+					MatchLabels: map[string]string{"tier": "gold"},
+				},
+			},
+		})
+
+		validator = serviceClassValidator{
+			client: fake.NewClientBuilder().
+				WithScheme(schemeBuilder).
+				WithLists(&ServiceClassList{}).
+				WithRuntimeObjects(class.DeepCopy()).
+				Build(),
+		}
+
+		other := newServiceClass("beans", "ham", ServiceClassSpec{Resource: ServiceClassResource{APIVersion: "foo.bar/v1", Kind: "baz"}})
+		Expect(validator.ValidateCreate(context.Background(), &other)).NotTo(HaveOccurred())
+
+		// service classes of other tenants pushed to the same namespace
+		other = newServiceClass("beans", "eggs", ServiceClassSpec{Resource: ServiceClassResource{APIVersion: "foo.bar/v1", Kind: "baz"}})
+		other.Labels = map[string]string{constants.PrimazaTenantLabel: "another-tenant"}
+		Expect(validator.ValidateCreate(context.Background(), &other)).NotTo(HaveOccurred())
+
+		other = newServiceClass("beans", "eggs", ServiceClassSpec{
+			Resource: ServiceClassResource{
+				APIVersion: "foo.bar/v1",
+				Kind:       "baz",
+				Selector: &v1.LabelSelector{
+					MatchExpressions: []v1.LabelSelectorRequirement{
+						{Key: "tier", Operator: v1.LabelSelectorOpIn, Values: []string{"silver", "bronze"}},
+					},
+				},
+			},
+		})
+		Expect(validator.ValidateCreate(context.Background(), &other)).NotTo(HaveOccurred())
+
+		other.Spec.Resource.Selector.MatchExpressions[0].Values = append(other.Spec.Resource.Selector.MatchExpressions[0].Values, "gold")
+		Expect(validator.ValidateCreate(context.Background(), &other)).To(Equal(
+			field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "resource"), "Service Class spam already manages services of type baz.foo.bar/v1"),
+			}.ToAggregate(),
+		))
+	})
+
+	DescribeTable("Disjoint selectors",
+		func(a, b *v1.LabelSelector, expected bool) {
+			Expect(disjointSelectors(a, b)).To(Equal(expected))
+			Expect(disjointSelectors(b, a)).To(Equal(expected))
+		},
+		Entry("nil selectors", nil, nil, false),
+		Entry("nil and non-nil selectors",
+			nil,
+			&v1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}},
+			false),
+		Entry("different values",
+			&v1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}},
+			&v1.LabelSelector{MatchLabels: map[string]string{"tier": "silver"}},
+			true),
+		Entry("different keys",
+			&v1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}},
+			&v1.LabelSelector{MatchLabels: map[string]string{"region": "eu"}},
+			false),
+		Entry("value and excluded values",
+			&v1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}},
+			&v1.LabelSelector{MatchExpressions: []v1.LabelSelectorRequirement{
+				{Key: "tier", Operator: v1.LabelSelectorOpNotIn, Values: []string{"gold", "silver"}},
+			}},
+			true),
+		Entry("value and not excluded values",
+			&v1.LabelSelector{MatchLabels: map[string]string{"tier": "bronze"}},
+			&v1.LabelSelector{MatchExpressions: []v1.LabelSelectorRequirement{
+				{Key: "tier", Operator: v1.LabelSelectorOpNotIn, Values: []string{"gold", "silver"}},
+			}},
+			false),
+		Entry("existing and missing label",
+			&v1.LabelSelector{MatchExpressions: []v1.LabelSelectorRequirement{
+				{Key: "tier", Operator: v1.LabelSelectorOpExists},
+			}},
+			&v1.LabelSelector{MatchExpressions: []v1.LabelSelectorRequirement{
+				{Key: "tier", Operator: v1.LabelSelectorOpDoesNotExist},
+			}},
+			true),
+		Entry("missing label and excluded values",
+			&v1.LabelSelector{MatchExpressions: []v1.LabelSelectorRequirement{
+				{Key: "tier", Operator: v1.LabelSelectorOpDoesNotExist},
+			}},
+			&v1.LabelSelector{MatchExpressions: []v1.LabelSelectorRequirement{
+				{Key: "tier", Operator: v1.LabelSelectorOpNotIn, Values: []string{"gold"}},
+			}},
+			false),
+	)
 })
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UpdateSummary sets the status summary of the ServiceClass from whether it
+// is paused, from its conditions and from the progress of its registration,
+// and updates its readiness
+func (sc *ServiceClass) UpdateSummary() {
+	sc.UpdateReadiness()
+	switch override := meta.FindStatusCondition(sc.Status.Conditions, ServiceClassConditionManualOverride); {
+	case sc.Spec.Paused:
+		sc.Status.Summary = fmt.Sprintf("Paused, %d resources matched", len(sc.Status.Preview))
+	case override != nil && override.Status == metav1.ConditionTrue:
+		sc.Status.Summary = withMessage("Manually edited", override.Message)
+	case sc.Status.Registration != nil:
+		sc.Status.Summary = fmt.Sprintf("Registered %d/%d services", sc.Status.Registration.Registered, sc.Status.Registration.Total)
+	default:
+		sc.Status.Summary = "Registering services"
+	}
+}
+
+// UpdateSummary sets the status summary of the RegisteredService from its
+// state and from the result of its latest health check, and updates its
+// readiness
+func (rs *RegisteredService) UpdateSummary() {
+	rs.UpdateReadiness()
+	state := rs.Status.State
+	if state == "" {
+		state = "Pending"
+	}
+	healthy := meta.FindStatusCondition(rs.Status.Conditions, RegisteredServiceConditionHealthy)
+	switch {
+	case healthy == nil:
+		rs.Status.Summary = state
+	case healthy.Status == metav1.ConditionTrue:
+		rs.Status.Summary = state + ", healthy"
+	default:
+		rs.Status.Summary = withMessage(state+", unhealthy", healthy.Message)
+	}
+	if rs.Status.IdleSince != nil {
+		rs.Status.Summary += ", idle since " + rs.Status.IdleSince.UTC().Format("2006-01-02")
+	}
+	if m := rs.Spec.Maintenance; m != nil {
+		rs.Status.Summary = withMessage(rs.Status.Summary+", in maintenance", m.Message)
+	}
+}
+
+// UpdateSummary sets the status summary of the ClusterEnvironment from its
+// state and from the messages of its failed conditions, and updates its
+// readiness
+func (ce *ClusterEnvironment) UpdateSummary() {
+	ce.UpdateReadiness()
+	summary := fmt.Sprintf("%s in %s", ce.Status.State, ce.Spec.EnvironmentName)
+	if ce.Status.State != ClusterEnvironmentStateOnline {
+		summary = withMessage(summary, ce.Status.failureMessage())
+	}
+	ce.Status.Summary = summary
+}
+
+// UpdateSummary sets the status summary of the ServiceClaim from its state,
+// the claimed RegisteredService and the state of its bindings, and updates
+// its readiness
+func (sc *ServiceClaim) UpdateSummary() {
+	sc.UpdateReadiness()
+	if sc.Status.State != ServiceClaimStateResolved {
+		summary := string(sc.Status.State)
+		if sc.Status.PreviousRegisteredService != "" {
+			summary += ", rebinding from " + sc.Status.PreviousRegisteredService
+		}
+		if ready := meta.FindStatusCondition(sc.Status.Conditions, ServiceClaimConditionReady); ready != nil && ready.Status == metav1.ConditionFalse {
+			summary = withMessage(summary, ready.Message)
+		}
+		sc.Status.Summary = summary
+		return
+	}
+
+	summary := "Bound to " + sc.Status.RegisteredService
+	if l := len(sc.Status.Bindings); l > 0 {
+		pushed := 0
+		for _, b := range sc.Status.Bindings {
+			if b.State == ServiceClaimBindingStatePushed {
+				pushed++
+			}
+		}
+		summary += fmt.Sprintf(" in %d/%d namespaces", pushed, l)
+	}
+	if degraded := meta.FindStatusCondition(sc.Status.Conditions, ServiceClaimConditionDegraded); degraded != nil && degraded.Status == metav1.ConditionTrue {
+		summary = withMessage(summary+", degraded", degraded.Message)
+	}
+	if draining := meta.FindStatusCondition(sc.Status.Conditions, ServiceClaimConditionDraining); draining != nil && draining.Status == metav1.ConditionTrue {
+		summary = withMessage(summary+", draining", draining.Message)
+	}
+	if stale := meta.FindStatusCondition(sc.Status.Conditions, ServiceClaimConditionStale); stale != nil && stale.Status == metav1.ConditionTrue {
+		summary = withMessage(summary+", stale", stale.Message)
+	}
+	sc.Status.Summary = summary
+}
+
+// failureMessage joins the messages of the failed conditions of the
+// ClusterEnvironment, but the Ready one which summarizes them
+func (s *ClusterEnvironmentStatus) failureMessage() string {
+	var messages []string
+	for _, c := range s.Conditions {
+		if c.Type != ConditionReady && c.Status == metav1.ConditionFalse && c.Message != "" {
+			messages = append(messages, c.Message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+func withMessage(summary string, message string) string {
+	if message == "" {
+		return summary
+	}
+	return summary + ": " + message
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Summary", func() {
+	DescribeTable("ServiceClass",
+		func(spec ServiceClassSpec, status ServiceClassStatus, expected string) {
+			sc := ServiceClass{Spec: spec, Status: status}
+			sc.UpdateSummary()
+			Expect(sc.Status.Summary).To(Equal(expected))
+		},
+		Entry("registering", ServiceClassSpec{}, ServiceClassStatus{}, "Registering services"),
+		Entry("registered", ServiceClassSpec{}, ServiceClassStatus{
+			Registration: &ServiceClassRegistration{Registered: 120, Total: 300},
+		}, "Registered 120/300 services"),
+		Entry("paused", ServiceClassSpec{Paused: true}, ServiceClassStatus{
+			Preview:      []ServiceClassResourcePreview{{Name: "mydb"}},
+			Registration: &ServiceClassRegistration{Registered: 1, Total: 1},
+		}, "Paused, 1 resources matched"),
+	)
+	DescribeTable("ServiceClaim",
+		func(status ServiceClaimStatus, expected string) {
+			sc := ServiceClaim{Status: status}
+			sc.UpdateSummary()
+			Expect(sc.Status.Summary).To(Equal(expected))
+		},
+		Entry("pending", ServiceClaimStatus{
+			State: ServiceClaimStatePending,
+			Conditions: []metav1.Condition{
+				{Type: ServiceClaimConditionReady, Status: metav1.ConditionFalse, Message: "SCI is not matched"},
+			},
+		}, "Pending: SCI is not matched"),
+		Entry("rebinding", ServiceClaimStatus{
+			State:                     ServiceClaimStatePending,
+			PreviousRegisteredService: "mydb",
+			Conditions: []metav1.Condition{
+				{Type: ServiceClaimConditionReady, Status: metav1.ConditionFalse, Message: "SCI is not matched"},
+			},
+		}, "Pending, rebinding from mydb: SCI is not matched"),
+		Entry("expired", ServiceClaimStatus{
+			State: ServiceClaimStateExpired,
+			Conditions: []metav1.Condition{
+				{Type: ServiceClaimConditionReady, Status: metav1.ConditionFalse, Message: "lease expired at 2023-06-01T12:00:00Z"},
+			},
+		}, "Expired: lease expired at 2023-06-01T12:00:00Z"),
+		Entry("resolved", ServiceClaimStatus{
+			State:             ServiceClaimStateResolved,
+			RegisteredService: "mydb",
+			Bindings: []ServiceClaimBinding{
+				{Namespace: "app", State: ServiceClaimBindingStatePushed},
+				{Namespace: "app2", State: ServiceClaimBindingStateFailed},
+			},
+		}, "Bound to mydb in 1/2 namespaces"),
+		Entry("degraded", ServiceClaimStatus{
+			State:             ServiceClaimStateResolved,
+			RegisteredService: "mydb",
+			Conditions: []metav1.Condition{
+				{Type: ServiceClaimConditionDegraded, Status: metav1.ConditionTrue, Message: "mydb is unreachable"},
+			},
+		}, "Bound to mydb, degraded: mydb is unreachable"),
+		Entry("draining", ServiceClaimStatus{
+			State:             ServiceClaimStateResolved,
+			RegisteredService: "mydb",
+			Conditions: []metav1.Condition{
+				{Type: ServiceClaimConditionDraining, Status: metav1.ConditionTrue, Message: "mydb is drained for maintenance"},
+			},
+		}, "Bound to mydb, draining: mydb is drained for maintenance"),
+		Entry("stale", ServiceClaimStatus{
+			State:             ServiceClaimStateResolved,
+			RegisteredService: "mydb",
+			Conditions: []metav1.Condition{
+				{Type: ServiceClaimConditionStale, Status: metav1.ConditionTrue, Message: "application not found"},
+			},
+		}, "Bound to mydb, stale: application not found"),
+	)
+
+	DescribeTable("RegisteredService",
+		func(status RegisteredServiceStatus, expected string) {
+			rs := RegisteredService{Status: status}
+			rs.UpdateSummary()
+			Expect(rs.Status.Summary).To(Equal(expected))
+		},
+		Entry("without health check", RegisteredServiceStatus{State: RegisteredServiceStateAvailable}, "Available"),
+		Entry("unhealthy", RegisteredServiceStatus{
+			State: RegisteredServiceStateUnreachable,
+			Conditions: []metav1.Condition{
+				{Type: RegisteredServiceConditionHealthy, Status: metav1.ConditionFalse, Message: "connection refused"},
+			},
+		}, "Unreachable, unhealthy: connection refused"),
+		Entry("idle", RegisteredServiceStatus{
+			State:     RegisteredServiceStateAvailable,
+			IdleSince: &metav1.Time{Time: time.Date(2023, 5, 10, 10, 0, 0, 0, time.UTC)},
+			Conditions: []metav1.Condition{
+				{Type: RegisteredServiceConditionHealthy, Status: metav1.ConditionTrue},
+			},
+		}, "Available, healthy, idle since 2023-05-10"),
+	)
+
+	It("summarizes RegisteredServices in maintenance", func() {
+		rs := RegisteredService{
+			Spec:   RegisteredServiceSpec{Maintenance: &Maintenance{Message: "upgrading to PostgreSQL 15"}},
+			Status: RegisteredServiceStatus{State: RegisteredServiceStateClaimed},
+		}
+		rs.UpdateSummary()
+		Expect(rs.Status.Summary).To(Equal("Claimed, in maintenance: upgrading to PostgreSQL 15"))
+	})
+
+	It("summarizes ClusterEnvironments", func() {
+		ce := ClusterEnvironment{
+			Spec: ClusterEnvironmentSpec{EnvironmentName: "prod"},
+			Status: ClusterEnvironmentStatus{
+				State: ClusterEnvironmentStatePartial,
+				Conditions: []metav1.Condition{
+					{Type: "Online", Status: metav1.ConditionTrue, Message: "Connection established"},
+					{Type: "ApplicationNamespacePermissionsRequired", Status: metav1.ConditionFalse, Message: "missing permissions in app"},
+				},
+			},
+		}
+		ce.UpdateSummary()
+		Expect(ce.Status.Summary).To(Equal("Partial in prod: missing permissions in app"))
+	})
+})
@@ -16,7 +16,34 @@ limitations under the License.
 
 package v1alpha1
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServiceMetadata describes a service to developers, so that tooling can
+// render it in a marketplace view
+type ServiceMetadata struct {
+	// DisplayName is the human-readable name of the service
+	// +optional
+	DisplayName string `json:"displayName,omitempty"`
+
+	// Description of the service
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Icon is the URL of the service's icon, possibly a data URL
+	// +optional
+	Icon string `json:"icon,omitempty"`
+
+	// DocumentationURL is the URL of the service's documentation
+	// +optional
+	DocumentationURL string `json:"documentationURL,omitempty"`
+
+	// Tags categorize the service, e.g. `database` or `sql`
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+}
 
 // ServiceClassIdentityItem defines an attribute that is necessary to
 // identify a service class.
@@ -47,7 +74,8 @@ type ApplicationSelector struct {
 // the resource may be used.
 type EnvironmentConstraints struct {
 	// Environments defines the environments that the RegisteredService may be
-	// used in.
+	// used in.  Environments prefixed by `!` are excluded, and `*` matches
+	// any sequence of characters (e.g. `dev-*` or `!*-restricted`).
 	Environments []string `json:"environments,omitempty"`
 }
 
@@ -56,14 +84,179 @@ type EnvironmentConstraints struct {
 type HealthCheckContainer struct {
 	// Container image with the client to run the test
 	Image string `json:"image"`
-	// Command to execute in the container to run the test
+	// Command to execute in the container to run the test.  Arguments are
+	// split on whitespaces, unless quoted with single or double quotes.
 	Command string `json:"command"`
+	// ImagePullPolicy of the container image
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// ServiceEndpointDefinitionMountPath is the directory the values of the
+	// service endpoint definition are mounted into, one file per item.  They
+	// are also provided as environment variables named after the items in
+	// upper case, e.g. `DB_PORT` for `db-port`.
+	// +optional
+	ServiceEndpointDefinitionMountPath string `json:"serviceEndpointDefinitionMountPath,omitempty"`
+	// BindingSecretMountPath is the directory the binding secret is mounted
+	// into, as it is projected into bound applications: one file per
+	// service class identity and service endpoint definition item, following
+	// the Service Binding specification.  This gives checks that need full
+	// credentials, like password files or TLS certificates, access to them.
+	// +optional
+	BindingSecretMountPath string `json:"bindingSecretMountPath,omitempty"`
+	// Resources required by the health check container
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// ServiceAccountName is the name of the ServiceAccount the health check
+	// Pod runs as
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// SecurityContext of the health check container, e.g. to comply with the
+	// restricted Pod Security Standard
+	// +optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+}
+
+// HealthCheckEndpoint defines which ServiceEndpointDefinition items hold the
+// address a health probe connects to.
+type HealthCheckEndpoint struct {
+	// HostKey is the name of the ServiceEndpointDefinition item holding the
+	// host of the service.  Defaults to "host".
+	// +optional
+	HostKey string `json:"hostKey,omitempty"`
+	// PortKey is the name of the ServiceEndpointDefinition item holding the
+	// port of the service.  Defaults to "port".
+	// +optional
+	PortKey string `json:"portKey,omitempty"`
 }
 
+// HealthCheckHTTPGetAction defines an HTTP GET health probe.
+type HealthCheckHTTPGetAction struct {
+	HealthCheckEndpoint `json:",inline"`
+	// Path to request.  Defaults to "/".
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Scheme to connect with, HTTP or HTTPS.  Defaults to HTTP.
+	// +optional
+	Scheme corev1.URIScheme `json:"scheme,omitempty"`
+}
+
+// HealthCheckTCPSocketAction defines a TCP health probe.
+type HealthCheckTCPSocketAction struct {
+	HealthCheckEndpoint `json:",inline"`
+}
+
+// HealthCheckGRPCAction defines a gRPC health probe.
+type HealthCheckGRPCAction struct {
+	HealthCheckEndpoint `json:",inline"`
+	// Service is the name of the service to check, as defined by the gRPC
+	// health checking protocol.  Defaults to the whole server.
+	// +optional
+	Service string `json:"service,omitempty"`
+}
+
+// HealthCheckConcurrencyPolicy defines how concurrent runs of a health check
+// container are handled
+// +kubebuilder:validation:Enum=Forbid;Replace
+type HealthCheckConcurrencyPolicy string
+
+const (
+	HealthCheckConcurrencyPolicyForbid  HealthCheckConcurrencyPolicy = "Forbid"
+	HealthCheckConcurrencyPolicyReplace HealthCheckConcurrencyPolicy = "Replace"
+)
+
 // HealthCheck defines metadata that can be used check
 // the health of a service and report status.
 type HealthCheck struct {
 	// Container defines a container that will run a check against the
 	// ServiceEndpointDefinition to determine connectivity and access.
-	Container HealthCheckContainer `json:"container"`
+	// +optional
+	Container *HealthCheckContainer `json:"container,omitempty"`
+
+	// HTTPGet checks that an HTTP GET request to the service succeeds.
+	// +optional
+	HTTPGet *HealthCheckHTTPGetAction `json:"httpGet,omitempty"`
+
+	// TCPSocket checks that a TCP connection to the service can be opened.
+	// +optional
+	TCPSocket *HealthCheckTCPSocketAction `json:"tcpSocket,omitempty"`
+
+	// GRPC checks the service with the gRPC health checking protocol.
+	// +optional
+	GRPC *HealthCheckGRPCAction `json:"grpc,omitempty"`
+
+	// Interval between two runs of the health check.  Defaults to 5 minutes.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// ActiveDeadlineSeconds is the time a run of the health check is allowed
+	// to take before failing.  Defaults to the interval for containers, and
+	// to 10 seconds for probes.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// TTLSecondsAfterFinished is the time the Job running a health check
+	// container is kept after finishing.  If not set, the latest Jobs are
+	// kept until they are replaced by newer ones.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// ConcurrencyPolicy defines what happens when a health check container
+	// is due to run while the previous run is still running: `Forbid` (the
+	// default) skips the new run, while `Replace` stops the previous run.
+	// +optional
+	ConcurrencyPolicy HealthCheckConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+
+	// FailureThreshold is the number of consecutive failed runs after which
+	// the service is considered unreachable.  Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+
+	// SuccessThreshold is the number of consecutive passed runs after which
+	// an unreachable service is considered reachable again.  Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	SuccessThreshold *int32 `json:"successThreshold,omitempty"`
+}
+
+// MaxStateTransitions is the number of state transitions kept in the status
+// of RegisteredServices and ServiceClaims
+const MaxStateTransitions = 10
+
+// StateTransition records a change of state of a resource
+type StateTransition struct {
+	// State the resource moved to
+	State string `json:"state"`
+	// Reason of the transition
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Time of the transition
+	Time metav1.Time `json:"time"`
+	// Actor that caused the transition
+	// +optional
+	Actor string `json:"actor,omitempty"`
+}
+
+// RecordStateTransition appends a transition to the given history if state
+// differs from the last recorded one.  Only the latest MaxStateTransitions
+// transitions are kept.
+func RecordStateTransition(history []StateTransition, state, reason, actor string) []StateTransition {
+	if l := len(history); l > 0 && history[l-1].State == state {
+		return history
+	}
+
+	history = append(history, StateTransition{
+		State:  state,
+		Reason: reason,
+		Time:   metav1.Now(),
+		Actor:  actor,
+	})
+	if l := len(history); l > MaxStateTransitions {
+		history = history[l-MaxStateTransitions:]
+	}
+	return history
 }
@@ -0,0 +1,50 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("State transitions", func() {
+	It("Records changes of state only", func() {
+		var h []StateTransition
+		h = RecordStateTransition(h, "Available", "ServiceRegistered", "primaza")
+		h = RecordStateTransition(h, "Available", "ServiceReleased", "ServiceClaim/spam")
+		h = RecordStateTransition(h, "Claimed", "ServiceClaimed", "ServiceClaim/spam")
+
+		Expect(h).To(HaveLen(2))
+		Expect(h[0].State).To(Equal("Available"))
+		Expect(h[0].Reason).To(Equal("ServiceRegistered"))
+		Expect(h[1].State).To(Equal("Claimed"))
+		Expect(h[1].Actor).To(Equal("ServiceClaim/spam"))
+	})
+
+	It("Keeps the latest transitions only", func() {
+		var h []StateTransition
+		for i := 0; i < MaxStateTransitions+5; i++ {
+			h = RecordStateTransition(h, fmt.Sprintf("state-%d", i), "", "")
+		}
+
+		Expect(h).To(HaveLen(MaxStateTransitions))
+		Expect(h[0].State).To(Equal("state-5"))
+		Expect(h[MaxStateTransitions-1].State).To(Equal(fmt.Sprintf("state-%d", MaxStateTransitions+4)))
+	})
+})
@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// admissionWarner returns warnings about objects that are admitted, to guide
+// users without rejecting their requests
+type admissionWarner interface {
+	Warnings(ctx context.Context, obj runtime.Object) []string
+}
+
+// withWarnings returns a validating webhook for obj that adds the warner's
+// warnings to the responses allowing a creation or an update
+func withWarnings(obj runtime.Object, validator admission.CustomValidator, warner admissionWarner) *admission.Webhook {
+	return &admission.Webhook{
+		Handler: &warningHandler{
+			Handler: admission.WithCustomValidator(obj, validator).Handler,
+			object:  obj,
+			warner:  warner,
+		},
+	}
+}
+
+type warningHandler struct {
+	admission.Handler
+	object  runtime.Object
+	warner  admissionWarner
+	decoder *admission.Decoder
+}
+
+var _ admission.DecoderInjector = &warningHandler{}
+
+// InjectDecoder injects the decoder into the handler and the wrapped one
+func (h *warningHandler) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	_, err := admission.InjectDecoderInto(d, h.Handler)
+	return err
+}
+
+// Handle handles admission requests
+func (h *warningHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	resp := h.Handler.Handle(ctx, req)
+	if !resp.Allowed || (req.Operation != admissionv1.Create && req.Operation != admissionv1.Update) {
+		return resp
+	}
+
+	obj := h.object.DeepCopyObject()
+	if err := h.decoder.DecodeRaw(req.Object, obj); err != nil {
+		// the request has been validated already, warnings are best-effort
+		return resp
+	}
+	return resp.WithWarnings(h.warner.Warnings(ctx, obj)...)
+}
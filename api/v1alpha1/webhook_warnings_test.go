@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func newServiceClassResource(resourceFields ...ServiceClassResourceFieldMapping) ServiceClassResource {
+	return ServiceClassResource{
+		APIVersion: "primaza.io/v1alpha1",
+		Kind:       "Backend",
+		ServiceEndpointDefinitionMappings: ServiceEndpointDefinitionMappings{
+			ResourceFields: resourceFields,
+		},
+	}
+}
+
+var _ = Describe("Admission warnings", func() {
+	var validator *serviceClassValidator
+	var handler admission.Handler
+	BeforeEach(func() {
+		scheme, err := SchemeBuilder.Build()
+		Expect(err).NotTo(HaveOccurred())
+
+		validator = &serviceClassValidator{
+			client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithLists(&ServiceClassList{}).
+				Build(),
+		}
+		decoder, err := admission.NewDecoder(scheme)
+		Expect(err).NotTo(HaveOccurred())
+		handler = withWarnings(&ServiceClass{}, validator, validator).Handler
+		_, err = admission.InjectDecoderInto(decoder, handler)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("ServiceClass warnings",
+		func(resource ServiceClassResource, expected []string) {
+			sc := newServiceClass("spam", "eggs", ServiceClassSpec{Resource: resource})
+			Expect(validator.Warnings(context.Background(), &sc)).To(Equal(expected))
+		},
+		Entry("No warnings",
+			newServiceClassResource(ServiceClassResourceFieldMapping{Name: "host", JsonPath: ".spec.host"}),
+			nil),
+		Entry("Whole metadata",
+			newServiceClassResource(ServiceClassResourceFieldMapping{Name: "meta", JsonPath: ".metadata"}),
+			[]string{"spec.resource.serviceEndpointDefinitionMappings.resourceFields[0].jsonPath: jsonPath .metadata maps the whole metadata of the service resource, consider mapping the fields you need only"}),
+		Entry("Credential-like key",
+			newServiceClassResource(
+				ServiceClassResourceFieldMapping{Name: "host", JsonPath: ".spec.host"},
+				ServiceClassResourceFieldMapping{Name: "dbPassword", JsonPath: ".spec.password"}),
+			[]string{"spec.resource.serviceEndpointDefinitionMappings.resourceFields[1].name: dbPassword looks like a credential, consider reading it from a secret with a secretRefFields mapping"}),
+	)
+
+	It("Adds warnings to allowed requests", func() {
+		sc := newServiceClass("spam", "eggs", ServiceClassSpec{
+			Resource: newServiceClassResource(ServiceClassResourceFieldMapping{Name: "token", JsonPath: ".spec.token"}),
+		})
+		raw, err := json.Marshal(sc)
+		Expect(err).NotTo(HaveOccurred())
+
+		resp := handler.Handle(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		})
+		Expect(resp.Allowed).To(BeTrue())
+		Expect(resp.Warnings).To(HaveLen(1))
+	})
+
+	It("Does not add warnings to denied requests", func() {
+		sc := newServiceClass("spam", "eggs", ServiceClassSpec{
+			Resource: newServiceClassResource(ServiceClassResourceFieldMapping{Name: "token", JsonPath: "{{"}),
+		})
+		raw, err := json.Marshal(sc)
+		Expect(err).NotTo(HaveOccurred())
+
+		resp := handler.Handle(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		})
+		Expect(resp.Allowed).To(BeFalse())
+		Expect(resp.Warnings).To(BeEmpty())
+	})
+})
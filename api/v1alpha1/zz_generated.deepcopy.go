@@ -22,6 +22,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -46,6 +47,21 @@ func (in *ApplicationSelector) DeepCopy() *ApplicationSelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BindingSecretEncoder) DeepCopyInto(out *BindingSecretEncoder) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BindingSecretEncoder.
+func (in *BindingSecretEncoder) DeepCopy() *BindingSecretEncoder {
+	if in == nil {
+		return nil
+	}
+	out := new(BindingSecretEncoder)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterEnvironment) DeepCopyInto(out *ClusterEnvironment) {
 	*out = *in
@@ -105,6 +121,28 @@ func (in *ClusterEnvironmentList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterEnvironmentNamespaceStatus) DeepCopyInto(out *ClusterEnvironmentNamespaceStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterEnvironmentNamespaceStatus.
+func (in *ClusterEnvironmentNamespaceStatus) DeepCopy() *ClusterEnvironmentNamespaceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterEnvironmentNamespaceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterEnvironmentSpec) DeepCopyInto(out *ClusterEnvironmentSpec) {
 	*out = *in
@@ -123,6 +161,30 @@ func (in *ClusterEnvironmentSpec) DeepCopyInto(out *ClusterEnvironmentSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.FailoverServers != nil {
+		in, out := &in.FailoverServers, &out.FailoverServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Topology != nil {
+		in, out := &in.Topology, &out.Topology
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(ClusterEnvironmentTLS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceCatalogFilters != nil {
+		in, out := &in.ServiceCatalogFilters, &out.ServiceCatalogFilters
+		*out = make([]ServiceCatalogFilter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterEnvironmentSpec.
@@ -145,6 +207,21 @@ func (in *ClusterEnvironmentStatus) DeepCopyInto(out *ClusterEnvironmentStatus)
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LastHeartbeatTime != nil {
+		in, out := &in.LastHeartbeatTime, &out.LastHeartbeatTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastContactTime != nil {
+		in, out := &in.LastContactTime, &out.LastContactTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]ClusterEnvironmentNamespaceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterEnvironmentStatus.
@@ -157,6 +234,26 @@ func (in *ClusterEnvironmentStatus) DeepCopy() *ClusterEnvironmentStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterEnvironmentTLS) DeepCopyInto(out *ClusterEnvironmentTLS) {
+	*out = *in
+	if in.PinnedPublicKeys != nil {
+		in, out := &in.PinnedPublicKeys, &out.PinnedPublicKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterEnvironmentTLS.
+func (in *ClusterEnvironmentTLS) DeepCopy() *ClusterEnvironmentTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterEnvironmentTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Environment) DeepCopyInto(out *Environment) {
 	*out = *in
@@ -192,10 +289,69 @@ func (in *EnvironmentConstraints) DeepCopy() *EnvironmentConstraints {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSecretStoreRef) DeepCopyInto(out *ExternalSecretStoreRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSecretStoreRef.
+func (in *ExternalSecretStoreRef) DeepCopy() *ExternalSecretStoreRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSecretStoreRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HealthCheck) DeepCopyInto(out *HealthCheck) {
 	*out = *in
-	out.Container = in.Container
+	if in.Container != nil {
+		in, out := &in.Container, &out.Container
+		*out = new(HealthCheckContainer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTPGet != nil {
+		in, out := &in.HTTPGet, &out.HTTPGet
+		*out = new(HealthCheckHTTPGetAction)
+		**out = **in
+	}
+	if in.TCPSocket != nil {
+		in, out := &in.TCPSocket, &out.TCPSocket
+		*out = new(HealthCheckTCPSocketAction)
+		**out = **in
+	}
+	if in.GRPC != nil {
+		in, out := &in.GRPC, &out.GRPC
+		*out = new(HealthCheckGRPCAction)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ActiveDeadlineSeconds != nil {
+		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SuccessThreshold != nil {
+		in, out := &in.SuccessThreshold, &out.SuccessThreshold
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheck.
@@ -211,6 +367,16 @@ func (in *HealthCheck) DeepCopy() *HealthCheck {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HealthCheckContainer) DeepCopyInto(out *HealthCheckContainer) {
 	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(corev1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheckContainer.
@@ -223,13 +389,172 @@ func (in *HealthCheckContainer) DeepCopy() *HealthCheckContainer {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheckEndpoint) DeepCopyInto(out *HealthCheckEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheckEndpoint.
+func (in *HealthCheckEndpoint) DeepCopy() *HealthCheckEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheckEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheckGRPCAction) DeepCopyInto(out *HealthCheckGRPCAction) {
+	*out = *in
+	out.HealthCheckEndpoint = in.HealthCheckEndpoint
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheckGRPCAction.
+func (in *HealthCheckGRPCAction) DeepCopy() *HealthCheckGRPCAction {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheckGRPCAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheckHTTPGetAction) DeepCopyInto(out *HealthCheckHTTPGetAction) {
+	*out = *in
+	out.HealthCheckEndpoint = in.HealthCheckEndpoint
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheckHTTPGetAction.
+func (in *HealthCheckHTTPGetAction) DeepCopy() *HealthCheckHTTPGetAction {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheckHTTPGetAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheckOverride) DeepCopyInto(out *HealthCheckOverride) {
+	*out = *in
+	if in.Environments != nil {
+		in, out := &in.Environments, &out.Environments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.HealthCheck.DeepCopyInto(&out.HealthCheck)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheckOverride.
+func (in *HealthCheckOverride) DeepCopy() *HealthCheckOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheckOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheckResult) DeepCopyInto(out *HealthCheckResult) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheckResult.
+func (in *HealthCheckResult) DeepCopy() *HealthCheckResult {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheckResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheckTCPSocketAction) DeepCopyInto(out *HealthCheckTCPSocketAction) {
+	*out = *in
+	out.HealthCheckEndpoint = in.HealthCheckEndpoint
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheckTCPSocketAction.
+func (in *HealthCheckTCPSocketAction) DeepCopy() *HealthCheckTCPSocketAction {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheckTCPSocketAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Maintenance) DeepCopyInto(out *Maintenance) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Maintenance.
+func (in *Maintenance) DeepCopy() *Maintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(Maintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatchingPreference) DeepCopyInto(out *MatchingPreference) {
+	*out = *in
+	if in.Topology != nil {
+		in, out := &in.Topology, &out.Topology
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchingPreference.
+func (in *MatchingPreference) DeepCopy() *MatchingPreference {
+	if in == nil {
+		return nil
+	}
+	out := new(MatchingPreference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReachabilityCheck) DeepCopyInto(out *ReachabilityCheck) {
+	*out = *in
+	out.HealthCheckEndpoint = in.HealthCheckEndpoint
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReachabilityCheck.
+func (in *ReachabilityCheck) DeepCopy() *ReachabilityCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ReachabilityCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RegisteredService) DeepCopyInto(out *RegisteredService) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegisteredService.
@@ -305,6 +630,7 @@ func (in *RegisteredServiceList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RegisteredServiceSpec) DeepCopyInto(out *RegisteredServiceSpec) {
 	*out = *in
+	in.ServiceMetadata.DeepCopyInto(&out.ServiceMetadata)
 	if in.Constraints != nil {
 		in, out := &in.Constraints, &out.Constraints
 		*out = new(RegisteredServiceConstraints)
@@ -313,6 +639,11 @@ func (in *RegisteredServiceSpec) DeepCopyInto(out *RegisteredServiceSpec) {
 	if in.HealthCheck != nil {
 		in, out := &in.HealthCheck, &out.HealthCheck
 		*out = new(HealthCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(Maintenance)
 		**out = **in
 	}
 	if in.ServiceClassIdentity != nil {
@@ -342,6 +673,40 @@ func (in *RegisteredServiceSpec) DeepCopy() *RegisteredServiceSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RegisteredServiceStatus) DeepCopyInto(out *RegisteredServiceStatus) {
 	*out = *in
+	if in.LastClaimedTime != nil {
+		in, out := &in.LastClaimedTime, &out.LastClaimedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Claims != nil {
+		in, out := &in.Claims, &out.Claims
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IdleSince != nil {
+		in, out := &in.IdleSince, &out.IdleSince
+		*out = (*in).DeepCopy()
+	}
+	if in.Transitions != nil {
+		in, out := &in.Transitions, &out.Transitions
+		*out = make([]StateTransition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HealthChecks != nil {
+		in, out := &in.HealthChecks, &out.HealthChecks
+		*out = make([]HealthCheckResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegisteredServiceStatus.
@@ -422,6 +787,21 @@ func (in *ServiceBindingSpec) DeepCopyInto(out *ServiceBindingSpec) {
 		*out = make([]Environment, len(*in))
 		copy(*out, *in)
 	}
+	if in.ProjectedKeys != nil {
+		in, out := &in.ProjectedKeys, &out.ProjectedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReachabilityCheck != nil {
+		in, out := &in.ReachabilityCheck, &out.ReachabilityCheck
+		*out = new(ReachabilityCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(ServiceBindingVault)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceBindingSpec.
@@ -456,12 +836,33 @@ func (in *ServiceBindingStatus) DeepCopy() *ServiceBindingStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceBindingVault) DeepCopyInto(out *ServiceBindingVault) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceBindingVault.
+func (in *ServiceBindingVault) DeepCopy() *ServiceBindingVault {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceBindingVault)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceCatalog) DeepCopyInto(out *ServiceCatalog) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceCatalog.
@@ -483,17 +884,37 @@ func (in *ServiceCatalog) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServiceCatalogList) DeepCopyInto(out *ServiceCatalogList) {
+func (in *ServiceCatalogFilter) DeepCopyInto(out *ServiceCatalogFilter) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]ServiceCatalog, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	if in.ServiceClassIdentity != nil {
+		in, out := &in.ServiceClassIdentity, &out.ServiceClassIdentity
+		*out = make([]ServiceClassIdentityItem, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceCatalogFilter.
+func (in *ServiceCatalogFilter) DeepCopy() *ServiceCatalogFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceCatalogFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceCatalogList) DeepCopyInto(out *ServiceCatalogList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServiceCatalog, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceCatalogList.
@@ -517,6 +938,7 @@ func (in *ServiceCatalogList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceCatalogService) DeepCopyInto(out *ServiceCatalogService) {
 	*out = *in
+	in.ServiceMetadata.DeepCopyInto(&out.ServiceMetadata)
 	if in.ServiceClassIdentity != nil {
 		in, out := &in.ServiceClassIdentity, &out.ServiceClassIdentity
 		*out = make([]ServiceClassIdentityItem, len(*in))
@@ -561,6 +983,28 @@ func (in *ServiceCatalogSpec) DeepCopy() *ServiceCatalogSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceCatalogStatus) DeepCopyInto(out *ServiceCatalogStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceCatalogStatus.
+func (in *ServiceCatalogStatus) DeepCopy() *ServiceCatalogStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceCatalogStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceClaim) DeepCopyInto(out *ServiceClaim) {
 	*out = *in
@@ -591,6 +1035,11 @@ func (in *ServiceClaim) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceClaimApplicationClusterContext) DeepCopyInto(out *ServiceClaimApplicationClusterContext) {
 	*out = *in
+	if in.AdditionalNamespaces != nil {
+		in, out := &in.AdditionalNamespaces, &out.AdditionalNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClaimApplicationClusterContext.
@@ -603,6 +1052,22 @@ func (in *ServiceClaimApplicationClusterContext) DeepCopy() *ServiceClaimApplica
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceClaimBinding) DeepCopyInto(out *ServiceClaimBinding) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClaimBinding.
+func (in *ServiceClaimBinding) DeepCopy() *ServiceClaimBinding {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceClaimBinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceClaimList) DeepCopyInto(out *ServiceClaimList) {
 	*out = *in
@@ -635,6 +1100,26 @@ func (in *ServiceClaimList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceClaimSelection) DeepCopyInto(out *ServiceClaimSelection) {
+	*out = *in
+	if in.Candidates != nil {
+		in, out := &in.Candidates, &out.Candidates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClaimSelection.
+func (in *ServiceClaimSelection) DeepCopy() *ServiceClaimSelection {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceClaimSelection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceClaimSpec) DeepCopyInto(out *ServiceClaimSpec) {
 	*out = *in
@@ -643,6 +1128,11 @@ func (in *ServiceClaimSpec) DeepCopyInto(out *ServiceClaimSpec) {
 		*out = make([]ServiceClassIdentityItem, len(*in))
 		copy(*out, *in)
 	}
+	if in.ServiceSelector != nil {
+		in, out := &in.ServiceSelector, &out.ServiceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ServiceEndpointDefinitionKeys != nil {
 		in, out := &in.ServiceEndpointDefinitionKeys, &out.ServiceEndpointDefinitionKeys
 		*out = make([]string, len(*in))
@@ -652,6 +1142,52 @@ func (in *ServiceClaimSpec) DeepCopyInto(out *ServiceClaimSpec) {
 	if in.ApplicationClusterContext != nil {
 		in, out := &in.ApplicationClusterContext, &out.ApplicationClusterContext
 		*out = new(ServiceClaimApplicationClusterContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Encoders != nil {
+		in, out := &in.Encoders, &out.Encoders
+		*out = make([]BindingSecretEncoder, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]Environment, len(*in))
+		copy(*out, *in)
+	}
+	if in.ProjectedKeys != nil {
+		in, out := &in.ProjectedKeys, &out.ProjectedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StaleGracePeriod != nil {
+		in, out := &in.StaleGracePeriod, &out.StaleGracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RenewTime != nil {
+		in, out := &in.RenewTime, &out.RenewTime
+		*out = (*in).DeepCopy()
+	}
+	if in.MatchingPreferences != nil {
+		in, out := &in.MatchingPreferences, &out.MatchingPreferences
+		*out = make([]MatchingPreference, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReachabilityCheck != nil {
+		in, out := &in.ReachabilityCheck, &out.ReachabilityCheck
+		*out = new(ReachabilityCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(ServiceClaimVault)
 		**out = **in
 	}
 }
@@ -676,6 +1212,34 @@ func (in *ServiceClaimStatus) DeepCopyInto(out *ServiceClaimStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Transitions != nil {
+		in, out := &in.Transitions, &out.Transitions
+		*out = make([]StateTransition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Bindings != nil {
+		in, out := &in.Bindings, &out.Bindings
+		*out = make([]ServiceClaimBinding, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Selection != nil {
+		in, out := &in.Selection, &out.Selection
+		*out = new(ServiceClaimSelection)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExpirationTime != nil {
+		in, out := &in.ExpirationTime, &out.ExpirationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.VaultKeys != nil {
+		in, out := &in.VaultKeys, &out.VaultKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClaimStatus.
@@ -688,6 +1252,21 @@ func (in *ServiceClaimStatus) DeepCopy() *ServiceClaimStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceClaimVault) DeepCopyInto(out *ServiceClaimVault) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClaimVault.
+func (in *ServiceClaimVault) DeepCopy() *ServiceClaimVault {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceClaimVault)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceClass) DeepCopyInto(out *ServiceClass) {
 	*out = *in
@@ -715,6 +1294,43 @@ func (in *ServiceClass) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceClassExport) DeepCopyInto(out *ServiceClassExport) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClassExport.
+func (in *ServiceClassExport) DeepCopy() *ServiceClassExport {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceClassExport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceClassExternalSecrets) DeepCopyInto(out *ServiceClassExternalSecrets) {
+	*out = *in
+	out.PushStoreRef = in.PushStoreRef
+	out.PullStoreRef = in.PullStoreRef
+	if in.RefreshInterval != nil {
+		in, out := &in.RefreshInterval, &out.RefreshInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClassExternalSecrets.
+func (in *ServiceClassExternalSecrets) DeepCopy() *ServiceClassExternalSecrets {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceClassExternalSecrets)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceClassIdentityItem) DeepCopyInto(out *ServiceClassIdentityItem) {
 	*out = *in
@@ -730,6 +1346,56 @@ func (in *ServiceClassIdentityItem) DeepCopy() *ServiceClassIdentityItem {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceClassJoin) DeepCopyInto(out *ServiceClassJoin) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClassJoin.
+func (in *ServiceClassJoin) DeepCopy() *ServiceClassJoin {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceClassJoin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceClassHandlerFieldMapping) DeepCopyInto(out *ServiceClassHandlerFieldMapping) {
+	*out = *in
+	if in.Transformations != nil {
+		in, out := &in.Transformations, &out.Transformations
+		*out = make([]ValueTransformation, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClassHandlerFieldMapping.
+func (in *ServiceClassHandlerFieldMapping) DeepCopy() *ServiceClassHandlerFieldMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceClassHandlerFieldMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceClassHelm) DeepCopyInto(out *ServiceClassHelm) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClassHelm.
+func (in *ServiceClassHelm) DeepCopy() *ServiceClassHelm {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceClassHelm)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceClassList) DeepCopyInto(out *ServiceClassList) {
 	*out = *in
@@ -762,9 +1428,51 @@ func (in *ServiceClassList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceClassMappingPreview) DeepCopyInto(out *ServiceClassMappingPreview) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClassMappingPreview.
+func (in *ServiceClassMappingPreview) DeepCopy() *ServiceClassMappingPreview {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceClassMappingPreview)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceClassResource) DeepCopyInto(out *ServiceClassResource) {
 	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OwnedBy != nil {
+		in, out := &in.OwnedBy, &out.OwnedBy
+		*out = new(ServiceClassResourceOwner)
+		**out = **in
+	}
+	if in.Secondary != nil {
+		in, out := &in.Secondary, &out.Secondary
+		*out = new(ServiceClassSecondaryResource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Related != nil {
+		in, out := &in.Related, &out.Related
+		*out = make([]ServiceClassSecondaryResource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Helm != nil {
+		in, out := &in.Helm, &out.Helm
+		*out = new(ServiceClassHelm)
+		**out = **in
+	}
 	in.ServiceEndpointDefinitionMappings.DeepCopyInto(&out.ServiceEndpointDefinitionMappings)
 }
 
@@ -781,6 +1489,16 @@ func (in *ServiceClassResource) DeepCopy() *ServiceClassResource {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceClassResourceFieldMapping) DeepCopyInto(out *ServiceClassResourceFieldMapping) {
 	*out = *in
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(string)
+		**out = **in
+	}
+	if in.Transformations != nil {
+		in, out := &in.Transformations, &out.Transformations
+		*out = make([]ValueTransformation, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClassResourceFieldMapping.
@@ -793,9 +1511,87 @@ func (in *ServiceClassResourceFieldMapping) DeepCopy() *ServiceClassResourceFiel
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceClassResourceOwner) DeepCopyInto(out *ServiceClassResourceOwner) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClassResourceOwner.
+func (in *ServiceClassResourceOwner) DeepCopy() *ServiceClassResourceOwner {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceClassResourceOwner)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceClassResourcePreview) DeepCopyInto(out *ServiceClassResourcePreview) {
+	*out = *in
+	if in.Mappings != nil {
+		in, out := &in.Mappings, &out.Mappings
+		*out = make([]ServiceClassMappingPreview, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClassResourcePreview.
+func (in *ServiceClassResourcePreview) DeepCopy() *ServiceClassResourcePreview {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceClassResourcePreview)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceClassRegistration) DeepCopyInto(out *ServiceClassRegistration) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClassRegistration.
+func (in *ServiceClassRegistration) DeepCopy() *ServiceClassRegistration {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceClassRegistration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceClassSecondaryResource) DeepCopyInto(out *ServiceClassSecondaryResource) {
+	*out = *in
+	out.Join = in.Join
+	if in.ResourceFields != nil {
+		in, out := &in.ResourceFields, &out.ResourceFields
+		*out = make([]ServiceClassResourceFieldMapping, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClassSecondaryResource.
+func (in *ServiceClassSecondaryResource) DeepCopy() *ServiceClassSecondaryResource {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceClassSecondaryResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceClassSecretRefFieldMapping) DeepCopyInto(out *ServiceClassSecretRefFieldMapping) {
 	*out = *in
+	if in.Transformations != nil {
+		in, out := &in.Transformations, &out.Transformations
+		*out = make([]ValueTransformation, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClassSecretRefFieldMapping.
@@ -811,6 +1607,7 @@ func (in *ServiceClassSecretRefFieldMapping) DeepCopy() *ServiceClassSecretRefFi
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceClassSpec) DeepCopyInto(out *ServiceClassSpec) {
 	*out = *in
+	in.ServiceMetadata.DeepCopyInto(&out.ServiceMetadata)
 	if in.Constraints != nil {
 		in, out := &in.Constraints, &out.Constraints
 		*out = new(EnvironmentConstraints)
@@ -819,7 +1616,14 @@ func (in *ServiceClassSpec) DeepCopyInto(out *ServiceClassSpec) {
 	if in.HealthCheck != nil {
 		in, out := &in.HealthCheck, &out.HealthCheck
 		*out = new(HealthCheck)
-		**out = **in
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HealthCheckOverrides != nil {
+		in, out := &in.HealthCheckOverrides, &out.HealthCheckOverrides
+		*out = make([]HealthCheckOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	in.Resource.DeepCopyInto(&out.Resource)
 	if in.ServiceClassIdentity != nil {
@@ -827,6 +1631,21 @@ func (in *ServiceClassSpec) DeepCopyInto(out *ServiceClassSpec) {
 		*out = make([]ServiceClassIdentityItem, len(*in))
 		copy(*out, *in)
 	}
+	if in.SyncInterval != nil {
+		in, out := &in.SyncInterval, &out.SyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ExternalSecrets != nil {
+		in, out := &in.ExternalSecrets, &out.ExternalSecrets
+		*out = new(ServiceClassExternalSecrets)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Export != nil {
+		in, out := &in.Export, &out.Export
+		*out = new(ServiceClassExport)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClassSpec.
@@ -849,6 +1668,18 @@ func (in *ServiceClassStatus) DeepCopyInto(out *ServiceClassStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Preview != nil {
+		in, out := &in.Preview, &out.Preview
+		*out = make([]ServiceClassResourcePreview, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Registration != nil {
+		in, out := &in.Registration, &out.Registration
+		*out = new(ServiceClassRegistration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceClassStatus.
@@ -887,12 +1718,23 @@ func (in *ServiceEndpointDefinitionMappings) DeepCopyInto(out *ServiceEndpointDe
 	if in.ResourceFields != nil {
 		in, out := &in.ResourceFields, &out.ResourceFields
 		*out = make([]ServiceClassResourceFieldMapping, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.SecretRefFields != nil {
 		in, out := &in.SecretRefFields, &out.SecretRefFields
 		*out = make([]ServiceClassSecretRefFieldMapping, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HandlerFields != nil {
+		in, out := &in.HandlerFields, &out.HandlerFields
+		*out = make([]ServiceClassHandlerFieldMapping, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -920,3 +1762,39 @@ func (in *ServiceEndpointDefinitionSecretRef) DeepCopy() *ServiceEndpointDefinit
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMetadata) DeepCopyInto(out *ServiceMetadata) {
+	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMetadata.
+func (in *ServiceMetadata) DeepCopy() *ServiceMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StateTransition) DeepCopyInto(out *StateTransition) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StateTransition.
+func (in *StateTransition) DeepCopy() *StateTransition {
+	if in == nil {
+		return nil
+	}
+	out := new(StateTransition)
+	in.DeepCopyInto(out)
+	return out
+}
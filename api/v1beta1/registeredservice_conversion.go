@@ -0,0 +1,280 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+// negativeConstraintSymbol prefixes the excluded environments in v1alpha1
+// environment constraints
+const negativeConstraintSymbol = "!"
+
+var _ conversion.Convertible = &RegisteredService{}
+
+// ConvertTo converts this RegisteredService to the Hub version (v1alpha1)
+func (src *RegisteredService) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.RegisteredService)
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec.ServiceMetadata = v1alpha1.ServiceMetadata(src.Spec.ServiceMetadata)
+	dst.Spec.SLA = src.Spec.SLA
+	dst.Spec.Priority = src.Spec.Priority
+	dst.Spec.SharingPolicy = v1alpha1.RegisteredServiceSharingPolicy(src.Spec.SharingPolicy)
+	dst.Spec.MaxClaims = src.Spec.MaxClaims
+	dst.Spec.Maintenance = (*v1alpha1.Maintenance)(src.Spec.Maintenance)
+	dst.Spec.Constraints = nil
+	if c := src.Spec.Constraints; c != nil {
+		dst.Spec.Constraints = &v1alpha1.RegisteredServiceConstraints{
+			Environments:        environmentsToV1alpha1(c.Environments),
+			EnvironmentSelector: c.EnvironmentSelector,
+		}
+	}
+	dst.Spec.HealthCheck = healthCheckToV1alpha1(src.Spec.HealthCheck)
+	dst.Spec.ServiceClassIdentity = nil
+	for _, i := range src.Spec.ServiceClassIdentity {
+		dst.Spec.ServiceClassIdentity = append(dst.Spec.ServiceClassIdentity, v1alpha1.ServiceClassIdentityItem{
+			Name:  i.Name,
+			Value: i.Value,
+		})
+	}
+	dst.Spec.ServiceEndpointDefinition = nil
+	for _, i := range src.Spec.ServiceEndpointDefinition {
+		item := v1alpha1.ServiceEndpointDefinitionItem{Name: i.Name, Value: i.Value}
+		if r := i.ValueFromSecret; r != nil {
+			item.ValueFromSecret = &v1alpha1.ServiceEndpointDefinitionSecretRef{Name: r.Name, Key: r.Key}
+		}
+		dst.Spec.ServiceEndpointDefinition = append(dst.Spec.ServiceEndpointDefinition, item)
+	}
+
+	dst.Status.State = src.Status.State
+	dst.Status.LastClaimedTime = src.Status.LastClaimedTime
+	dst.Status.Claims = src.Status.Claims
+	dst.Status.ClaimedBy = ""
+	if l := len(src.Status.Claims); l > 0 {
+		dst.Status.ClaimedBy = "ServiceClaim/" + src.Status.Claims[l-1]
+	}
+	dst.Status.IdleSince = src.Status.IdleSince
+	dst.Status.Transitions = nil
+	for _, t := range src.Status.Transitions {
+		dst.Status.Transitions = append(dst.Status.Transitions, v1alpha1.StateTransition{
+			State:  t.State,
+			Reason: t.Reason,
+			Time:   t.Time,
+			Actor:  t.Actor,
+		})
+	}
+	dst.Status.HealthChecks = nil
+	for _, h := range src.Status.HealthChecks {
+		dst.Status.HealthChecks = append(dst.Status.HealthChecks, v1alpha1.HealthCheckResult(h))
+	}
+	dst.Status.Conditions = src.Status.Conditions
+	dst.Status.ObservedGeneration = src.Status.ObservedGeneration
+	dst.Status.Summary = src.Status.Summary
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1alpha1) to this version
+func (dst *RegisteredService) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.RegisteredService)
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec.ServiceMetadata = ServiceMetadata(src.Spec.ServiceMetadata)
+	dst.Spec.SLA = src.Spec.SLA
+	dst.Spec.Priority = src.Spec.Priority
+	dst.Spec.SharingPolicy = string(src.Spec.SharingPolicy)
+	dst.Spec.MaxClaims = src.Spec.MaxClaims
+	dst.Spec.Maintenance = (*Maintenance)(src.Spec.Maintenance)
+	dst.Spec.Constraints = nil
+	if c := src.Spec.Constraints; c != nil {
+		dst.Spec.Constraints = &RegisteredServiceConstraints{
+			Environments:        environmentsFromV1alpha1(c.Environments),
+			EnvironmentSelector: c.EnvironmentSelector,
+		}
+	}
+	dst.Spec.HealthCheck = healthCheckFromV1alpha1(src.Spec.HealthCheck)
+	dst.Spec.ServiceClassIdentity = nil
+	for _, i := range src.Spec.ServiceClassIdentity {
+		dst.Spec.ServiceClassIdentity = append(dst.Spec.ServiceClassIdentity, ServiceClassIdentityItem{
+			Name:  i.Name,
+			Value: i.Value,
+		})
+	}
+	dst.Spec.ServiceEndpointDefinition = nil
+	for _, i := range src.Spec.ServiceEndpointDefinition {
+		item := ServiceEndpointDefinitionItem{Name: i.Name, Value: i.Value}
+		if r := i.ValueFromSecret; r != nil {
+			item.ValueFromSecret = &ServiceEndpointDefinitionSecretRef{Name: r.Name, Key: r.Key}
+		}
+		dst.Spec.ServiceEndpointDefinition = append(dst.Spec.ServiceEndpointDefinition, item)
+	}
+
+	dst.Status.State = src.Status.State
+	dst.Status.LastClaimedTime = src.Status.LastClaimedTime
+	dst.Status.Claims = src.Status.Claims
+	dst.Status.IdleSince = src.Status.IdleSince
+	dst.Status.Transitions = nil
+	for _, t := range src.Status.Transitions {
+		dst.Status.Transitions = append(dst.Status.Transitions, StateTransition{
+			State:  t.State,
+			Reason: t.Reason,
+			Time:   t.Time,
+			Actor:  t.Actor,
+		})
+	}
+	dst.Status.HealthChecks = nil
+	for _, h := range src.Status.HealthChecks {
+		dst.Status.HealthChecks = append(dst.Status.HealthChecks, HealthCheckResult(h))
+	}
+	dst.Status.Conditions = src.Status.Conditions
+	dst.Status.ObservedGeneration = src.Status.ObservedGeneration
+	dst.Status.Summary = src.Status.Summary
+
+	return nil
+}
+
+// environmentsToV1alpha1 converts structured environment constraints into
+// v1alpha1's list, where excluded environments are prefixed by `!`
+func environmentsToV1alpha1(c *EnvironmentConstraints) []string {
+	if c == nil {
+		return nil
+	}
+
+	var envs []string
+	envs = append(envs, c.Include...)
+	for _, e := range c.Exclude {
+		envs = append(envs, negativeConstraintSymbol+e)
+	}
+	return envs
+}
+
+// environmentsFromV1alpha1 converts v1alpha1's list of environment
+// constraints into structured ones
+func environmentsFromV1alpha1(envs []string) *EnvironmentConstraints {
+	if envs == nil {
+		return nil
+	}
+
+	c := &EnvironmentConstraints{}
+	for _, e := range envs {
+		if strings.HasPrefix(e, negativeConstraintSymbol) {
+			c.Exclude = append(c.Exclude, strings.TrimPrefix(e, negativeConstraintSymbol))
+		} else {
+			c.Include = append(c.Include, e)
+		}
+	}
+	return c
+}
+
+// healthCheckToV1alpha1 converts a health check to the Hub version
+func healthCheckToV1alpha1(hc *HealthCheck) *v1alpha1.HealthCheck {
+	if hc == nil {
+		return nil
+	}
+
+	dst := &v1alpha1.HealthCheck{
+		Interval:                hc.Interval,
+		ActiveDeadlineSeconds:   hc.ActiveDeadlineSeconds,
+		TTLSecondsAfterFinished: hc.TTLSecondsAfterFinished,
+		ConcurrencyPolicy:       v1alpha1.HealthCheckConcurrencyPolicy(hc.ConcurrencyPolicy),
+		FailureThreshold:        hc.FailureThreshold,
+		SuccessThreshold:        hc.SuccessThreshold,
+	}
+	if c := hc.Container; c != nil {
+		dst.Container = &v1alpha1.HealthCheckContainer{
+			Image:                              c.Image,
+			Command:                            c.Command,
+			ImagePullPolicy:                    c.ImagePullPolicy,
+			ServiceEndpointDefinitionMountPath: c.ServiceEndpointDefinitionMountPath,
+			BindingSecretMountPath:             c.BindingSecretMountPath,
+			Resources:                          c.Resources,
+			ServiceAccountName:                 c.ServiceAccountName,
+			SecurityContext:                    c.SecurityContext,
+		}
+	}
+	if h := hc.HTTPGet; h != nil {
+		dst.HTTPGet = &v1alpha1.HealthCheckHTTPGetAction{
+			HealthCheckEndpoint: v1alpha1.HealthCheckEndpoint(h.HealthCheckEndpoint),
+			Path:                h.Path,
+			Scheme:              h.Scheme,
+		}
+	}
+	if t := hc.TCPSocket; t != nil {
+		dst.TCPSocket = &v1alpha1.HealthCheckTCPSocketAction{
+			HealthCheckEndpoint: v1alpha1.HealthCheckEndpoint(t.HealthCheckEndpoint),
+		}
+	}
+	if g := hc.GRPC; g != nil {
+		dst.GRPC = &v1alpha1.HealthCheckGRPCAction{
+			HealthCheckEndpoint: v1alpha1.HealthCheckEndpoint(g.HealthCheckEndpoint),
+			Service:             g.Service,
+		}
+	}
+	return dst
+}
+
+// healthCheckFromV1alpha1 converts a health check from the Hub version
+func healthCheckFromV1alpha1(hc *v1alpha1.HealthCheck) *HealthCheck {
+	if hc == nil {
+		return nil
+	}
+
+	dst := &HealthCheck{
+		Interval:                hc.Interval,
+		ActiveDeadlineSeconds:   hc.ActiveDeadlineSeconds,
+		TTLSecondsAfterFinished: hc.TTLSecondsAfterFinished,
+		ConcurrencyPolicy:       HealthCheckConcurrencyPolicy(hc.ConcurrencyPolicy),
+		FailureThreshold:        hc.FailureThreshold,
+		SuccessThreshold:        hc.SuccessThreshold,
+	}
+	if c := hc.Container; c != nil {
+		dst.Container = &HealthCheckContainer{
+			Image:                              c.Image,
+			Command:                            c.Command,
+			ImagePullPolicy:                    c.ImagePullPolicy,
+			ServiceEndpointDefinitionMountPath: c.ServiceEndpointDefinitionMountPath,
+			BindingSecretMountPath:             c.BindingSecretMountPath,
+			Resources:                          c.Resources,
+			ServiceAccountName:                 c.ServiceAccountName,
+			SecurityContext:                    c.SecurityContext,
+		}
+	}
+	if h := hc.HTTPGet; h != nil {
+		dst.HTTPGet = &HealthCheckHTTPGetAction{
+			HealthCheckEndpoint: HealthCheckEndpoint(h.HealthCheckEndpoint),
+			Path:                h.Path,
+			Scheme:              h.Scheme,
+		}
+	}
+	if t := hc.TCPSocket; t != nil {
+		dst.TCPSocket = &HealthCheckTCPSocketAction{
+			HealthCheckEndpoint: HealthCheckEndpoint(t.HealthCheckEndpoint),
+		}
+	}
+	if g := hc.GRPC; g != nil {
+		dst.GRPC = &HealthCheckGRPCAction{
+			HealthCheckEndpoint: HealthCheckEndpoint(g.HealthCheckEndpoint),
+			Service:             g.Service,
+		}
+	}
+	return dst
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+var _ = Describe("RegisteredService conversion", func() {
+	now := metav1.Now()
+	hub := v1alpha1.RegisteredService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "spam",
+			Namespace: "eggs",
+		},
+		Spec: v1alpha1.RegisteredServiceSpec{
+			ServiceMetadata: v1alpha1.ServiceMetadata{
+				DisplayName:      "Spam DB",
+				Description:      "PostgreSQL database of the spam team",
+				Icon:             "https://example.com/postgres.svg",
+				DocumentationURL: "https://example.com/docs/spam-db",
+				Tags:             []string{"database", "sql"},
+			},
+			Constraints: &v1alpha1.RegisteredServiceConstraints{
+				Environments:        []string{"dev", "stage", "!prod"},
+				EnvironmentSelector: "topology.kubernetes.io/region=eu-west-1",
+			},
+			HealthCheck: &v1alpha1.HealthCheck{
+				Container: &v1alpha1.HealthCheckContainer{
+					Image:                              "postgres",
+					Command:                            "pg_isready",
+					ImagePullPolicy:                    corev1.PullIfNotPresent,
+					ServiceEndpointDefinitionMountPath: "/etc/sed",
+					BindingSecretMountPath:             "/bindings/db",
+					Resources: &corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+					},
+					ServiceAccountName: "healthcheck",
+					SecurityContext:    &corev1.SecurityContext{RunAsNonRoot: pointer.Bool(true)},
+				},
+				HTTPGet: &v1alpha1.HealthCheckHTTPGetAction{
+					HealthCheckEndpoint: v1alpha1.HealthCheckEndpoint{HostKey: "url", PortKey: "http-port"},
+					Path:                "/healthz",
+					Scheme:              corev1.URISchemeHTTPS,
+				},
+				TCPSocket:               &v1alpha1.HealthCheckTCPSocketAction{},
+				GRPC:                    &v1alpha1.HealthCheckGRPCAction{Service: "db"},
+				Interval:                &metav1.Duration{Duration: time.Minute},
+				ActiveDeadlineSeconds:   pointer.Int64(30),
+				TTLSecondsAfterFinished: pointer.Int32(600),
+				ConcurrencyPolicy:       v1alpha1.HealthCheckConcurrencyPolicyReplace,
+				FailureThreshold:        pointer.Int32(5),
+				SuccessThreshold:        pointer.Int32(2),
+			},
+			SLA:                  "L1",
+			Priority:             10,
+			SharingPolicy:        v1alpha1.RegisteredServiceSharingPolicyShared,
+			MaxClaims:            3,
+			Maintenance:          &v1alpha1.Maintenance{Message: "upgrading", Drain: true},
+			ServiceClassIdentity: []v1alpha1.ServiceClassIdentityItem{{Name: "type", Value: "psql"}},
+			ServiceEndpointDefinition: []v1alpha1.ServiceEndpointDefinitionItem{
+				{Name: "host", Value: "localhost"},
+				{Name: "password", ValueFromSecret: &v1alpha1.ServiceEndpointDefinitionSecretRef{Name: "spam-descriptor", Key: "password"}},
+			},
+		},
+		Status: v1alpha1.RegisteredServiceStatus{
+			State:           v1alpha1.RegisteredServiceStateClaimed,
+			LastClaimedTime: &now,
+			ClaimedBy:       "ServiceClaim/spam",
+			Claims:          []string{"eggs", "spam"},
+			Transitions: []v1alpha1.StateTransition{
+				{State: v1alpha1.RegisteredServiceStateClaimed, Reason: "ServiceClaimed", Time: now, Actor: "ServiceClaim/spam"},
+			},
+			HealthChecks: []v1alpha1.HealthCheckResult{
+				{Time: now, Passed: false, Duration: metav1.Duration{Duration: time.Second}, Message: "connection refused"},
+			},
+			Conditions: []metav1.Condition{
+				{Type: v1alpha1.RegisteredServiceConditionHealthy, Status: metav1.ConditionFalse, Reason: "HealthCheckFailed", LastTransitionTime: now},
+			},
+			ObservedGeneration: 2,
+			Summary:            "Claimed, unhealthy: connection refused",
+		},
+	}
+
+	It("Converts constraints to structured ones", func() {
+		rs := RegisteredService{}
+		Expect(rs.ConvertFrom(&hub)).To(Succeed())
+
+		Expect(rs.Spec.Constraints.Environments).To(Equal(&EnvironmentConstraints{
+			Include: []string{"dev", "stage"},
+			Exclude: []string{"prod"},
+		}))
+		Expect(rs.Spec.Constraints.EnvironmentSelector).To(Equal("topology.kubernetes.io/region=eu-west-1"))
+		Expect(rs.Spec.HealthCheck.Container.Command).To(Equal("pg_isready"))
+		Expect(rs.Status.Transitions).To(HaveLen(1))
+	})
+
+	It("Round trips through v1beta1", func() {
+		rs := RegisteredService{}
+		Expect(rs.ConvertFrom(&hub)).To(Succeed())
+
+		actual := v1alpha1.RegisteredService{}
+		Expect(rs.ConvertTo(&actual)).To(Succeed())
+		Expect(actual).To(Equal(hub))
+	})
+
+	It("Keeps services without constraints unconstrained", func() {
+		rs := RegisteredService{}
+		Expect(rs.ConvertFrom(&v1alpha1.RegisteredService{})).To(Succeed())
+		Expect(rs.Spec.Constraints).To(BeNil())
+
+		actual := v1alpha1.RegisteredService{}
+		Expect(rs.ConvertTo(&actual)).To(Succeed())
+		Expect(actual.Spec.Constraints).To(BeNil())
+	})
+})
@@ -0,0 +1,422 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EnvironmentConstraints defines in which environments a resource may be used
+type EnvironmentConstraints struct {
+	// Include lists the environments the resource may be used in.  `*`
+	// matches any sequence of characters (e.g. `dev-*`).
+	// +optional
+	Include []string `json:"include,omitempty"`
+
+	// Exclude lists the environments the resource can not be used in.  If set,
+	// the resource may be used in any environment that is not excluded.  `*`
+	// matches any sequence of characters (e.g. `*-restricted`).
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// RegisteredServiceConstraints defines constrains to be honored when determining
+// whether the service can be claimed from certain environments.
+type RegisteredServiceConstraints struct {
+	// Environments defines in which environments the RegisteredService may be used.
+	// +optional
+	Environments *EnvironmentConstraints `json:"environments,omitempty"`
+	// EnvironmentSelector is a label selector expression restricting the
+	// environments the RegisteredService may be used in to the ones with a
+	// ClusterEnvironment whose Topology labels and Labels match it (e.g.
+	// `topology.kubernetes.io/region in (eu-west-1,eu-central-1),!deprecated`).
+	// +optional
+	EnvironmentSelector string `json:"environmentSelector,omitempty"`
+}
+
+// ServiceClassIdentityItem defines an attribute that is necessary to
+// identify a service class.
+type ServiceClassIdentityItem struct {
+	// Name of the service class identity attribute.
+	Name string `json:"name"`
+
+	// Value of the service class identity attribute.
+	Value string `json:"value"`
+}
+
+// ServiceEndpointDefinitionSecretRef defines a reference to
+// one of the keys of a secret. This reference can then be used
+// when defining a ServiceEndpointDefinitionItem
+type ServiceEndpointDefinitionSecretRef struct {
+	// Name of the secret reference
+	Name string `json:"name"`
+
+	// Key of the secret reference field
+	Key string `json:"key"`
+}
+
+// ServiceEndpointDefinitionItem defines an attribute that is necessary for
+// a client to connect to a service
+type ServiceEndpointDefinitionItem struct {
+	// Name of the service endpoint definition attribute.
+	Name string `json:"name"`
+
+	// Value of the service endpoint definition attribute. It is mutually
+	// exclusive with ValueFromSecret.
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// Value reference of the service endpoint definition attribute. It is mutually
+	// exclusive with Value
+	// +optional
+	ValueFromSecret *ServiceEndpointDefinitionSecretRef `json:"valueFromSecret,omitempty"`
+}
+
+// HealthCheckContainer defines the container information to be used to
+// run health checks for the service.
+type HealthCheckContainer struct {
+	// Container image with the client to run the test
+	Image string `json:"image"`
+	// Command to execute in the container to run the test.  Arguments are
+	// split on whitespaces, unless quoted with single or double quotes.
+	Command string `json:"command"`
+	// ImagePullPolicy of the container image
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// ServiceEndpointDefinitionMountPath is the directory the values of the
+	// service endpoint definition are mounted into, one file per item.  They
+	// are also provided as environment variables named after the items in
+	// upper case, e.g. `DB_PORT` for `db-port`.
+	// +optional
+	ServiceEndpointDefinitionMountPath string `json:"serviceEndpointDefinitionMountPath,omitempty"`
+	// BindingSecretMountPath is the directory the binding secret is mounted
+	// into, as it is projected into bound applications: one file per
+	// service class identity and service endpoint definition item, following
+	// the Service Binding specification.  This gives checks that need full
+	// credentials, like password files or TLS certificates, access to them.
+	// +optional
+	BindingSecretMountPath string `json:"bindingSecretMountPath,omitempty"`
+	// Resources required by the health check container
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// ServiceAccountName is the name of the ServiceAccount the health check
+	// Pod runs as
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// SecurityContext of the health check container, e.g. to comply with the
+	// restricted Pod Security Standard
+	// +optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+}
+
+// HealthCheckEndpoint defines which ServiceEndpointDefinition items hold the
+// address a health probe connects to.
+type HealthCheckEndpoint struct {
+	// HostKey is the name of the ServiceEndpointDefinition item holding the
+	// host of the service.  Defaults to "host".
+	// +optional
+	HostKey string `json:"hostKey,omitempty"`
+	// PortKey is the name of the ServiceEndpointDefinition item holding the
+	// port of the service.  Defaults to "port".
+	// +optional
+	PortKey string `json:"portKey,omitempty"`
+}
+
+// HealthCheckHTTPGetAction defines an HTTP GET health probe.
+type HealthCheckHTTPGetAction struct {
+	HealthCheckEndpoint `json:",inline"`
+	// Path to request.  Defaults to "/".
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Scheme to connect with, HTTP or HTTPS.  Defaults to HTTP.
+	// +optional
+	Scheme corev1.URIScheme `json:"scheme,omitempty"`
+}
+
+// HealthCheckTCPSocketAction defines a TCP health probe.
+type HealthCheckTCPSocketAction struct {
+	HealthCheckEndpoint `json:",inline"`
+}
+
+// HealthCheckGRPCAction defines a gRPC health probe.
+type HealthCheckGRPCAction struct {
+	HealthCheckEndpoint `json:",inline"`
+	// Service is the name of the service to check, as defined by the gRPC
+	// health checking protocol.  Defaults to the whole server.
+	// +optional
+	Service string `json:"service,omitempty"`
+}
+
+// HealthCheckConcurrencyPolicy defines how concurrent runs of a health check
+// container are handled
+// +kubebuilder:validation:Enum=Forbid;Replace
+type HealthCheckConcurrencyPolicy string
+
+const (
+	HealthCheckConcurrencyPolicyForbid  HealthCheckConcurrencyPolicy = "Forbid"
+	HealthCheckConcurrencyPolicyReplace HealthCheckConcurrencyPolicy = "Replace"
+)
+
+// HealthCheck defines metadata that can be used check
+// the health of a service and report status.
+type HealthCheck struct {
+	// Container defines a container that will run a check against the
+	// ServiceEndpointDefinition to determine connectivity and access.
+	// +optional
+	Container *HealthCheckContainer `json:"container,omitempty"`
+
+	// HTTPGet checks that an HTTP GET request to the service succeeds.
+	// +optional
+	HTTPGet *HealthCheckHTTPGetAction `json:"httpGet,omitempty"`
+
+	// TCPSocket checks that a TCP connection to the service can be opened.
+	// +optional
+	TCPSocket *HealthCheckTCPSocketAction `json:"tcpSocket,omitempty"`
+
+	// GRPC checks the service with the gRPC health checking protocol.
+	// +optional
+	GRPC *HealthCheckGRPCAction `json:"grpc,omitempty"`
+
+	// Interval between two runs of the health check.  Defaults to 5 minutes.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// ActiveDeadlineSeconds is the time a run of the health check is allowed
+	// to take before failing.  Defaults to the interval for containers, and
+	// to 10 seconds for probes.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// TTLSecondsAfterFinished is the time the Job running a health check
+	// container is kept after finishing.  If not set, the latest Jobs are
+	// kept until they are replaced by newer ones.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// ConcurrencyPolicy defines what happens when a health check container
+	// is due to run while the previous run is still running: `Forbid` (the
+	// default) skips the new run, while `Replace` stops the previous run.
+	// +optional
+	ConcurrencyPolicy HealthCheckConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+
+	// FailureThreshold is the number of consecutive failed runs after which
+	// the service is considered unreachable.  Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+
+	// SuccessThreshold is the number of consecutive passed runs after which
+	// an unreachable service is considered reachable again.  Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	SuccessThreshold *int32 `json:"successThreshold,omitempty"`
+}
+
+// ServiceMetadata describes a service to developers, so that tooling can
+// render it in a marketplace view
+type ServiceMetadata struct {
+	// DisplayName is the human-readable name of the service
+	// +optional
+	DisplayName string `json:"displayName,omitempty"`
+
+	// Description of the service
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Icon is the URL of the service's icon, possibly a data URL
+	// +optional
+	Icon string `json:"icon,omitempty"`
+
+	// DocumentationURL is the URL of the service's documentation
+	// +optional
+	DocumentationURL string `json:"documentationURL,omitempty"`
+
+	// Tags categorize the service, e.g. `database` or `sql`
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+}
+
+// RegisteredServiceSpec defines the desired state of RegisteredService
+type RegisteredServiceSpec struct {
+	// ServiceMetadata describes the service to developers
+	ServiceMetadata `json:",inline"`
+
+	// Constraints defines under which circumstances the RegisteredService may
+	// be used.
+	// +optional
+	Constraints *RegisteredServiceConstraints `json:"constraints,omitempty"`
+
+	// HealthCheck defines a health check for the underlying service.
+	// +optional
+	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
+
+	// SLA defines the support level for this service.
+	// +optional
+	SLA string `json:"sla,omitempty"`
+
+	// Priority of the service over the other services matching the same
+	// claims, the higher the preferred.  Used by the claims ranking matching
+	// services by `Priority`.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// SharingPolicy defines whether the service can be claimed by a single
+	// ServiceClaim at a time, `Exclusive`, e.g. a dedicated database, or by
+	// several ones, `Shared`, e.g. a cache.  Defaults to `Exclusive`.
+	// +kubebuilder:validation:Enum=Exclusive;Shared
+	// +optional
+	SharingPolicy string `json:"sharingPolicy,omitempty"`
+
+	// MaxClaims limits the number of ServiceClaims a `Shared` service can be
+	// claimed by at once.  Unlimited if not set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxClaims int32 `json:"maxClaims,omitempty"`
+
+	// Maintenance, when set, puts the service in maintenance: it is removed
+	// from the ServiceCatalogs and can not be claimed any more, while the
+	// ServiceClaims that already claim it keep their bindings.
+	// +optional
+	Maintenance *Maintenance `json:"maintenance,omitempty"`
+
+	// ServiceClassIdentity defines a set of attributes that are sufficient to
+	// identify a service class.  A ServiceClaim whose ServiceClassIdentity
+	// field is a subset of a RegisteredService's keys can claim that service.
+	ServiceClassIdentity []ServiceClassIdentityItem `json:"serviceClassIdentity"`
+
+	// ServiceEndpointDefinition defines a set of attributes sufficient for a
+	// client to establish a connection to the service.
+	ServiceEndpointDefinition []ServiceEndpointDefinitionItem `json:"serviceEndpointDefinition"`
+}
+
+// StateTransition records a change of state of a resource
+type StateTransition struct {
+	// State the resource moved to
+	State string `json:"state"`
+	// Reason of the transition
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Time of the transition
+	Time metav1.Time `json:"time"`
+	// Actor that caused the transition
+	// +optional
+	Actor string `json:"actor,omitempty"`
+}
+
+// Maintenance describes the maintenance of a RegisteredService
+type Maintenance struct {
+	// Message explains the maintenance to the users of the service, e.g.
+	// `migrating to PostgreSQL 15`.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Drain signals the applications bound to the service that they should
+	// stop using it: their ServiceClaims get the Draining condition and a
+	// warning event is recorded on their ServiceBindings.
+	// +optional
+	Drain bool `json:"drain,omitempty"`
+}
+
+// HealthCheckResult records an execution of a health check
+type HealthCheckResult struct {
+	// Time the health check started at
+	Time metav1.Time `json:"time"`
+	// Passed reports whether the health check passed
+	Passed bool `json:"passed"`
+	// Duration of the health check
+	// +optional
+	Duration metav1.Duration `json:"duration,omitempty"`
+	// Message explaining the result, e.g. why the health check failed
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RegisteredServiceStatus defines the observed state of RegisteredService.
+type RegisteredServiceStatus struct {
+	// State describes the current state of the service.
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// LastClaimedTime is the last time the service has been claimed or released.
+	// +optional
+	LastClaimedTime *metav1.Time `json:"lastClaimedTime,omitempty"`
+
+	// Claims lists the names of the ServiceClaims the service is claimed by.
+	// +optional
+	Claims []string `json:"claims,omitempty"`
+
+	// IdleSince is set when the service has been available without being claimed
+	// for longer than the configured idle period, and it reports since when the
+	// service is not claimed.
+	// +optional
+	IdleSince *metav1.Time `json:"idleSince,omitempty"`
+
+	// Transitions records the latest changes of state of the service.
+	// +optional
+	Transitions []StateTransition `json:"transitions,omitempty"`
+
+	// HealthChecks records the latest executions of the health check.
+	// +optional
+	HealthChecks []HealthCheckResult `json:"healthChecks,omitempty"`
+
+	// Conditions of the service.  The Healthy condition reports the result
+	// of the latest health check.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of the RegisteredService the status
+	// was last computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Summary describes the status at a glance.
+	// +optional
+	Summary string `json:"summary,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state",description="the state of the RegisteredService"
+//+kubebuilder:printcolumn:name="Summary",type="string",JSONPath=".status.summary",description="the status of the RegisteredService at a glance"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// RegisteredService is the Schema for the registeredservices API.
+type RegisteredService struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RegisteredServiceSpec   `json:"spec,omitempty"`
+	Status RegisteredServiceStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RegisteredServiceList contains a list of RegisteredService.
+type RegisteredServiceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RegisteredService `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RegisteredService{}, &RegisteredServiceList{})
+}
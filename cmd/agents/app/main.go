@@ -35,6 +35,9 @@ import (
 	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
 	sccontrollers "github.com/primaza/primaza/controllers"
 	controllers "github.com/primaza/primaza/controllers/agents/app"
+	"github.com/primaza/primaza/pkg/primaza/diagnostics"
+	"github.com/primaza/primaza/pkg/primaza/tuning"
+	"github.com/primaza/primaza/pkg/version"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -56,25 +59,38 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var pprofAddr string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&pprofAddr, "pprof-bind-address", "",
+		"The address the pprof endpoint binds to. The endpoint is disabled if empty.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	var tuningOpts tuning.Options
+	tuningOpts.BindFlags(flag.CommandLine)
 	opts := zap.Options{
 		Development: true,
 	}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	logLevel := diagnostics.NewAtomicLevel(&opts)
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if err := tuning.LoadEnv(flag.CommandLine); err != nil {
+		setupLog.Error(err, "unable to load tuning options")
+		os.Exit(1)
+	}
+
 	ns, err := getWatchNamespaceFromEnv()
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	cfg := ctrl.GetConfigOrDie()
+	tuningOpts.ApplyToConfig(cfg)
+	mgrOpts := ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443,
@@ -93,7 +109,9 @@ func main() {
 		// if you are doing or is intended to do any operation such as perform cleanups
 		// after the manager stops then its usage might be unsafe.
 		// LeaderElectionReleaseOnCancel: true,
-	})
+	}
+	tuningOpts.ApplyToManager(&mgrOpts, scheme)
+	mgr, err := ctrl.NewManager(cfg, mgrOpts)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
@@ -107,7 +125,9 @@ func main() {
 	if err = (&controllers.ServiceClaimReconciler{
 		ServiceClaimReconciler: sccontrollers.ServiceClaimReconciler{Client: mgr.GetClient(),
 			Scheme: mgr.GetScheme(),
-		}}).SetupWithManager(mgr); err != nil {
+		},
+		ControlPlaneLimiter: tuningOpts.ControlPlaneLimiter(),
+	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ServiceClaim")
 		os.Exit(1)
 	}
@@ -123,6 +143,10 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Agent Service")
 		os.Exit(1)
 	}
+	if err = diagnostics.AddToManager(mgr, ns, logLevel, pprofAddr); err != nil {
+		setupLog.Error(err, "unable to add diagnostics")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -134,7 +158,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	setupLog.Info("starting manager")
+	setupLog.Info("starting manager", "version", version.Version)
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
@@ -25,15 +25,24 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
 	"github.com/primaza/primaza/controllers/agents/svc"
+	"github.com/primaza/primaza/pkg/primaza/diagnostics"
+	"github.com/primaza/primaza/pkg/primaza/discovery"
+	"github.com/primaza/primaza/pkg/primaza/tuning"
+	"github.com/primaza/primaza/pkg/version"
+	// discovery handlers register themselves when imported, e.g.
+	// _ "example.com/primaza-ack/rds"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -55,25 +64,48 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var pprofAddr string
+	var registrationWorkers int
+	var listPageSize int
+	var listFromCache bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&pprofAddr, "pprof-bind-address", "",
+		"The address the pprof endpoint binds to. The endpoint is disabled if empty.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.IntVar(&registrationWorkers, "registration-workers", svc.DefaultRegistrationWorkers,
+		"The number of resources of a ServiceClass registered concurrently.")
+	flag.IntVar(&listPageSize, "list-page-size", svc.DefaultListPageSize,
+		"The number of resources of a ServiceClass listed per request. Resources are listed all at once if 0.")
+	flag.BoolVar(&listFromCache, "list-from-cache", false,
+		"List the resources of ServiceClasses from the API server's watch cache, i.e. at resource version 0. "+
+			"Such listings may be stale, and are not paginated.")
+	var tuningOpts tuning.Options
+	tuningOpts.BindFlags(flag.CommandLine)
 	opts := zap.Options{
 		Development: true,
 	}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	logLevel := diagnostics.NewAtomicLevel(&opts)
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if err := tuning.LoadEnv(flag.CommandLine); err != nil {
+		setupLog.Error(err, "unable to load tuning options")
+		os.Exit(1)
+	}
+
 	ns, err := getWatchNamespaceFromEnv()
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	cfg := ctrl.GetConfigOrDie()
+	tuningOpts.ApplyToConfig(cfg)
+	mgrOpts := ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443,
@@ -81,6 +113,10 @@ func main() {
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "859ca7e5.agentsvc.primaza.io",
 		Namespace:              ns,
+		// secrets, configmaps and the roles granting access to them are
+		// read directly, so that the agent does not need to list and
+		// watch all of them in the namespace
+		ClientDisableCacheFor: []client.Object{&corev1.Secret{}, &corev1.ConfigMap{}, &rbacv1.Role{}, &rbacv1.RoleBinding{}},
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -92,13 +128,19 @@ func main() {
 		// if you are doing or is intended to do any operation such as perform cleanups
 		// after the manager stops then its usage might be unsafe.
 		// LeaderElectionReleaseOnCancel: true,
-	})
+	}
+	tuningOpts.ApplyToManager(&mgrOpts, scheme)
+	mgr, err := ctrl.NewManager(cfg, mgrOpts)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
 	serviceClassController := svc.NewServiceClassReconciler(mgr)
+	serviceClassController.RegistrationWorkers = registrationWorkers
+	serviceClassController.ListPageSize = listPageSize
+	serviceClassController.ListFromCache = listFromCache
+	serviceClassController.ControlPlaneLimiter = tuningOpts.ControlPlaneLimiter()
 	if err = serviceClassController.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ServiceClass")
 		os.Exit(1)
@@ -113,6 +155,10 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Agent Service")
 		os.Exit(1)
 	}
+	if err = diagnostics.AddToManager(mgr, ns, logLevel, pprofAddr); err != nil {
+		setupLog.Error(err, "unable to add diagnostics")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -124,7 +170,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	setupLog.Info("starting manager")
+	setupLog.Info("starting manager", "version", version.Version, "discoveryHandlers", discovery.Names())
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
@@ -20,6 +20,8 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -28,12 +30,23 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	primazaiov1beta1 "github.com/primaza/primaza/api/v1beta1"
 	"github.com/primaza/primaza/controllers"
+	"github.com/primaza/primaza/pkg/primaza/catalogserver"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+	"github.com/primaza/primaza/pkg/primaza/controlplane"
+	"github.com/primaza/primaza/pkg/primaza/diagnostics"
+	"github.com/primaza/primaza/pkg/primaza/library"
+	"github.com/primaza/primaza/pkg/primaza/notify"
+	"github.com/primaza/primaza/pkg/primaza/vault"
+	"github.com/primaza/primaza/pkg/primaza/workercluster"
+	"github.com/primaza/primaza/pkg/version"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -52,6 +65,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(primazaiov1alpha1.AddToScheme(scheme))
+	utilruntime.Must(primazaiov1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -59,8 +73,56 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var pprofAddr string
+	var idlePeriod time.Duration
+	var failoverClaims bool
+	var ro runnableOptions
+	var heartbeatPeriod time.Duration
+	var agentTokenServer string
+	var connectionIdleTimeout time.Duration
+	var vaultAddr, vaultTokenFile string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&pprofAddr, "pprof-bind-address", "",
+		"The address the pprof endpoint binds to. The endpoint is disabled if empty.")
+	flag.DurationVar(&idlePeriod, "registered-service-idle-period", 0,
+		"The period after which an unclaimed registered service is flagged as idle. "+
+			"Idle detection is disabled if not positive.")
+	flag.BoolVar(&failoverClaims, "failover-claims", false,
+		"Move back to pending the claims whose registered service is deregistered, "+
+			"so that they can be resolved by another registered service. "+
+			"Claims can override it with their rebind policy.")
+	flag.IntVar(&ro.backPressureQueueDepth, "backpressure-queue-depth", 0,
+		"The number of items queued by the controllers above which agents are asked to slow down their writes. "+
+			"Back-pressure is disabled if not positive.")
+	flag.DurationVar(&ro.backPressureDelay, "backpressure-delay", 30*time.Second,
+		"The time agents are asked to wait before writing again when back-pressure is signaled.")
+	flag.StringVar(&ro.notificationConfig, "notification-config", "",
+		"The file configuring the sinks operational alerts are sent to. "+
+			"Notifications are disabled if empty.")
+	flag.StringVar(&ro.catalogAddr, "catalog-bind-address", "",
+		"The address the service catalog endpoint binds to. The endpoint is disabled if empty.")
+	flag.StringVar(&ro.catalogCertDir, "catalog-cert-dir", "",
+		"The directory holding the certificate (tls.crt) and the key (tls.key) the service catalog endpoint serves HTTPS with. "+
+			"The endpoint serves HTTP if empty.")
+	flag.BoolVar(&ro.crossplaneServiceClasses, "crossplane-service-classes", false,
+		"Generate a ServiceClass for each Crossplane CompositeResourceDefinition offering a claim. "+
+			"Requires Crossplane to be installed in the cluster.")
+	flag.StringVar(&ro.serviceClassLibrary, "service-class-library", "",
+		"The comma-separated names of the templates of the ServiceClass library to install, or \"all\". "+
+			"Available templates are: "+strings.Join(library.Names(), ", ")+".")
+	flag.DurationVar(&heartbeatPeriod, "cluster-environment-heartbeat-period", controllers.DefaultHeartbeatPeriod,
+		"The period between two checks of the connection to the clusters of the cluster environments.")
+	flag.StringVar(&agentTokenServer, "agent-token-server", "",
+		"The URL of the API server agents authenticating with short-lived tokens connect to. "+
+			"Defaults to the URL Primaza connects to.")
+	flag.DurationVar(&connectionIdleTimeout, "cluster-connection-idle-timeout", workercluster.DefaultPoolIdleTimeout,
+		"The time after which unused connections to the clusters of the cluster environments are closed.")
+	flag.StringVar(&vaultAddr, "vault-address", "",
+		"The address of the Vault server the secret values of the claims asking for it are stored in. "+
+			"The Vault integration is disabled if empty.")
+	flag.StringVar(&vaultTokenFile, "vault-token-file", "/var/run/secrets/vault/token",
+		"The file holding the token Primaza authenticates to Vault with.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -70,6 +132,7 @@ func main() {
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	logLevel := diagnostics.NewAtomicLevel(&opts)
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
 	cfg, err := getConfig()
@@ -104,18 +167,30 @@ func main() {
 		os.Exit(1)
 	}
 
+	pool := workercluster.NewPool(connectionIdleTimeout)
 	if err = (&controllers.ClusterEnvironmentReconciler{
-		Client:        mgr.GetClient(),
-		Scheme:        mgr.GetScheme(),
-		AppAgentImage: cfg.AppImage,
-		SvcAgentImage: cfg.SvcImage,
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Recorder:        mgr.GetEventRecorderFor(constants.ControlPlaneActor),
+		AppAgentImage:   cfg.AppImage,
+		SvcAgentImage:   cfg.SvcImage,
+		HeartbeatPeriod: heartbeatPeriod,
+		AgentTokens:     newAgentTokenIssuer(mgr.GetConfig(), agentTokenServer),
+		Pool:            pool,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ClusterEnvironment")
 		os.Exit(1)
 	}
+	if err = (&primazaiov1alpha1.ClusterEnvironment{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterEnvironment")
+		os.Exit(1)
+	}
 	if err = (&controllers.ServiceClaimReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor(constants.ControlPlaneActor),
+		Pool:     pool,
+		Vault:    newVaultClient(vaultAddr, vaultTokenFile),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ServiceClaim")
 		os.Exit(1)
@@ -123,6 +198,7 @@ func main() {
 	if err = (&controllers.ServiceClassReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		Pool:   pool,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ServiceClass")
 		os.Exit(1)
@@ -132,10 +208,33 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&controllers.RegisteredServiceReconciler{
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		Recorder:       mgr.GetEventRecorderFor(constants.ControlPlaneActor),
+		FailoverClaims: failoverClaims,
+		Pool:           pool,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RegisteredService")
+		os.Exit(1)
+	}
+	if err = (&primazaiov1alpha1.RegisteredService{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "RegisteredService")
+		os.Exit(1)
+	}
+	if idlePeriod > 0 {
+		if err = (&controllers.RegisteredServiceIdleReconciler{
+			Client:     mgr.GetClient(),
+			IdlePeriod: idlePeriod,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "RegisteredServiceIdle")
+			os.Exit(1)
+		}
+	}
+	if err = (&controllers.RegisteredServiceHealthCheckReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "RegisteredService")
+		setupLog.Error(err, "unable to create controller", "controller", "RegisteredServiceHealthCheck")
 		os.Exit(1)
 	}
 
@@ -147,10 +246,20 @@ func main() {
 	if err = (&controllers.ServiceCatalogReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		Pool:   pool,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ServiceCatalog")
 		os.Exit(1)
 	}
+	ro.namespace = cfg.WatchNamespace
+	if err = addRunnables(mgr, pool, ro); err != nil {
+		setupLog.Error(err, "unable to add runnables")
+		os.Exit(1)
+	}
+	if err = diagnostics.AddToManager(mgr, cfg.WatchNamespace, logLevel, pprofAddr); err != nil {
+		setupLog.Error(err, "unable to add diagnostics")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -162,19 +271,143 @@ func main() {
 		os.Exit(1)
 	}
 
-	setupLog.Info("starting manager")
+	setupLog.Info("starting manager", "version", version.Version)
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
 
+// runnableOptions configures the runnables added to the manager besides the
+// controllers
+type runnableOptions struct {
+	namespace              string
+	backPressureQueueDepth int
+	backPressureDelay      time.Duration
+	notificationConfig     string
+	catalogAddr            string
+	catalogCertDir         string
+
+	crossplaneServiceClasses bool
+	serviceClassLibrary      string
+}
+
+// addRunnables adds the pruning of the cluster connection pool, the
+// synchronization of the namespaces of worker clusters, and the
+// back-pressure monitor, the notification watcher, the service catalog
+// endpoint, the generation of ServiceClasses from Crossplane
+// CompositeResourceDefinitions and the installation of the ServiceClass
+// library to the manager, when enabled
+func addRunnables(mgr ctrl.Manager, pool *workercluster.Pool, o runnableOptions) error {
+	if err := mgr.Add(pool); err != nil {
+		return fmt.Errorf("unable to add cluster connection pool: %w", err)
+	}
+
+	if err := (&controllers.NamespaceSyncReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Pool:   pool,
+	}).SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("unable to create controller NamespaceSync: %w", err)
+	}
+
+	if o.backPressureQueueDepth > 0 {
+		if err := mgr.Add(&controllers.BackPressureMonitor{
+			Client:        mgr.GetClient(),
+			Namespace:     o.namespace,
+			MaxQueueDepth: o.backPressureQueueDepth,
+			Delay:         o.backPressureDelay,
+		}); err != nil {
+			return fmt.Errorf("unable to add back-pressure monitor: %w", err)
+		}
+	}
+
+	if o.notificationConfig != "" {
+		notifier, err := notify.LoadConfig(o.notificationConfig)
+		if err != nil {
+			return fmt.Errorf("unable to load notification configuration: %w", err)
+		}
+		if err := mgr.Add(&controllers.NotificationWatcher{
+			Cache:    mgr.GetCache(),
+			Notifier: notifier,
+		}); err != nil {
+			return fmt.Errorf("unable to add notification watcher: %w", err)
+		}
+	}
+
+	if o.crossplaneServiceClasses {
+		if err := (&controllers.CrossplaneServiceClassReconciler{
+			Client:    mgr.GetClient(),
+			Scheme:    mgr.GetScheme(),
+			Namespace: o.namespace,
+		}).SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("unable to create controller CrossplaneServiceClass: %w", err)
+		}
+	}
+
+	if o.serviceClassLibrary != "" {
+		templates, err := library.Select(strings.Split(o.serviceClassLibrary, ","))
+		if err != nil {
+			return fmt.Errorf("unable to load service class library: %w", err)
+		}
+		if err := mgr.Add(&controllers.ServiceClassLibraryInstaller{
+			Client:    mgr.GetClient(),
+			Namespace: o.namespace,
+			Templates: templates,
+		}); err != nil {
+			return fmt.Errorf("unable to add service class library installer: %w", err)
+		}
+	}
+
+	if o.catalogAddr != "" {
+		if err := mgr.Add(&catalogserver.Server{
+			Addr:      o.catalogAddr,
+			Namespace: o.namespace,
+			CertDir:   o.catalogCertDir,
+			Reader:    mgr.GetClient(),
+			Reviewer:  &catalogserver.KubernetesReviewer{Client: mgr.GetClient()},
+		}); err != nil {
+			return fmt.Errorf("unable to add service catalog endpoint: %w", err)
+		}
+	}
+	return nil
+}
+
 type config struct {
 	WatchNamespace string
 	AppImage       string
 	SvcImage       string
 }
 
+// newAgentTokenIssuer returns the issuer of the agents' tokens for the API
+// server of the given REST config, reached by the agents at server if not
+// empty
+func newAgentTokenIssuer(cfg *rest.Config, server string) *controlplane.AgentTokenIssuer {
+	if server == "" {
+		server = cfg.Host
+	}
+	ca := cfg.CAData
+	if len(ca) == 0 && cfg.CAFile != "" {
+		d, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			setupLog.Error(err, "unable to read the certificate authority of the API server")
+			os.Exit(1)
+		}
+		ca = d
+	}
+	return &controlplane.AgentTokenIssuer{Server: server, CAData: ca}
+}
+
+// newVaultClient returns the client of the Vault server at addr, or nil if
+// the Vault integration is disabled
+func newVaultClient(addr, tokenFile string) *vault.Client {
+	if addr == "" {
+		return nil
+	}
+	setupLog.Info("storing secret values in vault", "address", addr)
+	return &vault.Client{Address: addr, TokenFile: tokenFile}
+}
+
 func getConfig() (*config, error) {
 	ns, err := getRequiredEnv(EnvWatchNamespace)
 	if err != nil {
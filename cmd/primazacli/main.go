@@ -0,0 +1,204 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// primazacli gathers the command line tools helping teams to work with
+// Primaza resources, e.g. in their CI.
+//
+// Usage:
+//
+//	primazacli fixtures [-values] FILE...
+//	primazacli library [-list] [NAME...]
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/fixtures"
+	"github.com/primaza/primaza/pkg/primaza/library"
+)
+
+type command struct {
+	description string
+	run         func(args []string, out io.Writer) error
+}
+
+var commands = map[string]command{
+	"fixtures": {
+		description: "generate the service resources matched by ServiceClasses, with dummy values",
+		run:         runFixtures,
+	},
+	"library": {
+		description: "print the ServiceClasses of the library of templates for popular operators",
+		run:         runLibrary,
+	},
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s COMMAND [OPTIONS] [ARGS]\n\nCommands:\n", os.Args[0])
+	for name, c := range commands {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %-10s %s\n", name, c.description)
+	}
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	c, ok := commands[flag.Arg(0)]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if err := c.run(flag.Args()[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+// runFixtures prints the fixtures generated for the ServiceClasses defined in
+// the given files, as a YAML stream
+func runFixtures(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("fixtures", flag.ExitOnError)
+	values := fs.Bool("values", false, "print the values the service endpoint definition is expected to hold instead of the resources")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s fixtures [-values] FILE...\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Reads ServiceClasses from FILEs, or from the standard input if FILE is -, and prints a service resource")
+		fmt.Fprintln(fs.Output(), "matched by each ServiceClass, with the Secrets and secondary resource it refers to.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no file given")
+	}
+
+	for _, file := range fs.Args() {
+		scs, err := readServiceClasses(file)
+		if err != nil {
+			return err
+		}
+
+		for _, sc := range scs {
+			f, err := fixtures.Generate(sc)
+			if err != nil {
+				return fmt.Errorf("service class %s: %w", sc.Name, err)
+			}
+
+			if *values {
+				if err := printYAML(out, map[string]interface{}{sc.Name: f.Values}); err != nil {
+					return err
+				}
+				continue
+			}
+			objs, err := f.Objects()
+			if err != nil {
+				return err
+			}
+			for _, o := range objs {
+				if err := printYAML(out, o.Object); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// runLibrary prints the ServiceClasses of the given templates of the library,
+// or of all of them, as a YAML stream
+func runLibrary(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("library", flag.ExitOnError)
+	list := fs.Bool("list", false, "print the names and versions of the templates instead of their ServiceClasses")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s library [-list] [NAME...]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Prints the ServiceClasses of the NAMEd templates of the library, or of all of them if no NAME is given,")
+		fmt.Fprintln(fs.Output(), "ready to be applied to Primaza's namespace.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	names := fs.Args()
+	if len(names) == 0 {
+		names = []string{library.All}
+	}
+	scs, err := library.Select(names)
+	if err != nil {
+		return err
+	}
+
+	for i := range scs {
+		if *list {
+			if _, err := fmt.Fprintf(out, "%s\t%d\t%s\n", scs[i].Name, library.Version(&scs[i]), scs[i].Spec.Description); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := printYAML(out, scs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readServiceClasses reads the ServiceClasses defined in the given YAML or
+// JSON file, ignoring the other objects
+func readServiceClasses(file string) ([]v1alpha1.ServiceClass, error) {
+	r := os.Stdin
+	if file != "-" {
+		f, err := os.Open(file) // #nosec G304 -- reading user provided files is the purpose of this tool
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var scs []v1alpha1.ServiceClass
+	decoder := k8syaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var sc v1alpha1.ServiceClass
+		if err := decoder.Decode(&sc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return scs, nil
+			}
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if sc.Kind == "ServiceClass" {
+			scs = append(scs, sc)
+		}
+	}
+}
+
+func printYAML(out io.Writer, obj interface{}) error {
+	b, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "---\n%s", b)
+	return err
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/primaza/primaza/api/v1alpha1"
 	"github.com/primaza/primaza/pkg/primaza/constants"
+	"github.com/primaza/primaza/pkg/version"
 	appsv1 "k8s.io/api/apps/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -79,9 +80,11 @@ func (r *AgentApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Req
 				return ctrl.Result{}, err
 			}
 		}
-	} else if !controllerutil.ContainsFinalizer(&agentappdeployment, agentappfinalizer) {
-		// add a finalizer since we have deletion logic
-		if controllerutil.AddFinalizer(&agentappdeployment, agentappfinalizer) {
+	} else {
+		// add a finalizer since we have deletion logic, and report our
+		// version to the control plane
+		f := controllerutil.AddFinalizer(&agentappdeployment, agentappfinalizer)
+		if version.Annotate(&agentappdeployment) || f {
 			if err = r.Update(ctx, &agentappdeployment, &client.UpdateOptions{}); err != nil {
 				return ctrl.Result{}, err
 			}
@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// podSpecPaths maps the kinds of PodSpec-able workloads that do not declare
+// their pod template at `.spec.template` to the path of their pod spec
+var podSpecPaths = map[string][]string{
+	"CronJob": {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// defaultPodSpecPath is the path of the pod spec of most PodSpec-able
+// workloads, e.g. Deployments, StatefulSets, DaemonSets, Jobs and Knative
+// Services
+var defaultPodSpecPath = []string{"spec", "template", "spec"}
+
+// podSpecField returns the path of the given field of the workload's pod spec
+func podSpecField(workload *unstructured.Unstructured, field string) []string {
+	p, ok := podSpecPaths[workload.GetKind()]
+	if !ok {
+		p = defaultPodSpecPath
+	}
+	return append(append([]string{}, p...), field)
+}
+
+// podTemplateMetadataField returns the path of the given field of the
+// metadata of the workload's pod template
+func podTemplateMetadataField(workload *unstructured.Unstructured, field string) []string {
+	p := podSpecField(workload, field)
+	p[len(p)-2] = "metadata"
+	return p
+}
+
+// containersPaths returns the paths of the lists of containers of the
+// workload's pod spec the binding secret is projected into
+func containersPaths(workload *unstructured.Unstructured) [][]string {
+	return [][]string{
+		podSpecField(workload, "containers"),
+		podSpecField(workload, "initContainers"),
+	}
+}
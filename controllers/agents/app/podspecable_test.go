@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPodSpecPaths(t *testing.T) {
+	workload := func(apiVersion, kind string, object map[string]interface{}) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: object}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetName("app")
+		return u
+	}
+	podSpec := func() map[string]interface{} {
+		return map[string]interface{}{
+			"containers":     []interface{}{map[string]interface{}{"name": "app"}},
+			"initContainers": []interface{}{map[string]interface{}{"name": "init"}},
+			"volumes":        []interface{}{map[string]interface{}{"name": "db"}},
+		}
+	}
+	template := func() map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{"annotations": map[string]interface{}{"team": "orders"}},
+			"spec":     podSpec(),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		workload *unstructured.Unstructured
+		podSpec  []string
+	}{
+		{
+			name:     "Deployment",
+			workload: workload("apps/v1", "Deployment", map[string]interface{}{"spec": map[string]interface{}{"template": template()}}),
+			podSpec:  []string{"spec", "template", "spec"},
+		},
+		{
+			name: "CronJob",
+			workload: workload("batch/v1", "CronJob", map[string]interface{}{"spec": map[string]interface{}{
+				"jobTemplate": map[string]interface{}{"spec": map[string]interface{}{"template": template()}},
+			}}),
+			podSpec: []string{"spec", "jobTemplate", "spec", "template", "spec"},
+		},
+		{
+			name:     "Knative Service",
+			workload: workload("serving.knative.dev/v1", "Service", map[string]interface{}{"spec": map[string]interface{}{"template": template()}}),
+			podSpec:  []string{"spec", "template", "spec"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := func(f string) []string { return append(append([]string{}, tt.podSpec...), f) }
+
+			if got := podSpecField(tt.workload, "volumes"); !reflect.DeepEqual(got, field("volumes")) {
+				t.Errorf("podSpecField() = %v, want %v", got, field("volumes"))
+			}
+			want := [][]string{field("containers"), field("initContainers")}
+			if got := containersPaths(tt.workload); !reflect.DeepEqual(got, want) {
+				t.Errorf("containersPaths() = %v, want %v", got, want)
+			}
+
+			// the paths lead to the pod spec of the workload
+			for _, p := range append(containersPaths(tt.workload), podSpecField(tt.workload, "volumes")) {
+				if items, found, err := unstructured.NestedSlice(tt.workload.Object, p...); err != nil || !found || len(items) != 1 {
+					t.Errorf("expected one item at %v, got %v (found: %v, error: %v)", p, items, found, err)
+				}
+			}
+			annotations, found, err := unstructured.NestedStringMap(tt.workload.Object, podTemplateMetadataField(tt.workload, "annotations")...)
+			if err != nil || !found || annotations["team"] != "orders" {
+				t.Errorf("expected the pod template annotations, got %v (found: %v, error: %v)", annotations, found, err)
+			}
+		})
+	}
+}
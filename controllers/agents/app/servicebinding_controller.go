@@ -22,17 +22,18 @@ import (
 	"fmt"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/primaza/primaza/api/v1alpha1"
 	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/audit"
 	"github.com/primaza/primaza/pkg/primaza/constants"
 	"go.uber.org/atomic"
 	v1 "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -44,7 +45,10 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
@@ -60,6 +64,15 @@ type ServiceBindingReconciler struct {
 	Scheme *runtime.Scheme
 	dynamic.Interface
 	informers map[string]informer
+	audit     *audit.Trail
+	// secretDigests maps the service bindings to the digest of their secret
+	secretDigests map[string]string
+	// mu guards informers and secretDigests, as service bindings may be
+	// reconciled concurrently
+	mu sync.Mutex
+	// events triggers the reconciliation of the service bindings whose
+	// selected applications changed
+	events chan event.GenericEvent
 }
 
 type informer struct {
@@ -72,6 +85,31 @@ func (i *informer) run() {
 	i.informer.Run(i.ctx.Done())
 }
 
+// hasInformer returns whether an informer runs for the named service binding
+func (r *ServiceBindingReconciler) hasInformer(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.informers[name]
+	return ok
+}
+
+// setInformer records the informer of the named service binding
+func (r *ServiceBindingReconciler) setInformer(name string, i informer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.informers[name] = i
+}
+
+// stopInformer stops the informer of the named service binding, if any
+func (r *ServiceBindingReconciler) stopInformer(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i, ok := r.informers[name]; ok {
+		i.cancelFunc()
+		delete(r.informers, name)
+	}
+}
+
 // ServiceBindingRoot points to the environment variable in the container
 // which is used as the volume mount path.  In the absence of this
 // environment variable, `/bindings` is used as the volume mount path.
@@ -83,10 +121,13 @@ const (
 
 func NewServiceBindingReconciler(mgr ctrl.Manager) *ServiceBindingReconciler {
 	return &ServiceBindingReconciler{
-		Client:    mgr.GetClient(),
-		Scheme:    mgr.GetScheme(),
-		Interface: dynamic.NewForConfigOrDie(mgr.GetConfig()),
-		informers: make(map[string]informer, 0),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Interface:     dynamic.NewForConfigOrDie(mgr.GetConfig()),
+		informers:     make(map[string]informer, 0),
+		audit:         audit.NewTrail(mgr.GetEventRecorderFor(constants.ApplicationAgentDeploymentName), constants.ApplicationAgentDeploymentName),
+		secretDigests: map[string]string{},
+		events:        make(chan event.GenericEvent),
 	}
 }
 
@@ -117,18 +158,16 @@ func (r *ServiceBindingReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	}
 	l.Info("ServiceBinding object retrieved", "ServiceBinding", serviceBinding)
 
-	applications, err := r.getApplication(ctx, serviceBinding)
-	if err != nil {
-		// error retrieving the application(s), so setting the service binding status to false and reconcile
-		if errUpdateStatus := r.setStatus(ctx, serviceBinding, metav1.ConditionFalse, conditionGetAppsFailureReason, primazaiov1alpha1.ServiceBindingStateReady, err.Error(), primazaiov1alpha1.ServiceBindingBoundCondition); errUpdateStatus != nil {
-			return ctrl.Result{}, errUpdateStatus
-		}
-		return ctrl.Result{}, err
-	}
 	l.Info("Check If service binding is deleted")
 	if serviceBinding.HasDeletionTimestamp() {
 		if controllerutil.ContainsFinalizer(&serviceBinding, ServiceBindingFinalizer) {
-
+			// every bound application is unbound, whether it is still
+			// selected or not
+			applications, err := r.boundApplications(ctx, serviceBinding)
+			if err != nil {
+				l.Error(err, "unable to retrieve the bound applications")
+				return ctrl.Result{}, err
+			}
 			if err = r.finalizeServiceBinding(ctx, serviceBinding, applications); err != nil {
 				l.Error(err, "Error on unbinding applications on Service Binding Deletion")
 				return ctrl.Result{}, err
@@ -146,6 +185,26 @@ func (r *ServiceBindingReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, nil
 	}
 
+	if err := r.unbindStaleApplications(ctx, serviceBinding); err != nil {
+		l.Error(err, "unable to unbind the applications not selected any more")
+		return ctrl.Result{}, err
+	}
+	// applications are watched before being looked up, so that the ones
+	// created later are bound too
+	if err := r.SetWatchersForResources(ctx, serviceBinding); err != nil {
+		l.Error(err, "Failed to set watchers on ServiceBinding resources ", "namespace", req.Namespace, "name", req.Name)
+		return ctrl.Result{}, err
+	}
+
+	applications, err := r.getApplication(ctx, serviceBinding)
+	if err != nil {
+		// error retrieving the application(s), so setting the service binding status to false and reconcile
+		if errUpdateStatus := r.setStatus(ctx, serviceBinding, metav1.ConditionFalse, conditionGetAppsFailureReason, primazaiov1alpha1.ServiceBindingStateReady, err.Error(), primazaiov1alpha1.ServiceBindingBoundCondition); errUpdateStatus != nil {
+			return ctrl.Result{}, errUpdateStatus
+		}
+		return ctrl.Result{}, err
+	}
+
 	l.Info("Add Finalizer if needed")
 	// add finalizer if needed
 	if !controllerutil.ContainsFinalizer(&serviceBinding, ServiceBindingFinalizer) {
@@ -155,15 +214,11 @@ func (r *ServiceBindingReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		}
 	}
 
-	if err := r.SetWatchersForResources(ctx, serviceBinding); err != nil {
-		l.Error(err, "Failed to set watchers on ServiceBinding resources ", "namespace", req.Namespace, "name", req.Name)
-		return ctrl.Result{}, err
-	}
-
 	var psSecret *v1.Secret
 	if psSecret, err = r.GetSecret(ctx, serviceBinding, applications); err != nil {
 		return ctrl.Result{}, err
 	}
+	r.recordSecretRotation(serviceBinding, psSecret)
 	if err = r.PrepareBinding(ctx, serviceBinding, applications, psSecret); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -176,10 +231,11 @@ func (r *ServiceBindingReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 
 func (r *ServiceBindingReconciler) finalizeServiceBinding(ctx context.Context, serviceBinding v1alpha1.ServiceBinding, applications []unstructured.Unstructured) error {
 	// need to stop the informers if the service class is deleted
-	if i, ok := r.informers[serviceBinding.Name]; ok {
-		i.cancelFunc()
-		delete(r.informers, serviceBinding.Name)
-	}
+	r.stopInformer(serviceBinding.Name)
+	r.mu.Lock()
+	delete(r.secretDigests, serviceBinding.Namespace+"/"+serviceBinding.Name)
+	r.mu.Unlock()
+	forgetServiceBinding(serviceBinding)
 	err := r.unbindApplications(ctx, serviceBinding, applications...)
 	if err != nil {
 		return err
@@ -200,6 +256,7 @@ func (r *ServiceBindingReconciler) GetSecret(ctx context.Context, serviceBinding
 		if err != nil {
 			return nil, err
 		}
+		recordBoundWorkloads(serviceBinding, nil, 0)
 		return nil, secErr
 	}
 	return psSecret, nil
@@ -210,16 +267,16 @@ func (r *ServiceBindingReconciler) PrepareBinding(ctx context.Context, serviceBi
 
 	volumeName := serviceBinding.Name
 	mountPathDir := serviceBinding.Name
-	sp := &v1.SecretProjection{
-		LocalObjectReference: v1.LocalObjectReference{
-			Name: serviceBinding.Spec.ServiceEndpointDefinitionSecret,
-		}}
+	sources := secretProjection(serviceBinding, psSecret)
+	if len(sources) == 0 {
+		l.Info("none of the projected keys is in the secret, no key is projected", "projected keys", serviceBinding.Spec.ProjectedKeys)
+	}
 
 	volumeProjection := &v1.Volume{
 		Name: volumeName,
 		VolumeSource: v1.VolumeSource{
 			Projected: &v1.ProjectedVolumeSource{
-				Sources: []v1.VolumeProjection{{Secret: sp}},
+				Sources: sources,
 			},
 		},
 	}
@@ -237,18 +294,51 @@ func (r *ServiceBindingReconciler) PrepareBinding(ctx context.Context, serviceBi
 	return nil
 }
 
+// secretProjection returns the sources projecting the binding secret's keys
+// restricted by the service binding's projected keys.  Keys missing from the
+// secret are skipped, as projecting them would prevent the application's pods
+// from starting.  No source is returned when none of the projected keys is in
+// the secret, as a secret projection without items projects every key.
+func secretProjection(sb primazaiov1alpha1.ServiceBinding, secret *v1.Secret) []v1.VolumeProjection {
+	sp := &v1.SecretProjection{
+		LocalObjectReference: v1.LocalObjectReference{
+			Name: sb.Spec.ServiceEndpointDefinitionSecret,
+		}}
+	for _, k := range sb.Spec.ProjectedKeys {
+		if _, ok := secret.Data[k]; ok {
+			sp.Items = append(sp.Items, v1.KeyToPath{Key: k, Path: k})
+		}
+	}
+	if len(sb.Spec.ProjectedKeys) > 0 && len(sp.Items) == 0 {
+		return []v1.VolumeProjection{}
+	}
+	return []v1.VolumeProjection{{Secret: sp}}
+}
+
+// secretEnvVar returns the environment variable taking its value from the
+// given key of the binding secret, so that the value is neither copied into
+// the application's spec nor stale once the secret is rotated.  Missing keys
+// leave the variable unset rather than preventing the pods from starting.
+func secretEnvVar(sb primazaiov1alpha1.ServiceBinding, e primazaiov1alpha1.Environment) v1.EnvVar {
+	optional := true
+	return v1.EnvVar{
+		Name: e.Name,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{Name: sb.Spec.ServiceEndpointDefinitionSecret},
+				Key:                  e.Key,
+				Optional:             &optional,
+			},
+		},
+	}
+}
+
 func (r *ServiceBindingReconciler) prepareContainerWithMounts(ctx context.Context,
 	sb primazaiov1alpha1.ServiceBinding, psSecret *v1.Secret, mountPathDir, volumeName string, unstructuredVolume map[string]interface{}, application unstructured.Unstructured) error {
 	l := log.FromContext(ctx)
 	l.Info("Prepare application mounting")
 
-	containersPaths := [][]string{}
-	volumesPath := []string{"spec", "template", "spec", "volumes"}
-
-	containersPaths = append(containersPaths,
-		[]string{"spec", "template", "spec", "containers"},
-		[]string{"spec", "template", "spec", "initContainers"},
-	)
+	volumesPath := podSpecField(&application, "volumes")
 	l.Info("referencing the volume in an unstructured object")
 	volumes, found, err := unstructured.NestedSlice(application.Object, volumesPath...)
 	if err != nil {
@@ -280,7 +370,7 @@ func (r *ServiceBindingReconciler) prepareContainerWithMounts(ctx context.Contex
 	}
 	l.Info("application object after setting the update volume", "Application", application)
 
-	for _, containersPath := range containersPaths {
+	for _, containersPath := range containersPaths(&application) {
 		l.Info("referencing containers in an unstructured object")
 		containers, found, err := unstructured.NestedSlice(application.Object, containersPath...)
 		if err != nil {
@@ -304,11 +394,17 @@ func (r *ServiceBindingReconciler) prepareContainerWithMounts(ctx context.Contex
 		l.Info("application object after setting the updated containers", "Application", application)
 	}
 
+	if err := setVaultAnnotations(&application, sb); err != nil {
+		l.Error(err, "unable to annotate the application for vault agent injection")
+		return err
+	}
+
 	l.Info("updating the application with updated volumes and volumeMounts")
 	if err := r.Update(ctx, &application); err != nil {
 		l.Error(err, "unable to update the application", "application", application)
 		return err
 	}
+	r.audit.Record(&sb, audit.ActionBind, application.GetKind(), &application)
 	return nil
 }
 
@@ -327,17 +423,25 @@ func (r *ServiceBindingReconciler) bindApplications(ctx context.Context,
 	l.Info("set the status of the service binding")
 	if len(el) != 0 {
 		cerr := errors.Join(el...)
+		recordBoundWorkloads(sb, psSecret, len(applications)-len(el))
 		err := r.setStatus(ctx, sb, metav1.ConditionFalse, conditionBindingFailure, primazaiov1alpha1.ServiceBindingStateMalformed, cerr.Error(), primazaiov1alpha1.ServiceBindingNotBoundCondition)
 		if err != nil {
 			return err
 		}
 		return cerr
 	}
-	err := r.setStatus(ctx, sb, metav1.ConditionTrue, conditionBindingSuccessful, primazaiov1alpha1.ServiceBindingStateReady, "", primazaiov1alpha1.ServiceBindingBoundCondition)
+	recordBoundWorkloads(sb, psSecret, len(applications))
+	// an unreachable service endpoint is retried with back-off
+	state := primazaiov1alpha1.ServiceBindingStateReady
+	rerr := checkReachability(ctx, &sb, psSecret)
+	if rerr != nil {
+		state = primazaiov1alpha1.ServiceBindingStateMalformed
+	}
+	err := r.setStatus(ctx, sb, metav1.ConditionTrue, conditionBindingSuccessful, state, "", primazaiov1alpha1.ServiceBindingBoundCondition)
 	if err != nil {
 		return err
 	}
-	return nil
+	return rerr
 }
 
 func (r *ServiceBindingReconciler) setStatus(ctx context.Context,
@@ -352,6 +456,7 @@ func (r *ServiceBindingReconciler) setStatus(ctx context.Context,
 	}
 	meta.SetStatusCondition(&sb.Status.Conditions, c)
 	sb.Status.State = state
+	sb.UpdateReadiness()
 
 	l.Info("updating the service binding status")
 	if err := r.Status().Update(ctx, &sb); err != nil {
@@ -398,9 +503,14 @@ func (r *ServiceBindingReconciler) getApplication(ctx context.Context,
 			},
 		}
 
+		selector, err := applicationSelector(sb)
+		if err != nil {
+			l.Error(err, "invalid Application selector")
+			return []unstructured.Unstructured{}, err
+		}
 		l.Info("retrieving the application objects", "Application", applicationList)
 		opts := &client.ListOptions{
-			LabelSelector: labels.Set(sb.Spec.Application.Selector.MatchLabels).AsSelector(),
+			LabelSelector: selector,
 			Namespace:     sb.Namespace,
 		}
 
@@ -412,9 +522,8 @@ func (r *ServiceBindingReconciler) getApplication(ctx context.Context,
 		applications = append(applications, applicationList.Items...)
 	}
 	if len(applications) == 0 {
-		// Requeue with a time interval is required as the applications is not available to reconcile
-		// In future, probably watching for applications os specific types (Deployment, CronJob etc.) based
-		// on label can be introduced or a webhook can detect application change and trigger reconciliation
+		// the service binding is reconciled again as soon as a selected
+		// application is created, see enqueueServiceBinding
 		return nil, fmt.Errorf("applications not found")
 	}
 	return applications, nil
@@ -433,11 +542,7 @@ func (r *ServiceBindingReconciler) updateContainerInfo(ctx context.Context, cont
 		}
 
 		for _, e := range sb.Spec.Env {
-			c.Env = append(c.Env, v1.EnvVar{
-				Name:  e.Name,
-				Value: string(psSecret.Data[e.Key]),
-			})
-
+			setEnvVar(c, secretEnvVar(sb, e))
 		}
 		mountPath := ""
 		for _, e := range c.Env {
@@ -485,6 +590,29 @@ func (r *ServiceBindingReconciler) updateContainerInfo(ctx context.Context, cont
 
 }
 
+// setEnvVar adds the environment variable to the container, replacing the
+// one with the same name if any
+func setEnvVar(c *v1.Container, env v1.EnvVar) {
+	for i := range c.Env {
+		if c.Env[i].Name == env.Name {
+			c.Env[i] = env
+			return
+		}
+	}
+	c.Env = append(c.Env, env)
+}
+
+// bindsEnvVar reports whether the service binding maps a key of its secret
+// to the environment variable with the given name
+func bindsEnvVar(sb primazaiov1alpha1.ServiceBinding, name string) bool {
+	for _, e := range sb.Spec.Env {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *ServiceBindingReconciler) removeVolumeMountFromContainer(ctx context.Context, sb primazaiov1alpha1.ServiceBinding, containers []interface{}, volumeName, mountPathDir, secretName string) error {
 	l := log.FromContext(ctx)
 	for i := range containers {
@@ -501,11 +629,13 @@ func (r *ServiceBindingReconciler) removeVolumeMountFromContainer(ctx context.Co
 				c.VolumeMounts = append(c.VolumeMounts[:i], c.VolumeMounts[i+1:]...)
 			}
 		}
-		for i, env := range c.Env {
-			if env.Name == ServiceBindingRoot {
-				c.Env = append(c.Env[:i], c.Env[(i+1):]...)
+		var env []v1.EnvVar
+		for _, e := range c.Env {
+			if e.Name != ServiceBindingRoot && !bindsEnvVar(sb, e.Name) {
+				env = append(env, e)
 			}
 		}
+		c.Env = env
 
 		nu, err := runtime.DefaultUnstructuredConverter.ToUnstructured(c)
 		if err != nil {
@@ -521,13 +651,7 @@ func (r *ServiceBindingReconciler) removeVolumeMount(ctx context.Context, sb pri
 	l := log.FromContext(ctx)
 	l.Info("Prepare removing application mounting")
 
-	containersPaths := [][]string{}
-	volumesPath := []string{"spec", "template", "spec", "volumes"}
-
-	containersPaths = append(containersPaths,
-		[]string{"spec", "template", "spec", "containers"},
-		[]string{"spec", "template", "spec", "initContainers"},
-	)
+	volumesPath := podSpecField(&application, "volumes")
 	l.Info("referencing the volume in an unstructured object")
 	volumes, found, err := unstructured.NestedSlice(application.Object, volumesPath...)
 	if err != nil {
@@ -552,7 +676,7 @@ func (r *ServiceBindingReconciler) removeVolumeMount(ctx context.Context, sb pri
 	}
 	l.Info("application object after setting the update volume", "Application", application)
 
-	for _, containersPath := range containersPaths {
+	for _, containersPath := range containersPaths(&application) {
 		l.Info("referencing containers in an unstructured object")
 		containers, found, err := unstructured.NestedSlice(application.Object, containersPath...)
 		if err != nil {
@@ -576,11 +700,17 @@ func (r *ServiceBindingReconciler) removeVolumeMount(ctx context.Context, sb pri
 		l.Info("application object after setting the updated containers", "Application", application)
 	}
 
+	if err := removeVaultAnnotations(&application, sb); err != nil {
+		l.Error(err, "unable to remove the vault agent injection annotations from the application")
+		return err
+	}
+
 	l.Info("updating the application with updated volumes and volumeMounts")
 	if err := r.Update(ctx, &application); err != nil {
 		l.Error(err, "unable to update the application", "application", application)
 		return err
 	}
+	r.audit.Record(&sb, audit.ActionUnbind, application.GetKind(), &application)
 	return nil
 }
 
@@ -627,7 +757,7 @@ func (r *ServiceBindingReconciler) RunInformer(ctx context.Context, resource sch
 	l := log.FromContext(ctx)
 
 	// check if informer already exists
-	if _, ok := r.informers[serviceBinding.GetName()]; ok {
+	if r.hasInformer(serviceBinding.GetName()) {
 		l.Info("Informer already exists")
 		return nil
 	}
@@ -646,76 +776,8 @@ func (r *ServiceBindingReconciler) RunInformer(ctx context.Context, resource sch
 
 	var synced atomic.Bool
 	synced.Store(false)
-	if _, err := i.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: func(obj interface{}) {
-			if !synced.Load() {
-				return
-			}
-			var psSecret *v1.Secret
-			var applicationResourceList []unstructured.Unstructured
-			applicationResource := obj.(*unstructured.Unstructured)
-			if applicationResource.GetName() == constants.ApplicationAgentDeploymentName {
-				return
-			}
-			applicationResourceList = append(applicationResourceList, *applicationResource)
-			l.Info(fmt.Sprintf("application resource %v", applicationResourceList))
-			if psSecret, err = r.GetSecret(ctx, serviceBinding, applicationResourceList); err != nil {
-				l.Error(err, "Informer AddEventHandler: Error retrieving secret")
-				return
-			}
-			if err := r.PrepareBinding(ctx, serviceBinding, applicationResourceList, psSecret); err != nil {
-				l.Error(err, "Informer AddEventHandler: Error preparing binding")
-				return
-			}
-		},
-		UpdateFunc: func(past, future interface{}) {
-			if !synced.Load() {
-				return
-			}
-			var applicationResourceList []unstructured.Unstructured
-			applicationResource := future.(*unstructured.Unstructured)
-			if applicationResource.GetName() == constants.ApplicationAgentDeploymentName {
-				return
-			}
-			applicationResourceList = append(applicationResourceList, *applicationResource)
-			l.Info(fmt.Sprintf("application resource %v", applicationResourceList))
-			var psSecret *v1.Secret
-			if psSecret, err = r.GetSecret(ctx, serviceBinding, applicationResourceList); err != nil {
-				l.Error(err, "Informer AddEventHandler: Error retrieving secret")
-				return
-			}
-			if err := r.PrepareBinding(ctx, serviceBinding, applicationResourceList, psSecret); err != nil {
-				l.Error(err, "Informer AddEventHandler: Error preparing binding")
-				return
-			}
-		},
-		DeleteFunc: func(obj interface{}) {
-			if !synced.Load() {
-				return
-			}
-			applicationResource := obj.(*unstructured.Unstructured)
-			if applicationResource.GetName() == constants.ApplicationAgentDeploymentName {
-				return
-			}
-			applications, _ := r.getApplication(ctx, serviceBinding)
-			if len(applications) == 0 {
-				var sb primazaiov1alpha1.ServiceBinding
-				if err := r.Get(ctx, types.NamespacedName{Name: serviceBinding.Name, Namespace: serviceBinding.Namespace}, &sb); err == nil {
-					// applications are deleted, so setting the service binding status to false and reconcile
-					if errUpdateStatus := r.setStatus(ctx,
-						sb,
-						metav1.ConditionFalse,
-						conditionGetAppsFailureReason,
-						primazaiov1alpha1.ServiceBindingStateReady,
-						"application was bound with the secret but the application got deleted",
-						primazaiov1alpha1.ServiceBindingBoundCondition); errUpdateStatus != nil {
-						l.Error(errUpdateStatus, "error on updating status")
-						return
-					}
-				}
-			}
-		},
-	}); err != nil {
+	key := types.NamespacedName{Name: serviceBinding.Name, Namespace: serviceBinding.Namespace}
+	if _, err := i.AddEventHandler(r.enqueueServiceBinding(ctx, key, synced.Load)); err != nil {
 		return err
 	}
 
@@ -723,12 +785,11 @@ func (r *ServiceBindingReconciler) RunInformer(ctx context.Context, resource sch
 	c, fc := context.WithCancel(ctx)
 
 	li := informer{informer: i, ctx: c, cancelFunc: fc}
-	r.informers[serviceBinding.GetName()] = li
+	r.setInformer(serviceBinding.GetName(), li)
 	go li.run()
 
 	if !cache.WaitForCacheSync(ctx.Done(), i.HasSynced) {
-		fc()
-		delete(r.informers, serviceBinding.GetName())
+		r.stopInformer(serviceBinding.GetName())
 		return fmt.Errorf("could not sync cache")
 	}
 
@@ -742,5 +803,6 @@ func (r *ServiceBindingReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&primazaiov1alpha1.ServiceBinding{}).
 		Owns(&v1.Secret{}).
+		Watches(&source.Channel{Source: r.events}, &handler.EnqueueRequestForObject{}).
 		Complete(r)
 }
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+// applicationSelector returns the label selector of the applications the
+// service binding selects, or nil if it selects an application by name
+func applicationSelector(sb primazaiov1alpha1.ServiceBinding) (labels.Selector, error) {
+	if sb.Spec.Application.Selector == nil {
+		return nil, nil
+	}
+	return metav1.LabelSelectorAsSelector(sb.Spec.Application.Selector)
+}
+
+// selectsApplication reports whether the application is selected by the
+// service binding, either by name or by labels
+func selectsApplication(sb primazaiov1alpha1.ServiceBinding, application *unstructured.Unstructured) bool {
+	if sb.Spec.Application.Name != "" && sb.Spec.Application.Name == application.GetName() {
+		return true
+	}
+	selector, err := applicationSelector(sb)
+	if err != nil || selector == nil {
+		return false
+	}
+	return selector.Matches(labels.Set(application.GetLabels()))
+}
+
+// isBoundBy reports whether the binding secret of the service binding is
+// projected into the application
+func isBoundBy(sb primazaiov1alpha1.ServiceBinding, application *unstructured.Unstructured) bool {
+	volumes, _, _ := unstructured.NestedSlice(application.Object, podSpecField(application, "volumes")...)
+	for _, v := range volumes {
+		if volume, ok := v.(map[string]interface{}); ok && volume["name"] == sb.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// boundApplications returns the applications the binding secret of the
+// service binding is projected into
+func (r *ServiceBindingReconciler) boundApplications(ctx context.Context, sb primazaiov1alpha1.ServiceBinding) ([]unstructured.Unstructured, error) {
+	applications := &unstructured.UnstructuredList{}
+	applications.SetAPIVersion(sb.Spec.Application.APIVersion)
+	applications.SetKind(sb.Spec.Application.Kind + "List")
+	if err := r.List(ctx, applications, client.InNamespace(sb.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var bound []unstructured.Unstructured
+	for i := range applications.Items {
+		if isBoundBy(sb, &applications.Items[i]) {
+			bound = append(bound, applications.Items[i])
+		}
+	}
+	return bound, nil
+}
+
+// unbindStaleApplications removes the projection of the binding secret from
+// the applications the service binding does not select any more, e.g.
+// because their labels changed
+func (r *ServiceBindingReconciler) unbindStaleApplications(ctx context.Context, sb primazaiov1alpha1.ServiceBinding) error {
+	bound, err := r.boundApplications(ctx, sb)
+	if err != nil {
+		return err
+	}
+
+	var stale []unstructured.Unstructured
+	for i := range bound {
+		if !selectsApplication(sb, &bound[i]) {
+			log.FromContext(ctx).Info("unbinding application not selected any more", "application", bound[i].GetName())
+			stale = append(stale, bound[i])
+		}
+	}
+	return r.unbindApplications(ctx, sb, stale...)
+}
+
+// enqueueServiceBinding returns an informer event handler that triggers the
+// reconciliation of the service binding whenever an application it selects,
+// or it is bound to, changes, so that applications are bound as soon as
+// they are created or get selected, and unbound as soon as they are not
+// selected any more
+func (r *ServiceBindingReconciler) enqueueServiceBinding(ctx context.Context, key types.NamespacedName, synced func() bool) cache.ResourceEventHandler {
+	concerns := func(obj interface{}) bool {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		application, ok := obj.(*unstructured.Unstructured)
+		if !ok || application.GetName() == constants.ApplicationAgentDeploymentName {
+			return false
+		}
+
+		var sb primazaiov1alpha1.ServiceBinding
+		if err := r.Get(ctx, key, &sb); err != nil {
+			return false
+		}
+		return selectsApplication(sb, application) || isBoundBy(sb, application)
+	}
+	enqueue := func() {
+		sb := &primazaiov1alpha1.ServiceBinding{}
+		sb.SetName(key.Name)
+		sb.SetNamespace(key.Namespace)
+		r.events <- event.GenericEvent{Object: sb}
+	}
+
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if synced() && concerns(obj) {
+				enqueue()
+			}
+		},
+		UpdateFunc: func(past, future interface{}) {
+			if synced() && (concerns(past) || concerns(future)) {
+				enqueue()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if synced() && concerns(obj) {
+				enqueue()
+			}
+		},
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+// newDeployment returns a Deployment with the given labels, whose pod spec
+// projects the binding secret of the named service binding if bound is not
+// empty
+func newDeployment(name string, labels map[string]string, bound string) *unstructured.Unstructured {
+	podSpec := map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{"name": "app", "image": "app:latest"}},
+	}
+	if bound != "" {
+		podSpec["volumes"] = []interface{}{map[string]interface{}{"name": bound, "secret": map[string]interface{}{"secretName": bound}}}
+		podSpec["containers"] = []interface{}{map[string]interface{}{
+			"name":         "app",
+			"image":        "app:latest",
+			"volumeMounts": []interface{}{map[string]interface{}{"name": bound, "mountPath": "/bindings/" + bound}},
+		}}
+	}
+	d := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"template": map[string]interface{}{"spec": podSpec}},
+	}}
+	d.SetAPIVersion("apps/v1")
+	d.SetKind("Deployment")
+	d.SetName(name)
+	d.SetNamespace("apps")
+	d.SetLabels(labels)
+	return d
+}
+
+func newServiceBinding(name string, application primazaiov1alpha1.ApplicationSelector) primazaiov1alpha1.ServiceBinding {
+	application.APIVersion = "apps/v1"
+	application.Kind = "Deployment"
+	return primazaiov1alpha1.ServiceBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "apps"},
+		Spec: primazaiov1alpha1.ServiceBindingSpec{
+			ServiceEndpointDefinitionSecret: name,
+			Application:                     application,
+		},
+	}
+}
+
+func TestSelectsApplication(t *testing.T) {
+	app := newDeployment("orders", map[string]string{"app": "orders", "tier": "backend"}, "")
+	selector := func(s metav1.LabelSelector) primazaiov1alpha1.ApplicationSelector {
+		return primazaiov1alpha1.ApplicationSelector{Selector: &s}
+	}
+	expression := func(key string, op metav1.LabelSelectorOperator, values ...string) metav1.LabelSelector {
+		return metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: key, Operator: op, Values: values}}}
+	}
+
+	tests := []struct {
+		name        string
+		application primazaiov1alpha1.ApplicationSelector
+		want        bool
+	}{
+		{name: "by name", application: primazaiov1alpha1.ApplicationSelector{Name: "orders"}, want: true},
+		{name: "by another name", application: primazaiov1alpha1.ApplicationSelector{Name: "billing"}},
+		{name: "matchLabels", application: selector(metav1.LabelSelector{MatchLabels: map[string]string{"app": "orders"}}), want: true},
+		{name: "matchLabels not matching", application: selector(metav1.LabelSelector{MatchLabels: map[string]string{"app": "billing"}})},
+		{name: "matchExpressions In", application: selector(expression("tier", metav1.LabelSelectorOpIn, "frontend", "backend")), want: true},
+		{name: "matchExpressions In not matching", application: selector(expression("tier", metav1.LabelSelectorOpIn, "frontend"))},
+		{name: "matchExpressions NotIn", application: selector(expression("tier", metav1.LabelSelectorOpNotIn, "frontend")), want: true},
+		{name: "matchExpressions NotIn not matching", application: selector(expression("tier", metav1.LabelSelectorOpNotIn, "backend"))},
+		{name: "matchExpressions Exists", application: selector(expression("tier", metav1.LabelSelectorOpExists)), want: true},
+		{name: "matchExpressions DoesNotExist", application: selector(expression("tier", metav1.LabelSelectorOpDoesNotExist))},
+		{
+			name: "matchLabels and matchExpressions",
+			application: selector(metav1.LabelSelector{
+				MatchLabels:      map[string]string{"app": "orders"},
+				MatchExpressions: expression("tier", metav1.LabelSelectorOpIn, "frontend").MatchExpressions,
+			}),
+		},
+		{name: "invalid selector", application: selector(expression("tier", metav1.LabelSelectorOpIn))},
+		{name: "neither name nor selector"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := newServiceBinding("db", tt.application)
+			if got := selectsApplication(sb, app); got != tt.want {
+				t.Errorf("selectsApplication() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBoundBy(t *testing.T) {
+	sb := newServiceBinding("db", primazaiov1alpha1.ApplicationSelector{Name: "orders"})
+	cronJob := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"jobTemplate": map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+			"volumes": []interface{}{map[string]interface{}{"name": "db"}},
+		}}}}},
+	}}
+	cronJob.SetKind("CronJob")
+
+	tests := []struct {
+		name        string
+		application *unstructured.Unstructured
+		want        bool
+	}{
+		{name: "bound", application: newDeployment("orders", nil, "db"), want: true},
+		{name: "bound by another binding", application: newDeployment("orders", nil, "cache")},
+		{name: "not bound", application: newDeployment("orders", nil, "")},
+		{name: "bound CronJob", application: cronJob, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBoundBy(sb, tt.application); got != tt.want {
+				t.Errorf("isBoundBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnbindStaleApplications(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newDeployment("orders", map[string]string{"tier": "backend"}, "db"),
+		// its labels changed since it was bound
+		newDeployment("billing", map[string]string{"tier": "frontend"}, "db"),
+		newDeployment("reports", map[string]string{"tier": "frontend"}, ""),
+	).Build()
+	r := &ServiceBindingReconciler{Client: cli, Scheme: scheme}
+	sb := newServiceBinding("db", primazaiov1alpha1.ApplicationSelector{Selector: &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"backend"}}},
+	}})
+
+	if err := r.unbindStaleApplications(context.Background(), sb); err != nil {
+		t.Fatal(err)
+	}
+
+	bound := map[string]bool{"orders": true, "billing": false, "reports": false}
+	for name, want := range bound {
+		app := &unstructured.Unstructured{}
+		app.SetAPIVersion("apps/v1")
+		app.SetKind("Deployment")
+		if err := cli.Get(context.Background(), types.NamespacedName{Namespace: "apps", Name: name}, app); err != nil {
+			t.Fatal(err)
+		}
+		if got := isBoundBy(sb, app); got != want {
+			t.Errorf("%s: expected bound to be %v, got %v", name, want, got)
+		}
+		mounts, _, _ := unstructured.NestedSlice(app.Object, "spec", "template", "spec", "containers")
+		mounted := len(mounts) == 1 && mounts[0].(map[string]interface{})["volumeMounts"] != nil
+		if mounted != want {
+			t.Errorf("%s: expected the volume to be mounted %v, got %v", name, want, mounted)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/controlplane"
+)
+
+var (
+	boundWorkloads = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "primaza_servicebinding_bound_workloads",
+			Help: "Number of workloads bound by a service binding, by service type and provider",
+		},
+		[]string{"namespace", "name", "type", "provider"},
+	)
+
+	bindingSecretRotations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "primaza_binding_secret_rotations_total",
+			Help: "Number of times the content of a binding secret changed, by service type and provider",
+		},
+		[]string{"type", "provider"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(boundWorkloads, bindingSecretRotations)
+}
+
+// serviceClass returns the service type and provider stored in the binding secret
+func serviceClass(secret *v1.Secret) (string, string) {
+	if secret == nil {
+		return "", ""
+	}
+	return string(secret.Data[controlplane.ServiceClassIdentityTypeKey]),
+		string(secret.Data[controlplane.ServiceClassIdentityProviderKey])
+}
+
+// recordBoundWorkloads sets the number of workloads bound by the given service binding
+func recordBoundWorkloads(sb primazaiov1alpha1.ServiceBinding, secret *v1.Secret, workloads int) {
+	forgetServiceBinding(sb)
+	t, p := serviceClass(secret)
+	boundWorkloads.WithLabelValues(sb.Namespace, sb.Name, t, p).Set(float64(workloads))
+}
+
+// forgetServiceBinding removes the metrics of the given service binding
+func forgetServiceBinding(sb primazaiov1alpha1.ServiceBinding) {
+	boundWorkloads.DeletePartialMatch(prometheus.Labels{"namespace": sb.Namespace, "name": sb.Name})
+}
+
+// recordSecretRotation counts a rotation if the content of the binding secret
+// changed since the last time it was observed for the given service binding.
+// The first observation is not a rotation.
+func (r *ServiceBindingReconciler) recordSecretRotation(sb primazaiov1alpha1.ServiceBinding, secret *v1.Secret) {
+	key := sb.Namespace + "/" + sb.Name
+	digest := secretDigest(secret)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if last, ok := r.secretDigests[key]; ok && last != digest {
+		bindingSecretRotations.WithLabelValues(serviceClass(secret)).Inc()
+	}
+	r.secretDigests[key] = digest
+}
+
+// secretDigest returns a digest of the secret's data
+func secretDigest(secret *v1.Secret) string {
+	keys := make([]string, 0, len(secret.Data))
+	for k := range secret.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write(secret.Data[k])
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/healthprobe"
+)
+
+const (
+	conditionEndpointReachable   = "EndpointReachable"
+	conditionEndpointUnreachable = "EndpointUnreachable"
+)
+
+// checkReachability verifies, if the service binding asks to, that the
+// service endpoint held by the binding secret can be reached from the
+// application namespace, and records the outcome in the Reachable condition.
+// As the agent runs in the application namespace, a failure usually points
+// to a DNS or network policy issue.
+func checkReachability(ctx context.Context, sb *primazaiov1alpha1.ServiceBinding, secret *v1.Secret) error {
+	rc := sb.Spec.ReachabilityCheck
+	if rc == nil {
+		meta.RemoveStatusCondition(&sb.Status.Conditions, primazaiov1alpha1.ServiceBindingReachableCondition)
+		return nil
+	}
+
+	err := dialServiceEndpoint(ctx, *rc, secret)
+	c := metav1.Condition{
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Type:               primazaiov1alpha1.ServiceBindingReachableCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             conditionEndpointReachable,
+	}
+	if err != nil {
+		log.FromContext(ctx).Info("Service endpoint is not reachable", "error", err.Error())
+		c.Status = metav1.ConditionFalse
+		c.Reason = conditionEndpointUnreachable
+		c.Message = err.Error()
+	}
+	meta.SetStatusCondition(&sb.Status.Conditions, c)
+	return err
+}
+
+func dialServiceEndpoint(ctx context.Context, rc primazaiov1alpha1.ReachabilityCheck, secret *v1.Secret) error {
+	hostKey, portKey := rc.Keys()
+	host, ok := secret.Data[hostKey]
+	if !ok {
+		return fmt.Errorf("binding secret has no key %q", hostKey)
+	}
+	port, ok := secret.Data[portKey]
+	if !ok {
+		return fmt.Errorf("binding secret has no key %q", portKey)
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, rc.RunTimeout())
+	defer cancel()
+	return healthprobe.TCPSocket(dialCtx, net.JoinHostPort(string(host), string(port)))
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+const (
+	vaultAnnotationPrefix      = "vault.hashicorp.com/"
+	vaultAgentInjectAnnotation = vaultAnnotationPrefix + "agent-inject"
+	vaultRoleAnnotation        = vaultAnnotationPrefix + "role"
+	vaultSecretAnnotation      = vaultAnnotationPrefix + "agent-inject-secret-"
+	vaultTemplateAnnotation    = vaultAnnotationPrefix + "agent-inject-template-"
+	vaultFileAnnotation        = vaultAnnotationPrefix + "agent-inject-file-"
+	vaultVolumePathAnnotation  = vaultAnnotationPrefix + "secret-volume-path-"
+
+	// vaultSecretsDir is the directory the Vault agent injector writes the
+	// keys of each service binding into, in a sub-directory named after the
+	// service binding
+	vaultSecretsDir = "/vault/secrets"
+)
+
+// vaultSecretAnnotations lists the prefixes of the annotations the Vault
+// agent injector reads for each injected secret
+var vaultSecretAnnotations = []string{
+	vaultSecretAnnotation,
+	vaultTemplateAnnotation,
+	vaultFileAnnotation,
+	vaultVolumePathAnnotation,
+}
+
+// setVaultAnnotations annotates the pod template of the application so that
+// the Vault agent injector writes each key of the service binding's Vault
+// secret into its own file
+func setVaultAnnotations(application *unstructured.Unstructured, sb primazaiov1alpha1.ServiceBinding) error {
+	v := sb.Spec.Vault
+	if v == nil {
+		return nil
+	}
+
+	annotationsPath := podTemplateMetadataField(application, "annotations")
+	annotations, _, err := unstructured.NestedStringMap(application.Object, annotationsPath...)
+	if err != nil {
+		return err
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[vaultAgentInjectAnnotation] = "true"
+	annotations[vaultRoleAnnotation] = v.Role
+	for _, k := range v.Keys {
+		name := vaultSecretName(sb, k)
+		annotations[vaultSecretAnnotation+name] = v.SecretPath
+		annotations[vaultTemplateAnnotation+name] = fmt.Sprintf(`{{- with secret %q -}}{{ index .Data.data %q }}{{- end }}`, v.SecretPath, k)
+		annotations[vaultFileAnnotation+name] = k
+		annotations[vaultVolumePathAnnotation+name] = path.Join(vaultSecretsDir, sb.Name)
+	}
+	return unstructured.SetNestedStringMap(application.Object, annotations, annotationsPath...)
+}
+
+// removeVaultAnnotations removes the annotations injecting the service
+// binding's Vault secret from the pod template of the application.  The
+// Vault agent injection is disabled once no other service binding uses it.
+func removeVaultAnnotations(application *unstructured.Unstructured, sb primazaiov1alpha1.ServiceBinding) error {
+	if sb.Spec.Vault == nil {
+		return nil
+	}
+
+	annotationsPath := podTemplateMetadataField(application, "annotations")
+	annotations, found, err := unstructured.NestedStringMap(application.Object, annotationsPath...)
+	if err != nil || !found {
+		return err
+	}
+
+	for _, k := range sb.Spec.Vault.Keys {
+		for _, p := range vaultSecretAnnotations {
+			delete(annotations, p+vaultSecretName(sb, k))
+		}
+	}
+	injected := false
+	for a := range annotations {
+		injected = injected || strings.HasPrefix(a, vaultSecretAnnotation)
+	}
+	if !injected {
+		delete(annotations, vaultAgentInjectAnnotation)
+		delete(annotations, vaultRoleAnnotation)
+	}
+	return unstructured.SetNestedStringMap(application.Object, annotations, annotationsPath...)
+}
+
+// vaultSecretName returns the name identifying the given key of the service
+// binding's Vault secret in the Vault agent injector annotations
+func vaultSecretName(sb primazaiov1alpha1.ServiceBinding, key string) string {
+	return fmt.Sprintf("%s-%s", sb.Name, key)
+}
@@ -26,6 +26,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/flowcontrol"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -33,7 +34,9 @@ import (
 
 	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
 	sccontrollers "github.com/primaza/primaza/controllers"
+	"github.com/primaza/primaza/pkg/primaza/backpressure"
 	"github.com/primaza/primaza/pkg/primaza/constants"
+	"github.com/primaza/primaza/pkg/primaza/remotewriter"
 	"github.com/primaza/primaza/pkg/primaza/workercluster"
 )
 
@@ -42,6 +45,10 @@ const ServiceClaimFinalizer = "serviceclaims.primaza.io/finalizer"
 // ServiceClaimReconciler reconciles a ServiceClaim object
 type ServiceClaimReconciler struct {
 	sccontrollers.ServiceClaimReconciler
+
+	// ControlPlaneLimiter throttles the writes to the control plane, nil
+	// disables throttling
+	ControlPlaneLimiter flowcontrol.RateLimiter
 }
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -72,6 +79,7 @@ func (r *ServiceClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	}
 	l.Info("remote cluster", "address", config.Host)
 
+	config.Wrap(remotewriter.Throttle(r.ControlPlaneLimiter, remotewriter.RecordThrottledWrite))
 	remote_client, err := client.New(config, client.Options{
 		Scheme: r.Client.Scheme(),
 		Mapper: r.Mapper,
@@ -80,23 +88,8 @@ func (r *ServiceClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, err
 	}
 
-	objKey := client.ObjectKey{
-		Name:      constants.ApplicationAgentDeploymentName,
-		Namespace: req.NamespacedName.Namespace,
-	}
-	var deployment appsv1.Deployment
-	err = r.Get(ctx, objKey, &deployment)
+	deployment, err := r.agentDeployment(ctx, req.Namespace)
 	if err != nil {
-		if apierrors.IsNotFound(err) {
-			// it should never happen that this controller does not find itself
-			// FIXME: the deployment's been deleted, and the pod
-			// we're running in is likely going to be deleted soon as well.  Do
-			// we have a cleaner way of triggering our own shutdown?
-			l.Error(err,
-				"application agent deployment not found, that should be a bug",
-				"expected deployment name", constants.ApplicationAgentDeploymentName)
-			os.Exit(1)
-		}
 		return ctrl.Result{}, err
 	}
 
@@ -133,12 +126,27 @@ func (r *ServiceClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, nil
 	}
 
+	// claims whose application does not exist any more are flagged or
+	// released according to their stale policy
+	released, recheck, err := r.checkStaleness(ctx, &sclaim)
+	if err != nil || released {
+		return ctrl.Result{}, err
+	}
+
+	// an overloaded control plane asks agents to slow down, requeueing
+	// coalesces the events received in the meantime
+	delay, err := backpressure.Delay(ctx, remote_client, remote_namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if delay > 0 {
+		l.Info("Control plane is overloaded, delaying service claim's write", "delay", delay)
+		return ctrl.Result{RequeueAfter: backpressure.Jitter(delay)}, nil
+	}
+
 	sclaimCopy := r.createServiceClaimCopy(sclaim, deployment, remote_namespace)
-	spec := sclaimCopy.Spec
-	op, err := controllerutil.CreateOrUpdate(ctx, remote_client, sclaimCopy, func() error {
-		sclaimCopy.Spec = spec
-		return nil
-	})
+	op, err := remotewriter.Apply(ctx, remote_client, constants.AgentFieldManager, sclaimCopy)
+	var reason string
 	if err != nil {
 		if strings.Contains(err.Error(), "admission webhook \"vserviceclaim.kb.io\" denied the request") {
 			c := metav1.Condition{
@@ -151,8 +159,10 @@ func (r *ServiceClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			meta.SetStatusCondition(&sclaim.Status.Conditions, c)
 
 			sclaimCopy.Status.State = primazaiov1alpha1.ServiceClaimStateInvalid
+			reason = constants.ValidationErrorReason
 		} else {
 			sclaimCopy.Status.State = primazaiov1alpha1.ServiceClaimStatePending
+			reason = constants.ServiceClaimPushFailedReason
 			l.Error(err, "Failed to create/update service claim",
 				"service", sclaim.Name,
 				"namespace", sclaim.Namespace)
@@ -160,27 +170,65 @@ func (r *ServiceClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	} else {
 		sclaimCopy.Status.State = primazaiov1alpha1.ServiceClaimStateResolved
+		reason = constants.ServiceClaimPushedReason
 		l.Info("Wrote service claim", "claim", sclaim.Name, "namespace", sclaim.Namespace, "operation", op)
 	}
 	sclaim.Status.ClaimID = sclaimCopy.Status.ClaimID
 	sclaim.Status.State = sclaimCopy.Status.State
+	sclaim.Status.Transitions = primazaiov1alpha1.RecordStateTransition(sclaim.Status.Transitions,
+		string(sclaim.Status.State), reason, constants.ApplicationAgentDeploymentName)
 	sclaim.Status.RegisteredService = sclaimCopy.Status.RegisteredService
+	sclaim.UpdateSummary()
 	if err := r.Status().Update(ctx, &sclaim); err != nil {
 		l.Error(err, "unable to update the ServiceClaim", "ServiceClaim", sclaim)
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: recheck}, nil
+}
+
+func (r *ServiceClaimReconciler) agentDeployment(ctx context.Context, namespace string) (appsv1.Deployment, error) {
+	objKey := client.ObjectKey{
+		Name:      constants.ApplicationAgentDeploymentName,
+		Namespace: namespace,
+	}
+	var deployment appsv1.Deployment
+	err := r.Get(ctx, objKey, &deployment)
+	if apierrors.IsNotFound(err) {
+		// it should never happen that this controller does not find itself
+		// FIXME: the deployment's been deleted, and the pod
+		// we're running in is likely going to be deleted soon as well.  Do
+		// we have a cleaner way of triggering our own shutdown?
+		log.FromContext(ctx).Error(err,
+			"application agent deployment not found, that should be a bug",
+			"expected deployment name", constants.ApplicationAgentDeploymentName)
+		os.Exit(1)
+	}
+	return deployment, err
 }
 
+// createServiceClaimCopy returns the copy of the service claim applied to the
+// control plane.  Only the claim's labels and spec are copied, so that the
+// local object's metadata, e.g. its uid, is never applied, while its status is
+// kept until it is read back from the control plane.
 func (r *ServiceClaimReconciler) createServiceClaimCopy(sclaim primazaiov1alpha1.ServiceClaim, deployment appsv1.Deployment, remote_namespace string) *primazaiov1alpha1.ServiceClaim {
-	sclaimCopy := sclaim.DeepCopy()
+	sclaimCopy := &primazaiov1alpha1.ServiceClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sclaim.Name,
+			Namespace: remote_namespace,
+			Labels:    sclaim.Labels,
+		},
+		Spec:   *sclaim.Spec.DeepCopy(),
+		Status: *sclaim.Status.DeepCopy(),
+	}
 	sclaimCopy.Spec.EnvironmentTag = ""
 	sclaimCopy.Spec.ApplicationClusterContext = &primazaiov1alpha1.ServiceClaimApplicationClusterContext{}
+	if acc := sclaim.Spec.ApplicationClusterContext; acc != nil {
+		// the application may span other namespaces of the cluster environment
+		sclaimCopy.Spec.ApplicationClusterContext.AdditionalNamespaces = acc.AdditionalNamespaces
+	}
 	sclaimCopy.Spec.ApplicationClusterContext.ClusterEnvironmentName = deployment.Labels["primaza.io/cluster-environment"]
 	sclaimCopy.Spec.ApplicationClusterContext.Namespace = sclaim.Namespace
-	sclaimCopy.Namespace = remote_namespace
-	sclaimCopy.ResourceVersion = ""
 	return sclaimCopy
 }
 
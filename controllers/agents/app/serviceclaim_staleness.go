@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+// checkStaleness verifies that the application selected by the claim still
+// exists.  Once the application has been missing for longer than the claim's
+// grace period, the claim is flagged with the Stale condition or released,
+// depending on its stale policy.  It returns whether the claim has been
+// released and how long to wait before checking the application again.
+func (r *ServiceClaimReconciler) checkStaleness(ctx context.Context, sclaim *primazaiov1alpha1.ServiceClaim) (bool, time.Duration, error) {
+	l := log.FromContext(ctx)
+
+	policy := sclaim.Spec.StalePolicy
+	if policy == "" || policy == primazaiov1alpha1.ServiceClaimStalePolicyIgnore {
+		meta.RemoveStatusCondition(&sclaim.Status.Conditions, primazaiov1alpha1.ServiceClaimConditionStale)
+		return false, 0, nil
+	}
+
+	// applications are not watched, so they are periodically looked up
+	gracePeriod := sclaim.Spec.StaleAfter()
+	found, err := r.applicationExists(ctx, *sclaim)
+	if err != nil {
+		return false, 0, err
+	}
+	if found {
+		meta.RemoveStatusCondition(&sclaim.Status.Conditions, primazaiov1alpha1.ServiceClaimConditionStale)
+		return false, gracePeriod, nil
+	}
+
+	stale := meta.FindStatusCondition(sclaim.Status.Conditions, primazaiov1alpha1.ServiceClaimConditionStale)
+	if stale == nil {
+		l.Info("Application of the service claim not found", "application", sclaim.Spec.Application, "grace period", gracePeriod)
+		meta.SetStatusCondition(&sclaim.Status.Conditions, metav1.Condition{
+			LastTransitionTime: metav1.NewTime(time.Now()),
+			Type:               primazaiov1alpha1.ServiceClaimConditionStale,
+			Status:             metav1.ConditionUnknown,
+			Reason:             constants.ApplicationNotFoundReason,
+			Message:            fmt.Sprintf("application not found, the claim will be considered stale in %s", gracePeriod),
+		})
+		return false, gracePeriod, nil
+	}
+
+	if missing := time.Since(stale.LastTransitionTime.Time); stale.Status != metav1.ConditionTrue && missing < gracePeriod {
+		return false, gracePeriod - missing, nil
+	}
+
+	if policy == primazaiov1alpha1.ServiceClaimStalePolicyRelease {
+		// the finalizer deletes the claim's copy from the control plane,
+		// which in turn releases the claimed registered service
+		l.Info("Releasing stale service claim", "application", sclaim.Spec.Application)
+		if err := r.Delete(ctx, sclaim); err != nil && !apierrors.IsNotFound(err) {
+			return false, 0, err
+		}
+		return true, 0, nil
+	}
+
+	if stale.Status != metav1.ConditionTrue {
+		l.Info("Flagging stale service claim", "application", sclaim.Spec.Application)
+		sclaim.Status.Transitions = primazaiov1alpha1.RecordStateTransition(sclaim.Status.Transitions,
+			string(sclaim.Status.State), constants.ServiceClaimStaleReason, constants.ApplicationAgentDeploymentName)
+	}
+	meta.SetStatusCondition(&sclaim.Status.Conditions, metav1.Condition{
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Type:               primazaiov1alpha1.ServiceClaimConditionStale,
+		Status:             metav1.ConditionTrue,
+		Reason:             constants.ApplicationNotFoundReason,
+		Message:            "application not found",
+	})
+	return false, gracePeriod, nil
+}
+
+// applicationExists looks up in the claim's namespace the application
+// selected by the claim
+func (r *ServiceClaimReconciler) applicationExists(ctx context.Context, sclaim primazaiov1alpha1.ServiceClaim) (bool, error) {
+	app := sclaim.Spec.Application
+	if app.Kind == "" || (app.Name == "" && app.Selector == nil) {
+		// the claim does not select any application that could go missing
+		return true, nil
+	}
+	if app.Name != "" {
+		application := &unstructured.Unstructured{}
+		application.SetAPIVersion(app.APIVersion)
+		application.SetKind(app.Kind)
+		err := r.Get(ctx, client.ObjectKey{Namespace: sclaim.Namespace, Name: app.Name}, application)
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(app.Selector)
+	if err != nil {
+		return false, err
+	}
+	applications := &unstructured.UnstructuredList{}
+	applications.SetAPIVersion(app.APIVersion)
+	applications.SetKind(app.Kind + "List")
+	err = r.List(ctx, applications, client.InNamespace(sclaim.Namespace), client.MatchingLabelsSelector{Selector: selector})
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	return len(applications.Items) > 0, err
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/primaza/primaza/api/v1alpha1"
 	"github.com/primaza/primaza/pkg/primaza/constants"
+	"github.com/primaza/primaza/pkg/version"
 	appsv1 "k8s.io/api/apps/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -91,9 +92,11 @@ func (r *AgentServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request
 				return ctrl.Result{}, err
 			}
 		}
-	} else if !controllerutil.ContainsFinalizer(&agentsvcdeployment, agentsvcfinalizer) {
-		// add a finalizer since we have deletion logic
-		if controllerutil.AddFinalizer(&agentsvcdeployment, agentsvcfinalizer) {
+	} else {
+		// add a finalizer since we have deletion logic, and report our
+		// version to the control plane
+		f := controllerutil.AddFinalizer(&agentsvcdeployment, agentsvcfinalizer)
+		if version.Annotate(&agentsvcdeployment) || f {
 			if err = r.Update(ctx, &agentsvcdeployment, &client.UpdateOptions{}); err != nil {
 				return ctrl.Result{}, err
 			}
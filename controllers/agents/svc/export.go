@@ -0,0 +1,194 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+// exportKey is the key of the manifests of the named registered service of
+// the service class, and of its secret, in the export bundle.  Names can not
+// contain underscores, so that the keys of the service classes sharing a
+// bundle never collide.
+func exportKey(serviceClass v1alpha1.ServiceClass, name string) string {
+	return fmt.Sprintf("%s_%s.yaml", serviceClass.Name, name)
+}
+
+// exportRoleName returns the name of the Role granting access to the export
+// bundle of a ServiceClass
+func exportRoleName(serviceClass v1alpha1.ServiceClass) string {
+	return fmt.Sprintf("primaza:svc:export:%s", serviceClass.Name)
+}
+
+// ReconcileExportRole maintains a Role, bound to the service agent, that
+// grants access only to the export bundle of the ServiceClass, if any.
+func (r *ServiceClassReconciler) ReconcileExportRole(ctx context.Context, serviceClass *v1alpha1.ServiceClass) error {
+	var rules []rbacv1.PolicyRule
+	if serviceClass.Spec.Export != nil {
+		rules = []rbacv1.PolicyRule{
+			{
+				APIGroups:     []string{""},
+				Resources:     []string{"configmaps", "secrets"},
+				Verbs:         []string{"get", "update"},
+				ResourceNames: []string{serviceClass.Spec.Export.ConfigMap},
+			},
+		}
+	}
+	return r.reconcileRole(ctx, serviceClass, exportRoleName(*serviceClass), rules)
+}
+
+// claimBundle labels the given ConfigMap or Secret of the export bundle when
+// it is created, and refuses to touch existing objects that are not export
+// bundles, e.g. the agent's kubeconfig
+func claimBundle(obj client.Object, kind string) error {
+	labels := obj.GetLabels()
+	if obj.GetResourceVersion() != "" && !isBundle(obj) {
+		return fmt.Errorf("%s %s is not labeled %s=true, refusing to use it as export bundle",
+			kind, obj.GetName(), constants.PrimazaExportBundleLabel)
+	}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[constants.PrimazaExportBundleLabel] = "true"
+	obj.SetLabels(labels)
+	return nil
+}
+
+// isBundle returns whether the given ConfigMap or Secret is an export bundle
+func isBundle(obj client.Object) bool {
+	return obj.GetLabels()[constants.PrimazaExportBundleLabel] == "true"
+}
+
+// exportRegisteredService renders the registered service into the ConfigMap
+// of the service class' export bundle, and its secret into the Secret with
+// the same name, instead of writing them to the control plane.  The bundle is
+// owned by the service classes exporting to it, so that it is garbage
+// collected along with them.  Existing ConfigMaps and Secrets that are not
+// labeled as export bundles are never touched.  It returns whether the registered service's
+// manifest has been created or updated.
+func (r *ServiceClassReconciler) exportRegisteredService(ctx context.Context, serviceClass v1alpha1.ServiceClass, rs v1alpha1.RegisteredService, secret *v1.Secret) (controllerutil.OperationResult, error) {
+	key := exportKey(serviceClass, rs.Name)
+	bundle := metav1.ObjectMeta{Name: serviceClass.Spec.Export.ConfigMap, Namespace: serviceClass.Namespace}
+
+	// the secret is rendered first, so that the registered service never
+	// refers to a missing secret once synchronized
+	if secret != nil && len(secret.StringData)+len(secret.Data) > 0 {
+		s := *secret
+		s.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}
+		manifest, err := yaml.Marshal(&s)
+		if err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+
+		secrets := &v1.Secret{ObjectMeta: bundle}
+		if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, secrets, func() error {
+			if err := claimBundle(secrets, "Secret"); err != nil {
+				return err
+			}
+			if secrets.Data == nil {
+				secrets.Data = map[string][]byte{}
+			}
+			secrets.Data[key] = manifest
+			return controllerutil.SetOwnerReference(&serviceClass, secrets, r.Scheme())
+		}); err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+	} else if err := r.unexportSecret(ctx, bundle, key); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	rs.TypeMeta = metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: registeredServiceKind}
+	manifest, err := yaml.Marshal(&rs)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	op := controllerutil.OperationResultNone
+	cm := &v1.ConfigMap{ObjectMeta: bundle}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		if err := claimBundle(cm, "ConfigMap"); err != nil {
+			return err
+		}
+		switch current, ok := cm.Data[key]; {
+		case !ok:
+			op = controllerutil.OperationResultCreated
+		case current != string(manifest):
+			op = controllerutil.OperationResultUpdated
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[key] = string(manifest)
+		return controllerutil.SetOwnerReference(&serviceClass, cm, r.Scheme())
+	}); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	return op, nil
+}
+
+// unexportRegisteredService removes the named registered service, and its
+// secret, from the service class' export bundle.  It returns whether the
+// registered service was exported.
+func (r *ServiceClassReconciler) unexportRegisteredService(ctx context.Context, serviceClass v1alpha1.ServiceClass, name string) (bool, error) {
+	key := exportKey(serviceClass, name)
+	bundle := metav1.ObjectMeta{Name: serviceClass.Spec.Export.ConfigMap, Namespace: serviceClass.Namespace}
+
+	cm := &v1.ConfigMap{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: bundle.Namespace, Name: bundle.Name}, cm); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	if !isBundle(cm) {
+		return false, nil
+	}
+	_, exported := cm.Data[key]
+	if exported {
+		delete(cm.Data, key)
+		if err := r.Update(ctx, cm); err != nil {
+			return false, err
+		}
+	}
+	return exported, r.unexportSecret(ctx, bundle, key)
+}
+
+// unexportSecret removes the secret with the given key from the Secret of the
+// export bundle, if any
+func (r *ServiceClassReconciler) unexportSecret(ctx context.Context, bundle metav1.ObjectMeta, key string) error {
+	secrets := &v1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: bundle.Namespace, Name: bundle.Name}, secrets); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if _, ok := secrets.Data[key]; !ok || !isBundle(secrets) {
+		return nil
+	}
+	delete(secrets.Data, key)
+	if err := r.Update(ctx, secrets); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
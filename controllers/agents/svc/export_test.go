@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+func newExportingServiceClass(name, bundle string) *v1alpha1.ServiceClass {
+	return &v1alpha1.ServiceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "services", UID: types.UID("uid-" + name)},
+		Spec:       v1alpha1.ServiceClassSpec{Export: &v1alpha1.ServiceClassExport{ConfigMap: bundle}},
+	}
+}
+
+func TestExportRegisteredService(t *testing.T) {
+	postgres := newExportingServiceClass("postgres", "registered-services")
+	redis := newExportingServiceClass("redis", "registered-services")
+	cli := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(postgres, redis).Build()
+	r := &ServiceClassReconciler{Client: cli}
+	ctx := context.Background()
+
+	// both service classes discover a service named db
+	rs := v1alpha1.RegisteredService{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "primaza-system"}}
+	secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db-descriptor"}, StringData: map[string]string{"password": "s3cr3t"}}
+	for _, sc := range []*v1alpha1.ServiceClass{postgres, redis} {
+		op, err := r.exportRegisteredService(ctx, *sc, rs, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if op != controllerutil.OperationResultCreated {
+			t.Errorf("expected the manifest of %s to be created, got %s", sc.Name, op)
+		}
+	}
+
+	key := client.ObjectKey{Namespace: "services", Name: "registered-services"}
+	cm := &v1.ConfigMap{}
+	if err := cli.Get(ctx, key, cm); err != nil {
+		t.Fatal(err)
+	}
+	secrets := &v1.Secret{}
+	if err := cli.Get(ctx, key, secrets); err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range []client.Object{cm, secrets} {
+		if !isBundle(o) {
+			t.Errorf("expected %s to be labeled as export bundle, got %v", o.GetName(), o.GetLabels())
+		}
+	}
+	for _, k := range []string{"postgres_db.yaml", "redis_db.yaml"} {
+		if _, ok := cm.Data[k]; !ok {
+			t.Errorf("expected the bundle to hold %s, got %v", k, cm.Data)
+		}
+		if _, ok := secrets.Data[k]; !ok {
+			t.Errorf("expected the bundle secret to hold %s", k)
+		}
+	}
+
+	// removing the service of a service class leaves the other one's
+	exported, err := r.unexportRegisteredService(ctx, *postgres, "db")
+	if err != nil || !exported {
+		t.Fatalf("expected the service to be unexported, got %v (%v)", exported, err)
+	}
+	if err := cli.Get(ctx, key, cm); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cm.Data["redis_db.yaml"]; !ok || len(cm.Data) != 1 {
+		t.Errorf("expected only the service of redis to be left, got %v", cm.Data)
+	}
+}
+
+func TestExportRegisteredServiceRefusesForeignObjects(t *testing.T) {
+	kubeconfig := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "primaza-svc-kubeconfig", Namespace: "services"},
+		Data:       map[string][]byte{"kubeconfig": []byte("config")},
+	}
+	sc := newExportingServiceClass("postgres", kubeconfig.Name)
+	cli := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(sc, kubeconfig).Build()
+	r := &ServiceClassReconciler{Client: cli}
+	ctx := context.Background()
+
+	rs := v1alpha1.RegisteredService{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "primaza-system"}}
+	secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db-descriptor"}, StringData: map[string]string{"password": "s3cr3t"}}
+	if _, err := r.exportRegisteredService(ctx, *sc, rs, secret); err == nil {
+		t.Fatal("expected the export to an unlabeled secret to be refused")
+	}
+
+	actual := &v1.Secret{}
+	if err := cli.Get(ctx, client.ObjectKeyFromObject(kubeconfig), actual); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual.Data, kubeconfig.Data) || len(actual.OwnerReferences) != 0 {
+		t.Errorf("expected the kubeconfig to be left untouched, got %v owned by %v", actual.Data, actual.OwnerReferences)
+	}
+
+	if exported, err := r.unexportRegisteredService(ctx, *sc, "db"); err != nil || exported {
+		t.Errorf("expected nothing to be unexported, got %v (%v)", exported, err)
+	}
+}
+
+func TestReconcileExportRole(t *testing.T) {
+	sc := newExportingServiceClass("postgres", "registered-services")
+	cli := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(sc).Build()
+	r := &ServiceClassReconciler{Client: cli}
+	ctx := context.Background()
+
+	if err := r.ReconcileExportRole(ctx, sc); err != nil {
+		t.Fatal(err)
+	}
+	role := &rbacv1.Role{}
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: "services", Name: "primaza:svc:export:postgres"}, role); err != nil {
+		t.Fatal(err)
+	}
+	want := []rbacv1.PolicyRule{{
+		APIGroups:     []string{""},
+		Resources:     []string{"configmaps", "secrets"},
+		Verbs:         []string{"get", "update"},
+		ResourceNames: []string{"registered-services"},
+	}}
+	if !reflect.DeepEqual(role.Rules, want) {
+		t.Errorf("expected the role to grant %v, got %v", want, role.Rules)
+	}
+}
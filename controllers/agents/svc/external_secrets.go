@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+	"github.com/primaza/primaza/pkg/primaza/remotewriter"
+)
+
+var (
+	pushSecretGVK     = schema.GroupVersionKind{Group: "external-secrets.io", Version: "v1alpha1", Kind: "PushSecret"}
+	externalSecretGVK = schema.GroupVersionKind{Group: "external-secrets.io", Version: "v1beta1", Kind: "ExternalSecret"}
+)
+
+const (
+	defaultRemoteKeyPrefix         = "primaza"
+	defaultExternalSecretsInterval = time.Hour
+)
+
+// writeRegisteredServiceWithExternalSecret creates or updates the registered
+// service, whose secret's values flow through External Secrets Operator
+// stores: a PushSecret pushes the values of a local secret to the push store,
+// and an ExternalSecret, written along with the registered service, reads
+// them from the pull store into the registered service's secret.  Raw
+// credentials are never written to the control plane by the agent.
+func (r *ServiceClassReconciler) writeRegisteredServiceWithExternalSecret(ctx context.Context, serviceClass v1alpha1.ServiceClass, remote_client client.Client, rs v1alpha1.RegisteredService, secret v1.Secret) (controllerutil.OperationResult, error) {
+	es := *serviceClass.Spec.ExternalSecrets
+	remoteKey := externalSecretRemoteKey(es, rs)
+	keys := secretKeys(secret)
+
+	// the local secret and its PushSecret are owned by the service class, so
+	// that they are garbage collected with it
+	local := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secret.Name, Namespace: serviceClass.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, local, func() error {
+		local.StringData = nil
+		local.Data = map[string][]byte{}
+		for k, v := range secret.StringData {
+			local.Data[k] = []byte(v)
+		}
+		for k, v := range secret.Data {
+			local.Data[k] = v
+		}
+		return controllerutil.SetOwnerReference(&serviceClass, local, r.Scheme())
+	}); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	push := &unstructured.Unstructured{}
+	push.SetGroupVersionKind(pushSecretGVK)
+	push.SetName(secret.Name)
+	push.SetNamespace(serviceClass.Namespace)
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, push, func() error {
+		push.Object["spec"] = pushSecretSpec(es, local.Name, remoteKey, keys)
+		return controllerutil.SetOwnerReference(&serviceClass, push, r.Scheme())
+	}); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	pull := &unstructured.Unstructured{}
+	pull.SetGroupVersionKind(externalSecretGVK)
+	pull.SetName(secret.Name)
+	pull.SetNamespace(rs.Namespace)
+	pull.Object["spec"] = externalSecretSpec(es, secret.Name, remoteKey, keys)
+	return remotewriter.ApplyWithDependency(ctx, remote_client, constants.AgentFieldManager, &rs, pull)
+}
+
+// externalSecretRemoteKey returns the key the values of the registered
+// service are stored under in the stores
+func externalSecretRemoteKey(es v1alpha1.ServiceClassExternalSecrets, rs v1alpha1.RegisteredService) string {
+	prefix := es.RemoteKeyPrefix
+	if prefix == "" {
+		prefix = defaultRemoteKeyPrefix
+	}
+	return fmt.Sprintf("%s-%s-%s", prefix, rs.Namespace, rs.Name)
+}
+
+// secretKeys returns the sorted keys of the secret
+func secretKeys(secret v1.Secret) []string {
+	keys := make([]string, 0, len(secret.StringData)+len(secret.Data))
+	for k := range secret.StringData {
+		keys = append(keys, k)
+	}
+	for k := range secret.Data {
+		if _, found := secret.StringData[k]; !found {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// storeRef returns the reference to the store in the format of the External
+// Secrets Operator resources
+func storeRef(ref v1alpha1.ExternalSecretStoreRef) map[string]interface{} {
+	kind := ref.Kind
+	if kind == "" {
+		kind = "SecretStore"
+	}
+	return map[string]interface{}{"name": ref.Name, "kind": kind}
+}
+
+// refreshInterval returns the refresh interval of the External Secrets
+// Operator resources
+func refreshInterval(es v1alpha1.ServiceClassExternalSecrets) string {
+	if es.RefreshInterval != nil {
+		return es.RefreshInterval.Duration.String()
+	}
+	return defaultExternalSecretsInterval.String()
+}
+
+// pushSecretSpec returns the spec of the PushSecret pushing each key of the
+// local secret as a property of the remote key
+func pushSecretSpec(es v1alpha1.ServiceClassExternalSecrets, local, remoteKey string, keys []string) map[string]interface{} {
+	data := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		data = append(data, map[string]interface{}{
+			"match": map[string]interface{}{
+				"secretKey": k,
+				"remoteRef": map[string]interface{}{"remoteKey": remoteKey, "property": k},
+			},
+		})
+	}
+	return map[string]interface{}{
+		"refreshInterval": refreshInterval(es),
+		"deletionPolicy":  "Delete",
+		"secretStoreRefs": []interface{}{storeRef(es.PushStoreRef)},
+		"selector": map[string]interface{}{
+			"secret": map[string]interface{}{"name": local},
+		},
+		"data": data,
+	}
+}
+
+// externalSecretSpec returns the spec of the ExternalSecret reading each
+// property of the remote key into the target secret
+func externalSecretSpec(es v1alpha1.ServiceClassExternalSecrets, target, remoteKey string, keys []string) map[string]interface{} {
+	data := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		data = append(data, map[string]interface{}{
+			"secretKey": k,
+			"remoteRef": map[string]interface{}{"key": remoteKey, "property": k},
+		})
+	}
+	return map[string]interface{}{
+		"refreshInterval": refreshInterval(es),
+		"secretStoreRef":  storeRef(es.PullStoreRef),
+		"target": map[string]interface{}{
+			"name":           target,
+			"creationPolicy": "Owner",
+		},
+		"data": data,
+	}
+}
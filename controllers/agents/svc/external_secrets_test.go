@@ -0,0 +1,175 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+// applyClient emulates the apply patches the fake client does not support:
+// missing objects are created and existing ones are overwritten
+type applyClient struct {
+	client.Client
+}
+
+func (c *applyClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+	current, _ := obj.DeepCopyObject().(client.Object)
+	if err := c.Client.Get(ctx, client.ObjectKeyFromObject(obj), current); apierrors.IsNotFound(err) {
+		return c.Client.Create(ctx, obj)
+	} else if err != nil {
+		return err
+	}
+	obj.SetResourceVersion(current.GetResourceVersion())
+	return c.Client.Update(ctx, obj)
+}
+
+func TestWriteRegisteredServiceWithExternalSecret(t *testing.T) {
+	ctx := context.Background()
+	serviceClass := v1alpha1.ServiceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "databases", Namespace: "services", UID: "sc-uid"},
+		Spec: v1alpha1.ServiceClassSpec{
+			ExternalSecrets: &v1alpha1.ServiceClassExternalSecrets{
+				PushStoreRef:    v1alpha1.ExternalSecretStoreRef{Name: "vault-push"},
+				PullStoreRef:    v1alpha1.ExternalSecretStoreRef{Name: "vault-pull", Kind: "ClusterSecretStore"},
+				RefreshInterval: &metav1.Duration{Duration: 10 * time.Minute},
+			},
+		},
+	}
+	worker := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(&serviceClass).Build()
+	control := &applyClient{Client: fake.NewClientBuilder().WithScheme(newTestScheme(t)).Build()}
+	r := &ServiceClassReconciler{Client: worker}
+
+	rs := v1alpha1.RegisteredService{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: "RegisteredService"},
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "primaza-system"},
+	}
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-descriptor"},
+		StringData: map[string]string{"password": "s3cr3t"},
+		Data:       map[string][]byte{"tls.key": {0xff, 0x00}},
+	}
+	if _, err := r.writeRegisteredServiceWithExternalSecret(ctx, serviceClass, control, rs, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	ownedBy := func(obj client.Object, name string) bool {
+		for _, o := range obj.GetOwnerReferences() {
+			if o.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	// the local secret holds the values, and is owned by the service class
+	local := v1.Secret{}
+	if err := worker.Get(ctx, types.NamespacedName{Namespace: "services", Name: "db-descriptor"}, &local); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]byte{"password": []byte("s3cr3t"), "tls.key": {0xff, 0x00}}; !reflect.DeepEqual(local.Data, want) {
+		t.Errorf("expected the local secret data %v, got %v", want, local.Data)
+	}
+	if !ownedBy(&local, "databases") {
+		t.Errorf("expected the local secret to be owned by the service class, got %v", local.OwnerReferences)
+	}
+
+	// the PushSecret pushes each key as a property of the remote key
+	push := &unstructured.Unstructured{}
+	push.SetGroupVersionKind(pushSecretGVK)
+	if err := worker.Get(ctx, types.NamespacedName{Namespace: "services", Name: "db-descriptor"}, push); err != nil {
+		t.Fatal(err)
+	}
+	pushData := func(key string) interface{} {
+		return map[string]interface{}{"match": map[string]interface{}{
+			"secretKey": key,
+			"remoteRef": map[string]interface{}{"remoteKey": "primaza-primaza-system-db", "property": key},
+		}}
+	}
+	wantPush := map[string]interface{}{
+		"refreshInterval": "10m0s",
+		"deletionPolicy":  "Delete",
+		"secretStoreRefs": []interface{}{map[string]interface{}{"name": "vault-push", "kind": "SecretStore"}},
+		"selector":        map[string]interface{}{"secret": map[string]interface{}{"name": "db-descriptor"}},
+		"data":            []interface{}{pushData("password"), pushData("tls.key")},
+	}
+	if !reflect.DeepEqual(push.Object["spec"], wantPush) {
+		t.Errorf("expected the PushSecret spec %v, got %v", wantPush, push.Object["spec"])
+	}
+	if !ownedBy(push, "databases") {
+		t.Errorf("expected the PushSecret to be owned by the service class, got %v", push.GetOwnerReferences())
+	}
+
+	// the ExternalSecret reads the properties back into the registered
+	// service's secret, and is owned by the registered service
+	pull := &unstructured.Unstructured{}
+	pull.SetGroupVersionKind(externalSecretGVK)
+	if err := control.Get(ctx, types.NamespacedName{Namespace: "primaza-system", Name: "db-descriptor"}, pull); err != nil {
+		t.Fatal(err)
+	}
+	pullData := func(key string) interface{} {
+		return map[string]interface{}{
+			"secretKey": key,
+			"remoteRef": map[string]interface{}{"key": "primaza-primaza-system-db", "property": key},
+		}
+	}
+	wantPull := map[string]interface{}{
+		"refreshInterval": "10m0s",
+		"secretStoreRef":  map[string]interface{}{"name": "vault-pull", "kind": "ClusterSecretStore"},
+		"target":          map[string]interface{}{"name": "db-descriptor", "creationPolicy": "Owner"},
+		"data":            []interface{}{pullData("password"), pullData("tls.key")},
+	}
+	if !reflect.DeepEqual(pull.Object["spec"], wantPull) {
+		t.Errorf("expected the ExternalSecret spec %v, got %v", wantPull, pull.Object["spec"])
+	}
+	if !ownedBy(pull, "db") {
+		t.Errorf("expected the ExternalSecret to be owned by the registered service, got %v", pull.GetOwnerReferences())
+	}
+
+	// the raw credentials are not written to the control plane
+	if err := control.Get(ctx, types.NamespacedName{Namespace: "primaza-system", Name: "db-descriptor"}, &v1.Secret{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected no secret in the control plane, got %v", err)
+	}
+	if err := control.Get(ctx, types.NamespacedName{Namespace: "primaza-system", Name: "db"}, &v1alpha1.RegisteredService{}); err != nil {
+		t.Errorf("expected the registered service to be written, got %v", err)
+	}
+}
+
+func TestExternalSecretRemoteKey(t *testing.T) {
+	rs := v1alpha1.RegisteredService{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "primaza-system"}}
+	if got := externalSecretRemoteKey(v1alpha1.ServiceClassExternalSecrets{}, rs); got != "primaza-primaza-system-db" {
+		t.Errorf("expected the default prefix, got %s", got)
+	}
+	if got := externalSecretRemoteKey(v1alpha1.ServiceClassExternalSecrets{RemoteKeyPrefix: "team-a"}, rs); got != "team-a-primaza-system-db" {
+		t.Errorf("expected the team-a prefix, got %s", got)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/helm"
+)
+
+// helmReleaseSelector restricts the given selector to the Secrets holding
+// the deployed revision of Helm releases
+func helmReleaseSelector(selector *metav1.LabelSelector) *metav1.LabelSelector {
+	ls := &metav1.LabelSelector{}
+	if selector != nil {
+		ls = selector.DeepCopy()
+	}
+	if ls.MatchLabels == nil {
+		ls.MatchLabels = map[string]string{}
+	}
+	ls.MatchLabels[helm.OwnerLabel] = "helm"
+	ls.MatchLabels[helm.StatusLabel] = helm.StatusDeployed
+	return ls
+}
+
+// serviceResource returns the service resource of the given object, and
+// whether the service class manages it.  For service classes discovering
+// Helm releases, it is the release stored in the object, if the release is
+// one of the service class' chart.
+func serviceResource(ctx context.Context, serviceClass v1alpha1.ServiceClass, obj *unstructured.Unstructured) (*unstructured.Unstructured, bool) {
+	if serviceClass.Spec.Resource.Helm == nil {
+		return obj, true
+	}
+
+	rel, err := helm.Release(obj)
+	if err != nil {
+		log.FromContext(ctx).Info("skipping helm release secret", "secret", obj.GetName(), "error", err)
+		return nil, false
+	}
+	return rel, helm.Chart(rel) == serviceClass.Spec.Resource.Helm.Chart
+}
+
+// helmReleases returns the releases of the service class' chart stored in
+// the given release Secrets
+func helmReleases(ctx context.Context, serviceClass v1alpha1.ServiceClass, secrets []unstructured.Unstructured) []unstructured.Unstructured {
+	releases := make([]unstructured.Unstructured, 0, len(secrets))
+	for i := range secrets {
+		if rel, ok := serviceResource(ctx, serviceClass, &secrets[i]); ok {
+			releases = append(releases, *rel)
+		}
+	}
+	return releases
+}
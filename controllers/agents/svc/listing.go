@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/pager"
+)
+
+// DefaultListPageSize is the default number of resources the agent lists per
+// request when discovering the resources of a service class
+const DefaultListPageSize = 500
+
+// listResources lists the resources matching the label selector a page of
+// ListPageSize resources at a time, following the continue tokens, so that
+// the listings of thousands of resources are not returned by a single
+// request.  If a continue token expires, the listing falls back to a single
+// request.  The resources are listed from the API server's watch cache, i.e.
+// at resource version 0, if ListFromCache is set.
+func (r *ServiceClassReconciler) listResources(ctx context.Context, ri dynamic.ResourceInterface, selector string) (*unstructured.UnstructuredList, error) {
+	p := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		return ri.List(ctx, opts)
+	})
+	p.PageSize = int64(r.ListPageSize)
+
+	opts := metav1.ListOptions{LabelSelector: selector}
+	if r.ListFromCache {
+		opts.ResourceVersion = "0"
+	}
+	obj, _, err := p.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	services := &unstructured.UnstructuredList{}
+	err = meta.EachListItem(obj, func(o runtime.Object) error {
+		u, ok := o.(*unstructured.Unstructured)
+		if !ok {
+			return fmt.Errorf("unexpected type %T in list", o)
+		}
+		services.Items = append(services.Items, *u)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return services, nil
+}
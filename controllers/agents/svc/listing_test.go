@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// pagedResource serves its items a page at a time, as the API server does,
+// and records the list requests it receives
+type pagedResource struct {
+	dynamic.ResourceInterface
+	items []unstructured.Unstructured
+	// expire makes the continue tokens expire
+	expire   bool
+	requests []metav1.ListOptions
+}
+
+func (r *pagedResource) List(_ context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	r.requests = append(r.requests, opts)
+
+	start := 0
+	if opts.Continue != "" {
+		if r.expire {
+			return nil, apierrors.NewResourceExpired("continue token expired")
+		}
+		start, _ = strconv.Atoi(opts.Continue)
+	}
+	end := len(r.items)
+	if opts.Limit > 0 && start+int(opts.Limit) < end {
+		end = start + int(opts.Limit)
+	}
+
+	list := &unstructured.UnstructuredList{Items: append([]unstructured.Unstructured{}, r.items[start:end]...)}
+	list.SetResourceVersion("42")
+	if end < len(r.items) {
+		list.SetContinue(strconv.Itoa(end))
+	}
+	return list, nil
+}
+
+func newDatabases(n int) []unstructured.Unstructured {
+	items := []unstructured.Unstructured{}
+	for i := 0; i < n; i++ {
+		items = append(items, newDatabase(fmt.Sprintf("db-%d", i), map[string]interface{}{}))
+	}
+	return items
+}
+
+func TestListResources(t *testing.T) {
+	page := func(rv string, limit int64, cont string) metav1.ListOptions {
+		return metav1.ListOptions{LabelSelector: "app=db", ResourceVersion: rv, Limit: limit, Continue: cont}
+	}
+	tests := []struct {
+		name          string
+		items         int
+		pageSize      int
+		listFromCache bool
+		expire        bool
+		wantRequests  []metav1.ListOptions
+	}{
+		{
+			name:         "follows the continue tokens",
+			items:        5,
+			pageSize:     2,
+			wantRequests: []metav1.ListOptions{page("", 2, ""), page("", 2, "2"), page("", 2, "4")},
+		},
+		{
+			name:         "lists in a single page",
+			items:        3,
+			pageSize:     DefaultListPageSize,
+			wantRequests: []metav1.ListOptions{page("", DefaultListPageSize, "")},
+		},
+		{
+			name:          "lists from the watch cache",
+			items:         3,
+			pageSize:      2,
+			listFromCache: true,
+			// the resource version is only allowed on the first page
+			wantRequests: []metav1.ListOptions{page("0", 2, ""), page("", 2, "2")},
+		},
+		{
+			name:          "falls back to a single request when the continue token expires",
+			items:         5,
+			pageSize:      2,
+			listFromCache: true,
+			expire:        true,
+			wantRequests:  []metav1.ListOptions{page("0", 2, ""), page("", 2, "2"), page("0", 0, "")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ri := &pagedResource{items: newDatabases(tt.items), expire: tt.expire}
+			r := &ServiceClassReconciler{ListPageSize: tt.pageSize, ListFromCache: tt.listFromCache}
+
+			services, err := r.listResources(context.Background(), ri, "app=db")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(ri.requests, tt.wantRequests) {
+				t.Errorf("expected the requests %v, got %v", tt.wantRequests, ri.requests)
+			}
+			if len(services.Items) != tt.items {
+				t.Fatalf("expected %d resources, got %d", tt.items, len(services.Items))
+			}
+			for i, s := range services.Items {
+				if want := fmt.Sprintf("db-%d", i); s.GetName() != want {
+					t.Errorf("expected resource %d to be %s, got %s", i, want, s.GetName())
+				}
+				if s.GroupVersionKind() != (schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Database"}) {
+					t.Errorf("expected resource %d to be a Database, got %v", i, s.GroupVersionKind())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+// agentFieldManagers are the field managers that write on behalf of Primaza.
+// `manager` is the default field manager of Primaza's binaries, used for the
+// objects written before the service agent had its own field manager, and the
+// service agent's own field manager was used before the objects were applied.
+var agentFieldManagers = map[string]struct{}{
+	constants.AgentFieldManager:        {},
+	constants.ServiceAgentFieldManager: {},
+	"manager":                          {},
+}
+
+// manualEditors returns the sorted field managers, other than Primaza's,
+// that own any of the given top-level fields of obj
+func manualEditors(obj metav1.Object, fields ...string) ([]string, error) {
+	editors := map[string]struct{}{}
+	for _, mf := range obj.GetManagedFields() {
+		if _, ok := agentFieldManagers[mf.Manager]; ok || mf.Subresource != "" || mf.FieldsV1 == nil {
+			continue
+		}
+
+		owned := map[string]json.RawMessage{}
+		if err := json.Unmarshal(mf.FieldsV1.Raw, &owned); err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			if _, ok := owned["f:"+f]; ok {
+				editors[mf.Manager] = struct{}{}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(editors))
+	for e := range editors {
+		names = append(names, e)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// manualEdits maps the name of the manually edited registered services to
+// the field managers that edited them or their secret.  Edits can be detected
+// concurrently.
+type manualEdits struct {
+	mu      sync.Mutex
+	editors map[string][]string
+}
+
+// detect is a HandleFunc that records whether the given registered service
+// or its secret have been manually edited.  It does not write anything.
+func (e *manualEdits) detect(ctx context.Context, remote_client client.Client, rs v1alpha1.RegisteredService, secret *v1.Secret) []error {
+	current := v1alpha1.RegisteredService{}
+	if err := remote_client.Get(ctx, types.NamespacedName{Namespace: rs.Namespace, Name: rs.Name}, &current); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return []error{err}
+	}
+	editors, err := manualEditors(&current, "spec")
+	if err != nil {
+		return []error{err}
+	}
+
+	if secret != nil {
+		cs := v1.Secret{}
+		if err := remote_client.Get(ctx, types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}, &cs); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return []error{err}
+			}
+		} else {
+			se, err := manualEditors(&cs, "data", "stringData")
+			if err != nil {
+				return []error{err}
+			}
+			editors = append(editors, se...)
+		}
+	}
+
+	if len(editors) > 0 {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if e.editors == nil {
+			e.editors = map[string][]string{}
+		}
+		e.editors[rs.Name] = editors
+	}
+	return nil
+}
+
+// guard returns a HandleFunc that writes the registered services with write
+// unless the given policy requires the manual edits to be kept
+func (e *manualEdits) guard(policy v1alpha1.ManualEditPolicy, write HandleFunc) HandleFunc {
+	return func(ctx context.Context, remote_client client.Client, rs v1alpha1.RegisteredService, secret *v1.Secret) []error {
+		l := log.FromContext(ctx).WithValues("namespace", rs.Namespace, "name", rs.Name)
+		switch policy {
+		case v1alpha1.ManualEditPolicyPause:
+			if len(e.editors) > 0 {
+				l.Info("Synchronization paused because of manual edits", "registered service", rs.Name)
+				return nil
+			}
+		case v1alpha1.ManualEditPolicyWarn:
+			if editors, ok := e.editors[rs.Name]; ok {
+				l.Info("Keeping manually edited registered service", "registered service", rs.Name, "editors", editors)
+				return nil
+			}
+		}
+		return write(ctx, remote_client, rs, secret)
+	}
+}
+
+// condition returns the ManualOverride condition reporting the manual edits
+func (e *manualEdits) condition(policy v1alpha1.ManualEditPolicy) metav1.Condition {
+	if len(e.editors) == 0 {
+		return metav1.Condition{
+			Type:    v1alpha1.ServiceClassConditionManualOverride,
+			Status:  metav1.ConditionFalse,
+			Reason:  constants.NoManualEditsReason,
+			Message: "No registered service has been manually edited",
+		}
+	}
+
+	names := make([]string, 0, len(e.editors))
+	for n := range e.editors {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	edited := make([]string, 0, len(names))
+	for _, n := range names {
+		edited = append(edited, fmt.Sprintf("%s (%s)", n, strings.Join(e.editors[n], ", ")))
+	}
+
+	reason := constants.ManualEditsRevertedReason
+	switch policy {
+	case v1alpha1.ManualEditPolicyWarn:
+		reason = constants.ManualEditsKeptReason
+	case v1alpha1.ManualEditPolicyPause:
+		reason = constants.SyncPausedReason
+	}
+	return metav1.Condition{
+		Type:    v1alpha1.ServiceClassConditionManualOverride,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: fmt.Sprintf("Registered services manually edited: %s", strings.Join(edited, "; ")),
+	}
+}
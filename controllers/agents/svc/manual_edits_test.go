@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+func TestManualEditors(t *testing.T) {
+	managed := func(manager, subresource, fields string) metav1.ManagedFieldsEntry {
+		return metav1.ManagedFieldsEntry{
+			Manager:     manager,
+			Operation:   metav1.ManagedFieldsOperationUpdate,
+			Subresource: subresource,
+			FieldsType:  "FieldsV1",
+			FieldsV1:    &metav1.FieldsV1{Raw: []byte(fields)},
+		}
+	}
+	tests := []struct {
+		name    string
+		managed []metav1.ManagedFieldsEntry
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "agent-only managers",
+			managed: []metav1.ManagedFieldsEntry{
+				managed(constants.ServiceAgentFieldManager, "", `{"f:spec":{"f:serviceEndpointDefinition":{}}}`),
+				managed(constants.AgentFieldManager, "", `{"f:spec":{}}`),
+				managed("manager", "", `{"f:spec":{}}`),
+			},
+			want: []string{},
+		},
+		{
+			name: "foreign manager on a mapped field",
+			managed: []metav1.ManagedFieldsEntry{
+				managed(constants.ServiceAgentFieldManager, "", `{"f:spec":{}}`),
+				managed("kubectl-edit", "", `{"f:spec":{"f:serviceEndpointDefinition":{}}}`),
+				managed("argocd", "", `{"f:spec":{"f:constraints":{}}}`),
+			},
+			want: []string{"argocd", "kubectl-edit"},
+		},
+		{
+			name: "foreign manager on an unrelated field",
+			managed: []metav1.ManagedFieldsEntry{
+				managed("kubectl-label", "", `{"f:metadata":{"f:labels":{"f:team":{}}}}`),
+				managed("primaza", "status", `{"f:status":{"f:state":{}}}`),
+			},
+			want: []string{},
+		},
+		{
+			name:    "malformed FieldsV1",
+			managed: []metav1.ManagedFieldsEntry{managed("kubectl-edit", "", `{"f:spec":`)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := &v1alpha1.RegisteredService{ObjectMeta: metav1.ObjectMeta{Name: "db", ManagedFields: tt.managed}}
+			got, err := manualEditors(rs, "spec")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("manualEditors() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("manualEditors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+	"github.com/primaza/primaza/pkg/primaza/remotewriter"
+)
+
+var (
+	registeredServiceWrites = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "primaza_registeredservice_writes_total",
+			Help: "Number of writes of registered services to the control plane, by operation (created, updated, unchanged or failed)",
+		},
+		[]string{"operation"},
+	)
+	discoveredResources = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "primaza_serviceclass_discovered_resources",
+			Help: "Number of resources matched by a service class",
+		},
+		[]string{"namespace", "serviceclass"},
+	)
+	remoteRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "primaza_remote_request_duration_seconds",
+			Help:    "Duration of the requests to the control plane, by method and status code",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method", "code"},
+	)
+	controlPlaneConnected = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "primaza_control_plane_connected",
+			Help: "Whether the agent can connect to the control plane",
+		},
+		[]string{"namespace"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(registeredServiceWrites, discoveredResources, remoteRequestDuration, controlPlaneConnected)
+}
+
+// recordRegisteredServiceWrite counts a write of a registered service, with
+// the operation performed or failed if err is not nil
+func recordRegisteredServiceWrite(op controllerutil.OperationResult, err error) {
+	operation := string(op)
+	if err != nil {
+		operation = "failed"
+	}
+	registeredServiceWrites.WithLabelValues(operation).Inc()
+}
+
+// recordDiscoveredResources sets the number of resources matched by the
+// service class
+func recordDiscoveredResources(serviceClass v1alpha1.ServiceClass, n int) {
+	discoveredResources.WithLabelValues(serviceClass.Namespace, serviceClass.Name).Set(float64(n))
+}
+
+// forgetDiscoveredResources removes the metrics of the service class
+func forgetDiscoveredResources(serviceClass v1alpha1.ServiceClass) {
+	discoveredResources.DeleteLabelValues(serviceClass.Namespace, serviceClass.Name)
+}
+
+// recordConnectionState sets whether the agent of the given namespace can
+// connect to the control plane
+func recordConnectionState(namespace string, state v1alpha1.ClusterEnvironmentState) {
+	v := 0.0
+	if state == v1alpha1.ClusterEnvironmentStateOnline {
+		v = 1
+	}
+	controlPlaneConnected.WithLabelValues(namespace).Set(v)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// instrumentRemoteRequests measures the duration of the requests sent
+// through the given round tripper
+func instrumentRemoteRequests(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		res, err := rt.RoundTrip(req)
+		code := "error"
+		if err == nil {
+			code = strconv.Itoa(res.StatusCode)
+		}
+		remoteRequestDuration.WithLabelValues(req.Method, code).Observe(time.Since(start).Seconds())
+		return res, err
+	})
+}
+
+// remoteClient returns a client for the control plane whose requests are
+// measured, and whose writes are throttled by the control plane limiter
+func (r *ServiceClassReconciler) remoteClient(config *rest.Config) (client.Client, error) {
+	// the API server uses the user agent as default field manager
+	config.UserAgent = constants.ServiceAgentFieldManager
+	config.Wrap(instrumentRemoteRequests)
+	config.Wrap(remotewriter.Throttle(r.ControlPlaneLimiter, remotewriter.RecordThrottledWrite))
+	return client.New(config, client.Options{
+		Scheme: r.Client.Scheme(),
+		Mapper: r.Client.RESTMapper(),
+	})
+}
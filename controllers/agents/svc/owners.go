@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"path"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+// isOwned tells whether the given resource is owned as required by the
+// ServiceClass's OwnedBy.  Any resource is owned if OwnedBy is not set.
+func isOwned(serviceClass v1alpha1.ServiceClass, obj metav1.Object) bool {
+	owner := serviceClass.Spec.Resource.OwnedBy
+	if owner == nil {
+		return true
+	}
+
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.APIVersion != owner.APIVersion || ref.Kind != owner.Kind {
+			continue
+		}
+		if owner.Name == "" {
+			return true
+		}
+		if ok, err := path.Match(owner.Name, ref.Name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterOwned removes from services the resources not owned as required by
+// the ServiceClass's OwnedBy
+func filterOwned(serviceClass v1alpha1.ServiceClass, services *unstructured.UnstructuredList) {
+	if serviceClass.Spec.Resource.OwnedBy == nil {
+		return
+	}
+
+	owned := make([]unstructured.Unstructured, 0, len(services.Items))
+	for i := range services.Items {
+		if isOwned(serviceClass, &services.Items[i]) {
+			owned = append(owned, services.Items[i])
+		}
+	}
+	services.Items = owned
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+// Preview evaluates the ServiceClass's mappings on the given services without
+// registering them
+func (r *ServiceClassReconciler) Preview(ctx context.Context, serviceClass v1alpha1.ServiceClass, services unstructured.UnstructuredList) []v1alpha1.ServiceClassResourcePreview {
+	preview := make([]v1alpha1.ServiceClassResourcePreview, 0, len(services.Items))
+	for _, data := range services.Items {
+		p := v1alpha1.ServiceClassResourcePreview{
+			Name:      data.GetName(),
+			Namespace: data.GetNamespace(),
+		}
+
+		mappings, err := ServiceEndpointDefinitionMapping(ctx, r.Client, data, serviceClass)
+		if err != nil {
+			p.Error = err.Error()
+			preview = append(preview, p)
+			continue
+		}
+
+		for _, m := range mappings {
+			mp := v1alpha1.ServiceClassMappingPreview{Name: m.Key()}
+			value, err := m.ReadKey(ctx)
+			if err != nil {
+				mp.Error = err.Error()
+			} else {
+				mp.Extracted = value != nil
+			}
+			p.Mappings = append(p.Mappings, mp)
+		}
+		preview = append(preview, p)
+	}
+	return preview
+}
+
+// refreshPreview updates the preview of the given ServiceClass if it is
+// paused.  It returns whether the ServiceClass is paused.
+func (r *ServiceClassReconciler) refreshPreview(ctx context.Context, serviceClass v1alpha1.ServiceClass) (bool, error) {
+	current := v1alpha1.ServiceClass{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: serviceClass.Namespace, Name: serviceClass.Name}, &current); err != nil {
+		return false, err
+	}
+	if !current.Spec.Paused {
+		return false, nil
+	}
+
+	services, err := r.GetResources(ctx, &current)
+	if err != nil {
+		return true, err
+	}
+	log.FromContext(ctx).Info("Refreshing preview of paused service class", "service class", current.Name)
+	current.Status.Preview = r.Preview(ctx, current, *services)
+	current.UpdateSummary()
+	return true, r.Status().Update(ctx, &current)
+}
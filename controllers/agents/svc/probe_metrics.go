@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+var (
+	healthChecks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "primaza_healthcheck_total",
+			Help: "Number of health probes run against a registered service, by result",
+		},
+		[]string{"namespace", "name", "result"},
+	)
+	healthCheckDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "primaza_healthcheck_duration_seconds",
+			Help:    "Duration of the health probes run against a registered service",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		},
+		[]string{"namespace", "name"},
+	)
+	healthCheckLastResult = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "primaza_healthcheck_last_result",
+			Help: "Whether the latest health probe run against a registered service passed",
+		},
+		[]string{"namespace", "name"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(healthChecks, healthCheckDuration, healthCheckLastResult)
+}
+
+// recordHealthCheck updates the health probe metrics of the registered
+// service with the given result
+func recordHealthCheck(rs v1alpha1.RegisteredService, result v1alpha1.HealthCheckResult) {
+	outcome, v := "failed", 0.0
+	if result.Passed {
+		outcome, v = "passed", 1
+	}
+	healthChecks.WithLabelValues(rs.Namespace, rs.Name, outcome).Inc()
+	healthCheckDuration.WithLabelValues(rs.Namespace, rs.Name).Observe(result.Duration.Seconds())
+	healthCheckLastResult.WithLabelValues(rs.Namespace, rs.Name).Set(v)
+}
+
+// forgetHealthCheck removes the health probe metrics of the registered
+// service
+func forgetHealthCheck(rs v1alpha1.RegisteredService) {
+	labels := prometheus.Labels{"namespace": rs.Namespace, "name": rs.Name}
+	healthChecks.DeletePartialMatch(labels)
+	healthCheckDuration.DeletePartialMatch(labels)
+	healthCheckLastResult.DeletePartialMatch(labels)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+	"github.com/primaza/primaza/pkg/primaza/healthprobe"
+)
+
+// probed returns a HandleFunc that runs handleFunc, and then the health probe
+// of the registered service if it defines one
+func probed(handleFunc HandleFunc) HandleFunc {
+	return func(ctx context.Context, remote_client client.Client, rs v1alpha1.RegisteredService, secret *v1.Secret) []error {
+		if errs := handleFunc(ctx, remote_client, rs, secret); errs != nil {
+			return errs
+		}
+		if err := probeRegisteredService(ctx, remote_client, rs, secret); err != nil {
+			return []error{err}
+		}
+		return nil
+	}
+}
+
+// probeInterval returns the interval between two runs of the health probe of
+// the service class, or 0 if it does not define one
+func probeInterval(serviceClass v1alpha1.ServiceClass) time.Duration {
+	hc := serviceClass.Spec.HealthCheck
+	if hc == nil || hc.Probe() == nil {
+		return 0
+	}
+	return hc.RunInterval()
+}
+
+// probeRegisteredService runs the health probe of the registered service
+// against the host and port of its service endpoint definition, and updates
+// its state accordingly
+func probeRegisteredService(ctx context.Context, remote_client client.Client, rs v1alpha1.RegisteredService, secret *v1.Secret) error {
+	l := log.FromContext(ctx).WithValues("service", rs.Name, "namespace", rs.Namespace)
+	hc := rs.Spec.HealthCheck
+	if hc == nil || hc.Probe() == nil {
+		return nil
+	}
+
+	start := time.Now()
+	hostKey, portKey := hc.Probe().Keys()
+	host, err := sedValue(rs, secret, hostKey)
+	if err == nil {
+		var port string
+		if port, err = sedValue(rs, secret, portKey); err == nil {
+			probeCtx, cancel := context.WithTimeout(ctx, hc.RunDeadline())
+			defer cancel()
+			err = healthprobe.Run(probeCtx, *hc, host, port)
+		}
+	}
+
+	result := v1alpha1.HealthCheckResult{
+		Time:     metav1.NewTime(start),
+		Passed:   err == nil,
+		Duration: metav1.Duration{Duration: time.Since(start)},
+	}
+	if err != nil {
+		l.Info("Health probe failed", "error", err.Error())
+		result.Message = err.Error()
+	}
+	recordHealthCheck(rs, result)
+	return updateProbedState(ctx, remote_client, rs, result)
+}
+
+// sedValue returns the value of the given service endpoint definition item,
+// reading it from the secret if needed
+func sedValue(rs v1alpha1.RegisteredService, secret *v1.Secret, key string) (string, error) {
+	for _, i := range rs.Spec.ServiceEndpointDefinition {
+		if i.Name != key {
+			continue
+		}
+		if i.ValueFromSecret == nil {
+			return i.Value, nil
+		}
+		if secret != nil {
+			if v, ok := secret.StringData[i.ValueFromSecret.Key]; ok {
+				return v, nil
+			}
+			if v, ok := secret.Data[i.ValueFromSecret.Key]; ok {
+				return string(v), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("service endpoint definition has no %s item", key)
+}
+
+// updateProbedState records the health probe result in the registered
+// service's status.  The registered service is moved to the Unreachable state
+// when its health probe fails failureThreshold times in a row.  When the
+// probe passes successThreshold times in a row, the registered service goes
+// back to the state it had before becoming unreachable.
+func updateProbedState(ctx context.Context, remote_client client.Client, rs v1alpha1.RegisteredService, result v1alpha1.HealthCheckResult) error {
+	if err := remote_client.Get(ctx, client.ObjectKeyFromObject(&rs), &rs); err != nil {
+		return err
+	}
+
+	reason := constants.HealthCheckFailedReason
+	if result.Passed {
+		reason = constants.HealthCheckPassedReason
+	}
+
+	state := rs.Status.State
+	rs.Status.RecordHealthCheck(result, reason)
+	if rs.Spec.HealthCheck.ThresholdReached(rs.Status.HealthChecks) {
+		switch {
+		case !result.Passed:
+			state = v1alpha1.RegisteredServiceStateUnreachable
+		case rs.Status.State == v1alpha1.RegisteredServiceStateUnreachable:
+			state = stateBeforeUnreachable(rs.Status.Transitions)
+		}
+	}
+
+	if rs.Status.State != state {
+		log.FromContext(ctx).Info("Updating registered service state", "service", rs.Name, "state", state, "reason", reason)
+		rs.Status.State = state
+		rs.Status.Transitions = v1alpha1.RecordStateTransition(rs.Status.Transitions,
+			state, reason, constants.ServiceAgentDeploymentName)
+	}
+	rs.UpdateSummary()
+	return remote_client.Status().Update(ctx, &rs)
+}
+
+// stateBeforeUnreachable returns the state the registered service had before
+// becoming unreachable, if it was claimed, or Available otherwise.  The
+// service agent can not look up the claims, but the control plane moves
+// unreachable services back to Available when their claim is deleted.
+func stateBeforeUnreachable(transitions []v1alpha1.StateTransition) string {
+	for i := len(transitions) - 1; i > 0; i-- {
+		if transitions[i].State == v1alpha1.RegisteredServiceStateUnreachable {
+			if transitions[i-1].State == v1alpha1.RegisteredServiceStateClaimed {
+				return v1alpha1.RegisteredServiceStateClaimed
+			}
+			break
+		}
+	}
+	return v1alpha1.RegisteredServiceStateAvailable
+}
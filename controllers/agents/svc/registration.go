@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/atomic"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+// DefaultRegistrationWorkers is the default number of resources of a service
+// class the agent registers concurrently
+const DefaultRegistrationWorkers = 8
+
+// registrationBackoff is the backoff of the retries of a registered service's
+// handling failing with transient errors
+var registrationBackoff = retry.DefaultBackoff
+
+// registrationWorkers returns the number of resources of the service class
+// handled concurrently.  The registered services of exported service classes
+// are all written into the same bundle, so they are handled one at a time.
+func (r *ServiceClassReconciler) registrationWorkers(serviceClass v1alpha1.ServiceClass) int {
+	if serviceClass.Spec.Export != nil || r.RegistrationWorkers < 1 {
+		return 1
+	}
+	return r.RegistrationWorkers
+}
+
+// retried returns a HandleFunc that runs handleFunc again, with an
+// exponential backoff, while all the errors it returns are transient
+func retried(handleFunc HandleFunc) HandleFunc {
+	return func(ctx context.Context, remote_client client.Client, rs v1alpha1.RegisteredService, secret *v1.Secret) []error {
+		var errs []error
+		_ = retry.OnError(registrationBackoff, func(error) bool { return transient(errs) }, func() error {
+			errs = handleFunc(ctx, remote_client, rs, secret)
+			return errors.Join(errs...)
+		})
+		return errs
+	}
+}
+
+// transient returns whether errs is not empty and all its errors are
+// transient API errors, that the same request may not meet again
+func transient(errs []error) bool {
+	for _, err := range errs {
+		if !apierrors.IsConflict(err) &&
+			!apierrors.IsServerTimeout(err) &&
+			!apierrors.IsTimeout(err) &&
+			!apierrors.IsTooManyRequests(err) &&
+			!apierrors.IsServiceUnavailable(err) &&
+			!apierrors.IsInternalError(err) {
+			return false
+		}
+	}
+	return len(errs) > 0
+}
+
+// counted returns a HandleFunc that runs handleFunc, and counts in n the
+// registered services it handled successfully
+func counted(n *atomic.Int32, handleFunc HandleFunc) HandleFunc {
+	return func(ctx context.Context, remote_client client.Client, rs v1alpha1.RegisteredService, secret *v1.Secret) []error {
+		errs := handleFunc(ctx, remote_client, rs, secret)
+		if errs == nil {
+			n.Add(1)
+		}
+		return errs
+	}
+}
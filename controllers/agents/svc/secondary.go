@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/sed"
+)
+
+// secondaryMappings returns the mappings of the ServiceClass's secondary and
+// related resources, each evaluated on the resource of its kind joined with
+// obj.  If no resource is joined, the mappings are evaluated on an empty
+// object, so that only optional or defaulted mappings succeed.
+func secondaryMappings(ctx context.Context, cli client.Client, obj unstructured.Unstructured, serviceClass v1alpha1.ServiceClass) ([]sed.SEDMapping, error) {
+	secondaries := serviceClass.Spec.Resource.SecondaryResources()
+	if len(secondaries) == 0 {
+		return nil, nil
+	}
+
+	// the secondary resources of cluster-scoped resources are looked up in
+	// the service class' namespace
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		namespace = serviceClass.Namespace
+	}
+
+	mappings := []sed.SEDMapping{}
+	for _, secondary := range secondaries {
+		joined, err := joinSecondary(ctx, cli, obj, namespace, secondary)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mapping := range secondary.ResourceFields {
+			m, err := sed.NewSEDResourceMapping(joined, mapping)
+			if err != nil {
+				return nil, err
+			}
+			mappings = append(mappings, m)
+		}
+	}
+	return mappings, nil
+}
+
+// joinSecondary returns the secondary resource related to obj in the given
+// namespace
+func joinSecondary(ctx context.Context, cli client.Client, obj unstructured.Unstructured, namespace string, secondary v1alpha1.ServiceClassSecondaryResource) (unstructured.Unstructured, error) {
+	if secondary.Join.By == v1alpha1.ServiceClassJoinByJSONPath {
+		return joinSecondaryByReference(ctx, cli, obj, namespace, secondary)
+	}
+
+	list := unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.FromAPIVersionAndKind(secondary.APIVersion, secondary.Kind+"List"))
+	opts := []client.ListOption{client.InNamespace(namespace)}
+	if secondary.Join.By == v1alpha1.ServiceClassJoinByLabel {
+		opts = append(opts, client.MatchingLabels{secondary.Join.Label: obj.GetName()})
+	}
+	if err := cli.List(ctx, &list, opts...); err != nil {
+		return unstructured.Unstructured{}, err
+	}
+
+	var joined []unstructured.Unstructured
+	for _, item := range list.Items {
+		if secondary.Join.By == v1alpha1.ServiceClassJoinByLabel || ownedBy(item, obj) {
+			joined = append(joined, item)
+		}
+	}
+
+	switch len(joined) {
+	case 0:
+		return unstructured.Unstructured{Object: map[string]interface{}{}}, nil
+	case 1:
+		return joined[0], nil
+	default:
+		return unstructured.Unstructured{}, fmt.Errorf("%d %s resources are related to %s, expected at most one",
+			len(joined), secondary.Kind, obj.GetName())
+	}
+}
+
+// joinSecondaryByReference returns the secondary resource whose name is read
+// from obj at the join's JSONPath.  An empty object is returned if obj does
+// not refer to any resource, or if the referred one does not exist.
+func joinSecondaryByReference(ctx context.Context, cli client.Client, obj unstructured.Unstructured, namespace string, secondary v1alpha1.ServiceClassSecondaryResource) (unstructured.Unstructured, error) {
+	empty := unstructured.Unstructured{Object: map[string]interface{}{}}
+
+	path := jsonpath.New("").AllowMissingKeys(true)
+	if err := path.Parse(fmt.Sprintf("{%s}", secondary.Join.JsonPath)); err != nil {
+		return unstructured.Unstructured{}, err
+	}
+	results, err := path.FindResults(obj.Object)
+	if err != nil {
+		return unstructured.Unstructured{}, err
+	}
+	var names []string
+	for _, r := range results {
+		for _, v := range r {
+			names = append(names, fmt.Sprintf("%v", v.Interface()))
+		}
+	}
+	switch {
+	case len(names) == 0 || names[0] == "":
+		return empty, nil
+	case len(names) > 1:
+		return unstructured.Unstructured{}, fmt.Errorf("%s of %s refers to %d %s resources, expected at most one",
+			secondary.Join.JsonPath, obj.GetName(), len(names), secondary.Kind)
+	}
+
+	joined := unstructured.Unstructured{}
+	joined.SetGroupVersionKind(schema.FromAPIVersionAndKind(secondary.APIVersion, secondary.Kind))
+	if err := cli.Get(ctx, types.NamespacedName{Namespace: namespace, Name: names[0]}, &joined); err != nil {
+		if apierrors.IsNotFound(err) {
+			return empty, nil
+		}
+		return unstructured.Unstructured{}, err
+	}
+	return joined, nil
+}
+
+// ownedBy tells whether obj is owned by owner
+func ownedBy(obj, owner unstructured.Unstructured) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == owner.GetUID() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+	"github.com/primaza/primaza/pkg/primaza/sed"
+)
+
+// secretsRoleName returns the name of the Role granting read access to the
+// secrets referenced by the services of a ServiceClass
+func secretsRoleName(serviceClass v1alpha1.ServiceClass) string {
+	return fmt.Sprintf("primaza:svc:secrets:%s", serviceClass.Name)
+}
+
+// ReferencedSecrets returns the sorted names of the secrets referred to by the
+// secretRefFields mappings of the given ServiceClass for the given services
+func (r *ServiceClassReconciler) ReferencedSecrets(ctx context.Context, serviceClass v1alpha1.ServiceClass, services unstructured.UnstructuredList) ([]string, error) {
+	names := map[string]struct{}{}
+	for _, data := range services.Items {
+		mappings, err := ServiceEndpointDefinitionMapping(ctx, r.Client, data, serviceClass)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range mappings {
+			sm, ok := m.(*sed.SEDSecretRefMapping)
+			if !ok {
+				continue
+			}
+			n, err := sm.SecretName()
+			if err != nil {
+				// the secret name can not be resolved yet
+				continue
+			}
+			names[*n] = struct{}{}
+		}
+	}
+
+	return sortedNames(names), nil
+}
+
+// sortedNames returns the sorted names of the given set
+func sortedNames(names map[string]struct{}) []string {
+	sorted := make([]string, 0, len(names))
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// ReconcileSecretsRole maintains a Role, bound to the service agent, that grants
+// read access only to the secrets referenced by the ServiceClass's services,
+// and write access to the local secrets pushed to External Secrets Operator
+// stores, if any.  Role and RoleBinding are owned by the ServiceClass and are
+// deleted if no secret is referenced.
+func (r *ServiceClassReconciler) ReconcileSecretsRole(ctx context.Context, serviceClass *v1alpha1.ServiceClass, services unstructured.UnstructuredList) error {
+	secrets, err := r.ReferencedSecrets(ctx, *serviceClass, services)
+	if err != nil {
+		return err
+	}
+
+	var rules []rbacv1.PolicyRule
+	if len(secrets) > 0 {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			Verbs:         []string{"get"},
+			ResourceNames: secrets,
+		})
+	}
+	if serviceClass.Spec.ExternalSecrets != nil && len(services.Items) > 0 {
+		pushed := make(map[string]struct{}, len(services.Items))
+		for _, data := range services.Items {
+			pushed[descriptorSecretName(RegisteredServiceName(*serviceClass, data))] = struct{}{}
+		}
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			Verbs:         []string{"get", "update"},
+			ResourceNames: sortedNames(pushed),
+		})
+	}
+	return r.reconcileRole(ctx, serviceClass, secretsRoleName(*serviceClass), rules)
+}
+
+// reconcileRole maintains the named Role granting rules to the service agent,
+// and the RoleBinding to its ServiceAccount.  Both are owned by the
+// ServiceClass, and are deleted if there is no rule.
+func (r *ServiceClassReconciler) reconcileRole(ctx context.Context, serviceClass *v1alpha1.ServiceClass, name string, rules []rbacv1.PolicyRule) error {
+	l := log.FromContext(ctx)
+
+	om := metav1.ObjectMeta{Name: name, Namespace: serviceClass.Namespace}
+	role := &rbacv1.Role{ObjectMeta: om}
+	binding := &rbacv1.RoleBinding{ObjectMeta: om}
+	if len(rules) == 0 {
+		for _, o := range []client.Object{binding, role} {
+			if err := r.Delete(ctx, o); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, role, func() error {
+		role.Rules = rules
+		return controllerutil.SetOwnerReference(serviceClass, role, r.Scheme())
+	})
+	if err != nil {
+		return err
+	}
+	l.Info("wrote role", "role", role.Name, "operation", op)
+
+	op, err = controllerutil.CreateOrUpdate(ctx, r.Client, binding, func() error {
+		binding.RoleRef = rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     role.Name,
+		}
+		binding.Subjects = []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      constants.ServiceAgentServiceAccountName,
+				Namespace: serviceClass.Namespace,
+			},
+		}
+		return controllerutil.SetOwnerReference(serviceClass, binding, r.Scheme())
+	})
+	if err != nil {
+		return err
+	}
+	l.Info("wrote role binding", "role binding", binding.Name, "operation", op)
+
+	return nil
+}
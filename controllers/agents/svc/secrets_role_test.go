@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+func newDatabase(name string, spec map[string]interface{}) unstructured.Unstructured {
+	u := unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	u.SetAPIVersion("example.com/v1")
+	u.SetKind("Database")
+	u.SetName(name)
+	u.SetNamespace("services")
+	return u
+}
+
+func TestReconcileSecretsRole(t *testing.T) {
+	serviceClass := &v1alpha1.ServiceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "databases", Namespace: "services", UID: "sc-uid"},
+		Spec: v1alpha1.ServiceClassSpec{
+			Resource: v1alpha1.ServiceClassResource{
+				APIVersion: "example.com/v1",
+				Kind:       "Database",
+				ServiceEndpointDefinitionMappings: v1alpha1.ServiceEndpointDefinitionMappings{
+					ResourceFields: []v1alpha1.ServiceClassResourceFieldMapping{
+						{Name: "host", JsonPath: ".spec.host"},
+					},
+					SecretRefFields: []v1alpha1.ServiceClassSecretRefFieldMapping{
+						{Name: "password", SecretName: ".spec.secretName", SecretKey: ".spec.secretKey"},
+					},
+				},
+			},
+		},
+	}
+	cli := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(serviceClass).Build()
+	r := &ServiceClassReconciler{Client: cli}
+	ctx := context.Background()
+
+	services := unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		newDatabase("orders", map[string]interface{}{"host": "orders", "secretName": "orders-credentials", "secretKey": "password"}),
+		newDatabase("billing", map[string]interface{}{"host": "billing", "secretName": "billing-credentials", "secretKey": "password"}),
+		newDatabase("billing-replica", map[string]interface{}{"host": "replica", "secretName": "billing-credentials", "secretKey": "password"}),
+		// the secret name of this one is not known yet
+		newDatabase("pending", map[string]interface{}{"host": "pending"}),
+	}}
+
+	secrets, err := r.ReferencedSecrets(ctx, *serviceClass, services)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"billing-credentials", "orders-credentials"}; !reflect.DeepEqual(secrets, want) {
+		t.Errorf("ReferencedSecrets() = %v, want %v", secrets, want)
+	}
+
+	if err := r.ReconcileSecretsRole(ctx, serviceClass, services); err != nil {
+		t.Fatal(err)
+	}
+
+	key := client.ObjectKey{Namespace: "services", Name: "primaza:svc:secrets:databases"}
+	role := &rbacv1.Role{}
+	if err := cli.Get(ctx, key, role); err != nil {
+		t.Fatal(err)
+	}
+	want := []rbacv1.PolicyRule{{
+		APIGroups:     []string{""},
+		Resources:     []string{"secrets"},
+		Verbs:         []string{"get"},
+		ResourceNames: []string{"billing-credentials", "orders-credentials"},
+	}}
+	if !reflect.DeepEqual(role.Rules, want) {
+		t.Errorf("expected the role to grant %v, got %v", want, role.Rules)
+	}
+	binding := &rbacv1.RoleBinding{}
+	if err := cli.Get(ctx, key, binding); err != nil {
+		t.Fatal(err)
+	}
+	if binding.RoleRef.Name != role.Name || len(binding.Subjects) != 1 ||
+		binding.Subjects[0].Kind != rbacv1.ServiceAccountKind || binding.Subjects[0].Name != "primaza-svc-agent" {
+		t.Errorf("expected the role to be bound to the service agent, got %v and %v", binding.RoleRef, binding.Subjects)
+	}
+	for _, o := range []metav1.Object{role, binding} {
+		if refs := o.GetOwnerReferences(); len(refs) != 1 || refs[0].UID != serviceClass.UID {
+			t.Errorf("expected %s to be owned by the service class, got %v", o.GetName(), refs)
+		}
+	}
+
+	// the role is deleted once no secret is referenced any more
+	services.Items = services.Items[3:]
+	if err := r.ReconcileSecretsRole(ctx, serviceClass, services); err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range []client.Object{&rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+		if err := cli.Get(ctx, key, o); !apierrors.IsNotFound(err) {
+			t.Errorf("expected %T to be deleted, got %v", o, err)
+		}
+	}
+}
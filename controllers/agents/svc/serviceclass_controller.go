@@ -18,9 +18,13 @@ package svc
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/atomic"
@@ -36,41 +40,110 @@ import (
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/audit"
+	"github.com/primaza/primaza/pkg/primaza/backpressure"
+	"github.com/primaza/primaza/pkg/primaza/clustercontext"
 	"github.com/primaza/primaza/pkg/primaza/constants"
+	primazaerrors "github.com/primaza/primaza/pkg/primaza/errors"
+	"github.com/primaza/primaza/pkg/primaza/remotewriter"
 	"github.com/primaza/primaza/pkg/primaza/sed"
 	"github.com/primaza/primaza/pkg/primaza/workercluster"
+	wauthz "github.com/primaza/primaza/pkg/primaza/workercluster/authz"
 )
 
-const finalizer = "serviceclasses.primaza.io/finalizer"
+const (
+	finalizer = "serviceclasses.primaza.io/finalizer"
+	// registeredServiceKind is the kind of the registered services the agent
+	// writes, as reported in the audit trail
+	registeredServiceKind = "RegisteredService"
+)
+
+// nonRetryableRequeueAfter is the delay after which a service class whose
+// reconciliation failed with a non-retryable error is checked again
+const nonRetryableRequeueAfter = 5 * time.Minute
 
 // ServiceClassReconciler reconciles a ServiceClass object
 type ServiceClassReconciler struct {
 	client.Client
 	dynamic.Interface
+	Recorder  record.EventRecorder
+	audit     *audit.Trail
 	informers map[string]informer
+	// informersMu guards informers, as service classes may be reconciled
+	// concurrently
+	informersMu sync.Mutex
+
+	// RegistrationWorkers is the number of resources of a service class
+	// handled concurrently
+	RegistrationWorkers int
+	// ListPageSize is the number of resources listed per request, 0 lists
+	// them all at once
+	ListPageSize int
+	// ListFromCache lists the resources from the API server's watch cache
+	ListFromCache bool
+	// ControlPlaneLimiter throttles the writes to the control plane, nil
+	// disables throttling
+	ControlPlaneLimiter flowcontrol.RateLimiter
 }
 
 type informer struct {
 	informer   cache.SharedIndexInformer
 	ctx        context.Context
 	cancelFunc context.CancelFunc
+	// selector is the label selector the informer filters resources with
+	selector string
+	// resync is the period the informer resyncs the resources at
+	resync time.Duration
 }
 
 func (i *informer) run() {
 	i.informer.Run(i.ctx.Done())
 }
 
+// getInformer returns the informer of the named service class, if any
+func (r *ServiceClassReconciler) getInformer(name string) (informer, bool) {
+	r.informersMu.Lock()
+	defer r.informersMu.Unlock()
+	i, ok := r.informers[name]
+	return i, ok
+}
+
+// setInformer records the informer of the named service class
+func (r *ServiceClassReconciler) setInformer(name string, i informer) {
+	r.informersMu.Lock()
+	defer r.informersMu.Unlock()
+	r.informers[name] = i
+}
+
+// stopInformer stops the informer of the named service class, if any
+func (r *ServiceClassReconciler) stopInformer(name string) {
+	r.informersMu.Lock()
+	defer r.informersMu.Unlock()
+	if i, ok := r.informers[name]; ok {
+		i.cancelFunc()
+		delete(r.informers, name)
+	}
+}
+
 func NewServiceClassReconciler(mgr ctrl.Manager) *ServiceClassReconciler {
+	recorder := mgr.GetEventRecorderFor(constants.ServiceAgentDeploymentName)
 	return &ServiceClassReconciler{
 		Client:    mgr.GetClient(),
 		Interface: dynamic.NewForConfigOrDie(mgr.GetConfig()),
+		Recorder:  recorder,
+		audit:     audit.NewTrail(recorder, constants.ServiceAgentDeploymentName),
 		informers: make(map[string]informer, 0),
+
+		RegistrationWorkers: DefaultRegistrationWorkers,
+		ListPageSize:        DefaultListPageSize,
 	}
 }
 
@@ -123,17 +196,18 @@ func (r *ServiceClassReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	if err = r.SetWatchersForResources(ctx, serviceClass); err != nil {
 		reconcileLog.Error(err, "Failed to set watchers on ServiceClass resources ", "namespace", req.Namespace, "name", req.Name)
-		return ctrl.Result{}, err
+		return retryResult(err)
 	}
 
 	// next, get all the services that this service class controls
 	services, err := r.GetResources(ctx, &serviceClass)
 	if err != nil {
 		reconcileLog.Error(err, "Failed to retrieve resources")
-		return ctrl.Result{}, err
+		return retryResult(err)
 	}
 	// then, write all the registered services up to the primaza cluster
 	errs := []error{}
+	var requeueAfter time.Duration
 	if serviceClass.DeletionTimestamp.IsZero() && controller.DeletionTimestamp.IsZero() {
 		// add a finalizer since we have deletion logic
 		if controllerutil.AddFinalizer(&serviceClass, finalizer) {
@@ -142,21 +216,28 @@ func (r *ServiceClassReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			}
 		}
 
-		err = r.HandleRegisteredServices(ctx, &serviceClass, *services, updateRegisteredService)
-		if err != nil {
-			reconcileLog.Error(err, "Failed to write registered services")
-			// fallthrough: we still want to write the service class status field
-			errs = append(errs, err)
+		// the secrets referenced by the services, and the export bundle,
+		// need to be accessible before the services are registered
+		roleErr := errors.Join(r.ReconcileSecretsRole(ctx, &serviceClass, *services), r.ReconcileExportRole(ctx, &serviceClass))
+		if roleErr != nil {
+			reconcileLog.Error(roleErr, "Failed to reconcile roles")
+		}
+
+		var delayed bool
+		requeueAfter, delayed, errs = r.registerServices(ctx, &serviceClass, *services)
+		if delayed {
+			return ctrl.Result{RequeueAfter: requeueAfter}, nil
+		}
+		if roleErr != nil {
+			errs = append(errs, roleErr)
 		}
 	} else if controllerutil.ContainsFinalizer(&serviceClass, finalizer) {
+		forgetDiscoveredResources(serviceClass)
 		// need to stop the informers if the service class is deleted
-		if i, ok := r.informers[serviceClass.Name]; ok {
-			i.cancelFunc()
-			delete(r.informers, serviceClass.Name)
-		}
+		r.stopInformer(serviceClass.Name)
 
 		// act on the registered service
-		err = r.HandleRegisteredServices(ctx, &serviceClass, *services, deleteRegisteredService)
+		err = r.HandleRegisteredServices(ctx, &serviceClass, *services, r.registeredServiceDeleter(serviceClass))
 		if err != nil {
 			reconcileLog.Error(err, "Failed to delete registered services")
 			errs = append(errs, err)
@@ -174,150 +255,432 @@ func (r *ServiceClassReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	}
 
 	// finally, write the status of the service class
+	serviceClass.UpdateSummary()
 	err = r.Client.Status().Update(ctx, &serviceClass)
 	if err != nil {
 		reconcileLog.Error(err, "Failed to write service class status")
 		errs = append(errs, err)
 	}
-	return ctrl.Result{}, errors.Join(errs...)
+	return ctrl.Result{RequeueAfter: requeueAfter}, errors.Join(errs...)
 }
 
-func updateRegisteredService(ctx context.Context, remote_client client.Client, rs v1alpha1.RegisteredService, secret *v1.Secret) []error {
-	spec := rs.Spec
-	reconcileLog := log.FromContext(ctx).WithValues("namespace", rs.Namespace, "name", rs.Name)
-	op, err := controllerutil.CreateOrUpdate(ctx, remote_client, &rs, func() error {
-		rs.Spec = spec
-		return nil
-	})
-	if err != nil {
-		reconcileLog.Error(err, "Failed to create registered service", "service", rs.Name, "namespace", rs.Namespace)
-	} else {
+// registeredServiceWriter returns a HandleFunc that writes the registered
+// services of the given service class
+func (r *ServiceClassReconciler) registeredServiceWriter(serviceClass v1alpha1.ServiceClass) HandleFunc {
+	return func(ctx context.Context, remote_client client.Client, rs v1alpha1.RegisteredService, secret *v1.Secret) []error {
+		reconcileLog := log.FromContext(ctx).WithValues("namespace", rs.Namespace, "name", rs.Name)
+		op, err := r.writeRegisteredService(ctx, serviceClass, remote_client, rs, secret)
+		recordRegisteredServiceWrite(op, err)
+		r.writeEvent(serviceClass, rs, op, err)
+		if err != nil {
+			reconcileLog.Error(err, "Failed to create registered service", "service", rs.Name, "namespace", rs.Namespace)
+			return []error{err}
+		}
 		reconcileLog.Info("Wrote registered service", "service", rs.Name, "namespace", rs.Namespace, "operation", op)
+		return nil
 	}
-	errs := []error{err}
-	if secret != nil {
-		data := secret.StringData
-		_, err := controllerutil.CreateOrUpdate(ctx, remote_client, secret, func() error {
-			secret.StringData = data
-			return controllerutil.SetOwnerReference(&rs, secret, remote_client.Scheme())
-		})
-		errs = append(errs, err)
+}
+
+// writeEvent records on the service class an event for the creation of the
+// registered service, or for the failure to write it.  Creations and updates
+// are also recorded in the audit trail.
+func (r *ServiceClassReconciler) writeEvent(serviceClass v1alpha1.ServiceClass, rs v1alpha1.RegisteredService, op controllerutil.OperationResult, err error) {
+	switch {
+	case err != nil:
+		r.Recorder.Eventf(&serviceClass, v1.EventTypeWarning, constants.RegisteredServiceWriteFailedReason,
+			"Failed to write registered service %s: %s", rs.Name, err)
+	case op == controllerutil.OperationResultCreated:
+		r.Recorder.Eventf(&serviceClass, v1.EventTypeNormal, constants.RegisteredServiceCreatedReason,
+			"Created registered service %s", rs.Name)
+		r.audit.Record(&serviceClass, audit.ActionCreate, registeredServiceKind, &rs)
+	case op == controllerutil.OperationResultUpdated:
+		r.audit.Record(&serviceClass, audit.ActionUpdate, registeredServiceKind, &rs)
 	}
-	return errs
 }
 
-func deleteRegisteredService(ctx context.Context, remote_client client.Client, rs v1alpha1.RegisteredService, secret *v1.Secret) []error {
-	reconcileLog := log.FromContext(ctx).WithValues("namespace", rs.Namespace, "name", rs.Name)
-	if err := remote_client.Delete(ctx, &rs); err != nil {
-		if apierrors.IsNotFound(err) {
-			// we tried to delete an object that doesn't exist, so
+// writeRegisteredService applies the registered service and its secret, so
+// that the registered service never refers to a missing secret.
+// The secret's values flow through External Secrets Operator stores if the
+// service class requires it, and both are rendered into the service class'
+// export bundle instead if it is exported.
+func (r *ServiceClassReconciler) writeRegisteredService(ctx context.Context, serviceClass v1alpha1.ServiceClass, remote_client client.Client, rs v1alpha1.RegisteredService, secret *v1.Secret) (controllerutil.OperationResult, error) {
+	if serviceClass.Spec.Export != nil {
+		return r.exportRegisteredService(ctx, serviceClass, rs, secret)
+	}
+	if serviceClass.Spec.ExternalSecrets != nil && secret != nil && len(secret.StringData)+len(secret.Data) > 0 {
+		return r.writeRegisteredServiceWithExternalSecret(ctx, serviceClass, remote_client, rs, *secret)
+	}
+	return remotewriter.ApplyWithSecret(ctx, remote_client, constants.AgentFieldManager, &rs, secret)
+}
+
+// registeredServiceDeleter returns a HandleFunc that deletes the registered
+// services of the given service class
+func (r *ServiceClassReconciler) registeredServiceDeleter(serviceClass v1alpha1.ServiceClass) HandleFunc {
+	return func(ctx context.Context, remote_client client.Client, rs v1alpha1.RegisteredService, secret *v1.Secret) []error {
+		reconcileLog := log.FromContext(ctx).WithValues("namespace", rs.Namespace, "name", rs.Name)
+		forgetHealthCheck(rs)
+		if serviceClass.Spec.Export != nil {
+			exported, err := r.unexportRegisteredService(ctx, serviceClass, rs.Name)
+			if err != nil {
+				reconcileLog.Error(err, "Failed to remove registered service from the export bundle", "bundle", serviceClass.Spec.Export.ConfigMap)
+				return []error{err}
+			}
+			if exported {
+				r.audit.Record(&serviceClass, audit.ActionDelete, registeredServiceKind, &rs)
+			}
 			return nil
 		}
-		reconcileLog.Error(err, "Failed to delete registered service", "namespace", rs.Namespace)
-		return []error{err}
+		if err := remote_client.Delete(ctx, &rs); err != nil {
+			if apierrors.IsNotFound(err) {
+				// we tried to delete an object that doesn't exist, so
+				return nil
+			}
+			reconcileLog.Error(err, "Failed to delete registered service", "namespace", rs.Namespace)
+			return []error{err}
+		}
+		r.audit.Record(&serviceClass, audit.ActionDelete, registeredServiceKind, &rs)
+
+		// we don't need to delete the secret, since the secret had the registered
+		// service set as an owner
+		return nil
 	}
+}
 
-	// we don't need to delete the secret, since the secret had the registered
-	// service set as an owner
-	return nil
+// restMapping returns the REST mapping of the service class' resource type.
+// Errors are classified, so that a kind that is not served by the cluster, or
+// not with the scope of the service class' resource, is reported as
+// ErrNotMapped.
+func (r *ServiceClassReconciler) restMapping(resource v1alpha1.ServiceClassResource) (*meta.RESTMapping, error) {
+	gvk := schema.FromAPIVersionAndKind(resource.APIVersion, resource.Kind)
+	op := fmt.Sprintf("map %s", gvk)
+	mapping, err := r.Client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, primazaerrors.Classify(op, err)
+	}
+	if clusterScoped := mapping.Scope.Name() == meta.RESTScopeNameRoot; clusterScoped != resource.ClusterScoped() {
+		return nil, primazaerrors.NotMapped(op, fmt.Errorf("%s is %s-scoped, set the ServiceClass resource's scope accordingly", gvk.Kind, mapping.Scope.Name()))
+	}
+	return mapping, nil
 }
 
-func (r *ServiceClassReconciler) GetResources(ctx context.Context, serviceClass *v1alpha1.ServiceClass) (*unstructured.UnstructuredList, error) {
-	typemeta := metav1.TypeMeta{
-		Kind:       serviceClass.Spec.Resource.Kind,
-		APIVersion: serviceClass.Spec.Resource.APIVersion,
+// resourceNamespace returns the namespace the service class' resources are
+// looked up in, all namespaces for cluster-scoped resources
+func resourceNamespace(serviceClass v1alpha1.ServiceClass) string {
+	if serviceClass.Spec.Resource.ClusterScoped() {
+		return metav1.NamespaceAll
 	}
-	gvk := typemeta.GroupVersionKind()
-	mapping, err := r.Client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	return serviceClass.Namespace
+}
+
+// RegisteredServiceName returns the name of the registered service of a
+// service class' resource.  Cluster-scoped resources are prefixed with their
+// kind, so that they don't conflict with the namespaced ones.
+func RegisteredServiceName(serviceClass v1alpha1.ServiceClass, data unstructured.Unstructured) string {
+	if serviceClass.Spec.Resource.ClusterScoped() {
+		return fmt.Sprintf("%s-%s", strings.ToLower(serviceClass.Spec.Resource.Kind), data.GetName())
+	}
+	// FIXME(sadlerap): this could cause naming conflicts; we need
+	// to take into account the type of resource somehow.
+	return data.GetName()
+}
+
+// retryResult returns the result for a reconciliation that failed with err.
+// Errors that will not resolve by themselves, e.g. a resource type that is
+// not installed yet, are not returned so that they don't trigger the
+// exponential backoff; the service class is instead checked again later.
+func retryResult(err error) (ctrl.Result, error) {
+	if !primazaerrors.Retryable(err) {
+		return ctrl.Result{RequeueAfter: nonRetryableRequeueAfter}, nil
+	}
+	return ctrl.Result{}, err
+}
+
+func (r *ServiceClassReconciler) GetResources(ctx context.Context, serviceClass *v1alpha1.ServiceClass) (*unstructured.UnstructuredList, error) {
+	mapping, err := r.restMapping(serviceClass.Spec.Resource)
 	if err != nil {
 		return nil, err
 	}
 
-	services, err := r.Interface.Resource(mapping.Resource).
-		Namespace(serviceClass.Namespace).
-		List(ctx, metav1.ListOptions{})
-
-	if err != nil || services == nil {
+	selector, err := resourceSelector(*serviceClass)
+	if err != nil {
+		return nil, err
+	}
+	services, err := r.listResources(ctx, r.Interface.Resource(mapping.Resource).Namespace(resourceNamespace(*serviceClass)), selector)
+	if err != nil {
 		return nil, err
 	}
 
+	filterOwned(*serviceClass, services)
+	if serviceClass.Spec.Resource.Helm != nil {
+		services.Items = helmReleases(ctx, *serviceClass, services.Items)
+	}
 	return services, nil
 }
 
+// resourceSelector returns the label selector restricting the resources
+// managed by the ServiceClass, as a string
+func resourceSelector(serviceClass v1alpha1.ServiceClass) (string, error) {
+	ls := serviceClass.Spec.Resource.Selector
+	if serviceClass.Spec.Resource.Helm != nil {
+		ls = helmReleaseSelector(ls)
+	}
+	if ls == nil {
+		return "", nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(ls)
+	if err != nil {
+		return "", err
+	}
+	return selector.String(), nil
+}
+
+// registerServices writes the registered services of the service class to
+// the control plane, or previews them if the service class is paused.  It
+// returns when the service class should be reconciled again, and whether the
+// writes have been delayed because the control plane is overloaded.
+func (r *ServiceClassReconciler) registerServices(ctx context.Context, serviceClass *v1alpha1.ServiceClass, services unstructured.UnstructuredList) (time.Duration, bool, []error) {
+	reconcileLog := log.FromContext(ctx)
+	recordDiscoveredResources(*serviceClass, len(services.Items))
+
+	// an overloaded control plane asks agents to slow down, requeueing
+	// coalesces the events received in the meantime
+	if delay := r.backPressure(ctx, *serviceClass); delay > 0 {
+		reconcileLog.Info("Control plane is overloaded, delaying registered services' writes", "delay", delay)
+		return backpressure.Jitter(delay), true, nil
+	}
+
+	policy := serviceClass.Spec.ManualEditPolicy
+	edits := &manualEdits{}
+	if serviceClass.Spec.Paused {
+		reconcileLog.Info("Service class is paused, previewing registered services")
+		serviceClass.Status.Preview = r.Preview(ctx, *serviceClass, services)
+		return syncInterval(*serviceClass), false, nil
+	}
+
+	if err := r.HandleRegisteredServices(ctx, serviceClass, services, edits.detect); err != nil {
+		reconcileLog.Error(err, "Failed to detect manual edits of registered services")
+		// we still want to write the service class status field
+		return 0, false, []error{err}
+	}
+
+	var errs []error
+	var registered atomic.Int32
+	serviceClass.Status.Preview = nil
+	meta.SetStatusCondition(&serviceClass.Status.Conditions, edits.condition(policy))
+	if err := r.HandleRegisteredServices(ctx, serviceClass, services, probed(edits.guard(policy, counted(&registered, r.registeredServiceWriter(*serviceClass))))); err != nil {
+		reconcileLog.Error(err, "Failed to write registered services")
+		// we still want to write the service class status field
+		errs = append(errs, err)
+	}
+	serviceClass.Status.Registration = &v1alpha1.ServiceClassRegistration{
+		Registered: int(registered.Load()),
+		Total:      len(services.Items),
+	}
+	// health probes are run at each reconciliation
+	return syncInterval(*serviceClass), false, errs
+}
+
+// syncInterval returns when the resources of the service class are fully
+// synchronized again: after the service class' sync interval, or sooner to
+// run its health probes
+func syncInterval(serviceClass v1alpha1.ServiceClass) time.Duration {
+	interval := serviceClass.Spec.SyncPeriod()
+	if p := probeInterval(serviceClass); p > 0 && p < interval {
+		return p
+	}
+	return interval
+}
+
+// primazaKubeconfig returns the configuration to connect to the control
+// plane, and the control plane namespace.  The service namespace holds the
+// kubeconfig of a single tenant, so the service classes of other tenants are
+// refused rather than written to the wrong control plane.
+func (r *ServiceClassReconciler) primazaKubeconfig(ctx context.Context, serviceClass v1alpha1.ServiceClass) (*rest.Config, string, error) {
+	config, remote_namespace, err := workercluster.GetPrimazaKubeconfig(ctx, serviceClass.Namespace, r.Client, constants.ServiceAgentKubeconfigSecretName)
+	if err != nil {
+		return nil, "", err
+	}
+	if t, ok := serviceClass.Labels[constants.PrimazaTenantLabel]; ok && t != remote_namespace {
+		return nil, "", fmt.Errorf("service class %s/%s belongs to tenant %s, but namespace %s is served by tenant %s", serviceClass.Namespace, serviceClass.Name, t, serviceClass.Namespace, remote_namespace)
+	}
+	return config, remote_namespace, nil
+}
+
+// backPressure returns the delay the control plane asks agents to wait
+// before writing to it.  Errors are only logged, as they are reported when
+// writing registered services.
+func (r *ServiceClassReconciler) backPressure(ctx context.Context, serviceClass v1alpha1.ServiceClass) time.Duration {
+	l := log.FromContext(ctx)
+	config, remote_namespace, err := r.primazaKubeconfig(ctx, serviceClass)
+	if err != nil {
+		return 0
+	}
+
+	remote_client, err := r.remoteClient(config)
+	if err != nil {
+		return 0
+	}
+
+	delay, err := backpressure.Delay(ctx, remote_client, remote_namespace)
+	if err != nil {
+		l.Info("Unable to read control plane back-pressure", "error", err.Error())
+	}
+	return delay
+}
+
+// checkCredentialsExpiry sets the CredentialsExpiring condition, and records
+// an Event when the credentials used to connect to the control plane are
+// found to be expiring or expired
+func (r *ServiceClassReconciler) checkCredentialsExpiry(ctx context.Context, serviceClass *v1alpha1.ServiceClass, config *rest.Config) {
+	expiry, err := clustercontext.CredentialsExpiry(config)
+	if err != nil {
+		log.FromContext(ctx).Info("Unable to check the expiry of the control plane credentials", "error", err.Error())
+		return
+	}
+
+	c := clustercontext.CredentialsExpiryCondition(v1alpha1.ServiceClassConditionCredentialsExpiring, expiry, time.Now())
+	prev := meta.FindStatusCondition(serviceClass.Status.Conditions, c.Type)
+	if c.Status == metav1.ConditionTrue && (prev == nil || prev.Reason != c.Reason) {
+		r.Recorder.Event(serviceClass, v1.EventTypeWarning, c.Reason, c.Message)
+	}
+	meta.SetStatusCondition(&serviceClass.Status.Conditions, c)
+}
+
 type HandleFunc func(context.Context, client.Client, v1alpha1.RegisteredService, *v1.Secret) []error
 
 func (r *ServiceClassReconciler) HandleRegisteredServices(ctx context.Context, serviceClass *v1alpha1.ServiceClass, services unstructured.UnstructuredList, handleFunc HandleFunc) error {
 	l := log.FromContext(ctx)
 	var err error
 
-	config, remote_namespace, err := workercluster.GetPrimazaKubeconfig(ctx, serviceClass.Namespace, r.Client, constants.ServiceAgentKubeconfigSecretName)
+	config, remote_namespace, err := r.primazaKubeconfig(ctx, *serviceClass)
 	if err != nil {
 		return err
 	}
 	l.Info("remote cluster", "address", config.Host)
+	r.checkCredentialsExpiry(ctx, serviceClass, config)
 
 	// TODO(sadlerap): move TestConnection from `workercluster` into a more
 	// general-purpose package
-	status := workercluster.TestConnection(ctx, config)
-	state := metav1.ConditionUnknown
+	status := workercluster.TestConnection(ctx, config, remote_namespace, wauthz.GetAgentSvcReporterPermissions()...)
+	state := metav1.ConditionFalse
 	if status.State == v1alpha1.ClusterEnvironmentStateOnline {
 		state = metav1.ConditionTrue
-	} else if status.State == v1alpha1.ClusterEnvironmentStateOffline {
-		state = metav1.ConditionFalse
 	}
 	meta.SetStatusCondition(&serviceClass.Status.Conditions, metav1.Condition{
-		Type:    "Connection",
+		Type:    v1alpha1.ServiceClassConditionConnection,
 		Message: status.Message,
 		Reason:  string(status.Reason),
 		Status:  state,
 	})
-	if status.State == v1alpha1.ClusterEnvironmentStateOffline {
-		return fmt.Errorf("Failed to connect to cluster")
+	recordConnectionState(serviceClass.Namespace, status.State)
+	if status.State != v1alpha1.ClusterEnvironmentStateOnline {
+		r.Recorder.Eventf(serviceClass, v1.EventTypeWarning, constants.RemoteConnectionFailedReason,
+			"Failed to connect to the control plane: %s", status.Message)
+		return status.Err
 	}
 
-	remote_client, err := client.New(config, client.Options{
-		Scheme: r.Client.Scheme(),
-		Mapper: r.Client.RESTMapper(),
-	})
+	remote_client, err := r.remoteClient(config)
 	if err != nil {
 		return err
 	}
 
-	var errorList []error
+	// the resources are handled by a bounded pool of workers.  A mapping
+	// that can not be read aborts the handling of the remaining ones.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		mu        sync.Mutex
+		errorList []error
+		aborted   error
+		wg        sync.WaitGroup
+	)
+	items := make(chan unstructured.Unstructured)
+	for i := 0; i < r.registrationWorkers(*serviceClass); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for data := range items {
+				errs, abort := r.handleRegisteredService(ctx, *serviceClass, remote_client, remote_namespace, data, handleFunc)
+				mu.Lock()
+				errorList = append(errorList, errs...)
+				if abort != nil && aborted == nil {
+					aborted = abort
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+send:
 	for _, data := range services.Items {
-		var mappings []sed.SEDMapping
-		if mappings, err = ServiceEndpointDefinitionMapping(r.Client, data, *serviceClass); err != nil {
-			return err
+		select {
+		case items <- data:
+		case <-ctx.Done():
+			break send
 		}
+	}
+	close(items)
+	wg.Wait()
 
-		var rs v1alpha1.RegisteredService
-		var err error
-		var secret *v1.Secret
-		if rs, secret, err = PrepareRegisteredService(ctx, *serviceClass, mappings, data, remote_namespace); err != nil {
-			errorList = append(errorList, err)
-			continue
-		}
+	if aborted != nil {
+		return aborted
+	}
+	return errors.Join(errorList...)
+}
 
-		// modify the registered service
-		if errs := handleFunc(ctx, remote_client, rs, secret); errs != nil {
-			errorList = append(errorList, errs...)
-		}
+// handleRegisteredService prepares the registered service of the given
+// resource, and handles it with handleFunc, retrying on transient errors.  It
+// returns the errors of the handling, and the error of the mapping lookup
+// that aborts the handling of the other resources.
+func (r *ServiceClassReconciler) handleRegisteredService(ctx context.Context, serviceClass v1alpha1.ServiceClass, remote_client client.Client, remote_namespace string, data unstructured.Unstructured, handleFunc HandleFunc) ([]error, error) {
+	mappings, err := ServiceEndpointDefinitionMapping(ctx, r.Client, data, serviceClass)
+	if err != nil {
+		r.mappingEvent(serviceClass, data, err)
+		return nil, err
 	}
 
-	return errors.Join(errorList...)
+	rs, secret, err := PrepareRegisteredService(ctx, serviceClass, mappings, data, remote_namespace)
+	if err != nil {
+		r.mappingEvent(serviceClass, data, err)
+		return []error{err}, nil
+	}
+
+	// modify the registered service
+	return retried(handleFunc)(ctx, remote_client, rs, secret), nil
+}
+
+// mappingEvent records on the service class an event for the failure to
+// read the service endpoint definition of the given resource
+func (r *ServiceClassReconciler) mappingEvent(serviceClass v1alpha1.ServiceClass, data unstructured.Unstructured, err error) {
+	r.Recorder.Eventf(&serviceClass, v1.EventTypeWarning, constants.MappingLookupFailedReason,
+		"Failed to look up the service endpoint definition of %s %s: %s", data.GetKind(), data.GetName(), err)
+}
+
+// descriptorSecretName returns the name of the secret holding the values of
+// the named registered service read from secrets
+func descriptorSecretName(name string) string {
+	return fmt.Sprintf("%s-descriptor", name)
 }
 
-func LookupServiceEndpointDescriptor(ctx context.Context, mappings []sed.SEDMapping, service unstructured.Unstructured) ([]v1alpha1.ServiceEndpointDefinitionItem, *v1.Secret, error) {
+// LookupServiceEndpointDescriptor reads the service endpoint definition of
+// the named registered service, along with the secret holding its values read
+// from secrets
+func LookupServiceEndpointDescriptor(ctx context.Context, mappings []sed.SEDMapping, name string) ([]v1alpha1.ServiceEndpointDefinitionItem, *v1.Secret, error) {
 	var sedMappings []v1alpha1.ServiceEndpointDefinitionItem
 	var errorList []error
-	secret := &v1.Secret{StringData: map[string]string{}}
-	secret.SetName(fmt.Sprintf("%s-descriptor", service.GetName()))
+	secret := &v1.Secret{StringData: map[string]string{}, Data: map[string][]byte{}}
+	secret.SetName(descriptorSecretName(name))
 	for _, mapping := range mappings {
 		value, err := mapping.ReadKey(ctx)
 		if err != nil {
 			errorList = append(errorList, err)
 			continue
 		}
+		if value == nil {
+			// optional mapping with no value
+			continue
+		}
 
 		item := v1alpha1.ServiceEndpointDefinitionItem{
 			Name:  mapping.Key(),
@@ -333,6 +696,16 @@ func LookupServiceEndpointDescriptor(ctx context.Context, mappings []sed.SEDMapp
 			}
 			secret.StringData[mapping.Key()] = *value
 		}
+		if mapping.Binary() {
+			// binary data can not be stored as string without being corrupted
+			b, err := base64.StdEncoding.DecodeString(*value)
+			if err != nil {
+				errorList = append(errorList, err)
+				continue
+			}
+			delete(secret.StringData, mapping.Key())
+			secret.Data[mapping.Key()] = b
+		}
 		sedMappings = append(sedMappings, item)
 	}
 
@@ -340,7 +713,7 @@ func LookupServiceEndpointDescriptor(ctx context.Context, mappings []sed.SEDMapp
 		return nil, nil, errors.Join(errorList...)
 	}
 
-	if len(secret.StringData) == 0 {
+	if len(secret.StringData) == 0 && len(secret.Data) == 0 {
 		secret = nil
 	}
 	return sedMappings, secret, nil
@@ -354,7 +727,8 @@ func PrepareRegisteredService(
 	remote_namespace string,
 ) (v1alpha1.RegisteredService, *v1.Secret, error) {
 	l := log.FromContext(ctx)
-	sedMappings, secret, err := LookupServiceEndpointDescriptor(ctx, mappings, data)
+	name := RegisteredServiceName(serviceClass, data)
+	sedMappings, secret, err := LookupServiceEndpointDescriptor(ctx, mappings, name)
 	if err != nil {
 		l.Error(err, "Failed to lookup service endpoint descriptor values",
 			"name", data.GetName(),
@@ -364,12 +738,11 @@ func PrepareRegisteredService(
 	}
 	rs := v1alpha1.RegisteredService{
 		ObjectMeta: metav1.ObjectMeta{
-			// FIXME(sadlerap): this could cause naming conflicts; we need
-			// to take into account the type of resource somehow.
-			Name:      data.GetName(),
+			Name:      name,
 			Namespace: remote_namespace,
 		},
 		Spec: v1alpha1.RegisteredServiceSpec{
+			ServiceMetadata:           serviceClass.Spec.ServiceMetadata,
 			ServiceEndpointDefinition: sedMappings,
 			ServiceClassIdentity:      serviceClass.Spec.ServiceClassIdentity,
 			HealthCheck:               serviceClass.Spec.HealthCheck,
@@ -379,6 +752,24 @@ func PrepareRegisteredService(
 	rs.Spec.Constraints = &v1alpha1.RegisteredServiceConstraints{
 		Environments: serviceClass.Spec.GetEnvironmentConstraints(),
 	}
+	// the control plane labels the service class with the cluster
+	// environment it is pushed to, claims can prefer some of them
+	if ce, ok := serviceClass.Labels[constants.PrimazaClusterEnvironmentLabel]; ok {
+		rs.SetLabels(map[string]string{constants.PrimazaClusterEnvironmentLabel: ce})
+	}
+
+	if err := sed.NewExportPolicy(serviceClass).Apply(&rs, secret); err != nil {
+		l.Error(err, "Service endpoint definition does not comply with the export policy",
+			"name", data.GetName(),
+			"namespace", data.GetNamespace(),
+			"gvk", data.GroupVersionKind())
+		return v1alpha1.RegisteredService{}, nil, err
+	}
+	if serviceClass.Spec.AnnotateProvenance {
+		if err := annotateProvenance(&rs, mappings); err != nil {
+			return v1alpha1.RegisteredService{}, nil, err
+		}
+	}
 
 	if secret != nil {
 		secret.SetNamespace(remote_namespace)
@@ -386,7 +777,27 @@ func PrepareRegisteredService(
 	return rs, secret, nil
 }
 
-func ServiceEndpointDefinitionMapping(cli client.Client, obj unstructured.Unstructured, serviceClass v1alpha1.ServiceClass) ([]sed.SEDMapping, error) {
+// annotateProvenance records in the registered service the field each of its
+// service endpoint definition items is read from
+func annotateProvenance(rs *v1alpha1.RegisteredService, mappings []sed.SEDMapping) error {
+	sources := map[string]string{}
+	for _, m := range mappings {
+		sources[m.Key()] = m.Source()
+	}
+	provenance := make(map[string]string, len(rs.Spec.ServiceEndpointDefinition))
+	for _, i := range rs.Spec.ServiceEndpointDefinition {
+		provenance[i.Name] = sources[i.Name]
+	}
+
+	b, err := json.Marshal(provenance)
+	if err != nil {
+		return err
+	}
+	rs.SetAnnotations(map[string]string{constants.PrimazaProvenanceAnnotation: string(b)})
+	return nil
+}
+
+func ServiceEndpointDefinitionMapping(ctx context.Context, cli client.Client, obj unstructured.Unstructured, serviceClass v1alpha1.ServiceClass) ([]sed.SEDMapping, error) {
 	mappings := []sed.SEDMapping{}
 
 	for _, mapping := range serviceClass.Spec.Resource.ServiceEndpointDefinitionMappings.ResourceFields {
@@ -405,6 +816,14 @@ func ServiceEndpointDefinitionMapping(cli client.Client, obj unstructured.Unstru
 		mappings = append(mappings, m)
 	}
 
+	mappings = append(mappings, sed.NewSEDHandlerMappings(obj, cli, serviceClass.Spec.Resource.ServiceEndpointDefinitionMappings.HandlerFields)...)
+
+	secondary, err := secondaryMappings(ctx, cli, obj, serviceClass)
+	if err != nil {
+		return nil, err
+	}
+	mappings = append(mappings, secondary...)
+
 	return mappings, nil
 }
 
@@ -423,12 +842,7 @@ func (r *ServiceClassReconciler) setOwnerReference(ctx context.Context, scclass
 
 func (r *ServiceClassReconciler) SetWatchersForResources(ctx context.Context, serviceClass v1alpha1.ServiceClass) error {
 	reconcileLog := log.FromContext(ctx)
-	typemeta := metav1.TypeMeta{
-		Kind:       serviceClass.Spec.Resource.Kind,
-		APIVersion: serviceClass.Spec.Resource.APIVersion,
-	}
-	gvk := typemeta.GroupVersionKind()
-	mapping, err := r.Client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	mapping, err := r.restMapping(serviceClass.Spec.Resource)
 	if err != nil {
 		reconcileLog.Error(err, "error on creating mapping")
 		return err
@@ -444,10 +858,21 @@ func (r *ServiceClassReconciler) SetWatchersForResources(ctx context.Context, se
 func (r *ServiceClassReconciler) RunInformer(ctx context.Context, resource schema.GroupVersionResource, serviceClass v1alpha1.ServiceClass) error {
 	l := log.FromContext(ctx)
 
+	selector, err := resourceSelector(serviceClass)
+	if err != nil {
+		return err
+	}
+
 	// check if informer already exists
-	if _, ok := r.informers[serviceClass.GetName()]; ok {
-		l.Info("Informer already exists")
-		return nil
+	resync := serviceClass.Spec.SyncPeriod()
+	if i, ok := r.getInformer(serviceClass.GetName()); ok {
+		if i.selector == selector && i.resync == resync {
+			l.Info("Informer already exists")
+			return nil
+		}
+		// the selector or the sync interval changed, so the informer needs
+		// to be restarted
+		r.stopInformer(serviceClass.GetName())
 	}
 	clusterConfig, err := rest.InClusterConfig()
 	if err != nil {
@@ -459,32 +884,40 @@ func (r *ServiceClassReconciler) RunInformer(ctx context.Context, resource schem
 		l.Info("failed creating cluster config")
 		panic(err)
 	}
-	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(clusterClient, time.Minute, serviceClass.Namespace, nil)
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(clusterClient, resync, resourceNamespace(serviceClass), func(o *metav1.ListOptions) {
+		o.LabelSelector = selector
+	})
 	i := factory.ForResource(resource).Informer()
 
 	var synced atomic.Bool
 	synced.Store(false)
 	if _, err := i.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
-			if !synced.Load() {
+			if !handlesEvent(&synced, serviceClass, obj) {
+				return
+			}
+			serviceClassResource, ok := serviceResource(ctx, serviceClass, obj.(*unstructured.Unstructured))
+			if !ok {
 				return
 			}
-			serviceClassResource := obj.(*unstructured.Unstructured)
 			if err := r.CreateOrUpdateRegisteredService(ctx, *serviceClassResource, serviceClass); err != nil {
 				return
 			}
 		},
 		UpdateFunc: func(past, future interface{}) {
-			if !synced.Load() {
+			if !handlesEvent(&synced, serviceClass, future) {
+				return
+			}
+			serviceClassResource, ok := serviceResource(ctx, serviceClass, future.(*unstructured.Unstructured))
+			if !ok {
 				return
 			}
-			serviceClassResource := future.(*unstructured.Unstructured)
 			if err := r.CreateOrUpdateRegisteredService(ctx, *serviceClassResource, serviceClass); err != nil {
 				return
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
-			if !synced.Load() {
+			if !handlesEvent(&synced, serviceClass, obj) {
 				return
 			}
 			if err := r.DeleteRegisteredService(ctx, serviceClass); err != nil {
@@ -498,13 +931,12 @@ func (r *ServiceClassReconciler) RunInformer(ctx context.Context, resource schem
 	l.Info("run informer", "GroupVersionResource", resource)
 	c, fc := context.WithCancel(ctx)
 
-	li := informer{informer: i, ctx: c, cancelFunc: fc}
-	r.informers[serviceClass.GetName()] = li
+	li := informer{informer: i, ctx: c, cancelFunc: fc, selector: selector, resync: resync}
+	r.setInformer(serviceClass.GetName(), li)
 	go li.run()
 
 	if !cache.WaitForCacheSync(ctx.Done(), i.HasSynced) {
-		fc()
-		delete(r.informers, serviceClass.GetName())
+		r.stopInformer(serviceClass.GetName())
 		return fmt.Errorf("could not sync cache")
 	}
 
@@ -513,63 +945,106 @@ func (r *ServiceClassReconciler) RunInformer(ctx context.Context, resource schem
 	return nil
 }
 
+// handlesEvent tells whether an informer event on the given object needs to
+// be handled, i.e. whether the informer is synced and the ServiceClass
+// manages the object
+func handlesEvent(synced *atomic.Bool, serviceClass v1alpha1.ServiceClass, obj interface{}) bool {
+	if !synced.Load() {
+		return false
+	}
+	o, ok := obj.(metav1.Object)
+	return !ok || isOwned(serviceClass, o)
+}
+
 func (r *ServiceClassReconciler) CreateOrUpdateRegisteredService(ctx context.Context, obj unstructured.Unstructured, serviceClass v1alpha1.ServiceClass) error {
 	l := log.FromContext(ctx)
 	var mappings []sed.SEDMapping
 	var err error
 
-	if mappings, err = ServiceEndpointDefinitionMapping(r.Client, obj, serviceClass); err != nil {
+	if paused, err := r.refreshPreview(ctx, serviceClass); err != nil || paused {
 		return err
 	}
-	config, remote_namespace, err := workercluster.GetPrimazaKubeconfig(ctx, serviceClass.Namespace, r.Client, constants.ServiceAgentKubeconfigSecretName)
+	if mappings, err = ServiceEndpointDefinitionMapping(ctx, r.Client, obj, serviceClass); err != nil {
+		r.mappingEvent(serviceClass, obj, err)
+		return err
+	}
+	config, remote_namespace, err := r.primazaKubeconfig(ctx, serviceClass)
 	if err != nil {
 		return err
 	}
-	remote_client, err := client.New(config, client.Options{
-		Scheme: r.Client.Scheme(),
-		Mapper: r.Client.RESTMapper(),
-	})
+	remote_client, err := r.remoteClient(config)
 	if err != nil {
 		return err
 	}
-	errs := []error{err}
 	var rs v1alpha1.RegisteredService
 	var secret *v1.Secret
 	if rs, secret, err = PrepareRegisteredService(ctx, serviceClass, mappings, obj, remote_namespace); err != nil {
+		r.mappingEvent(serviceClass, obj, err)
 		return err
 	}
-	spec := rs.Spec
-	op, err := controllerutil.CreateOrUpdate(ctx, remote_client, &rs, func() error {
-		rs.Spec = spec
-		return nil
-	})
+	if keep, err := r.keepManualEdits(ctx, remote_client, serviceClass, rs, secret); err != nil || keep {
+		return err
+	}
+	op, err := r.writeRegisteredService(ctx, serviceClass, remote_client, rs, secret)
+	recordRegisteredServiceWrite(op, err)
+	r.writeEvent(serviceClass, rs, op, err)
 	if err != nil {
 		l.Error(err, "Failed to create or update registered service")
-		errs = append(errs, err)
-	} else {
-		l.Info("Wrote registered service", "registered service", rs.Name, "namespace", rs.Namespace, "operation", op)
+		return err
 	}
-	if secret != nil {
-		data := secret.StringData
-		_, err := controllerutil.CreateOrUpdate(ctx, remote_client, secret, func() error {
-			secret.StringData = data
-			return controllerutil.SetOwnerReference(&rs, secret, remote_client.Scheme())
-		})
-		errs = append(errs, err)
+	l.Info("Wrote registered service", "registered service", rs.Name, "namespace", rs.Namespace, "operation", op)
+	return nil
+}
+
+// keepManualEdits tells whether the given registered service must not be
+// written, according to the ServiceClass's ManualEditPolicy
+func (r *ServiceClassReconciler) keepManualEdits(ctx context.Context, remote_client client.Client, serviceClass v1alpha1.ServiceClass, rs v1alpha1.RegisteredService, secret *v1.Secret) (bool, error) {
+	l := log.FromContext(ctx)
+
+	// the service class's status reports whether synchronization is paused
+	current := v1alpha1.ServiceClass{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: serviceClass.Namespace, Name: serviceClass.Name}, &current); err != nil {
+		return false, err
 	}
-	return errors.Join(errs...)
+	policy := current.Spec.ManualEditPolicy
+	if c := meta.FindStatusCondition(current.Status.Conditions, v1alpha1.ServiceClassConditionManualOverride); policy == v1alpha1.ManualEditPolicyPause &&
+		c != nil && c.Status == metav1.ConditionTrue {
+		l.Info("Synchronization paused because of manual edits", "registered service", rs.Name)
+		return true, nil
+	}
+
+	if policy != v1alpha1.ManualEditPolicyWarn && policy != v1alpha1.ManualEditPolicyPause {
+		return false, nil
+	}
+	edits := &manualEdits{}
+	if errs := edits.detect(ctx, remote_client, rs, secret); len(errs) > 0 {
+		return false, errors.Join(errs...)
+	}
+	if editors, ok := edits.editors[rs.Name]; ok {
+		l.Info("Keeping manually edited registered service", "registered service", rs.Name, "editors", editors)
+		return true, nil
+	}
+	return false, nil
 }
 
 func (r *ServiceClassReconciler) DeleteRegisteredService(ctx context.Context, serviceClass v1alpha1.ServiceClass) error {
 	l := log.FromContext(ctx)
-	config, _, err := workercluster.GetPrimazaKubeconfig(ctx, serviceClass.Namespace, r.Client, constants.ServiceAgentKubeconfigSecretName)
+	if paused, err := r.refreshPreview(ctx, serviceClass); err != nil || paused {
+		return err
+	}
+	if serviceClass.Spec.Export != nil {
+		exported, err := r.unexportRegisteredService(ctx, serviceClass, serviceClass.Name)
+		if exported {
+			r.audit.Record(&serviceClass, audit.ActionDelete, registeredServiceKind,
+				&v1alpha1.RegisteredService{ObjectMeta: metav1.ObjectMeta{Name: serviceClass.Name}})
+		}
+		return err
+	}
+	config, _, err := r.primazaKubeconfig(ctx, serviceClass)
 	if err != nil {
 		return err
 	}
-	remote_client, err := client.New(config, client.Options{
-		Scheme: r.Client.Scheme(),
-		Mapper: r.Client.RESTMapper(),
-	})
+	remote_client, err := r.remoteClient(config)
 	if err != nil {
 		return err
 	}
@@ -582,7 +1057,11 @@ func (r *ServiceClassReconciler) DeleteRegisteredService(ctx context.Context, se
 			Namespace: "primaza-system",
 		},
 	}
-	if err = remote_client.Delete(ctx, &registeredService, &client.DeleteOptions{}); !apierrors.IsNotFound(err) {
+	err = remote_client.Delete(ctx, &registeredService, &client.DeleteOptions{})
+	if err == nil {
+		r.audit.Record(&serviceClass, audit.ActionDelete, registeredServiceKind, &registeredService)
+	}
+	if !apierrors.IsNotFound(err) {
 		return err
 	}
 	return nil
@@ -0,0 +1,135 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package svc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+	"github.com/primaza/primaza/pkg/primaza/controlplane"
+	"github.com/primaza/primaza/pkg/primaza/sed"
+)
+
+func TestPrimazaKubeconfigTenant(t *testing.T) {
+	kubeconfig := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: constants.ServiceAgentKubeconfigSecretName, Namespace: "services"},
+		Data: map[string][]byte{
+			"server":    []byte("https://primaza.example.com"),
+			"token":     []byte("token"),
+			"namespace": []byte("primaza-system"),
+		},
+	}
+	cli := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(kubeconfig).Build()
+	r := &ServiceClassReconciler{Client: cli}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr bool
+	}{
+		{name: "no tenant label"},
+		{name: "same tenant", labels: map[string]string{constants.PrimazaTenantLabel: "primaza-system"}},
+		{name: "other tenant", labels: map[string]string{constants.PrimazaTenantLabel: "primaza-other"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serviceClass := v1alpha1.ServiceClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "databases", Namespace: "services", Labels: tt.labels},
+			}
+			config, namespace, err := r.primazaKubeconfig(context.Background(), serviceClass)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("primazaKubeconfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if namespace != "primaza-system" || config.Host != "https://primaza.example.com" {
+				t.Errorf("primazaKubeconfig() = %s, %s", config.Host, namespace)
+			}
+		})
+	}
+}
+
+func TestBinarySecretRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	// not valid UTF-8, so that it is corrupted if converted to a string
+	keystore := []byte{0xff, 0xfe, 0x00, 0x80, 0xc3, 0x28, 0x0a}
+
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders-credentials", Namespace: "services"},
+		Data:       map[string][]byte{"keystore.p12": keystore, "password": []byte("s3cr3t")},
+	}
+	worker := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(credentials).Build()
+	db := newDatabase("orders", map[string]interface{}{
+		"secretName":  "orders-credentials",
+		"keystoreKey": "keystore.p12",
+		"passwordKey": "password",
+	})
+
+	mappings := []sed.SEDMapping{}
+	for _, m := range []v1alpha1.ServiceClassSecretRefFieldMapping{
+		{Name: "keystore", SecretName: ".spec.secretName", SecretKey: ".spec.keystoreKey", Binary: true},
+		{Name: "password", SecretName: ".spec.secretName", SecretKey: ".spec.passwordKey"},
+	} {
+		mapping, err := sed.NewSEDSecretRefMapping("services", db, worker, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	items, descriptor, err := LookupServiceEndpointDescriptor(ctx, mappings, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if descriptor == nil {
+		t.Fatal("expected the descriptor secret")
+	}
+
+	// the descriptor secret is written to the control plane along with the
+	// registered service, and read back to build the binding secret
+	descriptor.Namespace = "primaza-system"
+	// the API server merges stringData into data, the fake client does not
+	for k, v := range descriptor.StringData {
+		descriptor.Data[k] = []byte(v)
+	}
+	descriptor.StringData = nil
+	control := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(descriptor).Build()
+	rs := v1alpha1.RegisteredService{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "primaza-system"},
+		Spec:       v1alpha1.RegisteredServiceSpec{ServiceEndpointDefinition: items},
+	}
+	sclaim := &v1alpha1.ServiceClaim{ObjectMeta: metav1.ObjectMeta{Name: "orders-claim", Namespace: "primaza-system"}}
+	binding := controlplane.NewBindingSecret(sclaim, sclaim.Name, sclaim.Namespace)
+	if n := controlplane.ExtractServiceEndpointDefinition(ctx, control, "primaza-system", rs, []string{"keystore", "password"}, binding); n != 2 {
+		t.Fatalf("expected 2 values in the binding secret, got %d", n)
+	}
+
+	if !bytes.Equal(binding.Data["keystore"], keystore) {
+		t.Errorf("expected the keystore to be %v, got %v", keystore, binding.Data["keystore"])
+	}
+	if string(binding.Data["password"]) != "s3cr3t" {
+		t.Errorf("expected the password to be %q, got %q", "s3cr3t", binding.Data["password"])
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/primaza/primaza/pkg/primaza/backpressure"
+)
+
+// backPressureCheckPeriod is the period the load of the control plane is
+// checked with
+const backPressureCheckPeriod = 10 * time.Second
+
+// BackPressureMonitor asks agents to slow down their writes while the work
+// queues of the control plane's controllers hold more than MaxQueueDepth
+// items, so that the control plane can catch up instead of being overloaded
+// further, e.g. when many agents reconnect at once
+type BackPressureMonitor struct {
+	client.Client
+	// Namespace is the control plane namespace agents write into
+	Namespace string
+	// MaxQueueDepth is the number of queued items above which agents are
+	// asked to slow down
+	MaxQueueDepth int
+	// Delay is the time agents are asked to wait before writing again
+	Delay time.Duration
+	// Gatherer provides the work queue metrics, it defaults to the
+	// controller-runtime metrics registry
+	Gatherer prometheus.Gatherer
+}
+
+//+kubebuilder:rbac:groups=core,namespace=system,resources=configmaps,verbs=get;list;watch;create;update;delete
+
+// Start checks the load of the control plane every backPressureCheckPeriod,
+// until ctx is done.  It implements manager.Runnable.
+func (m *BackPressureMonitor) Start(ctx context.Context) error {
+	l := log.FromContext(ctx).WithName("backpressure")
+	gatherer := m.Gatherer
+	if gatherer == nil {
+		gatherer = metrics.Registry
+	}
+
+	signaled := time.Duration(-1)
+	ticker := time.NewTicker(backPressureCheckPeriod)
+	defer ticker.Stop()
+	for {
+		depth, err := queueDepth(gatherer)
+		if err != nil {
+			l.Error(err, "unable to gather work queue metrics")
+		}
+
+		delay := time.Duration(0)
+		if depth > m.MaxQueueDepth {
+			delay = m.Delay
+		}
+		if err == nil && delay != signaled {
+			l.Info("signaling back-pressure to agents", "queueDepth", depth, "delay", delay)
+			if err := backpressure.Signal(ctx, m.Client, m.Namespace, delay); err != nil {
+				l.Error(err, "unable to signal back-pressure to agents")
+			} else {
+				signaled = delay
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// queueDepth returns the total number of items waiting in the work queues of
+// the controllers
+func queueDepth(gatherer prometheus.Gatherer) (int, error) {
+	families, err := gatherer.Gather()
+	if err != nil {
+		return 0, err
+	}
+
+	depth := 0.0
+	for _, f := range families {
+		if f.GetName() != "workqueue_depth" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			depth += m.GetGauge().GetValue()
+		}
+	}
+	return int(depth), nil
+}
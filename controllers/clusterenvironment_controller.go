@@ -20,32 +20,47 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	meta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/authn"
 	"github.com/primaza/primaza/pkg/envtag"
 	"github.com/primaza/primaza/pkg/primaza/clustercontext"
+	"github.com/primaza/primaza/pkg/primaza/constants"
 	"github.com/primaza/primaza/pkg/primaza/controlplane"
 	"github.com/primaza/primaza/pkg/primaza/workercluster"
 	"github.com/primaza/primaza/pkg/slices"
+	"github.com/primaza/primaza/pkg/version"
 )
 
 type namespaceType string
 
 func (t namespaceType) permissionRequiredReason() string {
-	return fmt.Sprintf("%sNamespacePermissionsRequired", t)
+	if t == applicationNamespaceType {
+		return primazaiov1alpha1.ClusterEnvironmentConditionApplicationNamespacePermissionsRequired
+	}
+	return primazaiov1alpha1.ClusterEnvironmentConditionServiceNamespacePermissionsRequired
 }
 
 const (
@@ -57,19 +72,41 @@ const (
 	PermissionsGrantedReason    = "PermissionsGranted"
 	ClientCreationErrorReason   = "ClientCreationError"
 	PermissionsNotGrantedReason = "PermissionsNotGranted"
+
+	// DefaultHeartbeatPeriod is the default period between two checks of the
+	// connection to a cluster
+	DefaultHeartbeatPeriod = time.Minute
 )
 
 // ClusterEnvironmentReconciler reconciles a ClusterEnvironment object
 type ClusterEnvironmentReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 
 	AppAgentImage string
 	SvcAgentImage string
+
+	// HeartbeatPeriod is the period between two checks of the connection to
+	// a cluster.  DefaultHeartbeatPeriod is used if not positive.
+	HeartbeatPeriod time.Duration
+
+	// AgentTokens issues the tokens of the agents of the ClusterEnvironments
+	// whose agentAuthentication is TokenRequest
+	AgentTokens *controlplane.AgentTokenIssuer
+
+	// Pool caches the connections to the clusters of ClusterEnvironments
+	Pool *workercluster.Pool
+
+	// events enqueues the ClusterEnvironments whose cluster comes back
+	// online
+	events chan event.GenericEvent
 }
 
 //+kubebuilder:rbac:groups="",namespace=system,resources=secrets,verbs=create;update;delete;get;list;watch
 //+kubebuilder:rbac:groups=rbac.authorization.k8s.io,namespace=system,resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",namespace=system,resources=serviceaccounts,verbs=get;create;delete
+//+kubebuilder:rbac:groups="",namespace=system,resources=serviceaccounts/token,verbs=create
 //+kubebuilder:rbac:groups=primaza.io,namespace=system,resources=clusterenvironments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=primaza.io,namespace=system,resources=clusterenvironments/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=primaza.io,namespace=system,resources=clusterenvironments/finalizers,verbs=update
@@ -98,8 +135,18 @@ func (r *ClusterEnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return ctrl.Result{}, err
 	}
 
+	// drop the stale conditions accumulated by former versions
+	if ce.Status.PruneConditions() {
+		l.Info("pruning stale cluster environment conditions")
+		ce.UpdateSummary()
+		if err := r.Client.Status().Update(ctx, ce); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	// check if instance is marked to be deleted
 	if ce.HasDeletionTimestamp() {
+		r.Pool.Evict(req.NamespacedName)
 		if controllerutil.ContainsFinalizer(ce, clusterEnvironmentFinalizer) {
 			// run finalizer
 			if err := r.finalizeClusterEnvironment(ctx, ce); err != nil {
@@ -123,27 +170,9 @@ func (r *ClusterEnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.R
 		}
 	}
 
-	// get cluster config
-	cfg, err := clustercontext.GetClusterRESTConfig(ctx, r.Client, ce.Namespace, ce.Spec.ClusterContextSecret)
+	// check the connection to the cluster
+	cfg, err := r.heartbeat(ctx, ce)
 	if err != nil {
-		if errors.Is(err, clustercontext.ErrSecretNotFound) {
-			c := workercluster.ConnectionStatus{
-				State:   primazaiov1alpha1.ClusterEnvironmentStateOffline,
-				Reason:  ClientCreationErrorReason,
-				Message: fmt.Sprintf("error creating the client: %s", err),
-			}
-			r.updateClusterEnvironmentStatus(ctx, ce, c)
-			if err := r.Client.Status().Update(ctx, ce); err != nil {
-				l.Error(err, "error updating cluster environment status", "status", ce.Status)
-				return ctrl.Result{}, err
-			}
-		}
-
-		return ctrl.Result{}, err
-	}
-
-	// test connection
-	if err := r.testConnection(ctx, cfg, ce); err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -168,6 +197,7 @@ func (r *ClusterEnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.R
 	}
 	l.Info("namespaces reconciled")
 
+	ce.UpdateSummary()
 	if err := r.Client.Status().Update(ctx, ce); err != nil {
 		l.Error(err, "error updating cluster environment status", "status", ce.Status)
 		return ctrl.Result{}, err
@@ -176,14 +206,102 @@ func (r *ClusterEnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.R
 	return ctrl.Result{}, nil
 }
 
-func (r *ClusterEnvironmentReconciler) testConnection(ctx context.Context, cfg *rest.Config, ce *primazaiov1alpha1.ClusterEnvironment) error {
-	cr := workercluster.TestConnection(ctx, cfg)
-	r.updateClusterEnvironmentStatus(ctx, ce, cr)
+func (r *ClusterEnvironmentReconciler) heartbeatPeriod() time.Duration {
+	if r.HeartbeatPeriod > 0 {
+		return r.HeartbeatPeriod
+	}
+	return DefaultHeartbeatPeriod
+}
+
+// clientCreationFailure returns the connection status reporting that no
+// client can be created for the cluster because of err, and whether err is
+// to be reported in the status
+func clientCreationFailure(err error) (workercluster.ConnectionStatus, bool) {
+	c := workercluster.ConnectionStatus{
+		State:   primazaiov1alpha1.ClusterEnvironmentStateOffline,
+		Reason:  ClientCreationErrorReason,
+		Message: fmt.Sprintf("error creating the client: %s", err),
+	}
+	switch {
+	case errors.Is(err, clustercontext.ErrInsecureConnection):
+		c.Reason = workercluster.TLSVerificationFailed
+		return c, true
+	case errors.Is(err, clustercontext.ErrSecretNotFound), errors.Is(err, clustercontext.ErrContextNotFound), errors.Is(err, authn.ErrUnsupportedAuth):
+		return c, true
+	}
+	return c, false
+}
 
+// heartbeat checks the connection to the cluster and records its outcome in
+// the status of the ClusterEnvironment.  When the cluster can not be reached,
+// the status is persisted and the connection error is returned.
+func (r *ClusterEnvironmentReconciler) heartbeat(ctx context.Context, ce *primazaiov1alpha1.ClusterEnvironment) (*rest.Config, error) {
+	cfg, err := r.restConfig(ctx, ce)
+	if err != nil {
+		if c, ok := clientCreationFailure(err); ok {
+			return nil, errors.Join(err, r.recordConnectionFailure(ctx, ce, c))
+		}
+		return nil, err
+	}
+
+	cr := workercluster.TestConnection(ctx, cfg, "")
 	if cr.Reason != workercluster.ConnectionSuccessful {
-		return fmt.Errorf("can not connect to target cluster")
+		return nil, errors.Join(cr.Err, r.recordConnectionFailure(ctx, ce, cr))
 	}
 
+	r.updateClusterEnvironmentStatus(ctx, ce, cr)
+	ce.Status.RecordHeartbeat(metav1.Now(), true, ce.Generation)
+	return cfg, nil
+}
+
+// restConfig returns the REST config to connect to the cluster, checking the
+// expiry of its credentials before enforcing the TLS settings and the
+// failover servers
+func (r *ClusterEnvironmentReconciler) restConfig(ctx context.Context, ce *primazaiov1alpha1.ClusterEnvironment) (*rest.Config, error) {
+	s, err := clustercontext.GetClusterEnvironmentSecret(ctx, r.Client, *ce)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := clustercontext.RESTConfigFromSecret(s)
+	if err != nil {
+		return nil, err
+	}
+	r.checkCredentialsExpiry(ctx, ce, cfg)
+	if err := clustercontext.ApplyTLSSettings(cfg, ce.Spec.TLS); err != nil {
+		return nil, err
+	}
+	if err := clustercontext.WithFailover(cfg, clustercontext.ClusterEnvironmentFailoverServers(s, *ce)); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// checkCredentialsExpiry sets the CredentialsExpiring condition, and records
+// an Event when the credentials are found to be expiring or expired
+func (r *ClusterEnvironmentReconciler) checkCredentialsExpiry(ctx context.Context, ce *primazaiov1alpha1.ClusterEnvironment, cfg *rest.Config) {
+	expiry, err := clustercontext.CredentialsExpiry(cfg)
+	if err != nil {
+		log.FromContext(ctx).Info("unable to check the expiry of the cluster credentials", "error", err.Error())
+		return
+	}
+
+	c := clustercontext.CredentialsExpiryCondition(primazaiov1alpha1.ClusterEnvironmentConditionCredentialsExpiring, expiry, time.Now())
+	prev := meta.FindStatusCondition(ce.Status.Conditions, c.Type)
+	if c.Status == metav1.ConditionTrue && (prev == nil || prev.Reason != c.Reason) {
+		r.Recorder.Event(ce, corev1.EventTypeWarning, c.Reason, c.Message)
+	}
+	ce.Status.SetCondition(c, ce.Generation)
+}
+
+func (r *ClusterEnvironmentReconciler) recordConnectionFailure(ctx context.Context, ce *primazaiov1alpha1.ClusterEnvironment, cs workercluster.ConnectionStatus) error {
+	r.Pool.Evict(client.ObjectKeyFromObject(ce))
+	r.updateClusterEnvironmentStatus(ctx, ce, cs)
+	ce.Status.RecordHeartbeat(metav1.Now(), false, ce.Generation)
+	ce.UpdateSummary()
+	if err := r.Client.Status().Update(ctx, ce); err != nil {
+		log.FromContext(ctx).Error(err, "error updating cluster environment status", "status", ce.Status)
+		return err
+	}
 	return nil
 }
 
@@ -205,13 +323,13 @@ func (r *ClusterEnvironmentReconciler) reconcileServiceNamespaces(ctx context.Co
 
 	errs := []error{}
 	for _, serviceclass := range serviceclassFilteredList {
-		cli, err := clustercontext.CreateClient(ctx, r.Client, *ce, r.Scheme, r.Client.RESTMapper())
+		cli, err := r.Pool.Client(ctx, r.Client, *ce, r.Scheme, r.Client.RESTMapper())
 		if err != nil {
 			errs = append(errs, err)
 			continue
 		}
 
-		if err := controlplane.PushServiceClassToNamespaces(ctx, cli, serviceclass, serviceNamespaces); err != nil {
+		if err := controlplane.PushServiceClassToNamespaces(ctx, cli, serviceclass, *ce, serviceNamespaces); err != nil {
 			if !apierrors.IsAlreadyExists(err) {
 				errs = append(errs,
 					fmt.Errorf("error pushing service class '%s' to cluster environment '%s': %w", serviceclass.Name, ce.Name, err))
@@ -238,21 +356,18 @@ func (r *ClusterEnvironmentReconciler) reconcileServiceBindingApplicationNamespa
 	}
 	for index := range serviceclaimFilteredList {
 		sclaim := serviceclaimFilteredList[index]
-		secret := &corev1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      sclaim.Name,
-				Namespace: sclaim.Namespace,
-			},
-			StringData: map[string]string{},
+		secret, err := r.resolvedBindingSecret(ctx, &sclaim)
+		if err != nil {
+			errs = append(errs, err)
+			continue
 		}
-		// ServiceClassIdentity values are going to override
-		// any values in the secret resource
-		for _, sci := range sclaim.Spec.ServiceClassIdentity {
-			secret.StringData[sci.Name] = sci.Value
+		if secret == nil {
+			l.Info("service claim is not resolved, skipping", "service claim", sclaim.Name)
+			continue
 		}
 		if sclaim.Spec.EnvironmentTag == "" {
 			if sclaim.Spec.ApplicationClusterContext != nil && ce.Name == sclaim.Spec.ApplicationClusterContext.ClusterEnvironmentName {
-				if err := controlplane.PushServiceBinding(ctx, &sclaim, secret, r.Scheme, r.Client, &sclaim.Spec.ApplicationClusterContext.Namespace, applicationNamespaces, cfg); err != nil {
+				if _, err := controlplane.PushServiceBinding(ctx, &sclaim, secret, r.Scheme, r.Client, ce.Name, sclaim.Spec.ApplicationClusterContext.Namespaces(), applicationNamespaces, cfg); err != nil {
 					errs = append(errs, err)
 				}
 			}
@@ -264,7 +379,7 @@ func (r *ClusterEnvironmentReconciler) reconcileServiceBindingApplicationNamespa
 			}
 
 			l.Info("cluster environment is matching environment", "cluster environment", ce, "environment tag", sclaim.Spec.EnvironmentTag)
-			if err := controlplane.PushServiceBinding(ctx, &sclaim, secret, r.Scheme, r.Client, nil, applicationNamespaces, cfg); err != nil {
+			if _, err := controlplane.PushServiceBinding(ctx, &sclaim, secret, r.Scheme, r.Client, ce.Name, nil, applicationNamespaces, cfg); err != nil {
 				errs = append(errs, err)
 			}
 		}
@@ -276,6 +391,20 @@ func (r *ClusterEnvironmentReconciler) reconcileServiceBindingApplicationNamespa
 	return nil
 }
 
+// resolvedBindingSecret returns the binding secret the service claim pushed
+// when it was resolved, or nil if it is not resolved.  Only resolved claims
+// are pushed, as the binding secret of the others is not known yet.
+func (r *ClusterEnvironmentReconciler) resolvedBindingSecret(ctx context.Context, sclaim *primazaiov1alpha1.ServiceClaim) (*corev1.Secret, error) {
+	if sclaim.Status.State != primazaiov1alpha1.ServiceClaimStateResolved || sclaim.Status.RegisteredService == "" {
+		return nil, nil
+	}
+	rs := &primazaiov1alpha1.RegisteredService{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: sclaim.Namespace, Name: sclaim.Status.RegisteredService}, rs); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return controlplane.ResolvedBindingSecret(ctx, r.Client, sclaim, rs)
+}
+
 func (r *ClusterEnvironmentReconciler) reconcileServiceCatalogApplicationNamespaces(ctx context.Context, cfg *rest.Config, ce *primazaiov1alpha1.ClusterEnvironment, applicationNamespaces []string) error {
 	servicecatalog := primazaiov1alpha1.ServiceCatalog{}
 	if err := r.Get(ctx, types.NamespacedName{Namespace: ce.Namespace, Name: ce.Spec.EnvironmentName}, &servicecatalog); apierrors.IsNotFound(err) {
@@ -286,7 +415,11 @@ func (r *ClusterEnvironmentReconciler) reconcileServiceCatalogApplicationNamespa
 	if err := r.Get(ctx, types.NamespacedName{Namespace: ce.Namespace, Name: ce.Spec.EnvironmentName}, &servicecatalog); err != nil {
 		return err
 	}
-	if err := controlplane.PushServiceCatalogToApplicationNamespaces(ctx, servicecatalog, r.Scheme, r.Client, applicationNamespaces, cfg); err != nil {
+	p, err := projectServiceCatalog(ctx, r.Client, servicecatalog, *ce)
+	if err != nil {
+		return err
+	}
+	if err := controlplane.PushServiceCatalogToApplicationNamespaces(ctx, p, r.Scheme, r.Client, applicationNamespaces, cfg); err != nil {
 		return err
 	}
 	return nil
@@ -345,7 +478,7 @@ func (r *ClusterEnvironmentReconciler) testTypedNamespacesPermissions(
 	}
 
 	co := r.buildPermissionCondition(ctx, nsType, failed)
-	meta.SetStatusCondition(&ce.Status.Conditions, co)
+	ce.Status.SetCondition(co, ce.Generation)
 
 	return failed, nil
 }
@@ -384,8 +517,11 @@ func (r *ClusterEnvironmentReconciler) reconcileNamespaces(
 		ClusterConfig:         cfg,
 		ApplicationNamespaces: ans,
 		ServiceNamespaces:     sns,
-		AppAgentImage:         r.AppAgentImage,
-		SvcAgentImage:         r.SvcAgentImage,
+		AppAgentImage:         workercluster.PinAgentImage(r.AppAgentImage, ce.Spec.AgentVersion),
+		SvcAgentImage:         workercluster.PinAgentImage(r.SvcAgentImage, ce.Spec.AgentVersion),
+	}
+	if ce.Spec.AgentAuthentication == primazaiov1alpha1.AgentAuthenticationTokenRequest {
+		s.AgentTokens = r.AgentTokens
 	}
 
 	nr, err := controlplane.NewNamespaceReconciler(s)
@@ -393,18 +529,178 @@ func (r *ClusterEnvironmentReconciler) reconcileNamespaces(
 		return err
 	}
 
-	if err := nr.ReconcileNamespaces(ctx); err != nil {
-		return err
+	err = nr.ReconcileNamespaces(ctx)
+	setNamespacesPrepared(ce, failedApplicationNamespaces, failedServiceNamespaces, err)
+	r.checkAgents(ctx, cfg, ce, ans, sns)
+	return err
+}
+
+// setNamespacesPrepared sets the Prepared condition of each namespace of
+// the ClusterEnvironment, according to the namespaces that lack the
+// permissions required by the agents and to the errors of their binding.
+// The namespaces that lack permissions have no agent deployed.
+func setNamespacesPrepared(ce *primazaiov1alpha1.ClusterEnvironment, failedApplicationNamespaces, failedServiceNamespaces []string, err error) {
+	set := func(namespaces, failed []string, t primazaiov1alpha1.ClusterEnvironmentNamespaceType, nt controlplane.NamespaceType) {
+		errs := controlplane.NamespaceBindingErrors(err, nt)
+		for _, ns := range namespaces {
+			c := metav1.Condition{
+				Type:    primazaiov1alpha1.ClusterEnvironmentNamespaceConditionPrepared,
+				Status:  metav1.ConditionTrue,
+				Reason:  constants.NamespacePreparedReason,
+				Message: "agent and its resources pushed",
+			}
+			switch {
+			case slices.ItemContains(failed, ns):
+				c.Status = metav1.ConditionFalse
+				c.Reason = PermissionsNotGrantedReason
+				c.Message = "permissions required by the agent are not granted"
+				ce.Status.SetNamespaceCondition(ns, t, metav1.Condition{
+					Type:    primazaiov1alpha1.ClusterEnvironmentNamespaceConditionAgentActive,
+					Status:  metav1.ConditionFalse,
+					Reason:  constants.AgentNotDeployedReason,
+					Message: "namespace is not prepared",
+				}, ce.Generation)
+			case errs[ns] != nil:
+				c.Status = metav1.ConditionFalse
+				c.Reason = constants.NamespacePrepareFailedReason
+				c.Message = errs[ns].Error()
+			}
+			ce.Status.SetNamespaceCondition(ns, t, c, ce.Generation)
+		}
 	}
-	return nil
+	set(ce.Spec.ApplicationNamespaces, failedApplicationNamespaces, primazaiov1alpha1.ClusterEnvironmentApplicationNamespace, controlplane.ApplicationNamespaceType)
+	set(ce.Spec.ServiceNamespaces, failedServiceNamespaces, primazaiov1alpha1.ClusterEnvironmentServiceNamespace, controlplane.ServiceNamespaceType)
+	ce.PruneNamespaces()
+}
+
+// checkAgents sets the AgentsRolledOut and AgentVersionSkew conditions, and
+// the AgentActive condition of each of the given namespaces, according to
+// the rollout and to the version of the agents deployed in them
+func (r *ClusterEnvironmentReconciler) checkAgents(ctx context.Context, cfg *rest.Config, ce *primazaiov1alpha1.ClusterEnvironment, applicationNamespaces, serviceNamespaces []string) {
+	l := log.FromContext(ctx)
+
+	cli, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		l.Error(err, "error creating client to check agents")
+		return
+	}
+
+	progressing, failed := []string{}, []string{}
+	skewed, unknown := []string{}, []string{}
+	check := func(namespaces []string, name string, t primazaiov1alpha1.ClusterEnvironmentNamespaceType) {
+		for _, ns := range namespaces {
+			s, err := workercluster.GetAgentStatus(ctx, cli, ns, name)
+			ce.Status.SetNamespaceCondition(ns, t, agentActiveCondition(s, err), ce.Generation)
+			switch {
+			case err != nil:
+				failed = append(failed, fmt.Sprintf("%s/%s: %v", ns, name, err))
+				continue
+			case s.Rollout == workercluster.RolloutFailed:
+				failed = append(failed, fmt.Sprintf("%s/%s: %s", ns, name, s.Message))
+			case s.Rollout == workercluster.RolloutProgressing:
+				progressing = append(progressing, fmt.Sprintf("%s/%s: %s", ns, name, s.Message))
+			}
+
+			if err := version.CheckSkew(s.Version, version.Version); errors.Is(err, version.ErrUnknownVersion) {
+				unknown = append(unknown, fmt.Sprintf("%s/%s: %q", ns, name, s.Version))
+			} else if err != nil {
+				skewed = append(skewed, fmt.Sprintf("%s/%s: %v", ns, name, err))
+			}
+		}
+	}
+	check(applicationNamespaces, constants.ApplicationAgentDeploymentName, primazaiov1alpha1.ClusterEnvironmentApplicationNamespace)
+	check(serviceNamespaces, constants.ServiceAgentDeploymentName, primazaiov1alpha1.ClusterEnvironmentServiceNamespace)
+
+	ce.Status.SetCondition(agentsRolloutCondition(progressing, failed), ce.Generation)
+	ce.Status.SetCondition(agentsVersionSkewCondition(skewed, unknown), ce.Generation)
+}
+
+func agentActiveCondition(s workercluster.AgentStatus, err error) metav1.Condition {
+	c := metav1.Condition{
+		Type:    primazaiov1alpha1.ClusterEnvironmentNamespaceConditionAgentActive,
+		Status:  metav1.ConditionFalse,
+		Reason:  constants.AgentsRollingOutReason,
+		Message: s.Message,
+	}
+	switch {
+	case apierrors.IsNotFound(err):
+		c.Reason = constants.AgentNotDeployedReason
+		c.Message = "agent deployment not found"
+	case err != nil:
+		c.Reason = constants.AgentsRolloutFailedReason
+		c.Message = err.Error()
+	case s.Rollout == workercluster.RolloutFailed:
+		c.Reason = constants.AgentsRolloutFailedReason
+	case s.Rollout == workercluster.RolloutComplete:
+		c.Status = metav1.ConditionTrue
+		c.Reason = constants.AgentActiveReason
+		c.Message = "agent rolled out"
+	}
+	return c
+}
+
+func agentsVersionSkewCondition(skewed, unknown []string) metav1.Condition {
+	switch {
+	case len(skewed) > 0:
+		return metav1.Condition{
+			Type:    primazaiov1alpha1.ClusterEnvironmentConditionAgentVersionSkew,
+			Status:  metav1.ConditionTrue,
+			Reason:  constants.AgentVersionSkewedReason,
+			Message: fmt.Sprintf("agents outside of the supported version skew with control plane %s: %s", version.Version, strings.Join(skewed, "; ")),
+		}
+	case len(unknown) > 0:
+		return metav1.Condition{
+			Type:    primazaiov1alpha1.ClusterEnvironmentConditionAgentVersionSkew,
+			Status:  metav1.ConditionUnknown,
+			Reason:  constants.AgentVersionUnknownReason,
+			Message: fmt.Sprintf("unknown versions of agents or control plane %q: %s", version.Version, strings.Join(unknown, "; ")),
+		}
+	default:
+		return metav1.Condition{
+			Type:    primazaiov1alpha1.ClusterEnvironmentConditionAgentVersionSkew,
+			Status:  metav1.ConditionFalse,
+			Reason:  constants.AgentVersionSupportedReason,
+			Message: fmt.Sprintf("all agents are within the supported version skew with control plane %s", version.Version),
+		}
+	}
+}
+
+func agentsRolloutCondition(progressing, failed []string) metav1.Condition {
+	c := metav1.Condition{
+		Type:    primazaiov1alpha1.ClusterEnvironmentConditionAgentsRolledOut,
+		Status:  metav1.ConditionFalse,
+		Reason:  constants.AgentsRollingOutReason,
+		Message: fmt.Sprintf("rolling out: %s", strings.Join(progressing, "; ")),
+	}
+	switch {
+	case len(failed) > 0:
+		c.Reason = constants.AgentsRolloutFailedReason
+		c.Message = fmt.Sprintf("rollout failed: %s", strings.Join(failed, "; "))
+		if len(progressing) > 0 {
+			c.Message += fmt.Sprintf("; rolling out: %s", strings.Join(progressing, "; "))
+		}
+	case len(progressing) == 0:
+		c.Status = metav1.ConditionTrue
+		c.Reason = constants.AgentsRolledOutReason
+		c.Message = "all agents are rolled out"
+	}
+	return c
 }
 
 func (r *ClusterEnvironmentReconciler) updateClusterEnvironmentStatus(ctx context.Context, ce *primazaiov1alpha1.ClusterEnvironment, cs workercluster.ConnectionStatus) {
 	l := log.FromContext(ctx)
 
 	l.Info("updating cluster environment status", "clusterenvironment", ce.GetName(), "connection status", cs)
+	if ce.Status.State != cs.State {
+		switch cs.State {
+		case primazaiov1alpha1.ClusterEnvironmentStateOnline:
+			r.Recorder.Event(ce, corev1.EventTypeNormal, constants.RemoteConnectionEstablishedReason, cs.Message)
+		case primazaiov1alpha1.ClusterEnvironmentStateOffline:
+			r.Recorder.Event(ce, corev1.EventTypeWarning, constants.RemoteConnectionFailedReason, cs.Message)
+		}
+	}
 	ce.Status.State = cs.State
-	meta.SetStatusCondition(&ce.Status.Conditions, cs.Condition())
+	ce.Status.SetCondition(cs.Condition(), ce.Generation)
 }
 
 func (r *ClusterEnvironmentReconciler) getRelatedClusterEnvironments(ctx context.Context, namespace string, envname string) ([]primazaiov1alpha1.ClusterEnvironment, error) {
@@ -472,22 +768,14 @@ func (r *ClusterEnvironmentReconciler) CreateServiceCatalog(ctx context.Context,
 	if err := r.Client.List(ctx, &rsl, &lo); err != nil {
 		return err
 	}
+	var cel primazaiov1alpha1.ClusterEnvironmentList
+	if err := r.Client.List(ctx, &cel, &lo); err != nil {
+		return err
+	}
 	var scs []primazaiov1alpha1.ServiceCatalogService
 	for _, rs := range rsl.Items {
-		if rs.Status.State == primazaiov1alpha1.RegisteredServiceStateAvailable &&
-			(rs.Spec.Constraints == nil || envtag.Match(ce.Spec.EnvironmentName, rs.Spec.Constraints.Environments)) {
-			// Extracting Keys of SED
-			sedKeys := make([]string, 0, len(rs.Spec.ServiceEndpointDefinition))
-			for i := 0; i < len(rs.Spec.ServiceEndpointDefinition); i++ {
-				sedKeys = append(sedKeys, rs.Spec.ServiceEndpointDefinition[i].Name)
-			}
-			// Initializing Service Catalog Service
-			serviceCatalogSvc := primazaiov1alpha1.ServiceCatalogService{
-				Name:                          rs.Name,
-				ServiceClassIdentity:          rs.Spec.ServiceClassIdentity,
-				ServiceEndpointDefinitionKeys: sedKeys,
-			}
-			scs = append(scs, serviceCatalogSvc)
+		if rs.Listed() && rs.Spec.Constraints.Allows(ce.Spec.EnvironmentName, cel.Items) {
+			scs = append(scs, controlplane.CatalogService(rs))
 		}
 	}
 	serviceCatalog := primazaiov1alpha1.ServiceCatalog{
@@ -509,7 +797,7 @@ func (r *ClusterEnvironmentReconciler) CreateServiceCatalog(ctx context.Context,
 }
 
 func (r *ClusterEnvironmentReconciler) finalizeClusterEnvironmentInNamespaces(ctx context.Context, ce *primazaiov1alpha1.ClusterEnvironment) error {
-	kcfg, err := clustercontext.GetClusterRESTConfig(ctx, r.Client, ce.Namespace, ce.Spec.ClusterContextSecret)
+	kcfg, err := r.Pool.Config(ctx, r.Client, *ce)
 	if err != nil {
 		return err
 	}
@@ -533,8 +821,19 @@ func (r *ClusterEnvironmentReconciler) finalizeClusterEnvironmentInNamespaces(ct
 }
 
 // SetupWithManager sets up the controller with the Manager.
+// The connection to the clusters is checked periodically by a separate
+// heartbeat, so that the ClusterEnvironments are only reconciled when they
+// change, e.g. not when the heartbeat updates their status, and when their
+// cluster comes back online.
 func (r *ClusterEnvironmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.events = make(chan event.GenericEvent)
+	if err := mgr.Add(&clusterEnvironmentHeartbeat{reconciler: r}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&primazaiov1alpha1.ClusterEnvironment{}).
+		For(&primazaiov1alpha1.ClusterEnvironment{},
+			builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{}))).
+		Watches(&source.Channel{Source: r.events}, &handler.EnqueueRequestForObject{}).
 		Complete(r)
 }
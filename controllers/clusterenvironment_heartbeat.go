@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/controlplane"
+	"github.com/primaza/primaza/pkg/slices"
+)
+
+// clusterEnvironmentHeartbeat checks the connection to the clusters of the
+// ClusterEnvironments every heartbeat period, and renews the tokens of their
+// agents, without running the whole reconciliation of the
+// ClusterEnvironments.  The ClusterEnvironments whose cluster comes back
+// online are reconciled, so that their namespaces are prepared again.
+type clusterEnvironmentHeartbeat struct {
+	reconciler *ClusterEnvironmentReconciler
+}
+
+// Start checks the connection to the clusters until ctx is done.  It
+// implements manager.Runnable.
+func (h *clusterEnvironmentHeartbeat) Start(ctx context.Context) error {
+	l := log.FromContext(ctx).WithName("clusterenvironment-heartbeat")
+	ticker := time.NewTicker(h.reconciler.heartbeatPeriod())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		cel := primazaiov1alpha1.ClusterEnvironmentList{}
+		if err := h.reconciler.Client.List(ctx, &cel); err != nil {
+			l.Error(err, "unable to list cluster environments")
+			continue
+		}
+		for i := range cel.Items {
+			ce := &cel.Items[i]
+			if ce.HasDeletionTimestamp() {
+				continue
+			}
+			if err := h.beat(log.IntoContext(ctx, l.WithValues("clusterenvironment", ce.Name)), ce); err != nil {
+				l.Error(err, "heartbeat failed", "clusterenvironment", ce.Name)
+			}
+		}
+	}
+}
+
+// beat checks the connection to the cluster of the ClusterEnvironment, and
+// renews the tokens of its agents.  The ClusterEnvironment is enqueued for
+// reconciliation when its cluster comes back online.
+func (h *clusterEnvironmentHeartbeat) beat(ctx context.Context, ce *primazaiov1alpha1.ClusterEnvironment) error {
+	r := h.reconciler
+	wasOnline := meta.IsStatusConditionTrue(ce.Status.Conditions, primazaiov1alpha1.ClusterEnvironmentConditionOnline)
+
+	cfg, err := r.heartbeat(ctx, ce)
+	if err != nil {
+		return err
+	}
+
+	terr := r.renewAgentTokens(ctx, cfg, ce)
+	ce.UpdateSummary()
+	if err := r.Client.Status().Update(ctx, ce); err != nil {
+		return errors.Join(terr, err)
+	}
+
+	if !wasOnline {
+		select {
+		case r.events <- event.GenericEvent{Object: ce}:
+		case <-ctx.Done():
+		}
+	}
+	return terr
+}
+
+// renewAgentTokens renews the tokens of the agents of the prepared
+// namespaces of the ClusterEnvironment, if they authenticate with
+// short-lived tokens
+func (r *ClusterEnvironmentReconciler) renewAgentTokens(ctx context.Context, cfg *rest.Config, ce *primazaiov1alpha1.ClusterEnvironment) error {
+	if r.AgentTokens == nil || ce.Spec.AgentAuthentication != primazaiov1alpha1.AgentAuthenticationTokenRequest {
+		return nil
+	}
+
+	wcli, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	aerr := r.AgentTokens.RenewAgentTokens(ctx, r.Client, wcli, controlplane.ApplicationNamespaceType, ce.Name, ce.Namespace,
+		preparedNamespaces(ce, ce.Spec.ApplicationNamespaces, primazaiov1alpha1.ClusterEnvironmentApplicationNamespace))
+	serr := r.AgentTokens.RenewAgentTokens(ctx, r.Client, wcli, controlplane.ServiceNamespaceType, ce.Name, ce.Namespace,
+		preparedNamespaces(ce, ce.Spec.ServiceNamespaces, primazaiov1alpha1.ClusterEnvironmentServiceNamespace))
+	return errors.Join(aerr, serr)
+}
+
+// preparedNamespaces returns the namespaces among the given ones whose
+// Prepared condition is true, i.e. in which the agent was pushed
+func preparedNamespaces(ce *primazaiov1alpha1.ClusterEnvironment, namespaces []string, t primazaiov1alpha1.ClusterEnvironmentNamespaceType) []string {
+	prepared := []string{}
+	for _, ns := range ce.Status.Namespaces {
+		if ns.Type != t || !slices.ItemContains(namespaces, ns.Name) {
+			continue
+		}
+		if meta.IsStatusConditionTrue(ns.Conditions, primazaiov1alpha1.ClusterEnvironmentNamespaceConditionPrepared) {
+			prepared = append(prepared, ns.Name)
+		}
+	}
+	return prepared
+}
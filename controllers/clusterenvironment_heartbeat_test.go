@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+func TestPreparedNamespaces(t *testing.T) {
+	prepared := func(status metav1.ConditionStatus) []metav1.Condition {
+		return []metav1.Condition{{Type: primazaiov1alpha1.ClusterEnvironmentNamespaceConditionPrepared, Status: status}}
+	}
+	ce := &primazaiov1alpha1.ClusterEnvironment{
+		Spec: primazaiov1alpha1.ClusterEnvironmentSpec{
+			ApplicationNamespaces: []string{"apps"},
+			ServiceNamespaces:     []string{"services", "databases", "queues"},
+		},
+		Status: primazaiov1alpha1.ClusterEnvironmentStatus{
+			Namespaces: []primazaiov1alpha1.ClusterEnvironmentNamespaceStatus{
+				{Name: "apps", Type: primazaiov1alpha1.ClusterEnvironmentApplicationNamespace, Conditions: prepared(metav1.ConditionTrue)},
+				{Name: "services", Type: primazaiov1alpha1.ClusterEnvironmentServiceNamespace, Conditions: prepared(metav1.ConditionTrue)},
+				{Name: "databases", Type: primazaiov1alpha1.ClusterEnvironmentServiceNamespace, Conditions: prepared(metav1.ConditionFalse)},
+				{Name: "removed", Type: primazaiov1alpha1.ClusterEnvironmentServiceNamespace, Conditions: prepared(metav1.ConditionTrue)},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		namespaces []string
+		nsType     primazaiov1alpha1.ClusterEnvironmentNamespaceType
+		want       []string
+	}{
+		{name: "application namespaces", namespaces: ce.Spec.ApplicationNamespaces, nsType: primazaiov1alpha1.ClusterEnvironmentApplicationNamespace, want: []string{"apps"}},
+		{name: "service namespaces", namespaces: ce.Spec.ServiceNamespaces, nsType: primazaiov1alpha1.ClusterEnvironmentServiceNamespace, want: []string{"services"}},
+		{name: "namespace of the other type", namespaces: []string{"apps"}, nsType: primazaiov1alpha1.ClusterEnvironmentServiceNamespace, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := preparedNamespaces(ce, tt.namespaces, tt.nsType); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("preparedNamespaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+	"github.com/primaza/primaza/pkg/primaza/workercluster"
+)
+
+// DefaultNamespaceSyncPeriod is the default period the namespaces of
+// ClusterEnvironments are synchronized with
+const DefaultNamespaceSyncPeriod = 5 * time.Minute
+
+// NamespaceSyncReconciler ensures that the application and service
+// namespaces of the ClusterEnvironments that enable SynchronizeNamespaces
+// exist in their cluster, are labeled, and hold the RBAC resources required
+// by their agent
+type NamespaceSyncReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// SyncPeriod is the period namespaces are synchronized with, to restore
+	// the resources deleted or modified in the worker clusters
+	SyncPeriod time.Duration
+	// Pool caches the connections to the clusters of ClusterEnvironments
+	Pool *workercluster.Pool
+}
+
+//+kubebuilder:rbac:groups=primaza.io,namespace=system,resources=clusterenvironments,verbs=get;list;watch
+//+kubebuilder:rbac:groups=primaza.io,namespace=system,resources=clusterenvironments/status,verbs=get;update;patch
+
+// Reconcile synchronizes the namespaces of a ClusterEnvironment and reports
+// the outcome for each of them with the Synchronized condition of its status
+func (r *NamespaceSyncReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+
+	ce := &primazaiov1alpha1.ClusterEnvironment{}
+	if err := r.Client.Get(ctx, req.NamespacedName, ce); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if ce.HasDeletionTimestamp() {
+		return ctrl.Result{}, nil
+	}
+
+	nn := ce.Status.DeepCopy().Namespaces
+	if ce.Spec.SynchronizeNamespaces {
+		cli, err := r.Pool.Client(ctx, r.Client, *ce, r.Scheme, r.Client.RESTMapper())
+		if err != nil {
+			l.Error(err, "unable to connect to the cluster, namespaces not synchronized")
+			return ctrl.Result{RequeueAfter: r.syncPeriod()}, nil
+		}
+		r.synchronizeNamespaces(ctx, cli, ce)
+	} else {
+		ce.Status.RemoveNamespaceCondition(primazaiov1alpha1.ClusterEnvironmentNamespaceConditionSynchronized)
+	}
+	ce.PruneNamespaces()
+
+	if !equality.Semantic.DeepEqual(nn, ce.Status.Namespaces) {
+		ce.UpdateSummary()
+		if err := r.Client.Status().Update(ctx, ce); err != nil {
+			if apierrors.IsConflict(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+			return ctrl.Result{}, err
+		}
+	}
+
+	if !ce.Spec.SynchronizeNamespaces {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: r.syncPeriod()}, nil
+}
+
+func (r *NamespaceSyncReconciler) synchronizeNamespaces(ctx context.Context, cli client.Client, ce *primazaiov1alpha1.ClusterEnvironment) {
+	l := log.FromContext(ctx)
+
+	sync := func(namespaces []string, t primazaiov1alpha1.ClusterEnvironmentNamespaceType, kind workercluster.AgentKind) {
+		for _, ns := range namespaces {
+			c := metav1.Condition{
+				Type:    primazaiov1alpha1.ClusterEnvironmentNamespaceConditionSynchronized,
+				Status:  metav1.ConditionTrue,
+				Reason:  constants.NamespaceSynchronizedReason,
+				Message: "namespace synchronized",
+			}
+			if err := workercluster.SynchronizeNamespace(ctx, cli, ce.Name, ns, kind); err != nil {
+				l.Error(err, "error synchronizing namespace", "namespace", ns, "type", t)
+				c.Status = metav1.ConditionFalse
+				c.Reason = constants.NamespaceSyncFailedReason
+				c.Message = err.Error()
+			}
+			ce.Status.SetNamespaceCondition(ns, t, c, ce.Generation)
+		}
+	}
+	sync(ce.Spec.ApplicationNamespaces, primazaiov1alpha1.ClusterEnvironmentApplicationNamespace, workercluster.ApplicationAgentKind)
+	sync(ce.Spec.ServiceNamespaces, primazaiov1alpha1.ClusterEnvironmentServiceNamespace, workercluster.ServiceAgentKind)
+}
+
+func (r *NamespaceSyncReconciler) syncPeriod() time.Duration {
+	if r.SyncPeriod > 0 {
+		return r.SyncPeriod
+	}
+	return DefaultNamespaceSyncPeriod
+}
+
+// SetupWithManager sets up the controller with the Manager.  Only changes
+// to the specification of ClusterEnvironments trigger a synchronization, so
+// that the updates of their status do not.
+func (r *NamespaceSyncReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("namespacesync").
+		For(&primazaiov1alpha1.ClusterEnvironment{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/crossplane"
+)
+
+// CrossplaneServiceClassReconciler generates a ServiceClass for each
+// Crossplane CompositeResourceDefinition offering a claim, so that the claims
+// provisioned through Crossplane are discovered by the service agents
+type CrossplaneServiceClassReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Namespace the ServiceClasses are generated in
+	Namespace string
+}
+
+// Reconcile creates or updates the ServiceClass generated for the
+// CompositeResourceDefinition.  ServiceClasses not generated by Primaza are
+// never overwritten, and the generated ServiceClasses are garbage collected
+// along with their CompositeResourceDefinition.
+func (r *CrossplaneServiceClassReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+
+	xrd := crossplane.NewCompositeResourceDefinition()
+	if err := r.Get(ctx, req.NamespacedName, xrd); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if xrd.GetDeletionTimestamp() != nil {
+		return ctrl.Result{}, nil
+	}
+
+	spec, err := crossplane.ServiceClassSpec(xrd)
+	switch {
+	case errors.Is(err, crossplane.ErrNoClaim):
+		l.Info("composite resource definition does not offer a claim, skipping generation")
+		return ctrl.Result{}, r.deleteGenerated(ctx, xrd)
+	case err != nil:
+		return ctrl.Result{}, err
+	case !crossplane.Enabled(xrd):
+		l.Info("service class generation disabled by annotation", "annotation", crossplane.ServiceClassAnnotation)
+		return ctrl.Result{}, r.deleteGenerated(ctx, xrd)
+	}
+
+	sc := &primazaiov1alpha1.ServiceClass{}
+	sc.Name = xrd.GetName()
+	sc.Namespace = r.Namespace
+	if err := r.Get(ctx, client.ObjectKeyFromObject(sc), sc); err == nil && !crossplane.IsOwnedBy(sc, xrd) {
+		l.Info("service class already exists, skipping generation", "service class", sc.Name)
+		return ctrl.Result{}, nil
+	} else if client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, sc, func() error {
+		sc.Spec.Resource.APIVersion = spec.Resource.APIVersion
+		sc.Spec.Resource.Kind = spec.Resource.Kind
+		sc.Spec.Resource.ServiceEndpointDefinitionMappings = spec.Resource.ServiceEndpointDefinitionMappings
+		sc.Spec.ServiceClassIdentity = spec.ServiceClassIdentity
+		return controllerutil.SetControllerReference(xrd, sc, r.Scheme)
+	})
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("error generating service class for composite resource definition %s: %w", xrd.GetName(), err)
+	}
+	l.Info("generated service class", "service class", sc.Name, "operation", op)
+	return ctrl.Result{}, nil
+}
+
+// deleteGenerated deletes the ServiceClass generated for the
+// CompositeResourceDefinition, if any
+func (r *CrossplaneServiceClassReconciler) deleteGenerated(ctx context.Context, xrd *unstructured.Unstructured) error {
+	sc := &primazaiov1alpha1.ServiceClass{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: r.Namespace, Name: xrd.GetName()}, sc); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !crossplane.IsOwnedBy(sc, xrd) {
+		return nil
+	}
+	if err := r.Delete(ctx, sc); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CrossplaneServiceClassReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("crossplane-serviceclass").
+		For(crossplane.NewCompositeResourceDefinition()).
+		Owns(&primazaiov1alpha1.ServiceClass{}).
+		Complete(r)
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/notify"
+)
+
+// NotificationWatcher sends a notification when a Cluster Environment goes
+// offline, a Service Claim fails or a Registered Service becomes unreachable.
+// It watches the changes of state, whichever controller or agent makes them.
+type NotificationWatcher struct {
+	Cache    cache.Cache
+	Notifier notify.Notifier
+}
+
+// notificationDetector returns the notifications to send for the change of
+// an object from prev to obj
+type notificationDetector func(prev, obj client.Object) []notify.Notification
+
+// Start watches the changes of state until ctx is done.  It implements
+// manager.Runnable.
+func (w *NotificationWatcher) Start(ctx context.Context) error {
+	l := log.FromContext(ctx).WithName("notifications")
+	detectors := map[client.Object]notificationDetector{
+		&primazaiov1alpha1.ClusterEnvironment{}: clusterEnvironmentNotifications,
+		&primazaiov1alpha1.ServiceClaim{}:       serviceClaimNotifications,
+		&primazaiov1alpha1.RegisteredService{}:  registeredServiceNotifications,
+	}
+	for obj, detect := range detectors {
+		inf, err := w.Cache.GetInformer(ctx, obj)
+		if err != nil {
+			return err
+		}
+
+		detect := detect
+		handler := toolscache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(o, n interface{}) {
+				prev, ok := o.(client.Object)
+				if !ok {
+					return
+				}
+				obj, ok := n.(client.Object)
+				if !ok {
+					return
+				}
+				for _, notification := range detect(prev, obj) {
+					l.Info("sending notification", "notification", notification.String())
+					if err := w.Notifier.Notify(ctx, notification); err != nil {
+						l.Error(err, "unable to send notification", "notification", notification.String())
+					}
+				}
+			},
+		}
+		if _, err := inf.AddEventHandler(handler); err != nil {
+			return err
+		}
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+func newNotification(t notify.EventType, kind string, obj client.Object, message string) notify.Notification {
+	return notify.Notification{
+		Type:      t,
+		Kind:      kind,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		Message:   message,
+		Time:      time.Now(),
+	}
+}
+
+func clusterEnvironmentNotifications(o, n client.Object) []notify.Notification {
+	old, oldOk := o.(*primazaiov1alpha1.ClusterEnvironment)
+	ce, ok := n.(*primazaiov1alpha1.ClusterEnvironment)
+	if !oldOk || !ok ||
+		ce.Status.State != primazaiov1alpha1.ClusterEnvironmentStateOffline ||
+		old.Status.State == primazaiov1alpha1.ClusterEnvironmentStateOffline {
+		return nil
+	}
+
+	message := ""
+	if c := meta.FindStatusCondition(ce.Status.Conditions, primazaiov1alpha1.ClusterEnvironmentConditionOnline); c != nil {
+		message = c.Message
+	}
+	return []notify.Notification{newNotification(notify.EventConnectionLost, "ClusterEnvironment", ce, message)}
+}
+
+func serviceClaimNotifications(o, n client.Object) []notify.Notification {
+	old, oldOk := o.(*primazaiov1alpha1.ServiceClaim)
+	sclaim, ok := n.(*primazaiov1alpha1.ServiceClaim)
+	if !oldOk || !ok {
+		return nil
+	}
+
+	var nn []notify.Notification
+	if sclaim.Status.State == primazaiov1alpha1.ServiceClaimStateInvalid &&
+		old.Status.State != primazaiov1alpha1.ServiceClaimStateInvalid {
+		message := ""
+		if c := meta.FindStatusCondition(sclaim.Status.Conditions, primazaiov1alpha1.ServiceClaimConditionReady); c != nil {
+			message = c.Message
+		}
+		nn = append(nn, newNotification(notify.EventClaimFailed, "ServiceClaim", sclaim, message))
+	}
+
+	failed := map[string]bool{}
+	for _, b := range old.Status.Bindings {
+		failed[b.ClusterEnvironment+"/"+b.Namespace] = b.State == primazaiov1alpha1.ServiceClaimBindingStateFailed
+	}
+	for _, b := range sclaim.Status.Bindings {
+		if b.State == primazaiov1alpha1.ServiceClaimBindingStateFailed && !failed[b.ClusterEnvironment+"/"+b.Namespace] {
+			message := fmt.Sprintf("binding to %s in cluster environment %s failed: %s", b.Namespace, b.ClusterEnvironment, b.Message)
+			nn = append(nn, newNotification(notify.EventClaimFailed, "ServiceClaim", sclaim, message))
+		}
+	}
+	return nn
+}
+
+func registeredServiceNotifications(o, n client.Object) []notify.Notification {
+	old, oldOk := o.(*primazaiov1alpha1.RegisteredService)
+	rs, ok := n.(*primazaiov1alpha1.RegisteredService)
+	if !oldOk || !ok ||
+		rs.Status.State != primazaiov1alpha1.RegisteredServiceStateUnreachable ||
+		old.Status.State == primazaiov1alpha1.RegisteredServiceStateUnreachable {
+		return nil
+	}
+
+	message := ""
+	if c := meta.FindStatusCondition(rs.Status.Conditions, primazaiov1alpha1.RegisteredServiceConditionHealthy); c != nil {
+		message = c.Message
+	}
+	return []notify.Notification{newNotification(notify.EventHealthCheckFailed, "RegisteredService", rs, message)}
+}
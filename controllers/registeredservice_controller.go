@@ -19,21 +19,36 @@ package controllers
 import (
 	"context"
 	"errors"
+	"sort"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8errors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
-	"github.com/primaza/primaza/pkg/envtag"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+	"github.com/primaza/primaza/pkg/primaza/controlplane"
+	"github.com/primaza/primaza/pkg/primaza/workercluster"
 )
 
 // RegisteredServiceReconciler reconciles a RegisteredService object
 type RegisteredServiceReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	Recorder record.EventRecorder
+
+	// FailoverClaims moves back to pending the claims whose registered
+	// service is deregistered, so that they can claim another service,
+	// unless their rebind policy says otherwise
+	FailoverClaims bool
+
+	// Pool caches the connections to the clusters of ClusterEnvironments
+	Pool *workercluster.Pool
 }
 
 func ServiceInCatalog(sc primazaiov1alpha1.ServiceCatalog, serviceName string) int {
@@ -107,9 +122,15 @@ func (r *RegisteredServiceReconciler) addServiceToCatalogs(ctx context.Context,
 		return err
 	}
 
+	var cel primazaiov1alpha1.ClusterEnvironmentList
+	if err := r.List(ctx, &cel, client.InNamespace(rs.Namespace)); err != nil {
+		log.Error(err, "Error found getting list of ClusterEnvironment")
+		return err
+	}
+
 	var errs []error
 	for _, sc := range catalogs.Items {
-		if rs.Spec.Constraints == nil || envtag.Match(sc.Name, rs.Spec.Constraints.Environments) {
+		if rs.Spec.Constraints.Allows(sc.Name, cel.Items) {
 			err = r.addServiceToCatalog(ctx, sc, rs)
 			if err != nil {
 				log.Error(err, "Error found adding RegisteredService to ServiceCatalog")
@@ -125,28 +146,28 @@ func (r *RegisteredServiceReconciler) addServiceToCatalogs(ctx context.Context,
 func (r *RegisteredServiceReconciler) addServiceToCatalog(ctx context.Context, sc primazaiov1alpha1.ServiceCatalog, rs primazaiov1alpha1.RegisteredService) error {
 	log := log.FromContext(ctx)
 
-	// Extracting Keys of SED
-	sedKeys := make([]string, 0, len(rs.Spec.ServiceEndpointDefinition))
-	for i := 0; i < len(rs.Spec.ServiceEndpointDefinition); i++ {
-		sedKeys = append(sedKeys, rs.Spec.ServiceEndpointDefinition[i].Name)
-	}
+	scs := controlplane.CatalogService(rs)
 
-	// Initializing Service Catalog Service
-	scs := primazaiov1alpha1.ServiceCatalogService{
-		Name:                          rs.Name,
-		ServiceClassIdentity:          rs.Spec.ServiceClassIdentity,
-		ServiceEndpointDefinitionKeys: sedKeys,
-	}
-
-	if ServiceInCatalog(sc, scs.Name) == -1 {
-		log.Info("Updating Service Catalog")
+	// the entry is refreshed when the service changed, e.g. its metadata
+	si := ServiceInCatalog(sc, scs.Name)
+	switch {
+	case si == -1:
 		sc.Spec.Services = append(sc.Spec.Services, scs)
-		err := r.Update(ctx, &sc)
-		if err != nil {
-			// Service Catalog update failed
-			return err
-		}
+	case equality.Semantic.DeepEqual(sc.Spec.Services[si], scs):
+		return nil
+	default:
+		sc.Spec.Services[si] = scs
+	}
+	// services are sorted by name, so that the catalog does not depend on
+	// the order services are registered in, e.g. when diffed by GitOps tools
+	sort.SliceStable(sc.Spec.Services, func(i, j int) bool {
+		return sc.Spec.Services[i].Name < sc.Spec.Services[j].Name
+	})
 
+	log.Info("Updating Service Catalog")
+	if err := r.Update(ctx, &sc); err != nil {
+		// Service Catalog update failed
+		return err
 	}
 
 	return nil
@@ -183,6 +204,11 @@ func (r *RegisteredServiceReconciler) Reconcile(ctx context.Context, req ctrl.Re
 			return ctrl.Result{}, err
 		}
 
+		if err = r.handleClaimedServiceDeregistration(ctx, req.NamespacedName.Namespace, req.Name); err != nil {
+			log.Error(err, "Error handling deregistration of claimed service")
+			return ctrl.Result{}, err
+		}
+
 		return ctrl.Result{}, nil
 
 	} else if err != nil {
@@ -190,43 +216,47 @@ func (r *RegisteredServiceReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		return ctrl.Result{}, err
 	}
 
-	if rs.Status == (primazaiov1alpha1.RegisteredServiceStatus{}) {
-		rs.Status = primazaiov1alpha1.RegisteredServiceStatus{
-			State: primazaiov1alpha1.RegisteredServiceStateAvailable,
-		}
+	if rs.Status.State == "" {
+		rs.Status.State = primazaiov1alpha1.RegisteredServiceStateAvailable
+		rs.Status.Transitions = primazaiov1alpha1.RecordStateTransition(rs.Status.Transitions,
+			rs.Status.State, constants.ServiceRegisteredReason, constants.ControlPlaneActor)
 		log.Info("Updating status of RegisteredService")
+		rs.UpdateSummary()
 		err = r.Status().Update(ctx, &rs)
 		if err != nil {
 			log.Error(err, "RegisteredService Status Failed")
 			return ctrl.Result{}, err
 		}
 
-		err = r.addServiceToCatalogs(ctx, rs)
-
-		if err != nil {
-			// Service Catalog update failed
-			log.Error(err, "Error adding service to ServiceCatalog")
-			return ctrl.Result{}, err
-		}
+	}
 
-	} else if rs.Status.State == primazaiov1alpha1.RegisteredServiceStateAvailable {
+	// services that are in maintenance or that can not accept any more claims
+	// are not listed in the catalogs
+	if rs.Listed() {
 		err = r.addServiceToCatalogs(ctx, rs)
-
-		if err != nil {
-			// Service Catalog update failed
-			log.Error(err, "Error adding service to ServiceCatalog")
-			return ctrl.Result{}, err
-		}
-	} else if rs.Status.State == primazaiov1alpha1.RegisteredServiceStateClaimed ||
-		rs.Status.State == primazaiov1alpha1.RegisteredServiceStateUnreachable {
-
+	} else {
 		err = r.removeServiceFromCatalogs(ctx, req.NamespacedName.Namespace, req.Name)
+	}
+	if err != nil {
+		// Service Catalog update failed
+		log.Error(err, "Error updating ServiceCatalog")
+		return ctrl.Result{}, err
+	}
 
-		if err != nil {
-			// Service Catalog update failed
-			log.Error(err, "Error removing service from ServiceCatalog")
-			return ctrl.Result{}, err
-		}
+	switch rs.Status.State {
+	case primazaiov1alpha1.RegisteredServiceStateUnreachable:
+		err = r.handleClaimedServiceUnreachable(ctx, rs)
+	case primazaiov1alpha1.RegisteredServiceStateClaimed:
+		err = r.handleClaimedServiceRecovery(ctx, rs)
+	}
+	if err != nil {
+		log.Error(err, "Error handling the claims of the registered service")
+		return ctrl.Result{}, err
+	}
+
+	if err = r.handleMaintenance(ctx, rs); err != nil {
+		log.Error(err, "Error handling the maintenance of the registered service")
+		return ctrl.Result{}, err
 	}
 
 	return ctrl.Result{}, nil
@@ -0,0 +1,248 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+	"github.com/primaza/primaza/pkg/primaza/controlplane"
+	"github.com/primaza/primaza/pkg/slices"
+)
+
+var claimedServiceDeregistrations = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "primaza_claimed_registeredservice_deregistrations_total",
+		Help: "Number of registered services deregistered while claimed",
+	},
+	[]string{"namespace"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(claimedServiceDeregistrations)
+}
+
+// handleClaimedServiceDeregistration marks as degraded the claims that were
+// resolved with a registered service that does not exist anymore, and notifies
+// the application namespaces the claims are bound to.  Claims whose rebind
+// policy allows it are moved back to pending so that they can be resolved by
+// another registered service.
+func (r *RegisteredServiceReconciler) handleClaimedServiceDeregistration(ctx context.Context, namespace, serviceName string) error {
+	message := fmt.Sprintf("claimed registered service %s has been deregistered", serviceName)
+	return r.handleLostClaimedService(ctx, namespace, serviceName, constants.ServiceDeregisteredReason, message)
+}
+
+// handleClaimedServiceUnreachable is the counterpart of
+// handleClaimedServiceDeregistration for registered services that became
+// unreachable.  Claims are only moved back to pending if their rebind policy
+// is OnUnreachable, and the registered service is released.
+func (r *RegisteredServiceReconciler) handleClaimedServiceUnreachable(ctx context.Context, rs primazaiov1alpha1.RegisteredService) error {
+	message := fmt.Sprintf("claimed registered service %s is unreachable", rs.Name)
+	return r.handleLostClaimedService(ctx, rs.Namespace, rs.Name, constants.ServiceUnreachableReason, message)
+}
+
+func (r *RegisteredServiceReconciler) handleLostClaimedService(ctx context.Context, namespace, serviceName, reason, message string) error {
+	l := log.FromContext(ctx)
+	unreachable := reason == constants.ServiceUnreachableReason
+
+	var scl primazaiov1alpha1.ServiceClaimList
+	if err := r.List(ctx, &scl, &client.ListOptions{Namespace: namespace}); err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range scl.Items {
+		sclaim := scl.Items[i]
+		if sclaim.Status.State != primazaiov1alpha1.ServiceClaimStateResolved ||
+			sclaim.Status.RegisteredService != serviceName {
+			continue
+		}
+		// unreachable services are reconciled until they recover, claims
+		// are only handled the first time
+		if c := meta.FindStatusCondition(sclaim.Status.Conditions, primazaiov1alpha1.ServiceClaimConditionDegraded); c != nil && c.Reason == reason {
+			continue
+		}
+
+		l.Info(message, "service claim", sclaim.Name, "registered service", serviceName)
+		if !unreachable {
+			claimedServiceDeregistrations.WithLabelValues(namespace).Inc()
+		}
+
+		if err := r.notifyApplicationNamespaces(ctx, sclaim, reason, message); err != nil {
+			// notification is best-effort
+			l.Error(err, "error notifying application namespaces", "service claim", sclaim.Name)
+		}
+
+		meta.SetStatusCondition(&sclaim.Status.Conditions, metav1.Condition{
+			Type:    primazaiov1alpha1.ServiceClaimConditionDegraded,
+			Status:  metav1.ConditionTrue,
+			Reason:  reason,
+			Message: message,
+		})
+		rebind := sclaim.Spec.Rebinds(unreachable, r.FailoverClaims)
+		if rebind {
+			sclaim.Status.State = primazaiov1alpha1.ServiceClaimStatePending
+			sclaim.Status.RegisteredService = ""
+			sclaim.Status.PreviousRegisteredService = serviceName
+			sclaim.Status.Transitions = primazaiov1alpha1.RecordStateTransition(sclaim.Status.Transitions,
+				string(sclaim.Status.State), reason, constants.ControlPlaneActor)
+		}
+		sclaim.UpdateSummary()
+		if err := r.Status().Update(ctx, &sclaim); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if rebind {
+			r.Recorder.Eventf(&sclaim, corev1.EventTypeWarning, reason, "%s, rebinding the claim", message)
+		} else {
+			r.Recorder.Event(&sclaim, corev1.EventTypeWarning, reason, message)
+		}
+		if rebind && unreachable {
+			if err := r.releaseUnreachableService(ctx, namespace, serviceName, sclaim); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// releaseUnreachableService forgets that the unreachable registered service
+// is claimed by the given claim, which has been rebound.  The registered
+// service only becomes Available once it recovers, see
+// handleClaimedServiceRecovery.
+func (r *RegisteredServiceReconciler) releaseUnreachableService(ctx context.Context, namespace, serviceName string, sclaim primazaiov1alpha1.ServiceClaim) error {
+	var rs primazaiov1alpha1.RegisteredService
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: serviceName}, &rs); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !slices.ItemContains(rs.Status.Claims, sclaim.Name) {
+		return nil
+	}
+
+	rs.Status.RemoveClaim(sclaim.Name)
+	return r.Status().Update(ctx, &rs)
+}
+
+// handleClaimedServiceRecovery clears the Degraded condition of the claims
+// still resolved with a registered service that is reachable again.  If
+// none is, because they have all been rebound, the registered service is
+// released.
+func (r *RegisteredServiceReconciler) handleClaimedServiceRecovery(ctx context.Context, rs primazaiov1alpha1.RegisteredService) error {
+	var scl primazaiov1alpha1.ServiceClaimList
+	if err := r.List(ctx, &scl, &client.ListOptions{Namespace: rs.Namespace}); err != nil {
+		return err
+	}
+
+	claimed := false
+	var errs []error
+	for i := range scl.Items {
+		sclaim := scl.Items[i]
+		if sclaim.Status.State != primazaiov1alpha1.ServiceClaimStateResolved ||
+			sclaim.Status.RegisteredService != rs.Name {
+			continue
+		}
+		claimed = true
+
+		c := meta.FindStatusCondition(sclaim.Status.Conditions, primazaiov1alpha1.ServiceClaimConditionDegraded)
+		if c == nil || c.Reason != constants.ServiceUnreachableReason {
+			continue
+		}
+		meta.RemoveStatusCondition(&sclaim.Status.Conditions, primazaiov1alpha1.ServiceClaimConditionDegraded)
+		sclaim.UpdateSummary()
+		if err := r.Status().Update(ctx, &sclaim); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.Recorder.Eventf(&sclaim, corev1.EventTypeNormal, constants.HealthCheckPassedReason,
+			"claimed registered service %s is reachable again", rs.Name)
+	}
+
+	if !claimed && recoveredFromUnreachable(rs) {
+		log.FromContext(ctx).Info("releasing recovered registered service, its claims have been rebound")
+		rs.Status.State = primazaiov1alpha1.RegisteredServiceStateAvailable
+		rs.Status.Claims = nil
+		rs.Status.ClaimedBy = ""
+		rs.Status.Transitions = primazaiov1alpha1.RecordStateTransition(rs.Status.Transitions,
+			rs.Status.State, constants.ServiceReleasedReason, constants.ControlPlaneActor)
+		rs.UpdateSummary()
+		if err := r.Status().Update(ctx, &rs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// recoveredFromUnreachable reports whether the registered service has been
+// moved to its current state by a passing health check
+func recoveredFromUnreachable(rs primazaiov1alpha1.RegisteredService) bool {
+	l := len(rs.Status.Transitions)
+	return l > 0 && rs.Status.Transitions[l-1].Reason == constants.HealthCheckPassedReason
+}
+
+func (r *RegisteredServiceReconciler) notifyApplicationNamespaces(ctx context.Context, sclaim primazaiov1alpha1.ServiceClaim, reason, message string) error {
+	var cel []primazaiov1alpha1.ClusterEnvironment
+	if acc := sclaim.Spec.ApplicationClusterContext; acc != nil {
+		ce := primazaiov1alpha1.ClusterEnvironment{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: sclaim.Namespace, Name: acc.ClusterEnvironmentName}, &ce); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		cel = append(cel, ce)
+	} else {
+		var l primazaiov1alpha1.ClusterEnvironmentList
+		if err := r.List(ctx, &l, &client.ListOptions{Namespace: sclaim.Namespace}); err != nil {
+			return err
+		}
+		for _, ce := range l.Items {
+			if ce.Spec.EnvironmentName == sclaim.Spec.EnvironmentTag {
+				cel = append(cel, ce)
+			}
+		}
+	}
+
+	var errs []error
+	for _, ce := range cel {
+		cli, err := r.Pool.Client(ctx, r.Client, ce, r.Scheme, r.Client.RESTMapper())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		ns := ce.Spec.ApplicationNamespaces
+		if acc := sclaim.Spec.ApplicationClusterContext; acc != nil {
+			ns = acc.Namespaces()
+		}
+		if err := controlplane.NotifyServiceBindings(ctx, cli, sclaim, ns, reason, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
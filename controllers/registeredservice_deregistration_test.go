@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+func TestRegisteredServiceDeregistrationNotifiesClaims(t *testing.T) {
+	ctx := context.Background()
+	namespace := "primaza-deregistration"
+	rs := &primazaiov1alpha1.RegisteredService{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: namespace}}
+	claim := func(name, service string, policy primazaiov1alpha1.ServiceClaimRebindPolicy) *primazaiov1alpha1.ServiceClaim {
+		return &primazaiov1alpha1.ServiceClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       primazaiov1alpha1.ServiceClaimSpec{RebindPolicy: policy},
+			Status: primazaiov1alpha1.ServiceClaimStatus{
+				State:             primazaiov1alpha1.ServiceClaimStateResolved,
+				RegisteredService: service,
+			},
+		}
+	}
+	cli := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(
+		rs,
+		claim("orders", "db", primazaiov1alpha1.ServiceClaimRebindPolicyNever),
+		claim("billing", "db", primazaiov1alpha1.ServiceClaimRebindPolicyOnDeregistration),
+		claim("reports", "warehouse", primazaiov1alpha1.ServiceClaimRebindPolicyOnDeregistration),
+	).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &RegisteredServiceReconciler{Client: cli, Scheme: cli.Scheme(), Recorder: recorder}
+
+	deregistrations := func() float64 {
+		m := &dto.Metric{}
+		if err := claimedServiceDeregistrations.WithLabelValues(namespace).Write(m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetCounter().GetValue()
+	}
+	before := deregistrations()
+
+	if err := cli.Delete(ctx, rs); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "db"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(name string) primazaiov1alpha1.ServiceClaim {
+		sclaim := primazaiov1alpha1.ServiceClaim{}
+		if err := cli.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &sclaim); err != nil {
+			t.Fatal(err)
+		}
+		return sclaim
+	}
+	degraded := func(sclaim primazaiov1alpha1.ServiceClaim) bool {
+		c := meta.FindStatusCondition(sclaim.Status.Conditions, primazaiov1alpha1.ServiceClaimConditionDegraded)
+		return c != nil && c.Status == metav1.ConditionTrue && c.Reason == constants.ServiceDeregisteredReason
+	}
+
+	// the claim that does not rebind keeps the service, but is degraded
+	orders := get("orders")
+	if orders.Status.State != primazaiov1alpha1.ServiceClaimStateResolved || orders.Status.RegisteredService != "db" {
+		t.Errorf("expected orders to stay resolved with db, got %s with %q", orders.Status.State, orders.Status.RegisteredService)
+	}
+	if !degraded(orders) {
+		t.Errorf("expected orders to be degraded, got %v", orders.Status.Conditions)
+	}
+
+	// the claim that rebinds is moved back to pending
+	billing := get("billing")
+	if billing.Status.State != primazaiov1alpha1.ServiceClaimStatePending || billing.Status.PreviousRegisteredService != "db" {
+		t.Errorf("expected billing to be pending after db, got %s after %q", billing.Status.State, billing.Status.PreviousRegisteredService)
+	}
+	if !degraded(billing) {
+		t.Errorf("expected billing to be degraded, got %v", billing.Status.Conditions)
+	}
+
+	// claims of other services are left alone
+	if reports := get("reports"); reports.Status.State != primazaiov1alpha1.ServiceClaimStateResolved || len(reports.Status.Conditions) != 0 {
+		t.Errorf("expected reports to be left alone, got %s with %v", reports.Status.State, reports.Status.Conditions)
+	}
+
+	events := []string{}
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	want := []string{
+		"Warning RegisteredServiceDeregistered claimed registered service db has been deregistered",
+		"Warning RegisteredServiceDeregistered claimed registered service db has been deregistered, rebinding the claim",
+	}
+	for _, w := range want {
+		found := false
+		for _, e := range events {
+			found = found || e == w
+		}
+		if !found {
+			t.Errorf("expected event %q, got %v", w, strings.Join(events, "; "))
+		}
+	}
+
+	if got := deregistrations() - before; got != 2 {
+		t.Errorf("expected 2 deregistrations to be counted, got %v", got)
+	}
+}
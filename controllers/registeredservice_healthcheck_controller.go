@@ -0,0 +1,395 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+// healthCheckJobsHistory is the number of finished health check Jobs kept
+// for each RegisteredService
+const healthCheckJobsHistory = 3
+
+// RegisteredServiceHealthCheckReconciler periodically runs the health check
+// container of RegisteredServices as Jobs, and moves them to the Unreachable state when
+// the health check fails
+type RegisteredServiceHealthCheckReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=primaza.io,namespace=system,resources=registeredservices,verbs=get;list;watch
+//+kubebuilder:rbac:groups=primaza.io,namespace=system,resources=registeredservices/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=primaza.io,namespace=system,resources=serviceclaims,verbs=get;list;watch
+//+kubebuilder:rbac:groups=batch,namespace=system,resources=jobs,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=core,namespace=system,resources=secrets,verbs=get;list;watch;create;update
+
+func (r *RegisteredServiceHealthCheckReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+
+	var rs primazaiov1alpha1.RegisteredService
+	if err := r.Get(ctx, req.NamespacedName, &rs); err != nil {
+		// health check Jobs are garbage collected with their RegisteredService
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	jobs, err := r.healthCheckJobs(ctx, rs)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// probes are run by the service agent
+	hc := rs.Spec.HealthCheck
+	if hc == nil || hc.Container == nil {
+		return ctrl.Result{}, r.deleteJobs(ctx, jobs)
+	}
+
+	// finished Jobs may have been deleted after their TTL, so the next run
+	// is scheduled from the latest recorded result if there is no Job left
+	next := time.Now()
+	if n := len(rs.Status.HealthChecks); n > 0 {
+		next = rs.Status.HealthChecks[n-1].Time.Add(hc.RunInterval())
+	}
+	if len(jobs) > 0 {
+		last := jobs[0]
+		next = last.CreationTimestamp.Add(hc.RunInterval())
+		result := healthCheckJobResult(last)
+		switch {
+		case result != nil:
+			if err := r.updateState(ctx, rs, *result); err != nil {
+				return ctrl.Result{}, err
+			}
+		case !hc.ReplacesRunningChecks():
+			// the completion of the Job triggers a new reconciliation
+			return ctrl.Result{}, nil
+		case time.Until(next) > 0:
+			return ctrl.Result{RequeueAfter: time.Until(next)}, nil
+		default:
+			l.Info("replacing running health check", "job", last.Name)
+			if err := r.deleteJobs(ctx, jobs[:1]); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	if len(jobs) > healthCheckJobsHistory {
+		if err := r.deleteJobs(ctx, jobs[healthCheckJobsHistory:]); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if wait := time.Until(next); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	secret, err := r.writeHealthCheckSecret(ctx, rs)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	job, err := r.healthCheckJob(rs, secret.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	l.Info("running health check", "job", job.GenerateName)
+	if err := r.Create(ctx, job); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// healthCheckJobs returns the health check Jobs of the given RegisteredService,
+// from the most to the least recent
+func (r *RegisteredServiceHealthCheckReconciler) healthCheckJobs(ctx context.Context, rs primazaiov1alpha1.RegisteredService) ([]batchv1.Job, error) {
+	var jl batchv1.JobList
+	if err := r.List(ctx, &jl, client.InNamespace(rs.Namespace)); err != nil {
+		return nil, err
+	}
+
+	jobs := []batchv1.Job{}
+	for i := range jl.Items {
+		if metav1.IsControlledBy(&jl.Items[i], &rs) {
+			jobs = append(jobs, jl.Items[i])
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[j].CreationTimestamp.Before(&jobs[i].CreationTimestamp)
+	})
+	return jobs, nil
+}
+
+// healthCheckJobResult returns the result of the Job, or nil if it is not
+// finished
+func healthCheckJobResult(job batchv1.Job) *primazaiov1alpha1.HealthCheckResult {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue || (c.Type != batchv1.JobComplete && c.Type != batchv1.JobFailed) {
+			continue
+		}
+
+		start := job.CreationTimestamp
+		if job.Status.StartTime != nil {
+			start = *job.Status.StartTime
+		}
+		return &primazaiov1alpha1.HealthCheckResult{
+			Time:     start,
+			Passed:   c.Type == batchv1.JobComplete,
+			Duration: metav1.Duration{Duration: c.LastTransitionTime.Sub(start.Time)},
+			Message:  c.Message,
+		}
+	}
+	return nil
+}
+
+// deleteJobs deletes the given Jobs along with their Pods
+func (r *RegisteredServiceHealthCheckReconciler) deleteJobs(ctx context.Context, jobs []batchv1.Job) error {
+	var errs []error
+	for i := range jobs {
+		if err := r.Delete(ctx, &jobs[i], client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !k8errors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// updateState records the health check result in the RegisteredService's
+// status.  The RegisteredService is moved to the Unreachable state when its
+// health check fails failureThreshold times in a row, and back to Available,
+// or Claimed if a ServiceClaim is resolved with it, when the health check
+// passes successThreshold times in a row.
+func (r *RegisteredServiceHealthCheckReconciler) updateState(ctx context.Context, rs primazaiov1alpha1.RegisteredService, result primazaiov1alpha1.HealthCheckResult) error {
+	reason := constants.HealthCheckFailedReason
+	if result.Passed {
+		reason = constants.HealthCheckPassedReason
+	}
+
+	state := rs.Status.State
+	recorded := rs.Status.RecordHealthCheck(result, reason)
+	if rs.Spec.HealthCheck.ThresholdReached(rs.Status.HealthChecks) {
+		switch {
+		case !result.Passed:
+			state = primazaiov1alpha1.RegisteredServiceStateUnreachable
+		case rs.Status.State == primazaiov1alpha1.RegisteredServiceStateUnreachable:
+			claimed, err := r.isClaimed(ctx, rs)
+			if err != nil {
+				return err
+			}
+			state = primazaiov1alpha1.RegisteredServiceStateAvailable
+			if claimed {
+				state = primazaiov1alpha1.RegisteredServiceStateClaimed
+			}
+		}
+	}
+
+	if !recorded && rs.Status.State == state {
+		return nil
+	}
+
+	if rs.Status.State != state {
+		log.FromContext(ctx).Info("updating registered service state", "state", state, "reason", reason)
+		rs.Status.State = state
+		rs.Status.Transitions = primazaiov1alpha1.RecordStateTransition(rs.Status.Transitions,
+			state, reason, constants.ControlPlaneActor)
+	}
+	rs.UpdateSummary()
+	return r.Status().Update(ctx, &rs)
+}
+
+func (r *RegisteredServiceHealthCheckReconciler) isClaimed(ctx context.Context, rs primazaiov1alpha1.RegisteredService) (bool, error) {
+	var scl primazaiov1alpha1.ServiceClaimList
+	if err := r.List(ctx, &scl, client.InNamespace(rs.Namespace)); err != nil {
+		return false, err
+	}
+
+	for _, sclaim := range scl.Items {
+		if sclaim.Status.State == primazaiov1alpha1.ServiceClaimStateResolved &&
+			sclaim.Status.RegisteredService == rs.Name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// healthCheckPrefix returns the prefix of the names of the objects created to
+// run the health check of the given RegisteredService.  Job names are used as
+// Pod label values, that are at most 63 characters long.
+func healthCheckPrefix(rs primazaiov1alpha1.RegisteredService) string {
+	return strings.TrimRight(fmt.Sprintf("%.44s", rs.Name), ".-") + "-healthcheck"
+}
+
+// writeHealthCheckSecret writes the Secret holding the values of the service
+// endpoint definition of the given RegisteredService, including the ones read
+// from other Secrets, so that they can be injected into the health check
+// container.  As in binding secrets, the items of the service class identity
+// are included too, unless a service endpoint definition item has the same
+// name.
+func (r *RegisteredServiceHealthCheckReconciler) writeHealthCheckSecret(ctx context.Context, rs primazaiov1alpha1.RegisteredService) (*corev1.Secret, error) {
+	data := map[string][]byte{}
+	for _, sci := range rs.Spec.ServiceClassIdentity {
+		data[sci.Name] = []byte(sci.Value)
+	}
+	for _, sed := range rs.Spec.ServiceEndpointDefinition {
+		if sed.ValueFromSecret == nil {
+			data[sed.Name] = []byte(sed.Value)
+			continue
+		}
+
+		var s corev1.Secret
+		if err := r.Get(ctx, types.NamespacedName{Namespace: rs.Namespace, Name: sed.ValueFromSecret.Name}, &s); err != nil {
+			return nil, err
+		}
+		data[sed.Name] = s.Data[sed.ValueFromSecret.Key]
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      healthCheckPrefix(rs) + "-sed",
+			Namespace: rs.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		secret.Data = data
+		return controllerutil.SetControllerReference(&rs, secret, r.Scheme)
+	})
+	return secret, err
+}
+
+// sedEnvVarName returns the name of the environment variable holding the given
+// service endpoint definition item, e.g. DB_PORT for db-port
+func sedEnvVarName(name string) string {
+	return strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z':
+			return c - 'a' + 'A'
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			return c
+		}
+		return '_'
+	}, name)
+}
+
+// healthCheckJob builds a Job running the health check container of the given
+// RegisteredService, with the values of its service endpoint definition read
+// from the given Secret.  The Job fails if the check does not complete within
+// the health check deadline, and is deleted after its TTL if one is set.
+func (r *RegisteredServiceHealthCheckReconciler) healthCheckJob(rs primazaiov1alpha1.RegisteredService, secretName string) (*batchv1.Job, error) {
+	hc := rs.Spec.HealthCheck
+	command, err := primazaiov1alpha1.ParseCommand(hc.Container.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	container := corev1.Container{
+		Name:            "healthcheck",
+		Image:           hc.Container.Image,
+		Command:         command,
+		ImagePullPolicy: hc.Container.ImagePullPolicy,
+		SecurityContext: hc.Container.SecurityContext,
+	}
+	if hc.Container.Resources != nil {
+		container.Resources = *hc.Container.Resources
+	}
+	for _, sed := range rs.Spec.ServiceEndpointDefinition {
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name: sedEnvVarName(sed.Name),
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+					Key:                  sed.Name,
+				},
+			},
+		})
+	}
+	var volumes []corev1.Volume
+	if mountPath := hc.Container.ServiceEndpointDefinitionMountPath; mountPath != "" {
+		items := make([]corev1.KeyToPath, 0, len(rs.Spec.ServiceEndpointDefinition))
+		for _, sed := range rs.Spec.ServiceEndpointDefinition {
+			items = append(items, corev1.KeyToPath{Key: sed.Name, Path: sed.Name})
+		}
+		volumes = append(volumes, corev1.Volume{
+			Name:         "sed",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: secretName, Items: items}},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      "sed",
+			MountPath: mountPath,
+			ReadOnly:  true,
+		})
+	}
+	if mountPath := hc.Container.BindingSecretMountPath; mountPath != "" {
+		volumes = append(volumes, corev1.Volume{
+			Name:         "binding",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: secretName}},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      "binding",
+			MountPath: mountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: healthCheckPrefix(rs) + "-",
+			Namespace:    rs.Namespace,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            pointer.Int32(0),
+			ActiveDeadlineSeconds:   pointer.Int64(int64(hc.RunDeadline().Seconds())),
+			TTLSecondsAfterFinished: hc.TTLSecondsAfterFinished,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: hc.Container.ServiceAccountName,
+					Containers:         []corev1.Container{container},
+					Volumes:            volumes,
+				},
+			},
+		},
+	}
+	if err := controllerutil.SetControllerReference(&rs, job, r.Scheme); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RegisteredServiceHealthCheckReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("registeredservice-healthcheck").
+		For(&primazaiov1alpha1.RegisteredService{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	k8errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+var registeredServiceIdle = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "primaza_registeredservice_idle",
+		Help: "Whether a registered service has not been claimed for longer than the idle period",
+	},
+	[]string{"namespace", "name"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(registeredServiceIdle)
+}
+
+// RegisteredServiceIdleReconciler flags RegisteredServices that have been
+// available without being claimed for longer than IdlePeriod
+type RegisteredServiceIdleReconciler struct {
+	client.Client
+	IdlePeriod time.Duration
+}
+
+//+kubebuilder:rbac:groups=primaza.io,namespace=system,resources=registeredservices,verbs=get;list;watch
+//+kubebuilder:rbac:groups=primaza.io,namespace=system,resources=registeredservices/status,verbs=get;update;patch
+
+func (r *RegisteredServiceIdleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+
+	var rs primazaiov1alpha1.RegisteredService
+	if err := r.Get(ctx, req.NamespacedName, &rs); err != nil {
+		if k8errors.IsNotFound(err) {
+			registeredServiceIdle.DeleteLabelValues(req.Namespace, req.Name)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	idleSince, requeueAfter := r.idleSince(rs)
+	if idleSince != nil {
+		registeredServiceIdle.WithLabelValues(rs.Namespace, rs.Name).Set(1)
+	} else {
+		registeredServiceIdle.WithLabelValues(rs.Namespace, rs.Name).Set(0)
+	}
+
+	if !idleSince.Equal(rs.Status.IdleSince) {
+		l.Info("updating registered service idle status", "idle since", idleSince)
+		rs.Status.IdleSince = idleSince
+		rs.UpdateSummary()
+		if err := r.Status().Update(ctx, &rs); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// idleSince returns since when the registered service is idle, or nil if it is not.
+// If the service may become idle later, it also returns the time to wait before
+// checking it again.
+func (r *RegisteredServiceIdleReconciler) idleSince(rs primazaiov1alpha1.RegisteredService) (*metav1.Time, time.Duration) {
+	// only services that are healthy, not claimed and claimable can be idle
+	if rs.Status.State != primazaiov1alpha1.RegisteredServiceStateAvailable || rs.Spec.Maintenance != nil {
+		return nil, 0
+	}
+
+	since := rs.CreationTimestamp
+	if rs.Status.LastClaimedTime != nil {
+		since = *rs.Status.LastClaimedTime
+	}
+
+	if wait := time.Until(since.Add(r.IdlePeriod)); wait > 0 {
+		return nil, wait
+	}
+	return &since, 0
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RegisteredServiceIdleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("registeredservice-idle").
+		For(&primazaiov1alpha1.RegisteredService{}).
+		Complete(r)
+}
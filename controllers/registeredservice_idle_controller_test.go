@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+func TestRegisteredServiceIdleSince(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) metav1.Time { return metav1.NewTime(now.Add(-d).Truncate(time.Second)) }
+	service := func(state string, created metav1.Time, lastClaimed *metav1.Time) primazaiov1alpha1.RegisteredService {
+		return primazaiov1alpha1.RegisteredService{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", CreationTimestamp: created},
+			Status:     primazaiov1alpha1.RegisteredServiceStatus{State: state, LastClaimedTime: lastClaimed},
+		}
+	}
+	lastClaimed := ago(90 * time.Minute)
+	recentlyClaimed := ago(10 * time.Minute)
+	maintained := service(primazaiov1alpha1.RegisteredServiceStateAvailable, ago(2*time.Hour), nil)
+	maintained.Spec.Maintenance = &primazaiov1alpha1.Maintenance{}
+
+	tests := []struct {
+		name      string
+		rs        primazaiov1alpha1.RegisteredService
+		wantSince *metav1.Time
+		wantWait  bool
+	}{
+		{name: "claimed", rs: service(primazaiov1alpha1.RegisteredServiceStateClaimed, ago(2*time.Hour), nil)},
+		{name: "unreachable", rs: service(primazaiov1alpha1.RegisteredServiceStateUnreachable, ago(2*time.Hour), nil)},
+		{name: "in maintenance", rs: maintained},
+		{name: "available for less than the idle period", rs: service(primazaiov1alpha1.RegisteredServiceStateAvailable, ago(10*time.Minute), nil), wantWait: true},
+		{name: "never claimed for longer than the idle period", rs: service(primazaiov1alpha1.RegisteredServiceStateAvailable, ago(2*time.Hour), nil), wantSince: ptrTime(ago(2 * time.Hour))},
+		{name: "released for longer than the idle period", rs: service(primazaiov1alpha1.RegisteredServiceStateAvailable, ago(2*time.Hour), &lastClaimed), wantSince: &lastClaimed},
+		{name: "released for less than the idle period", rs: service(primazaiov1alpha1.RegisteredServiceStateAvailable, ago(2*time.Hour), &recentlyClaimed), wantWait: true},
+	}
+	r := &RegisteredServiceIdleReconciler{IdlePeriod: time.Hour}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			since, wait := r.idleSince(tt.rs)
+			if !since.Equal(tt.wantSince) {
+				t.Errorf("idleSince() = %v, want %v", since, tt.wantSince)
+			}
+			if (wait > 0) != tt.wantWait || wait > time.Hour {
+				t.Errorf("idleSince() wait = %v, want a wait %v", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestRegisteredServiceIdleReconcile(t *testing.T) {
+	created := metav1.NewTime(time.Now().Add(-48 * time.Hour).Truncate(time.Second))
+	rs := &primazaiov1alpha1.RegisteredService{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "primaza-system", CreationTimestamp: created},
+		Status:     primazaiov1alpha1.RegisteredServiceStatus{State: primazaiov1alpha1.RegisteredServiceStateAvailable},
+	}
+	cli := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(rs).Build()
+	r := &RegisteredServiceIdleReconciler{Client: cli, IdlePeriod: 24 * time.Hour}
+	key := types.NamespacedName{Namespace: rs.Namespace, Name: rs.Name}
+
+	reconcile := func() primazaiov1alpha1.RegisteredService {
+		t.Helper()
+		if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatal(err)
+		}
+		current := primazaiov1alpha1.RegisteredService{}
+		if err := cli.Get(context.Background(), key, &current); err != nil {
+			t.Fatal(err)
+		}
+		return current
+	}
+	idleGauge := func() float64 {
+		t.Helper()
+		m := &dto.Metric{}
+		if err := registeredServiceIdle.WithLabelValues(key.Namespace, key.Name).Write(m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetGauge().GetValue()
+	}
+
+	current := reconcile()
+	if !current.Status.IdleSince.Equal(&created) {
+		t.Errorf("expected the service to be idle since %v, got %v", created, current.Status.IdleSince)
+	}
+	if want := "idle since " + created.UTC().Format("2006-01-02"); !strings.Contains(current.Status.Summary, want) {
+		t.Errorf("expected the summary %q to contain %q", current.Status.Summary, want)
+	}
+	if idleGauge() != 1 {
+		t.Errorf("expected the idle gauge to be 1, got %v", idleGauge())
+	}
+
+	// the service is not idle any more once claimed
+	current.Status.State = primazaiov1alpha1.RegisteredServiceStateClaimed
+	if err := cli.Status().Update(context.Background(), &current); err != nil {
+		t.Fatal(err)
+	}
+	current = reconcile()
+	if current.Status.IdleSince != nil {
+		t.Errorf("expected the claimed service not to be idle, got %v", current.Status.IdleSince)
+	}
+	if strings.Contains(current.Status.Summary, "idle") {
+		t.Errorf("expected the summary %q not to report the service idle", current.Status.Summary)
+	}
+	if idleGauge() != 0 {
+		t.Errorf("expected the idle gauge to be 0, got %v", idleGauge())
+	}
+}
+
+func ptrTime(t metav1.Time) *metav1.Time {
+	return &t
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+// handleMaintenance signals the applications bound to a registered service
+// that is drained for maintenance: the claims resolved with it get the
+// Draining condition and the application namespaces they are bound to are
+// notified.  The condition is removed once the drain ends.
+func (r *RegisteredServiceReconciler) handleMaintenance(ctx context.Context, rs primazaiov1alpha1.RegisteredService) error {
+	l := log.FromContext(ctx)
+	draining := rs.Spec.Maintenance != nil && rs.Spec.Maintenance.Drain
+
+	var scl primazaiov1alpha1.ServiceClaimList
+	if err := r.List(ctx, &scl, &client.ListOptions{Namespace: rs.Namespace}); err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range scl.Items {
+		sclaim := scl.Items[i]
+		if sclaim.Status.State != primazaiov1alpha1.ServiceClaimStateResolved ||
+			sclaim.Status.RegisteredService != rs.Name {
+			continue
+		}
+
+		drained := meta.IsStatusConditionTrue(sclaim.Status.Conditions, primazaiov1alpha1.ServiceClaimConditionDraining)
+		if drained == draining {
+			continue
+		}
+
+		var message string
+		if draining {
+			message = fmt.Sprintf("claimed registered service %s is drained for maintenance", rs.Name)
+			if m := rs.Spec.Maintenance.Message; m != "" {
+				message += ": " + m
+			}
+			l.Info(message, "service claim", sclaim.Name)
+			if err := r.notifyApplicationNamespaces(ctx, sclaim, constants.ServiceDrainingReason, message); err != nil {
+				// notification is best-effort
+				l.Error(err, "error notifying application namespaces", "service claim", sclaim.Name)
+			}
+			meta.SetStatusCondition(&sclaim.Status.Conditions, metav1.Condition{
+				Type:    primazaiov1alpha1.ServiceClaimConditionDraining,
+				Status:  metav1.ConditionTrue,
+				Reason:  constants.ServiceDrainingReason,
+				Message: message,
+			})
+		} else {
+			message = fmt.Sprintf("claimed registered service %s is not drained anymore", rs.Name)
+			meta.RemoveStatusCondition(&sclaim.Status.Conditions, primazaiov1alpha1.ServiceClaimConditionDraining)
+		}
+
+		sclaim.UpdateSummary()
+		if err := r.Status().Update(ctx, &sclaim); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if draining {
+			r.Recorder.Event(&sclaim, corev1.EventTypeWarning, constants.ServiceDrainingReason, message)
+		} else {
+			r.Recorder.Event(&sclaim, corev1.EventTypeNormal, constants.ServiceDrainEndedReason, message)
+		}
+	}
+
+	return errors.Join(errs...)
+}
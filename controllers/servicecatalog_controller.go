@@ -23,12 +23,21 @@ import (
 
 	"github.com/primaza/primaza/api/v1alpha1"
 	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
-	"github.com/primaza/primaza/pkg/primaza/clustercontext"
+	"github.com/primaza/primaza/pkg/primaza/constants"
 	"github.com/primaza/primaza/pkg/primaza/controlplane"
+	"github.com/primaza/primaza/pkg/primaza/workercluster"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 //+kubebuilder:rbac:groups=primaza.io.primaza.io,namespace=system,resources=servicecatalogs,verbs=get;list;watch;create;update;patch;delete
@@ -39,6 +48,9 @@ import (
 type ServiceCatalogReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// Pool caches the connections to the clusters of ClusterEnvironments
+	Pool *workercluster.Pool
 }
 
 func (r *ServiceCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -67,20 +79,48 @@ func (r *ServiceCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		if ce.Spec.EnvironmentName == serviceCatalog.Name {
 			if err := r.PushServiceCatalog(ctx, serviceCatalog, ce); err != nil {
 				l.Error(err, fmt.Sprintf("ServiceCatalog:%v failed to be pushed to application namespaces of Cluster Envoronment:%v ", serviceCatalog, ce.Name))
-				errorList = append(errorList, err)
+				errorList = append(errorList, fmt.Errorf("cluster environment %s: %w", ce.Name, err))
 			}
 		}
 	}
+
+	setPushedCondition(&serviceCatalog, errors.Join(errorList...))
+	serviceCatalog.UpdateReadiness()
+	if err := r.Status().Update(ctx, &serviceCatalog); err != nil {
+		l.Error(err, "Failed to update ServiceCatalog status")
+		errorList = append(errorList, err)
+	}
 	return ctrl.Result{}, errors.Join(errorList...)
 }
 
+// setPushedCondition reports in the catalog's status whether it has been
+// pushed to all the application namespaces
+func setPushedCondition(serviceCatalog *v1alpha1.ServiceCatalog, err error) {
+	c := metav1.Condition{
+		Type:               v1alpha1.ServiceCatalogConditionPushed,
+		Status:             metav1.ConditionTrue,
+		Reason:             constants.ServiceCatalogPushedReason,
+		ObservedGeneration: serviceCatalog.Generation,
+	}
+	if err != nil {
+		c.Status = metav1.ConditionFalse
+		c.Reason = constants.ServiceCatalogPushFailedReason
+		c.Message = err.Error()
+	}
+	meta.SetStatusCondition(&serviceCatalog.Status.Conditions, c)
+}
+
 func (r *ServiceCatalogReconciler) PushServiceCatalog(ctx context.Context, serviceCatalog v1alpha1.ServiceCatalog, ce v1alpha1.ClusterEnvironment) error {
 	l := log.FromContext(ctx)
-	cfg, err := clustercontext.GetClusterRESTConfig(ctx, r.Client, ce.Namespace, ce.Spec.ClusterContextSecret)
+	cfg, err := r.Pool.Config(ctx, r.Client, ce)
+	if err != nil {
+		return err
+	}
+	p, err := projectServiceCatalog(ctx, r.Client, serviceCatalog, ce)
 	if err != nil {
 		return err
 	}
-	if err := controlplane.PushServiceCatalogToApplicationNamespaces(ctx, serviceCatalog, r.Scheme, r.Client, ce.Spec.ApplicationNamespaces, cfg); err != nil {
+	if err := controlplane.PushServiceCatalogToApplicationNamespaces(ctx, p, r.Scheme, r.Client, ce.Spec.ApplicationNamespaces, cfg); err != nil {
 		l.Error(err, "error pushing service catalog")
 		return err
 	}
@@ -88,9 +128,64 @@ func (r *ServiceCatalogReconciler) PushServiceCatalog(ctx context.Context, servi
 
 }
 
-// SetupWithManager sets up the controller with the Manager.
+// projectServiceCatalog gathers the claims and the registered services the
+// projection of the catalog onto the application namespaces of the cluster
+// environment depends on
+func projectServiceCatalog(ctx context.Context, cli client.Client, serviceCatalog v1alpha1.ServiceCatalog, ce v1alpha1.ClusterEnvironment) (controlplane.ServiceCatalogProjection, error) {
+	lo := client.ListOptions{Namespace: ce.Namespace}
+	var claims v1alpha1.ServiceClaimList
+	if err := cli.List(ctx, &claims, &lo); err != nil {
+		return controlplane.ServiceCatalogProjection{}, err
+	}
+	var services v1alpha1.RegisteredServiceList
+	if err := cli.List(ctx, &services, &lo); err != nil {
+		return controlplane.ServiceCatalogProjection{}, err
+	}
+
+	return controlplane.ServiceCatalogProjection{
+		Catalog:            serviceCatalog,
+		ClusterEnvironment: ce,
+		Claims:             claims.Items,
+		Services:           services.Items,
+	}, nil
+}
+
+// catalogsOfNamespace enqueues the catalogs of the namespace of the object,
+// whose projections may depend on it
+func (r *ServiceCatalogReconciler) catalogsOfNamespace(o client.Object) []reconcile.Request {
+	var catalogs v1alpha1.ServiceCatalogList
+	if err := r.List(context.Background(), &catalogs, client.InNamespace(o.GetNamespace())); err != nil {
+		return nil
+	}
+
+	rr := make([]reconcile.Request, 0, len(catalogs.Items))
+	for _, c := range catalogs.Items {
+		rr = append(rr, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: c.Namespace, Name: c.Name}})
+	}
+	return rr
+}
+
+// catalogOfClusterEnvironment enqueues the catalog of the environment of the
+// cluster environment
+func catalogOfClusterEnvironment(o client.Object) []reconcile.Request {
+	ce, ok := o.(*v1alpha1.ClusterEnvironment)
+	if !ok || ce.Spec.EnvironmentName == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: ce.Namespace, Name: ce.Spec.EnvironmentName}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.  Besides the
+// catalogs, the controller watches the claims and the registered services
+// the projections of the catalogs depend on, and the specification of the
+// cluster environments they are projected for.
 func (r *ServiceCatalogReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&primazaiov1alpha1.ServiceCatalog{}).
+		Watches(&source.Kind{Type: &primazaiov1alpha1.ServiceClaim{}}, handler.EnqueueRequestsFromMapFunc(r.catalogsOfNamespace)).
+		Watches(&source.Kind{Type: &primazaiov1alpha1.RegisteredService{}}, handler.EnqueueRequestsFromMapFunc(r.catalogsOfNamespace)).
+		Watches(&source.Kind{Type: &primazaiov1alpha1.ClusterEnvironment{}},
+			handler.EnqueueRequestsFromMapFunc(catalogOfClusterEnvironment),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Complete(r)
 }
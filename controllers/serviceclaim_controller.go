@@ -27,26 +27,36 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/google/uuid"
-	"github.com/primaza/primaza/api/v1alpha1"
 	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
-	"github.com/primaza/primaza/pkg/envtag"
-	"github.com/primaza/primaza/pkg/primaza/clustercontext"
+	"github.com/primaza/primaza/pkg/primaza/audit"
 	"github.com/primaza/primaza/pkg/primaza/constants"
 	"github.com/primaza/primaza/pkg/primaza/controlplane"
+	"github.com/primaza/primaza/pkg/primaza/matching"
+	"github.com/primaza/primaza/pkg/primaza/vault"
+	"github.com/primaza/primaza/pkg/primaza/workercluster"
 	"github.com/primaza/primaza/pkg/slices"
 )
 
 // ServiceClaimReconciler reconciles a ServiceClaim object
 type ServiceClaimReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
-	Mapper meta.RESTMapper
+	Scheme   *runtime.Scheme
+	Mapper   meta.RESTMapper
+	Recorder record.EventRecorder
+
+	// Pool caches the connections to the clusters of ClusterEnvironments
+	Pool *workercluster.Pool
+
+	// Vault stores the secret-backed values of the claims asking for it.
+	// Claims asking for it fail to resolve if nil.
+	Vault *vault.Client
 }
 
 const ServiceClaimFinalizer = "serviceclaims.primaza.io/finalizer"
@@ -54,6 +64,7 @@ const ServiceClaimFinalizer = "serviceclaims.primaza.io/finalizer"
 //+kubebuilder:rbac:groups=primaza.io,namespace=system,resources=serviceclaims,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=primaza.io,namespace=system,resources=serviceclaims/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=primaza.io,namespace=system,resources=serviceclaims/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",namespace=system,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -82,6 +93,7 @@ func (r *ServiceClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			if err := r.processClaimMarkedForDeletion(ctx, req, sclaim); err != nil {
 				return ctrl.Result{}, err
 			}
+			forgetServiceClaim(sclaim)
 			// Remove finalizer from service binding
 			if finalizerBool := controllerutil.RemoveFinalizer(&sclaim, ServiceClaimFinalizer); !finalizerBool {
 				l.Error(errors.New("Finalizer not removed for service claim"), "Finalizer not removed for service claim")
@@ -103,17 +115,24 @@ func (r *ServiceClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}
 
+	// claims with a TTL expire unless their lease is renewed
+	expired, renewWithin, err := r.checkExpiry(ctx, req, &sclaim)
+	if err != nil || expired {
+		return ctrl.Result{}, err
+	}
+
 	switch sclaim.Status.State {
 	case "":
 		sclaim.Status.ClaimID = uuid.New().String()
 		l.Info("reconciling new service claim")
-		return ctrl.Result{}, r.processPendingClaim(ctx, req, sclaim)
+		return ctrl.Result{RequeueAfter: renewWithin}, r.processPendingClaim(ctx, req, sclaim)
 	case primazaiov1alpha1.ServiceClaimStatePending:
 		l.Info("reconciling pending service claim")
-		return ctrl.Result{}, r.processPendingClaim(ctx, req, sclaim)
+		return ctrl.Result{RequeueAfter: renewWithin}, r.processPendingClaim(ctx, req, sclaim)
 	default:
 		l.Info("reconciling resolved service claim")
-		return ctrl.Result{}, nil
+		r.recordResolvedServiceClaim(ctx, sclaim)
+		return ctrl.Result{RequeueAfter: renewWithin}, nil
 	}
 }
 
@@ -145,97 +164,68 @@ func (r *ServiceClaimReconciler) processClaimMarkedForDeletion(ctx context.Conte
 		l.Info("unable to retrieve RegisteredServiceList", "error", err)
 		errs = append(errs, client.IgnoreNotFound(err))
 	}
-	var registeredServiceFound bool
-	var registeredService primazaiov1alpha1.RegisteredService
-	for _, rs := range rsl.Items {
-		// Check if the ServiceClassIdentity given in ServiceClaim is a subset of
-		// ServiceClassIdentity given in the RegisteredService
-		if checkSCISubset(sclaim.Spec.ServiceClassIdentity, rs.Spec.ServiceClassIdentity) &&
-			(rs.Spec.Constraints == nil ||
-				envtag.Match(sclaim.Spec.EnvironmentTag, rs.Spec.Constraints.Environments)) {
-			registeredServiceFound = true
-			registeredService = rs
-			break
-		}
-	}
 
 	if err := r.DeleteServiceBindingsAndSecret(ctx, req, sclaim); err != nil {
 		l.Error(err, "unable to delete service binding and secret", "Service Binding", sclaim.Name)
 		errs = append(errs, err)
 	}
+	if err := r.deleteFromVault(ctx, sclaim); err != nil {
+		l.Error(err, "unable to delete the service endpoint definition from vault", "ServiceClaim", sclaim.Name)
+		errs = append(errs, err)
+	}
 
-	if registeredServiceFound {
-		if err := r.changeServiceState(ctx, registeredService, primazaiov1alpha1.RegisteredServiceStateAvailable); err != nil {
-			l.Error(err, "unable to update the RegisteredService", "RegisteredService", registeredService)
+	for i := range rsl.Items {
+		rs := &rsl.Items[i]
+		if rs.Name != sclaim.Status.RegisteredService && !slices.ItemContains(rs.Status.Claims, sclaim.Name) {
+			continue
+		}
+		if err := r.releaseService(ctx, rs, sclaim, constants.ServiceReleasedReason); err != nil {
+			l.Error(err, "unable to update the RegisteredService", "RegisteredService", rs)
 			errs = append(errs, err)
+		} else {
+			r.audit().Record(&sclaim, audit.ActionRelease, "RegisteredService", rs)
 		}
 	}
 	return errors.Join(errs...)
 }
 
-// Ref. https://stackoverflow.com/a/18879994/547840
-func checkSCISubset(serviceClaim, registeredService []v1alpha1.ServiceClassIdentityItem) bool {
-	set := make(map[v1alpha1.ServiceClassIdentityItem]int)
-	for _, value := range registeredService {
-		set[value] += 1
-	}
-
-	for _, value := range serviceClaim {
-		if count, found := set[value]; !found {
-			return false
-		} else if count < 1 {
-			return false
-		} else {
-			set[value] = count - 1
-		}
-	}
+// audit returns the trail recording the control plane's actions on behalf of
+// service claims
+func (r *ServiceClaimReconciler) audit() *audit.Trail {
+	return audit.NewTrail(r.Recorder, constants.ControlPlaneActor)
+}
 
-	return true
+// serviceClaimActor returns the actor recorded in the state transitions
+// caused by the given claim
+func serviceClaimActor(sclaim primazaiov1alpha1.ServiceClaim) string {
+	return fmt.Sprintf("ServiceClaim/%s", sclaim.Name)
 }
 
-func (r *ServiceClaimReconciler) extractServiceEndpointDefinition(
-	ctx context.Context,
-	req ctrl.Request,
-	rs v1alpha1.RegisteredService,
-	sedKeys []string,
-	secret *corev1.Secret) (int, error) {
-	l := log.FromContext(ctx)
-	count := 0
+// claimService records that the registered service is claimed by the claim
+func (r *ServiceClaimReconciler) claimService(ctx context.Context, rs *primazaiov1alpha1.RegisteredService, sclaim primazaiov1alpha1.ServiceClaim, reason string) error {
+	rs.Status.AddClaim(sclaim.Name)
+	return r.changeServiceState(ctx, rs, primazaiov1alpha1.RegisteredServiceStateClaimed, reason, serviceClaimActor(sclaim))
+}
 
-	// loop over the ServiceEndpointDefinition array part of RegisteredService
-	for _, sed := range rs.Spec.ServiceEndpointDefinition {
-		// check if the value is non-empty
-		if sed.Value != "" {
-			// check if the ServiceEndpointDefinitionKeys part of ServiceClaim has the current
-			// SED name in the RegisteredService
-			if slices.ItemContains(sedKeys, sed.Name) {
-				secret.StringData[sed.Name] = sed.Value
-				count++
-			}
-		} else if k := sed.ValueFromSecret.Key; k != "" { // check value if the key is non-empty
-			if slices.ItemContains(sedKeys, sed.Name) {
-				sec := &corev1.Secret{}
-				nn := types.NamespacedName{Namespace: req.Namespace, Name: sed.ValueFromSecret.Name}
-				if err := r.Get(ctx, nn, sec); err != nil {
-					l.Info("unable to retrieve Secret", "error", err, "secret", nn)
-					continue
-				}
-
-				secret.StringData[sed.Name] = string(sec.Data[k])
-				count++
-			}
-		}
+// releaseService records that the registered service is not claimed by the
+// claim any more.  The registered service becomes Available once no claim
+// claims it.
+func (r *ServiceClaimReconciler) releaseService(ctx context.Context, rs *primazaiov1alpha1.RegisteredService, sclaim primazaiov1alpha1.ServiceClaim, reason string) error {
+	rs.Status.RemoveClaim(sclaim.Name)
+	state := rs.Status.State
+	if len(rs.Status.Claims) == 0 {
+		state = primazaiov1alpha1.RegisteredServiceStateAvailable
 	}
-	return count, nil
+	return r.changeServiceState(ctx, rs, state, reason, serviceClaimActor(sclaim))
 }
 
-func (r *ServiceClaimReconciler) changeServiceState(ctx context.Context, rs primazaiov1alpha1.RegisteredService, state string) error {
+func (r *ServiceClaimReconciler) changeServiceState(ctx context.Context, rs *primazaiov1alpha1.RegisteredService, state, reason, actor string) error {
+	now := metav1.Now()
+	rs.Status.LastClaimedTime = &now
 	rs.Status.State = state
-	if err := r.Status().Update(ctx, &rs); err != nil {
-		return err
-	}
-
-	return nil
+	rs.Status.Transitions = primazaiov1alpha1.RecordStateTransition(rs.Status.Transitions, state, reason, actor)
+	rs.UpdateSummary()
+	return r.Status().Update(ctx, rs)
 }
 
 func (r *ServiceClaimReconciler) getEnvironmentFromClusterEnvironment(
@@ -259,18 +249,10 @@ func (r *ServiceClaimReconciler) processServiceClaim(
 	rsl primazaiov1alpha1.RegisteredServiceList,
 	sclaim primazaiov1alpha1.ServiceClaim) error {
 	l := log.FromContext(ctx)
-	secret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      req.NamespacedName.Name,
-			Namespace: req.NamespacedName.Namespace,
-		},
-		StringData: map[string]string{},
-	}
+	secret := controlplane.NewBindingSecret(&sclaim, req.NamespacedName.Name, req.NamespacedName.Namespace)
 
 	// count the number of secret data entries
 	count := 0
-	// loop over every RegisteredService
-	registeredServiceFound := false
 	var registeredService primazaiov1alpha1.RegisteredService
 	env := sclaim.Spec.EnvironmentTag
 	if sclaim.Spec.ApplicationClusterContext != nil {
@@ -284,22 +266,17 @@ func (r *ServiceClaimReconciler) processServiceClaim(
 
 	}
 
-	for _, rs := range rsl.Items {
-		// Check if the ServiceClassIdentity given in ServiceClaim is a subset of
-		// ServiceClassIdentity given in the RegisteredService
-		if checkSCISubset(sclaim.Spec.ServiceClassIdentity, rs.Spec.ServiceClassIdentity) &&
-			(rs.Spec.Constraints == nil ||
-				envtag.Match(env, rs.Spec.Constraints.Environments)) {
-			registeredServiceFound = true
-			registeredService = rs
-			var err error
-			count, err = r.extractServiceEndpointDefinition(ctx, req, rs, sclaim.Spec.ServiceEndpointDefinitionKeys, secret)
-			if err != nil {
-				l.Error(err, "unable to extract SED")
-				return err
-			}
-			break
-		}
+	// Check if the ServiceClassIdentity given in ServiceClaim is a subset of
+	// ServiceClassIdentity given in the RegisteredService
+	ranked, err := r.rankServices(ctx, sclaim, env, rsl.Items)
+	if err != nil {
+		l.Error(err, "unable to find a registered service")
+		return err
+	}
+	registeredServiceFound := len(ranked) > 0
+	if registeredServiceFound {
+		registeredService = ranked[0]
+		count = controlplane.ExtractServiceEndpointDefinition(ctx, r.Client, req.Namespace, registeredService, sclaim.Spec.ServiceEndpointDefinitionKeys, secret)
 	}
 
 	if !registeredServiceFound {
@@ -313,10 +290,15 @@ func (r *ServiceClaimReconciler) processServiceClaim(
 		meta.SetStatusCondition(&sclaim.Status.Conditions, c)
 
 		sclaim.Status.State = "Pending"
+		sclaim.Status.Transitions = primazaiov1alpha1.RecordStateTransition(sclaim.Status.Transitions,
+			string(sclaim.Status.State), constants.NoMatchingServiceFoundReason, constants.ControlPlaneActor)
+		sclaim.UpdateSummary()
 		if err := r.Status().Update(ctx, &sclaim); err != nil {
 			l.Error(err, "unable to update the ServiceClaim", "ServiceClaim", sclaim)
 			return err
 		}
+		recordServiceClaimActive(sclaim, nil, false)
+		r.Recorder.Event(&sclaim, corev1.EventTypeWarning, constants.NoMatchingServiceFoundReason, "No registered service matches the service class identity")
 
 		return fmt.Errorf("SCI is not matched")
 	}
@@ -334,79 +316,179 @@ func (r *ServiceClaimReconciler) processServiceClaim(
 		meta.SetStatusCondition(&sclaim.Status.Conditions, c)
 
 		sclaim.Status.State = "Pending"
+		sclaim.Status.Transitions = primazaiov1alpha1.RecordStateTransition(sclaim.Status.Transitions,
+			string(sclaim.Status.State), constants.NoMatchingServiceFoundReason, constants.ControlPlaneActor)
+		sclaim.UpdateSummary()
 		if err := r.Status().Update(ctx, &sclaim); err != nil {
 			l.Error(err, "unable to update the ServiceClaim", "ServiceClaim", sclaim)
 			return err
 		}
+		recordServiceClaimActive(sclaim, nil, false)
+		r.Recorder.Eventf(&sclaim, corev1.EventTypeWarning, constants.NoMatchingServiceFoundReason,
+			"Registered service %s does not provide all the service endpoint definition keys", registeredService.Name)
 
 		return fmt.Errorf("key not available in the list of SEDs")
 	}
 
-	// ServiceClassIdentity values are going to override
-	// any values in the secret resource
-	for _, sci := range sclaim.Spec.ServiceClassIdentity {
-		secret.StringData[sci.Name] = sci.Value
+	if err := r.storeInVault(ctx, &sclaim, secret); err != nil {
+		l.Error(err, "unable to store the service endpoint definition in vault", "ServiceClaim", sclaim.Name)
+		r.Recorder.Eventf(&sclaim, corev1.EventTypeWarning, constants.BindingFailedReason, "Failed to store the secret values in Vault: %s", err)
+		return err
+	}
+
+	if err := controlplane.CompleteBindingSecret(secret, &sclaim, registeredService.Spec.ServiceClassIdentity); err != nil {
+		l.Error(err, "unable to encode the binding secret", "ServiceClaim", sclaim.Name)
+		return err
 	}
 
 	// Update RegisteredService status to Claimed to avoid raise conditions
-	if err := r.changeServiceState(ctx, registeredService, primazaiov1alpha1.RegisteredServiceStateClaimed); err != nil {
+	if err := r.claimService(ctx, &registeredService, sclaim, constants.ServiceClaimedReason); err != nil {
 		l.Error(err, "unable to update the RegisteredService", "RegisteredService", registeredService)
 		return err
 	}
 
-	err := r.pushToClusterEnvironments(ctx, req, sclaim, secret)
+	bindings, err := r.pushToClusterEnvironments(ctx, req, sclaim, secret)
+	for _, b := range bindings {
+		sclaim.Status.SetBinding(b)
+	}
 	if err != nil {
 		l.Error(err, "error pushing to cluster environments")
+		r.Recorder.Eventf(&sclaim, corev1.EventTypeWarning, constants.BindingFailedReason, "Failed to push the binding: %s", err)
 		// Update RegisteredService status back to Available
-		if err := r.changeServiceState(ctx, registeredService, primazaiov1alpha1.RegisteredServiceStateAvailable); err != nil {
+		if err := r.releaseService(ctx, &registeredService, sclaim, constants.BindingFailedReason); err != nil {
 			l.Error(err, "unable to update the RegisteredService", "RegisteredService", registeredService)
 		}
+		// report which copies of the binding secret failed
+		sclaim.UpdateSummary()
+		if err := r.Status().Update(ctx, &sclaim); err != nil {
+			l.Error(err, "unable to update the ServiceClaim", "ServiceClaim", sclaim)
+		}
 		return client.IgnoreNotFound(err)
 	}
 
 	sclaim.Status.State = "Resolved"
 	sclaim.Status.RegisteredService = registeredService.Name
+	sclaim.Status.Selection = newServiceClaimSelection(sclaim, ranked)
+	sclaim.Status.Transitions = primazaiov1alpha1.RecordStateTransition(sclaim.Status.Transitions,
+		string(sclaim.Status.State), constants.ServiceClaimResolvedReason, constants.ControlPlaneActor)
+	meta.RemoveStatusCondition(&sclaim.Status.Conditions, primazaiov1alpha1.ServiceClaimConditionDegraded)
+	rebound := sclaim.Status.PreviousRegisteredService
+	if rebound != "" {
+		meta.SetStatusCondition(&sclaim.Status.Conditions, metav1.Condition{
+			Type:    primazaiov1alpha1.ServiceClaimConditionRebound,
+			Status:  metav1.ConditionTrue,
+			Reason:  constants.ServiceReboundReason,
+			Message: fmt.Sprintf("rebound from registered service %s to %s", rebound, registeredService.Name),
+		})
+		sclaim.Status.PreviousRegisteredService = ""
+	}
+	sclaim.UpdateSummary()
 	if err := r.Status().Update(ctx, &sclaim); err != nil {
 		l.Error(err, "unable to update the ServiceClaim", "ServiceClaim", sclaim)
 		return err
 	}
+	recordServiceClaimActive(sclaim, secret, true)
+	r.Recorder.Eventf(&sclaim, corev1.EventTypeNormal, constants.ServiceClaimResolvedReason, "Claimed registered service %s", registeredService.Name)
+	if rebound != "" {
+		r.Recorder.Eventf(&sclaim, corev1.EventTypeNormal, constants.ServiceReboundReason,
+			"Rebound from registered service %s to %s", rebound, registeredService.Name)
+	}
+	r.audit().Record(&sclaim, audit.ActionClaim, "RegisteredService", &registeredService)
 
 	return nil
 }
 
+// rankServices returns the registered services the claim matches, from the
+// most to the least preferred.  The registered services the claim can not
+// claim, because they are unreachable or claimed by as many claims as they
+// can be, are not considered, and neither are the ones the claim's service
+// selector, if any, does not select.  When the claim
+// expresses matching preferences, the matching registered services
+// discovered in the most preferred cluster environments come first.  Ties are
+// broken by the claim's ranking policy.
+func (r *ServiceClaimReconciler) rankServices(
+	ctx context.Context,
+	sclaim primazaiov1alpha1.ServiceClaim,
+	environment string,
+	services []primazaiov1alpha1.RegisteredService,
+) ([]primazaiov1alpha1.RegisteredService, error) {
+	services, err := matching.SelectServices(sclaim.Spec.ServiceSelector, services)
+	if err != nil {
+		return nil, err
+	}
+	services = matching.ClaimableServices(services, sclaim.Name)
+
+	// the cluster environments are needed to score the services against the
+	// claim's preferences, and to check the services' environment selectors
+	var cel primazaiov1alpha1.ClusterEnvironmentList
+	if err := r.List(ctx, &cel, client.InNamespace(sclaim.Namespace)); err != nil {
+		return nil, err
+	}
+	return matching.Rank(sclaim.Spec.ServiceClassIdentity, environment, services, sclaim.Spec.MatchingPreferences, cel.Items, sclaim.Spec.Ranking()), nil
+}
+
+// newServiceClaimSelection reports how the first of the ranked registered
+// services has been chosen
+func newServiceClaimSelection(sclaim primazaiov1alpha1.ServiceClaim, ranked []primazaiov1alpha1.RegisteredService) *primazaiov1alpha1.ServiceClaimSelection {
+	selection := &primazaiov1alpha1.ServiceClaimSelection{
+		RankingPolicy: sclaim.Spec.Ranking(),
+		Matches:       int32(len(ranked)),
+	}
+	for i := 0; i < len(ranked) && i < primazaiov1alpha1.MaxServiceClaimSelectionCandidates; i++ {
+		selection.Candidates = append(selection.Candidates, ranked[i].Name)
+	}
+	return selection
+}
+
 func (r *ServiceClaimReconciler) pushToClusterEnvironments(
 	ctx context.Context,
 	req ctrl.Request,
 	sclaim primazaiov1alpha1.ServiceClaim,
 	secret *corev1.Secret,
-) error {
+) ([]primazaiov1alpha1.ServiceClaimBinding, error) {
 	l := log.FromContext(ctx)
 	errs := []error{}
-	if sclaim.Spec.ApplicationClusterContext != nil {
+	bindings := []primazaiov1alpha1.ServiceClaimBinding{}
+	if acc := sclaim.Spec.ApplicationClusterContext; acc != nil {
 		var err error
-		ce, err := r.getEnvironmentFromClusterEnvironment(ctx, req, sclaim.Spec.ApplicationClusterContext.ClusterEnvironmentName)
+		ce, err := r.getEnvironmentFromClusterEnvironment(ctx, req, acc.ClusterEnvironmentName)
 		if err != nil {
 			l.Info("error getting ClusterEnvironment", "error", err)
-			return err
+			return nil, err
 		}
-		cfg, err := clustercontext.GetClusterRESTConfig(ctx, r.Client, ce.Namespace, ce.Spec.ClusterContextSecret)
+		cfg, err := r.Pool.Config(ctx, r.Client, *ce)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if err = controlplane.PushServiceBinding(ctx, &sclaim, secret, r.Scheme, r.Client, &sclaim.Spec.ApplicationClusterContext.Namespace, ce.Spec.ApplicationNamespaces, cfg); err != nil {
+		b, err := controlplane.PushServiceBinding(ctx, &sclaim, secret, r.Scheme, r.Client, ce.Name, acc.Namespaces(), ce.Spec.ApplicationNamespaces, cfg)
+		bindings = append(bindings, b...)
+		if err != nil {
 			errs = append(errs, err)
 		}
+		for _, ns := range acc.AdditionalNamespaces {
+			if !slices.ItemContains(ce.Spec.ApplicationNamespaces, ns) {
+				err := fmt.Errorf("namespace %s is not an application namespace of cluster environment %s", ns, ce.Name)
+				errs = append(errs, err)
+				bindings = append(bindings, primazaiov1alpha1.ServiceClaimBinding{
+					ClusterEnvironment: ce.Name,
+					Namespace:          ns,
+					State:              primazaiov1alpha1.ServiceClaimBindingStateFailed,
+					Message:            err.Error(),
+					LastTransitionTime: metav1.Now(),
+				})
+			}
+		}
 	} else {
 		var cel primazaiov1alpha1.ClusterEnvironmentList
-		if err := r.List(ctx, &cel); err != nil {
+		if err := r.List(ctx, &cel, client.InNamespace(sclaim.Namespace)); err != nil {
 			l.Info("error fetching ClusterEnvironmentList", "error", err)
-			return client.IgnoreNotFound(err)
+			return nil, client.IgnoreNotFound(err)
 		}
 
 		for _, ce := range cel.Items {
-			cfg, err := clustercontext.GetClusterRESTConfig(ctx, r.Client, ce.Namespace, ce.Spec.ClusterContextSecret)
+			cfg, err := r.Pool.Config(ctx, r.Client, ce)
 			if err != nil {
-				return err
+				return bindings, err
 			}
 			// check if the ServiceClaim EnvironmentTag matches the EnvironmentName part of ClusterEnvironment
 			if ce.Spec.EnvironmentName != sclaim.Spec.EnvironmentTag {
@@ -415,16 +497,14 @@ func (r *ServiceClaimReconciler) pushToClusterEnvironments(
 			}
 
 			l.Info("cluster environment is matching environment", "cluster environment", ce, "environment tag", sclaim.Spec.EnvironmentTag)
-			if err = controlplane.PushServiceBinding(ctx, &sclaim, secret, r.Scheme, r.Client, nil, ce.Spec.ApplicationNamespaces, cfg); err != nil {
+			b, err := controlplane.PushServiceBinding(ctx, &sclaim, secret, r.Scheme, r.Client, ce.Name, nil, ce.Spec.ApplicationNamespaces, cfg)
+			bindings = append(bindings, b...)
+			if err != nil {
 				errs = append(errs, err)
 			}
-
 		}
 	}
-	if len(errs) > 0 {
-		return errors.Join(errs...)
-	}
-	return nil
+	return bindings, errors.Join(errs...)
 }
 
 func (r *ServiceClaimReconciler) DeleteServiceBindingsAndSecret(
@@ -442,23 +522,23 @@ func (r *ServiceClaimReconciler) DeleteServiceBindingsAndSecret(
 			l.Info("error getting ClusterEnvironment", "error", err)
 			return err
 		}
-		cli, err := clustercontext.CreateClient(ctx, r.Client, *ce, r.Scheme, r.Client.RESTMapper())
+		cli, err := r.Pool.Client(ctx, r.Client, *ce, r.Scheme, r.Client.RESTMapper())
 		if err != nil {
 			return err
 		}
-		ns := []string{sclaim.Spec.ApplicationClusterContext.Namespace}
+		ns := sclaim.Spec.ApplicationClusterContext.Namespaces()
 		if err = controlplane.DeleteServiceBindingAndSecretFromNamespaces(ctx, cli, sclaim, ns); err != nil {
 			errs = append(errs, err)
 		}
 	} else {
 		var cel primazaiov1alpha1.ClusterEnvironmentList
-		if err := r.List(ctx, &cel); err != nil {
+		if err := r.List(ctx, &cel, client.InNamespace(sclaim.Namespace)); err != nil {
 			l.Info("error fetching ClusterEnvironmentList", "error", err)
 			return client.IgnoreNotFound(err)
 		}
 
 		for _, ce := range cel.Items {
-			cli, err := clustercontext.CreateClient(ctx, r.Client, ce, r.Scheme, r.Client.RESTMapper())
+			cli, err := r.Pool.Client(ctx, r.Client, ce, r.Scheme, r.Client.RESTMapper())
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := primazaiov1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+func TestRankServicesEnvironmentSelector(t *testing.T) {
+	psql := []primazaiov1alpha1.ServiceClassIdentityItem{{Name: "type", Value: "psql"}}
+	ce := &primazaiov1alpha1.ClusterEnvironment{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "primaza-system"},
+		Spec:       primazaiov1alpha1.ClusterEnvironmentSpec{EnvironmentName: "prod", Labels: []string{"tier=gold"}},
+	}
+	services := []primazaiov1alpha1.RegisteredService{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "gold", Namespace: "primaza-system"},
+			Spec: primazaiov1alpha1.RegisteredServiceSpec{
+				ServiceClassIdentity: psql,
+				Constraints:          &primazaiov1alpha1.RegisteredServiceConstraints{EnvironmentSelector: "tier=gold"},
+			},
+			Status: primazaiov1alpha1.RegisteredServiceStatus{State: primazaiov1alpha1.RegisteredServiceStateAvailable},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "silver", Namespace: "primaza-system"},
+			Spec: primazaiov1alpha1.RegisteredServiceSpec{
+				ServiceClassIdentity: psql,
+				Constraints:          &primazaiov1alpha1.RegisteredServiceConstraints{EnvironmentSelector: "tier=silver"},
+			},
+			Status: primazaiov1alpha1.RegisteredServiceStatus{State: primazaiov1alpha1.RegisteredServiceStateAvailable},
+		},
+	}
+	scheme := newTestScheme(t)
+	r := &ServiceClaimReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(ce).Build(), Scheme: scheme}
+
+	// the claim expresses no matching preferences
+	sclaim := primazaiov1alpha1.ServiceClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: "primaza-system"},
+		Spec:       primazaiov1alpha1.ServiceClaimSpec{ServiceClassIdentity: psql, EnvironmentTag: "prod"},
+	}
+	ranked, err := r.rankServices(context.Background(), sclaim, "prod", services)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranked) != 1 || ranked[0].Name != "gold" {
+		t.Errorf("expected only the gold service to match, got %v", ranked)
+	}
+}
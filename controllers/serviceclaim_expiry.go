@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/audit"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+// checkExpiry enforces the claim's TTL.  Once the claim's lease has expired,
+// its bindings are revoked, the claimed registered service is released and
+// the claim is moved to the Expired state.  An expired claim whose lease has
+// been renewed is moved back to Pending, so that it is resolved again.  It
+// returns whether the claim is expired and how long to wait before checking
+// the claim's lease again.
+func (r *ServiceClaimReconciler) checkExpiry(ctx context.Context, req ctrl.Request, sclaim *primazaiov1alpha1.ServiceClaim) (bool, time.Duration, error) {
+	l := log.FromContext(ctx)
+
+	expiration := sclaim.ExpirationTime()
+	if !expiration.Equal(sclaim.Status.ExpirationTime) {
+		sclaim.Status.ExpirationTime = expiration
+		// the status of new claims is written once they are processed
+		if sclaim.Status.State != "" {
+			if err := r.Status().Update(ctx, sclaim); err != nil {
+				return false, 0, err
+			}
+		}
+	}
+	if expiration == nil {
+		return false, 0, nil
+	}
+
+	remaining := time.Until(expiration.Time)
+	expired := sclaim.Status.State == primazaiov1alpha1.ServiceClaimStateExpired
+	switch {
+	case remaining > 0 && expired:
+		l.Info("renewing expired service claim", "expiration", expiration)
+		sclaim.Status.State = primazaiov1alpha1.ServiceClaimStatePending
+		sclaim.Status.Transitions = primazaiov1alpha1.RecordStateTransition(sclaim.Status.Transitions,
+			string(sclaim.Status.State), constants.ServiceClaimRenewedReason, constants.ControlPlaneActor)
+		sclaim.UpdateSummary()
+		if err := r.Status().Update(ctx, sclaim); err != nil {
+			return false, 0, err
+		}
+		r.Recorder.Eventf(sclaim, corev1.EventTypeNormal, constants.ServiceClaimRenewedReason, "Lease renewed until %s", expiration.UTC().Format(time.RFC3339))
+		return false, remaining, nil
+	case remaining > 0:
+		return false, remaining, nil
+	case expired:
+		return true, 0, nil
+	}
+
+	l.Info("service claim expired", "expiration", expiration)
+	if err := r.expireServiceClaim(ctx, req, *sclaim); err != nil {
+		return false, 0, err
+	}
+
+	sclaim.Status.State = primazaiov1alpha1.ServiceClaimStateExpired
+	sclaim.Status.RegisteredService = ""
+	sclaim.Status.Bindings = nil
+	sclaim.Status.Transitions = primazaiov1alpha1.RecordStateTransition(sclaim.Status.Transitions,
+		string(sclaim.Status.State), constants.ServiceClaimExpiredReason, constants.ControlPlaneActor)
+	message := fmt.Sprintf("lease expired at %s", expiration.UTC().Format(time.RFC3339))
+	meta.SetStatusCondition(&sclaim.Status.Conditions, metav1.Condition{
+		Type:    primazaiov1alpha1.ServiceClaimConditionReady,
+		Status:  metav1.ConditionFalse,
+		Reason:  constants.ServiceClaimExpiredReason,
+		Message: message,
+	})
+	sclaim.UpdateSummary()
+	if err := r.Status().Update(ctx, sclaim); err != nil {
+		return false, 0, err
+	}
+	recordServiceClaimActive(*sclaim, nil, false)
+	r.Recorder.Event(sclaim, corev1.EventTypeWarning, constants.ServiceClaimExpiredReason, "Bindings revoked, "+message)
+	return true, 0, nil
+}
+
+// expireServiceClaim revokes the bindings of an expired claim and releases
+// the registered service it is resolved with
+func (r *ServiceClaimReconciler) expireServiceClaim(ctx context.Context, req ctrl.Request, sclaim primazaiov1alpha1.ServiceClaim) error {
+	var errs []error
+	if err := r.DeleteServiceBindingsAndSecret(ctx, req, sclaim); err != nil {
+		errs = append(errs, err)
+	}
+	if err := r.deleteFromVault(ctx, sclaim); err != nil {
+		errs = append(errs, err)
+	}
+
+	if name := sclaim.Status.RegisteredService; name != "" {
+		var rs primazaiov1alpha1.RegisteredService
+		err := r.Get(ctx, types.NamespacedName{Namespace: sclaim.Namespace, Name: name}, &rs)
+		switch {
+		case err != nil:
+			errs = append(errs, client.IgnoreNotFound(err))
+		default:
+			if err := r.releaseService(ctx, &rs, sclaim, constants.ServiceClaimExpiredReason); err != nil {
+				errs = append(errs, err)
+			} else {
+				r.audit().Record(&sclaim, audit.ActionRelease, "RegisteredService", &rs)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
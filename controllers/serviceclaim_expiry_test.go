@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+func TestCheckExpiryRenewsExpiredClaim(t *testing.T) {
+	sclaim := &primazaiov1alpha1.ServiceClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "claim",
+			Namespace:         "primaza-system",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+		},
+		Spec: primazaiov1alpha1.ServiceClaimSpec{
+			TTL:       &metav1.Duration{Duration: time.Hour},
+			RenewTime: &metav1.Time{Time: time.Now()},
+		},
+		Status: primazaiov1alpha1.ServiceClaimStatus{
+			State: primazaiov1alpha1.ServiceClaimStateExpired,
+		},
+	}
+	scheme := newTestScheme(t)
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sclaim).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &ServiceClaimReconciler{Client: cli, Scheme: scheme, Recorder: recorder}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: sclaim.Namespace, Name: sclaim.Name}}
+	expired, renewWithin, err := r.checkExpiry(context.Background(), req, sclaim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired {
+		t.Error("expected the renewed claim not to be expired")
+	}
+	if renewWithin <= 0 || renewWithin > time.Hour {
+		t.Errorf("expected the lease to be checked again within an hour, got %s", renewWithin)
+	}
+
+	got := &primazaiov1alpha1.ServiceClaim{}
+	if err := cli.Get(context.Background(), req.NamespacedName, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.State != primazaiov1alpha1.ServiceClaimStatePending {
+		t.Errorf("expected the claim to be Pending, got %q", got.Status.State)
+	}
+	if got.Status.ExpirationTime == nil {
+		t.Error("expected the expiration time to be reported")
+	}
+	select {
+	case e := <-recorder.Events:
+		if !strings.Contains(e, constants.ServiceClaimRenewedReason) {
+			t.Errorf("expected a renewal event, got %q", e)
+		}
+	default:
+		t.Error("expected a renewal event")
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/controlplane"
+)
+
+var activeServiceClaims = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "primaza_serviceclaim_active",
+		Help: "Whether a service claim is resolved with a registered service, by service type and provider",
+	},
+	[]string{"namespace", "name", "type", "provider"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(activeServiceClaims)
+}
+
+// recordServiceClaimActive sets whether the given claim is resolved.  Type and
+// provider are taken from the binding secret when available, as the type may
+// come from the claimed registered service.
+func recordServiceClaimActive(sclaim primazaiov1alpha1.ServiceClaim, secret *corev1.Secret, active bool) {
+	values := map[string]string{}
+	for _, sci := range sclaim.Spec.ServiceClassIdentity {
+		values[sci.Name] = sci.Value
+	}
+	if secret != nil {
+		for k, v := range secret.StringData {
+			values[k] = v
+		}
+	}
+
+	forgetServiceClaim(sclaim)
+	v := 0.0
+	if active {
+		v = 1
+	}
+	activeServiceClaims.WithLabelValues(
+		sclaim.Namespace,
+		sclaim.Name,
+		values[controlplane.ServiceClassIdentityTypeKey],
+		values[controlplane.ServiceClassIdentityProviderKey]).Set(v)
+}
+
+// recordResolvedServiceClaim sets as active a claim that was already resolved,
+// e.g. before Primaza restarted, taking the type from the claimed registered
+// service when the claim does not provide it
+func (r *ServiceClaimReconciler) recordResolvedServiceClaim(ctx context.Context, sclaim primazaiov1alpha1.ServiceClaim) {
+	secret := controlplane.NewBindingSecret(&sclaim, sclaim.Name, sclaim.Namespace)
+	for _, sci := range sclaim.Spec.ServiceClassIdentity {
+		secret.StringData[sci.Name] = sci.Value
+	}
+
+	var rs primazaiov1alpha1.RegisteredService
+	if err := r.Get(ctx, types.NamespacedName{Namespace: sclaim.Namespace, Name: sclaim.Status.RegisteredService}, &rs); err == nil {
+		controlplane.SetBindingSecretType(secret, &sclaim, rs.Spec.ServiceClassIdentity)
+	}
+	recordServiceClaimActive(sclaim, secret, true)
+}
+
+// forgetServiceClaim removes the metrics of the given claim
+func forgetServiceClaim(sclaim primazaiov1alpha1.ServiceClaim) {
+	activeServiceClaims.DeletePartialMatch(prometheus.Labels{"namespace": sclaim.Namespace, "name": sclaim.Name})
+}
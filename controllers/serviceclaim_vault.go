@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+// storeInVault moves the secret-backed values of the binding secret to Vault,
+// if the claim asks for it, and records their keys in the claim's status.
+// The remaining values, like the ServiceClassIdentity ones, stay in the
+// binding secret.
+func (r *ServiceClaimReconciler) storeInVault(ctx context.Context, sclaim *primazaiov1alpha1.ServiceClaim, secret *corev1.Secret) error {
+	if sclaim.Spec.Vault == nil {
+		sclaim.Status.VaultKeys = nil
+		return nil
+	}
+	if r.Vault == nil {
+		return errors.New("vault is not configured, Primaza has to be started with --vault-address")
+	}
+
+	data := make(map[string]string, len(secret.Data))
+	keys := make([]string, 0, len(secret.Data))
+	for k, v := range secret.Data {
+		data[k] = string(v)
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if err := r.Vault.Write(ctx, sclaim.VaultMount(), sclaim.VaultPath(), data); err != nil {
+		return err
+	}
+	log.FromContext(ctx).Info("stored service endpoint definition in vault", "mount", sclaim.VaultMount(), "path", sclaim.VaultPath(), "keys", keys)
+
+	secret.Data = nil
+	sclaim.Status.VaultKeys = keys
+	return nil
+}
+
+// deleteFromVault deletes the secret holding the secret-backed values of the
+// claim from Vault
+func (r *ServiceClaimReconciler) deleteFromVault(ctx context.Context, sclaim primazaiov1alpha1.ServiceClaim) error {
+	if sclaim.Spec.Vault == nil || r.Vault == nil {
+		return nil
+	}
+	return r.Vault.Delete(ctx, sclaim.VaultMount(), sclaim.VaultPath())
+}
@@ -29,14 +29,17 @@ import (
 
 	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
 	"github.com/primaza/primaza/pkg/envtag"
-	"github.com/primaza/primaza/pkg/primaza/clustercontext"
 	"github.com/primaza/primaza/pkg/primaza/controlplane"
+	"github.com/primaza/primaza/pkg/primaza/workercluster"
 )
 
 // ServiceClassReconciler reconciles a ServiceClass object
 type ServiceClassReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// Pool caches the connections to the clusters of ClusterEnvironments
+	Pool *workercluster.Pool
 }
 
 //+kubebuilder:rbac:groups=primaza.io,namespace=system,resources=serviceclasses,verbs=get;list;watch;create;update;patch;delete
@@ -98,7 +101,7 @@ func (r *ServiceClassReconciler) finalize(ctx context.Context, sc *primazaiov1al
 
 func (r *ServiceClassReconciler) reconcileEnvironments(ctx context.Context, sc *primazaiov1alpha1.ServiceClass) error {
 	cee := primazaiov1alpha1.ClusterEnvironmentList{}
-	if err := r.List(ctx, &cee, &client.ListOptions{}); err != nil {
+	if err := r.List(ctx, &cee, &client.ListOptions{Namespace: sc.Namespace}); err != nil {
 		return err
 	}
 
@@ -106,13 +109,13 @@ func (r *ServiceClassReconciler) reconcileEnvironments(ctx context.Context, sc *
 
 	errs := []error{}
 	for _, ce := range ff {
-		cli, err := clustercontext.CreateClient(ctx, r.Client, ce, r.Scheme, r.Client.RESTMapper())
+		cli, err := r.Pool.Client(ctx, r.Client, ce, r.Scheme, r.Client.RESTMapper())
 		if err != nil {
 			errs = append(errs, err)
 			continue
 		}
 
-		if err := controlplane.PushServiceClassToNamespaces(ctx, cli, *sc, ce.Spec.ServiceNamespaces); err != nil {
+		if err := controlplane.PushServiceClassToNamespaces(ctx, cli, *sc, ce, ce.Spec.ServiceNamespaces); err != nil {
 			errs = append(errs,
 				fmt.Errorf("error pushing service class '%s' to cluster environment '%s': %w", sc.Name, ce.Name, err))
 		}
@@ -127,14 +130,14 @@ func (r *ServiceClassReconciler) removeFromEnvironments(ctx context.Context, sc
 		return nil
 	}
 
-	ff, err := r.getRelatedClusterEnvironments(ctx, sc.Spec.GetEnvironmentConstraints())
+	ff, err := r.getRelatedClusterEnvironments(ctx, sc.Namespace, sc.Spec.GetEnvironmentConstraints())
 	if err != nil {
 		return err
 	}
 
 	errs := []error{}
 	for _, ce := range ff {
-		cli, err := clustercontext.CreateClient(ctx, r.Client, ce, r.Scheme, r.Client.RESTMapper())
+		cli, err := r.Pool.Client(ctx, r.Client, ce, r.Scheme, r.Client.RESTMapper())
 		if err != nil {
 			return err
 		}
@@ -147,9 +150,9 @@ func (r *ServiceClassReconciler) removeFromEnvironments(ctx context.Context, sc
 	return errors.Join(errs...)
 }
 
-func (r *ServiceClassReconciler) getRelatedClusterEnvironments(ctx context.Context, constraints []string) ([]primazaiov1alpha1.ClusterEnvironment, error) {
+func (r *ServiceClassReconciler) getRelatedClusterEnvironments(ctx context.Context, namespace string, constraints []string) ([]primazaiov1alpha1.ClusterEnvironment, error) {
 	cee := primazaiov1alpha1.ClusterEnvironmentList{}
-	if err := r.List(ctx, &cee, &client.ListOptions{}); err != nil {
+	if err := r.List(ctx, &cee, &client.ListOptions{Namespace: namespace}); err != nil {
 		return nil, err
 	}
 
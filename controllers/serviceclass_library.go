@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/library"
+)
+
+// ServiceClassLibraryInstaller instantiates templates of the ServiceClass
+// library in the control plane namespace when the manager starts
+type ServiceClassLibraryInstaller struct {
+	client.Client
+
+	// Namespace the ServiceClasses are created in
+	Namespace string
+	// Templates are the ServiceClasses to instantiate, as returned by
+	// library.Select
+	Templates []primazaiov1alpha1.ServiceClass
+}
+
+// Start creates the ServiceClasses of the templates, and updates the ones
+// instantiated from an older version of their template.  ServiceClasses not
+// instantiated from the library, including the ones whose template label has
+// been removed to customize them, are never overwritten.  It implements
+// manager.Runnable.
+func (i *ServiceClassLibraryInstaller) Start(ctx context.Context) error {
+	l := log.FromContext(ctx).WithName("serviceclass-library")
+
+	for _, t := range i.Templates {
+		t := t
+		if err := i.install(ctx, &t); err != nil {
+			l.Error(err, "unable to install service class template", "template", t.Name)
+		}
+	}
+	return nil
+}
+
+// install creates or upgrades the ServiceClass of the template
+func (i *ServiceClassLibraryInstaller) install(ctx context.Context, t *primazaiov1alpha1.ServiceClass) error {
+	l := log.FromContext(ctx).WithValues("template", t.Name, "version", library.Version(t))
+
+	sc := &primazaiov1alpha1.ServiceClass{}
+	err := i.Get(ctx, client.ObjectKey{Namespace: i.Namespace, Name: t.Name}, sc)
+	switch {
+	case apierrors.IsNotFound(err):
+		t.Namespace = i.Namespace
+		if err := i.Create(ctx, t); err != nil {
+			return fmt.Errorf("error creating service class: %w", err)
+		}
+		l.Info("installed service class template")
+		return nil
+	case err != nil:
+		return err
+	case sc.Labels[library.TemplateLabel] != t.Name:
+		l.Info("service class already exists, skipping installation")
+		return nil
+	case library.Version(sc) >= library.Version(t):
+		return nil
+	}
+
+	previous := library.Version(sc)
+	if sc.Annotations == nil {
+		sc.Annotations = map[string]string{}
+	}
+	sc.Annotations[library.VersionAnnotation] = t.Annotations[library.VersionAnnotation]
+	sc.Spec = t.Spec
+	if err := i.Update(ctx, sc); err != nil {
+		return fmt.Errorf("error upgrading service class: %w", err)
+	}
+	l.Info("upgraded service class template", "previous", previous)
+	return nil
+}
@@ -5,7 +5,7 @@ AGENTSAPP_MAIN=./cmd/agents/app/main.go
 
 .PHONY: build
 build: fmt vet ## Build manager binary.
-	$(GO) build -o bin/agentapp ${AGENTSAPP_MAIN}
+	$(GO) build $(GO_LDFLAGS) -o bin/agentapp ${AGENTSAPP_MAIN}
 
 .PHONY: run
 run: fmt vet ## Run a controller from your host.
@@ -16,7 +16,7 @@ run: fmt vet ## Run a controller from your host.
 # More info: https://docs.docker.com/develop/develop-images/build_enhancements/
 .PHONY: docker-build
 docker-build: ## Build docker image with the manager.
-	docker build $(DOCKER_BUILD_ARGS) -t $(IMG) -f $(AGENTAPP_DOCKERFILE) .
+	docker build $(DOCKER_BUILD_ARGS) --build-arg VERSION=v$(VERSION) -t $(IMG) -f $(AGENTAPP_DOCKERFILE) .
 
 .PHONY: docker-push
 docker-push: ## Push docker image with the manager.
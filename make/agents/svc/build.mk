@@ -5,7 +5,7 @@ AGENTSSVC_MAIN=./cmd/agents/svc/main.go
 
 .PHONY: build
 build: fmt vet ## Build manager binary.
-	$(GO) build -o bin/agentsvc ${AGENTSSVC_MAIN}
+	$(GO) build $(GO_LDFLAGS) -o bin/agentsvc ${AGENTSSVC_MAIN}
 
 .PHONY: run
 run: fmt vet ## Run a controller from your host.
@@ -16,7 +16,7 @@ run: fmt vet ## Run a controller from your host.
 # More info: https://docs.docker.com/develop/develop-images/build_enhancements/
 .PHONY: docker-build
 docker-build: ## Build docker image with the manager.
-	docker build $(DOCKER_BUILD_ARGS) -t $(IMG) -f $(AGENTSVC_DOCKERFILE) .
+	docker build $(DOCKER_BUILD_ARGS) --build-arg VERSION=v$(VERSION) -t $(IMG) -f $(AGENTSVC_DOCKERFILE) .
 
 .PHONY: docker-push
 docker-push: ## Push docker image with the manager.
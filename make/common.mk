@@ -5,6 +5,10 @@
 # - use environment variables to overwrite this value (e.g export VERSION=0.0.2)
 VERSION ?= 0.0.1
 
+# GO_LDFLAGS sets the version the binaries report, agents report it to the
+# control plane that checks their version skew.
+GO_LDFLAGS ?= -ldflags "-X github.com/primaza/primaza/pkg/version.Version=v$(VERSION)"
+
 # ENVTEST_K8S_VERSION refers to the version of kubebuilder assets to be downloaded by envtest binary.
 ENVTEST_K8S_VERSION = 1.25.0
 
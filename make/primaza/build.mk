@@ -1,10 +1,15 @@
 ##@ Build
 DOCKER_BUILD_ARGS ?=
 PRIMAZA_MAIN=./cmd/primaza/main.go
+PRIMAZACLI_MAIN=./cmd/primazacli/main.go
 
 .PHONY: build
 build: generate fmt vet ## Build manager binary.
-	$(GO) build -o bin/manager ${PRIMAZA_MAIN}
+	$(GO) build $(GO_LDFLAGS) -o bin/manager ${PRIMAZA_MAIN}
+
+.PHONY: build-cli
+build-cli: fmt vet ## Build primazacli binary.
+	$(GO) build $(GO_LDFLAGS) -o bin/primazacli ${PRIMAZACLI_MAIN}
 
 .PHONY: run
 run: manifests generate fmt vet ## Run a controller from your host.
@@ -15,7 +20,7 @@ run: manifests generate fmt vet ## Run a controller from your host.
 # More info: https://docs.docker.com/develop/develop-images/build_enhancements/
 .PHONY: docker-build
 docker-build: ## Build docker image with the manager.
-	docker build $(DOCKER_BUILD_ARGS) -t $(IMG) -f $(PRIMAZA_DOCKERFILE) .
+	docker build $(DOCKER_BUILD_ARGS) --build-arg VERSION=v$(VERSION) -t $(IMG) -f $(PRIMAZA_DOCKERFILE) .
 
 .PHONY: docker-push
 docker-push: ## Push docker image with the manager.
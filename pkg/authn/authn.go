@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// ErrUnsupportedAuth is returned when a kubeconfig relies on an
+// authentication method Primaza can not use
+var ErrUnsupportedAuth = errors.New("unsupported authentication method")
+
+// OIDCAuthProvider is the name of the OpenID Connect auth provider
+const OIDCAuthProvider = "oidc"
+
+// lookPath finds the exec credential plugins' commands
+var lookPath = exec.LookPath
+
+// Validate checks that the credentials of the REST config can be obtained
+// without user interaction, i.e. that:
+//   - the command of the exec credential plugin, if any, is installed and
+//     does not require standard input;
+//   - the auth provider, if any, is OpenID Connect, configured with an issuer
+//     and a client ID so that its ID token can be refreshed.
+//
+// Other auth providers, like gcp and azure, have been removed from client-go
+// in favor of exec credential plugins.
+func Validate(cfg *rest.Config) error {
+	if e := cfg.ExecProvider; e != nil {
+		if e.InteractiveMode == clientcmdapi.AlwaysExecInteractiveMode {
+			return fmt.Errorf("%w: exec credential plugin %q requires user interaction", ErrUnsupportedAuth, e.Command)
+		}
+		if _, err := lookPath(e.Command); err != nil {
+			return fmt.Errorf("%w: exec credential plugin %q is not installed: %v", ErrUnsupportedAuth, e.Command, err)
+		}
+	}
+
+	if p := cfg.AuthProvider; p != nil {
+		if p.Name != OIDCAuthProvider {
+			return fmt.Errorf("%w: auth provider %q is not supported, use an exec credential plugin instead", ErrUnsupportedAuth, p.Name)
+		}
+		for _, k := range []string{"idp-issuer-url", "client-id"} {
+			if p.Config[k] == "" {
+				return fmt.Errorf("%w: oidc auth provider lacks %q", ErrUnsupportedAuth, k)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestValidate(t *testing.T) {
+	lookPath = func(file string) (string, error) {
+		if file == "aws" {
+			return "/usr/local/bin/aws", nil
+		}
+		return "", errors.New("executable file not found in $PATH")
+	}
+	oidc := map[string]string{"idp-issuer-url": "https://issuer.example.com", "client-id": "primaza", "refresh-token": "token"}
+
+	tests := []struct {
+		name    string
+		cfg     rest.Config
+		wantErr bool
+	}{
+		{name: "static token", cfg: rest.Config{BearerToken: "token"}},
+		{
+			name: "exec plugin",
+			cfg:  rest.Config{ExecProvider: &clientcmdapi.ExecConfig{Command: "aws", InteractiveMode: clientcmdapi.NeverExecInteractiveMode}},
+		},
+		{
+			name:    "exec plugin not installed",
+			cfg:     rest.Config{ExecProvider: &clientcmdapi.ExecConfig{Command: "gke-gcloud-auth-plugin"}},
+			wantErr: true,
+		},
+		{
+			name:    "interactive exec plugin",
+			cfg:     rest.Config{ExecProvider: &clientcmdapi.ExecConfig{Command: "aws", InteractiveMode: clientcmdapi.AlwaysExecInteractiveMode}},
+			wantErr: true,
+		},
+		{name: "oidc", cfg: rest.Config{AuthProvider: &clientcmdapi.AuthProviderConfig{Name: "oidc", Config: oidc}}},
+		{
+			name:    "oidc without issuer",
+			cfg:     rest.Config{AuthProvider: &clientcmdapi.AuthProviderConfig{Name: "oidc", Config: map[string]string{"client-id": "primaza"}}},
+			wantErr: true,
+		},
+		{
+			name:    "removed auth provider",
+			cfg:     rest.Config{AuthProvider: &clientcmdapi.AuthProviderConfig{Name: "gcp"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUnsupportedAuth) {
+				t.Errorf("Validate() error = %v, is not ErrUnsupportedAuth", err)
+			}
+		})
+	}
+}
+
+func TestTokenConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string][]byte
+		want bool
+	}{
+		{name: "token", data: map[string][]byte{"server": []byte("https://primaza:6443"), "token": []byte("token"), "ca.crt": []byte("ca")}, want: true},
+		{name: "no server", data: map[string][]byte{"token": []byte("token")}},
+		{name: "no token", data: map[string][]byte{"server": []byte("https://primaza:6443")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, ok := TokenConfig(tt.data)
+			if ok != tt.want {
+				t.Fatalf("TokenConfig() = %v, want %v", ok, tt.want)
+			}
+			if ok && (cfg.Host != "https://primaza:6443" || cfg.BearerToken != "token" || string(cfg.CAData) != "ca") {
+				t.Errorf("TokenConfig() = %+v", cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// ServerSecretKey is the secret key containing the URL of the API server
+	// a token authenticates to
+	ServerSecretKey = "server"
+	// CASecretKey is the optional secret key containing the PEM encoded
+	// certificate authority of the API server
+	CASecretKey = "ca.crt"
+	// TokenSecretKey is the secret key containing a bearer token, e.g. a
+	// short-lived ServiceAccount token
+	TokenSecretKey = "token"
+)
+
+// TokenConfig returns the REST config to connect to an API server with the
+// bearer token held by a secret's data, and whether the data holds a token
+// and the server it authenticates to
+func TokenConfig(data map[string][]byte) (*rest.Config, bool) {
+	server, token := data[ServerSecretKey], data[TokenSecretKey]
+	if len(server) == 0 || len(token) == 0 {
+		return nil, false
+	}
+
+	return &rest.Config{
+		Host:            string(server),
+		BearerToken:     string(token),
+		TLSClientConfig: rest.TLSClientConfig{CAData: data[CASecretKey]},
+	}, true
+}
@@ -35,7 +35,13 @@ func TestResourcePermissions(ctx context.Context, cfg *rest.Config, namespaces [
 	return checkPermissions(ctx, c, namespaces, permissions), nil
 }
 
-func checkPermissions(ctx context.Context, c *kubernetes.Clientset, namespaces []string, permissions []ResourcePermissions) map[string]NamespacedPermissionsReport {
+// CheckResourcePermissions checks whether the given permissions are granted
+// in namespace to the user of the given client
+func CheckResourcePermissions(ctx context.Context, c kubernetes.Interface, namespace string, permissions []ResourcePermissions) NamespacedPermissionsReport {
+	return checkPermissionsInNamespace(ctx, c, namespace, permissions)
+}
+
+func checkPermissions(ctx context.Context, c kubernetes.Interface, namespaces []string, permissions []ResourcePermissions) map[string]NamespacedPermissionsReport {
 	rr := map[string]NamespacedPermissionsReport{}
 	for _, ns := range namespaces {
 		rr[ns] = checkPermissionsInNamespace(ctx, c, ns, permissions)
@@ -43,7 +49,7 @@ func checkPermissions(ctx context.Context, c *kubernetes.Clientset, namespaces [
 	return rr
 }
 
-func checkPermissionsInNamespace(ctx context.Context, c *kubernetes.Clientset, namespace string, permissions []ResourcePermissions) NamespacedPermissionsReport {
+func checkPermissionsInNamespace(ctx context.Context, c kubernetes.Interface, namespace string, permissions []ResourcePermissions) NamespacedPermissionsReport {
 	l := log.FromContext(ctx)
 	r := NamespacedPermissionsReport{}
 	for _, p := range permissions {
@@ -69,7 +75,7 @@ func checkPermissionsInNamespace(ctx context.Context, c *kubernetes.Clientset, n
 	return r
 }
 
-func checkAccess(ctx context.Context, c *kubernetes.Clientset, np NamespacedPermission) (bool, error) {
+func checkAccess(ctx context.Context, c kubernetes.Interface, np NamespacedPermission) (bool, error) {
 	sar := np.selfSubjectAccessReview()
 
 	r, err := c.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &sar, metav1.CreateOptions{})
@@ -18,6 +18,8 @@ package authz
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	authorizationv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -91,6 +93,32 @@ func (r *NamespacedPermissionsReport) AllSatisfied() bool {
 	return len(r.Failed) == 0 && len(r.InError) == 0
 }
 
+// String describes the permissions that are not granted, and the ones that
+// could not be checked along with the error, e.g. "not granted: create
+// secrets./v1 in ns; not checked: get secrets./v1 in ns (timeout)"
+func (r NamespacedPermissionsReport) String() string {
+	parts := []string{}
+	if len(r.Failed) > 0 {
+		ff := make([]string, len(r.Failed))
+		for i, p := range r.Failed {
+			ff[i] = p.String()
+		}
+		parts = append(parts, "not granted: "+strings.Join(ff, ", "))
+	}
+	if len(r.InError) > 0 {
+		ee := make([]string, 0, len(r.InError))
+		for p, err := range r.InError {
+			ee = append(ee, fmt.Sprintf("%s (%s)", p, err))
+		}
+		sort.Strings(ee)
+		parts = append(parts, "not checked: "+strings.Join(ee, ", "))
+	}
+	if len(parts) == 0 {
+		return "all permissions granted"
+	}
+	return strings.Join(parts, "; ")
+}
+
 func (r *NamespacedPermissionsReport) satisfied(np NamespacedPermission) {
 	r.Satisfied = append(r.Satisfied, np)
 }
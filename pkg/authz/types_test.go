@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNamespacedPermissionsReportString(t *testing.T) {
+	create := NamespacedPermission{Verb: "create", Resource: "secrets", Version: "v1", Namespace: "primaza"}
+	get := NamespacedPermission{Verb: "get", Resource: "secrets", Version: "v1", Namespace: "primaza"}
+	del := NamespacedPermission{Verb: "delete", Group: "primaza.io", Resource: "registeredservices", Namespace: "primaza", Name: "db"}
+	tests := []struct {
+		name   string
+		report NamespacedPermissionsReport
+		want   string
+	}{
+		{
+			name:   "all granted",
+			report: NamespacedPermissionsReport{Satisfied: []NamespacedPermission{create, get}},
+			want:   "all permissions granted",
+		},
+		{
+			name:   "not granted",
+			report: NamespacedPermissionsReport{Satisfied: []NamespacedPermission{get}, Failed: []NamespacedPermission{create, del}},
+			want:   "not granted: create secrets./v1 in primaza, delete registeredservices.primaza.io/ db in primaza",
+		},
+		{
+			name: "not granted and not checked",
+			report: NamespacedPermissionsReport{
+				Failed:  []NamespacedPermission{create},
+				InError: map[NamespacedPermission]error{get: errors.New("timeout"), del: errors.New("forbidden")},
+			},
+			want: "not granted: create secrets./v1 in primaza; " +
+				"not checked: delete registeredservices.primaza.io/ db in primaza (forbidden), get secrets./v1 in primaza (timeout)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.report.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
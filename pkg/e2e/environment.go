@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/controllers"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+// Environment is a multi-cluster test environment made of a Primaza control
+// plane, running Primaza's controllers, and a worker cluster.  Both clusters
+// are envtest API servers: workloads, and so Primaza's agents, do not run.
+type Environment struct {
+	// CRDDirectoryPaths are the paths to Primaza's CRDs.  If empty,
+	// `config/crd/bases` relative to RootDir is used.
+	CRDDirectoryPaths []string
+
+	// RootDir is the root of Primaza's repository.  It defaults to the
+	// current directory.
+	RootDir string
+
+	// Namespace is the control plane's namespace.  It defaults to
+	// `primaza-system`.
+	Namespace string
+
+	// AppAgentImage and SvcAgentImage are the images of the agents pushed
+	// to the worker cluster.
+	AppAgentImage string
+	SvcAgentImage string
+
+	// ControlPlane is the client of the control plane, set by Start
+	ControlPlane client.Client
+	// Worker is the client of the worker cluster, set by Start
+	Worker client.Client
+	// WorkerConfig is the REST configuration of the worker cluster, set by Start
+	WorkerConfig *rest.Config
+
+	scheme       *runtime.Scheme
+	controlPlane *envtest.Environment
+	worker       *envtest.Environment
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	managerErr   error
+}
+
+// Scheme returns the scheme of the environment's clients
+func (e *Environment) Scheme() *runtime.Scheme {
+	return e.scheme
+}
+
+// Start starts the control plane and the worker cluster, installs Primaza's
+// CRDs in both, and runs Primaza's controllers against the control plane
+func (e *Environment) Start() error {
+	if e.Namespace == "" {
+		e.Namespace = constants.PrimazaNamespace
+	}
+	crds := e.CRDDirectoryPaths
+	if len(crds) == 0 {
+		crds = []string{filepath.Join(e.RootDir, "config", "crd", "bases")}
+	}
+
+	e.scheme = runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(e.scheme))
+	utilruntime.Must(primazaiov1alpha1.AddToScheme(e.scheme))
+
+	e.controlPlane = &envtest.Environment{CRDDirectoryPaths: crds, ErrorIfCRDPathMissing: true, Scheme: e.scheme}
+	e.worker = &envtest.Environment{CRDDirectoryPaths: crds, ErrorIfCRDPathMissing: true, Scheme: e.scheme}
+
+	cpConfig, err := e.controlPlane.Start()
+	if err != nil {
+		return fmt.Errorf("error starting the control plane: %w", err)
+	}
+	if e.WorkerConfig, err = e.worker.Start(); err != nil {
+		return errors.Join(fmt.Errorf("error starting the worker cluster: %w", err), e.Stop())
+	}
+
+	if e.ControlPlane, err = client.New(cpConfig, client.Options{Scheme: e.scheme}); err != nil {
+		return errors.Join(err, e.Stop())
+	}
+	if e.Worker, err = client.New(e.WorkerConfig, client.Options{Scheme: e.scheme}); err != nil {
+		return errors.Join(err, e.Stop())
+	}
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: e.Namespace}}
+	if err := e.ControlPlane.Create(context.Background(), ns); err != nil {
+		return errors.Join(err, e.Stop())
+	}
+
+	if err := e.startManager(cpConfig); err != nil {
+		return errors.Join(err, e.Stop())
+	}
+	return nil
+}
+
+func (e *Environment) startManager(cfg *rest.Config) error {
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:                 e.scheme,
+		Namespace:              e.Namespace,
+		MetricsBindAddress:     "0",
+		HealthProbeBindAddress: "0",
+	})
+	if err != nil {
+		return err
+	}
+
+	reconcilers := []interface{ SetupWithManager(ctrl.Manager) error }{
+		&controllers.ClusterEnvironmentReconciler{
+			Client:        mgr.GetClient(),
+			Scheme:        mgr.GetScheme(),
+			Recorder:      mgr.GetEventRecorderFor(constants.ControlPlaneActor),
+			AppAgentImage: e.AppAgentImage,
+			SvcAgentImage: e.SvcAgentImage,
+		},
+		&controllers.ServiceClaimReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor(constants.ControlPlaneActor),
+		},
+		&controllers.ServiceClassReconciler{Client: mgr.GetClient(), Scheme: mgr.GetScheme()},
+		&controllers.RegisteredServiceReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor(constants.ControlPlaneActor),
+		},
+		&controllers.ServiceCatalogReconciler{Client: mgr.GetClient(), Scheme: mgr.GetScheme()},
+	}
+	for _, r := range reconcilers {
+		if err := r.SetupWithManager(mgr); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		e.managerErr = mgr.Start(ctx)
+	}()
+	return nil
+}
+
+// Stop stops Primaza's controllers and both clusters
+func (e *Environment) Stop() error {
+	var errs []error
+	if e.cancel != nil {
+		e.cancel()
+		e.wg.Wait()
+		errs = append(errs, e.managerErr)
+	}
+	for _, env := range []*envtest.Environment{e.worker, e.controlPlane} {
+		if env != nil && env.Config != nil {
+			errs = append(errs, env.Stop())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WorkerKubeconfig returns a kubeconfig to connect to the worker cluster
+func (e *Environment) WorkerKubeconfig() ([]byte, error) {
+	cfg := e.WorkerConfig
+	kc := clientcmdapi.NewConfig()
+	kc.Clusters["worker"] = &clientcmdapi.Cluster{
+		Server:                   cfg.Host,
+		CertificateAuthorityData: cfg.CAData,
+	}
+	kc.AuthInfos["worker"] = &clientcmdapi.AuthInfo{
+		ClientCertificateData: cfg.CertData,
+		ClientKeyData:         cfg.KeyData,
+		Token:                 cfg.BearerToken,
+	}
+	kc.Contexts["worker"] = &clientcmdapi.Context{Cluster: "worker", AuthInfo: "worker"}
+	kc.CurrentContext = "worker"
+	return clientcmd.Write(*kc)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/e2e"
+)
+
+// TestEnvironment registers a service and checks that it is published in the
+// environment's service catalog.  It requires the envtest binaries, see
+// KUBEBUILDER_ASSETS.
+func TestEnvironment(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set")
+	}
+
+	env := &e2e.Environment{RootDir: "../.."}
+	if err := env.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := env.Stop(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if _, err := env.RegisterClusterEnvironment(ctx, "worker", primazaiov1alpha1.ClusterEnvironmentSpec{
+		EnvironmentName:       "dev",
+		ApplicationNamespaces: []string{"applications"},
+		ServiceNamespaces:     []string{"services"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := env.RegisterService(ctx, "postgres", primazaiov1alpha1.RegisteredServiceSpec{
+		ServiceClassIdentity: []primazaiov1alpha1.ServiceClassIdentityItem{{Name: "type", Value: "psql"}},
+		ServiceEndpointDefinition: []primazaiov1alpha1.ServiceEndpointDefinitionItem{
+			{Name: "host", Value: "postgres.services"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.WaitForCatalogService(ctx, "dev", "postgres", true); err != nil {
+		t.Fatal(err)
+	}
+}
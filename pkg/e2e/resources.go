@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/clustercontext"
+)
+
+// PollInterval is the interval at which the Wait functions check the
+// state of the control plane
+var PollInterval = 250 * time.Millisecond
+
+// RegisterClusterEnvironment creates the worker's kubeconfig secret and a
+// ClusterEnvironment for the worker cluster in the control plane, and creates
+// the application and service namespaces in the worker cluster
+func (e *Environment) RegisterClusterEnvironment(ctx context.Context, name string, spec primazaiov1alpha1.ClusterEnvironmentSpec) (*primazaiov1alpha1.ClusterEnvironment, error) {
+	kc, err := e.WorkerKubeconfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.ClusterContextSecret == "" {
+		spec.ClusterContextSecret = fmt.Sprintf("%s-kubeconfig", name)
+	}
+	s := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: spec.ClusterContextSecret, Namespace: e.Namespace},
+		Data:       map[string][]byte{clustercontext.KubeconfigSecretKey: kc},
+	}
+	if err := e.ControlPlane.Create(ctx, s); err != nil {
+		return nil, err
+	}
+
+	for _, n := range append(append([]string{}, spec.ApplicationNamespaces...), spec.ServiceNamespaces...) {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: n}}
+		if err := e.Worker.Create(ctx, ns); client.IgnoreAlreadyExists(err) != nil {
+			return nil, err
+		}
+	}
+
+	ce := &primazaiov1alpha1.ClusterEnvironment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: e.Namespace},
+		Spec:       spec,
+	}
+	if err := e.ControlPlane.Create(ctx, ce); err != nil {
+		return nil, err
+	}
+	return ce, nil
+}
+
+// RegisterServiceClass creates a ServiceClass in the control plane
+func (e *Environment) RegisterServiceClass(ctx context.Context, name string, spec primazaiov1alpha1.ServiceClassSpec) (*primazaiov1alpha1.ServiceClass, error) {
+	sc := &primazaiov1alpha1.ServiceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: e.Namespace},
+		Spec:       spec,
+	}
+	if err := e.ControlPlane.Create(ctx, sc); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// RegisterService creates a RegisteredService in the control plane, as the
+// service agent would do when discovering a service
+func (e *Environment) RegisterService(ctx context.Context, name string, spec primazaiov1alpha1.RegisteredServiceSpec) (*primazaiov1alpha1.RegisteredService, error) {
+	rs := &primazaiov1alpha1.RegisteredService{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: e.Namespace},
+		Spec:       spec,
+	}
+	if err := e.ControlPlane.Create(ctx, rs); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// WaitForClusterEnvironmentState waits until the given ClusterEnvironment
+// reaches the given state, or the context is done
+func (e *Environment) WaitForClusterEnvironmentState(ctx context.Context, name string, state primazaiov1alpha1.ClusterEnvironmentState) error {
+	ce := &primazaiov1alpha1.ClusterEnvironment{}
+	err := wait.PollImmediateUntilWithContext(ctx, PollInterval, func(ctx context.Context) (bool, error) {
+		if err := e.ControlPlane.Get(ctx, types.NamespacedName{Namespace: e.Namespace, Name: name}, ce); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		return ce.Status.State == state, nil
+	})
+	if err != nil {
+		return fmt.Errorf("cluster environment %s is %q, expected %q: %w", name, ce.Status.State, state, err)
+	}
+	return nil
+}
+
+// WaitForRegisteredServiceState waits until the given RegisteredService
+// reaches the given state, or the context is done
+func (e *Environment) WaitForRegisteredServiceState(ctx context.Context, name string, state string) error {
+	rs := &primazaiov1alpha1.RegisteredService{}
+	err := wait.PollImmediateUntilWithContext(ctx, PollInterval, func(ctx context.Context) (bool, error) {
+		if err := e.ControlPlane.Get(ctx, types.NamespacedName{Namespace: e.Namespace, Name: name}, rs); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		return rs.Status.State == state, nil
+	})
+	if err != nil {
+		return fmt.Errorf("registered service %s is %q, expected %q: %w", name, rs.Status.State, state, err)
+	}
+	return nil
+}
+
+// WaitForCatalogService waits until the ServiceCatalog of the given
+// environment does (or does not) contain the given service, or the context
+// is done
+func (e *Environment) WaitForCatalogService(ctx context.Context, environment, service string, present bool) error {
+	err := wait.PollImmediateUntilWithContext(ctx, PollInterval, func(ctx context.Context) (bool, error) {
+		found, err := e.CatalogContains(ctx, environment, service)
+		if err != nil {
+			return false, err
+		}
+		return found == present, nil
+	})
+	if err != nil {
+		return fmt.Errorf("service catalog %s: expected service %s presence to be %t: %w", environment, service, present, err)
+	}
+	return nil
+}
+
+// CatalogContains tells whether the ServiceCatalog of the given environment
+// contains the given service
+func (e *Environment) CatalogContains(ctx context.Context, environment, service string) (bool, error) {
+	sc := &primazaiov1alpha1.ServiceCatalog{}
+	if err := e.ControlPlane.Get(ctx, types.NamespacedName{Namespace: e.Namespace, Name: environment}, sc); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	for _, s := range sc.Spec.Services {
+		if s.Name == service {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -16,9 +16,17 @@ limitations under the License.
 
 package envtag
 
-import "strings"
+import (
+	"errors"
+	"strings"
+)
 
-const NegativeConstraintSymbol = "!"
+const (
+	NegativeConstraintSymbol = "!"
+	// WildcardSymbol matches any sequence of characters, possibly empty, in
+	// environment constraints
+	WildcardSymbol = "*"
+)
 
 type matchResult byte
 
@@ -49,7 +57,7 @@ func Match(environment string, constraints []string) bool {
 func match(environment, constraint string) matchResult {
 	if strings.HasPrefix(constraint, NegativeConstraintSymbol) {
 		pc := strings.TrimPrefix(constraint, NegativeConstraintSymbol)
-		if environment == pc {
+		if MatchPattern(pc, environment) {
 			return forbidden
 		}
 
@@ -58,8 +66,46 @@ func match(environment, constraint string) matchResult {
 
 	}
 
-	if environment == constraint {
+	if MatchPattern(constraint, environment) {
 		return matched
 	}
 	return unmatched
 }
+
+// MatchPattern reports whether the environment matches the pattern, where
+// `*` matches any sequence of characters, possibly empty (e.g. `dev-*`)
+func MatchPattern(pattern, environment string) bool {
+	parts := strings.Split(pattern, WildcardSymbol)
+	if len(parts) == 1 {
+		return pattern == environment
+	}
+
+	// the first part is a prefix and the last one a suffix, while the ones
+	// in between can be anywhere, in order
+	first, last := parts[0], parts[len(parts)-1]
+	if len(environment) < len(first)+len(last) ||
+		!strings.HasPrefix(environment, first) || !strings.HasSuffix(environment, last) {
+		return false
+	}
+	rest := environment[len(first) : len(environment)-len(last)]
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, p)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(p):]
+	}
+	return true
+}
+
+// ValidatePattern checks that the pattern of a constraint, without its
+// negation, is supported
+func ValidatePattern(pattern string) error {
+	switch {
+	case strings.ContainsAny(pattern, "?[]\\"):
+		return errors.New("only the * wildcard is supported")
+	case strings.Contains(pattern, WildcardSymbol+WildcardSymbol):
+		return errors.New("consecutive wildcards are not allowed")
+	}
+	return nil
+}
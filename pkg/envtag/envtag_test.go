@@ -42,6 +42,13 @@ func Test_MatchEmptyContainsts(t *testing.T) {
 		{environment: "prod", constraints: []string{"!test", "stage"}, want: true},
 		{environment: "prod", constraints: []string{"!test", "!stage"}, want: true},
 		{environment: "prod", constraints: []string{"!test", "!prod"}, want: false},
+		{environment: "dev-1", constraints: []string{"dev-*"}, want: true},
+		{environment: "dev", constraints: []string{"dev-*"}, want: false},
+		{environment: "eu-prod-restricted", constraints: []string{"!*-restricted"}, want: false},
+		{environment: "eu-prod", constraints: []string{"!*-restricted"}, want: true},
+		{environment: "eu-dev-1", constraints: []string{"*-dev-*", "!us-*"}, want: true},
+		{environment: "us-dev-1", constraints: []string{"*-dev-*", "!us-*"}, want: false},
+		{environment: "prod", constraints: []string{"*"}, want: true},
 	}
 
 	for _, te := range tt {
@@ -50,3 +57,46 @@ func Test_MatchEmptyContainsts(t *testing.T) {
 		}
 	}
 }
+
+func Test_MatchPattern(t *testing.T) {
+	type test struct {
+		pattern     string
+		environment string
+		want        bool
+	}
+	tt := []test{
+		{pattern: "dev", environment: "dev", want: true},
+		{pattern: "dev", environment: "dev-1", want: false},
+		{pattern: "dev*", environment: "dev", want: true},
+		{pattern: "*dev", environment: "my-dev", want: true},
+		{pattern: "a*b*c", environment: "abc", want: true},
+		{pattern: "a*b*c", environment: "axxbyyc", want: true},
+		{pattern: "a*b*c", environment: "acb", want: false},
+		{pattern: "ab*ba", environment: "aba", want: false},
+		{pattern: "*", environment: "", want: true},
+	}
+	for _, te := range tt {
+		if got := envtag.MatchPattern(te.pattern, te.environment); got != te.want {
+			t.Errorf("pattern %q, environment %q: expected %v, got %v", te.pattern, te.environment, te.want, got)
+		}
+	}
+}
+
+func Test_ValidatePattern(t *testing.T) {
+	type test struct {
+		pattern string
+		wantErr bool
+	}
+	tt := []test{
+		{pattern: "dev-*", wantErr: false},
+		{pattern: "*-dev-*", wantErr: false},
+		{pattern: "dev-?", wantErr: true},
+		{pattern: "dev-[12]", wantErr: true},
+		{pattern: "dev-**", wantErr: true},
+	}
+	for _, te := range tt {
+		if err := envtag.ValidatePattern(te.pattern); (err != nil) != te.wantErr {
+			t.Errorf("pattern %q: unexpected error %v", te.pattern, err)
+		}
+	}
+}
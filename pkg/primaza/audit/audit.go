@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Action is an operation recorded in the audit trail
+type Action string
+
+const (
+	ActionCreate  Action = "Create"
+	ActionUpdate  Action = "Update"
+	ActionDelete  Action = "Delete"
+	ActionClaim   Action = "Claim"
+	ActionRelease Action = "Release"
+	ActionBind    Action = "Bind"
+	ActionUnbind  Action = "Unbind"
+)
+
+// Reason is the reason of the events recording audit entries, so that the
+// trail can be retrieved with `kubectl get events --field-selector reason=Audit`
+const Reason = "Audit"
+
+const (
+	ActorAnnotation     = "audit.primaza.io/actor"
+	ActionAnnotation    = "audit.primaza.io/action"
+	SourceUIDAnnotation = "audit.primaza.io/source-uid"
+	TargetAnnotation    = "audit.primaza.io/target"
+	TargetUIDAnnotation = "audit.primaza.io/target-uid"
+	TimeAnnotation      = "audit.primaza.io/timestamp"
+)
+
+// Entry is an operation performed by an actor on a target resource, because
+// of a source resource
+type Entry struct {
+	Actor  string
+	Action Action
+	Source client.Object
+	// TargetKind is the kind of the target, as typed objects retrieved
+	// through a client do not always carry their type meta
+	TargetKind string
+	Target     client.Object
+	Time       time.Time
+}
+
+// Annotations returns the annotations describing the entry
+func (e Entry) Annotations() map[string]string {
+	a := map[string]string{
+		ActorAnnotation:     e.Actor,
+		ActionAnnotation:    string(e.Action),
+		SourceUIDAnnotation: string(e.Source.GetUID()),
+		TargetAnnotation:    e.target(),
+		TimeAnnotation:      e.Time.UTC().Format(time.RFC3339),
+	}
+	if uid := e.Target.GetUID(); uid != "" {
+		a[TargetUIDAnnotation] = string(uid)
+	}
+	return a
+}
+
+// Message returns a human readable description of the entry
+func (e Entry) Message() string {
+	return fmt.Sprintf("%s %s by %s", e.Action, e.target(), e.Actor)
+}
+
+func (e Entry) target() string {
+	if ns := e.Target.GetNamespace(); ns != "" {
+		return fmt.Sprintf("%s/%s/%s", e.TargetKind, ns, e.Target.GetName())
+	}
+	return fmt.Sprintf("%s/%s", e.TargetKind, e.Target.GetName())
+}
+
+// Trail records audit entries as events on their source resource
+type Trail struct {
+	recorder record.EventRecorder
+	actor    string
+	now      func() time.Time
+}
+
+// NewTrail returns a trail recording the operations of the given actor
+// through recorder
+func NewTrail(recorder record.EventRecorder, actor string) *Trail {
+	return &Trail{recorder: recorder, actor: actor, now: time.Now}
+}
+
+// Record records that the trail's actor performed action on target, of kind
+// targetKind, because of source.  Recording on a nil trail is a no-op.
+func (t *Trail) Record(source client.Object, action Action, targetKind string, target client.Object) {
+	if t == nil {
+		return
+	}
+	e := Entry{
+		Actor:      t.actor,
+		Action:     action,
+		Source:     source,
+		TargetKind: targetKind,
+		Target:     target,
+		Time:       t.now(),
+	}
+	t.recorder.AnnotatedEventf(source, e.Annotations(), corev1.EventTypeNormal, Reason, "%s", e.Message())
+}
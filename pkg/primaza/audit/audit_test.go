@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestEntry(t *testing.T) {
+	at := time.Date(2023, 5, 4, 10, 0, 0, 0, time.UTC)
+	source := &v1alpha1.ServiceClass{ObjectMeta: metav1.ObjectMeta{Name: "sc", Namespace: "services", UID: "source-uid"}}
+
+	tests := []struct {
+		name        string
+		entry       Entry
+		annotations map[string]string
+		message     string
+	}{
+		{
+			name: "namespaced target",
+			entry: Entry{
+				Actor:      "primaza-svc-agent",
+				Action:     ActionCreate,
+				Source:     source,
+				TargetKind: "RegisteredService",
+				Target:     &v1alpha1.RegisteredService{ObjectMeta: metav1.ObjectMeta{Name: "rs", Namespace: "primaza-system", UID: "target-uid"}},
+				Time:       at,
+			},
+			annotations: map[string]string{
+				ActorAnnotation:     "primaza-svc-agent",
+				ActionAnnotation:    "Create",
+				SourceUIDAnnotation: "source-uid",
+				TargetAnnotation:    "RegisteredService/primaza-system/rs",
+				TargetUIDAnnotation: "target-uid",
+				TimeAnnotation:      "2023-05-04T10:00:00Z",
+			},
+			message: "Create RegisteredService/primaza-system/rs by primaza-svc-agent",
+		},
+		{
+			name: "target without uid",
+			entry: Entry{
+				Actor:      "primaza",
+				Action:     ActionDelete,
+				Source:     source,
+				TargetKind: "RegisteredService",
+				Target:     &v1alpha1.RegisteredService{ObjectMeta: metav1.ObjectMeta{Name: "rs"}},
+				Time:       at.In(time.FixedZone("CEST", 2*60*60)),
+			},
+			annotations: map[string]string{
+				ActorAnnotation:     "primaza",
+				ActionAnnotation:    "Delete",
+				SourceUIDAnnotation: "source-uid",
+				TargetAnnotation:    "RegisteredService/rs",
+				TimeAnnotation:      "2023-05-04T10:00:00Z",
+			},
+			message: "Delete RegisteredService/rs by primaza",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entry.Annotations(); !reflect.DeepEqual(got, tt.annotations) {
+				t.Errorf("Annotations() = %v, want %v", got, tt.annotations)
+			}
+			if got := tt.entry.Message(); got != tt.message {
+				t.Errorf("Message() = %q, want %q", got, tt.message)
+			}
+		})
+	}
+}
+
+func TestTrailRecord(t *testing.T) {
+	recorder := record.NewFakeRecorder(1)
+	trail := NewTrail(recorder, "primaza")
+	sclaim := &v1alpha1.ServiceClaim{ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: "primaza-system"}}
+	rs := &v1alpha1.RegisteredService{ObjectMeta: metav1.ObjectMeta{Name: "rs", Namespace: "primaza-system"}}
+
+	trail.Record(sclaim, ActionClaim, "RegisteredService", rs)
+	want := "Normal Audit Claim RegisteredService/primaza-system/rs by primaza"
+	if got := <-recorder.Events; got != want {
+		t.Errorf("recorded event = %q, want %q", got, want)
+	}
+
+	var none *Trail
+	none.Record(sclaim, ActionClaim, "RegisteredService", rs)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backpressure
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap, in the control plane
+	// namespace, that signals back-pressure to agents
+	ConfigMapName = "primaza-backpressure"
+	// DelayKey is the ConfigMap key holding the delay agents should wait
+	// before writing to the control plane, as a duration (e.g. `30s`)
+	DelayKey = "delay"
+)
+
+// Delay returns the delay the control plane asks agents to wait before
+// writing to it, or 0 if the control plane is not overloaded.  Agents that
+// are not allowed to read the ConfigMap never slow down.
+func Delay(ctx context.Context, cli client.Client, namespace string) (time.Duration, error) {
+	var cm corev1.ConfigMap
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ConfigMapName}, &cm); err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	d, err := time.ParseDuration(cm.Data[DelayKey])
+	if err != nil || d < 0 {
+		return 0, nil
+	}
+	return d, nil
+}
+
+// Signal asks agents writing to the given namespace to wait for delay before
+// writing again.  A delay of 0 lifts the back-pressure.
+func Signal(ctx context.Context, cli client.Client, namespace string, delay time.Duration) error {
+	cm := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: ConfigMapName},
+	}
+	if delay <= 0 {
+		return client.IgnoreNotFound(cli.Delete(ctx, &cm))
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, cli, &cm, func() error {
+		cm.Data = map[string]string{DelayKey: delay.String()}
+		return nil
+	})
+	return err
+}
+
+// Jitter returns a random duration between delay and twice delay, so that
+// agents slowed down at the same time do not write again all at once
+func Jitter(delay time.Duration) time.Duration {
+	if delay <= 0 {
+		return 0
+	}
+	// #nosec G404 -- jitter does not need a cryptographically secure source
+	return delay + time.Duration(rand.Int63n(int64(delay)))
+}
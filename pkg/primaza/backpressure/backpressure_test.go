@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backpressure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSignal(t *testing.T) {
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().Build()
+
+	tests := []struct {
+		name  string
+		delay time.Duration
+	}{
+		{name: "no back-pressure", delay: 0},
+		{name: "back-pressure", delay: 30 * time.Second},
+		{name: "stronger back-pressure", delay: time.Minute},
+		{name: "back-pressure lifted", delay: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Signal(ctx, cli, "primaza-system", tt.delay); err != nil {
+				t.Fatalf("Signal() error = %v", err)
+			}
+			got, err := Delay(ctx, cli, "primaza-system")
+			if err != nil {
+				t.Fatalf("Delay() error = %v", err)
+			}
+			if got != tt.delay {
+				t.Errorf("Delay() = %v, want %v", got, tt.delay)
+			}
+		})
+	}
+}
+
+func TestDelay(t *testing.T) {
+	tests := []struct {
+		name  string
+		delay string
+		want  time.Duration
+	}{
+		{name: "valid delay", delay: "1m30s", want: 90 * time.Second},
+		{name: "invalid delay", delay: "soon", want: 0},
+		{name: "negative delay", delay: "-1s", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "primaza-system", Name: ConfigMapName},
+				Data:       map[string]string{DelayKey: tt.delay},
+			}).Build()
+			got, err := Delay(context.Background(), cli, "primaza-system")
+			if err != nil {
+				t.Fatalf("Delay() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Delay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	if got := Jitter(0); got != 0 {
+		t.Errorf("Jitter(0) = %v, want 0", got)
+	}
+	for i := 0; i < 100; i++ {
+		if got := Jitter(time.Second); got < time.Second || got >= 2*time.Second {
+			t.Fatalf("Jitter(1s) = %v, want between 1s and 2s", got)
+		}
+	}
+}
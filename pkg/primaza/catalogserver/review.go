@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrUnauthenticated is returned when a bearer token is not valid
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Reviewer authenticates the bearer tokens of requests, and authorizes
+// their users to perform the actions described by the resource attributes
+type Reviewer interface {
+	Review(ctx context.Context, token string, attributes authorizationv1.ResourceAttributes) (bool, error)
+}
+
+// KubernetesReviewer reviews bearer tokens with the TokenReview API, and
+// authorizes their users with the SubjectAccessReview API
+type KubernetesReviewer struct {
+	Client client.Client
+}
+
+// Review returns whether the user the token belongs to is allowed to
+// perform the actions described by the resource attributes.  It returns
+// ErrUnauthenticated if the token is not valid.
+func (r *KubernetesReviewer) Review(ctx context.Context, token string, attributes authorizationv1.ResourceAttributes) (bool, error) {
+	tr := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	if err := r.Client.Create(ctx, tr); err != nil {
+		return false, fmt.Errorf("error reviewing token: %w", err)
+	}
+	if !tr.Status.Authenticated {
+		return false, ErrUnauthenticated
+	}
+
+	u := tr.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(u.Extra))
+	for k, v := range u.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &attributes,
+			User:               u.Username,
+			UID:                u.UID,
+			Groups:             u.Groups,
+			Extra:              extra,
+		},
+	}
+	if err := r.Client.Create(ctx, sar); err != nil {
+		return false, fmt.Errorf("error reviewing access: %w", err)
+	}
+	return sar.Status.Allowed, nil
+}
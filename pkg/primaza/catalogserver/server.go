@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+const (
+	// CatalogsPath is the path the catalogs are served on.  The catalog of
+	// an environment is served on CatalogsPath followed by its name.
+	CatalogsPath = "/catalogs/"
+	// EntriesSubresource is the virtual subresource of the ServiceCatalogs
+	// users need to be allowed to get or list to query the endpoint
+	EntriesSubresource = "entries"
+)
+
+// Catalog is the JSON representation of the catalog of an environment
+type Catalog struct {
+	Environment string                                    `json:"environment"`
+	Services    []primazaiov1alpha1.ServiceCatalogService `json:"services"`
+}
+
+// Server serves the service catalogs of Namespace as JSON on Addr, over
+// HTTPS if CertDir is not empty.  Requests are authenticated with bearer
+// tokens, and their users need to be allowed to get or list the `entries`
+// subresource of ServiceCatalogs.
+type Server struct {
+	Addr      string
+	Namespace string
+	// CertDir holds the certificate and the key the server serves HTTPS
+	// with, as tls.crt and tls.key
+	CertDir  string
+	Reader   client.Reader
+	Reviewer Reviewer
+}
+
+// Start serves the catalogs until ctx is done.  It implements
+// manager.Runnable.
+func (s *Server) Start(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(_ net.Listener) context.Context { return ctx },
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.FromContext(ctx).WithName("catalogserver").Info("serving catalogs", "address", s.Addr, "tls", s.CertDir != "")
+		if s.CertDir != "" {
+			errs <- srv.ListenAndServeTLS(filepath.Join(s.CertDir, "tls.crt"), filepath.Join(s.CertDir, "tls.key"))
+			return
+		}
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that
+// standby replicas serve the catalogs as well
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Handler returns the handler serving the catalogs
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(CatalogsPath, s.serveCatalogs)
+	return mux
+}
+
+func (s *Server) serveCatalogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, CatalogsPath)
+	if strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	attributes := authorizationv1.ResourceAttributes{
+		Namespace:   s.Namespace,
+		Verb:        "list",
+		Group:       primazaiov1alpha1.GroupVersion.Group,
+		Resource:    "servicecatalogs",
+		Subresource: EntriesSubresource,
+	}
+	if name != "" {
+		attributes.Verb = "get"
+		attributes.Name = name
+	}
+	if !s.authorize(w, r, attributes) {
+		return
+	}
+
+	var body interface{}
+	var err error
+	if name == "" {
+		body, err = s.listCatalogs(r.Context())
+	} else {
+		body, err = s.getCatalog(r.Context(), name)
+	}
+	switch {
+	case apierrors.IsNotFound(err):
+		http.NotFound(w, r)
+		return
+	case err != nil:
+		log.FromContext(r.Context()).Error(err, "error reading service catalogs")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.FromContext(r.Context()).Error(err, "error writing service catalogs")
+	}
+}
+
+// authorize reviews the bearer token of the request, and writes the error
+// response if it is not allowed
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, attributes authorizationv1.ResourceAttributes) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+
+	allowed, err := s.Reviewer.Review(r.Context(), token, attributes)
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	case err != nil:
+		log.FromContext(r.Context()).Error(err, "error reviewing catalog request")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return false
+	case !allowed:
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (s *Server) listCatalogs(ctx context.Context) ([]Catalog, error) {
+	var l primazaiov1alpha1.ServiceCatalogList
+	if err := s.Reader.List(ctx, &l, client.InNamespace(s.Namespace)); err != nil {
+		return nil, err
+	}
+
+	cc := make([]Catalog, 0, len(l.Items))
+	for _, sc := range l.Items {
+		cc = append(cc, catalog(sc))
+	}
+	return cc, nil
+}
+
+func (s *Server) getCatalog(ctx context.Context, name string) (Catalog, error) {
+	var sc primazaiov1alpha1.ServiceCatalog
+	if err := s.Reader.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: name}, &sc); err != nil {
+		return Catalog{}, err
+	}
+	return catalog(sc), nil
+}
+
+func catalog(sc primazaiov1alpha1.ServiceCatalog) Catalog {
+	services := sc.Spec.Services
+	if services == nil {
+		services = []primazaiov1alpha1.ServiceCatalogService{}
+	}
+	return Catalog{Environment: sc.Name, Services: services}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+// reviewer allows the token "viewer" to get and list the entries of the
+// catalogs, and the token "reader" to get the one of the dev catalog only
+type reviewer struct {
+	attributes authorizationv1.ResourceAttributes
+}
+
+func (r *reviewer) Review(_ context.Context, token string, attributes authorizationv1.ResourceAttributes) (bool, error) {
+	r.attributes = attributes
+	switch token {
+	case "viewer":
+		return true, nil
+	case "reader":
+		return attributes.Verb == "get" && attributes.Name == "dev", nil
+	default:
+		return false, ErrUnauthenticated
+	}
+}
+
+func TestServer(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := primazaiov1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	dev := &primazaiov1alpha1.ServiceCatalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev", Namespace: "primaza-system"},
+		Spec: primazaiov1alpha1.ServiceCatalogSpec{
+			Services: []primazaiov1alpha1.ServiceCatalogService{
+				{
+					Name:                          "postgres",
+					ServiceClassIdentity:          []primazaiov1alpha1.ServiceClassIdentityItem{{Name: "type", Value: "postgresql"}},
+					ServiceEndpointDefinitionKeys: []string{"host"},
+				},
+			},
+		},
+	}
+	prod := &primazaiov1alpha1.ServiceCatalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod", Namespace: "primaza-system"},
+	}
+	other := &primazaiov1alpha1.ServiceCatalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "stage", Namespace: "other"},
+	}
+	rv := &reviewer{}
+	s := &Server{
+		Namespace: "primaza-system",
+		Reader:    fake.NewClientBuilder().WithScheme(scheme).WithObjects(dev, prod, other).Build(),
+		Reviewer:  rv,
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+		wantVerb   string
+		want       interface{}
+	}{
+		{
+			name:       "list",
+			path:       "/catalogs/",
+			token:      "viewer",
+			wantStatus: http.StatusOK,
+			wantVerb:   "list",
+			want: []Catalog{
+				{Environment: "dev", Services: dev.Spec.Services},
+				{Environment: "prod", Services: []primazaiov1alpha1.ServiceCatalogService{}},
+			},
+		},
+		{
+			name:       "get",
+			path:       "/catalogs/dev",
+			token:      "reader",
+			wantStatus: http.StatusOK,
+			wantVerb:   "get",
+			want:       Catalog{Environment: "dev", Services: dev.Spec.Services},
+		},
+		{
+			name:       "forbidden",
+			path:       "/catalogs/",
+			token:      "reader",
+			wantStatus: http.StatusForbidden,
+			wantVerb:   "list",
+		},
+		{
+			name:       "unauthenticated",
+			path:       "/catalogs/dev",
+			token:      "invalid",
+			wantStatus: http.StatusUnauthorized,
+			wantVerb:   "get",
+		},
+		{
+			name:       "no token",
+			path:       "/catalogs/dev",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "not found",
+			path:       "/catalogs/stage",
+			token:      "viewer",
+			wantStatus: http.StatusNotFound,
+			wantVerb:   "get",
+		},
+		{
+			name:       "method not allowed",
+			method:     http.MethodPost,
+			path:       "/catalogs/dev",
+			token:      "viewer",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rv.attributes = authorizationv1.ResourceAttributes{}
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rv.attributes.Verb != tt.wantVerb {
+				t.Errorf("reviewed verb = %q, want %q", rv.attributes.Verb, tt.wantVerb)
+			}
+			if tt.wantVerb != "" && (rv.attributes.Subresource != EntriesSubresource || rv.attributes.Namespace != "primaza-system") {
+				t.Errorf("reviewed attributes = %+v", rv.attributes)
+			}
+			if tt.want == nil {
+				return
+			}
+
+			got := reflect.New(reflect.TypeOf(tt.want))
+			if err := json.Unmarshal(rec.Body.Bytes(), got.Interface()); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Elem().Interface(), tt.want) {
+				t.Errorf("body = %v, want %v", got.Elem().Interface(), tt.want)
+			}
+		})
+	}
+}
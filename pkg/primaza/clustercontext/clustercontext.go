@@ -27,12 +27,23 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/authn"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var ErrSecretNotFound = fmt.Errorf("Cluster Context Secret not found")
+var ErrContextNotFound = fmt.Errorf("Kubeconfig context not found")
+
+const (
+	// KubeconfigSecretKey is the secret key containing the kubeconfig
+	KubeconfigSecretKey = "kubeconfig"
+	// ContextSecretKey is the optional secret key containing the name of
+	// the kubeconfig context to use. If not set, the kubeconfig's current
+	// context is used.
+	ContextSecretKey = "context"
+)
 
 func CreateClient(
 	ctx context.Context,
@@ -41,7 +52,7 @@ func CreateClient(
 	scheme *runtime.Scheme,
 	mapper meta.RESTMapper,
 ) (client.Client, error) {
-	cfg, err := GetClusterRESTConfig(ctx, primazaCli, ce.Namespace, ce.Spec.ClusterContextSecret)
+	cfg, err := GetClusterEnvironmentRESTConfig(ctx, primazaCli, ce)
 	if err != nil {
 		return nil, err
 	}
@@ -58,13 +69,88 @@ func CreateClient(
 	return cli, nil
 }
 
+// GetClusterEnvironmentRESTConfig returns the REST config to connect to the
+// cluster of the given ClusterEnvironment, enforcing its TLS settings
+func GetClusterEnvironmentRESTConfig(ctx context.Context, cli client.Client, ce primazaiov1alpha1.ClusterEnvironment) (*rest.Config, error) {
+	s, err := GetClusterEnvironmentSecret(ctx, cli, ce)
+	if err != nil {
+		return nil, err
+	}
+	return ClusterEnvironmentRESTConfigFromSecret(s, ce)
+}
+
+// GetClusterEnvironmentSecret returns the secret holding the connection
+// information of the given ClusterEnvironment
+func GetClusterEnvironmentSecret(ctx context.Context, cli client.Client, ce primazaiov1alpha1.ClusterEnvironment) (*corev1.Secret, error) {
+	return getSecret(ctx, cli, ce.Namespace, ce.Spec.ClusterContextSecret)
+}
+
+// ClusterEnvironmentRESTConfigFromSecret returns the REST config to connect
+// to the cluster of the given ClusterEnvironment from its secret, enforcing
+// its TLS settings and failing over to its other servers
+func ClusterEnvironmentRESTConfigFromSecret(s *corev1.Secret, ce primazaiov1alpha1.ClusterEnvironment) (*rest.Config, error) {
+	cfg, err := RESTConfigFromSecret(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := ApplyTLSSettings(cfg, ce.Spec.TLS); err != nil {
+		return nil, err
+	}
+	if err := WithFailover(cfg, ClusterEnvironmentFailoverServers(s, ce)); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 func GetClusterRESTConfig(ctx context.Context, cli client.Client, secretNamespace, secretName string) (*rest.Config, error) {
 	s, err := getSecret(ctx, cli, secretNamespace, secretName)
 	if err != nil {
 		return nil, err
 	}
 
-	return clientcmd.RESTConfigFromKubeConfig(s.Data["kubeconfig"])
+	return RESTConfigFromSecret(s)
+}
+
+// RESTConfigFromSecret builds a REST config from the kubeconfig stored in the
+// given secret, using the context named in the secret's `context` key if any.
+// Secrets lacking a kubeconfig can instead hold a bearer token along with the
+// server it authenticates to (see authn.TokenConfig).
+// It fails with authn.ErrUnsupportedAuth if the credentials can not be
+// obtained without user interaction.
+func RESTConfigFromSecret(s *corev1.Secret) (*rest.Config, error) {
+	cfg, err := restConfigFromSecret(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := authn.Validate(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func restConfigFromSecret(s *corev1.Secret) (*rest.Config, error) {
+	kubeconfig, found := s.Data[KubeconfigSecretKey]
+	if !found {
+		if cfg, ok := authn.TokenConfig(s.Data); ok {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("Field %q field in secret %s:%s does not exist", KubeconfigSecretKey, s.Name, s.Namespace)
+	}
+
+	kubecontext, found := s.Data[ContextSecretKey]
+	if !found {
+		return clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	}
+
+	cfg, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	if _, found := cfg.Contexts[string(kubecontext)]; !found {
+		return nil, fmt.Errorf("%w: context %q in secret %s:%s", ErrContextNotFound, kubecontext, s.Name, s.Namespace)
+	}
+
+	return clientcmd.NewNonInteractiveClientConfig(*cfg, string(kubecontext), &clientcmd.ConfigOverrides{}, nil).ClientConfig()
 }
 
 func getSecret(ctx context.Context, cli client.Client, secretNamespace, secretName string) (*corev1.Secret, error) {
@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clustercontext
+
+import (
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const contextsKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com:6443
+- name: prod
+  cluster:
+    server: https://prod.example.com:6443
+users:
+- name: primaza
+  user:
+    token: token
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: primaza
+- name: prod
+  context:
+    cluster: prod
+    user: primaza
+current-context: dev
+`
+
+func TestRESTConfigFromSecretContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		context  *string
+		wantHost string
+		wantErr  error
+	}{
+		{name: "current context", wantHost: "https://dev.example.com:6443"},
+		{name: "explicit context", context: ptr("prod"), wantHost: "https://prod.example.com:6443"},
+		{name: "unknown context", context: ptr("staging"), wantErr: ErrContextNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &corev1.Secret{Data: map[string][]byte{KubeconfigSecretKey: []byte(contextsKubeconfig)}}
+			if tt.context != nil {
+				s.Data[ContextSecretKey] = []byte(*tt.context)
+			}
+
+			cfg, err := RESTConfigFromSecret(s)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Host != tt.wantHost {
+				t.Errorf("expected host %s, got %s", tt.wantHost, cfg.Host)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
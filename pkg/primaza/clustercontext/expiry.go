@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clustercontext
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+// CredentialsExpiryWarningPeriod is how long before they expire credentials
+// are reported as expiring soon
+const CredentialsExpiryWarningPeriod = 7 * 24 * time.Hour
+
+// CredentialsExpiry returns the time the credentials of the REST config
+// stop working, i.e. the earliest expiry of its client certificate and of its
+// bearer token if it is a JWT.  It returns the zero time when the credentials
+// do not expire, or their expiry can not be known (e.g. opaque tokens).
+func CredentialsExpiry(cfg *rest.Config) (time.Time, error) {
+	var expiry time.Time
+	earliest := func(t time.Time) {
+		if !t.IsZero() && (expiry.IsZero() || t.Before(expiry)) {
+			expiry = t
+		}
+	}
+
+	if len(cfg.CertData) > 0 {
+		t, err := certificateExpiry(cfg.CertData)
+		if err != nil {
+			return time.Time{}, err
+		}
+		earliest(t)
+	}
+	if cfg.BearerToken != "" {
+		earliest(tokenExpiry(cfg.BearerToken))
+	}
+	return expiry, nil
+}
+
+func certificateExpiry(data []byte) (time.Time, error) {
+	b, _ := pem.Decode(data)
+	if b == nil || b.Type != "CERTIFICATE" {
+		return time.Time{}, fmt.Errorf("client certificate is not PEM encoded")
+	}
+	c, err := x509.ParseCertificate(b.Bytes)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing client certificate: %w", err)
+	}
+	return c.NotAfter, nil
+}
+
+// tokenExpiry returns the expiry of a JWT, without verifying it, or the zero
+// time if the token is not a JWT or has no expiry
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	claims := struct {
+		Exp int64 `json:"exp"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0).UTC()
+}
+
+// CredentialsExpiryCondition returns the condition of the given type
+// reporting whether credentials expiring at the given time, as returned by
+// CredentialsExpiry, are expired or expire within
+// CredentialsExpiryWarningPeriod
+func CredentialsExpiryCondition(conditionType string, expiry time.Time, now time.Time) metav1.Condition {
+	c := metav1.Condition{
+		Type:    conditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  constants.CredentialsValidReason,
+		Message: "credentials do not expire",
+	}
+	switch {
+	case expiry.IsZero():
+	case !now.Before(expiry):
+		c.Status = metav1.ConditionTrue
+		c.Reason = constants.CredentialsExpiredReason
+		c.Message = fmt.Sprintf("credentials expired at %s", expiry.UTC().Format(time.RFC3339))
+	case expiry.Sub(now) < CredentialsExpiryWarningPeriod:
+		c.Status = metav1.ConditionTrue
+		c.Reason = constants.CredentialsExpireSoonReason
+		c.Message = fmt.Sprintf("credentials expire at %s", expiry.UTC().Format(time.RFC3339))
+	default:
+		c.Message = fmt.Sprintf("credentials expire at %s", expiry.UTC().Format(time.RFC3339))
+	}
+	return c
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clustercontext
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+func testCertificate(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "primaza"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func testToken(payload string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"RS256"}`)) + "." + enc([]byte(payload)) + ".signature"
+}
+
+func TestCredentialsExpiry(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	certExpiry := now.Add(30 * 24 * time.Hour)
+	tokenExpiry := now.Add(24 * time.Hour)
+	cert := testCertificate(t, certExpiry)
+
+	tests := []struct {
+		name    string
+		cfg     rest.Config
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "no credentials", cfg: rest.Config{}},
+		{name: "opaque token", cfg: rest.Config{BearerToken: "sha256~opaque"}},
+		{name: "token without expiry", cfg: rest.Config{BearerToken: testToken(`{"sub":"primaza"}`)}},
+		{name: "token", cfg: rest.Config{BearerToken: testToken(fmt.Sprintf(`{"exp":%d}`, tokenExpiry.Unix()))}, want: tokenExpiry},
+		{name: "certificate", cfg: rest.Config{TLSClientConfig: rest.TLSClientConfig{CertData: cert}}, want: certExpiry},
+		{
+			name: "certificate and token",
+			cfg: rest.Config{
+				BearerToken:     testToken(fmt.Sprintf(`{"exp":%d}`, tokenExpiry.Unix())),
+				TLSClientConfig: rest.TLSClientConfig{CertData: cert},
+			},
+			want: tokenExpiry,
+		},
+		{name: "invalid certificate", cfg: rest.Config{TLSClientConfig: rest.TLSClientConfig{CertData: []byte("not a certificate")}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CredentialsExpiry(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CredentialsExpiry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("CredentialsExpiry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCredentialsExpiryCondition(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		expiry time.Time
+		status metav1.ConditionStatus
+		reason string
+	}{
+		{name: "no expiry", status: metav1.ConditionFalse, reason: constants.CredentialsValidReason},
+		{name: "valid", expiry: now.Add(CredentialsExpiryWarningPeriod + time.Hour), status: metav1.ConditionFalse, reason: constants.CredentialsValidReason},
+		{name: "expires soon", expiry: now.Add(time.Hour), status: metav1.ConditionTrue, reason: constants.CredentialsExpireSoonReason},
+		{name: "expired", expiry: now.Add(-time.Hour), status: metav1.ConditionTrue, reason: constants.CredentialsExpiredReason},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := CredentialsExpiryCondition("CredentialsExpiring", tt.expiry, now)
+			if c.Type != "CredentialsExpiring" || c.Status != tt.status || c.Reason != tt.reason {
+				t.Errorf("CredentialsExpiryCondition() = %+v, want status %s and reason %s", c, tt.status, tt.reason)
+			}
+		})
+	}
+}
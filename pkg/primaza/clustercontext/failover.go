@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clustercontext
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+// FailoverServersSecretKey is the optional secret key listing the URLs of
+// other API servers of the same cluster, separated by commas or newlines.
+// They are tried in order when the kubeconfig's server can not be reached.
+const FailoverServersSecretKey = "failover-servers"
+
+// failoverStates records the server in use for each list of servers, so
+// that the REST configs built for the same cluster share it
+var failoverStates sync.Map
+
+// failoverState is the server in use among a list of servers
+type failoverState struct {
+	mu     sync.Mutex
+	active int
+}
+
+// SecretFailoverServers returns the failover servers listed in the
+// FailoverServersSecretKey of the secret, if any
+func SecretFailoverServers(s *corev1.Secret) []string {
+	return strings.Fields(strings.ReplaceAll(string(s.Data[FailoverServersSecretKey]), ",", " "))
+}
+
+// ClusterEnvironmentFailoverServers returns the failover servers of a
+// ClusterEnvironment, listed in its specification and in its secret
+func ClusterEnvironmentFailoverServers(s *corev1.Secret, ce primazaiov1alpha1.ClusterEnvironment) []string {
+	return append(append([]string{}, ce.Spec.FailoverServers...), SecretFailoverServers(s)...)
+}
+
+// WithFailover makes the clients built from cfg send their requests to the
+// first of cfg's server and the given servers that can be connected to.
+// The server in use is remembered, so that the next requests are sent to
+// it first.  Requests are only retried on another server when the
+// connection could not be established, so that they are never sent twice.
+func WithFailover(cfg *rest.Config, servers []string) error {
+	if len(servers) == 0 {
+		return nil
+	}
+
+	uu := make([]*url.URL, 0, len(servers)+1)
+	for _, s := range append([]string{cfg.Host}, servers...) {
+		u, err := url.Parse(s)
+		if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+			return fmt.Errorf("invalid server URL %q", s)
+		}
+		uu = append(uu, u)
+	}
+
+	st, _ := failoverStates.LoadOrStore(strings.Join(append([]string{cfg.Host}, servers...), ","), &failoverState{})
+	state := st.(*failoverState)
+	cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &failoverRoundTripper{rt: rt, servers: uu, state: state}
+	})
+	return nil
+}
+
+type failoverRoundTripper struct {
+	rt      http.RoundTripper
+	servers []*url.URL
+	state   *failoverState
+}
+
+func (f *failoverRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.state.mu.Lock()
+	start := f.state.active
+	f.state.mu.Unlock()
+
+	var err error
+	for i := range f.servers {
+		idx := (start + i) % len(f.servers)
+		r := req.Clone(req.Context())
+		r.URL.Scheme = f.servers[idx].Scheme
+		r.URL.Host = f.servers[idx].Host
+		r.Host = ""
+		if i > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, err
+			}
+			if r.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		var resp *http.Response
+		resp, err = f.rt.RoundTrip(r)
+		if !isDialError(err) {
+			f.state.mu.Lock()
+			f.state.active = idx
+			f.state.mu.Unlock()
+			return resp, err
+		}
+	}
+	return nil, err
+}
+
+// isDialError tells whether err means that no connection to the server
+// could be established, so that the request was not sent
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	return (errors.As(err, &opErr) && opErr.Op == "dial") || errors.As(err, &dnsErr)
+}
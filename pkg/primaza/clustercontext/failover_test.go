@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clustercontext
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestSecretFailoverServers(t *testing.T) {
+	s := &corev1.Secret{Data: map[string][]byte{
+		FailoverServersSecretKey: []byte("https://lb-1:6443, https://lb-2:6443\nhttps://lb-3:6443\n"),
+	}}
+	expected := []string{"https://lb-1:6443", "https://lb-2:6443", "https://lb-3:6443"}
+	if actual := SecretFailoverServers(s); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if actual := SecretFailoverServers(&corev1.Secret{}); len(actual) != 0 {
+		t.Errorf("expected no servers, got %v", actual)
+	}
+}
+
+func TestWithFailover(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// a server nobody listens on
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := "http://" + l.Addr().String()
+	l.Close()
+
+	if err := WithFailover(&rest.Config{Host: down}, []string{"lb-2:6443"}); err == nil || !strings.Contains(err.Error(), "invalid server URL") {
+		t.Errorf("expected invalid server URL error, got %v", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		cfg := &rest.Config{Host: down}
+		if err := WithFailover(cfg, []string{srv.URL}); err != nil {
+			t.Fatal(err)
+		}
+		hc, err := rest.HTTPClientFor(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := hc.Get(down + "/version")
+		if err != nil {
+			t.Fatalf("request %d: expected failover, got %v", i, err)
+		}
+		resp.Body.Close()
+		if hits != i {
+			t.Errorf("request %d: expected %d hits, got %d", i, i, hits)
+		}
+	}
+
+	st, _ := failoverStates.Load(down + "," + srv.URL)
+	if active := st.(*failoverState).active; active != 1 {
+		t.Errorf("expected the failover server to be remembered, got %d", active)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clustercontext
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/slices"
+)
+
+var ErrInsecureConnection = fmt.Errorf("TLS verification of the cluster's certificate is disabled")
+var ErrPinMismatch = fmt.Errorf("no certificate presented by the cluster matches the pinned public keys")
+
+// ApplyTLSSettings enforces the TLS settings of a ClusterEnvironment on the
+// given REST config.  It fails with ErrInsecureConnection when verification is
+// required but the config disables it; connections to a cluster presenting
+// no pinned certificate fail with ErrPinMismatch.
+func ApplyTLSSettings(cfg *rest.Config, settings *primazaiov1alpha1.ClusterEnvironmentTLS) error {
+	if settings == nil {
+		return nil
+	}
+	if settings.RequireVerification && (cfg.Insecure || !rest.IsConfigTransportTLS(*cfg)) {
+		return fmt.Errorf("%w: %s", ErrInsecureConnection, cfg.Host)
+	}
+	if len(settings.PinnedPublicKeys) == 0 {
+		return nil
+	}
+
+	tlsConfig, err := rest.TLSConfigFor(cfg)
+	if err != nil {
+		return err
+	}
+	if tlsConfig == nil {
+		return fmt.Errorf("%w: %s is not served over TLS", ErrPinMismatch, cfg.Host)
+	}
+	tlsConfig.VerifyPeerCertificate = verifyPins(settings.PinnedPublicKeys)
+
+	// a custom transport can not be used along with TLS options, which are
+	// now held by the transport's TLS config
+	cfg.TLSClientConfig = rest.TLSClientConfig{}
+	cfg.Transport = utilnet.SetTransportDefaults(&http.Transport{
+		TLSClientConfig: tlsConfig,
+		Proxy:           cfg.Proxy,
+		DialContext:     cfg.Dial,
+	})
+	return nil
+}
+
+// SPKIPin returns the base64 encoded SHA-256 digest of the certificate's
+// Subject Public Key Info, as expected in pinned public keys
+func SPKIPin(cert *x509.Certificate) string {
+	d := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(d[:])
+}
+
+// verifyPins returns a function checking that one of the certificates
+// presented by the server matches a pin
+func verifyPins(pins []string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			if slices.ItemContains(pins, SPKIPin(cert)) {
+				return nil
+			}
+		}
+		return ErrPinMismatch
+	}
+}
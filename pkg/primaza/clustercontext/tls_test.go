@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clustercontext
+
+import (
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/rest"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+func TestApplyTLSSettings(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer srv.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	pin := SPKIPin(srv.Certificate())
+	// the digest of an empty string
+	otherPin := "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+
+	verified := rest.Config{Host: srv.URL, TLSClientConfig: rest.TLSClientConfig{CAData: ca}}
+	insecure := rest.Config{Host: srv.URL, TLSClientConfig: rest.TLSClientConfig{Insecure: true}}
+
+	tests := []struct {
+		name       string
+		cfg        rest.Config
+		settings   *primazaiov1alpha1.ClusterEnvironmentTLS
+		applyErr   error
+		requestErr error
+	}{
+		{name: "no settings", cfg: insecure},
+		{name: "verification required", cfg: verified, settings: &primazaiov1alpha1.ClusterEnvironmentTLS{RequireVerification: true}},
+		{name: "verification skipped", cfg: insecure, settings: &primazaiov1alpha1.ClusterEnvironmentTLS{RequireVerification: true}, applyErr: ErrInsecureConnection},
+		{name: "plain http", cfg: rest.Config{Host: "http://worker"}, settings: &primazaiov1alpha1.ClusterEnvironmentTLS{RequireVerification: true}, applyErr: ErrInsecureConnection},
+		{name: "matching pin", cfg: verified, settings: &primazaiov1alpha1.ClusterEnvironmentTLS{PinnedPublicKeys: []string{otherPin, pin}}},
+		{name: "matching pin without verification", cfg: insecure, settings: &primazaiov1alpha1.ClusterEnvironmentTLS{PinnedPublicKeys: []string{pin}}},
+		{name: "mismatching pin", cfg: verified, settings: &primazaiov1alpha1.ClusterEnvironmentTLS{PinnedPublicKeys: []string{otherPin}}, requestErr: ErrPinMismatch},
+		{name: "mismatching pin without verification", cfg: insecure, settings: &primazaiov1alpha1.ClusterEnvironmentTLS{PinnedPublicKeys: []string{otherPin}}, requestErr: ErrPinMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if err := ApplyTLSSettings(&cfg, tt.settings); !errors.Is(err, tt.applyErr) {
+				t.Fatalf("ApplyTLSSettings() error = %v, want %v", err, tt.applyErr)
+			}
+			if tt.applyErr != nil {
+				return
+			}
+
+			c, err := rest.HTTPClientFor(&cfg)
+			if err != nil {
+				t.Fatalf("HTTPClientFor() error = %v", err)
+			}
+			res, err := c.Get(srv.URL)
+			if err == nil {
+				res.Body.Close()
+			}
+			if !errors.Is(err, tt.requestErr) {
+				t.Errorf("Get() error = %v, want %v", err, tt.requestErr)
+			}
+		})
+	}
+}
@@ -20,6 +20,17 @@ const (
 	PrimazaNamespace               = "primaza-system"
 	ServiceAgentDeploymentName     = "primaza-svc-agent"
 	ApplicationAgentDeploymentName = "primaza-app-agent"
+	ServiceAgentServiceAccountName = "primaza-svc-agent"
+	// ServiceAgentFieldManager is the user agent of the service agent's
+	// requests to the control plane, and the field manager of the registered
+	// services written before they were applied
+	ServiceAgentFieldManager = "primaza-svc-agent"
+	// AgentFieldManager is the field manager the agents apply the objects
+	// they write on remote clusters with
+	AgentFieldManager = "primaza-agent"
+	// ControlPlaneFieldManager is the field manager the control plane
+	// applies the objects it pushes to worker clusters with
+	ControlPlaneFieldManager = "primaza-control-plane"
 	// This is the name of the secret that contains the information the service
 	// agents needs to write back registered services up to primaza.  It contains
 	// two keys: `kubeconfig`, a serialized kubeconfig for the upstream kubeconfig
@@ -27,6 +38,55 @@ const (
 	ApplicationAgentKubeconfigSecretName = "primaza-app-kubeconfig" // #nosec G101
 	ServiceAgentKubeconfigSecretName     = "primaza-svc-kubeconfig" // #nosec G101
 	// Reasons for status condition
-	NoMatchingServiceFoundReason = "NoMatchingServiceFound"
-	ValidationErrorReason        = "ValidationError"
+	NoMatchingServiceFoundReason   = "NoMatchingServiceFound"
+	ValidationErrorReason          = "ValidationError"
+	ServiceDeregisteredReason      = "RegisteredServiceDeregistered"
+	ServiceUnreachableReason       = "RegisteredServiceUnreachable"
+	ServiceReboundReason           = "RegisteredServiceRebound"
+	ServiceDrainingReason          = "RegisteredServiceDraining"
+	ServiceDrainEndedReason        = "RegisteredServiceDrainEnded"
+	NoManualEditsReason            = "NoManualEdits"
+	ManualEditsRevertedReason      = "ManualEditsReverted"
+	ManualEditsKeptReason          = "ManualEditsKept"
+	SyncPausedReason               = "SyncPaused"
+	ApplicationNotFoundReason      = "ApplicationNotFound"
+	ServiceCatalogPushedReason     = "ServiceCatalogPushed"
+	ServiceCatalogPushFailedReason = "ServiceCatalogPushFailed"
+	CredentialsValidReason         = "CredentialsValid"
+	CredentialsExpireSoonReason    = "CredentialsExpireSoon"
+	CredentialsExpiredReason       = "CredentialsExpired"
+	NamespaceSynchronizedReason    = "NamespaceSynchronized"
+	NamespaceSyncFailedReason      = "NamespaceSyncFailed"
+	AgentsRolledOutReason          = "AgentsRolledOut"
+	AgentsRollingOutReason         = "AgentsRollingOut"
+	AgentsRolloutFailedReason      = "AgentsRolloutFailed"
+	AgentVersionSupportedReason    = "AgentVersionSupported"
+	AgentVersionSkewedReason       = "AgentVersionSkewed"
+	AgentVersionUnknownReason      = "AgentVersionUnknown"
+	NamespacePreparedReason        = "NamespacePrepared"
+	NamespacePrepareFailedReason   = "NamespacePrepareFailed"
+	AgentActiveReason              = "AgentActive"
+	AgentNotDeployedReason         = "AgentNotDeployed"
+	// Reasons for state transitions
+	ServiceRegisteredReason      = "ServiceRegistered"
+	ServiceClaimedReason         = "ServiceClaimed"
+	ServiceReleasedReason        = "ServiceReleased"
+	BindingFailedReason          = "BindingFailed"
+	ServiceClaimResolvedReason   = "ServiceClaimResolved"
+	ServiceClaimPushedReason     = "ServiceClaimPushed"
+	ServiceClaimPushFailedReason = "ServiceClaimPushFailed"
+	HealthCheckPassedReason      = "HealthCheckPassed"
+	HealthCheckFailedReason      = "HealthCheckFailed"
+	ServiceClaimStaleReason      = "ServiceClaimStale"
+	ServiceClaimExpiredReason    = "ServiceClaimExpired"
+	ServiceClaimRenewedReason    = "ServiceClaimRenewed"
+	// Reasons for events
+	RegisteredServiceCreatedReason     = "RegisteredServiceCreated"
+	RegisteredServiceWriteFailedReason = "RegisteredServiceWriteFailed"
+	RemoteConnectionEstablishedReason  = "RemoteConnectionEstablished"
+	RemoteConnectionFailedReason       = "RemoteConnectionFailed"
+	MappingLookupFailedReason          = "MappingLookupFailed"
+	// ControlPlaneActor is the actor of the state transitions caused by
+	// the control plane
+	ControlPlaneActor = "primaza"
 )
@@ -5,4 +5,14 @@ const (
 	PrimazaClusterEnvironmentLabel string = "primaza.io/cluster-environment"
 	PrimazaNamespaceTypeLabel      string = "primaza.io/namespace-type"
 	PrimazaNamespaceLabel          string = "primaza.io/namespace"
+	// PrimazaApplicationNamespaceLabel and PrimazaServiceNamespaceLabel mark
+	// the namespaces of a worker cluster synchronized by Primaza
+	PrimazaApplicationNamespaceLabel string = "primaza.io/application-namespace"
+	PrimazaServiceNamespaceLabel     string = "primaza.io/service-namespace"
+	// PrimazaExportBundleLabel marks the ConfigMaps and Secrets the service
+	// agent renders the exported registered services into
+	PrimazaExportBundleLabel string = "primaza.io/export-bundle"
+	// PrimazaProvenanceAnnotation records, as a JSON object, the field each
+	// service endpoint definition item of a registered service is read from
+	PrimazaProvenanceAnnotation string = "primaza.io/sed-provenance"
 )
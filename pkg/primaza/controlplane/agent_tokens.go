@@ -0,0 +1,173 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/primaza/primaza/pkg/authn"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+const (
+	// AgentTokenExpiration is the lifetime of the tokens issued to agents
+	AgentTokenExpiration = time.Hour
+
+	// agentTokenExpirationAnnotation holds the expiration time of the token
+	// stored in an agent's secret
+	agentTokenExpirationAnnotation = "primaza.io/token-expiration"
+)
+
+// AgentTokenIssuer issues the short-lived ServiceAccount tokens agents use
+// to authenticate to the control plane, and pushes them in the agents'
+// secrets
+type AgentTokenIssuer struct {
+	// Server is the URL of the control plane's API server, as reached from
+	// the worker clusters
+	Server string
+	// CAData is the PEM encoded certificate authority of the control plane's
+	// API server
+	CAData []byte
+}
+
+// PushAgentToken ensures the secret of the agent of the given kind in the
+// namespace of the worker cluster holds a token for the agent's
+// ServiceAccount.  The token is renewed when half of its lifetime has passed.
+// As an agent serves a single tenant, the secret of another tenant's agent is
+// not overwritten.
+func (i *AgentTokenIssuer) PushAgentToken(
+	ctx context.Context,
+	pcli client.Client,
+	wcli kubernetes.Interface,
+	kind NamespaceType,
+	serviceAccount, ceName, ceNamespace, namespace string) error {
+	l := log.FromContext(ctx)
+
+	name := agentSecretName(kind)
+	s, err := wcli.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		s = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	case err != nil:
+		return err
+	case len(s.Data["namespace"]) > 0 && string(s.Data["namespace"]) != ceNamespace:
+		return fmt.Errorf("namespace %s is already served by tenant %s", namespace, s.Data["namespace"])
+	case !agentTokenNeedsRenewal(s, time.Now()):
+		return nil
+	}
+
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceAccount,
+			Namespace: ceNamespace,
+			Labels:    bakeRoleBindingsLabels(ceName, ceNamespace, namespace, kind),
+		},
+	}
+	if err := pcli.Create(ctx, sa); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	expiration := int64(AgentTokenExpiration.Seconds())
+	tr := &authenticationv1.TokenRequest{Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &expiration}}
+	if err := pcli.SubResource("token").Create(ctx, sa, tr); err != nil {
+		return err
+	}
+
+	if s.Annotations == nil {
+		s.Annotations = map[string]string{}
+	}
+	s.Annotations[agentTokenExpirationAnnotation] = tr.Status.ExpirationTimestamp.UTC().Format(time.RFC3339)
+	s.Data = map[string][]byte{
+		authn.ServerSecretKey: []byte(i.Server),
+		authn.CASecretKey:     i.CAData,
+		authn.TokenSecretKey:  []byte(tr.Status.Token),
+		"namespace":           []byte(ceNamespace),
+	}
+
+	l.Info("pushing agent token", "namespace", namespace, "secret", name, "expiration", tr.Status.ExpirationTimestamp)
+	if s.ResourceVersion == "" {
+		_, err = wcli.CoreV1().Secrets(namespace).Create(ctx, s, metav1.CreateOptions{})
+	} else {
+		_, err = wcli.CoreV1().Secrets(namespace).Update(ctx, s, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// RenewAgentTokens renews the tokens of the agents of the given kind in the
+// given namespaces of a cluster environment, when they passed half of their
+// lifetime.  Unlike the binding of the namespaces, it does not push the agents
+// nor their resources.
+func (i *AgentTokenIssuer) RenewAgentTokens(
+	ctx context.Context,
+	pcli client.Client,
+	wcli kubernetes.Interface,
+	kind NamespaceType,
+	ceName, ceNamespace string,
+	namespaces []string) error {
+	errs := []error{}
+	for _, ns := range namespaces {
+		sa := bakeAgentServiceAccountName(kind, ceName, ns)
+		if err := i.PushAgentToken(ctx, pcli, wcli, kind, sa, ceName, ceNamespace, ns); err != nil {
+			errs = append(errs, fmt.Errorf("namespace %s: %w", ns, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeleteAgentServiceAccount deletes the ServiceAccount Primaza created for
+// an agent to issue its tokens, if any
+func DeleteAgentServiceAccount(ctx context.Context, pcli client.Client, serviceAccount, ceNamespace string) error {
+	sa := &corev1.ServiceAccount{}
+	if err := pcli.Get(ctx, client.ObjectKey{Namespace: ceNamespace, Name: serviceAccount}, sa); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if sa.Labels["app"] != "primaza" {
+		return nil
+	}
+	return client.IgnoreNotFound(pcli.Delete(ctx, sa))
+}
+
+// agentTokenNeedsRenewal tells whether the token held by an agent's secret
+// is missing or has passed half of its lifetime
+func agentTokenNeedsRenewal(s *corev1.Secret, now time.Time) bool {
+	if len(s.Data[authn.TokenSecretKey]) == 0 {
+		return true
+	}
+	exp, err := time.Parse(time.RFC3339, s.Annotations[agentTokenExpirationAnnotation])
+	if err != nil {
+		return true
+	}
+	return exp.Sub(now) < AgentTokenExpiration/2
+}
+
+func agentSecretName(kind NamespaceType) string {
+	if kind == ApplicationNamespaceType {
+		return constants.ApplicationAgentKubeconfigSecretName
+	}
+	return constants.ServiceAgentKubeconfigSecretName
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAgentTokenNeedsRenewal(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	secret := func(token string, expiration string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{agentTokenExpirationAnnotation: expiration}},
+			Data:       map[string][]byte{"token": []byte(token)},
+		}
+	}
+	tests := []struct {
+		name   string
+		secret *corev1.Secret
+		want   bool
+	}{
+		{name: "no token", secret: &corev1.Secret{}, want: true},
+		{name: "kubeconfig", secret: &corev1.Secret{Data: map[string][]byte{"kubeconfig": []byte("{}")}}, want: true},
+		{name: "no expiration", secret: secret("token", ""), want: true},
+		{name: "fresh token", secret: secret("token", "2023-06-01T12:50:00Z"), want: false},
+		{name: "token past half of its lifetime", secret: secret("token", "2023-06-01T12:20:00Z"), want: true},
+		{name: "expired token", secret: secret("token", "2023-06-01T11:00:00Z"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := agentTokenNeedsRenewal(tt.secret, now); got != tt.want {
+				t.Errorf("agentTokenNeedsRenewal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
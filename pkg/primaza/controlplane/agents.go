@@ -39,3 +39,9 @@ func bakeRoleBindingName(role, ceName, namespace string) string {
 	tr, _ := strings.CutPrefix(role, "primaza:")
 	return fmt.Sprintf("%s-%s-%s", tr, ceName, namespace)
 }
+
+// bakeAgentServiceAccountName returns the name of the ServiceAccount of the
+// control plane the agent of the given kind deployed in namespace acts as
+func bakeAgentServiceAccountName(kind NamespaceType, ceName, namespace string) string {
+	return fmt.Sprintf("primaza-%s-%s-%s", kind.Short(), ceName, namespace)
+}
@@ -19,8 +19,13 @@ package controlplane
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+	"github.com/primaza/primaza/pkg/primaza/remotewriter"
+	"github.com/primaza/primaza/pkg/primaza/vault"
+	"github.com/primaza/primaza/pkg/slices"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,19 +33,24 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// PushServiceBinding pushes the service binding and the binding secret of the
+// service claim to the given application namespaces of the cluster environment
+// ce, or to all of them if namespaces is nil.  Namespaces that are not
+// application namespaces are skipped.  It returns the state of each copy, and
+// the errors that occurred.
 func PushServiceBinding(
 	ctx context.Context,
 	sc *primazaiov1alpha1.ServiceClaim,
 	secret *corev1.Secret,
 	scheme *runtime.Scheme,
 	controllerruntimeClient client.Client,
-	nspace *string,
+	ce string,
+	namespaces []string,
 	applicationNamespaces []string,
-	cfg *rest.Config) error {
+	cfg *rest.Config) ([]primazaiov1alpha1.ServiceClaimBinding, error) {
 	l := log.FromContext(ctx)
 	oc := client.Options{
 		Scheme: scheme,
@@ -48,23 +58,32 @@ func PushServiceBinding(
 	}
 	cecli, err := client.New(cfg, oc)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	errs := []error{}
+	bindings := []primazaiov1alpha1.ServiceClaimBinding{}
 	for _, ns := range applicationNamespaces {
-		if nspace == nil || *nspace == ns {
-			l.Info("pushing to application namespace", "application namespace", ns)
-			if err := pushServiceBindingToNamespace(ctx, cecli, ns, sc, secret); err != nil {
-				errs = append(errs, err)
-				l.Error(err, "error pushing to application namespaces", "application namespace", ns)
-			}
+		if namespaces != nil && !slices.ItemContains(namespaces, ns) {
+			continue
 		}
+
+		l.Info("pushing to application namespace", "application namespace", ns)
+		binding := primazaiov1alpha1.ServiceClaimBinding{
+			ClusterEnvironment: ce,
+			Namespace:          ns,
+			State:              primazaiov1alpha1.ServiceClaimBindingStatePushed,
+			LastTransitionTime: metav1.Now(),
+		}
+		if err := pushServiceBindingToNamespace(ctx, cecli, ns, sc, secret); err != nil {
+			errs = append(errs, err)
+			l.Error(err, "error pushing to application namespaces", "application namespace", ns)
+			binding.State = primazaiov1alpha1.ServiceClaimBindingStateFailed
+			binding.Message = err.Error()
+		}
+		bindings = append(bindings, binding)
 	}
-	if len(errs) > 0 {
-		return errors.Join(errs...)
-	}
-	return nil
+	return bindings, errors.Join(errs...)
 }
 
 func pushServiceBindingToNamespace(
@@ -80,58 +99,55 @@ func pushServiceBindingToNamespace(
 			Name:      sc.Name,
 			Namespace: namespace,
 		},
-		Spec: primazaiov1alpha1.ServiceBindingSpec{
-			ServiceEndpointDefinitionSecret: sc.Name,
-			Application:                     sc.Spec.Application,
-		},
+		Spec: serviceBindingSpec(sc),
 	}
 
-	op, err := controllerutil.CreateOrUpdate(ctx, cli, &sb, func() error {
-		sb.Spec = primazaiov1alpha1.ServiceBindingSpec{
-			ServiceEndpointDefinitionSecret: sc.Name,
-			Application:                     sc.Spec.Application,
-		}
-		return nil
-	})
-
+	secret.Namespace = namespace
+	l.Info("applying service binding and secret for service claim", "secret", secret.Name, "service claim", sc.Name)
+	// the secret is written first, so that the service binding never refers
+	// to a missing secret
+	op, err := remotewriter.ApplyWithSecret(ctx, cli, constants.ControlPlaneFieldManager, &sb, secret)
 	if err != nil {
-		l.Error(err, "Failed to create or update service binding")
+		l.Error(err, "Failed to apply service binding and secret", "service claim", sc.Name)
 		return err
-	} else {
-		l.Info("Wrote service binding", "binding", sb.Name, "namespace", sb.Namespace, "operation", op)
 	}
+	l.Info("Wrote service binding and secret", "binding", sb.Name, "namespace", sb.Namespace, "operation", op)
 
-	if err := cli.Get(ctx, types.NamespacedName{Namespace: namespace, Name: sc.Name}, &sb); err != nil {
-		return err
-	}
+	return nil
+}
 
-	secret.OwnerReferences = []metav1.OwnerReference{
-		{
-			APIVersion: "primaza.io/v1alpha1",
-			Kind:       "ServiceBinding",
-			Name:       sc.Name,
-			UID:        sb.UID,
-		},
+// serviceBindingSpec returns the spec of the service binding projecting the
+// binding secret of the given claim into its application
+func serviceBindingSpec(sc *primazaiov1alpha1.ServiceClaim) primazaiov1alpha1.ServiceBindingSpec {
+	spec := primazaiov1alpha1.ServiceBindingSpec{
+		ServiceEndpointDefinitionSecret: sc.Name,
+		Application:                     sc.Spec.Application,
+		Env:                             sc.Spec.Env,
+		ProjectedKeys:                   sc.Spec.ProjectedKeys,
+		ReachabilityCheck:               sc.Spec.ReachabilityCheck,
 	}
-	secret.Namespace = namespace
-	data := secret.StringData
-	l.Info("creating or updating secret for service claim", "secret", secret, "service claim", sc)
-	op, err = controllerutil.CreateOrUpdate(ctx, cli, secret, func() error {
-		secret.StringData = data
-		return nil
-	})
-
-	if err != nil {
-		l.Error(err, "error creating or updating secret for service claim", "secret", secret, "service claim", sc)
-		return err
-	} else {
-		l.Info("Wrote secret", "secret", secret.Name, "namespace", secret.Namespace, "operation", op)
+	// the secret-backed values stored in vault are projected by the vault
+	// agent injector
+	if v := sc.Spec.Vault; v != nil && len(sc.Status.VaultKeys) > 0 {
+		spec.Vault = &primazaiov1alpha1.ServiceBindingVault{
+			Role:       v.Role,
+			SecretPath: vault.SecretPath(sc.VaultMount(), sc.VaultPath()),
+			Keys:       sc.Status.VaultKeys,
+		}
 	}
-
-	return nil
+	return spec
 }
 
-func PushServiceCatalogToApplicationNamespaces(ctx context.Context, sc primazaiov1alpha1.ServiceCatalog, scheme *runtime.Scheme, controllerruntimeClient client.Client, applicationNamespaces []string, cfg *rest.Config) error {
+// PushServiceCatalogToApplicationNamespaces pushes to each of the given
+// application namespaces its projection of the service catalog.  The copies
+// of the catalog are only updated when their projection changed.
+func PushServiceCatalogToApplicationNamespaces(
+	ctx context.Context,
+	p ServiceCatalogProjection,
+	scheme *runtime.Scheme,
+	controllerruntimeClient client.Client,
+	applicationNamespaces []string,
+	cfg *rest.Config) error {
 	l := log.FromContext(ctx)
 	oc := client.Options{
 		Scheme: scheme,
@@ -145,18 +161,15 @@ func PushServiceCatalogToApplicationNamespaces(ctx context.Context, sc primazaio
 	for _, ns := range applicationNamespaces {
 		sccp := &primazaiov1alpha1.ServiceCatalog{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      sc.Name,
+				Name:      p.Catalog.Name,
 				Namespace: ns,
 			},
+			Spec: p.Namespace(ns),
 		}
 
-		op, err := controllerutil.CreateOrUpdate(ctx, cli, sccp, func() error {
-			sccp.Spec = sc.Spec
-			return nil
-		})
-
+		op, err := remotewriter.Apply(ctx, cli, constants.ControlPlaneFieldManager, sccp)
 		if err != nil {
-			l.Error(err, "Failed to create or update service catalog")
+			l.Error(err, "Failed to apply service catalog")
 			errorList = append(errorList, err)
 		} else {
 			l.Info("Wrote service catalog", "catalog", sccp.Name, "namespace", sccp.Namespace, "operation", op)
@@ -187,3 +200,47 @@ func DeleteServiceBindingAndSecretFromNamespaces(ctx context.Context, cli client
 
 	return errors.Join(errs...)
 }
+
+// NotifyServiceBindings records a warning event on the service bindings of
+// the given service claim in the given namespaces
+func NotifyServiceBindings(ctx context.Context, cli client.Client, sc primazaiov1alpha1.ServiceClaim, namespaces []string, reason, message string) error {
+	var errs []error
+
+	for _, ns := range namespaces {
+		sb := &primazaiov1alpha1.ServiceBinding{}
+		if err := cli.Get(ctx, types.NamespacedName{Namespace: ns, Name: sc.Name}, sb); err != nil {
+			if !apierrors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		now := metav1.Now()
+		e := &corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s.%x", sb.Name, now.UnixNano()),
+				Namespace: ns,
+			},
+			InvolvedObject: corev1.ObjectReference{
+				APIVersion:      primazaiov1alpha1.GroupVersion.String(),
+				Kind:            "ServiceBinding",
+				Name:            sb.Name,
+				Namespace:       sb.Namespace,
+				UID:             sb.UID,
+				ResourceVersion: sb.ResourceVersion,
+			},
+			Reason:         reason,
+			Message:        message,
+			Type:           corev1.EventTypeWarning,
+			Source:         corev1.EventSource{Component: "primaza"},
+			FirstTimestamp: now,
+			LastTimestamp:  now,
+			Count:          1,
+		}
+		if err := cli.Create(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"context"
+	"testing"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNotifyServiceBindings(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := primazaiov1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	sb := &primazaiov1alpha1.ServiceBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: "frontend", UID: "sb-uid"},
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sb).Build()
+	sc := primazaiov1alpha1.ServiceClaim{ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: "primaza-system"}}
+
+	// the binding is missing from the backend namespace, which is skipped
+	err := NotifyServiceBindings(context.Background(), cli, sc, []string{"frontend", "backend"}, "ServiceDeregistered", "service db was deregistered")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for ns, want := range map[string]int{"frontend": 1, "backend": 0} {
+		ee := corev1.EventList{}
+		if err := cli.List(context.Background(), &ee, client.InNamespace(ns)); err != nil {
+			t.Fatal(err)
+		}
+		if len(ee.Items) != want {
+			t.Fatalf("expected %d events in namespace %s, got %d", want, ns, len(ee.Items))
+		}
+	}
+
+	ee := corev1.EventList{}
+	if err := cli.List(context.Background(), &ee, client.InNamespace("frontend")); err != nil {
+		t.Fatal(err)
+	}
+	e := ee.Items[0]
+	if e.Type != corev1.EventTypeWarning || e.Reason != "ServiceDeregistered" || e.Message != "service db was deregistered" {
+		t.Errorf("unexpected event %s %s: %s", e.Type, e.Reason, e.Message)
+	}
+	o := e.InvolvedObject
+	if o.Kind != "ServiceBinding" || o.Name != sb.Name || o.Namespace != sb.Namespace || o.UID != sb.UID {
+		t.Errorf("expected the event to involve the service binding, got %v", o)
+	}
+}
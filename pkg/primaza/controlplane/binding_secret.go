@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"context"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/slices"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// ServiceClassIdentityTypeKey is the ServiceClassIdentity item
+	// that identifies the type of the service (e.g. `postgresql`)
+	ServiceClassIdentityTypeKey = "type"
+
+	// ServiceClassIdentityProviderKey is the ServiceClassIdentity item
+	// that identifies the provider of the service (e.g. `aws`)
+	ServiceClassIdentityProviderKey = "provider"
+
+	// BindingSecretTypePrefix is the prefix the Service Binding specification
+	// uses for the type of binding secrets
+	BindingSecretTypePrefix = "servicebinding.io/"
+)
+
+// NewBindingSecret returns an empty binding secret for the given service claim.
+// The secret's type is derived from the claim via BindingSecretType.
+func NewBindingSecret(sclaim *primazaiov1alpha1.ServiceClaim, name, namespace string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Type:       BindingSecretType(sclaim),
+		StringData: map[string]string{},
+	}
+}
+
+// ResolvedBindingSecret returns the binding secret of the given claim, resolved
+// to the registered service rs.  It holds the same values as the secret pushed
+// when the claim was resolved, so that pushing it again does not remove any of
+// them.
+func ResolvedBindingSecret(ctx context.Context, cli client.Reader, sclaim *primazaiov1alpha1.ServiceClaim, rs *primazaiov1alpha1.RegisteredService) (*corev1.Secret, error) {
+	secret := NewBindingSecret(sclaim, sclaim.Name, sclaim.Namespace)
+	ExtractServiceEndpointDefinition(ctx, cli, sclaim.Namespace, *rs, sclaim.Spec.ServiceEndpointDefinitionKeys, secret)
+	// the secret-backed values were stored in vault when the claim was
+	// resolved
+	if sclaim.Spec.Vault != nil {
+		secret.Data = nil
+	}
+	if err := CompleteBindingSecret(secret, sclaim, rs.Spec.ServiceClassIdentity); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// ExtractServiceEndpointDefinition copies into the binding secret the values
+// of the registered service's endpoint definition whose names are in sedKeys.
+// Secret-backed values are read from the secrets of the given namespace.  It
+// returns the number of values copied.
+func ExtractServiceEndpointDefinition(
+	ctx context.Context,
+	cli client.Reader,
+	namespace string,
+	rs primazaiov1alpha1.RegisteredService,
+	sedKeys []string,
+	secret *corev1.Secret) int {
+	l := log.FromContext(ctx)
+	count := 0
+
+	// loop over the ServiceEndpointDefinition array part of RegisteredService
+	for _, sed := range rs.Spec.ServiceEndpointDefinition {
+		// check if the ServiceEndpointDefinitionKeys part of ServiceClaim has the current
+		// SED name in the RegisteredService
+		if !slices.ItemContains(sedKeys, sed.Name) {
+			continue
+		}
+		// check if the value is non-empty
+		if sed.Value != "" {
+			secret.StringData[sed.Name] = sed.Value
+			count++
+		} else if ref := sed.ValueFromSecret; ref != nil && ref.Key != "" { // check value if the key is non-empty
+			sec := &corev1.Secret{}
+			nn := types.NamespacedName{Namespace: namespace, Name: ref.Name}
+			if err := cli.Get(ctx, nn, sec); err != nil {
+				l.Info("unable to retrieve Secret", "error", err, "secret", nn)
+				continue
+			}
+
+			// copy raw bytes, so that binary data is not corrupted
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			secret.Data[sed.Name] = sec.Data[ref.Key]
+			count++
+		}
+	}
+	return count
+}
+
+// CompleteBindingSecret sets the claim's ServiceClassIdentity values, which
+// override any value extracted from the service endpoint definition, the type
+// of the binding secret, and the entries of the claim's encoders.  sci is the
+// ServiceClassIdentity of the claimed registered service.
+func CompleteBindingSecret(secret *corev1.Secret, sclaim *primazaiov1alpha1.ServiceClaim, sci []primazaiov1alpha1.ServiceClassIdentityItem) error {
+	for _, i := range sclaim.Spec.ServiceClassIdentity {
+		secret.StringData[i.Name] = i.Value
+	}
+	SetBindingSecretType(secret, sclaim, sci)
+	return EncodeBindingSecret(secret, sclaim)
+}
+
+// BindingSecretType returns the Kubernetes Secret type to use for the
+// binding secret of the given claim. An explicit SecretType in the claim's
+// spec takes precedence; otherwise, the type is `servicebinding.io/<type>`
+// where `<type>` is the value of the claim's `type` ServiceClassIdentity item.
+func BindingSecretType(sclaim *primazaiov1alpha1.ServiceClaim) corev1.SecretType {
+	if sclaim.Spec.SecretType != "" {
+		return corev1.SecretType(sclaim.Spec.SecretType)
+	}
+
+	if t, ok := serviceClassIdentityType(sclaim.Spec.ServiceClassIdentity); ok {
+		return corev1.SecretType(BindingSecretTypePrefix + t)
+	}
+
+	return corev1.SecretTypeOpaque
+}
+
+// SetBindingSecretType completes the binding secret with the `type` data key
+// and the matching Secret type when the claim does not provide a `type`
+// ServiceClassIdentity item, taking it from the given ServiceClassIdentity
+// (usually the claimed RegisteredService's one).
+func SetBindingSecretType(secret *corev1.Secret, sclaim *primazaiov1alpha1.ServiceClaim, sci []primazaiov1alpha1.ServiceClassIdentityItem) {
+	if _, ok := secret.StringData[ServiceClassIdentityTypeKey]; ok {
+		return
+	}
+
+	t, ok := serviceClassIdentityType(sci)
+	if !ok {
+		return
+	}
+
+	if secret.StringData == nil {
+		secret.StringData = map[string]string{}
+	}
+	secret.StringData[ServiceClassIdentityTypeKey] = t
+	if sclaim.Spec.SecretType == "" {
+		secret.Type = corev1.SecretType(BindingSecretTypePrefix + t)
+	}
+}
+
+func serviceClassIdentityType(sci []primazaiov1alpha1.ServiceClassIdentityItem) (string, bool) {
+	for _, i := range sci {
+		if i.Name == ServiceClassIdentityTypeKey && i.Value != "" {
+			return i.Value, true
+		}
+	}
+	return "", false
+}
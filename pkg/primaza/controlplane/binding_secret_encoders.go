@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// EncodeBindingSecret renders the binding secret's entries with each of the
+// claim's encoders, and stores the results in the secret under the encoders'
+// keys.  Encoded keys are not included in the rendered entries.
+func EncodeBindingSecret(secret *corev1.Secret, sclaim *primazaiov1alpha1.ServiceClaim) error {
+	if len(sclaim.Spec.Encoders) == 0 {
+		return nil
+	}
+
+	encoded := map[string]struct{}{}
+	for _, e := range sclaim.Spec.Encoders {
+		encoded[e.Key] = struct{}{}
+	}
+
+	entries := map[string]string{}
+	for k, v := range secret.Data {
+		if _, ok := encoded[k]; !ok {
+			entries[k] = string(v)
+		}
+	}
+	// StringData takes precedence over Data, as it does on the API server
+	for k, v := range secret.StringData {
+		if _, ok := encoded[k]; !ok {
+			entries[k] = v
+		}
+	}
+
+	for _, e := range sclaim.Spec.Encoders {
+		v, err := encode(entries, e.Format)
+		if err != nil {
+			return err
+		}
+		if secret.StringData == nil {
+			secret.StringData = map[string]string{}
+		}
+		secret.StringData[e.Key] = v
+	}
+	return nil
+}
+
+func encode(entries map[string]string, format primazaiov1alpha1.BindingSecretFormat) (string, error) {
+	switch format {
+	case primazaiov1alpha1.BindingSecretFormatJSON:
+		b, err := json.Marshal(entries)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	case primazaiov1alpha1.BindingSecretFormatProperties:
+		return encodeLines(entries, func(k, v string) string {
+			return escapeProperty(k, true) + "=" + escapeProperty(v, false)
+		}), nil
+	case primazaiov1alpha1.BindingSecretFormatDotenv:
+		return encodeLines(entries, func(k, v string) string {
+			return k + "=" + quoteDotenv(v)
+		}), nil
+	default:
+		return "", fmt.Errorf("unknown binding secret format %s", format)
+	}
+}
+
+// encodeLines renders one line per entry, sorted by key
+func encodeLines(entries map[string]string, line func(k, v string) string) string {
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(line(k, entries[k]))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// escapeProperty escapes s as a key or a value of a Java properties file
+func escapeProperty(s string, key bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '=', ':', '#', '!':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case ' ':
+			// leading spaces are trimmed, and spaces end keys
+			if key || i == 0 {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// quoteDotenv returns s as a double-quoted dotenv value
+func quoteDotenv(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"$", `\$`,
+		"`", "\\`",
+		"\n", `\n`,
+		"\r", `\r`,
+	)
+	return `"` + r.Replace(s) + `"`
+}
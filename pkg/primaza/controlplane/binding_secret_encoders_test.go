@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"testing"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestEncodeBindingSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		format primazaiov1alpha1.BindingSecretFormat
+		want   string
+	}{
+		{
+			name:   "json",
+			format: primazaiov1alpha1.BindingSecretFormatJSON,
+			want:   `{"cert":"-----BEGIN-----\n","host":"db:5432","password":"p=ss \"word\"$","type":"psql"}`,
+		},
+		{
+			name:   "properties",
+			format: primazaiov1alpha1.BindingSecretFormatProperties,
+			want:   "cert=-----BEGIN-----\\n\nhost=db\\:5432\npassword=p\\=ss \"word\"$\ntype=psql\n",
+		},
+		{
+			name:   "dotenv",
+			format: primazaiov1alpha1.BindingSecretFormatDotenv,
+			want:   "cert=\"-----BEGIN-----\\n\"\nhost=\"db:5432\"\npassword=\"p=ss \\\"word\\\"\\$\"\ntype=\"psql\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sclaim := &primazaiov1alpha1.ServiceClaim{
+				Spec: primazaiov1alpha1.ServiceClaimSpec{
+					Encoders: []primazaiov1alpha1.BindingSecretEncoder{
+						{Format: tt.format, Key: "binding"},
+					},
+				},
+			}
+			secret := &corev1.Secret{
+				StringData: map[string]string{
+					"host":     "db:5432",
+					"password": `p=ss "word"$`,
+					"type":     "psql",
+				},
+				Data: map[string][]byte{
+					"cert":    []byte("-----BEGIN-----\n"),
+					"binding": []byte("stale"),
+				},
+			}
+
+			if err := EncodeBindingSecret(secret, sclaim); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := secret.StringData["binding"]; got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
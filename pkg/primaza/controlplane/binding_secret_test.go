@@ -0,0 +1,202 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"context"
+	"testing"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResolvedBindingSecret(t *testing.T) {
+	sclaim := &primazaiov1alpha1.ServiceClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: "primaza-system"},
+		Spec: primazaiov1alpha1.ServiceClaimSpec{
+			ServiceClassIdentity: []primazaiov1alpha1.ServiceClassIdentityItem{
+				{Name: "provider", Value: "aws"},
+			},
+			ServiceEndpointDefinitionKeys: []string{"host", "password"},
+			Encoders: []primazaiov1alpha1.BindingSecretEncoder{
+				{Format: primazaiov1alpha1.BindingSecretFormatDotenv, Key: ".env"},
+			},
+		},
+		Status: primazaiov1alpha1.ServiceClaimStatus{
+			State:             primazaiov1alpha1.ServiceClaimStateResolved,
+			RegisteredService: "db",
+		},
+	}
+	rs := &primazaiov1alpha1.RegisteredService{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "primaza-system"},
+		Spec: primazaiov1alpha1.RegisteredServiceSpec{
+			ServiceClassIdentity: []primazaiov1alpha1.ServiceClassIdentityItem{
+				{Name: "type", Value: "psql"},
+				{Name: "provider", Value: "aws"},
+			},
+			ServiceEndpointDefinition: []primazaiov1alpha1.ServiceEndpointDefinitionItem{
+				{Name: "host", Value: "db:5432"},
+				{Name: "password", ValueFromSecret: &primazaiov1alpha1.ServiceEndpointDefinitionSecretRef{Name: "db-credentials", Key: "password"}},
+				{Name: "user", Value: "admin"},
+			},
+		},
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: "primaza-system"},
+		Data:       map[string][]byte{"password": []byte("s3cr3t")},
+	}
+	cli := fake.NewClientBuilder().WithObjects(credentials).Build()
+
+	secret, err := ResolvedBindingSecret(context.Background(), cli, sclaim, rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if secret.Type != "servicebinding.io/psql" {
+		t.Errorf("expected type servicebinding.io/psql, got %s", secret.Type)
+	}
+	want := map[string]string{"host": "db:5432", "provider": "aws", "type": "psql"}
+	for k, v := range want {
+		if secret.StringData[k] != v {
+			t.Errorf("expected %s to be %q, got %q", k, v, secret.StringData[k])
+		}
+	}
+	if string(secret.Data["password"]) != "s3cr3t" {
+		t.Errorf("expected the secret-backed password, got %q", secret.Data["password"])
+	}
+	if _, ok := secret.StringData[".env"]; !ok {
+		t.Error("expected the encoded .env key")
+	}
+	if _, ok := secret.StringData["user"]; ok {
+		t.Error("expected the unclaimed user key to be left out")
+	}
+
+	// claims using vault do not hold the secret-backed values
+	sclaim.Spec.Vault = &primazaiov1alpha1.ServiceClaimVault{Role: "app"}
+	secret, err = ResolvedBindingSecret(context.Background(), cli, sclaim, rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := secret.Data["password"]; ok {
+		t.Error("expected the password to be left in vault")
+	}
+	if secret.StringData["host"] != "db:5432" {
+		t.Errorf("expected the host to be kept, got %q", secret.StringData["host"])
+	}
+}
+
+func TestBindingSecretType(t *testing.T) {
+	tests := []struct {
+		name       string
+		secretType string
+		sci        []primazaiov1alpha1.ServiceClassIdentityItem
+		want       corev1.SecretType
+	}{
+		{
+			name: "opaque without a type",
+			sci:  []primazaiov1alpha1.ServiceClassIdentityItem{{Name: "provider", Value: "aws"}},
+			want: corev1.SecretTypeOpaque,
+		},
+		{
+			name: "opaque with an empty type",
+			sci:  []primazaiov1alpha1.ServiceClassIdentityItem{{Name: "type", Value: ""}},
+			want: corev1.SecretTypeOpaque,
+		},
+		{
+			name: "service binding type from the identity",
+			sci:  []primazaiov1alpha1.ServiceClassIdentityItem{{Name: "type", Value: "psql"}},
+			want: "servicebinding.io/psql",
+		},
+		{
+			name:       "explicit type",
+			secretType: "example.com/db",
+			sci:        []primazaiov1alpha1.ServiceClassIdentityItem{{Name: "type", Value: "psql"}},
+			want:       "example.com/db",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sclaim := &primazaiov1alpha1.ServiceClaim{
+				Spec: primazaiov1alpha1.ServiceClaimSpec{
+					SecretType:           tt.secretType,
+					ServiceClassIdentity: tt.sci,
+				},
+			}
+			if got := BindingSecretType(sclaim); got != tt.want {
+				t.Errorf("BindingSecretType() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetBindingSecretType(t *testing.T) {
+	tests := []struct {
+		name       string
+		secretType string
+		stringData map[string]string
+		sci        []primazaiov1alpha1.ServiceClassIdentityItem
+		wantType   corev1.SecretType
+		wantKey    string
+	}{
+		{
+			name:     "takes the type from the service identity",
+			sci:      []primazaiov1alpha1.ServiceClassIdentityItem{{Name: "type", Value: "psql"}},
+			wantType: "servicebinding.io/psql",
+			wantKey:  "psql",
+		},
+		{
+			name:       "keeps the type of the claim",
+			stringData: map[string]string{"type": "mysql"},
+			sci:        []primazaiov1alpha1.ServiceClassIdentityItem{{Name: "type", Value: "psql"}},
+			wantType:   corev1.SecretTypeOpaque,
+			wantKey:    "mysql",
+		},
+		{
+			name:       "keeps the explicit secret type",
+			secretType: "example.com/db",
+			sci:        []primazaiov1alpha1.ServiceClassIdentityItem{{Name: "type", Value: "psql"}},
+			wantType:   corev1.SecretTypeOpaque,
+			wantKey:    "psql",
+		},
+		{
+			name:     "leaves the secret unchanged without a type",
+			sci:      []primazaiov1alpha1.ServiceClassIdentityItem{{Name: "provider", Value: "aws"}},
+			wantType: corev1.SecretTypeOpaque,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sclaim := &primazaiov1alpha1.ServiceClaim{
+				Spec: primazaiov1alpha1.ServiceClaimSpec{SecretType: tt.secretType},
+			}
+			secret := &corev1.Secret{Type: corev1.SecretTypeOpaque, StringData: tt.stringData}
+
+			SetBindingSecretType(secret, sclaim, tt.sci)
+
+			if secret.Type != tt.wantType {
+				t.Errorf("expected type %s, got %s", tt.wantType, secret.Type)
+			}
+			if got := secret.StringData[ServiceClassIdentityTypeKey]; got != tt.wantKey {
+				t.Errorf("expected the type key to be %q, got %q", tt.wantKey, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"sort"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/matching"
+)
+
+// CatalogService returns the entry of a registered service in a catalog
+func CatalogService(rs primazaiov1alpha1.RegisteredService) primazaiov1alpha1.ServiceCatalogService {
+	sedKeys := make([]string, 0, len(rs.Spec.ServiceEndpointDefinition))
+	for _, sed := range rs.Spec.ServiceEndpointDefinition {
+		sedKeys = append(sedKeys, sed.Name)
+	}
+
+	return primazaiov1alpha1.ServiceCatalogService{
+		Name:                          rs.Name,
+		ServiceMetadata:               rs.Spec.ServiceMetadata,
+		ServiceClassIdentity:          rs.Spec.ServiceClassIdentity,
+		ServiceEndpointDefinitionKeys: sedKeys,
+	}
+}
+
+// ServiceCatalogProjection projects the catalog of an environment onto the
+// application namespaces of one of its cluster environments
+type ServiceCatalogProjection struct {
+	// Catalog of the environment, which lists the available services whose
+	// constraints match the environment
+	Catalog primazaiov1alpha1.ServiceCatalog
+	// ClusterEnvironment the catalog is projected for
+	ClusterEnvironment primazaiov1alpha1.ClusterEnvironment
+	// Claims of the cluster environment's namespace
+	Claims []primazaiov1alpha1.ServiceClaim
+	// Services registered in the cluster environment's namespace
+	Services []primazaiov1alpha1.RegisteredService
+}
+
+// Namespace returns the catalog of the given application namespace, i.e.
+// the services of the environment's catalog that match the cluster
+// environment's filters, along with the services claimed by the claims bound
+// in the namespace
+func (p ServiceCatalogProjection) Namespace(namespace string) primazaiov1alpha1.ServiceCatalogSpec {
+	ss := matching.FilterCatalog(p.Catalog.Spec.Services, p.ClusterEnvironment.Spec.ServiceCatalogFilters)
+	listed := make(map[string]bool, len(ss))
+	for _, s := range ss {
+		listed[s.Name] = true
+	}
+
+	claimed := []primazaiov1alpha1.ServiceCatalogService{}
+	for _, sc := range p.Claims {
+		rs := sc.Status.RegisteredService
+		if rs == "" || listed[rs] || !p.boundIn(sc, namespace) {
+			continue
+		}
+		for _, s := range p.Services {
+			if s.Name == rs {
+				claimed = append(claimed, CatalogService(s))
+				listed[rs] = true
+				break
+			}
+		}
+	}
+	sort.Slice(claimed, func(i, j int) bool { return claimed[i].Name < claimed[j].Name })
+
+	services := append(append([]primazaiov1alpha1.ServiceCatalogService{}, ss...), claimed...)
+	if len(services) == 0 {
+		services = nil
+	}
+	return primazaiov1alpha1.ServiceCatalogSpec{Services: services}
+}
+
+func (p ServiceCatalogProjection) boundIn(sc primazaiov1alpha1.ServiceClaim, namespace string) bool {
+	for _, b := range sc.Status.Bindings {
+		if b.ClusterEnvironment == p.ClusterEnvironment.Name && b.Namespace == namespace {
+			return true
+		}
+	}
+	return false
+}
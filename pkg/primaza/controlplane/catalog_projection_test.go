@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"reflect"
+	"testing"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServiceCatalogProjection(t *testing.T) {
+	registeredService := func(name, serviceType string) primazaiov1alpha1.RegisteredService {
+		return primazaiov1alpha1.RegisteredService{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: primazaiov1alpha1.RegisteredServiceSpec{
+				ServiceClassIdentity: []primazaiov1alpha1.ServiceClassIdentityItem{{Name: "type", Value: serviceType}},
+				ServiceEndpointDefinition: []primazaiov1alpha1.ServiceEndpointDefinitionItem{
+					{Name: "host", Value: name},
+				},
+			},
+		}
+	}
+	postgres := registeredService("postgres", "postgresql")
+	redis := registeredService("redis", "redis")
+	claimed := registeredService("claimed", "redis")
+
+	p := ServiceCatalogProjection{
+		Catalog: primazaiov1alpha1.ServiceCatalog{
+			Spec: primazaiov1alpha1.ServiceCatalogSpec{
+				Services: []primazaiov1alpha1.ServiceCatalogService{CatalogService(postgres), CatalogService(redis)},
+			},
+		},
+		ClusterEnvironment: primazaiov1alpha1.ClusterEnvironment{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker"},
+			Spec: primazaiov1alpha1.ClusterEnvironmentSpec{
+				ServiceCatalogFilters: []primazaiov1alpha1.ServiceCatalogFilter{
+					{ServiceClassIdentity: []primazaiov1alpha1.ServiceClassIdentityItem{{Name: "type", Value: "postgresql"}}},
+				},
+			},
+		},
+		Claims: []primazaiov1alpha1.ServiceClaim{
+			{
+				Status: primazaiov1alpha1.ServiceClaimStatus{
+					RegisteredService: "claimed",
+					Bindings: []primazaiov1alpha1.ServiceClaimBinding{
+						{ClusterEnvironment: "worker", Namespace: "apps"},
+						{ClusterEnvironment: "other", Namespace: "more-apps"},
+					},
+				},
+			},
+		},
+		Services: []primazaiov1alpha1.RegisteredService{postgres, redis, claimed},
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		want      []primazaiov1alpha1.ServiceCatalogService
+	}{
+		{
+			name:      "filtered catalog and claimed services",
+			namespace: "apps",
+			want:      []primazaiov1alpha1.ServiceCatalogService{CatalogService(postgres), CatalogService(claimed)},
+		},
+		{
+			name:      "claims bound in other cluster environments",
+			namespace: "more-apps",
+			want:      []primazaiov1alpha1.ServiceCatalogService{CatalogService(postgres)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Namespace(tt.namespace); !reflect.DeepEqual(got.Services, tt.want) {
+				t.Errorf("Namespace() = %v, want %v", got.Services, tt.want)
+			}
+		})
+	}
+
+	if got := CatalogService(postgres); !reflect.DeepEqual(got.ServiceEndpointDefinitionKeys, []string{"host"}) {
+		t.Errorf("CatalogService() keys = %v, want [host]", got.ServiceEndpointDefinitionKeys)
+	}
+}
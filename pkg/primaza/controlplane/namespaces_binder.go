@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/primaza/primaza/pkg/primaza/workercluster"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -34,23 +35,25 @@ type NamespacesBinder interface {
 	BindNamespaces(ctx context.Context, ceName string, ceNamespace string, namespaces []string) error
 }
 
-func NewApplicationNamespacesBinder(primazaClient client.Client, workerClient *kubernetes.Clientset, agentImage string) NamespacesBinder {
+func NewApplicationNamespacesBinder(primazaClient client.Client, workerClient *kubernetes.Clientset, agentImage string, tokens *AgentTokenIssuer) NamespacesBinder {
 	return &namespacesBinder{
 		pcli:       primazaClient,
 		wcli:       workerClient,
 		kind:       ApplicationNamespaceType,
 		agentImage: agentImage,
 		pushAgent:  workercluster.PushApplicationAgent,
+		tokens:     tokens,
 	}
 }
 
-func NewServiceNamespacesBinder(primazaClient client.Client, workerClient *kubernetes.Clientset, agentImage string) NamespacesBinder {
+func NewServiceNamespacesBinder(primazaClient client.Client, workerClient *kubernetes.Clientset, agentImage string, tokens *AgentTokenIssuer) NamespacesBinder {
 	return &namespacesBinder{
 		pcli:       primazaClient,
 		wcli:       workerClient,
 		kind:       ServiceNamespaceType,
 		agentImage: agentImage,
 		pushAgent:  workercluster.PushServiceAgent,
+		tokens:     tokens,
 	}
 }
 
@@ -61,30 +64,82 @@ type namespacesBinder struct {
 
 	agentImage string
 	pushAgent  func(context.Context, *kubernetes.Clientset, string, string, string) error
+
+	// tokens issues the agents' tokens, if they authenticate with
+	// short-lived tokens
+	tokens *AgentTokenIssuer
 }
 
 func (b *namespacesBinder) BindNamespaces(ctx context.Context, ceName string, ceNamespace string, namespaces []string) error {
 	l := log.FromContext(ctx)
 
-	ens := []string{}
+	ens := map[string]error{}
 	for _, ns := range namespaces {
 		if err := b.bindNamespace(ctx, ceName, ceNamespace, ns); err != nil {
-			ens = append(ens, ns)
+			ens[ns] = err
 			l.Error(err, "error binding namespace", "cluster-environment", ceName, "namespace", ns)
 		}
 	}
 
 	if len(ens) != 0 {
-		return fmt.Errorf("error binding namespaces: %v", ens)
+		return &NamespacesBindingError{Type: b.kind, Errors: ens}
 	}
 	return nil
 }
 
+// NamespacesBindingError reports the namespaces of a given type that could
+// not be bound, along with the error each of them failed with
+type NamespacesBindingError struct {
+	Type   NamespaceType
+	Errors map[string]error
+}
+
+func (e *NamespacesBindingError) Error() string {
+	nn := make([]string, 0, len(e.Errors))
+	for ns := range e.Errors {
+		nn = append(nn, ns)
+	}
+	sort.Strings(nn)
+	return fmt.Sprintf("error binding namespaces: %v", nn)
+}
+
+// NamespaceBindingErrors collects the errors of the namespaces of the given
+// type that could not be bound, from the NamespacesBindingErrors wrapped or
+// joined in err
+func NamespaceBindingErrors(err error, t NamespaceType) map[string]error {
+	ee := map[string]error{}
+	var collect func(error)
+	collect = func(err error) {
+		if e, ok := err.(*NamespacesBindingError); ok && e.Type == t {
+			for ns, err := range e.Errors {
+				ee[ns] = err
+			}
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, err := range u.Unwrap() {
+				collect(err)
+			}
+		case interface{ Unwrap() error }:
+			collect(u.Unwrap())
+		}
+	}
+	collect(err)
+	return ee
+}
+
 func (b *namespacesBinder) bindNamespace(ctx context.Context, ceName, ceNamespace string, namespace string) error {
 	if err := b.createRoleBindings(ctx, ceName, ceNamespace, namespace); err != nil {
 		return err
 	}
 
+	if b.tokens != nil {
+		sa := b.bakeServiceAccountName(ceName, namespace)
+		if err := b.tokens.PushAgentToken(ctx, b.pcli, b.wcli, b.kind, sa, ceName, ceNamespace, namespace); err != nil {
+			return err
+		}
+	}
+
 	if err := b.pushAgent(ctx, b.wcli, namespace, ceName, b.agentImage); err != nil {
 		return err
 	}
@@ -133,5 +188,5 @@ func (b *namespacesBinder) createRoleBinding(ctx context.Context, ceName, ceName
 }
 
 func (b *namespacesBinder) bakeServiceAccountName(ceName, namespace string) string {
-	return fmt.Sprintf("primaza-%s-%s-%s", b.kind.Short(), ceName, namespace)
+	return bakeAgentServiceAccountName(b.kind, ceName, namespace)
 }
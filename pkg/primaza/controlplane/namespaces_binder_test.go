@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestNamespaceBindingErrors(t *testing.T) {
+	errA := errors.New("a")
+	errB := errors.New("b")
+	errC := errors.New("c")
+	err := errors.Join(
+		&NamespacesBindingError{Type: ApplicationNamespaceType, Errors: map[string]error{"apps": errA}},
+		fmt.Errorf("wrapped: %w", &NamespacesBindingError{Type: ServiceNamespaceType, Errors: map[string]error{"svcs": errB, "dbs": errC}}),
+		errors.New("unbinding failed"),
+	)
+
+	tests := []struct {
+		name string
+		err  error
+		t    NamespaceType
+		want map[string]error
+	}{
+		{name: "application", err: err, t: ApplicationNamespaceType, want: map[string]error{"apps": errA}},
+		{name: "service", err: err, t: ServiceNamespaceType, want: map[string]error{"svcs": errB, "dbs": errC}},
+		{name: "no binding error", err: errors.New("unbinding failed"), t: ServiceNamespaceType, want: map[string]error{}},
+		{name: "nil", err: nil, t: ServiceNamespaceType, want: map[string]error{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NamespaceBindingErrors(tt.err, tt.t); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NamespaceBindingErrors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNamespacesBindingErrorMessage(t *testing.T) {
+	err := &NamespacesBindingError{Type: ServiceNamespaceType, Errors: map[string]error{"svcs": errors.New("b"), "dbs": errors.New("c")}}
+	if got, want := err.Error(), "error binding namespaces: [dbs svcs]"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
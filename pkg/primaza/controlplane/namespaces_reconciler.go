@@ -39,6 +39,10 @@ type ClusterEnvironmentState struct {
 
 	AppAgentImage string
 	SvcAgentImage string
+
+	// AgentTokens issues the tokens agents authenticate with, or is nil if
+	// agents use the kubeconfig provided in their namespace
+	AgentTokens *AgentTokenIssuer
 }
 
 type NamespacesReconciler interface {
@@ -70,9 +74,9 @@ func NewNamespaceReconciler(e ClusterEnvironmentState) (NamespacesReconciler, er
 	return &namespacesReconciler{
 		pcli:        cli,
 		env:         e,
-		appBinder:   NewApplicationNamespacesBinder(cli, wcli, e.AppAgentImage),
+		appBinder:   NewApplicationNamespacesBinder(cli, wcli, e.AppAgentImage, e.AgentTokens),
 		appUnbinder: NewApplicationNamespacesUnbinder(cli, wcli),
-		svcBinder:   NewServiceNamespacesBinder(cli, wcli, e.SvcAgentImage),
+		svcBinder:   NewServiceNamespacesBinder(cli, wcli, e.SvcAgentImage, e.AgentTokens),
 		svcUnbinder: NewServiceNamespacesUnbinder(cli, wcli),
 	}, nil
 }
@@ -86,6 +86,11 @@ func (b *namespacesUnbinder) unbindNamespace(ctx context.Context, ceName, ceName
 		return err
 	}
 
+	sa := bakeAgentServiceAccountName(b.kind, ceName, namespace)
+	if err := DeleteAgentServiceAccount(ctx, b.pcli, sa, ceNamespace); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -18,27 +18,49 @@ package controlplane
 
 import (
 	"context"
+	"fmt"
 
 	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+	"github.com/primaza/primaza/pkg/primaza/remotewriter"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-func PushServiceClassToNamespaces(ctx context.Context, cli client.Client, sc primazaiov1alpha1.ServiceClass, namespaces []string) error {
+// PushServiceClassToNamespaces writes a copy of the service class in the given
+// namespaces of a cluster environment.  The health check of the copies is the
+// one the service class defines for the cluster environment's environment,
+// and the copies are labeled with the cluster environment's name, so that
+// service agents can tell the registered services where they are discovered.
+// They are also labeled with the tenant, i.e. the service class' namespace,
+// and the copies of other tenants' service classes are not overwritten.
+func PushServiceClassToNamespaces(ctx context.Context, cli client.Client, sc primazaiov1alpha1.ServiceClass, ce primazaiov1alpha1.ClusterEnvironment, namespaces []string) error {
+	spec := sc.Spec
+	spec.HealthCheck = sc.Spec.HealthCheckFor(ce.Spec.EnvironmentName)
+	spec.HealthCheckOverrides = nil
+
 	for _, ns := range namespaces {
+		// do not overwrite the service classes of other tenants
+		current := &primazaiov1alpha1.ServiceClass{}
+		if err := cli.Get(ctx, client.ObjectKey{Namespace: ns, Name: sc.Name}, current); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		if t, ok := current.Labels[constants.PrimazaTenantLabel]; ok && t != sc.Namespace {
+			return fmt.Errorf("service class %s/%s belongs to tenant %s", ns, sc.Name, t)
+		}
+
 		sccp := &primazaiov1alpha1.ServiceClass{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      sc.Name,
 				Namespace: ns,
+				Labels: map[string]string{
+					constants.PrimazaClusterEnvironmentLabel: ce.Name,
+					constants.PrimazaTenantLabel:             sc.Namespace,
+				},
 			},
+			Spec: spec,
 		}
-
-		_, err := controllerutil.CreateOrUpdate(ctx, cli, sccp, func() error {
-			sccp.Spec = sc.Spec
-			return nil
-		})
-		if err != nil {
+		if _, err := remotewriter.Apply(ctx, cli, constants.ControlPlaneFieldManager, sccp); err != nil {
 			return err
 		}
 	}
@@ -48,11 +70,13 @@ func PushServiceClassToNamespaces(ctx context.Context, cli client.Client, sc pri
 
 func DeleteServiceClassFromNamespaces(ctx context.Context, cli client.Client, sc primazaiov1alpha1.ServiceClass, namespaces []string) error {
 	for _, ns := range namespaces {
-		sccp := &primazaiov1alpha1.ServiceClass{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      sc.Name,
-				Namespace: ns,
-			},
+		sccp := &primazaiov1alpha1.ServiceClass{}
+		if err := cli.Get(ctx, client.ObjectKey{Namespace: ns, Name: sc.Name}, sccp); err != nil {
+			return err
+		}
+		// leave the service classes of other tenants alone
+		if t, ok := sccp.Labels[constants.PrimazaTenantLabel]; ok && t != sc.Namespace {
+			continue
 		}
 
 		if err := cli.Delete(ctx, sccp, &client.DeleteOptions{}); err != nil {
@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crossplane
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+// CompositeResourceDefinitionGVK is the GroupVersionKind of the Crossplane
+// CompositeResourceDefinitions
+var CompositeResourceDefinitionGVK = schema.GroupVersionKind{
+	Group:   "apiextensions.crossplane.io",
+	Version: "v1",
+	Kind:    "CompositeResourceDefinition",
+}
+
+const (
+	// ServiceClassAnnotation opts a CompositeResourceDefinition out of the
+	// generation of its ServiceClass when set to `false`
+	ServiceClassAnnotation = "primaza.io/service-class"
+
+	// Provider is the value of the `provider` ServiceClassIdentity item of
+	// the generated ServiceClasses
+	Provider = "crossplane"
+
+	// connectionSecretNamePath is the path of the name of the Secret the
+	// connection details of a claim are written to, in the claim's namespace
+	connectionSecretNamePath = ".spec.writeConnectionSecretToRef.name"
+)
+
+// ErrNoClaim is returned for the CompositeResourceDefinitions that do not
+// offer a claim: the connection details of their composite resources are
+// written to Secrets in arbitrary namespaces, which ServiceClasses can not
+// read from
+var ErrNoClaim = errors.New("composite resource definition does not offer a claim")
+
+// Enabled tells whether a ServiceClass is generated for the
+// CompositeResourceDefinition
+func Enabled(xrd *unstructured.Unstructured) bool {
+	return xrd.GetAnnotations()[ServiceClassAnnotation] != "false"
+}
+
+// ServiceClassSpec returns the spec of the ServiceClass discovering the claims
+// of the composite resources defined by the CompositeResourceDefinition.  Each
+// of the connection secret keys the CompositeResourceDefinition declares is
+// read from the claim's connection Secret.  The ServiceClass identifies the
+// services by `type`, the lowercased kind of the claim, and `provider`,
+// `crossplane`.
+func ServiceClassSpec(xrd *unstructured.Unstructured) (*primazaiov1alpha1.ServiceClassSpec, error) {
+	group, _, err := unstructured.NestedString(xrd.Object, "spec", "group")
+	if err != nil {
+		return nil, err
+	}
+	kind, found, err := unstructured.NestedString(xrd.Object, "spec", "claimNames", "kind")
+	if err != nil {
+		return nil, err
+	}
+	if !found || kind == "" {
+		return nil, ErrNoClaim
+	}
+	version, err := referenceableVersion(xrd)
+	if err != nil {
+		return nil, err
+	}
+	keys, _, err := unstructured.NestedStringSlice(xrd.Object, "spec", "connectionSecretKeys")
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := make([]primazaiov1alpha1.ServiceClassSecretRefFieldMapping, 0, len(keys))
+	for _, k := range keys {
+		mappings = append(mappings, primazaiov1alpha1.ServiceClassSecretRefFieldMapping{
+			Name:       k,
+			SecretName: connectionSecretNamePath,
+			// a quoted JSONPath evaluates to the string itself
+			SecretKey: fmt.Sprintf("%q", k),
+		})
+	}
+
+	return &primazaiov1alpha1.ServiceClassSpec{
+		Resource: primazaiov1alpha1.ServiceClassResource{
+			APIVersion: schema.GroupVersion{Group: group, Version: version}.String(),
+			Kind:       kind,
+			ServiceEndpointDefinitionMappings: primazaiov1alpha1.ServiceEndpointDefinitionMappings{
+				SecretRefFields: mappings,
+			},
+		},
+		ServiceClassIdentity: []primazaiov1alpha1.ServiceClassIdentityItem{
+			{Name: "type", Value: strings.ToLower(kind)},
+			{Name: "provider", Value: Provider},
+		},
+	}, nil
+}
+
+// referenceableVersion returns the version of the composite resources
+// Compositions refer to, falling back to the first served one
+func referenceableVersion(xrd *unstructured.Unstructured) (string, error) {
+	versions, _, err := unstructured.NestedSlice(xrd.Object, "spec", "versions")
+	if err != nil {
+		return "", err
+	}
+
+	served := ""
+	for _, v := range versions {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(m, "name")
+		if r, _, _ := unstructured.NestedBool(m, "referenceable"); r {
+			return name, nil
+		}
+		if s, _, _ := unstructured.NestedBool(m, "served"); s && served == "" {
+			served = name
+		}
+	}
+	if served == "" {
+		return "", fmt.Errorf("composite resource definition %s serves no version", xrd.GetName())
+	}
+	return served, nil
+}
+
+// NewCompositeResourceDefinition returns an empty CompositeResourceDefinition,
+// to be filled by the client
+func NewCompositeResourceDefinition() *unstructured.Unstructured {
+	xrd := &unstructured.Unstructured{}
+	xrd.SetGroupVersionKind(CompositeResourceDefinitionGVK)
+	return xrd
+}
+
+// IsOwnedBy tells whether the ServiceClass has been generated for the given
+// CompositeResourceDefinition
+func IsOwnedBy(sc *primazaiov1alpha1.ServiceClass, xrd *unstructured.Unstructured) bool {
+	ref := metav1.GetControllerOf(sc)
+	return ref != nil && ref.UID == xrd.GetUID()
+}
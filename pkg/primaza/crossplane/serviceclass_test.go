@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crossplane
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/sed"
+)
+
+func newXRD(spec map[string]interface{}) *unstructured.Unstructured {
+	xrd := NewCompositeResourceDefinition()
+	xrd.SetName("xpostgresqlinstances.database.example.org")
+	xrd.Object["spec"] = spec
+	return xrd
+}
+
+func TestServiceClassSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    map[string]interface{}
+		want    *primazaiov1alpha1.ServiceClassSpec
+		wantErr error
+	}{
+		{
+			name: "referenceable version",
+			spec: map[string]interface{}{
+				"group":                "database.example.org",
+				"claimNames":           map[string]interface{}{"kind": "PostgreSQLInstance"},
+				"connectionSecretKeys": []interface{}{"username", "password"},
+				"versions": []interface{}{
+					map[string]interface{}{"name": "v1alpha1", "served": true},
+					map[string]interface{}{"name": "v1", "served": true, "referenceable": true},
+				},
+			},
+			want: &primazaiov1alpha1.ServiceClassSpec{
+				Resource: primazaiov1alpha1.ServiceClassResource{
+					APIVersion: "database.example.org/v1",
+					Kind:       "PostgreSQLInstance",
+					ServiceEndpointDefinitionMappings: primazaiov1alpha1.ServiceEndpointDefinitionMappings{
+						SecretRefFields: []primazaiov1alpha1.ServiceClassSecretRefFieldMapping{
+							{Name: "username", SecretName: ".spec.writeConnectionSecretToRef.name", SecretKey: `"username"`},
+							{Name: "password", SecretName: ".spec.writeConnectionSecretToRef.name", SecretKey: `"password"`},
+						},
+					},
+				},
+				ServiceClassIdentity: []primazaiov1alpha1.ServiceClassIdentityItem{
+					{Name: "type", Value: "postgresqlinstance"},
+					{Name: "provider", Value: "crossplane"},
+				},
+			},
+		},
+		{
+			name: "served version",
+			spec: map[string]interface{}{
+				"group":      "database.example.org",
+				"claimNames": map[string]interface{}{"kind": "PostgreSQLInstance"},
+				"versions": []interface{}{
+					map[string]interface{}{"name": "v1alpha1", "served": false},
+					map[string]interface{}{"name": "v1beta1", "served": true},
+				},
+			},
+			want: &primazaiov1alpha1.ServiceClassSpec{
+				Resource: primazaiov1alpha1.ServiceClassResource{
+					APIVersion: "database.example.org/v1beta1",
+					Kind:       "PostgreSQLInstance",
+					ServiceEndpointDefinitionMappings: primazaiov1alpha1.ServiceEndpointDefinitionMappings{
+						SecretRefFields: []primazaiov1alpha1.ServiceClassSecretRefFieldMapping{},
+					},
+				},
+				ServiceClassIdentity: []primazaiov1alpha1.ServiceClassIdentityItem{
+					{Name: "type", Value: "postgresqlinstance"},
+					{Name: "provider", Value: "crossplane"},
+				},
+			},
+		},
+		{
+			name: "no claim",
+			spec: map[string]interface{}{
+				"group":    "database.example.org",
+				"versions": []interface{}{map[string]interface{}{"name": "v1", "served": true, "referenceable": true}},
+			},
+			wantErr: ErrNoClaim,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ServiceClassSpec(newXRD(tt.spec))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ServiceClassSpec() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ServiceClassSpec() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestConnectionSecretMapping checks that the service agent reads the
+// connection details of a claim through the generated mappings
+func TestConnectionSecretMapping(t *testing.T) {
+	spec, err := ServiceClassSpec(newXRD(map[string]interface{}{
+		"group":                "database.example.org",
+		"claimNames":           map[string]interface{}{"kind": "PostgreSQLInstance"},
+		"connectionSecretKeys": []interface{}{"password"},
+		"versions":             []interface{}{map[string]interface{}{"name": "v1", "served": true, "referenceable": true}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claim := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"writeConnectionSecretToRef": map[string]interface{}{"name": "db-conn"}},
+	}}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-conn", Namespace: "services"},
+		Data:       map[string][]byte{"password": []byte("s3cr3t")},
+	}
+	cli := fake.NewClientBuilder().WithObjects(secret).Build()
+
+	m, err := sed.NewSEDSecretRefMapping("services", claim, cli, spec.Resource.ServiceEndpointDefinitionMappings.SecretRefFields[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := m.ReadKey(context.Background())
+	if err != nil {
+		t.Fatalf("ReadKey() error = %v", err)
+	}
+	if *v != "s3cr3t" {
+		t.Errorf("ReadKey() = %s, want s3cr3t", *v)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	xrd := newXRD(nil)
+	if !Enabled(xrd) {
+		t.Error("Enabled() = false, want true")
+	}
+	xrd.SetAnnotations(map[string]string{ServiceClassAnnotation: "false"})
+	if Enabled(xrd) {
+		t.Error("Enabled() = true, want false")
+	}
+}
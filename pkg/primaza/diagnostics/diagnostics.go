@@ -0,0 +1,34 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	uberzap "go.uber.org/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// AddToManager adds to the manager the watcher of the log level configured
+// in the namespace, and the pprof endpoint if pprofAddr is not empty
+func AddToManager(mgr manager.Manager, namespace string, level uberzap.AtomicLevel, pprofAddr string) error {
+	if err := mgr.Add(&LogLevelWatcher{Reader: mgr.GetAPIReader(), Namespace: namespace, Level: level}); err != nil {
+		return err
+	}
+	if pprofAddr != "" {
+		return mgr.Add(&PprofServer{Addr: pprofAddr})
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap, in the namespace Primaza
+	// or an agent runs in, that configures its diagnostics
+	ConfigMapName = "primaza-diagnostics"
+	// LogLevelKey is the ConfigMap key holding the log level, as accepted by
+	// the `--zap-log-level` flag: `debug`, `info`, `error`, or a
+	// positive verbosity
+	LogLevelKey = "logLevel"
+)
+
+// logLevelCheckPeriod is the period the ConfigMap is checked with
+const logLevelCheckPeriod = 10 * time.Second
+
+var levels = map[string]zapcore.Level{
+	"debug": zapcore.DebugLevel,
+	"info":  zapcore.InfoLevel,
+	"error": zapcore.ErrorLevel,
+}
+
+// ParseLevel parses a log level as accepted by the `--zap-log-level` flag
+func ParseLevel(value string) (zapcore.Level, error) {
+	if l, ok := levels[strings.ToLower(value)]; ok {
+		return l, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v <= 0 || v > 127 {
+		return 0, fmt.Errorf("invalid log level %q", value)
+	}
+	return zapcore.Level(int8(-v)), nil
+}
+
+// NewAtomicLevel makes the level of the logger built from opts adjustable at
+// runtime, and returns it.  Its initial level is the one set by flags, if
+// any, or the default one.
+func NewAtomicLevel(opts *zap.Options) uberzap.AtomicLevel {
+	if l, ok := opts.Level.(uberzap.AtomicLevel); ok {
+		return l
+	}
+	level := uberzap.NewAtomicLevelAt(zapcore.InfoLevel)
+	if opts.Development {
+		level.SetLevel(zapcore.DebugLevel)
+	}
+	opts.Level = level
+	return level
+}
+
+// LogLevelWatcher sets the log level to the one configured in the
+// diagnostics ConfigMap, or back to the initial one if none is configured
+type LogLevelWatcher struct {
+	// Reader reads the ConfigMap, it should not be backed by a cache so
+	// that no permission to list and watch ConfigMaps is required
+	Reader    client.Reader
+	Namespace string
+	Level     uberzap.AtomicLevel
+}
+
+// Start checks the ConfigMap every logLevelCheckPeriod, until ctx is done.
+// It implements manager.Runnable.
+func (w *LogLevelWatcher) Start(ctx context.Context) error {
+	l := log.FromContext(ctx).WithName("diagnostics")
+	initial := w.Level.Level()
+
+	ticker := time.NewTicker(logLevelCheckPeriod)
+	defer ticker.Stop()
+	for {
+		level, err := w.configuredLevel(ctx, initial)
+		if err != nil {
+			l.Error(err, "unable to read the configured log level", "configmap", ConfigMapName)
+		} else if level != w.Level.Level() {
+			l.Info("changing log level", "from", w.Level.Level(), "to", level)
+			w.Level.SetLevel(level)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that the
+// log level of standby replicas is changed as well
+func (w *LogLevelWatcher) NeedLeaderElection() bool {
+	return false
+}
+
+// configuredLevel returns the level configured in the ConfigMap, or def if
+// none is configured
+func (w *LogLevelWatcher) configuredLevel(ctx context.Context, def zapcore.Level) (zapcore.Level, error) {
+	cm := corev1.ConfigMap{}
+	if err := w.Reader.Get(ctx, client.ObjectKey{Namespace: w.Namespace, Name: ConfigMapName}, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return def, nil
+		}
+		return def, err
+	}
+	value, ok := cm.Data[LogLevelKey]
+	if !ok {
+		return def, nil
+	}
+	return ParseLevel(value)
+}
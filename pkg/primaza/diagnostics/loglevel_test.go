@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    zapcore.Level
+		wantErr bool
+	}{
+		{value: "debug", want: zapcore.DebugLevel},
+		{value: "Info", want: zapcore.InfoLevel},
+		{value: "error", want: zapcore.ErrorLevel},
+		{value: "3", want: zapcore.Level(-3)},
+		{value: "0", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "verbose", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseLevel(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAtomicLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		opts zap.Options
+		want zapcore.Level
+	}{
+		{name: "development default", opts: zap.Options{Development: true}, want: zapcore.DebugLevel},
+		{name: "production default", opts: zap.Options{}, want: zapcore.InfoLevel},
+		{name: "flag", opts: zap.Options{Development: true, Level: uberzap.NewAtomicLevelAt(zapcore.ErrorLevel)}, want: zapcore.ErrorLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level := NewAtomicLevel(&tt.opts)
+			if got := level.Level(); got != tt.want {
+				t.Errorf("NewAtomicLevel() = %v, want %v", got, tt.want)
+			}
+			level.SetLevel(zapcore.Level(-2))
+			if !tt.opts.Level.Enabled(zapcore.Level(-2)) {
+				t.Errorf("options level is not adjusted along with the atomic level")
+			}
+		})
+	}
+}
+
+func TestConfiguredLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		want    zapcore.Level
+		wantErr bool
+	}{
+		{name: "no ConfigMap", want: zapcore.InfoLevel},
+		{name: "no level", data: map[string]string{}, want: zapcore.InfoLevel},
+		{name: "level", data: map[string]string{LogLevelKey: "debug"}, want: zapcore.DebugLevel},
+		{name: "verbosity", data: map[string]string{LogLevelKey: "5"}, want: zapcore.Level(-5)},
+		{name: "invalid level", data: map[string]string{LogLevelKey: "spam"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder()
+			if tt.data != nil {
+				builder = builder.WithObjects(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: "primaza-system"},
+					Data:       tt.data,
+				})
+			}
+			w := LogLevelWatcher{Reader: builder.Build(), Namespace: "primaza-system"}
+			got, err := w.configuredLevel(context.Background(), zapcore.InfoLevel)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("configuredLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("configuredLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogLevelWatcher(t *testing.T) {
+	cli := fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: "primaza-system"},
+		Data:       map[string]string{LogLevelKey: "debug"},
+	}).Build()
+	w := LogLevelWatcher{Reader: cli, Namespace: "primaza-system", Level: uberzap.NewAtomicLevelAt(zapcore.InfoLevel)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Start(ctx) }()
+
+	// the level is checked as soon as the watcher starts
+	deadline := time.After(5 * time.Second)
+	for w.Level.Level() != zapcore.DebugLevel {
+		select {
+		case <-deadline:
+			t.Fatalf("expected the level to be set to debug, got %v", w.Level.Level())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the watcher to stop once its context is done")
+	}
+	if w.NeedLeaderElection() {
+		t.Error("expected the log level of standby replicas to be changed as well")
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Handler produces the service endpoint definition values of service
+// resources, e.g. by calling a cloud provider's API for the endpoint of a
+// database instance managed by ACK
+type Handler interface {
+	// Discover returns the values of the given service resource, by key.
+	// The client reads resources in the service agent's namespace.
+	Discover(ctx context.Context, cli client.Client, resource unstructured.Unstructured) (map[string]string, error)
+}
+
+// HandlerFunc adapts a function to the Handler interface
+type HandlerFunc func(ctx context.Context, cli client.Client, resource unstructured.Unstructured) (map[string]string, error)
+
+// Discover calls f
+func (f HandlerFunc) Discover(ctx context.Context, cli client.Client, resource unstructured.Unstructured) (map[string]string, error) {
+	return f(ctx, cli, resource)
+}
+
+var (
+	mu       sync.RWMutex
+	handlers = map[string]Handler{}
+)
+
+// Register makes a handler available to ServiceClasses under the given name.
+// It panics if the name is empty or already registered, as handlers are
+// expected to be registered from init functions.
+func Register(name string, h Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if name == "" {
+		panic("discovery: handler name is empty")
+	}
+	if _, found := handlers[name]; found {
+		panic(fmt.Sprintf("discovery: handler %s is already registered", name))
+	}
+	handlers[name] = h
+}
+
+// Lookup returns the handler registered under the given name
+func Lookup(name string) (Handler, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	h, found := handlers[name]
+	return h, found
+}
+
+// Names returns the sorted names of the registered handlers
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(handlers))
+	for n := range handlers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discovery
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRegister(t *testing.T) {
+	h := HandlerFunc(func(ctx context.Context, cli client.Client, resource unstructured.Unstructured) (map[string]string, error) {
+		return map[string]string{"host": resource.GetName() + ".example.com"}, nil
+	})
+	Register("test-register", h)
+
+	got, found := Lookup("test-register")
+	if !found {
+		t.Fatal("Lookup() handler not found")
+	}
+	resource := unstructured.Unstructured{}
+	resource.SetName("mydb")
+	values, err := got.Discover(context.Background(), nil, resource)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	if want := map[string]string{"host": "mydb.example.com"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("Discover() = %v, want %v", values, want)
+	}
+
+	if _, found := Lookup("test-missing"); found {
+		t.Error("Lookup() found an unregistered handler")
+	}
+
+	names := Names()
+	if len(names) == 0 || names[0] != "test-register" {
+		t.Errorf("Names() = %v, want test-register", names)
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	h := HandlerFunc(func(ctx context.Context, cli client.Client, resource unstructured.Unstructured) (map[string]string, error) {
+		return nil, nil
+	})
+	Register("test-twice", h)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() did not panic on a duplicate name")
+		}
+	}()
+	Register("test-twice", h)
+}
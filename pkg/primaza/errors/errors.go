@@ -0,0 +1,135 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+var (
+	// ErrUnreachable is the kind of the errors caused by a cluster, or a
+	// service, that can not be connected to
+	ErrUnreachable = errors.New("unreachable")
+	// ErrUnauthorized is the kind of the errors caused by missing or
+	// rejected credentials, or by missing permissions
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrNotMapped is the kind of the errors caused by a resource kind that
+	// is not served by the cluster
+	ErrNotMapped = errors.New("not mapped")
+	// ErrSEDResolution is the kind of the errors caused by a Service
+	// Endpoint Definition value that can not be read
+	ErrSEDResolution = errors.New("service endpoint definition not resolved")
+)
+
+// Error is the error of an operation, classified by its Kind.  Both the kind
+// and the cause can be matched with errors.Is and errors.As.
+type Error struct {
+	// Kind is one of the ErrXXX errors of this package
+	Kind error
+	// Op describes the failed operation
+	Op string
+	// Err is the cause of the failure
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Op, e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+func newError(kind error, op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Op: op, Err: err}
+}
+
+// Unreachable classifies err, the cause of the failure of op, as ErrUnreachable
+func Unreachable(op string, err error) error {
+	return newError(ErrUnreachable, op, err)
+}
+
+// Unauthorized classifies err, the cause of the failure of op, as ErrUnauthorized
+func Unauthorized(op string, err error) error {
+	return newError(ErrUnauthorized, op, err)
+}
+
+// NotMapped classifies err, the cause of the failure of op, as ErrNotMapped
+func NotMapped(op string, err error) error {
+	return newError(ErrNotMapped, op, err)
+}
+
+// SEDResolution classifies err, the cause of the failure of op, as ErrSEDResolution
+func SEDResolution(op string, err error) error {
+	return newError(ErrSEDResolution, op, err)
+}
+
+// Classify classifies err, returned by a request to a cluster's API server
+// while performing op, according to its cause.  Errors already classified,
+// and errors of unknown cause, are returned as they are.
+func Classify(op string, err error) error {
+	var e *Error
+	var netErr net.Error
+	switch {
+	case err == nil || errors.As(err, &e):
+		return err
+	case apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err):
+		return Unauthorized(op, err)
+	case meta.IsNoMatchError(err):
+		return NotMapped(op, err)
+	case errors.As(err, &netErr) || apierrors.IsServiceUnavailable(err) || apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err):
+		return Unreachable(op, err)
+	default:
+		return err
+	}
+}
+
+// Reason returns the reason of a status condition reporting err, or an
+// empty string if err is not classified
+func Reason(err error) string {
+	switch {
+	case errors.Is(err, ErrUnreachable):
+		return "Unreachable"
+	case errors.Is(err, ErrUnauthorized):
+		return "Unauthorized"
+	case errors.Is(err, ErrNotMapped):
+		return "NotMapped"
+	case errors.Is(err, ErrSEDResolution):
+		return "SEDResolutionFailed"
+	default:
+		return ""
+	}
+}
+
+// Retryable tells whether retrying the failed operation right away may
+// succeed.  Unauthorized, not mapped, and not resolved errors require a
+// change to the clusters, like granting permissions or installing a CRD, so
+// retrying them is better delayed.
+func Retryable(err error) bool {
+	return err != nil &&
+		!errors.Is(err, ErrUnauthorized) &&
+		!errors.Is(err, ErrNotMapped) &&
+		!errors.Is(err, ErrSEDResolution)
+}
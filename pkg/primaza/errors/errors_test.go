@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClassify(t *testing.T) {
+	gr := schema.GroupResource{Group: "primaza.io", Resource: "registeredservices"}
+	tests := []struct {
+		name      string
+		err       error
+		kind      error
+		reason    string
+		retryable bool
+	}{
+		{
+			name:      "forbidden",
+			err:       apierrors.NewForbidden(gr, "db", errors.New("no RBAC policy matched")),
+			kind:      ErrUnauthorized,
+			reason:    "Unauthorized",
+			retryable: false,
+		},
+		{
+			name:      "unauthorized",
+			err:       apierrors.NewUnauthorized("token expired"),
+			kind:      ErrUnauthorized,
+			reason:    "Unauthorized",
+			retryable: false,
+		},
+		{
+			name:      "no kind match",
+			err:       &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "example.com", Kind: "Database"}},
+			kind:      ErrNotMapped,
+			reason:    "NotMapped",
+			retryable: false,
+		},
+		{
+			name:      "connection refused",
+			err:       &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			kind:      ErrUnreachable,
+			reason:    "Unreachable",
+			retryable: true,
+		},
+		{
+			name:      "service unavailable",
+			err:       apierrors.NewServiceUnavailable("overloaded"),
+			kind:      ErrUnreachable,
+			reason:    "Unreachable",
+			retryable: true,
+		},
+		{
+			name:      "already classified",
+			err:       fmt.Errorf("reading host: %w", SEDResolution("resolve host", errors.New("no results"))),
+			kind:      ErrSEDResolution,
+			reason:    "SEDResolutionFailed",
+			retryable: false,
+		},
+		{
+			name:      "unknown",
+			err:       apierrors.NewConflict(gr, "db", errors.New("modified")),
+			reason:    "",
+			retryable: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Classify("write registered service", tt.err)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Classify() = %v, does not wrap %v", err, tt.err)
+			}
+			if tt.kind != nil && !errors.Is(err, tt.kind) {
+				t.Errorf("Classify() = %v, is not %v", err, tt.kind)
+			}
+			if got := Reason(err); got != tt.reason {
+				t.Errorf("Reason() = %q, want %q", got, tt.reason)
+			}
+			if got := Retryable(err); got != tt.retryable {
+				t.Errorf("Retryable() = %v, want %v", got, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	if err := Unreachable("connect to cluster", nil); err != nil {
+		t.Errorf("Unreachable(nil) = %v, want nil", err)
+	}
+
+	cause := errors.New("connection refused")
+	err := Unreachable("connect to cluster", cause)
+	if want := "connect to cluster: unreachable: connection refused"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	var e *Error
+	if !errors.As(err, &e) || e.Op != "connect to cluster" || e.Err != cause {
+		t.Errorf("errors.As() = %v, want the operation and its cause", e)
+	}
+}
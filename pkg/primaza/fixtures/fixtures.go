@@ -0,0 +1,348 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fixtures
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+// placeholderUID is the UID of the owners referred to by generated resources
+const placeholderUID = "00000000-0000-0000-0000-000000000000"
+
+// Fixture holds the resources generated for a ServiceClass
+type Fixture struct {
+	// Resource is the service resource matched by the ServiceClass
+	Resource unstructured.Unstructured
+	// Secondary is the secondary resource related to Resource, if the
+	// ServiceClass defines one
+	Secondary *unstructured.Unstructured
+	// Related are the resources related to Resource, one for each related
+	// resource the ServiceClass defines
+	Related []unstructured.Unstructured
+	// Secrets are the secrets referred to by Resource
+	Secrets []corev1.Secret
+	// Values are the values the service endpoint definition is expected to
+	// hold once extracted from the generated resources
+	Values map[string]string
+}
+
+// Objects returns the generated resources as unstructured objects, secrets
+// first, so that they can be applied in order
+func (f *Fixture) Objects() ([]unstructured.Unstructured, error) {
+	var objs []unstructured.Unstructured
+	for i := range f.Secrets {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&f.Secrets[i])
+		if err != nil {
+			return nil, err
+		}
+		unstructured.RemoveNestedField(u, "metadata", "creationTimestamp")
+		objs = append(objs, unstructured.Unstructured{Object: u})
+	}
+	objs = append(objs, f.Resource)
+	if f.Secondary != nil {
+		objs = append(objs, *f.Secondary)
+	}
+	objs = append(objs, f.Related...)
+	return objs, nil
+}
+
+// Generate returns a service resource matched by the given ServiceClass, in
+// which every resource field and secret reference declared by its mappings
+// holds a dummy value, along with the secrets, secondary and related
+// resources it refers to
+func Generate(sc v1alpha1.ServiceClass) (*Fixture, error) {
+	r := sc.Spec.Resource
+	name := sc.Name + "-fixture"
+	f := &Fixture{Values: map[string]string{}}
+
+	f.Resource.SetAPIVersion(r.APIVersion)
+	f.Resource.SetKind(r.Kind)
+	f.Resource.SetName(name)
+	f.Resource.SetNamespace(sc.Namespace)
+	if labels := selectedLabels(r.Selector); len(labels) > 0 {
+		f.Resource.SetLabels(labels)
+	}
+	if o := r.OwnedBy; o != nil {
+		f.Resource.SetOwnerReferences([]metav1.OwnerReference{
+			{APIVersion: o.APIVersion, Kind: o.Kind, Name: matchingName(o.Name), UID: placeholderUID},
+		})
+	}
+
+	mappings := r.ServiceEndpointDefinitionMappings
+	if err := setResourceFields(&f.Resource, mappings.ResourceFields, f.Values); err != nil {
+		return nil, err
+	}
+	if err := f.setSecretRefFields(name, mappings.SecretRefFields); err != nil {
+		return nil, err
+	}
+
+	if s := r.Secondary; s != nil {
+		secondary, err := f.generateSecondary(sc, *s, name+"-secondary")
+		if err != nil {
+			return nil, err
+		}
+		f.Secondary = &secondary
+	}
+	for i, s := range r.Related {
+		related, err := f.generateSecondary(sc, s, fmt.Sprintf("%s-related-%d", name, i))
+		if err != nil {
+			return nil, err
+		}
+		f.Related = append(f.Related, related)
+	}
+	return f, nil
+}
+
+// generateSecondary returns a secondary resource with the given name, joined
+// with the fixture's resource, in which every resource field holds a dummy
+// value
+func (f *Fixture) generateSecondary(sc v1alpha1.ServiceClass, s v1alpha1.ServiceClassSecondaryResource, name string) (unstructured.Unstructured, error) {
+	r := sc.Spec.Resource
+	secondary := unstructured.Unstructured{}
+	secondary.SetAPIVersion(s.APIVersion)
+	secondary.SetKind(s.Kind)
+	secondary.SetName(name)
+	secondary.SetNamespace(sc.Namespace)
+	switch s.Join.By {
+	case v1alpha1.ServiceClassJoinByLabel:
+		secondary.SetLabels(map[string]string{s.Join.Label: f.Resource.GetName()})
+	case v1alpha1.ServiceClassJoinByOwner:
+		secondary.SetOwnerReferences([]metav1.OwnerReference{
+			{APIVersion: r.APIVersion, Kind: r.Kind, Name: f.Resource.GetName(), UID: placeholderUID},
+		})
+	case v1alpha1.ServiceClassJoinByJSONPath:
+		if _, err := setPath(f.Resource.Object, s.Join.JsonPath, name); err != nil {
+			return unstructured.Unstructured{}, fmt.Errorf("join of %s: %w", s.Kind, err)
+		}
+	}
+	if err := setResourceFields(&secondary, s.ResourceFields, f.Values); err != nil {
+		return unstructured.Unstructured{}, err
+	}
+	return secondary, nil
+}
+
+// setResourceFields sets the dummy value of each mapping into the resource
+func setResourceFields(resource *unstructured.Unstructured, mappings []v1alpha1.ServiceClassResourceFieldMapping, values map[string]string) error {
+	for _, m := range mappings {
+		// the field may already be set if another mapping reads it
+		stored, err := setPath(resource.Object, m.JsonPath, encode(dummyValue(m.Name), m.Transformations))
+		if err != nil {
+			return fmt.Errorf("mapping %s: %w", m.Name, err)
+		}
+		values[m.Name] = decode(stored, m.Transformations, false)
+	}
+	return nil
+}
+
+// setSecretRefFields sets the secret names and keys of each mapping into the
+// service resource, and generates the secrets they refer to.  Mappings whose
+// secret name is read from the same field share the same secret.
+func (f *Fixture) setSecretRefFields(name string, mappings []v1alpha1.ServiceClassSecretRefFieldMapping) error {
+	secrets := map[string]*corev1.Secret{}
+	for _, m := range mappings {
+		secretName, err := setPath(f.Resource.Object, m.SecretName, fmt.Sprintf("%s-secret-%d", name, len(secrets)))
+		if err != nil {
+			return fmt.Errorf("mapping %s: %w", m.Name, err)
+		}
+		key, err := setPath(f.Resource.Object, m.SecretKey, m.Name)
+		if err != nil {
+			return fmt.Errorf("mapping %s: %w", m.Name, err)
+		}
+
+		secret, ok := secrets[secretName]
+		if !ok {
+			secret = &corev1.Secret{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+				ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: f.Resource.GetNamespace()},
+				Data:       map[string][]byte{},
+			}
+			secrets[secretName] = secret
+		}
+		if _, ok := secret.Data[key]; !ok {
+			secret.Data[key] = []byte(encode(dummyValue(m.Name), m.Transformations))
+		}
+		f.Values[m.Name] = decode(string(secret.Data[key]), m.Transformations, m.Binary)
+	}
+
+	names := make([]string, 0, len(secrets))
+	for n := range secrets {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		f.Secrets = append(f.Secrets, *secrets[n])
+	}
+	return nil
+}
+
+// dummyValue returns the value generated for the given mapping.  It is
+// lowercase and URL safe, so that it is unchanged by transformations.
+func dummyValue(name string) string {
+	return strings.ToLower(fmt.Sprintf("fixture-%s", name))
+}
+
+// encode returns the value to store so that the given transformations
+// result in value
+func encode(value string, transformations []v1alpha1.ValueTransformation) string {
+	for i := len(transformations) - 1; i >= 0; i-- {
+		if transformations[i] == v1alpha1.ValueTransformationBase64Decode {
+			value = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+	}
+	return value
+}
+
+// decode returns the value extracted from an already stored value
+func decode(stored string, transformations []v1alpha1.ValueTransformation, binary bool) string {
+	value := stored
+	for _, t := range transformations {
+		if t == v1alpha1.ValueTransformationBase64Decode {
+			if b, err := base64.StdEncoding.DecodeString(value); err == nil {
+				value = string(b)
+			}
+		}
+	}
+	if binary {
+		value = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	return value
+}
+
+// setPath sets value at the field the given JSONPath refers to, creating the
+// missing maps and slices, unless the field is already set.  It returns the
+// value of the field.
+func setPath(obj map[string]interface{}, path string, value string) (string, error) {
+	p, err := jsonpath.Parse("fixture", fmt.Sprintf("{%s}", path))
+	if err != nil {
+		return "", err
+	}
+	if len(p.Root.Nodes) != 1 {
+		return "", fmt.Errorf("unsupported JSONPath %s", path)
+	}
+	list, ok := p.Root.Nodes[0].(*jsonpath.ListNode)
+	if !ok || len(list.Nodes) == 0 {
+		return "", fmt.Errorf("unsupported JSONPath %s", path)
+	}
+
+	var current interface{} = obj
+	set := func(v interface{}) {}
+	for _, n := range list.Nodes {
+		switch node := n.(type) {
+		case *jsonpath.FieldNode:
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				m = map[string]interface{}{}
+				set(m)
+			}
+			current = m[node.Value]
+			set = func(v interface{}) { m[node.Value] = v }
+		case *jsonpath.ArrayNode:
+			if !node.Params[0].Known || node.Params[0].Value < 0 {
+				return "", fmt.Errorf("unsupported JSONPath %s: only positive array indexes are supported", path)
+			}
+			index := node.Params[0].Value
+			s, _ := current.([]interface{})
+			for len(s) <= index {
+				s = append(s, nil)
+			}
+			set(s)
+			current = s[index]
+			set = func(v interface{}) { s[index] = v }
+		default:
+			return "", fmt.Errorf("unsupported JSONPath %s: %s", path, n)
+		}
+	}
+
+	if current != nil {
+		return fmt.Sprintf("%v", current), nil
+	}
+	set(value)
+	return value, nil
+}
+
+// selectedLabels returns labels matched by the given selector
+func selectedLabels(selector *metav1.LabelSelector) map[string]string {
+	if selector == nil {
+		return nil
+	}
+
+	labels := map[string]string{}
+	for k, v := range selector.MatchLabels {
+		labels[k] = v
+	}
+	for _, e := range selector.MatchExpressions {
+		switch e.Operator {
+		case metav1.LabelSelectorOpIn:
+			if len(e.Values) > 0 {
+				labels[e.Key] = e.Values[0]
+			}
+		case metav1.LabelSelectorOpExists:
+			labels[e.Key] = "fixture"
+		}
+	}
+	return labels
+}
+
+// matchingName returns a name matching the given shell pattern
+func matchingName(pattern string) string {
+	if pattern == "" {
+		return "fixture-owner"
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteString("fixture")
+		case '?':
+			b.WriteByte('x')
+		case '[':
+			// use the first character of the class
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				b.WriteByte(c)
+				continue
+			}
+			class := strings.TrimPrefix(pattern[i+1:i+end], "!")
+			if len(class) > 0 && !strings.HasPrefix(pattern[i+1:], "!") {
+				b.WriteByte(class[0])
+			} else {
+				b.WriteByte('x')
+			}
+			i += end
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteByte(pattern[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fixtures
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/sed"
+)
+
+func TestGenerate(t *testing.T) {
+	sc := v1alpha1.ServiceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "psql", Namespace: "services"},
+		Spec: v1alpha1.ServiceClassSpec{
+			Resource: v1alpha1.ServiceClassResource{
+				APIVersion: "db.example.com/v1",
+				Kind:       "Database",
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"tier": "prod"},
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "team", Operator: metav1.LabelSelectorOpIn, Values: []string{"payments"}},
+					},
+				},
+				OwnedBy: &v1alpha1.ServiceClassResourceOwner{APIVersion: "db.example.com/v1", Kind: "Cluster", Name: "prod-*"},
+				ServiceEndpointDefinitionMappings: v1alpha1.ServiceEndpointDefinitionMappings{
+					ResourceFields: []v1alpha1.ServiceClassResourceFieldMapping{
+						{Name: "host", JsonPath: ".status.endpoints[1].host"},
+						{Name: "port", JsonPath: ".spec.port", Secret: false},
+						{Name: "token", JsonPath: ".spec.token", Transformations: []v1alpha1.ValueTransformation{
+							v1alpha1.ValueTransformationBase64Decode, v1alpha1.ValueTransformationTrimSpace,
+						}},
+					},
+					SecretRefFields: []v1alpha1.ServiceClassSecretRefFieldMapping{
+						{Name: "username", SecretName: ".spec.credentials.name", SecretKey: ".spec.credentials.usernameKey"},
+						{Name: "password", SecretName: ".spec.credentials.name", SecretKey: ".spec.credentials.passwordKey"},
+						{Name: "keystore", SecretName: ".spec.tls.secret", SecretKey: ".spec.tls.key", Binary: true},
+					},
+				},
+			},
+		},
+	}
+
+	f, err := Generate(sc)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	selector, _ := metav1.LabelSelectorAsSelector(sc.Spec.Resource.Selector)
+	if !selector.Matches(labels.Set(f.Resource.GetLabels())) {
+		t.Errorf("Generate() labels %v do not match selector %v", f.Resource.GetLabels(), selector)
+	}
+	if owners := f.Resource.GetOwnerReferences(); len(owners) != 1 || owners[0].Name != "prod-fixture" {
+		t.Errorf("Generate() owner references = %v, want prod-fixture", owners)
+	}
+	if len(f.Secrets) != 2 {
+		t.Errorf("Generate() secrets = %d, want 2", len(f.Secrets))
+	}
+
+	objs, err := f.Objects()
+	if err != nil {
+		t.Fatalf("Objects() error = %v", err)
+	}
+	if len(objs) != 3 {
+		t.Errorf("Objects() = %d objects, want 3", len(objs))
+	}
+
+	// the service agent extracts the expected values from the fixture
+	cli := fake.NewClientBuilder()
+	for i := range f.Secrets {
+		cli = cli.WithObjects(&f.Secrets[i])
+	}
+	mappings := sc.Spec.Resource.ServiceEndpointDefinitionMappings
+	got := map[string]string{}
+	for _, m := range mappings.ResourceFields {
+		rm, err := sed.NewSEDResourceMapping(f.Resource, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := rm.ReadKey(context.Background())
+		if err != nil {
+			t.Fatalf("mapping %s: %v", m.Name, err)
+		}
+		got[m.Name] = *v
+	}
+	for _, m := range mappings.SecretRefFields {
+		sm, err := sed.NewSEDSecretRefMapping("services", f.Resource, cli.Build(), m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := sm.ReadKey(context.Background())
+		if err != nil {
+			t.Fatalf("mapping %s: %v", m.Name, err)
+		}
+		got[m.Name] = *v
+	}
+	if !reflect.DeepEqual(got, f.Values) {
+		t.Errorf("extracted values = %v, want %v", got, f.Values)
+	}
+}
+
+func TestGenerateSecondary(t *testing.T) {
+	sc := v1alpha1.ServiceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "psql", Namespace: "services"},
+		Spec: v1alpha1.ServiceClassSpec{
+			Resource: v1alpha1.ServiceClassResource{
+				APIVersion: "db.example.com/v1",
+				Kind:       "Database",
+				Secondary: &v1alpha1.ServiceClassSecondaryResource{
+					APIVersion: "v1",
+					Kind:       "Service",
+					Join:       v1alpha1.ServiceClassJoin{By: v1alpha1.ServiceClassJoinByLabel, Label: "db"},
+					ResourceFields: []v1alpha1.ServiceClassResourceFieldMapping{
+						{Name: "host", JsonPath: ".spec.clusterIP"},
+					},
+				},
+			},
+		},
+	}
+
+	f, err := Generate(sc)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if f.Secondary == nil {
+		t.Fatal("Generate() secondary resource is missing")
+	}
+	if got := f.Secondary.GetLabels()["db"]; got != f.Resource.GetName() {
+		t.Errorf("Generate() secondary label = %s, want %s", got, f.Resource.GetName())
+	}
+	if got := f.Values["host"]; got != "fixture-host" {
+		t.Errorf("Generate() host = %s, want fixture-host", got)
+	}
+}
+
+func TestGenerateRelated(t *testing.T) {
+	sc := v1alpha1.ServiceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "psql", Namespace: "services"},
+		Spec: v1alpha1.ServiceClassSpec{
+			Resource: v1alpha1.ServiceClassResource{
+				APIVersion: "db.example.com/v1",
+				Kind:       "Database",
+				Related: []v1alpha1.ServiceClassSecondaryResource{
+					{
+						APIVersion: "v1",
+						Kind:       "ConfigMap",
+						Join:       v1alpha1.ServiceClassJoin{By: v1alpha1.ServiceClassJoinByJSONPath, JsonPath: ".spec.configRef.name"},
+						ResourceFields: []v1alpha1.ServiceClassResourceFieldMapping{
+							{Name: "database", JsonPath: ".data.database"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	f, err := Generate(sc)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(f.Related) != 1 {
+		t.Fatalf("Generate() related resources = %d, want 1", len(f.Related))
+	}
+	ref, _, _ := unstructured.NestedString(f.Resource.Object, "spec", "configRef", "name")
+	if ref != f.Related[0].GetName() {
+		t.Errorf("Generate() reference = %s, want %s", ref, f.Related[0].GetName())
+	}
+	if got := f.Values["database"]; got != "fixture-database" {
+		t.Errorf("Generate() database = %s, want fixture-database", got)
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		obj     map[string]interface{}
+		path    string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "nested field",
+			obj:  map[string]interface{}{},
+			path: ".spec.host",
+			want: map[string]interface{}{"spec": map[string]interface{}{"host": "v"}},
+		},
+		{
+			name: "array index",
+			obj:  map[string]interface{}{},
+			path: ".spec.hosts[1]",
+			want: map[string]interface{}{"spec": map[string]interface{}{"hosts": []interface{}{nil, "v"}}},
+		},
+		{
+			name: "escaped dots",
+			obj:  map[string]interface{}{},
+			path: `.metadata.annotations.example\.com/host`,
+			want: map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{"example.com/host": "v"}}},
+		},
+		{
+			name: "already set",
+			obj:  map[string]interface{}{"spec": map[string]interface{}{"host": "other"}},
+			path: ".spec.host",
+			want: map[string]interface{}{"spec": map[string]interface{}{"host": "other"}},
+		},
+		{
+			name:    "filter",
+			obj:     map[string]interface{}{},
+			path:    ".spec.hosts[?(@.primary==true)].name",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := setPath(tt.obj, tt.path, "v")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(tt.obj, tt.want) {
+				t.Errorf("setPath() = %v, want %v", tt.obj, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package healthprobe
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http2"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+// grpcServing is the SERVING status of the gRPC health checking protocol
+const grpcServing = 1
+
+// Run runs the probe of the health check against the given host and port.
+// It returns an error if the health check does not define a probe, or if the
+// probe fails.
+func Run(ctx context.Context, hc v1alpha1.HealthCheck, host, port string) error {
+	address := net.JoinHostPort(host, port)
+	switch {
+	case hc.HTTPGet != nil:
+		scheme := strings.ToLower(string(hc.HTTPGet.Scheme))
+		if scheme == "" {
+			scheme = strings.ToLower(string(corev1.URISchemeHTTP))
+		}
+		path := hc.HTTPGet.Path
+		if path == "" {
+			path = "/"
+		}
+		return HTTPGet(ctx, fmt.Sprintf("%s://%s%s", scheme, address, path))
+	case hc.TCPSocket != nil:
+		return TCPSocket(ctx, address)
+	case hc.GRPC != nil:
+		return GRPC(ctx, address, hc.GRPC.Service)
+	}
+	return fmt.Errorf("health check does not define a probe")
+}
+
+// HTTPGet checks that a GET request to the given URL returns a status code
+// between 200 and 399.  As for Kubernetes' probes, the certificate of HTTPS
+// servers is not verified.
+func HTTPGet(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec G402 -- probes check availability, not identity
+	transport.DisableKeepAlives = true
+	res, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("HTTP probe failed with status %s", res.Status)
+	}
+	return nil
+}
+
+// TCPSocket checks that a TCP connection can be opened to the given address
+func TCPSocket(ctx context.Context, address string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// GRPC checks that the given service of the server at the given address is
+// serving, with the gRPC health checking protocol over plaintext HTTP/2.  If
+// service is empty, the status of the whole server is checked.
+func GRPC(ctx context.Context, address, service string) error {
+	// HealthCheckRequest message, whose only field is the service name
+	var msg []byte
+	if service != "" {
+		msg = append([]byte{0x0a}, binary.AppendUvarint(nil, uint64(len(service)))...)
+		msg = append(msg, service...)
+	}
+	body := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(msg)))
+	body = append(body, msg...)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("http://%s/grpc.health.v1.Health/Check", address), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	transport := &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+	defer transport.CloseIdleConnections()
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// trailers are only available once the body is read
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("gRPC probe failed with HTTP status %s", res.Status)
+	}
+	if code, msg := grpcStatus(res); code != "0" {
+		return fmt.Errorf("gRPC probe failed with status %s: %s", code, msg)
+	}
+
+	if status := grpcHealthStatus(data); status != grpcServing {
+		return fmt.Errorf("gRPC probe failed: service is not serving (status %d)", status)
+	}
+	return nil
+}
+
+// grpcStatus returns the gRPC status code and message of the response, that
+// are sent as trailers, or as headers if the response has no body
+func grpcStatus(res *http.Response) (string, string) {
+	if code := res.Trailer.Get("Grpc-Status"); code != "" {
+		return code, res.Trailer.Get("Grpc-Message")
+	}
+	if code := res.Header.Get("Grpc-Status"); code != "" {
+		return code, res.Header.Get("Grpc-Message")
+	}
+	return "", "missing grpc-status"
+}
+
+// grpcHealthStatus decodes the status field of a length-prefixed
+// HealthCheckResponse message.  It returns 0 (UNKNOWN) if the message can not
+// be decoded.
+func grpcHealthStatus(data []byte) uint64 {
+	if len(data) < 5 {
+		return 0
+	}
+	msg := data[5:]
+	if l := binary.BigEndian.Uint32(data[1:5]); int(l) < len(msg) {
+		msg = msg[:l]
+	}
+
+	for len(msg) > 0 {
+		tag, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return 0
+		}
+		msg = msg[n:]
+		// only varint fields are expected
+		if tag&0x7 != 0 {
+			return 0
+		}
+		value, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return 0
+		}
+		msg = msg[n:]
+		if tag>>3 == 1 {
+			return value
+		}
+	}
+	return 0
+}
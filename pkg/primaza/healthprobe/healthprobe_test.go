@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package healthprobe
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/http2"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+func TestRun(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	httpServer := httptest.NewServer(mux)
+	defer httpServer.Close()
+	httpsServer := httptest.NewTLSServer(mux)
+	defer httpsServer.Close()
+	grpcAddress := serveGRPC(t, map[string]uint64{"": 1, "db": 2})
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddress := closed.Addr().String()
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		hc      v1alpha1.HealthCheck
+		address string
+		wantErr bool
+	}{
+		{
+			name:    "http get succeeds",
+			hc:      v1alpha1.HealthCheck{HTTPGet: &v1alpha1.HealthCheckHTTPGetAction{Path: "/healthz"}},
+			address: httpServer.Listener.Addr().String(),
+		},
+		{
+			name:    "https get succeeds with self-signed certificate",
+			hc:      v1alpha1.HealthCheck{HTTPGet: &v1alpha1.HealthCheckHTTPGetAction{Path: "/healthz", Scheme: corev1.URISchemeHTTPS}},
+			address: httpsServer.Listener.Addr().String(),
+		},
+		{
+			name:    "http get fails on error status",
+			hc:      v1alpha1.HealthCheck{HTTPGet: &v1alpha1.HealthCheckHTTPGetAction{Path: "/broken"}},
+			address: httpServer.Listener.Addr().String(),
+			wantErr: true,
+		},
+		{
+			name:    "tcp socket succeeds",
+			hc:      v1alpha1.HealthCheck{TCPSocket: &v1alpha1.HealthCheckTCPSocketAction{}},
+			address: httpServer.Listener.Addr().String(),
+		},
+		{
+			name:    "tcp socket fails on closed port",
+			hc:      v1alpha1.HealthCheck{TCPSocket: &v1alpha1.HealthCheckTCPSocketAction{}},
+			address: closedAddress,
+			wantErr: true,
+		},
+		{
+			name:    "grpc succeeds on serving server",
+			hc:      v1alpha1.HealthCheck{GRPC: &v1alpha1.HealthCheckGRPCAction{}},
+			address: grpcAddress,
+		},
+		{
+			name:    "grpc fails on not serving service",
+			hc:      v1alpha1.HealthCheck{GRPC: &v1alpha1.HealthCheckGRPCAction{Service: "db"}},
+			address: grpcAddress,
+			wantErr: true,
+		},
+		{
+			name:    "grpc fails on unknown service",
+			hc:      v1alpha1.HealthCheck{GRPC: &v1alpha1.HealthCheckGRPCAction{Service: "cache"}},
+			address: grpcAddress,
+			wantErr: true,
+		},
+		{
+			name:    "container is not a probe",
+			hc:      v1alpha1.HealthCheck{Container: &v1alpha1.HealthCheckContainer{Image: "postgres", Command: "pg_isready"}},
+			address: httpServer.Listener.Addr().String(),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port, err := net.SplitHostPort(tt.address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := Run(context.Background(), tt.hc, host, port); (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// serveGRPC serves the gRPC health checking protocol over plaintext HTTP/2,
+// reporting the given statuses
+func serveGRPC(t *testing.T, statuses map[string]uint64) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req [256]byte
+		n, _ := r.Body.Read(req[:])
+		service := ""
+		if n > 7 {
+			service = string(req[7:n])
+		}
+
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		status, ok := statuses[service]
+		if !ok {
+			// NOT_FOUND
+			w.Header().Set("Grpc-Status", "5")
+			return
+		}
+		msg := binary.AppendUvarint([]byte{0x08}, status)
+		body := make([]byte, 5, 5+len(msg))
+		binary.BigEndian.PutUint32(body[1:], uint32(len(msg)))
+		_, _ = w.Write(append(body, msg...))
+		w.Header().Set("Grpc-Status", "0")
+	})
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go (&http2.Server{}).ServeConn(conn, &http2.ServeConnOpts{Handler: handler})
+		}
+	}()
+	return l.Addr().String()
+}
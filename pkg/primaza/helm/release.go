@@ -0,0 +1,185 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const (
+	// OwnerLabel is the label Helm sets to `helm` on its release Secrets
+	OwnerLabel = "owner"
+	// StatusLabel is the label holding the status of the release revision
+	// stored in a release Secret
+	StatusLabel = "status"
+	// StatusDeployed is the status of the current revision of a release
+	StatusDeployed = "deployed"
+
+	// ReleaseAPIVersion and ReleaseKind identify the resources decoded from
+	// release Secrets
+	ReleaseAPIVersion = "helm.sh/v3"
+	ReleaseKind       = "Release"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// release is the part of a Helm release used to build its resource
+type release struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int64  `json:"version"`
+	Info      struct {
+		Status string `json:"status"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+		Values map[string]interface{} `json:"values"`
+	} `json:"chart"`
+	Config   map[string]interface{} `json:"config"`
+	Manifest string                 `json:"manifest"`
+}
+
+// Release returns the resource describing the Helm release stored in the
+// given release Secret.  Its name and namespace are the ones of the release,
+// and it holds:
+//   - `.chart`: the `name`, `version` and `appVersion` of the chart;
+//   - `.values`: the values of the release, the ones it has been installed
+//     with overriding the defaults of the chart;
+//   - `.resources`: the resources rendered by the chart;
+//   - `.status` and `.revision` of the release.
+func Release(secret *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	data, found, err := unstructured.NestedString(secret.Object, "data", "release")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("secret %s/%s is not a helm release", secret.GetNamespace(), secret.GetName())
+	}
+	r, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding helm release %s/%s: %w", secret.GetNamespace(), secret.GetName(), err)
+	}
+	resources, err := parseManifest(r.Manifest)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing manifest of helm release %s/%s: %w", r.Namespace, r.Name, err)
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"chart": map[string]interface{}{
+			"name":       r.Chart.Metadata.Name,
+			"version":    r.Chart.Metadata.Version,
+			"appVersion": r.Chart.Metadata.AppVersion,
+		},
+		"values":    coalesce(r.Config, r.Chart.Values),
+		"resources": resources,
+		"status":    r.Info.Status,
+		"revision":  r.Version,
+	}}
+	obj.SetAPIVersion(ReleaseAPIVersion)
+	obj.SetKind(ReleaseKind)
+	obj.SetName(r.Name)
+	obj.SetNamespace(r.Namespace)
+	obj.SetUID(secret.GetUID())
+	obj.SetLabels(secret.GetLabels())
+	obj.SetCreationTimestamp(secret.GetCreationTimestamp())
+	return obj, nil
+}
+
+// Chart returns the name of the chart of a release returned by Release
+func Chart(rel *unstructured.Unstructured) string {
+	name, _, _ := unstructured.NestedString(rel.Object, "chart", "name")
+	return name
+}
+
+// decode decodes a release as stored in a Secret: the base64 encoding of the
+// Secret's data wraps the base64 encoding of the gzipped JSON release
+func decode(data string) (*release, error) {
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	b, err = base64.StdEncoding.DecodeString(string(b))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(b, gzipMagic) {
+		gz, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		if b, err = io.ReadAll(gz); err != nil {
+			return nil, err
+		}
+	}
+
+	var r release
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// parseManifest returns the resources of a multi-document YAML manifest
+func parseManifest(manifest string) ([]interface{}, error) {
+	resources := []interface{}{}
+	d := utilyaml.NewYAMLOrJSONDecoder(bytes.NewBufferString(manifest), 4096)
+	for {
+		var obj map[string]interface{}
+		if err := d.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				return resources, nil
+			}
+			return nil, err
+		}
+		if obj != nil {
+			resources = append(resources, obj)
+		}
+	}
+}
+
+// coalesce returns the values overriding the defaults, recursively merging
+// the nested maps
+func coalesce(values, defaults map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(defaults)+len(values))
+	for k, v := range defaults {
+		out[k] = v
+	}
+	for k, v := range values {
+		vm, ok := v.(map[string]interface{})
+		dm, dok := out[k].(map[string]interface{})
+		if ok && dok {
+			out[k] = coalesce(vm, dm)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// releaseSecret returns the Secret Helm stores the given release in
+func releaseSecret(t *testing.T, release map[string]interface{}) *unstructured.Unstructured {
+	b, err := json.Marshal(release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data := base64.StdEncoding.EncodeToString([]byte(base64.StdEncoding.EncodeToString(buf.Bytes())))
+
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"data": map[string]interface{}{"release": data},
+	}}
+	secret.SetAPIVersion("v1")
+	secret.SetKind("Secret")
+	secret.SetName("sh.helm.release.v1.orders-db.v2")
+	secret.SetNamespace("services")
+	secret.SetLabels(map[string]string{"owner": "helm", "name": "orders-db", "status": "deployed"})
+	return secret
+}
+
+func TestRelease(t *testing.T) {
+	secret := releaseSecret(t, map[string]interface{}{
+		"name":      "orders-db",
+		"namespace": "services",
+		"version":   2,
+		"info":      map[string]interface{}{"status": "deployed"},
+		"chart": map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "postgresql", "version": "12.5.6", "appVersion": "15.3.0"},
+			"values": map[string]interface{}{
+				"auth":    map[string]interface{}{"username": "postgres", "database": "postgres"},
+				"service": map[string]interface{}{"port": 5432},
+			},
+		},
+		"config": map[string]interface{}{
+			"auth": map[string]interface{}{"database": "orders"},
+		},
+		"manifest": "---\n# Source: postgresql/templates/secrets.yaml\napiVersion: v1\nkind: Secret\nmetadata:\n  name: orders-db-postgresql\n" +
+			"---\napiVersion: v1\nkind: Service\nmetadata:\n  name: orders-db-postgresql\nspec:\n  ports:\n  - port: 5432\n",
+	})
+
+	rel, err := Release(secret)
+	if err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if rel.GetName() != "orders-db" || rel.GetNamespace() != "services" {
+		t.Errorf("Release() = %s/%s, want services/orders-db", rel.GetNamespace(), rel.GetName())
+	}
+	if got := Chart(rel); got != "postgresql" {
+		t.Errorf("Chart() = %s, want postgresql", got)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: ".values.auth.database", want: "orders"},
+		{path: ".values.auth.username", want: "postgres"},
+		{path: ".values.service.port", want: "5432"},
+		{path: ".chart.appVersion", want: "15.3.0"},
+		{path: `.resources[?(@.kind=="Secret")].metadata.name`, want: "orders-db-postgresql"},
+		{path: `.resources[?(@.kind=="Service")].spec.ports[0].port`, want: "5432"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			p := jsonpath.New("")
+			if err := p.Parse("{" + tt.path + "}"); err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := p.Execute(&buf, rel.Object); err != nil {
+				t.Fatalf("jsonpath error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("%s = %s, want %s", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseNotARelease(t *testing.T) {
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"data": map[string]interface{}{"password": "czNjcjN0"},
+	}}
+	if _, err := Release(secret); err == nil {
+		t.Error("Release() error = nil, want error")
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	got := coalesce(
+		map[string]interface{}{"auth": map[string]interface{}{"database": "orders"}, "replicas": 3},
+		map[string]interface{}{"auth": map[string]interface{}{"database": "postgres", "username": "postgres"}, "replicas": 1, "tls": false},
+	)
+	want := map[string]interface{}{
+		"auth":     map[string]interface{}{"database": "orders", "username": "postgres"},
+		"replicas": 3,
+		"tls":      false,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("coalesce() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package library
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+const (
+	// TemplateLabel labels the ServiceClasses instantiated from a template
+	// with the template's name
+	TemplateLabel = "primaza.io/library-template"
+
+	// VersionAnnotation holds the version of the template a ServiceClass
+	// has been instantiated from
+	VersionAnnotation = "primaza.io/library-version"
+
+	// All selects all the templates of the library
+	All = "all"
+)
+
+// ErrUnknownTemplate is returned when the library has no template with the
+// requested name
+var ErrUnknownTemplate = errors.New("unknown service class template")
+
+//go:embed templates/*.yaml
+var templates embed.FS
+
+// Names returns the sorted names of the templates of the library
+func Names() []string {
+	entries, err := templates.ReadDir("templates")
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the ServiceClass defined by the template with the given name.
+// The ServiceClass is named after the template and carries the template's
+// label and version annotation.
+func Get(name string) (*primazaiov1alpha1.ServiceClass, error) {
+	b, err := templates.ReadFile(path.Join("templates", name+".yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+
+	sc := &primazaiov1alpha1.ServiceClass{}
+	if err := yaml.UnmarshalStrict(b, sc); err != nil {
+		return nil, fmt.Errorf("invalid service class template %s: %w", name, err)
+	}
+	return sc, nil
+}
+
+// Select returns the ServiceClasses defined by the templates with the given
+// names, or by all the templates if one of the names is All
+func Select(names []string) ([]primazaiov1alpha1.ServiceClass, error) {
+	for _, n := range names {
+		if n == All {
+			names = Names()
+			break
+		}
+	}
+
+	scs := make([]primazaiov1alpha1.ServiceClass, 0, len(names))
+	for _, n := range names {
+		sc, err := Get(n)
+		if err != nil {
+			return nil, err
+		}
+		scs = append(scs, *sc)
+	}
+	return scs, nil
+}
+
+// Version returns the version of the template the ServiceClass has been
+// instantiated from, or 0 if the ServiceClass does not come from the library
+func Version(sc *primazaiov1alpha1.ServiceClass) int {
+	if _, ok := sc.Labels[TemplateLabel]; !ok {
+		return 0
+	}
+	v, err := strconv.Atoi(sc.Annotations[VersionAnnotation])
+	if err != nil {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package library
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTemplates(t *testing.T) {
+	names := Names()
+	if len(names) == 0 {
+		t.Fatal("Names() returned no template")
+	}
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			sc, err := Get(name)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if sc.Name != name {
+				t.Errorf("Get() name = %s, want %s", sc.Name, name)
+			}
+			if got := sc.Labels[TemplateLabel]; got != name {
+				t.Errorf("Get() label %s = %s, want %s", TemplateLabel, got, name)
+			}
+			if Version(sc) < 1 {
+				t.Errorf("Get() version = %q, want a positive integer", sc.Annotations[VersionAnnotation])
+			}
+			if len(sc.Spec.ServiceClassIdentity) == 0 {
+				t.Error("Get() service class identity is empty")
+			}
+
+			r := &sc.Spec.Resource
+			errs := append(r.ValidateMapping(), r.ValidateSecondary()...)
+			errs = append(errs, r.ValidateOwnedBy()...)
+			errs = append(errs, r.ValidateHelm()...)
+			if len(errs) != 0 {
+				t.Errorf("template is invalid: %v", errs.ToAggregate())
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	scs, err := Select([]string{All})
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if len(scs) != len(Names()) {
+		t.Errorf("Select() = %d service classes, want %d", len(scs), len(Names()))
+	}
+
+	scs, err = Select([]string{"rabbitmq"})
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if len(scs) != 1 || scs[0].Name != "rabbitmq" {
+		t.Errorf("Select() = %v, want rabbitmq", scs)
+	}
+
+	if _, err := Select([]string{"rabbitmq", "unknown"}); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("Select() error = %v, want %v", err, ErrUnknownTemplate)
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package matching
+
+import (
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+// SCISubset checks if the ServiceClassIdentity of a claim is a subset of
+// the ServiceClassIdentity of a registered service.
+// Ref. https://stackoverflow.com/a/18879994/547840
+func SCISubset(serviceClaim, registeredService []v1alpha1.ServiceClassIdentityItem) bool {
+	set := make(map[v1alpha1.ServiceClassIdentityItem]int)
+	for _, value := range registeredService {
+		set[value] += 1
+	}
+
+	for _, value := range serviceClaim {
+		if count, found := set[value]; !found {
+			return false
+		} else if count < 1 {
+			return false
+		} else {
+			set[value] = count - 1
+		}
+	}
+
+	return true
+}
+
+// Matches checks if a registered service satisfies the given ServiceClassIdentity
+// and can be used in the given environment, whose cluster environments are
+// looked up in the given ones
+func Matches(sci []v1alpha1.ServiceClassIdentityItem, environment string, clusterEnvironments []v1alpha1.ClusterEnvironment, rs v1alpha1.RegisteredService) bool {
+	return SCISubset(sci, rs.Spec.ServiceClassIdentity) && rs.Spec.Constraints.Allows(environment, clusterEnvironments)
+}
+
+// FindService returns the registered service matching the given
+// ServiceClassIdentity in the given environment.  When several services
+// match, the one whose name comes first is returned, so that the outcome
+// does not depend on the order of services.
+func FindService(sci []v1alpha1.ServiceClassIdentityItem, environment string, clusterEnvironments []v1alpha1.ClusterEnvironment, services []v1alpha1.RegisteredService) (*v1alpha1.RegisteredService, bool) {
+	var found *v1alpha1.RegisteredService
+	for i := range services {
+		if Matches(sci, environment, clusterEnvironments, services[i]) && (found == nil || services[i].Name < found.Name) {
+			found = &services[i]
+		}
+	}
+	return found, found != nil
+}
+
+// SelectServices returns the registered services whose labels match the
+// given selector, or all of them if the selector is nil
+func SelectServices(selector *metav1.LabelSelector, services []v1alpha1.RegisteredService) ([]v1alpha1.RegisteredService, error) {
+	if selector == nil {
+		return services, nil
+	}
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := []v1alpha1.RegisteredService{}
+	for _, rs := range services {
+		if sel.Matches(labels.Set(rs.Labels)) {
+			selected = append(selected, rs)
+		}
+	}
+	return selected, nil
+}
+
+// ClaimableServices returns the registered services the named claim can
+// claim, according to their state, maintenance and sharing policy, see
+// RegisteredService.AcceptsClaim
+func ClaimableServices(services []v1alpha1.RegisteredService, claim string) []v1alpha1.RegisteredService {
+	claimable := make([]v1alpha1.RegisteredService, 0, len(services))
+	for i := range services {
+		if services[i].AcceptsClaim(claim) {
+			claimable = append(claimable, services[i])
+		}
+	}
+	return claimable
+}
+
+// FilterCatalog returns the services of a catalog whose ServiceClassIdentity
+// matches at least one of the given filters, or all of them if there is no
+// filter
+func FilterCatalog(services []v1alpha1.ServiceCatalogService, filters []v1alpha1.ServiceCatalogFilter) []v1alpha1.ServiceCatalogService {
+	if len(filters) == 0 {
+		return services
+	}
+
+	var ss []v1alpha1.ServiceCatalogService
+	for _, s := range services {
+		for _, f := range filters {
+			if SCISubset(f.ServiceClassIdentity, s.ServiceClassIdentity) {
+				ss = append(ss, s)
+				break
+			}
+		}
+	}
+	return ss
+}
+
+// Score returns the sum of the weights of the preferences satisfied by a
+// registered service discovered in the given cluster environment.  No
+// preference is satisfied when the cluster environment is unknown.
+func Score(preferences []v1alpha1.MatchingPreference, ce *v1alpha1.ClusterEnvironment) int32 {
+	if ce == nil {
+		return 0
+	}
+
+	var score int32
+	for _, p := range preferences {
+		if satisfies(p, *ce) {
+			score += p.Weight
+		}
+	}
+	return score
+}
+
+func satisfies(preference v1alpha1.MatchingPreference, ce v1alpha1.ClusterEnvironment) bool {
+	if preference.AvoidEnvironment != "" && preference.AvoidEnvironment == ce.Spec.EnvironmentName {
+		return false
+	}
+	for k, v := range preference.Topology {
+		if cv, ok := ce.Spec.Topology[k]; !ok || cv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// FindPreferredService returns the best ranked matching service, see Rank
+func FindPreferredService(
+	sci []v1alpha1.ServiceClassIdentityItem,
+	environment string,
+	services []v1alpha1.RegisteredService,
+	preferences []v1alpha1.MatchingPreference,
+	clusterEnvironments []v1alpha1.ClusterEnvironment,
+	policy v1alpha1.ServiceClaimRankingPolicy,
+) (*v1alpha1.RegisteredService, bool) {
+	ranked := Rank(sci, environment, services, preferences, clusterEnvironments, policy)
+	if len(ranked) == 0 {
+		return nil, false
+	}
+	return &ranked[0], true
+}
+
+// Rank returns the services matching the given ServiceClassIdentity in the
+// given environment, from the most to the least preferred.  Services are
+// ranked by their score against the given preferences first, looking them up
+// in the given cluster environments through the cluster environment label set
+// by service agents.  Ties are broken by the given ranking policy, and
+// finally by the name of services.
+func Rank(
+	sci []v1alpha1.ServiceClassIdentityItem,
+	environment string,
+	services []v1alpha1.RegisteredService,
+	preferences []v1alpha1.MatchingPreference,
+	clusterEnvironments []v1alpha1.ClusterEnvironment,
+	policy v1alpha1.ServiceClaimRankingPolicy,
+) []v1alpha1.RegisteredService {
+	ces := make(map[string]*v1alpha1.ClusterEnvironment, len(clusterEnvironments))
+	for i := range clusterEnvironments {
+		ces[clusterEnvironments[i].Name] = &clusterEnvironments[i]
+	}
+
+	ranked := []v1alpha1.RegisteredService{}
+	scores := map[string]int32{}
+	for _, rs := range services {
+		if !Matches(sci, environment, clusterEnvironments, rs) {
+			continue
+		}
+		ranked = append(ranked, rs)
+		scores[rs.Name] = Score(preferences, ces[rs.Labels[constants.PrimazaClusterEnvironmentLabel]])
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := &ranked[i], &ranked[j]
+		if scores[a.Name] != scores[b.Name] {
+			return scores[a.Name] > scores[b.Name]
+		}
+		if c := compare(policy, a, b); c != 0 {
+			return c < 0
+		}
+		return a.Name < b.Name
+	})
+	return ranked
+}
+
+// compare returns a negative number when the service a is preferred over b
+// according to the given ranking policy, a positive number when b is
+// preferred over a, and zero when the policy does not tell them apart
+func compare(policy v1alpha1.ServiceClaimRankingPolicy, a, b *v1alpha1.RegisteredService) int {
+	switch policy {
+	case v1alpha1.ServiceClaimRankingPolicyMostSpecific:
+		// matching services share the claim's attributes, so the one with
+		// fewer attributes has fewer attributes the claim did not ask for
+		return len(a.Spec.ServiceClassIdentity) - len(b.Spec.ServiceClassIdentity)
+	case v1alpha1.ServiceClaimRankingPolicyNewest:
+		switch {
+		case b.CreationTimestamp.Before(&a.CreationTimestamp):
+			return -1
+		case a.CreationTimestamp.Before(&b.CreationTimestamp):
+			return 1
+		}
+		return 0
+	default:
+		return int(b.Spec.Priority) - int(a.Spec.Priority)
+	}
+}
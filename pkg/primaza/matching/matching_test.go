@@ -0,0 +1,300 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package matching
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+func TestFilterCatalog(t *testing.T) {
+	postgres := v1alpha1.ServiceCatalogService{
+		Name: "postgres",
+		ServiceClassIdentity: []v1alpha1.ServiceClassIdentityItem{
+			{Name: "type", Value: "postgresql"},
+			{Name: "provider", Value: "aws"},
+		},
+	}
+	mysql := v1alpha1.ServiceCatalogService{
+		Name: "mysql",
+		ServiceClassIdentity: []v1alpha1.ServiceClassIdentityItem{
+			{Name: "type", Value: "mysql"},
+			{Name: "provider", Value: "aws"},
+		},
+	}
+	redis := v1alpha1.ServiceCatalogService{
+		Name: "redis",
+		ServiceClassIdentity: []v1alpha1.ServiceClassIdentityItem{
+			{Name: "type", Value: "redis"},
+		},
+	}
+	services := []v1alpha1.ServiceCatalogService{postgres, mysql, redis}
+	filter := func(items ...v1alpha1.ServiceClassIdentityItem) v1alpha1.ServiceCatalogFilter {
+		return v1alpha1.ServiceCatalogFilter{ServiceClassIdentity: items}
+	}
+
+	tests := []struct {
+		name    string
+		filters []v1alpha1.ServiceCatalogFilter
+		want    []v1alpha1.ServiceCatalogService
+	}{
+		{
+			name: "no filter",
+			want: services,
+		},
+		{
+			name:    "single attribute",
+			filters: []v1alpha1.ServiceCatalogFilter{filter(v1alpha1.ServiceClassIdentityItem{Name: "type", Value: "postgresql"})},
+			want:    []v1alpha1.ServiceCatalogService{postgres},
+		},
+		{
+			name: "all attributes of a filter",
+			filters: []v1alpha1.ServiceCatalogFilter{filter(
+				v1alpha1.ServiceClassIdentityItem{Name: "type", Value: "redis"},
+				v1alpha1.ServiceClassIdentityItem{Name: "provider", Value: "aws"},
+			)},
+		},
+		{
+			name: "any filter",
+			filters: []v1alpha1.ServiceCatalogFilter{
+				filter(v1alpha1.ServiceClassIdentityItem{Name: "provider", Value: "aws"}),
+				filter(v1alpha1.ServiceClassIdentityItem{Name: "type", Value: "redis"}),
+			},
+			want: services,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FilterCatalog(services, tt.filters); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterCatalog() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newRegisteredService(name string, labels map[string]string, sci ...v1alpha1.ServiceClassIdentityItem) v1alpha1.RegisteredService {
+	return v1alpha1.RegisteredService{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       v1alpha1.RegisteredServiceSpec{ServiceClassIdentity: sci},
+	}
+}
+
+func TestSelectServices(t *testing.T) {
+	payments := newRegisteredService("payments-db", map[string]string{"team": "payments"})
+	billing := newRegisteredService("billing-db", map[string]string{"team": "billing"})
+	unlabeled := newRegisteredService("db", nil)
+	services := []v1alpha1.RegisteredService{payments, billing, unlabeled}
+
+	tests := []struct {
+		name     string
+		selector *metav1.LabelSelector
+		want     []v1alpha1.RegisteredService
+	}{
+		{
+			name: "no selector",
+			want: services,
+		},
+		{
+			name:     "match labels",
+			selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+			want:     []v1alpha1.RegisteredService{payments},
+		},
+		{
+			name: "match expressions",
+			selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "team", Operator: metav1.LabelSelectorOpExists},
+			}},
+			want: []v1alpha1.RegisteredService{payments, billing},
+		},
+		{
+			name:     "no match",
+			selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "search"}},
+			want:     []v1alpha1.RegisteredService{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectServices(tt.selector, services)
+			if err != nil {
+				t.Fatalf("SelectServices() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SelectServices() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid selector", func(t *testing.T) {
+		selector := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "team", Operator: "Is", Values: []string{"payments"}},
+		}}
+		if _, err := SelectServices(selector, services); err == nil {
+			t.Errorf("SelectServices() expected an error")
+		}
+	})
+}
+
+func TestClaimableServices(t *testing.T) {
+	exclusive := newRegisteredService("exclusive", nil)
+	claimed := newRegisteredService("claimed", nil)
+	claimed.Status.Claims = []string{"orders"}
+	maintained := newRegisteredService("maintained", nil)
+	maintained.Spec.Maintenance = &v1alpha1.Maintenance{Message: "upgrading"}
+	unreachable := newRegisteredService("unreachable", nil)
+	unreachable.Status.State = v1alpha1.RegisteredServiceStateUnreachable
+	shared := newRegisteredService("shared", nil)
+	shared.Spec.SharingPolicy = v1alpha1.RegisteredServiceSharingPolicyShared
+	shared.Spec.MaxClaims = 2
+	shared.Status.Claims = []string{"orders", "payments"}
+	services := []v1alpha1.RegisteredService{exclusive, claimed, maintained, unreachable, shared}
+
+	tests := []struct {
+		name  string
+		claim string
+		want  []v1alpha1.RegisteredService
+	}{
+		{
+			name:  "new claim",
+			claim: "billing",
+			want:  []v1alpha1.RegisteredService{exclusive},
+		},
+		{
+			name:  "claim already claiming services",
+			claim: "orders",
+			want:  []v1alpha1.RegisteredService{exclusive, claimed, shared},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClaimableServices(services, tt.claim); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClaimableServices() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesEnvironmentConstraints(t *testing.T) {
+	psql := v1alpha1.ServiceClassIdentityItem{Name: "type", Value: "psql"}
+	ces := []v1alpha1.ClusterEnvironment{
+		{Spec: v1alpha1.ClusterEnvironmentSpec{EnvironmentName: "prod", Labels: []string{"tier=gold"},
+			Topology: map[string]string{"topology.kubernetes.io/region": "eu-west-1"}}},
+		{Spec: v1alpha1.ClusterEnvironmentSpec{EnvironmentName: "dev", Labels: []string{"deprecated"},
+			Topology: map[string]string{"topology.kubernetes.io/region": "us-east-1"}}},
+	}
+
+	tests := []struct {
+		name        string
+		environment string
+		constraints *v1alpha1.RegisteredServiceConstraints
+		want        bool
+	}{
+		{name: "no constraints", environment: "dev", want: true},
+		{name: "excluded environment", environment: "prod", constraints: &v1alpha1.RegisteredServiceConstraints{Environments: []string{"!prod"}}},
+		{name: "region", environment: "prod", want: true, constraints: &v1alpha1.RegisteredServiceConstraints{EnvironmentSelector: "topology.kubernetes.io/region in (eu-west-1,eu-central-1)"}},
+		{name: "other region", environment: "dev", constraints: &v1alpha1.RegisteredServiceConstraints{EnvironmentSelector: "topology.kubernetes.io/region in (eu-west-1,eu-central-1)"}},
+		{name: "tags", environment: "dev", constraints: &v1alpha1.RegisteredServiceConstraints{EnvironmentSelector: "!deprecated"}},
+		{name: "tag values", environment: "prod", want: true, constraints: &v1alpha1.RegisteredServiceConstraints{EnvironmentSelector: "tier=gold,!deprecated"}},
+		{name: "unknown environment", environment: "stage", constraints: &v1alpha1.RegisteredServiceConstraints{EnvironmentSelector: "!deprecated"}},
+		{name: "both", environment: "prod", constraints: &v1alpha1.RegisteredServiceConstraints{Environments: []string{"dev"}, EnvironmentSelector: "tier=gold"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := newRegisteredService("psql", nil, psql)
+			rs.Spec.Constraints = tt.constraints
+			if got := Matches([]v1alpha1.ServiceClassIdentityItem{psql}, tt.environment, ces, rs); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindServiceTieBreaking(t *testing.T) {
+	psql := v1alpha1.ServiceClassIdentityItem{Name: "type", Value: "psql"}
+	services := []v1alpha1.RegisteredService{
+		newRegisteredService("psql-b", nil, psql),
+		newRegisteredService("psql-a", nil, psql),
+		newRegisteredService("mysql", nil, v1alpha1.ServiceClassIdentityItem{Name: "type", Value: "mysql"}),
+	}
+
+	rs, found := FindService([]v1alpha1.ServiceClassIdentityItem{psql}, "prod", nil, services)
+	if !found || rs.Name != "psql-a" {
+		t.Errorf("FindService() = %v, want psql-a", rs)
+	}
+
+	rs, found = FindPreferredService([]v1alpha1.ServiceClassIdentityItem{psql}, "prod", services,
+		[]v1alpha1.MatchingPreference{{Weight: 10, AvoidEnvironment: "dev"}}, nil, v1alpha1.ServiceClaimRankingPolicyPriority)
+	if !found || rs.Name != "psql-a" {
+		t.Errorf("FindPreferredService() = %v, want psql-a", rs)
+	}
+}
+
+func TestRank(t *testing.T) {
+	psql := v1alpha1.ServiceClassIdentityItem{Name: "type", Value: "psql"}
+	aws := v1alpha1.ServiceClassIdentityItem{Name: "provider", Value: "aws"}
+	now := time.Now()
+
+	older := newRegisteredService("older", nil, psql, aws)
+	older.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+	priority := newRegisteredService("priority", nil, psql, aws)
+	priority.CreationTimestamp = metav1.NewTime(now.Add(-2 * time.Hour))
+	priority.Spec.Priority = 10
+	specific := newRegisteredService("specific", nil, psql)
+	specific.CreationTimestamp = metav1.NewTime(now.Add(-3 * time.Hour))
+	newer := newRegisteredService("newer", nil, psql, aws)
+	newer.CreationTimestamp = metav1.NewTime(now)
+	mysql := newRegisteredService("mysql", nil, v1alpha1.ServiceClassIdentityItem{Name: "type", Value: "mysql"})
+	services := []v1alpha1.RegisteredService{older, priority, specific, newer, mysql}
+
+	names := func(services []v1alpha1.RegisteredService) []string {
+		ns := []string{}
+		for _, s := range services {
+			ns = append(ns, s.Name)
+		}
+		return ns
+	}
+
+	tests := []struct {
+		policy v1alpha1.ServiceClaimRankingPolicy
+		want   []string
+	}{
+		{
+			policy: v1alpha1.ServiceClaimRankingPolicyPriority,
+			want:   []string{"priority", "newer", "older", "specific"},
+		},
+		{
+			policy: v1alpha1.ServiceClaimRankingPolicyMostSpecific,
+			want:   []string{"specific", "newer", "older", "priority"},
+		},
+		{
+			policy: v1alpha1.ServiceClaimRankingPolicyNewest,
+			want:   []string{"newer", "older", "priority", "specific"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			got := Rank([]v1alpha1.ServiceClassIdentityItem{psql}, "prod", services, nil, nil, tt.policy)
+			if !reflect.DeepEqual(names(got), tt.want) {
+				t.Errorf("Rank() = %v, want %v", names(got), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulation
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/primaza/primaza/pkg/primaza/matching"
+)
+
+// Result is the outcome of matching a scenario's claim
+type Result struct {
+	Claim    string
+	Expected string
+	Got      string
+	// Err is set when the claim could not be matched
+	Err error
+}
+
+// Passed reports whether the claim matched the expected service
+func (r Result) Passed() bool {
+	return r.Err == nil && r.Expected == r.Got
+}
+
+// Run matches every claim of the scenario against its services
+func Run(s Scenario) []Result {
+	rss := s.RegisteredServices()
+	ces := s.ClusterEnvironments()
+	results := make([]Result, 0, len(s.Claims))
+	for _, c := range s.Claims {
+		r := Result{Claim: c.Name, Expected: c.Expect}
+		selected, err := matching.SelectServices(c.ServiceSelector, rss)
+		if err != nil {
+			r.Err = err
+		} else if rs, ok := matching.FindPreferredService(c.ServiceClassIdentity, c.Environment, matching.ClaimableServices(selected, c.Name), c.Preferences, ces, c.RankingPolicy); ok {
+			r.Got = rs.Name
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// RunFile loads the scenario in the given file, runs it and reports any
+// unexpected outcome as a test error
+func RunFile(t testing.TB, path string) {
+	t.Helper()
+
+	s, err := LoadScenario(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range Run(*s) {
+		if r.Err != nil {
+			t.Errorf("scenario %q, claim %q: %v", s.Name, r.Claim, r.Err)
+		} else if !r.Passed() {
+			t.Errorf("scenario %q, claim %q: expected service %q, got %q", s.Name, r.Claim, r.Expected, r.Got)
+		}
+	}
+}
+
+// RunDir runs every scenario file (*.yaml) in the given directory
+func RunDir(t *testing.T, dir string) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range files {
+		f := f
+		t.Run(filepath.Base(f), func(t *testing.T) {
+			RunFile(t, f)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulation
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Scenario describes a set of registered services and the outcome expected
+// when claiming them
+type Scenario struct {
+	// Name of the scenario
+	Name string `json:"name"`
+
+	// Clusters services are discovered in
+	Clusters []Cluster `json:"clusters,omitempty"`
+	// Services available for claiming
+	Services []Service `json:"services"`
+
+	// Claims to be matched against Services
+	Claims []Claim `json:"claims"`
+}
+
+// Cluster describes a cluster environment
+type Cluster struct {
+	Name        string            `json:"name"`
+	Environment string            `json:"environment"`
+	Topology    map[string]string `json:"topology,omitempty"`
+	Labels      []string          `json:"labels,omitempty"`
+}
+
+// Service describes a registered service
+type Service struct {
+	Name                 string                                 `json:"name"`
+	ServiceClassIdentity []v1alpha1.ServiceClassIdentityItem    `json:"serviceClassIdentity"`
+	Constraints          *v1alpha1.RegisteredServiceConstraints `json:"constraints,omitempty"`
+	// Labels of the registered service
+	Labels map[string]string `json:"labels,omitempty"`
+	// Priority of the registered service
+	Priority int32 `json:"priority,omitempty"`
+	// Cluster the service is discovered in
+	Cluster string `json:"cluster,omitempty"`
+	// SharingPolicy and MaxClaims of the registered service
+	SharingPolicy v1alpha1.RegisteredServiceSharingPolicy `json:"sharingPolicy,omitempty"`
+	MaxClaims     int32                                   `json:"maxClaims,omitempty"`
+	// Maintenance puts the registered service in maintenance
+	Maintenance *v1alpha1.Maintenance `json:"maintenance,omitempty"`
+	// Claims already claiming the registered service
+	Claims []string `json:"claims,omitempty"`
+}
+
+// Claim describes a service claim and its expected outcome
+type Claim struct {
+	Name                 string                              `json:"name"`
+	ServiceClassIdentity []v1alpha1.ServiceClassIdentityItem `json:"serviceClassIdentity,omitempty"`
+	// ServiceSelector restricts the services the claim can match by label
+	ServiceSelector *metav1.LabelSelector `json:"serviceSelector,omitempty"`
+
+	// Environment in which the claim is made
+	Environment string `json:"environment,omitempty"`
+	// Preferences on the cluster environment of the claimed service
+	Preferences []v1alpha1.MatchingPreference `json:"preferences,omitempty"`
+	// RankingPolicy breaking the ties between matching services
+	RankingPolicy v1alpha1.ServiceClaimRankingPolicy `json:"rankingPolicy,omitempty"`
+
+	// Expect is the name of the service the claim is expected to match.
+	// If empty, the claim is expected not to match any service.
+	Expect string `json:"expect,omitempty"`
+}
+
+// LoadScenario reads a scenario from a YAML file
+func LoadScenario(path string) (*Scenario, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Scenario{}
+	if err := yaml.UnmarshalStrict(b, s); err != nil {
+		return nil, fmt.Errorf("error parsing scenario %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// RegisteredServices returns the registered services described by the scenario
+func (s *Scenario) RegisteredServices() []v1alpha1.RegisteredService {
+	rss := make([]v1alpha1.RegisteredService, 0, len(s.Services))
+	for _, svc := range s.Services {
+		labels := map[string]string{}
+		for k, v := range svc.Labels {
+			labels[k] = v
+		}
+		if svc.Cluster != "" {
+			labels[constants.PrimazaClusterEnvironmentLabel] = svc.Cluster
+		}
+		rss = append(rss, v1alpha1.RegisteredService{
+			ObjectMeta: metav1.ObjectMeta{Name: svc.Name, Labels: labels},
+			Spec: v1alpha1.RegisteredServiceSpec{
+				ServiceClassIdentity: svc.ServiceClassIdentity,
+				Constraints:          svc.Constraints,
+				Priority:             svc.Priority,
+				SharingPolicy:        svc.SharingPolicy,
+				MaxClaims:            svc.MaxClaims,
+				Maintenance:          svc.Maintenance,
+			},
+			Status: v1alpha1.RegisteredServiceStatus{Claims: svc.Claims},
+		})
+	}
+	return rss
+}
+
+// ClusterEnvironments returns the cluster environments described by the
+// scenario's clusters
+func (s *Scenario) ClusterEnvironments() []v1alpha1.ClusterEnvironment {
+	ces := make([]v1alpha1.ClusterEnvironment, 0, len(s.Clusters))
+	for _, ce := range s.Clusters {
+		ces = append(ces, v1alpha1.ClusterEnvironment{
+			ObjectMeta: metav1.ObjectMeta{Name: ce.Name},
+			Spec: v1alpha1.ClusterEnvironmentSpec{
+				EnvironmentName: ce.Environment,
+				Topology:        ce.Topology,
+				Labels:          ce.Labels,
+			},
+		})
+	}
+	return ces
+}
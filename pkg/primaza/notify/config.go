@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SinkTypeSlack posts notifications to a Slack incoming webhook
+	SinkTypeSlack = "slack"
+	// SinkTypeHTTP posts notifications as JSON documents to an HTTP endpoint
+	SinkTypeHTTP = "http"
+
+	sinkTimeout = 10 * time.Second
+)
+
+// Config lists the sinks notifications are sent to
+type Config struct {
+	Sinks []SinkConfig `json:"sinks"`
+}
+
+// SinkConfig defines a sink and the types of the events sent to it, all of
+// them if empty
+type SinkConfig struct {
+	Type   string      `json:"type"`
+	URL    string      `json:"url"`
+	Events []EventType `json:"events,omitempty"`
+}
+
+// LoadConfig reads the sinks configuration from the given YAML file and
+// returns the router sending notifications to them
+func LoadConfig(path string) (Router, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.UnmarshalStrict(b, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing notification configuration %s: %w", path, err)
+	}
+	return cfg.Router()
+}
+
+// Router returns the router sending notifications to the configured sinks
+func (c Config) Router() (Router, error) {
+	r := Router{}
+	cli := &http.Client{Timeout: sinkTimeout}
+	for i, s := range c.Sinks {
+		if s.URL == "" {
+			return nil, fmt.Errorf("sink %d: url is required", i)
+		}
+		for _, e := range s.Events {
+			if !isEventType(e) {
+				return nil, fmt.Errorf("sink %d: unsupported event type %q", i, e)
+			}
+		}
+
+		switch s.Type {
+		case SinkTypeSlack:
+			r.Add(&SlackNotifier{URL: s.URL, Client: cli}, s.Events...)
+		case SinkTypeHTTP:
+			r.Add(&HTTPNotifier{URL: s.URL, Client: cli}, s.Events...)
+		default:
+			return nil, fmt.Errorf("sink %d: unsupported sink type %q", i, s.Type)
+		}
+	}
+	return r, nil
+}
+
+func isEventType(t EventType) bool {
+	for _, e := range EventTypes {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EventType identifies the kind of operational event a notification is about
+type EventType string
+
+const (
+	// EventConnectionLost is sent when a Cluster Environment goes offline
+	EventConnectionLost EventType = "ConnectionLost"
+	// EventClaimFailed is sent when a Service Claim becomes invalid or its
+	// binding secret can not be pushed to an application namespace
+	EventClaimFailed EventType = "ClaimFailed"
+	// EventHealthCheckFailed is sent when a Registered Service becomes
+	// unreachable
+	EventHealthCheckFailed EventType = "HealthCheckFailed"
+)
+
+// EventTypes lists all the supported event types
+var EventTypes = []EventType{EventConnectionLost, EventClaimFailed, EventHealthCheckFailed}
+
+// Notification describes an operational event
+type Notification struct {
+	Type      EventType `json:"type"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Message   string    `json:"message,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// String returns a human readable description of the notification
+func (n Notification) String() string {
+	s := fmt.Sprintf("%s: %s %s/%s", n.Type, n.Kind, n.Namespace, n.Name)
+	if n.Message != "" {
+		s += ": " + n.Message
+	}
+	return s
+}
+
+// Notifier sends notifications to a sink
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Router sends each notification to the notifiers configured for its type
+type Router map[EventType][]Notifier
+
+// Add sends notifications of the given types to the notifier, or of all
+// types if none is given
+func (r Router) Add(notifier Notifier, types ...EventType) {
+	if len(types) == 0 {
+		types = EventTypes
+	}
+	for _, t := range types {
+		r[t] = append(r[t], notifier)
+	}
+}
+
+// Notify sends the notification to all the notifiers configured for its type,
+// and returns the errors of the ones that failed
+func (r Router) Notify(ctx context.Context, n Notification) error {
+	var errs []error
+	for _, notifier := range r[n.Type] {
+		if err := notifier.Notify(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRouter(t *testing.T) {
+	var got []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		got = append(got, body)
+	}))
+	defer srv.Close()
+
+	r, err := Config{Sinks: []SinkConfig{
+		{Type: SinkTypeSlack, URL: srv.URL, Events: []EventType{EventConnectionLost}},
+		{Type: SinkTypeHTTP, URL: srv.URL},
+	}}.Router()
+	if err != nil {
+		t.Fatalf("Router() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		n    Notification
+		want []map[string]interface{}
+	}{
+		{
+			name: "all sinks",
+			n: Notification{Type: EventConnectionLost, Kind: "ClusterEnvironment", Namespace: "primaza-system", Name: "prod",
+				Message: "connection refused", Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+			want: []map[string]interface{}{
+				{"text": "ConnectionLost: ClusterEnvironment primaza-system/prod: connection refused"},
+				{"type": "ConnectionLost", "kind": "ClusterEnvironment", "namespace": "primaza-system", "name": "prod",
+					"message": "connection refused", "time": "2023-01-01T00:00:00Z"},
+			},
+		},
+		{
+			name: "http sink only",
+			n: Notification{Type: EventHealthCheckFailed, Kind: "RegisteredService", Namespace: "primaza-system", Name: "db",
+				Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+			want: []map[string]interface{}{
+				{"type": "HealthCheckFailed", "kind": "RegisteredService", "namespace": "primaza-system", "name": "db",
+					"time": "2023-01-01T00:00:00Z"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			if err := r.Notify(context.Background(), tt.n); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("Notify() sent %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestNotifyError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	n := &HTTPNotifier{URL: srv.URL}
+	if err := n.Notify(context.Background(), Notification{Type: EventClaimFailed}); err == nil {
+		t.Errorf("Notify() expected an error")
+	}
+}
+
+func TestConfigRouter(t *testing.T) {
+	tests := []struct {
+		name    string
+		sink    SinkConfig
+		wantErr bool
+	}{
+		{name: "slack", sink: SinkConfig{Type: SinkTypeSlack, URL: "https://hooks.slack.com/services/x"}},
+		{name: "missing url", sink: SinkConfig{Type: SinkTypeHTTP}, wantErr: true},
+		{name: "unsupported type", sink: SinkConfig{Type: "email", URL: "mailto:ops@example.com"}, wantErr: true},
+		{name: "unsupported event", sink: SinkConfig{Type: SinkTypeHTTP, URL: "https://example.com", Events: []EventType{"Deleted"}},
+			wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Config{Sinks: []SinkConfig{tt.sink}}.Router()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Router() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
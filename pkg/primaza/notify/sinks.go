@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// HTTPNotifier posts notifications as JSON documents to an HTTP endpoint
+type HTTPNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// Notify posts the notification to the endpoint
+func (h *HTTPNotifier) Notify(ctx context.Context, n Notification) error {
+	return post(ctx, h.Client, h.URL, n)
+}
+
+// SlackNotifier posts notifications as messages to a Slack incoming webhook
+type SlackNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// Notify posts the notification to the webhook
+func (s *SlackNotifier) Notify(ctx context.Context, n Notification) error {
+	return post(ctx, s.Client, s.URL, map[string]string{"text": n.String()})
+}
+
+func post(ctx context.Context, cli *http.Client, url string, body interface{}) error {
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("notification sink %s returned %s", req.URL.Host, res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotewriter
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	throttledWrites = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "primaza_control_plane_throttled_writes_total",
+			Help: "Number of writes to the control plane delayed by the agent's rate limiter",
+		},
+	)
+	throttledWriteDelay = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "primaza_control_plane_throttled_write_delay_seconds",
+			Help:    "Time the writes to the control plane waited for the agent's rate limiter",
+			Buckets: prometheus.DefBuckets,
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(throttledWrites, throttledWriteDelay)
+}
+
+// RecordThrottledWrite counts a write to the control plane delayed by the
+// rate limiter, and the time it waited.  It is meant to be passed to Throttle.
+func RecordThrottledWrite(delay time.Duration) {
+	throttledWrites.Inc()
+	throttledWriteDelay.Observe(delay.Seconds())
+}
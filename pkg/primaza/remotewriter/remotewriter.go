@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotewriter
+
+import (
+	"context"
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Apply writes obj with a server-side apply patch on behalf of the given
+// field manager, forcing the ownership of the fields obj sets.  Repeated
+// applies of the same object are idempotent, and the fields other managers
+// own and obj does not set are left untouched.  obj is updated with the
+// state of the object on the cluster.
+//
+// It returns whether the object has been created, updated or left unchanged.
+func Apply(ctx context.Context, cli client.Client, fieldManager string, obj client.Object) (controllerutil.OperationResult, error) {
+	gvk, err := apiutil.GVKForObject(obj, cli.Scheme())
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	current, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return controllerutil.OperationResultNone, errors.New("object is not a client.Object")
+	}
+	op := controllerutil.OperationResultUpdated
+	if err := cli.Get(ctx, client.ObjectKeyFromObject(obj), current); apierrors.IsNotFound(err) {
+		op = controllerutil.OperationResultCreated
+	} else if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	obj.SetResourceVersion("")
+	obj.SetManagedFields(nil)
+	if err := cli.Patch(ctx, obj, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	if op == controllerutil.OperationResultUpdated && obj.GetResourceVersion() == current.GetResourceVersion() {
+		op = controllerutil.OperationResultNone
+	}
+	return op, nil
+}
+
+// ApplyWithSecret applies obj and the secret it refers to, so that obj never
+// refers to a missing or stale secret, as ApplyWithDependency does.  The
+// secret's string data is folded into its data, as the API server does, so
+// that repeated applies match the stored secret.  As the type of a secret
+// is immutable, a stored secret of a different type is deleted and created
+// again.  If secret is nil, only obj is applied.
+//
+// It returns the result of the operation on obj.
+func ApplyWithSecret(
+	ctx context.Context,
+	cli client.Client,
+	fieldManager string,
+	obj client.Object,
+	secret *corev1.Secret,
+) (controllerutil.OperationResult, error) {
+	if secret == nil {
+		return Apply(ctx, cli, fieldManager, obj)
+	}
+
+	if len(secret.StringData) > 0 {
+		data := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+		for k, v := range secret.Data {
+			data[k] = v
+		}
+		for k, v := range secret.StringData {
+			data[k] = []byte(v)
+		}
+		secret.Data = data
+		secret.StringData = nil
+	}
+	if err := deleteSecretOfOtherType(ctx, cli, secret); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	return ApplyWithDependency(ctx, cli, fieldManager, obj, secret)
+}
+
+// deleteSecretOfOtherType deletes the stored copy of secret if its type
+// differs from the desired one, so that the secret can be created again with
+// the desired type
+func deleteSecretOfOtherType(ctx context.Context, cli client.Client, secret *corev1.Secret) error {
+	t := secret.Type
+	if t == "" {
+		t = corev1.SecretTypeOpaque
+	}
+
+	current := &corev1.Secret{}
+	if err := cli.Get(ctx, client.ObjectKeyFromObject(secret), current); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if current.Type == t || (current.Type == "" && t == corev1.SecretTypeOpaque) {
+		return nil
+	}
+	return client.IgnoreNotFound(cli.Delete(ctx, current))
+}
+
+// ApplyWithDependency applies obj and the dependency it refers to, e.g. a
+// secret, so that obj never refers to a missing or stale dependency:
+//   - the dependency is applied first;
+//   - then obj is applied.  If it fails and the dependency has just been
+//     created, the dependency is deleted;
+//   - finally, the dependency is made owned by obj, so that it is garbage
+//     collected with it.
+//
+// It returns the result of the operation on obj.
+func ApplyWithDependency(
+	ctx context.Context,
+	cli client.Client,
+	fieldManager string,
+	obj client.Object,
+	dependency client.Object,
+) (controllerutil.OperationResult, error) {
+	// the ownership is applied from the desired dependency, as applying the
+	// state read back from the cluster would claim the fields of the other
+	// field managers
+	owned, ok := dependency.DeepCopyObject().(client.Object)
+	if !ok {
+		return controllerutil.OperationResultNone, errors.New("dependency is not a client.Object")
+	}
+
+	dependencyOp, err := Apply(ctx, cli, fieldManager, dependency)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	op, err := Apply(ctx, cli, fieldManager, obj)
+	if err != nil {
+		if dependencyOp == controllerutil.OperationResultCreated {
+			if derr := cli.Delete(ctx, dependency); derr != nil && !apierrors.IsNotFound(derr) {
+				err = errors.Join(err, derr)
+			}
+		}
+		return op, err
+	}
+
+	if err := controllerutil.SetOwnerReference(obj, owned, cli.Scheme()); err != nil {
+		return op, err
+	}
+	_, err = Apply(ctx, cli, fieldManager, owned)
+	return op, err
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotewriter
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+// applyClient records the options of the apply patches, and creates the
+// objects missing when they are applied, as the API server does and the fake
+// client does not
+type applyClient struct {
+	client.Client
+	options []client.PatchOptions
+}
+
+func (c *applyClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+
+	po := client.PatchOptions{}
+	po.ApplyOptions(opts)
+	c.options = append(c.options, po)
+
+	current, _ := obj.DeepCopyObject().(client.Object)
+	if err := c.Client.Get(ctx, client.ObjectKeyFromObject(obj), current); apierrors.IsNotFound(err) {
+		return c.Client.Create(ctx, obj)
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestApplyWithSecret(t *testing.T) {
+	tests := []struct {
+		name       string
+		registered bool
+		wantErr    bool
+	}{
+		{
+			name:       "applies the secret and the object owning it",
+			registered: true,
+		},
+		{
+			name:       "deletes the secret if the object can not be applied",
+			registered: false,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			if tt.registered {
+				if err := primazaiov1alpha1.AddToScheme(scheme); err != nil {
+					t.Fatal(err)
+				}
+			}
+			cli := &applyClient{Client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+
+			rs := &primazaiov1alpha1.RegisteredService{
+				ObjectMeta: metav1.ObjectMeta{Name: "mydb", Namespace: "primaza-system"},
+				Spec:       primazaiov1alpha1.RegisteredServiceSpec{SLA: "L1"},
+			}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "mydb-descriptor", Namespace: "primaza-system"},
+				StringData: map[string]string{"password": "secret"},
+			}
+			op, err := ApplyWithSecret(context.Background(), cli, "primaza-test", rs, secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			actual := &corev1.Secret{}
+			err = cli.Get(context.Background(), client.ObjectKeyFromObject(secret), actual)
+			if tt.wantErr {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("expected the secret to be deleted, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if op != controllerutil.OperationResultCreated {
+				t.Errorf("expected the registered service to be created, got %s", op)
+			}
+			if refs := actual.GetOwnerReferences(); len(refs) != 1 || refs[0].Kind != "RegisteredService" || refs[0].Name != rs.Name {
+				t.Errorf("expected the secret to be owned by the registered service, got %v", refs)
+			}
+			if len(actual.StringData) != 0 || string(actual.Data["password"]) != "secret" {
+				t.Errorf("expected the string data to be folded into the data, got %v and %v", actual.StringData, actual.Data)
+			}
+			for _, o := range cli.options {
+				if o.FieldManager != "primaza-test" || o.Force == nil || !*o.Force {
+					t.Errorf("expected a forced apply by primaza-test, got %s (force: %v)", o.FieldManager, o.Force)
+				}
+			}
+		})
+	}
+}
+
+// immutableSecretTypeClient rejects the changes of the type of a secret, as
+// the API server does
+type immutableSecretTypeClient struct {
+	client.Client
+}
+
+func (c *immutableSecretTypeClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if s, ok := obj.(*corev1.Secret); ok {
+		current := &corev1.Secret{}
+		if err := c.Client.Get(ctx, client.ObjectKeyFromObject(s), current); err == nil && s.Type != "" && current.Type != s.Type {
+			return apierrors.NewInvalid(s.GroupVersionKind().GroupKind(), s.Name, nil)
+		}
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestApplyWithSecretTypeChange(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := primazaiov1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	stored := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mydb", Namespace: "primaza-system"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
+	cli := &applyClient{Client: &immutableSecretTypeClient{fake.NewClientBuilder().WithScheme(scheme).WithObjects(stored).Build()}}
+
+	sb := &primazaiov1alpha1.ServiceBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "mydb", Namespace: "primaza-system"},
+		Spec:       primazaiov1alpha1.ServiceBindingSpec{ServiceEndpointDefinitionSecret: "mydb"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mydb", Namespace: "primaza-system"},
+		Type:       "servicebinding.io/psql",
+		StringData: map[string]string{"password": "secret", "type": "psql"},
+	}
+	if _, err := ApplyWithSecret(context.Background(), cli, "primaza-test", sb, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	actual := &corev1.Secret{}
+	if err := cli.Get(context.Background(), client.ObjectKeyFromObject(secret), actual); err != nil {
+		t.Fatal(err)
+	}
+	if actual.Type != "servicebinding.io/psql" {
+		t.Errorf("expected the secret to be recreated with type servicebinding.io/psql, got %s", actual.Type)
+	}
+	if string(actual.Data["type"]) != "psql" {
+		t.Errorf("expected the secret data to be applied, got %v", actual.Data)
+	}
+}
+
+func TestApply(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	cli := &applyClient{Client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycm", Namespace: "primaza-system"},
+		Data:       map[string]string{"key": "value"},
+	}
+	op, err := Apply(context.Background(), cli, "primaza-test", cm.DeepCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op != controllerutil.OperationResultCreated {
+		t.Errorf("expected the config map to be created, got %s", op)
+	}
+
+	applied := cm.DeepCopy()
+	applied.Data["key"] = "other"
+	op, err = Apply(context.Background(), cli, "primaza-test", applied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op != controllerutil.OperationResultUpdated {
+		t.Errorf("expected the config map to be updated, got %s", op)
+	}
+	if applied.Kind != "ConfigMap" || applied.Data["key"] != "other" {
+		t.Errorf("expected the applied object to be read back, got %v", applied)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotewriter
+
+import (
+	"net/http"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Throttle returns a wrapper of round trippers that waits for limiter before
+// sending write requests, so that the writes of all the clients sharing
+// limiter do not exceed its rate.  The time a throttled write waited is
+// reported to observe.  Reads are never throttled, and a nil limiter disables
+// throttling.
+func Throttle(limiter flowcontrol.RateLimiter, observe func(time.Duration)) func(http.RoundTripper) http.RoundTripper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		if limiter == nil {
+			return rt
+		}
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if isWrite(req) && !limiter.TryAccept() {
+				start := time.Now()
+				if err := limiter.Wait(req.Context()); err != nil {
+					return nil, err
+				}
+				if observe != nil {
+					observe(time.Since(start))
+				}
+			}
+			return rt.RoundTrip(req)
+		})
+	}
+}
+
+// isWrite returns whether the request modifies objects
+func isWrite(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
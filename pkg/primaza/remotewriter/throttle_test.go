@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotewriter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/client-go/util/flowcontrol"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func TestThrottle(t *testing.T) {
+	sent := 0
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+
+	send := func(ctx context.Context, rt http.RoundTripper, method string) error {
+		req, err := http.NewRequestWithContext(ctx, method, "https://primaza.example", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = rt.RoundTrip(req)
+		return err
+	}
+
+	t.Run("writes are throttled", func(t *testing.T) {
+		var waits []time.Duration
+		limiter := flowcontrol.NewTokenBucketRateLimiter(1000, 1)
+		throttled := Throttle(limiter, func(d time.Duration) { waits = append(waits, d) })(rt)
+
+		for _, m := range []string{http.MethodGet, http.MethodPatch, http.MethodGet, http.MethodPost} {
+			if err := send(context.Background(), throttled, m); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(waits) != 1 {
+			t.Errorf("expected 1 throttled write, got %d", len(waits))
+		}
+	})
+
+	t.Run("canceled writes are not sent", func(t *testing.T) {
+		sent = 0
+		limiter := flowcontrol.NewTokenBucketRateLimiter(0.001, 1)
+		throttled := Throttle(limiter, nil)(rt)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := send(ctx, throttled, http.MethodDelete); err != nil {
+			t.Fatal(err)
+		}
+		if err := send(ctx, throttled, http.MethodPut); err == nil {
+			t.Error("expected the throttled write to fail")
+		}
+		if sent != 1 {
+			t.Errorf("expected 1 request to be sent, got %d", sent)
+		}
+	})
+
+	t.Run("nil limiter disables throttling", func(t *testing.T) {
+		sent = 0
+		throttled := Throttle(nil, func(time.Duration) { t.Error("unexpected throttling") })(rt)
+		for i := 0; i < 10; i++ {
+			if err := send(context.Background(), throttled, http.MethodPost); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if sent != 10 {
+			t.Errorf("expected 10 requests to be sent, got %d", sent)
+		}
+	})
+}
+
+func TestRecordThrottledWrite(t *testing.T) {
+	before := &dto.Metric{}
+	if err := throttledWrites.Write(before); err != nil {
+		t.Fatal(err)
+	}
+
+	RecordThrottledWrite(200 * time.Millisecond)
+
+	after := &dto.Metric{}
+	if err := throttledWrites.Write(after); err != nil {
+		t.Fatal(err)
+	}
+	if d := after.GetCounter().GetValue() - before.GetCounter().GetValue(); d != 1 {
+		t.Errorf("expected 1 more throttled write, got %v", d)
+	}
+
+	// the collectors are served along with the agents' other metrics
+	if err := metrics.Registry.Register(throttledWrites); err == nil {
+		t.Errorf("expected the throttled writes counter to be registered")
+	}
+}
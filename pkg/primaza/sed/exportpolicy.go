@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sed
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+// MaxExportedValueSize is the maximum size, in bytes, of a value copied out
+// of the worker cluster
+const MaxExportedValueSize = 64 * 1024
+
+// ExportPolicy ensures that only the fields explicitly mapped by a
+// ServiceClass leave the worker cluster
+type ExportPolicy struct {
+	keys map[string]bool
+}
+
+// NewExportPolicy returns the export policy allowing the service endpoint
+// definition items mapped by the given ServiceClass
+func NewExportPolicy(serviceClass v1alpha1.ServiceClass) ExportPolicy {
+	keys := map[string]bool{}
+	mappings := serviceClass.Spec.Resource.ServiceEndpointDefinitionMappings
+	for _, m := range mappings.ResourceFields {
+		keys[m.Name] = true
+	}
+	for _, m := range mappings.SecretRefFields {
+		keys[m.Name] = true
+	}
+	for _, m := range mappings.HandlerFields {
+		keys[m.Name] = true
+	}
+	for _, secondary := range serviceClass.Spec.Resource.SecondaryResources() {
+		for _, m := range secondary.ResourceFields {
+			keys[m.Name] = true
+		}
+	}
+	return ExportPolicy{keys: keys}
+}
+
+// Apply removes from the registered service's service endpoint definition,
+// and from its secret, the items that are not mapped by the ServiceClass.  It
+// fails if a value is larger than MaxExportedValueSize.
+func (p ExportPolicy) Apply(rs *v1alpha1.RegisteredService, secret *corev1.Secret) error {
+	items := make([]v1alpha1.ServiceEndpointDefinitionItem, 0, len(rs.Spec.ServiceEndpointDefinition))
+	for _, i := range rs.Spec.ServiceEndpointDefinition {
+		if !p.keys[i.Name] {
+			continue
+		}
+		if len(i.Value) > MaxExportedValueSize {
+			return fmt.Errorf("value of %s is larger than %d bytes", i.Name, MaxExportedValueSize)
+		}
+		items = append(items, i)
+	}
+	rs.Spec.ServiceEndpointDefinition = items
+
+	if secret == nil {
+		return nil
+	}
+	for k, v := range secret.StringData {
+		switch {
+		case !p.keys[k]:
+			delete(secret.StringData, k)
+		case len(v) > MaxExportedValueSize:
+			return fmt.Errorf("value of %s is larger than %d bytes", k, MaxExportedValueSize)
+		}
+	}
+	for k, v := range secret.Data {
+		switch {
+		case !p.keys[k]:
+			delete(secret.Data, k)
+		case len(v) > MaxExportedValueSize:
+			return fmt.Errorf("value of %s is larger than %d bytes", k, MaxExportedValueSize)
+		}
+	}
+	return nil
+}
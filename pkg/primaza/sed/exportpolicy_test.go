@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sed
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+func TestExportPolicy(t *testing.T) {
+	serviceClass := v1alpha1.ServiceClass{
+		Spec: v1alpha1.ServiceClassSpec{
+			Resource: v1alpha1.ServiceClassResource{
+				ServiceEndpointDefinitionMappings: v1alpha1.ServiceEndpointDefinitionMappings{
+					ResourceFields:  []v1alpha1.ServiceClassResourceFieldMapping{{Name: "host"}},
+					SecretRefFields: []v1alpha1.ServiceClassSecretRefFieldMapping{{Name: "password"}},
+				},
+				Secondary: &v1alpha1.ServiceClassSecondaryResource{
+					ResourceFields: []v1alpha1.ServiceClassResourceFieldMapping{{Name: "port"}},
+				},
+				Related: []v1alpha1.ServiceClassSecondaryResource{
+					{ResourceFields: []v1alpha1.ServiceClassResourceFieldMapping{{Name: "database"}}},
+				},
+			},
+		},
+	}
+	policy := NewExportPolicy(serviceClass)
+
+	tests := []struct {
+		name       string
+		items      []v1alpha1.ServiceEndpointDefinitionItem
+		secret     *corev1.Secret
+		wantItems  []v1alpha1.ServiceEndpointDefinitionItem
+		wantSecret *corev1.Secret
+		wantErr    bool
+	}{
+		{
+			name:      "mapped items",
+			items:     []v1alpha1.ServiceEndpointDefinitionItem{{Name: "host", Value: "db"}, {Name: "port", Value: "5432"}, {Name: "database", Value: "app"}},
+			wantItems: []v1alpha1.ServiceEndpointDefinitionItem{{Name: "host", Value: "db"}, {Name: "port", Value: "5432"}, {Name: "database", Value: "app"}},
+		},
+		{
+			name: "unmapped items",
+			items: []v1alpha1.ServiceEndpointDefinitionItem{
+				{Name: "host", Value: "db"},
+				{Name: "token", ValueFromSecret: &v1alpha1.ServiceEndpointDefinitionSecretRef{Name: "db-descriptor", Key: "token"}},
+			},
+			secret: &corev1.Secret{
+				StringData: map[string]string{"password": "secret", "token": "leaked"},
+				Data:       map[string][]byte{"cert": []byte("leaked")},
+			},
+			wantItems:  []v1alpha1.ServiceEndpointDefinitionItem{{Name: "host", Value: "db"}},
+			wantSecret: &corev1.Secret{StringData: map[string]string{"password": "secret"}, Data: map[string][]byte{}},
+		},
+		{
+			name:    "oversized value",
+			items:   []v1alpha1.ServiceEndpointDefinitionItem{{Name: "host", Value: strings.Repeat("a", MaxExportedValueSize+1)}},
+			wantErr: true,
+		},
+		{
+			name:    "oversized secret value",
+			secret:  &corev1.Secret{Data: map[string][]byte{"password": make([]byte, MaxExportedValueSize+1)}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := v1alpha1.RegisteredService{Spec: v1alpha1.RegisteredServiceSpec{ServiceEndpointDefinition: tt.items}}
+			err := policy.Apply(&rs, tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(rs.Spec.ServiceEndpointDefinition) != 0 || len(tt.wantItems) != 0 {
+				if !reflect.DeepEqual(rs.Spec.ServiceEndpointDefinition, tt.wantItems) {
+					t.Errorf("Apply() items = %v, want %v", rs.Spec.ServiceEndpointDefinition, tt.wantItems)
+				}
+			}
+			if !reflect.DeepEqual(tt.secret, tt.wantSecret) {
+				t.Errorf("Apply() secret = %v, want %v", tt.secret, tt.wantSecret)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sed
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/discovery"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// handlerValues memoizes the values produced by discovery handlers for a
+// service resource, so that each handler is called once however many
+// mappings read its values
+type handlerValues struct {
+	resource unstructured.Unstructured
+	cli      client.Client
+
+	values map[string]map[string]string
+	errs   map[string]error
+}
+
+// discover returns the values produced by the named handler
+func (h *handlerValues) discover(ctx context.Context, name string) (map[string]string, error) {
+	if err, found := h.errs[name]; found {
+		return nil, err
+	}
+	if values, found := h.values[name]; found {
+		return values, nil
+	}
+
+	handler, found := discovery.Lookup(name)
+	if !found {
+		err := fmt.Errorf("discovery handler %s is not registered in the service agent", name)
+		h.errs[name] = err
+		return nil, err
+	}
+	values, err := handler.Discover(ctx, h.cli, h.resource)
+	if err != nil {
+		h.errs[name] = err
+		return nil, err
+	}
+	h.values[name] = values
+	return values, nil
+}
+
+// SEDHandlerMapping reads a value produced by a discovery handler
+type SEDHandlerMapping struct {
+	values *handlerValues
+
+	key             string
+	handler         string
+	handlerKey      string
+	secret          bool
+	optional        bool
+	transformations []v1alpha1.ValueTransformation
+}
+
+// NewSEDHandlerMappings returns the mappings of the given service resource
+// whose values are produced by discovery handlers.  Mappings share the values
+// produced by their handler.
+func NewSEDHandlerMappings(resource unstructured.Unstructured, cli client.Client, mappings []v1alpha1.ServiceClassHandlerFieldMapping) []SEDMapping {
+	values := &handlerValues{
+		resource: resource,
+		cli:      cli,
+		values:   map[string]map[string]string{},
+		errs:     map[string]error{},
+	}
+	sedMappings := make([]SEDMapping, 0, len(mappings))
+	for _, m := range mappings {
+		sedMappings = append(sedMappings, &SEDHandlerMapping{
+			values:          values,
+			key:             m.Name,
+			handler:         m.Handler,
+			handlerKey:      m.HandlerKey(),
+			secret:          m.Secret,
+			optional:        m.Optional,
+			transformations: m.Transformations,
+		})
+	}
+	return sedMappings
+}
+
+func (s *SEDHandlerMapping) Key() string {
+	return s.key
+}
+
+func (mapping *SEDHandlerMapping) ReadKey(ctx context.Context) (*string, error) {
+	value, err := mapping.readKey(ctx)
+	if err != nil {
+		return nil, resolutionError(mapping.key, err)
+	}
+	return value, nil
+}
+
+func (mapping *SEDHandlerMapping) readKey(ctx context.Context) (*string, error) {
+	values, err := mapping.values.discover(ctx, mapping.handler)
+	if err != nil {
+		return nil, err
+	}
+
+	v, found := values[mapping.handlerKey]
+	if !found {
+		if mapping.optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("discovery handler %s produced no value for %s", mapping.handler, mapping.handlerKey)
+	}
+
+	value, err := Transform(v, mapping.transformations)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+func (s *SEDHandlerMapping) InSecret() bool {
+	return s.secret
+}
+
+func (s *SEDHandlerMapping) Binary() bool {
+	return false
+}
+
+// Source returns the handler and the key the value is produced by
+func (s *SEDHandlerMapping) Source() string {
+	return fmt.Sprintf("Handler %s %s", s.handler, s.handlerKey)
+}
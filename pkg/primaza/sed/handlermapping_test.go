@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sed
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/discovery"
+	primazaerrors "github.com/primaza/primaza/pkg/primaza/errors"
+)
+
+func TestHandlerMappings(t *testing.T) {
+	calls := 0
+	discovery.Register("test-rds", discovery.HandlerFunc(func(ctx context.Context, cli client.Client, resource unstructured.Unstructured) (map[string]string, error) {
+		calls++
+		return map[string]string{"endpoint": " " + resource.GetName() + ".rds.example.com ", "port": "5432"}, nil
+	}))
+
+	resource := unstructured.Unstructured{Object: map[string]interface{}{}}
+	resource.SetName("mydb")
+	mappings := NewSEDHandlerMappings(resource, nil, []v1alpha1.ServiceClassHandlerFieldMapping{
+		{Name: "host", Handler: "test-rds", Key: "endpoint", Transformations: []v1alpha1.ValueTransformation{v1alpha1.ValueTransformationTrimSpace}},
+		{Name: "port", Handler: "test-rds"},
+		{Name: "database", Handler: "test-rds", Optional: true},
+		{Name: "username", Handler: "test-rds"},
+		{Name: "password", Handler: "test-missing"},
+	})
+
+	tests := []struct {
+		want    *string
+		wantErr bool
+	}{
+		{want: ptr("mydb.rds.example.com")},
+		{want: ptr("5432")},
+		{want: nil},
+		{wantErr: true},
+		{wantErr: true},
+	}
+	for i, tt := range tests {
+		m := mappings[i]
+		got, err := m.ReadKey(context.Background())
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: ReadKey() error = %v, wantErr %v", m.Key(), err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, primazaerrors.ErrSEDResolution) {
+			t.Errorf("%s: ReadKey() error = %v, want ErrSEDResolution", m.Key(), err)
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: ReadKey() = %v, want %v", m.Key(), got, tt.want)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+	if got := mappings[0].Source(); got != "Handler test-rds endpoint" {
+		t.Errorf("Source() = %s, want Handler test-rds endpoint", got)
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -17,10 +17,34 @@ limitations under the License.
 // Package sed contains logic for ServiceEndpointDefinition
 package sed
 
-import "context"
+import (
+	"context"
+	"fmt"
+
+	primazaerrors "github.com/primaza/primaza/pkg/primaza/errors"
+)
 
 type SEDMapping interface {
 	Key() string
+	// ReadKey returns the value of the mapping, or nil if the mapping
+	// is optional and no value has been found.  Errors are classified as
+	// ErrSEDResolution, unless they are caused by the cluster.
 	ReadKey(context.Context) (*string, error)
 	InSecret() bool
+	// Binary reports whether the value returned by ReadKey is
+	// base64 encoded binary data
+	Binary() bool
+	// Source describes the field the value is read from, e.g.
+	// `Service/mydb .spec.clusterIP`
+	Source() string
+}
+
+// resolutionError classifies the error that prevented the value of key from
+// being read
+func resolutionError(key string, err error) error {
+	op := fmt.Sprintf("resolve %s", key)
+	if cerr := primazaerrors.Classify(op, err); cerr != err {
+		return cerr
+	}
+	return primazaerrors.SEDResolution(op, err)
 }
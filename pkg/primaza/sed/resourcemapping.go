@@ -30,9 +30,13 @@ import (
 type SEDResourceMapping struct {
 	resource unstructured.Unstructured
 
-	key    string
-	path   *jsonpath.JSONPath
-	secret bool
+	key             string
+	jsonPath        string
+	path            *jsonpath.JSONPath
+	secret          bool
+	optional        bool
+	defaultValue    *string
+	transformations []v1alpha1.ValueTransformation
 }
 
 func NewSEDResourceMapping(resource unstructured.Unstructured, mapping v1alpha1.ServiceClassResourceFieldMapping) (*SEDResourceMapping, error) {
@@ -43,10 +47,14 @@ func NewSEDResourceMapping(resource unstructured.Unstructured, mapping v1alpha1.
 	}
 
 	return &SEDResourceMapping{
-		resource: resource,
-		key:      mapping.Name,
-		path:     path,
-		secret:   mapping.Secret,
+		resource:        resource,
+		key:             mapping.Name,
+		jsonPath:        mapping.JsonPath,
+		path:            path,
+		secret:          mapping.Secret,
+		optional:        mapping.Optional,
+		defaultValue:    mapping.Default,
+		transformations: mapping.Transformations,
 	}, nil
 }
 
@@ -55,19 +63,51 @@ func (s *SEDResourceMapping) Key() string {
 }
 
 func (mapping *SEDResourceMapping) ReadKey(ctx context.Context) (*string, error) {
-	results, err := mapping.path.FindResults(mapping.resource.Object)
+	value, err := mapping.readKey(ctx)
 	if err != nil {
-		return nil, err
+		return nil, resolutionError(mapping.key, err)
+	}
+	return value, nil
+}
+
+func (mapping *SEDResourceMapping) readKey(ctx context.Context) (*string, error) {
+	results, err := mapping.path.FindResults(mapping.resource.Object)
+	if err != nil || len(results) == 0 || len(results[0]) == 0 {
+		switch {
+		case mapping.defaultValue != nil:
+			value := *mapping.defaultValue
+			return &value, nil
+		case mapping.optional:
+			return nil, nil
+		case err != nil:
+			return nil, err
+		default:
+			return nil, fmt.Errorf("jsonPath lookup into resource returned no results")
+		}
 	}
 
 	if len(results) != 1 || len(results[0]) != 1 {
 		return nil, fmt.Errorf("jsonPath lookup into resource returned multiple results: %v", results)
 	}
 
-	value := fmt.Sprintf("%v", results[0][0])
+	value, err := Transform(fmt.Sprintf("%v", results[0][0]), mapping.transformations)
+	if err != nil {
+		return nil, err
+	}
 	return &value, nil
 }
 
 func (s *SEDResourceMapping) InSecret() bool {
 	return s.secret
 }
+
+func (s *SEDResourceMapping) Binary() bool {
+	return false
+}
+
+func (s *SEDResourceMapping) Source() string {
+	if s.resource.GetName() == "" {
+		return s.jsonPath
+	}
+	return fmt.Sprintf("%s/%s %s", s.resource.GetKind(), s.resource.GetName(), s.jsonPath)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sed
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+	primazaerrors "github.com/primaza/primaza/pkg/primaza/errors"
+)
+
+func TestResourceMappingReadKey(t *testing.T) {
+	resource := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"host": "mydb.example.com", "port": int64(5432)},
+	}}
+	resource.SetKind("Database")
+	resource.SetName("mydb")
+
+	tests := []struct {
+		name    string
+		mapping v1alpha1.ServiceClassResourceFieldMapping
+		want    *string
+		wantErr bool
+	}{
+		{
+			name:    "present field",
+			mapping: v1alpha1.ServiceClassResourceFieldMapping{Name: "port", JsonPath: ".spec.port"},
+			want:    ptr("5432"),
+		},
+		{
+			name:    "present field with a default",
+			mapping: v1alpha1.ServiceClassResourceFieldMapping{Name: "host", JsonPath: ".spec.host", Default: ptr("localhost")},
+			want:    ptr("mydb.example.com"),
+		},
+		{
+			name:    "missing optional field with a default",
+			mapping: v1alpha1.ServiceClassResourceFieldMapping{Name: "database", JsonPath: ".spec.database", Optional: true, Default: ptr("postgres")},
+			want:    ptr("postgres"),
+		},
+		{
+			name:    "missing optional field without a default",
+			mapping: v1alpha1.ServiceClassResourceFieldMapping{Name: "database", JsonPath: ".spec.database", Optional: true},
+			want:    nil,
+		},
+		{
+			name:    "missing required field",
+			mapping: v1alpha1.ServiceClassResourceFieldMapping{Name: "database", JsonPath: ".spec.database"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewSEDResourceMapping(resource, tt.mapping)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := m.ReadKey(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, primazaerrors.ErrSEDResolution) {
+				t.Errorf("ReadKey() error = %v, want ErrSEDResolution", err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("ReadKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
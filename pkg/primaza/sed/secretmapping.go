@@ -19,6 +19,7 @@ package sed
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 
 	"github.com/primaza/primaza/api/v1alpha1"
@@ -34,9 +35,14 @@ type SEDSecretRefMapping struct {
 	resource  unstructured.Unstructured
 	cli       client.Client
 
-	key        string
-	secretName *jsonpath.JSONPath
-	secretKey  *jsonpath.JSONPath
+	key            string
+	secretNamePath string
+	secretName     *jsonpath.JSONPath
+	secretKeyPath  string
+	secretKey      *jsonpath.JSONPath
+	binary         bool
+
+	transformations []v1alpha1.ValueTransformation
 }
 
 func NewSEDSecretRefMapping(
@@ -56,12 +62,17 @@ func NewSEDSecretRefMapping(
 	}
 
 	return &SEDSecretRefMapping{
-		namespace:  namespace,
-		resource:   resource,
-		cli:        cli,
-		key:        mapping.Name,
-		secretKey:  pathKey,
-		secretName: pathName,
+		namespace:      namespace,
+		resource:       resource,
+		cli:            cli,
+		key:            mapping.Name,
+		secretKeyPath:  mapping.SecretKey,
+		secretKey:      pathKey,
+		secretNamePath: mapping.SecretName,
+		secretName:     pathName,
+		binary:         mapping.Binary,
+
+		transformations: mapping.Transformations,
 	}, nil
 }
 
@@ -70,6 +81,14 @@ func (s *SEDSecretRefMapping) Key() string {
 }
 
 func (mapping *SEDSecretRefMapping) ReadKey(ctx context.Context) (*string, error) {
+	value, err := mapping.readKey(ctx)
+	if err != nil {
+		return nil, resolutionError(mapping.key, err)
+	}
+	return value, nil
+}
+
+func (mapping *SEDSecretRefMapping) readKey(ctx context.Context) (*string, error) {
 	secKey, err := readSingleJsonPath(mapping.secretKey, mapping.resource)
 	if err != nil {
 		return nil, err
@@ -89,13 +108,24 @@ func (mapping *SEDSecretRefMapping) ReadKey(ctx context.Context) (*string, error
 	}
 
 	if vb, ok := s.Data[*secKey]; ok {
-		v := string(vb)
+		v, err := Transform(string(vb), mapping.transformations)
+		if err != nil {
+			return nil, err
+		}
+		if mapping.binary {
+			v = base64.StdEncoding.EncodeToString([]byte(v))
+		}
 		return &v, nil
 	}
 
 	return nil, fmt.Errorf("secret key '%s/%s:%s' not Found", mapping.namespace, *secName, *secKey)
 }
 
+// SecretName returns the name of the secret referred to by the mapping
+func (mapping *SEDSecretRefMapping) SecretName() (*string, error) {
+	return readSingleJsonPath(mapping.secretName, mapping.resource)
+}
+
 func readSingleJsonPath(path *jsonpath.JSONPath, resource unstructured.Unstructured) (*string, error) {
 	results, err := path.FindResults(resource.Object)
 	if err != nil {
@@ -113,3 +143,21 @@ func readSingleJsonPath(path *jsonpath.JSONPath, resource unstructured.Unstructu
 func (s *SEDSecretRefMapping) InSecret() bool {
 	return true
 }
+
+func (s *SEDSecretRefMapping) Binary() bool {
+	return s.binary
+}
+
+// Source returns the secret and key the value is read from, or the paths
+// they are read from if they can not be resolved
+func (s *SEDSecretRefMapping) Source() string {
+	name, err := readSingleJsonPath(s.secretName, s.resource)
+	if err != nil {
+		return fmt.Sprintf("Secret %s %s", s.secretNamePath, s.secretKeyPath)
+	}
+	key, err := readSingleJsonPath(s.secretKey, s.resource)
+	if err != nil {
+		return fmt.Sprintf("Secret/%s %s", *name, s.secretKeyPath)
+	}
+	return fmt.Sprintf("Secret/%s %s", *name, *key)
+}
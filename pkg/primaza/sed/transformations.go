@@ -0,0 +1,49 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sed
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+// Transform applies the given transformations, in order, to value
+func Transform(value string, transformations []v1alpha1.ValueTransformation) (string, error) {
+	for _, t := range transformations {
+		switch t {
+		case v1alpha1.ValueTransformationBase64Decode:
+			b, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return "", fmt.Errorf("error applying transformation %s: %w", t, err)
+			}
+			value = string(b)
+		case v1alpha1.ValueTransformationTrimSpace:
+			value = strings.TrimSpace(value)
+		case v1alpha1.ValueTransformationToLower:
+			value = strings.ToLower(value)
+		case v1alpha1.ValueTransformationURLEncode:
+			value = url.QueryEscape(value)
+		default:
+			return "", fmt.Errorf("unknown transformation %s", t)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sed
+
+import (
+	"testing"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		name            string
+		value           string
+		transformations []v1alpha1.ValueTransformation
+		want            string
+		wantErr         bool
+	}{
+		{
+			name:  "no transformation",
+			value: " Secret ",
+			want:  " Secret ",
+		},
+		{
+			name:            "base64 decode",
+			value:           "c2VjcmV0",
+			transformations: []v1alpha1.ValueTransformation{v1alpha1.ValueTransformationBase64Decode},
+			want:            "secret",
+		},
+		{
+			name:            "invalid base64",
+			value:           "not base64!",
+			transformations: []v1alpha1.ValueTransformation{v1alpha1.ValueTransformationBase64Decode},
+			wantErr:         true,
+		},
+		{
+			name:            "trim space",
+			value:           "\t secret\n",
+			transformations: []v1alpha1.ValueTransformation{v1alpha1.ValueTransformationTrimSpace},
+			want:            "secret",
+		},
+		{
+			name:            "to lower",
+			value:           "SeCrEt",
+			transformations: []v1alpha1.ValueTransformation{v1alpha1.ValueTransformationToLower},
+			want:            "secret",
+		},
+		{
+			name:            "url encode",
+			value:           "p@ss word/&",
+			transformations: []v1alpha1.ValueTransformation{v1alpha1.ValueTransformationURLEncode},
+			want:            "p%40ss+word%2F%26",
+		},
+		{
+			name:  "chain applied in order",
+			value: "ICBQQFNTICA=",
+			transformations: []v1alpha1.ValueTransformation{
+				v1alpha1.ValueTransformationBase64Decode,
+				v1alpha1.ValueTransformationTrimSpace,
+				v1alpha1.ValueTransformationToLower,
+				v1alpha1.ValueTransformationURLEncode,
+			},
+			want: "p%40ss",
+		},
+		{
+			name:  "order matters",
+			value: " P@SS ",
+			transformations: []v1alpha1.ValueTransformation{
+				v1alpha1.ValueTransformationURLEncode,
+				v1alpha1.ValueTransformationTrimSpace,
+			},
+			want: "+P%40SS+",
+		},
+		{
+			name:            "unknown transformation",
+			value:           "secret",
+			transformations: []v1alpha1.ValueTransformation{"rot13"},
+			wantErr:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Transform(tt.value, tt.transformations)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Transform() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Transform() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
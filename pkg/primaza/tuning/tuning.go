@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tuning
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// DefaultMaxConcurrentReconciles is the default number of objects of a
+	// kind reconciled concurrently
+	DefaultMaxConcurrentReconciles = 1
+	// DefaultQPS is the default number of queries per second the client
+	// sends to the API server
+	DefaultQPS = 20
+	// DefaultBurst is the default number of queries the client sends to the
+	// API server in a burst
+	DefaultBurst = 30
+	// DefaultSyncPeriod is the default period the watched objects are
+	// reconciled at, even if they did not change
+	DefaultSyncPeriod = 10 * time.Hour
+	// DefaultControlPlaneQPS is the default number of writes per second the
+	// agent sends to the control plane
+	DefaultControlPlaneQPS = 10
+	// DefaultControlPlaneBurst is the default number of writes the agent
+	// sends to the control plane in a burst
+	DefaultControlPlaneBurst = 20
+)
+
+// flags maps the tuning flags to the environment variables their values
+// default to
+var flags = map[string]string{
+	"max-concurrent-reconciles": "PRIMAZA_MAX_CONCURRENT_RECONCILES",
+	"kube-api-qps":              "PRIMAZA_KUBE_API_QPS",
+	"kube-api-burst":            "PRIMAZA_KUBE_API_BURST",
+	"sync-period":               "PRIMAZA_SYNC_PERIOD",
+	"control-plane-qps":         "PRIMAZA_CONTROL_PLANE_QPS",
+	"control-plane-burst":       "PRIMAZA_CONTROL_PLANE_BURST",
+}
+
+// Options are the tuning options of an agent
+type Options struct {
+	// MaxConcurrentReconciles is the number of objects of a kind reconciled
+	// concurrently
+	MaxConcurrentReconciles int
+	// QPS is the number of queries per second the client sends to the API
+	// server
+	QPS float64
+	// Burst is the number of queries the client sends to the API server in
+	// a burst
+	Burst int
+	// SyncPeriod is the period the watched objects are reconciled at, even
+	// if they did not change
+	SyncPeriod time.Duration
+	// ControlPlaneQPS is the number of writes per second the agent sends to
+	// the control plane, 0 disables the limit
+	ControlPlaneQPS float64
+	// ControlPlaneBurst is the number of writes the agent sends to the
+	// control plane in a burst
+	ControlPlaneBurst int
+}
+
+// BindFlags binds the options to flags of fs
+func (o *Options) BindFlags(fs *flag.FlagSet) {
+	fs.IntVar(&o.MaxConcurrentReconciles, "max-concurrent-reconciles", DefaultMaxConcurrentReconciles,
+		"The number of objects of a kind reconciled concurrently.")
+	fs.Float64Var(&o.QPS, "kube-api-qps", DefaultQPS,
+		"The number of queries per second sent to the API server.")
+	fs.IntVar(&o.Burst, "kube-api-burst", DefaultBurst,
+		"The number of queries sent to the API server in a burst.")
+	fs.DurationVar(&o.SyncPeriod, "sync-period", DefaultSyncPeriod,
+		"The period the watched objects are reconciled at, even if they did not change.")
+	fs.Float64Var(&o.ControlPlaneQPS, "control-plane-qps", DefaultControlPlaneQPS,
+		"The number of writes per second sent to the control plane, 0 disables the limit.")
+	fs.IntVar(&o.ControlPlaneBurst, "control-plane-burst", DefaultControlPlaneBurst,
+		"The number of writes sent to the control plane in a burst.")
+}
+
+// LoadEnv sets the tuning flags of fs that are not set on the command line
+// from their environment variable, e.g. PRIMAZA_MAX_CONCURRENT_RECONCILES for
+// --max-concurrent-reconciles.  It must be called once fs is parsed.
+func LoadEnv(fs *flag.FlagSet) error {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	for name, env := range flags {
+		v, ok := os.LookupEnv(env)
+		if !ok || set[name] || fs.Lookup(name) == nil {
+			continue
+		}
+		if err := fs.Set(name, v); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", v, env, err)
+		}
+	}
+	return nil
+}
+
+// ApplyToConfig sets the client's rate limits of cfg
+func (o *Options) ApplyToConfig(cfg *rest.Config) {
+	cfg.QPS = float32(o.QPS)
+	cfg.Burst = o.Burst
+}
+
+// ApplyToManager sets the sync period of the manager's cache, and the
+// number of objects reconciled concurrently by the controllers of each kind
+// known by the manager's scheme
+func (o *Options) ApplyToManager(opts *ctrl.Options, scheme *runtime.Scheme) {
+	opts.SyncPeriod = &o.SyncPeriod
+
+	if opts.Controller.GroupKindConcurrency == nil {
+		opts.Controller.GroupKindConcurrency = map[string]int{}
+	}
+	for gvk := range scheme.AllKnownTypes() {
+		opts.Controller.GroupKindConcurrency[gvk.GroupKind().String()] = o.MaxConcurrentReconciles
+	}
+}
+
+// ControlPlaneLimiter returns the rate limiter shared by the writes to the
+// control plane, or nil if they are not limited
+func (o *Options) ControlPlaneLimiter() flowcontrol.RateLimiter {
+	if o.ControlPlaneQPS <= 0 {
+		return nil
+	}
+	burst := o.ControlPlaneBurst
+	if burst < 1 {
+		burst = 1
+	}
+	return flowcontrol.NewTokenBucketRateLimiter(float32(o.ControlPlaneQPS), burst)
+}
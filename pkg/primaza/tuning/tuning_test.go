@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tuning
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestLoadEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		want    Options
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			want: Options{MaxConcurrentReconciles: 1, QPS: 20, Burst: 30, SyncPeriod: 10 * time.Hour, ControlPlaneQPS: 10, ControlPlaneBurst: 20},
+		},
+		{
+			name: "environment overrides defaults",
+			env: map[string]string{
+				"PRIMAZA_MAX_CONCURRENT_RECONCILES": "4",
+				"PRIMAZA_KUBE_API_QPS":              "50",
+				"PRIMAZA_SYNC_PERIOD":               "1h",
+			},
+			want: Options{MaxConcurrentReconciles: 4, QPS: 50, Burst: 30, SyncPeriod: time.Hour, ControlPlaneQPS: 10, ControlPlaneBurst: 20},
+		},
+		{
+			name: "control plane budget",
+			args: []string{"--control-plane-qps=0"},
+			env: map[string]string{
+				"PRIMAZA_CONTROL_PLANE_QPS":   "5",
+				"PRIMAZA_CONTROL_PLANE_BURST": "5",
+			},
+			want: Options{MaxConcurrentReconciles: 1, QPS: 20, Burst: 30, SyncPeriod: 10 * time.Hour, ControlPlaneQPS: 0, ControlPlaneBurst: 5},
+		},
+		{
+			name: "flags override environment",
+			args: []string{"--max-concurrent-reconciles=8", "--kube-api-burst=100"},
+			env: map[string]string{
+				"PRIMAZA_MAX_CONCURRENT_RECONCILES": "4",
+				"PRIMAZA_KUBE_API_BURST":            "60",
+			},
+			want: Options{MaxConcurrentReconciles: 8, QPS: 20, Burst: 100, SyncPeriod: 10 * time.Hour, ControlPlaneQPS: 10, ControlPlaneBurst: 20},
+		},
+		{
+			name:    "invalid environment",
+			env:     map[string]string{"PRIMAZA_SYNC_PERIOD": "often"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			o := Options{}
+			o.BindFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := LoadEnv(fs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.wantErr && o != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, o)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	o := Options{MaxConcurrentReconciles: 4, QPS: 50, Burst: 100, SyncPeriod: time.Hour}
+
+	cfg := &rest.Config{}
+	o.ApplyToConfig(cfg)
+	if cfg.QPS != 50 || cfg.Burst != 100 {
+		t.Errorf("expected a QPS of 50 and a burst of 100, got %v and %d", cfg.QPS, cfg.Burst)
+	}
+
+	opts := ctrl.Options{}
+	o.ApplyToManager(&opts, scheme)
+	if opts.SyncPeriod == nil || *opts.SyncPeriod != time.Hour {
+		t.Errorf("expected a sync period of 1h, got %v", opts.SyncPeriod)
+	}
+	if c := opts.Controller.GroupKindConcurrency["Deployment.apps"]; c != 4 {
+		t.Errorf("expected 4 deployments reconciled concurrently, got %d", c)
+	}
+}
+
+func TestControlPlaneLimiter(t *testing.T) {
+	if l := (&Options{ControlPlaneQPS: 0}).ControlPlaneLimiter(); l != nil {
+		t.Errorf("expected no limiter, got %v", l)
+	}
+	l := (&Options{ControlPlaneQPS: 5, ControlPlaneBurst: 10}).ControlPlaneLimiter()
+	if l == nil || l.QPS() != 5 {
+		t.Errorf("expected a limiter of 5 writes per second, got %v", l)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Client writes secrets to the KV version 2 secrets engines of a Vault server
+type Client struct {
+	// Address of the Vault server (e.g. `https://vault.vault.svc:8200`)
+	Address string
+	// TokenFile is the file holding the token Primaza authenticates with.
+	// It is read on every request, so that the token can be rotated.
+	TokenFile string
+	Client    *http.Client
+}
+
+// SecretPath returns the path the Vault agent reads the secret stored at path
+// in the given KV version 2 mount from
+func SecretPath(mount, path string) string {
+	return fmt.Sprintf("%s/data/%s", strings.Trim(mount, "/"), strings.Trim(path, "/"))
+}
+
+// Write stores data as the latest version of the secret at path in the given
+// mount
+func (c *Client) Write(ctx context.Context, mount, path string, data map[string]string) error {
+	return c.do(ctx, http.MethodPut, SecretPath(mount, path), map[string]interface{}{"data": data})
+}
+
+// Delete removes all the versions of the secret at path in the given mount.
+// Deleting a secret that does not exist is not an error.
+func (c *Client) Delete(ctx context.Context, mount, path string) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("%s/metadata/%s", strings.Trim(mount, "/"), strings.Trim(path, "/")), nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body interface{}) error {
+	cli := c.Client
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+
+	token, err := os.ReadFile(c.TokenFile)
+	if err != nil {
+		return fmt.Errorf("error reading vault token: %w", err)
+	}
+
+	var b bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&b).Encode(body); err != nil {
+			return err
+		}
+	}
+	url := fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(c.Address, "/"), path)
+	req, err := http.NewRequestWithContext(ctx, method, url, &b)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", strings.TrimSpace(string(token)))
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound && method == http.MethodDelete {
+		return nil
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("vault %s %s returned %s", method, path, res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	type request struct {
+		Method string
+		Path   string
+		Token  string
+		Body   map[string]interface{}
+	}
+	var got []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := request{Method: r.Method, Path: r.URL.Path, Token: r.Header.Get("X-Vault-Token")}
+		_ = json.NewDecoder(r.Body).Decode(&req.Body)
+		got = append(got, req)
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("s.token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := Client{Address: srv.URL + "/", TokenFile: tokenFile}
+
+	if err := c.Write(context.Background(), "secret/", "primaza/apps/db", map[string]string{"password": "s3cr3t"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := c.Delete(context.Background(), "secret", "primaza/apps/db"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	want := []request{
+		{Method: http.MethodPut, Path: "/v1/secret/data/primaza/apps/db", Token: "s.token",
+			Body: map[string]interface{}{"data": map[string]interface{}{"password": "s3cr3t"}}},
+		{Method: http.MethodDelete, Path: "/v1/secret/metadata/primaza/apps/db", Token: "s.token"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("requests = %v, want %v", got, want)
+	}
+}
+
+func TestClientError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("s.token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := Client{Address: srv.URL, TokenFile: tokenFile}
+	if err := c.Write(context.Background(), "secret", "db", map[string]string{}); err == nil {
+		t.Error("Write() error = nil, want forbidden")
+	}
+	if err := (&Client{Address: srv.URL}).Delete(context.Background(), "secret", "db"); err == nil {
+		t.Error("Delete() without token error = nil, want error")
+	}
+}
@@ -50,9 +50,14 @@ func DeleteApplicationAgent(ctx context.Context, cli *kubernetes.Clientset, name
 	return nil
 }
 
+// PushApplicationAgent deploys the application agent in namespace, or upgrades it if it
+// runs an image other than the given one
 func PushApplicationAgent(ctx context.Context, cli *kubernetes.Clientset, namespace string, ceName string, image string) error {
-	if err := createAgentAppDeployment(ctx, cli, namespace, ceName, image); err != nil && !errors.IsAlreadyExists(err) {
-		return err
+	if err := createAgentAppDeployment(ctx, cli, namespace, ceName, image); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		return upgradeAgent(ctx, cli, namespace, constants.ApplicationAgentDeploymentName, image)
 	}
 	return nil
 }
@@ -49,11 +49,15 @@ func DeleteServiceAgent(ctx context.Context, cli *kubernetes.Clientset, namespac
 	return nil
 }
 
+// PushServiceAgent deploys the service agent in namespace, or upgrades it if it
+// runs an image other than the given one
 func PushServiceAgent(ctx context.Context, cli *kubernetes.Clientset, namespace string, ceName string, image string) error {
-	if err := createAgentSvcDeployment(ctx, cli, namespace, ceName, image); err != nil && !errors.IsAlreadyExists(err) {
-		return err
+	if err := createAgentSvcDeployment(ctx, cli, namespace, ceName, image); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		return upgradeAgent(ctx, cli, namespace, constants.ServiceAgentDeploymentName, image)
 	}
-
 	return nil
 }
 
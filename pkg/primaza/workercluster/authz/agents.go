@@ -29,12 +29,18 @@ func GetAgentAppRequiredPermissions() []authz.ResourcePermissions {
 			Resource: "deployments",
 		},
 		{
-			Verbs:    []string{"delete"},
+			Verbs:    []string{"get", "update", "delete"},
 			Version:  "",
 			Group:    "apps",
 			Resource: "deployments",
 			Name:     "primaza-app-agent",
 		},
+		{
+			Verbs:    []string{"create"},
+			Version:  "",
+			Group:    "",
+			Resource: "events",
+		},
 	}
 }
 
@@ -47,7 +53,7 @@ func GetAgentSvcRequiredPermissions() []authz.ResourcePermissions {
 			Resource: "deployments",
 		},
 		{
-			Verbs:    []string{"delete"},
+			Verbs:    []string{"get", "update", "delete"},
 			Version:  "",
 			Group:    "apps",
 			Resource: "deployments",
@@ -55,3 +61,21 @@ func GetAgentSvcRequiredPermissions() []authz.ResourcePermissions {
 		},
 	}
 }
+
+// GetAgentSvcReporterPermissions returns the permissions the service agent
+// requires in Primaza's namespace of the control plane cluster to report the
+// RegisteredServices it discovers
+func GetAgentSvcReporterPermissions() []authz.ResourcePermissions {
+	return []authz.ResourcePermissions{
+		{
+			Verbs:    []string{"get", "create", "update", "patch", "delete"},
+			Group:    "primaza.io",
+			Resource: "registeredservices",
+		},
+		{
+			Verbs:    []string{"get", "create", "update", "patch", "delete"},
+			Group:    "",
+			Resource: "secrets",
+		},
+	}
+}
@@ -18,77 +18,135 @@ package workercluster
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 
 	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/authz"
+	"github.com/primaza/primaza/pkg/primaza/clustercontext"
+	primazaerrors "github.com/primaza/primaza/pkg/primaza/errors"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type ConnectionStatusReason string
 
 const (
-	ConnectionSuccessful ConnectionStatusReason = "ConnectionSuccessful"
-	ConnectionError      ConnectionStatusReason = "ConnectionError"
-	ClientCreationError  ConnectionStatusReason = "ClientCreationError"
+	ConnectionSuccessful   ConnectionStatusReason = "ConnectionSuccessful"
+	ConnectionError        ConnectionStatusReason = "ConnectionError"
+	ConnectionUnauthorized ConnectionStatusReason = "ConnectionUnauthorized"
+	TLSVerificationFailed  ConnectionStatusReason = "TLSVerificationFailed"
+	ClientCreationError    ConnectionStatusReason = "ClientCreationError"
+	PermissionsMissing     ConnectionStatusReason = "PermissionsMissing"
 )
 
 type ConnectionStatus struct {
 	State   primazaiov1alpha1.ClusterEnvironmentState
 	Reason  ConnectionStatusReason
 	Message string
+	// Err is the classified error that prevented the connection, if any
+	Err error
+	// Permissions reports the outcome of the check of the required
+	// permissions, if any
+	Permissions authz.NamespacedPermissionsReport
 }
 
-func TestConnection(ctx context.Context, cfg *rest.Config) ConnectionStatus {
+// TestConnection checks that the cluster is reachable and, if any, that the
+// given permissions are granted in namespace.  A reachable cluster that does
+// not grant all the permissions is reported Partial, with the permissions
+// missing in the message.
+func TestConnection(ctx context.Context, cfg *rest.Config, namespace string, permissions ...authz.ResourcePermissions) ConnectionStatus {
 	c, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		return ConnectionStatus{
 			State:   primazaiov1alpha1.ClusterEnvironmentStateOffline,
 			Reason:  ClientCreationError,
 			Message: fmt.Sprintf("error creating the client: %s", err),
+			Err:     primazaerrors.Unreachable("create client", err),
 		}
 	}
 
 	v, err := c.ServerVersion()
 	if err != nil {
+		cerr := primazaerrors.Classify("connect to cluster", err)
+		reason := ConnectionError
+		if errors.Is(cerr, primazaerrors.ErrUnauthorized) {
+			reason = ConnectionUnauthorized
+		} else if isTLSVerificationError(err) {
+			reason = TLSVerificationFailed
+			cerr = primazaerrors.Unreachable("verify cluster certificate", err)
+		} else if !errors.Is(cerr, primazaerrors.ErrUnreachable) {
+			// failing to get the server version means the cluster is not
+			// reachable, whatever the cause
+			cerr = primazaerrors.Unreachable("connect to cluster", err)
+		}
 		return ConnectionStatus{
 			State:   primazaiov1alpha1.ClusterEnvironmentStateOffline,
-			Reason:  ConnectionError,
+			Reason:  reason,
 			Message: fmt.Sprintf("error connecting to target cluster: %s", err),
+			Err:     cerr,
+		}
+	}
+
+	if len(permissions) == 0 {
+		return ConnectionStatus{
+			State:   primazaiov1alpha1.ClusterEnvironmentStateOnline,
+			Reason:  ConnectionSuccessful,
+			Message: fmt.Sprintf("successfully connected to target cluster: kubernetes version found %s", v),
+		}
+	}
+	return testPermissions(ctx, c, namespace, permissions, v.String())
+}
+
+func testPermissions(ctx context.Context, c kubernetes.Interface, namespace string, permissions []authz.ResourcePermissions, version string) ConnectionStatus {
+	r := authz.CheckResourcePermissions(ctx, c, namespace, permissions)
+	if !r.AllSatisfied() {
+		return ConnectionStatus{
+			State:       primazaiov1alpha1.ClusterEnvironmentStatePartial,
+			Reason:      PermissionsMissing,
+			Message:     fmt.Sprintf("connected to target cluster, but permissions are missing in namespace %s: %s", namespace, r),
+			Err:         primazaerrors.Unauthorized("check permissions", fmt.Errorf("permissions missing in namespace %s: %s", namespace, r)),
+			Permissions: r,
 		}
 	}
 
 	return ConnectionStatus{
-		State:   primazaiov1alpha1.ClusterEnvironmentStateOnline,
-		Reason:  ConnectionSuccessful,
-		Message: fmt.Sprintf("successfully connected to target cluster: kubernetes version found %s", v),
+		State:       primazaiov1alpha1.ClusterEnvironmentStateOnline,
+		Reason:      ConnectionSuccessful,
+		Message:     fmt.Sprintf("successfully connected to target cluster: kubernetes version found %s, required permissions granted in namespace %s", version, namespace),
+		Permissions: r,
 	}
 }
 
+// isTLSVerificationError tells whether err is caused by the cluster
+// presenting an untrusted certificate
+func isTLSVerificationError(err error) bool {
+	var verr *tls.CertificateVerificationError
+	return errors.As(err, &verr) || errors.Is(err, clustercontext.ErrPinMismatch)
+}
+
 func GetPrimazaKubeconfig(ctx context.Context, namespace string, cli client.Client, secretName string) (*rest.Config, string, error) {
-	// TODO(sadlerap): can we use the functionality in GetClusterRESTConfig
-	// from pkg/primaza/clustercontext to do de-duplicate this?
 	s := v1.Secret{}
 	k := client.ObjectKey{Namespace: namespace, Name: secretName}
 	if err := cli.Get(ctx, k, &s); err != nil {
 		return nil, "", err
 	}
-	if _, found := s.Data["kubeconfig"]; !found {
-		return nil, "", fmt.Errorf("Field \"kubeconfig\" field in secret %s:%s does not exist", s.Name, s.Namespace)
-	}
 
 	if _, found := s.Data["namespace"]; !found {
 		return nil, "", fmt.Errorf("Field \"namespace\" field in secret %s:%s does not exist", s.Name, s.Namespace)
 	}
 
-	restConfig, err := clientcmd.RESTConfigFromKubeConfig(s.Data["kubeconfig"])
+	restConfig, err := clustercontext.RESTConfigFromSecret(&s)
 	if err != nil {
 		return nil, "", err
 	}
+	if err := clustercontext.WithFailover(restConfig, clustercontext.SecretFailoverServers(&s)); err != nil {
+		return nil, "", err
+	}
 	return restConfig, string(s.Data["namespace"]), nil
 }
 
@@ -101,7 +159,7 @@ func (c ConnectionStatus) Condition() metav1.Condition {
 	}()
 
 	m := metav1.Condition{
-		Type:    "Online",
+		Type:    primazaiov1alpha1.ClusterEnvironmentConditionOnline,
 		Reason:  string(c.Reason),
 		Message: c.Message,
 		Status:  status,
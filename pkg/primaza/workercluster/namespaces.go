@@ -0,0 +1,255 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workercluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/primaza/primaza/pkg/primaza/constants"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// AgentKind is the kind of the agent running in a namespace of a worker
+// cluster
+type AgentKind string
+
+const (
+	ApplicationAgentKind AgentKind = "app"
+	ServiceAgentKind     AgentKind = "svc"
+)
+
+// leaderElectionRules are the rules of the agents' leader election Role
+var leaderElectionRules = []rbacv1.PolicyRule{
+	{
+		APIGroups: []string{"coordination.k8s.io"},
+		Resources: []string{"leases"},
+		Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"configmaps"},
+		Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"events"},
+		Verbs:     []string{"create", "patch"},
+	},
+}
+
+// managerRules are the rules of the agents' manager Role, they mirror the
+// manifests in config/agents
+var managerRules = map[AgentKind][]rbacv1.PolicyRule{
+	ApplicationAgentKind: {
+		{
+			APIGroups: []string{"primaza.io"},
+			Resources: []string{"servicebindings", "serviceclaims", "servicecatalogs"},
+			Verbs:     []string{"get", "list", "watch", "update", "patch", "delete", "deletecollection"},
+		},
+		{
+			APIGroups: []string{"primaza.io"},
+			Resources: []string{"serviceclaims/status", "servicebindings/status"},
+			Verbs:     []string{"get", "list", "watch", "update"},
+		},
+		{
+			APIGroups: []string{"primaza.io"},
+			Resources: []string{"servicebindings/finalizers"},
+			Verbs:     []string{"update"},
+		},
+		{
+			APIGroups: []string{"apps"},
+			Resources: []string{"deployments", "statefulsets", "daemonsets"},
+			Verbs:     []string{"get", "list", "watch", "update", "patch"},
+		},
+		{
+			APIGroups: []string{"batch"},
+			Resources: []string{"jobs", "cronjobs"},
+			Verbs:     []string{"get", "list", "watch", "update", "patch"},
+		},
+		{
+			APIGroups: []string{"serving.knative.dev"},
+			Resources: []string{"services"},
+			Verbs:     []string{"get", "list", "watch", "update", "patch"},
+		},
+		{
+			APIGroups:     []string{"apps"},
+			Resources:     []string{"deployments", "deployments/finalizers"},
+			Verbs:         []string{"update"},
+			ResourceNames: []string{constants.ApplicationAgentDeploymentName},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"secrets"},
+			Verbs:     []string{"list", "get", "watch", "update"},
+		},
+		{
+			APIGroups:     []string{""},
+			Resources:     []string{"configmaps"},
+			Verbs:         []string{"get"},
+			ResourceNames: []string{"primaza-diagnostics"},
+		},
+	},
+	ServiceAgentKind: {
+		{
+			APIGroups: []string{"primaza.io"},
+			Resources: []string{"serviceclasses"},
+			Verbs:     []string{"create", "delete", "deletecollection", "get", "list", "patch", "update", "watch"},
+		},
+		{
+			APIGroups: []string{"primaza.io"},
+			Resources: []string{"serviceclasses/status"},
+			Verbs:     []string{"get", "patch", "update"},
+		},
+		{
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			Verbs:         []string{"get"},
+			ResourceNames: []string{constants.ServiceAgentKubeconfigSecretName},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"configmaps", "secrets"},
+			Verbs:     []string{"create"},
+		},
+		{
+			APIGroups: []string{"rbac.authorization.k8s.io"},
+			Resources: []string{"roles", "rolebindings"},
+			Verbs:     []string{"create", "delete", "get", "update"},
+		},
+		{
+			APIGroups: []string{"rbac.authorization.k8s.io"},
+			Resources: []string{"roles"},
+			Verbs:     []string{"bind", "escalate"},
+		},
+		{
+			APIGroups: []string{"apps"},
+			Resources: []string{"deployments"},
+			Verbs:     []string{"get", "list", "watch"},
+		},
+		{
+			APIGroups:     []string{"apps"},
+			Resources:     []string{"deployments", "deployments/finalizers"},
+			Verbs:         []string{"update"},
+			ResourceNames: []string{constants.ServiceAgentDeploymentName},
+		},
+		{
+			APIGroups:     []string{""},
+			Resources:     []string{"configmaps"},
+			Verbs:         []string{"get"},
+			ResourceNames: []string{"primaza-diagnostics"},
+		},
+	},
+}
+
+// SynchronizeNamespace ensures that namespace exists in the worker cluster,
+// that it is labeled as a namespace of the given kind of the cluster
+// environment ceName, and that it holds the ServiceAccount, Roles and
+// RoleBindings required by the agent of the given kind.  Existing resources
+// are updated if they drifted.
+func SynchronizeNamespace(ctx context.Context, cli client.Client, ceName string, namespace string, kind AgentKind) error {
+	if err := synchronizeNamespaceLabels(ctx, cli, ceName, namespace, kind); err != nil {
+		return err
+	}
+
+	labels := map[string]string{
+		"app.kubernetes.io/part-of":              "primaza",
+		constants.PrimazaClusterEnvironmentLabel: ceName,
+	}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: AgentServiceAccountName(kind), Namespace: namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, cli, sa, func() error {
+		sa.Labels = mergeLabels(sa.Labels, labels)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error synchronizing service account %s: %w", sa.Name, err)
+	}
+
+	roles := map[string][]rbacv1.PolicyRule{
+		fmt.Sprintf("primaza:%s:manager", kind):         managerRules[kind],
+		fmt.Sprintf("primaza:%s:leader-election", kind): leaderElectionRules,
+	}
+	for name, rules := range roles {
+		if err := synchronizeRole(ctx, cli, namespace, name, rules, sa.Name, labels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AgentServiceAccountName returns the name of the ServiceAccount the agent
+// of the given kind runs as
+func AgentServiceAccountName(kind AgentKind) string {
+	return fmt.Sprintf("primaza-%s-agent", kind)
+}
+
+func synchronizeNamespaceLabels(ctx context.Context, cli client.Client, ceName string, namespace string, kind AgentKind) error {
+	typeLabel := constants.PrimazaApplicationNamespaceLabel
+	if kind == ServiceAgentKind {
+		typeLabel = constants.PrimazaServiceNamespaceLabel
+	}
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, cli, ns, func() error {
+		ns.Labels = mergeLabels(ns.Labels, map[string]string{
+			constants.PrimazaClusterEnvironmentLabel: ceName,
+			typeLabel:                                "true",
+		})
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error synchronizing namespace: %w", err)
+	}
+	return nil
+}
+
+func synchronizeRole(ctx context.Context, cli client.Client, namespace string, name string, rules []rbacv1.PolicyRule, serviceAccount string, labels map[string]string) error {
+	r := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, cli, r, func() error {
+		r.Labels = mergeLabels(r.Labels, labels)
+		r.Rules = rules
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error synchronizing role %s: %w", name, err)
+	}
+
+	rb := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, cli, rb, func() error {
+		rb.Labels = mergeLabels(rb.Labels, labels)
+		// the role reference is immutable, it is only set on creation
+		if rb.RoleRef.Name == "" {
+			rb.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name}
+		}
+		rb.Subjects = []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: serviceAccount, Namespace: namespace}}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error synchronizing role binding %s: %w", name, err)
+	}
+	return nil
+}
+
+func mergeLabels(labels map[string]string, required map[string]string) map[string]string {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range required {
+		labels[k] = v
+	}
+	return labels
+}
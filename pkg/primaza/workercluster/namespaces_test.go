@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workercluster
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	"github.com/primaza/primaza/pkg/primaza/constants"
+)
+
+func TestSynchronizeNamespace(t *testing.T) {
+	ctx := context.Background()
+	existing := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "services", Labels: map[string]string{"team": "db"}},
+	}
+	cli := fake.NewClientBuilder().WithObjects(existing).Build()
+
+	for _, tc := range []struct {
+		namespace string
+		kind      AgentKind
+		label     string
+	}{
+		{"applications", ApplicationAgentKind, constants.PrimazaApplicationNamespaceLabel},
+		{"services", ServiceAgentKind, constants.PrimazaServiceNamespaceLabel},
+	} {
+		// synchronizing twice must be idempotent
+		for i := 0; i < 2; i++ {
+			if err := SynchronizeNamespace(ctx, cli, "worker", tc.namespace, tc.kind); err != nil {
+				t.Fatalf("%s: unexpected error: %v", tc.namespace, err)
+			}
+		}
+
+		ns := &corev1.Namespace{}
+		if err := cli.Get(ctx, types.NamespacedName{Name: tc.namespace}, ns); err != nil {
+			t.Fatalf("%s: namespace not found: %v", tc.namespace, err)
+		}
+		if ns.Labels[tc.label] != "true" || ns.Labels[constants.PrimazaClusterEnvironmentLabel] != "worker" {
+			t.Errorf("%s: unexpected labels %v", tc.namespace, ns.Labels)
+		}
+
+		sa := &corev1.ServiceAccount{}
+		if err := cli.Get(ctx, types.NamespacedName{Namespace: tc.namespace, Name: AgentServiceAccountName(tc.kind)}, sa); err != nil {
+			t.Errorf("%s: service account not found: %v", tc.namespace, err)
+		}
+
+		rb := &rbacv1.RoleBinding{}
+		name := "primaza:" + string(tc.kind) + ":manager"
+		if err := cli.Get(ctx, types.NamespacedName{Namespace: tc.namespace, Name: name}, rb); err != nil {
+			t.Fatalf("%s: role binding not found: %v", tc.namespace, err)
+		}
+		if rb.RoleRef.Name != name || len(rb.Subjects) != 1 || rb.Subjects[0].Name != sa.Name {
+			t.Errorf("%s: unexpected role binding %v", tc.namespace, rb)
+		}
+	}
+
+	ns := &corev1.Namespace{}
+	if err := cli.Get(ctx, types.NamespacedName{Name: "services"}, ns); err != nil {
+		t.Fatal(err)
+	}
+	if ns.Labels["team"] != "db" {
+		t.Errorf("existing labels were not kept: %v", ns.Labels)
+	}
+
+	// drifted roles are restored
+	r := &rbacv1.Role{}
+	key := types.NamespacedName{Namespace: "applications", Name: "primaza:app:manager"}
+	if err := cli.Get(ctx, key, r); err != nil {
+		t.Fatal(err)
+	}
+	r.Rules = nil
+	if err := cli.Update(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+	if err := SynchronizeNamespace(ctx, cli, "worker", "applications", ApplicationAgentKind); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.Get(ctx, key, r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Rules) != len(managerRules[ApplicationAgentKind]) {
+		t.Errorf("role was not restored: %v", r.Rules)
+	}
+}
+
+// grants expands the rules into the set of the permissions they grant
+func grants(rules []rbacv1.PolicyRule) map[string]bool {
+	g := map[string]bool{}
+	for _, r := range rules {
+		names := r.ResourceNames
+		if len(names) == 0 {
+			names = []string{"*"}
+		}
+		for _, group := range r.APIGroups {
+			for _, resource := range r.Resources {
+				for _, verb := range r.Verbs {
+					for _, name := range names {
+						g[strings.Join([]string{group, resource, verb, name}, "/")] = true
+					}
+				}
+			}
+		}
+	}
+	return g
+}
+
+func TestManagerRulesMirrorManifests(t *testing.T) {
+	for _, kind := range []AgentKind{ApplicationAgentKind, ServiceAgentKind} {
+		path := filepath.Join("..", "..", "..", "config", "agents", string(kind), "rbac", "manager_role.yaml")
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		role := rbacv1.Role{}
+		if err := yaml.Unmarshal(b, &role); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+
+		want, got := grants(role.Rules), grants(managerRules[kind])
+		for g := range want {
+			if !got[g] {
+				t.Errorf("%s: %s is granted by %s but not by managerRules", kind, g, path)
+			}
+		}
+		for g := range got {
+			if !want[g] {
+				t.Errorf("%s: %s is granted by managerRules but not by %s", kind, g, path)
+			}
+		}
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workercluster
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+	"github.com/primaza/primaza/pkg/primaza/clustercontext"
+)
+
+// DefaultPoolIdleTimeout is the default time after which unused connections
+// are evicted from a Pool
+const DefaultPoolIdleTimeout = 10 * time.Minute
+
+// Pool caches the REST configs and clients used to connect to the clusters
+// of ClusterEnvironments, so that controllers reuse their connections.
+//
+// A cached connection is used as long as the ClusterEnvironment's spec and
+// the secret holding its connection information do not change.  Connections
+// are evicted when the ClusterEnvironment is Offline, when they are reported
+// unhealthy with Evict, or when they have not been used for IdleTimeout.
+//
+// A nil Pool caches nothing.  The returned configs and clients are shared, and
+// must not be modified.
+type Pool struct {
+	// IdleTimeout is the time after which unused connections are evicted,
+	// it defaults to DefaultPoolIdleTimeout
+	IdleTimeout time.Duration
+
+	mu      sync.Mutex
+	entries map[types.NamespacedName]*poolEntry
+	now     func() time.Time
+}
+
+type poolEntry struct {
+	// version identifies the connection information the entry was built
+	// from
+	version  string
+	config   *rest.Config
+	client   client.Client
+	lastUsed time.Time
+}
+
+// NewPool returns an empty Pool
+func NewPool(idleTimeout time.Duration) *Pool {
+	return &Pool{IdleTimeout: idleTimeout}
+}
+
+// Config returns the REST config to connect to the cluster of the given
+// ClusterEnvironment
+func (p *Pool) Config(ctx context.Context, cli client.Client, ce primazaiov1alpha1.ClusterEnvironment) (*rest.Config, error) {
+	e, err := p.entry(ctx, cli, ce)
+	if err != nil {
+		return nil, err
+	}
+	return e.config, nil
+}
+
+// Client returns a client for the cluster of the given ClusterEnvironment,
+// built with the given scheme and mapper the first time
+func (p *Pool) Client(ctx context.Context, cli client.Client, ce primazaiov1alpha1.ClusterEnvironment, scheme *runtime.Scheme, mapper meta.RESTMapper) (client.Client, error) {
+	e, err := p.entry(ctx, cli, ce)
+	if err != nil {
+		return nil, err
+	}
+
+	if p != nil {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+	}
+	if e.client == nil {
+		c, err := client.New(e.config, client.Options{Scheme: scheme, Mapper: mapper})
+		if err != nil {
+			return nil, err
+		}
+		e.client = c
+	}
+	return e.client, nil
+}
+
+// Evict removes the connection to the cluster of the given
+// ClusterEnvironment, e.g. when it is found unhealthy
+func (p *Pool) Evict(key types.NamespacedName) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.entries, key)
+}
+
+// Prune evicts the connections that have not been used for IdleTimeout, and
+// returns the number of connections evicted
+func (p *Pool) Prune() int {
+	if p == nil {
+		return 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for k, e := range p.entries {
+		if p.clock().Sub(e.lastUsed) >= p.idleTimeout() {
+			delete(p.entries, k)
+			n++
+		}
+	}
+	return n
+}
+
+// Start prunes the idle connections periodically, until ctx is done.  It
+// implements manager.Runnable.
+func (p *Pool) Start(ctx context.Context) error {
+	l := log.FromContext(ctx).WithName("workercluster-pool")
+	ticker := time.NewTicker(p.idleTimeout())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if n := p.Prune(); n > 0 {
+				l.Info("evicted idle cluster connections", "count", n)
+			}
+		}
+	}
+}
+
+// NeedLeaderElection returns false, as each replica prunes its own
+// connections.  It implements manager.LeaderElectionRunnable.
+func (p *Pool) NeedLeaderElection() bool {
+	return false
+}
+
+func (p *Pool) entry(ctx context.Context, cli client.Client, ce primazaiov1alpha1.ClusterEnvironment) (*poolEntry, error) {
+	s, err := clustercontext.GetClusterEnvironmentSecret(ctx, cli, ce)
+	if err != nil {
+		return nil, err
+	}
+	newEntry := func(version string) (*poolEntry, error) {
+		cfg, err := clustercontext.ClusterEnvironmentRESTConfigFromSecret(s, ce)
+		if err != nil {
+			return nil, err
+		}
+		return &poolEntry{version: version, config: cfg}, nil
+	}
+	if p == nil {
+		return newEntry("")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := types.NamespacedName{Namespace: ce.Namespace, Name: ce.Name}
+	if ce.Status.State == primazaiov1alpha1.ClusterEnvironmentStateOffline {
+		delete(p.entries, key)
+		return newEntry("")
+	}
+
+	version := fmt.Sprintf("%s/%d", s.ResourceVersion, ce.Generation)
+	if e, ok := p.entries[key]; ok && e.version == version {
+		e.lastUsed = p.clock()
+		return e, nil
+	}
+
+	e, err := newEntry(version)
+	if err != nil {
+		delete(p.entries, key)
+		return nil, err
+	}
+	e.lastUsed = p.clock()
+	if p.entries == nil {
+		p.entries = map[types.NamespacedName]*poolEntry{}
+	}
+	p.entries[key] = e
+	return e, nil
+}
+
+func (p *Pool) idleTimeout() time.Duration {
+	if p.IdleTimeout > 0 {
+		return p.IdleTimeout
+	}
+	return DefaultPoolIdleTimeout
+}
+
+func (p *Pool) clock() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
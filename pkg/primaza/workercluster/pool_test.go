@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workercluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	primazaiov1alpha1 "github.com/primaza/primaza/api/v1alpha1"
+)
+
+const testKubeconfig = `
+apiVersion: v1
+kind: Config
+clusters:
+- name: worker
+  cluster:
+    server: https://worker:6443
+contexts:
+- name: worker
+  context:
+    cluster: worker
+    user: worker
+current-context: worker
+users:
+- name: worker
+  user:
+    token: token
+`
+
+func TestPool(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "primaza-system", Name: "worker-kubeconfig"},
+		Data:       map[string][]byte{"kubeconfig": []byte(testKubeconfig)},
+	}
+	cli := fake.NewClientBuilder().WithObjects(secret).Build()
+	ce := primazaiov1alpha1.ClusterEnvironment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "primaza-system", Name: "worker", Generation: 1},
+		Spec:       primazaiov1alpha1.ClusterEnvironmentSpec{ClusterContextSecret: "worker-kubeconfig"},
+		Status:     primazaiov1alpha1.ClusterEnvironmentStatus{State: primazaiov1alpha1.ClusterEnvironmentStateOnline},
+	}
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	pool := &Pool{IdleTimeout: time.Minute, now: func() time.Time { return now }}
+
+	config := func(ce primazaiov1alpha1.ClusterEnvironment) *rest.Config {
+		t.Helper()
+		cfg, err := pool.Config(ctx, cli, ce)
+		if err != nil {
+			t.Fatalf("Config() error = %v", err)
+		}
+		return cfg
+	}
+
+	first := config(ce)
+	if config(ce) != first {
+		t.Errorf("Config() did not reuse the pooled config")
+	}
+
+	ce.Generation = 2
+	second := config(ce)
+	if second == first {
+		t.Errorf("Config() reused the pooled config after the cluster environment changed")
+	}
+
+	secret.Data["context"] = []byte("worker")
+	if err := cli.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	third := config(ce)
+	if third == second {
+		t.Errorf("Config() reused the pooled config after the secret changed")
+	}
+
+	pool.Evict(client.ObjectKeyFromObject(&ce))
+	if config(ce) == third {
+		t.Errorf("Config() reused an evicted config")
+	}
+
+	offline := ce
+	offline.Status.State = primazaiov1alpha1.ClusterEnvironmentStateOffline
+	if config(offline) == config(offline) {
+		t.Errorf("Config() pooled the config of an offline cluster environment")
+	}
+
+	config(ce)
+	now = now.Add(30 * time.Second)
+	if n := pool.Prune(); n != 0 {
+		t.Errorf("Prune() = %d, want 0", n)
+	}
+	now = now.Add(time.Minute)
+	if n := pool.Prune(); n != 1 {
+		t.Errorf("Prune() = %d, want 1", n)
+	}
+	if _, ok := pool.entries[types.NamespacedName{Namespace: "primaza-system", Name: "worker"}]; ok {
+		t.Errorf("Prune() did not evict the idle connection")
+	}
+}
+
+func TestNilPool(t *testing.T) {
+	var pool *Pool
+	cli := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "primaza-system", Name: "worker-kubeconfig"},
+		Data:       map[string][]byte{"kubeconfig": []byte(testKubeconfig)},
+	}).Build()
+	ce := primazaiov1alpha1.ClusterEnvironment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "primaza-system", Name: "worker"},
+		Spec:       primazaiov1alpha1.ClusterEnvironmentSpec{ClusterContextSecret: "worker-kubeconfig"},
+	}
+
+	cfg, err := pool.Config(context.Background(), cli, ce)
+	if err != nil {
+		t.Fatalf("Config() error = %v", err)
+	}
+	if cfg.Host != "https://worker:6443" {
+		t.Errorf("Config().Host = %s, want https://worker:6443", cfg.Host)
+	}
+	pool.Evict(client.ObjectKeyFromObject(&ce))
+	if n := pool.Prune(); n != 0 {
+		t.Errorf("Prune() = %d, want 0", n)
+	}
+}
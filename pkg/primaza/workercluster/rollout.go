@@ -0,0 +1,146 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workercluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/primaza/primaza/pkg/version"
+)
+
+// agentContainerName is the name of the container running the agent in its
+// deployment
+const agentContainerName = "manager"
+
+// RolloutState is the state of the rollout of an agent's deployment
+type RolloutState string
+
+const (
+	// RolloutComplete means all the replicas run the latest revision and
+	// are available
+	RolloutComplete RolloutState = "Complete"
+	// RolloutProgressing means some replicas do not run the latest revision
+	// yet, or are not available
+	RolloutProgressing RolloutState = "Progressing"
+	// RolloutFailed means the deployment exceeded its progress deadline
+	RolloutFailed RolloutState = "Failed"
+)
+
+// PinAgentImage returns image with its tag, or digest, replaced by version.
+// The image is returned unchanged if version is empty.
+func PinAgentImage(image string, version string) string {
+	if version == "" {
+		return image
+	}
+
+	name, _, _ := strings.Cut(image, "@")
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	return name + ":" + version
+}
+
+// AgentStatus is the status of an agent's deployment
+type AgentStatus struct {
+	// Rollout is the state of the deployment's rollout
+	Rollout RolloutState
+	// Message describes the state of the rollout
+	Message string
+	// Version is the version the agent reports, if any
+	Version string
+}
+
+// GetAgentStatus returns the status of the agent deployment named name in
+// namespace
+func GetAgentStatus(ctx context.Context, cli kubernetes.Interface, namespace string, name string) (AgentStatus, error) {
+	dep, err := cli.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return AgentStatus{}, err
+	}
+
+	s, m := DeploymentRollout(dep)
+	return AgentStatus{
+		Rollout: s,
+		Message: m,
+		Version: dep.GetAnnotations()[version.AgentVersionAnnotation],
+	}, nil
+}
+
+// DeploymentRollout returns the state of the rollout of a deployment, and
+// a message describing it, the same way as `kubectl rollout status` does
+func DeploymentRollout(dep *appsv1.Deployment) (RolloutState, string) {
+	if dep.Generation > dep.Status.ObservedGeneration {
+		return RolloutProgressing, "waiting for the deployment spec update to be observed"
+	}
+
+	for _, c := range dep.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			return RolloutFailed, fmt.Sprintf("deployment exceeded its progress deadline: %s", c.Message)
+		}
+	}
+
+	replicas := int32(1)
+	if dep.Spec.Replicas != nil {
+		replicas = *dep.Spec.Replicas
+	}
+	switch {
+	case dep.Status.UpdatedReplicas < replicas:
+		return RolloutProgressing, fmt.Sprintf("%d out of %d new replicas have been updated", dep.Status.UpdatedReplicas, replicas)
+	case dep.Status.Replicas > dep.Status.UpdatedReplicas:
+		return RolloutProgressing, fmt.Sprintf("%d old replicas are pending termination", dep.Status.Replicas-dep.Status.UpdatedReplicas)
+	case dep.Status.AvailableReplicas < dep.Status.UpdatedReplicas:
+		return RolloutProgressing, fmt.Sprintf("%d of %d updated replicas are available", dep.Status.AvailableReplicas, dep.Status.UpdatedReplicas)
+	default:
+		return RolloutComplete, "successfully rolled out"
+	}
+}
+
+// upgradeAgent updates the image of the agent deployment named name in
+// namespace, if it differs from the given one
+func upgradeAgent(ctx context.Context, cli *kubernetes.Clientset, namespace string, name string, image string) error {
+	dep, err := cli.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting deployment: %w", err)
+	}
+
+	if !setContainerImage(dep.Spec.Template.Spec.Containers, agentContainerName, image) {
+		return nil
+	}
+	if _, err := cli.AppsV1().Deployments(namespace).Update(ctx, dep, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error upgrading deployment: %w", err)
+	}
+	return nil
+}
+
+// setContainerImage sets the image of the named container, and returns
+// whether it changed
+func setContainerImage(cc []corev1.Container, name string, image string) bool {
+	for i := range cc {
+		if cc[i].Name == name && cc[i].Image != image {
+			cc[i].Image = image
+			return true
+		}
+	}
+	return false
+}
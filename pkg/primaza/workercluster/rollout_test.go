@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workercluster
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPinAgentImage(t *testing.T) {
+	for _, tc := range []struct {
+		image    string
+		version  string
+		expected string
+	}{
+		{"ghcr.io/primaza/primaza-agentapp:latest", "", "ghcr.io/primaza/primaza-agentapp:latest"},
+		{"ghcr.io/primaza/primaza-agentapp:latest", "v0.2.0", "ghcr.io/primaza/primaza-agentapp:v0.2.0"},
+		{"ghcr.io/primaza/primaza-agentapp", "v0.2.0", "ghcr.io/primaza/primaza-agentapp:v0.2.0"},
+		{"localhost:5000/agentapp", "v0.2.0", "localhost:5000/agentapp:v0.2.0"},
+		{"localhost:5000/agentapp:dev@sha256:abcd", "v0.2.0", "localhost:5000/agentapp:v0.2.0"},
+	} {
+		if actual := PinAgentImage(tc.image, tc.version); actual != tc.expected {
+			t.Errorf("PinAgentImage(%q, %q) = %q, expected %q", tc.image, tc.version, actual, tc.expected)
+		}
+	}
+}
+
+func TestDeploymentRollout(t *testing.T) {
+	two := int32(2)
+	for _, tc := range []struct {
+		name     string
+		status   appsv1.DeploymentStatus
+		expected RolloutState
+	}{
+		{
+			name:     "not observed",
+			status:   appsv1.DeploymentStatus{ObservedGeneration: 1},
+			expected: RolloutProgressing,
+		},
+		{
+			name:     "updating",
+			status:   appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 2},
+			expected: RolloutProgressing,
+		},
+		{
+			name:     "terminating old replicas",
+			status:   appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 2},
+			expected: RolloutProgressing,
+		},
+		{
+			name:     "not available",
+			status:   appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1},
+			expected: RolloutProgressing,
+		},
+		{
+			name: "deadline exceeded",
+			status: appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Conditions: []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded"},
+				},
+			},
+			expected: RolloutFailed,
+		},
+		{
+			name:     "complete",
+			status:   appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+			expected: RolloutComplete,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dep := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &two}, Status: tc.status}
+			dep.Generation = 2
+			if actual, m := DeploymentRollout(dep); actual != tc.expected {
+				t.Errorf("expected %s, got %s (%s)", tc.expected, actual, m)
+			}
+		})
+	}
+}
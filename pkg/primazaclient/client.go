@@ -0,0 +1,175 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package primazaclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+// DefaultPollInterval is the interval claims are polled with while waiting
+// for them to be resolved
+const DefaultPollInterval = 2 * time.Second
+
+// ErrClaimNotResolved is returned when reading the binding secret of a claim
+// that is not resolved yet
+var ErrClaimNotResolved = errors.New("service claim is not resolved")
+
+// InvalidClaimError is returned when waiting for a claim that Primaza
+// rejected
+type InvalidClaimError struct {
+	Name    string
+	Message string
+}
+
+func (e *InvalidClaimError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("service claim %s is invalid", e.Name)
+	}
+	return fmt.Sprintf("service claim %s is invalid: %s", e.Name, e.Message)
+}
+
+// Client reads and writes Primaza resources.  The embedded controller-runtime
+// client can be used for any operation the helpers do not cover.
+type Client struct {
+	client.Client
+
+	// PollInterval is the interval claims are polled with while waiting for
+	// them to be resolved, DefaultPollInterval if not set
+	PollInterval time.Duration
+}
+
+// NewScheme returns a scheme with the Primaza and Kubernetes core types
+func NewScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+// New returns a Client for the cluster the given configuration connects to
+func New(config *rest.Config) (*Client, error) {
+	cli, err := client.New(config, client.Options{Scheme: NewScheme()})
+	if err != nil {
+		return nil, err
+	}
+	return NewForClient(cli), nil
+}
+
+// NewForClient returns a Client using the given controller-runtime client,
+// whose scheme must include the Primaza types
+func NewForClient(cli client.Client) *Client {
+	return &Client{Client: cli}
+}
+
+// Catalog returns the services of the catalog of the given environment.  The
+// catalog is found in the Primaza namespace, or in the namespaces of the
+// application agents.
+func (c *Client) Catalog(ctx context.Context, namespace string, environment string) ([]v1alpha1.ServiceCatalogService, error) {
+	var catalog v1alpha1.ServiceCatalog
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: environment}, &catalog); err != nil {
+		return nil, err
+	}
+	return catalog.Spec.Services, nil
+}
+
+// Claim creates the given claim and waits for it to be resolved, until ctx
+// is done.  It returns the resolved claim.
+func (c *Client) Claim(ctx context.Context, sclaim *v1alpha1.ServiceClaim) (*v1alpha1.ServiceClaim, error) {
+	if err := c.Create(ctx, sclaim); err != nil {
+		return nil, err
+	}
+	return c.WaitForClaim(ctx, client.ObjectKeyFromObject(sclaim))
+}
+
+// WaitForClaim waits for the given claim to be resolved, until ctx is done.
+// It returns the resolved claim, or an InvalidClaimError if the claim has
+// been rejected.
+func (c *Client) WaitForClaim(ctx context.Context, key client.ObjectKey) (*v1alpha1.ServiceClaim, error) {
+	interval := c.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	var sclaim v1alpha1.ServiceClaim
+	err := wait.PollImmediateUntilWithContext(ctx, interval, func(ctx context.Context) (bool, error) {
+		if err := c.Get(ctx, key, &sclaim); err != nil {
+			return false, err
+		}
+
+		switch sclaim.Status.State {
+		case v1alpha1.ServiceClaimStateResolved:
+			return true, nil
+		case v1alpha1.ServiceClaimStateInvalid:
+			err := &InvalidClaimError{Name: sclaim.Name}
+			if ready := meta.FindStatusCondition(sclaim.Status.Conditions, v1alpha1.ServiceClaimConditionReady); ready != nil {
+				err.Message = ready.Message
+			}
+			return false, err
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sclaim, nil
+}
+
+// BindingSecret returns the binding secret of the given resolved claim, in the
+// claim's namespace
+func (c *Client) BindingSecret(ctx context.Context, sclaim *v1alpha1.ServiceClaim) (*corev1.Secret, error) {
+	if sclaim.Status.State != v1alpha1.ServiceClaimStateResolved {
+		return nil, ErrClaimNotResolved
+	}
+
+	var secret corev1.Secret
+	if err := c.Get(ctx, client.ObjectKey{Namespace: sclaim.Namespace, Name: sclaim.Name}, &secret); err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// BindingValues returns the values of the binding secret of the given
+// resolved claim, by service endpoint definition key
+func (c *Client) BindingValues(ctx context.Context, sclaim *v1alpha1.ServiceClaim) (map[string]string, error) {
+	secret, err := c.BindingSecret(ctx, sclaim)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(secret.Data)+len(secret.StringData))
+	for k, v := range secret.Data {
+		values[k] = string(v)
+	}
+	for k, v := range secret.StringData {
+		values[k] = v
+	}
+	return values, nil
+}
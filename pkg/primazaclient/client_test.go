@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package primazaclient
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/primaza/primaza/api/v1alpha1"
+)
+
+func TestCatalog(t *testing.T) {
+	services := []v1alpha1.ServiceCatalogService{
+		{
+			Name:                          "mydb",
+			ServiceClassIdentity:          []v1alpha1.ServiceClassIdentityItem{{Name: "type", Value: "psql"}},
+			ServiceEndpointDefinitionKeys: []string{"host", "password"},
+		},
+	}
+	cli := NewForClient(fake.NewClientBuilder().WithScheme(NewScheme()).WithObjects(&v1alpha1.ServiceCatalog{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "primaza-system", Name: "dev"},
+		Spec:       v1alpha1.ServiceCatalogSpec{Services: services},
+	}).Build())
+
+	got, err := cli.Catalog(context.Background(), "primaza-system", "dev")
+	if err != nil {
+		t.Fatalf("Catalog() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, services) {
+		t.Errorf("Catalog() = %v, want %v", got, services)
+	}
+}
+
+func TestWaitForClaim(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  v1alpha1.ServiceClaimStatus
+		wantErr bool
+		// wantInvalid is the error returned for claims rejected by Primaza
+		wantInvalid *InvalidClaimError
+	}{
+		{
+			name:   "resolved",
+			status: v1alpha1.ServiceClaimStatus{State: v1alpha1.ServiceClaimStateResolved, RegisteredService: "mydb"},
+		},
+		{
+			name: "invalid",
+			status: v1alpha1.ServiceClaimStatus{
+				State: v1alpha1.ServiceClaimStateInvalid,
+				Conditions: []metav1.Condition{
+					{Type: v1alpha1.ServiceClaimConditionReady, Status: metav1.ConditionFalse, Message: "spec is immutable"},
+				},
+			},
+			wantErr:     true,
+			wantInvalid: &InvalidClaimError{Name: "myclaim", Message: "spec is immutable"},
+		},
+		{
+			name:    "pending",
+			status:  v1alpha1.ServiceClaimStatus{State: v1alpha1.ServiceClaimStatePending},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sclaim := &v1alpha1.ServiceClaim{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "myclaim"},
+				Status:     tt.status,
+			}
+			cli := NewForClient(fake.NewClientBuilder().WithScheme(NewScheme()).WithObjects(sclaim).Build())
+			cli.PollInterval = 10 * time.Millisecond
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			got, err := cli.WaitForClaim(ctx, client.ObjectKeyFromObject(sclaim))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WaitForClaim() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var invalid *InvalidClaimError
+			if errors.As(err, &invalid) != (tt.wantInvalid != nil) || (invalid != nil && *invalid != *tt.wantInvalid) {
+				t.Errorf("WaitForClaim() error = %v, want %v", err, tt.wantInvalid)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Status.RegisteredService != "mydb" {
+				t.Errorf("WaitForClaim() registered service = %s, want mydb", got.Status.RegisteredService)
+			}
+		})
+	}
+}
+
+func TestBindingValues(t *testing.T) {
+	sclaim := &v1alpha1.ServiceClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "myclaim"},
+		Status:     v1alpha1.ServiceClaimStatus{State: v1alpha1.ServiceClaimStateResolved},
+	}
+	cli := NewForClient(fake.NewClientBuilder().WithScheme(NewScheme()).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "myclaim"},
+		Data:       map[string][]byte{"host": []byte("mydb.services"), "password": []byte("s3cr3t")},
+	}).Build())
+
+	got, err := cli.BindingValues(context.Background(), sclaim)
+	if err != nil {
+		t.Fatalf("BindingValues() error = %v", err)
+	}
+	want := map[string]string{"host": "mydb.services", "password": "s3cr3t"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BindingValues() = %v, want %v", got, want)
+	}
+
+	sclaim.Status.State = v1alpha1.ServiceClaimStatePending
+	if _, err := cli.BindingValues(context.Background(), sclaim); !errors.Is(err, ErrClaimNotResolved) {
+		t.Errorf("BindingValues() error = %v, want %v", err, ErrClaimNotResolved)
+	}
+}
@@ -0,0 +1,26 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package primazaclient provides a typed client for Go tools integrating with
+// Primaza: it lists the services of a catalog, claims services and waits for
+// the claims to be resolved, and reads the resulting binding secrets.
+//
+//	cli, err := primazaclient.New(config)
+//	...
+//	sclaim, err := cli.Claim(ctx, &v1alpha1.ServiceClaim{...})
+//	...
+//	values, err := cli.BindingValues(ctx, sclaim)
+package primazaclient
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Version is the version of the binary, set at build time with
+// `-ldflags "-X github.com/primaza/primaza/pkg/version.Version=v0.1.0"`
+var Version = "v0.0.0-dev"
+
+// AgentVersionAnnotation is the annotation agents report their version
+// with on their deployment
+const AgentVersionAnnotation = "primaza.io/agent-version"
+
+// MaxMinorSkew is the number of minor versions agents may lag behind the
+// control plane.  Agents newer than the control plane are not supported.
+const MaxMinorSkew = 1
+
+// ErrUnknownVersion is returned when a version is not a release version
+var ErrUnknownVersion = errors.New("unknown version")
+
+// Annotate sets the AgentVersionAnnotation of obj to Version, and returns
+// whether it changed
+func Annotate(obj metav1.Object) bool {
+	aa := obj.GetAnnotations()
+	if aa[AgentVersionAnnotation] == Version {
+		return false
+	}
+	if aa == nil {
+		aa = map[string]string{}
+	}
+	aa[AgentVersionAnnotation] = Version
+	obj.SetAnnotations(aa)
+	return true
+}
+
+// CheckSkew returns an error if the agent's version is outside of the skew
+// supported by the control plane's version, or ErrUnknownVersion if one of
+// them is not a release version
+func CheckSkew(agent string, controlPlane string) error {
+	amaj, amin, err := parse(agent)
+	if err != nil {
+		return err
+	}
+	cmaj, cmin, err := parse(controlPlane)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case amaj != cmaj:
+		return fmt.Errorf("major version %d differs from the control plane's %d", amaj, cmaj)
+	case amin > cmin:
+		return fmt.Errorf("version %s is newer than the control plane's %s", agent, controlPlane)
+	case cmin-amin > MaxMinorSkew:
+		return fmt.Errorf("version %s is more than %d minor versions older than the control plane's %s", agent, MaxMinorSkew, controlPlane)
+	default:
+		return nil
+	}
+}
+
+// parse returns the major and minor numbers of a semantic version, with or
+// without the v prefix.  Pre-release versions are not release versions.
+func parse(v string) (int, int, error) {
+	s := strings.TrimPrefix(v, "v")
+	s, _, _ = strings.Cut(s, "+")
+	if strings.Contains(s, "-") {
+		return 0, 0, fmt.Errorf("%w: %q", ErrUnknownVersion, v)
+	}
+
+	pp := strings.Split(s, ".")
+	if len(pp) != 3 {
+		return 0, 0, fmt.Errorf("%w: %q", ErrUnknownVersion, v)
+	}
+	nn := make([]int, len(pp))
+	for i, p := range pp {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("%w: %q", ErrUnknownVersion, v)
+		}
+		nn[i] = n
+	}
+	return nn[0], nn[1], nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The Primaza Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version
+
+import (
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCheckSkew(t *testing.T) {
+	for _, tc := range []struct {
+		agent        string
+		controlPlane string
+		skewed       bool
+		unknown      bool
+	}{
+		{agent: "v0.2.0", controlPlane: "v0.2.1"},
+		{agent: "0.1.3", controlPlane: "v0.2.0"},
+		{agent: "v0.1.0", controlPlane: "v0.3.0", skewed: true},
+		{agent: "v0.3.0", controlPlane: "v0.2.0", skewed: true},
+		{agent: "v1.2.0", controlPlane: "v0.2.0", skewed: true},
+		{agent: "v0.2.0+build.1", controlPlane: "v0.2.0"},
+		{agent: "v0.0.0-dev", controlPlane: "v0.2.0", unknown: true},
+		{agent: "v0.2.0", controlPlane: "latest", unknown: true},
+		{agent: "", controlPlane: "v0.2.0", unknown: true},
+	} {
+		err := CheckSkew(tc.agent, tc.controlPlane)
+		switch {
+		case tc.unknown && !errors.Is(err, ErrUnknownVersion):
+			t.Errorf("CheckSkew(%q, %q): expected unknown version, got %v", tc.agent, tc.controlPlane, err)
+		case !tc.unknown && tc.skewed != (err != nil):
+			t.Errorf("CheckSkew(%q, %q): expected skewed %v, got %v", tc.agent, tc.controlPlane, tc.skewed, err)
+		}
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	cm := &corev1.ConfigMap{}
+	if !Annotate(cm) {
+		t.Error("expected the annotation to be set")
+	}
+	if cm.Annotations[AgentVersionAnnotation] != Version {
+		t.Errorf("unexpected annotations %v", cm.Annotations)
+	}
+	if Annotate(cm) {
+		t.Error("expected the annotation to be unchanged")
+	}
+}
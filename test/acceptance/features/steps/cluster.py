@@ -282,7 +282,7 @@ class Cluster(object):
                 client.V1PolicyRule(
                     api_groups=["apps"],
                     resources=["deployments"],
-                    verbs=["delete", "get"],
+                    verbs=["delete", "get", "update"],
                     resource_names=[f"primaza-{nstype}-agent"]),
             ] + pmz_rules)
         rbacv1.create_namespaced_role(namespace, r)